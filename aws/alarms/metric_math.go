@@ -0,0 +1,42 @@
+package alarms
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// MetricMathID is the ID of the expression query, so it cannot be used by the expressions metrics
+const MetricMathID = "result"
+
+// MetricMath returns the latest value of the expression over the metrics between start and end,
+// nil if the metrics have no data
+func MetricMath(cwc aws.CWAPI, expression *string, metrics []*cloudwatch.MetricDataQuery, start time.Time, end time.Time) (*float64, error) {
+	queries := append([]*cloudwatch.MetricDataQuery{}, metrics...)
+	queries = append(queries, &cloudwatch.MetricDataQuery{
+		Id:         to.Strp(MetricMathID),
+		Expression: expression,
+		ReturnData: to.Boolp(true),
+	})
+
+	out, err := cwc.GetMetricData(&cloudwatch.GetMetricDataInput{
+		StartTime:         &start,
+		EndTime:           &end,
+		ScanBy:            to.Strp(cloudwatch.ScanByTimestampDescending),
+		MetricDataQueries: queries,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range out.MetricDataResults {
+		if to.Strs(result.Id) == MetricMathID && len(result.Values) > 0 {
+			return result.Values[0], nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,30 @@
+package alarms
+
+import (
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/coinbase/odin/aws"
+)
+
+// InAlarm returns the names of the alarms that are in the ALARM state
+func InAlarm(cwc aws.CWAPI, names []*string) ([]string, error) {
+	breached := []string{}
+	if len(names) == 0 {
+		return breached, nil
+	}
+
+	out, err := cwc.DescribeAlarms(&cloudwatch.DescribeAlarmsInput{
+		AlarmNames: names,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, alarm := range out.MetricAlarms {
+		if alarm.StateValue != nil && *alarm.StateValue == cloudwatch.StateValueAlarm {
+			breached = append(breached, *alarm.AlarmName)
+		}
+	}
+
+	return breached, nil
+}
@@ -0,0 +1,60 @@
+package alb
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// FindRule returns the listener rule with the ARN, or with the priority on the listener
+func FindRule(albc aws.ALBAPI, ruleArn *string, listenerArn *string, priority *string) (*elbv2.Rule, error) {
+	input := &elbv2.DescribeRulesInput{}
+	if ruleArn != nil {
+		input.RuleArns = []*string{ruleArn}
+	} else {
+		input.ListenerArn = listenerArn
+	}
+
+	out, err := albc.DescribeRules(input)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range out.Rules {
+		if ruleArn != nil && to.Strs(rule.RuleArn) == *ruleArn {
+			return rule, nil
+		}
+
+		if ruleArn == nil && priority != nil && to.Strs(rule.Priority) == *priority {
+			return rule, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Listener Rule Not Found")
+}
+
+// ForwardTargetGroup returns the target group the rule forwards to
+func ForwardTargetGroup(rule *elbv2.Rule) (*string, error) {
+	if len(rule.Actions) != 1 || to.Strs(rule.Actions[0].Type) != elbv2.ActionTypeEnumForward {
+		return nil, fmt.Errorf("Listener Rule %v must only forward to a target group", to.Strs(rule.RuleArn))
+	}
+
+	return rule.Actions[0].TargetGroupArn, nil
+}
+
+// SetForwardTargetGroup changes the target group the rule forwards to
+func SetForwardTargetGroup(albc aws.ALBAPI, ruleArn *string, targetGroupArn *string) error {
+	_, err := albc.ModifyRule(&elbv2.ModifyRuleInput{
+		RuleArn: ruleArn,
+		Actions: []*elbv2.Action{
+			&elbv2.Action{
+				Type:           to.Strp(elbv2.ActionTypeEnumForward),
+				TargetGroupArn: targetGroupArn,
+			},
+		},
+	})
+
+	return err
+}
@@ -15,6 +15,9 @@ type TargetGroup struct {
 	ServiceNameTag  *string
 	TargetGroupArn  *string
 	TargetGroupName *string
+	VpcID           *string
+
+	LoadBalancerArns []*string
 }
 
 // ProjectName returns tag
@@ -68,6 +71,91 @@ func createDescribeTargetHealthInput(arn *string, instances []string) *elbv2.Des
 	}
 }
 
+//////
+// Attributes
+//////
+
+// TargetGroupAttributes returns the target groups attributes
+func TargetGroupAttributes(albc aws.ALBAPI, arn *string) (map[string]string, error) {
+	out, err := albc.DescribeTargetGroupAttributes(&elbv2.DescribeTargetGroupAttributesInput{
+		TargetGroupArn: arn,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := map[string]string{}
+	for _, a := range out.Attributes {
+		attrs[to.Strs(a.Key)] = to.Strs(a.Value)
+	}
+
+	return attrs, nil
+}
+
+// SetTargetGroupAttributes modifies only the given attributes of the target group
+func SetTargetGroupAttributes(albc aws.ALBAPI, arn *string, attrs map[string]string) error {
+	tgAttrs := []*elbv2.TargetGroupAttribute{}
+	for k, v := range attrs {
+		tgAttrs = append(tgAttrs, &elbv2.TargetGroupAttribute{Key: to.Strp(k), Value: to.Strp(v)})
+	}
+
+	_, err := albc.ModifyTargetGroupAttributes(&elbv2.ModifyTargetGroupAttributesInput{
+		TargetGroupArn: arn,
+		Attributes:     tgAttrs,
+	})
+
+	return err
+}
+
+// LoadBalancerAttributes returns the load balancers attributes
+func LoadBalancerAttributes(albc aws.ALBAPI, arn *string) (map[string]string, error) {
+	out, err := albc.DescribeLoadBalancerAttributes(&elbv2.DescribeLoadBalancerAttributesInput{
+		LoadBalancerArn: arn,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := map[string]string{}
+	for _, a := range out.Attributes {
+		attrs[to.Strs(a.Key)] = to.Strs(a.Value)
+	}
+
+	return attrs, nil
+}
+
+// LoadBalancerTags returns the ProjectName and ConfigName tags of the load balancer
+func LoadBalancerTags(albc aws.ALBAPI, arn *string) (*string, *string, error) {
+	out, err := albc.DescribeTags(&elbv2.DescribeTagsInput{ResourceArns: []*string{arn}})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(out.TagDescriptions) != 1 {
+		return nil, nil, fmt.Errorf("Load balancer %v tags not found", to.Strs(arn))
+	}
+
+	tags := out.TagDescriptions[0].Tags
+	return aws.FetchELBV2Tag(tags, to.Strp("ProjectName")), aws.FetchELBV2Tag(tags, to.Strp("ConfigName")), nil
+}
+
+// SetLoadBalancerAttributes modifies only the given attributes of the load balancer
+func SetLoadBalancerAttributes(albc aws.ALBAPI, arn *string, attrs map[string]string) error {
+	lbAttrs := []*elbv2.LoadBalancerAttribute{}
+	for k, v := range attrs {
+		lbAttrs = append(lbAttrs, &elbv2.LoadBalancerAttribute{Key: to.Strp(k), Value: to.Strp(v)})
+	}
+
+	_, err := albc.ModifyLoadBalancerAttributes(&elbv2.ModifyLoadBalancerAttributesInput{
+		LoadBalancerArn: arn,
+		Attributes:      lbAttrs,
+	})
+
+	return err
+}
+
 //////
 // Find
 //////
@@ -103,6 +191,9 @@ func find(alb aws.ALBAPI, targetGroupName *string) (*TargetGroup, error) {
 		ServiceNameTag:  aws.FetchELBV2Tag(awsTags, to.Strp("ServiceName")),
 		TargetGroupArn:  awsTarget.TargetGroupArn,
 		TargetGroupName: targetGroupName,
+		VpcID:           awsTarget.VpcId,
+
+		LoadBalancerArns: awsTarget.LoadBalancerArns,
 	}, nil
 }
 
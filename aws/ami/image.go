@@ -2,7 +2,10 @@ package ami
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/coinbase/odin/aws"
 	"github.com/coinbase/step/utils/to"
@@ -12,6 +15,10 @@ import (
 type Image struct {
 	ImageID       *string
 	DeployWithTag *string
+	Architecture  *string
+	OwnerID       *string
+	CreatedAt     *time.Time
+	Tags          map[string]*string
 }
 
 func isID(name string) bool {
@@ -60,11 +67,127 @@ func find(ec2c aws.EC2API, in *ec2.DescribeImagesInput) (*Image, error) {
 		if im == nil {
 			return nil, fmt.Errorf("AMI Image nil")
 		}
-		return &Image{
-			im.ImageId,
-			aws.FetchEc2Tag(im.Tags, to.Strp("DeployWith")),
-		}, nil
+		return newImage(im), nil
 	default:
 		return nil, fmt.Errorf("Must be exactly 1 Image with tag Name, there are %v", len(output.Images))
 	}
 }
+
+// ParseFilter parses a filter of comma separated EC2 image filters e.g. name=web-*,tag:baked-by=imagepipeline
+// "owner" selects the owning accounts instead of filtering, a value without a key is a Name tag
+func ParseFilter(filter string) (filters []*ec2.Filter, owners []*string, err error) {
+	for _, part := range strings.Split(filter, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		key, value := "tag:Name", part
+		if i := strings.Index(part, "="); i >= 0 {
+			key, value = strings.TrimSpace(part[:i]), strings.TrimSpace(part[i+1:])
+		}
+
+		if key == "" || value == "" {
+			return nil, nil, fmt.Errorf("AMI filter %q must be key=value", part)
+		}
+
+		if key == "owner" {
+			owners = append(owners, to.Strp(value))
+			continue
+		}
+
+		filters = append(filters, &ec2.Filter{Name: to.Strp(key), Values: []*string{to.Strp(value)}})
+	}
+
+	if len(filters) == 0 {
+		return nil, nil, fmt.Errorf("AMI filter %q selects every image", filter)
+	}
+
+	// Only images in the account are considered by default, so a public image cannot match by name
+	if len(owners) == 0 {
+		owners = []*string{to.Strp("self")}
+	}
+
+	return filters, owners, nil
+}
+
+// Latest returns the most recently created available image matching the filter
+func Latest(ec2c aws.EC2API, filter string) (*Image, error) {
+	filters, owners, err := ParseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	filters = append(filters, &ec2.Filter{Name: to.Strp("state"), Values: []*string{to.Strp("available")}})
+
+	output, err := ec2c.DescribeImages(&ec2.DescribeImagesInput{Filters: filters, Owners: owners})
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *Image
+	for _, im := range output.Images {
+		if im == nil {
+			continue
+		}
+
+		image := newImage(im)
+		if image.CreatedAt == nil {
+			continue
+		}
+
+		if latest == nil || image.CreatedAt.After(*latest.CreatedAt) {
+			latest = image
+		}
+	}
+
+	if latest == nil {
+		return nil, fmt.Errorf("No AMI matches filter %q", filter)
+	}
+
+	return latest, nil
+}
+
+func newImage(im *ec2.Image) *Image {
+	image := &Image{
+		ImageID:       im.ImageId,
+		DeployWithTag: aws.FetchEc2Tag(im.Tags, to.Strp("DeployWith")),
+		Architecture:  im.Architecture,
+		OwnerID:       im.OwnerId,
+		Tags:          map[string]*string{},
+	}
+
+	// EC2 returns the creation date as a string e.g. 2020-01-01T00:00:00.000Z
+	if created, err := time.Parse(time.RFC3339, to.Strs(im.CreationDate)); err == nil {
+		image.CreatedAt = &created
+	}
+
+	for _, tag := range im.Tags {
+		if tag != nil && tag.Key != nil {
+			image.Tags[*tag.Key] = tag.Value
+		}
+	}
+
+	return image
+}
+
+// Describe returns the image with the ID, nil if it does not exist or was deregistered
+func Describe(ec2c aws.EC2API, id *string) (*ec2.Image, error) {
+	output, err := ec2c.DescribeImages(&ec2.DescribeImagesInput{ImageIds: []*string{id}})
+
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "InvalidAMIID.NotFound" {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, im := range output.Images {
+		if im != nil && to.Strs(im.ImageId) == to.Strs(id) {
+			return im, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -3,6 +3,7 @@ package ami
 import (
 	"testing"
 
+	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/coinbase/odin/aws/mocks"
 	"github.com/coinbase/step/utils/to"
 	"github.com/stretchr/testify/assert"
@@ -30,3 +31,55 @@ func Test_Find_Tag(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "ami-000000", *img.ImageID)
 }
+
+func Test_Find_Attributes(t *testing.T) {
+	ec2c := &mocks.EC2Client{}
+	ec2c.AddImage("ubuntu", "ami-000000")
+	ec2c.DescribeImagesResp.Resp.Images[0].OwnerId = to.Strp("111111111111")
+	ec2c.DescribeImagesResp.Resp.Images[0].CreationDate = to.Strp("2020-01-02T03:04:05.000Z")
+
+	img, err := Find(ec2c, to.Strp("ubuntu"))
+	assert.NoError(t, err)
+	assert.Equal(t, "111111111111", *img.OwnerID)
+	assert.Equal(t, 2020, img.CreatedAt.Year())
+	assert.Equal(t, "odin", *img.Tags["DeployWith"])
+}
+
+func Test_ParseFilter(t *testing.T) {
+	filters, owners, err := ParseFilter("name=web-*, tag:baked-by=imagepipeline")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(filters))
+	assert.Equal(t, "name", *filters[0].Name)
+	assert.Equal(t, "web-*", *filters[0].Values[0])
+	assert.Equal(t, "tag:baked-by", *filters[1].Name)
+	assert.Equal(t, []string{"self"}, to.StrSlice(owners))
+
+	filters, owners, err = ParseFilter("ubuntu,owner=111111111111")
+	assert.NoError(t, err)
+	assert.Equal(t, "tag:Name", *filters[0].Name)
+	assert.Equal(t, []string{"111111111111"}, to.StrSlice(owners))
+
+	_, _, err = ParseFilter("owner=111111111111")
+	assert.Error(t, err)
+
+	_, _, err = ParseFilter("name=")
+	assert.Error(t, err)
+}
+
+func Test_Latest(t *testing.T) {
+	ec2c := &mocks.EC2Client{}
+	ec2c.AddImage("web", "ami-000001")
+	ec2c.DescribeImagesResp.Resp.Images[0].CreationDate = to.Strp("2020-01-01T00:00:00.000Z")
+	ec2c.DescribeImagesResp.Resp.Images = append(ec2c.DescribeImagesResp.Resp.Images,
+		&ec2.Image{ImageId: to.Strp("ami-000002"), CreationDate: to.Strp("2020-02-01T00:00:00.000Z")},
+		&ec2.Image{ImageId: to.Strp("ami-000003"), CreationDate: to.Strp("2019-12-01T00:00:00.000Z")},
+	)
+
+	img, err := Latest(ec2c, "name=web-*")
+	assert.NoError(t, err)
+	assert.Equal(t, "ami-000002", *img.ImageID)
+
+	ec2c.DescribeImagesResp.Resp.Images = nil
+	_, err = Latest(ec2c, "name=web-*")
+	assert.Error(t, err)
+}
@@ -2,9 +2,13 @@ package asg
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
 	"github.com/coinbase/odin/aws"
 	"github.com/coinbase/odin/aws/lc"
 	"github.com/coinbase/step/utils/is"
@@ -19,10 +23,15 @@ type ASG struct {
 	ReleaseIDTag   *string
 	ReleaseIdTag   *string
 
+	// PreviousReleaseIDTag is the release this ASG replaced, linking it to the chain of releases
+	PreviousReleaseIDTag *string
+
 	DesiredCapacity *int64
+	CreatedTime     *time.Time
 
 	AutoScalingGroupName    *string
 	LaunchConfigurationName *string
+	LaunchTemplateName      *string // Deleted separately as it is an EC2 resource
 
 	LoadBalancerNames []*string
 	TargetGroupARNs   []*string
@@ -53,6 +62,16 @@ func (s *ASG) ReleaseID() *string {
 	return s.ReleaseIdTag
 }
 
+// PreviousReleaseID returns tag
+func (s *ASG) PreviousReleaseID() *string {
+	return s.PreviousReleaseIDTag
+}
+
+// Owned returns whether the ASG has all the tags Odin creates it with, ASGs without them were made by hand
+func (s *ASG) Owned() bool {
+	return !is.EmptyStr(s.ProjectName()) && !is.EmptyStr(s.ConfigName()) && !is.EmptyStr(s.ReleaseID())
+}
+
 // ServiceID returns tag
 func (s *ASG) ServiceID() *string {
 	// Name of the AutoScalingGroup is the ServiceID
@@ -64,6 +83,11 @@ func (s *ASG) ServiceID() *string {
 //////
 
 func newASG(group *autoscaling.Group) *ASG {
+	var launchTemplateName *string
+	if group.LaunchTemplate != nil {
+		launchTemplateName = group.LaunchTemplate.LaunchTemplateName
+	}
+
 	return &ASG{
 		ProjectNameTag: aws.FetchASGTag(group.Tags, to.Strp("ProjectName")),
 		ConfigNameTag:  aws.FetchASGTag(group.Tags, to.Strp("ConfigName")),
@@ -71,13 +95,17 @@ func newASG(group *autoscaling.Group) *ASG {
 		ReleaseIDTag:   aws.FetchASGTag(group.Tags, to.Strp("ReleaseID")),
 		ReleaseIdTag:   aws.FetchASGTag(group.Tags, to.Strp("ReleaseId")),
 
+		PreviousReleaseIDTag: aws.FetchASGTag(group.Tags, to.Strp("PreviousReleaseID")),
+
 		AutoScalingGroupName:    group.AutoScalingGroupName,
 		LaunchConfigurationName: group.LaunchConfigurationName,
+		LaunchTemplateName:      launchTemplateName,
 
 		LoadBalancerNames: group.LoadBalancerNames,
 		TargetGroupARNs:   group.TargetGroupARNs,
 
 		DesiredCapacity: group.DesiredCapacity,
+		CreatedTime:     group.CreatedTime,
 
 		instances: group.Instances,
 	}
@@ -193,6 +221,50 @@ func ForProjectConfigReleaseID(asgc aws.ASGAPI, projectName *string, configName
 	return asgs, nil
 }
 
+// ForNames returns the ASGs with the names that exist
+func ForNames(asgc aws.ASGAPI, names []*string) ([]*ASG, error) {
+	if len(names) == 0 {
+		return []*ASG{}, nil
+	}
+
+	all, err := findInAws(asgc, &autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: names,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := map[string]bool{}
+	for _, name := range names {
+		wanted[to.Strs(name)] = true
+	}
+
+	asgs := []*ASG{}
+	for _, asg := range all {
+		if wanted[to.Strs(asg.AutoScalingGroupName)] {
+			asgs = append(asgs, asg)
+		}
+	}
+
+	return asgs, nil
+}
+
+// All returns every ASG in the account, including those not created by odin
+func All(asgc aws.ASGAPI) ([]*ASG, error) {
+	return findInAws(asgc, &autoscaling.DescribeAutoScalingGroupsInput{})
+}
+
+// Attached returns whether the ASG is attached to any load balancers or target groups
+func (s *ASG) Attached() bool {
+	return len(s.LoadBalancerNames) > 0 || len(s.TargetGroupARNs) > 0
+}
+
+// ForProjectConfig returns all ASGs of the project config
+func ForProjectConfig(asgc aws.ASGAPI, projectName *string, configName *string) ([]*ASG, error) {
+	return forProjectConfig(asgc, projectName, configName)
+}
+
 func forProjectConfig(asgc aws.ASGAPI, projectName *string, configName *string) ([]*ASG, error) {
 	all, err := findInAws(asgc, &autoscaling.DescribeAutoScalingGroupsInput{})
 	if err != nil {
@@ -246,6 +318,73 @@ func (s *ASG) Teardown(asgc aws.ASGAPI, cwc aws.CWAPI) error {
 		return err
 	}
 
+	return s.Delete(asgc, cwc)
+}
+
+// Detach removes the ASG from its load balancers and target groups, which starts draining its instances
+func (s *ASG) Detach(asgc aws.ASGAPI) error {
+	return s.detach(asgc)
+}
+
+// Draining returns whether any of the ASGs instances are still registered with its load balancers or target groups
+func (s *ASG) Draining(elbc aws.ELBAPI, albc aws.ALBAPI) (bool, error) {
+	ids := map[string]bool{}
+	for _, i := range s.instances {
+		if i.InstanceId != nil {
+			ids[*i.InstanceId] = true
+		}
+	}
+
+	for _, name := range s.LoadBalancerNames {
+		output, err := elbc.DescribeInstanceHealth(&elb.DescribeInstanceHealthInput{LoadBalancerName: name})
+		if err != nil {
+			return false, err
+		}
+
+		for _, state := range output.InstanceStates {
+			if state.InstanceId != nil && ids[*state.InstanceId] {
+				return true, nil
+			}
+		}
+	}
+
+	for _, arn := range s.TargetGroupARNs {
+		output, err := albc.DescribeTargetHealth(&elbv2.DescribeTargetHealthInput{TargetGroupArn: arn})
+		if err != nil {
+			return false, err
+		}
+
+		for _, thd := range output.TargetHealthDescriptions {
+			if thd.Target != nil && thd.Target.Id != nil && ids[*thd.Target.Id] {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// HasTerminationHooks returns whether the ASG has lifecycle hooks on instance termination
+func (s *ASG) HasTerminationHooks(asgc aws.ASGAPI) (bool, error) {
+	output, err := asgc.DescribeLifecycleHooks(&autoscaling.DescribeLifecycleHooksInput{
+		AutoScalingGroupName: s.ServiceID(),
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, hook := range output.LifecycleHooks {
+		if hook.LifecycleTransition != nil && *hook.LifecycleTransition == "autoscaling:EC2_INSTANCE_TERMINATING" {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Delete deletes the detached ASG with launch config and alarms
+func (s *ASG) Delete(asgc aws.ASGAPI, cwc aws.CWAPI) error {
 	// Delete Alarms
 	alarms, err := s.alarmNames(asgc)
 	if err != nil {
@@ -261,7 +400,11 @@ func (s *ASG) Teardown(asgc aws.ASGAPI, cwc aws.CWAPI) error {
 		return err
 	}
 
-	// Delete Launch Config as well
+	// Delete Launch Config as well, ASGs with a launch template have none
+	if s.LaunchConfigurationName == nil {
+		return nil
+	}
+
 	if err := lc.Teardown(asgc, s.LaunchConfigurationName); err != nil {
 		return err
 	}
@@ -324,3 +467,139 @@ func (s *ASG) deleteGroup(asgc aws.ASGAPI) error {
 	}
 	return nil
 }
+
+//////////
+// Capacity
+//////////
+
+// InsufficientCapacity returns whether a scaling activity of the group started after since
+// failed because EC2 had insufficient capacity for the instance type
+func InsufficientCapacity(asgc aws.ASGAPI, asgName *string, since *time.Time) (bool, error) {
+	output, err := asgc.DescribeScalingActivities(&autoscaling.DescribeScalingActivitiesInput{
+		AutoScalingGroupName: asgName,
+		MaxRecords:           to.Int64p(20),
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, activity := range output.Activities {
+		if activity.StatusCode == nil || *activity.StatusCode != autoscaling.ScalingActivityStatusCodeFailed {
+			continue
+		}
+
+		if since != nil && activity.StartTime != nil && activity.StartTime.Before(*since) {
+			continue
+		}
+
+		if strings.Contains(to.Strs(activity.StatusMessage), "InsufficientInstanceCapacity") {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// InterruptedInstances returns the instances of the group being terminated
+// because of a Spot interruption notice or rebalance recommendation
+func InterruptedInstances(asgc aws.ASGAPI, asgName *string) (map[string]bool, error) {
+	output, err := asgc.DescribeScalingActivities(&autoscaling.DescribeScalingActivitiesInput{
+		AutoScalingGroupName: asgName,
+		MaxRecords:           to.Int64p(50),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	interrupted := map[string]bool{}
+	for _, activity := range output.Activities {
+		description := to.Strs(activity.Description)
+		if !strings.HasPrefix(description, "Terminating EC2 instance: ") {
+			continue
+		}
+
+		cause := strings.ToLower(to.Strs(activity.Cause))
+		if strings.Contains(cause, "interruption") || strings.Contains(cause, "rebalance recommendation") {
+			interrupted[strings.TrimPrefix(description, "Terminating EC2 instance: ")] = true
+		}
+	}
+
+	return interrupted, nil
+}
+
+// UpdateLaunchConfiguration sets the launch configuration used for new instances of the group
+func UpdateLaunchConfiguration(asgc aws.ASGAPI, asgName *string, lcName *string) error {
+	_, err := asgc.UpdateAutoScalingGroup(&autoscaling.UpdateAutoScalingGroupInput{
+		AutoScalingGroupName:    asgName,
+		LaunchConfigurationName: lcName,
+	})
+	return err
+}
+
+// UpdateCapacity sets the min size and desired capacity of the ASG
+func UpdateCapacity(asgc aws.ASGAPI, asgName *string, minSize int64, desiredCapacity int64) error {
+	_, err := asgc.UpdateAutoScalingGroup(&autoscaling.UpdateAutoScalingGroupInput{
+		AutoScalingGroupName: asgName,
+		MinSize:              &minSize,
+		DesiredCapacity:      &desiredCapacity,
+	})
+	return err
+}
+
+// UpdateSubnets moves the ASG to the subnets with the capacity
+func UpdateSubnets(asgc aws.ASGAPI, asgName *string, subnetIds *string, minSize int64, desiredCapacity int64) error {
+	_, err := asgc.UpdateAutoScalingGroup(&autoscaling.UpdateAutoScalingGroupInput{
+		AutoScalingGroupName: asgName,
+		VPCZoneIdentifier:    subnetIds,
+		MinSize:              &minSize,
+		DesiredCapacity:      &desiredCapacity,
+	})
+	return err
+}
+
+// RemoveScaleInProtection stops protecting the ASGs new and current instances from scale in
+func RemoveScaleInProtection(asgc aws.ASGAPI, asgName *string) error {
+	_, err := asgc.UpdateAutoScalingGroup(&autoscaling.UpdateAutoScalingGroupInput{
+		AutoScalingGroupName:             asgName,
+		NewInstancesProtectedFromScaleIn: to.Boolp(false),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	instances, err := GetInstances(asgc, asgName)
+	if err != nil {
+		return err
+	}
+
+	ids := []*string{}
+	for _, id := range instances.InstanceIDs() {
+		ids = append(ids, to.Strp(id))
+	}
+
+	// SetInstanceProtection accepts at most 50 instances
+	for len(ids) > 0 {
+		size := 50
+		if len(ids) < size {
+			size = len(ids)
+		}
+
+		batch := ids[:size]
+		ids = ids[size:]
+
+		_, err := asgc.SetInstanceProtection(&autoscaling.SetInstanceProtectionInput{
+			AutoScalingGroupName: asgName,
+			InstanceIds:          batch,
+			ProtectedFromScaleIn: to.Boolp(false),
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
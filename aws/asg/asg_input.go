@@ -48,7 +48,7 @@ func (s *Input) SetDefaults() {
 		s.HealthCheckGracePeriod = to.Int64p(300)
 	}
 
-	if s.LaunchConfigurationName == nil {
+	if s.LaunchConfigurationName == nil && s.LaunchTemplate == nil {
 		s.LaunchConfigurationName = s.AutoScalingGroupName // Makes the name the same
 	}
 
@@ -62,7 +62,7 @@ func (s *Input) SetDefaults() {
 	}
 }
 
-// AddTag adds a tag to the input
+// AddTag adds a tag to the input, which is propagated to the instances the ASG launches
 func (s *Input) AddTag(key string, value *string) {
 	if s.Tags == nil {
 		s.Tags = []*autoscaling.Tag{}
@@ -76,7 +76,7 @@ func (s *Input) AddTag(key string, value *string) {
 	}
 
 	// Add new Tag
-	s.Tags = append(s.Tags, &autoscaling.Tag{Key: &key, Value: value})
+	s.Tags = append(s.Tags, &autoscaling.Tag{Key: &key, Value: value, PropagateAtLaunch: to.Boolp(true)})
 }
 
 // ToASG returns ASG object
@@ -99,3 +99,66 @@ func Test_Teardown(t *testing.T) {
 	err = asgs[0].Teardown(asgc, cwc)
 	assert.NoError(t, err)
 }
+
+func Test_Draining(t *testing.T) {
+	// func (s *ASG) Draining(elbc aws.ELBAPI, albc aws.ALBAPI) (bool, error) {
+	asgc := &mocks.ASGClient{}
+	elbc := &mocks.ELBClient{}
+	albc := &mocks.ALBClient{}
+
+	asgc.AddPreviousRuntimeResources("project", "config", "service1", "not_release")
+	asgs, err := ForProjectConfigNOTReleaseID(asgc, to.Strp("project"), to.Strp("config"), to.Strp("release"))
+	assert.NoError(t, err)
+
+	draining, err := asgs[0].Draining(elbc, albc)
+	assert.NoError(t, err)
+	assert.False(t, draining)
+
+	elbc.AddELB("elb", "project", "config", "service1")
+	asgs[0].LoadBalancerNames = []*string{to.Strp("elb")}
+	draining, err = asgs[0].Draining(elbc, albc)
+	assert.NoError(t, err)
+	assert.True(t, draining)
+
+	albc.AddTargetGroup("tg", "project", "config", "service1")
+	asgs[0].LoadBalancerNames = nil
+	asgs[0].TargetGroupARNs = []*string{to.Strp("tg")}
+	draining, err = asgs[0].Draining(elbc, albc)
+	assert.NoError(t, err)
+	assert.True(t, draining)
+
+	asgs[0].instances = nil
+	draining, err = asgs[0].Draining(elbc, albc)
+	assert.NoError(t, err)
+	assert.False(t, draining)
+}
+
+func Test_HasTerminationHooks(t *testing.T) {
+	// func (s *ASG) HasTerminationHooks(asgc aws.ASGAPI) (bool, error) {
+	asgc := &mocks.ASGClient{}
+
+	name := asgc.AddPreviousRuntimeResources("project", "config", "service1", "not_release")
+	asgs, err := ForProjectConfigNOTReleaseID(asgc, to.Strp("project"), to.Strp("config"), to.Strp("release"))
+	assert.NoError(t, err)
+
+	hooked, err := asgs[0].HasTerminationHooks(asgc)
+	assert.NoError(t, err)
+	assert.False(t, hooked)
+
+	asgc.AddTerminationHook(name, "termination")
+	hooked, err = asgs[0].HasTerminationHooks(asgc)
+	assert.NoError(t, err)
+	assert.True(t, hooked)
+}
+
+func Test_ASG_Owned(t *testing.T) {
+	group := mocks.MakeMockASG("asg", "project", "config", "service", "release")
+	assert.True(t, newASG(group).Owned())
+
+	// Hand made ASGs sharing the project and config tags have no release
+	group = mocks.MakeMockASG("asg", "project", "config", "service", "")
+	assert.False(t, newASG(group).Owned())
+
+	group.Tags = group.Tags[:1]
+	assert.False(t, newASG(group).Owned())
+}
@@ -1,24 +1,45 @@
 package aws
 
 import (
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/service/appmesh"
+	"github.com/aws/aws-sdk-go/service/appmesh/appmeshiface"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
 	"github.com/aws/aws-sdk-go/service/elb"
 	"github.com/aws/aws-sdk-go/service/elb/elbiface"
 	"github.com/aws/aws-sdk-go/service/elbv2"
 	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-sdk-go/service/lambda/lambdaiface"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	"github.com/aws/aws-sdk-go/service/pricing/pricingiface"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/aws/aws-sdk-go/service/servicequotas"
+	"github.com/aws/aws-sdk-go/service/servicequotas/servicequotasiface"
 	"github.com/aws/aws-sdk-go/service/sfn"
 	"github.com/aws/aws-sdk-go/service/sfn/sfniface"
 	"github.com/aws/aws-sdk-go/service/sns"
 	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
 	ar "github.com/coinbase/step/aws"
 )
 
@@ -169,6 +190,36 @@ type SNSAPI snsiface.SNSAPI
 // SFNAPI aws API
 type SFNAPI sfniface.SFNAPI
 
+// STSAPI aws API
+type STSAPI stsiface.STSAPI
+
+// SSMAPI aws API
+type SSMAPI ssmiface.SSMAPI
+
+// SMAPI aws API
+type SMAPI secretsmanageriface.SecretsManagerAPI
+
+// LambdaAPI aws API
+type LambdaAPI lambdaiface.LambdaAPI
+
+// MeshAPI aws API
+type MeshAPI appmeshiface.AppMeshAPI
+
+// PricingAPI aws API
+type PricingAPI pricingiface.PricingAPI
+
+// KMSAPI aws API
+type KMSAPI kmsiface.KMSAPI
+
+// DynamoDBAPI aws API
+type DynamoDBAPI dynamodbiface.DynamoDBAPI
+
+// QuotasAPI aws API
+type QuotasAPI servicequotasiface.ServiceQuotasAPI
+
+// ECRAPI aws API
+type ECRAPI ecriface.ECRAPI
+
 // Clients for AWS
 type Clients interface {
 	S3Client(region *string, accountID *string, role *string) S3API
@@ -180,54 +231,159 @@ type Clients interface {
 	IAMClient(region *string, accountID *string, role *string) IAMAPI
 	SNSClient(region *string, accountID *string, role *string) SNSAPI
 	SFNClient(region *string, accountID *string, role *string) SFNAPI
+	STSClient(region *string, accountID *string, role *string) STSAPI
+	SSMClient(region *string, accountID *string, role *string) SSMAPI
+	SMClient(region *string, accountID *string, role *string) SMAPI
+	LambdaClient(region *string, accountID *string, role *string) LambdaAPI
+	MeshClient(region *string, accountID *string, role *string) MeshAPI
+	PricingClient(region *string, accountID *string, role *string) PricingAPI
+	KMSClient(region *string, accountID *string, role *string) KMSAPI
+	DynamoDBClient(region *string, accountID *string, role *string) DynamoDBAPI
+	QuotasClient(region *string, accountID *string, role *string) QuotasAPI
+	ECRClient(region *string, accountID *string, role *string) ECRAPI
 }
 
 // ClientsStr implementation
+// Its clients back off throttled calls, and its EC2, ELB, ELBv2 and Pricing clients cache describe calls within a CacheScope
 type ClientsStr struct {
 	ar.Clients
 }
 
+// instrument counts the clients calls and backs off its throttled calls
+func instrument(c *client.Client) {
+	countCalls(c)
+	retryThrottles(c)
+}
+
 // S3Client returns client for region account and role
 func (awsc *ClientsStr) S3Client(region *string, accountID *string, role *string) S3API {
-	return s3.New(awsc.Session(), awsc.Config(region, accountID, role))
+	c := s3.New(awsc.Session(), awsc.Config(region, accountID, role))
+	instrument(c.Client)
+	return c
 }
 
 // ASGClient returns client for region account and role
 func (awsc *ClientsStr) ASGClient(region *string, accountID *string, role *string) ASGAPI {
-	return autoscaling.New(awsc.Session(), awsc.Config(region, accountID, role))
+	c := autoscaling.New(awsc.Session(), awsc.Config(region, accountID, role))
+	instrument(c.Client)
+	return c
 }
 
 // ELBClient returns client for region account and role
 func (awsc *ClientsStr) ELBClient(region *string, accountID *string, role *string) ELBAPI {
-	return elb.New(awsc.Session(), awsc.Config(region, accountID, role))
+	c := elb.New(awsc.Session(), awsc.Config(region, accountID, role))
+	instrument(c.Client)
+	return &cachedELB{ELBAPI: c, key: cacheKey("elb", region, accountID, role)}
 }
 
 // EC2Client returns client for region account and role
 func (awsc *ClientsStr) EC2Client(region *string, accountID *string, role *string) EC2API {
-	return ec2.New(awsc.Session(), awsc.Config(region, accountID, role))
+	c := ec2.New(awsc.Session(), awsc.Config(region, accountID, role))
+	instrument(c.Client)
+	return &cachedEC2{EC2API: c, key: cacheKey("ec2", region, accountID, role)}
 }
 
 // ALBClient returns client for region account and role
 func (awsc *ClientsStr) ALBClient(region *string, accountID *string, role *string) ALBAPI {
-	return elbv2.New(awsc.Session(), awsc.Config(region, accountID, role))
+	c := elbv2.New(awsc.Session(), awsc.Config(region, accountID, role))
+	instrument(c.Client)
+	return &cachedALB{ALBAPI: c, key: cacheKey("elbv2", region, accountID, role)}
 }
 
 // CWClient returns client for region account and role
 func (awsc *ClientsStr) CWClient(region *string, accountID *string, role *string) CWAPI {
-	return cloudwatch.New(awsc.Session(), awsc.Config(region, accountID, role))
+	c := cloudwatch.New(awsc.Session(), awsc.Config(region, accountID, role))
+	instrument(c.Client)
+	return c
 }
 
 // IAMClient returns client for region account and role
 func (awsc *ClientsStr) IAMClient(region *string, accountID *string, role *string) IAMAPI {
-	return iam.New(awsc.Session(), awsc.Config(region, accountID, role))
+	c := iam.New(awsc.Session(), awsc.Config(region, accountID, role))
+	instrument(c.Client)
+	return c
 }
 
 // SNSClient returns client for region account and role
 func (awsc *ClientsStr) SNSClient(region *string, accountID *string, role *string) SNSAPI {
-	return sns.New(awsc.Session(), awsc.Config(region, accountID, role))
+	c := sns.New(awsc.Session(), awsc.Config(region, accountID, role))
+	instrument(c.Client)
+	return c
 }
 
 // SFNClient returns client for region account and role
 func (awsc *ClientsStr) SFNClient(region *string, accountID *string, role *string) SFNAPI {
-	return sfn.New(awsc.Session(), awsc.Config(region, accountID, role))
+	c := sfn.New(awsc.Session(), awsc.Config(region, accountID, role))
+	instrument(c.Client)
+	return c
+}
+
+// STSClient returns client for region account and role
+func (awsc *ClientsStr) STSClient(region *string, accountID *string, role *string) STSAPI {
+	c := sts.New(awsc.Session(), awsc.Config(region, accountID, role))
+	instrument(c.Client)
+	return c
+}
+
+// SSMClient returns client for region account and role
+func (awsc *ClientsStr) SSMClient(region *string, accountID *string, role *string) SSMAPI {
+	c := ssm.New(awsc.Session(), awsc.Config(region, accountID, role))
+	instrument(c.Client)
+	return c
+}
+
+// SMClient returns client for region account and role
+func (awsc *ClientsStr) SMClient(region *string, accountID *string, role *string) SMAPI {
+	c := secretsmanager.New(awsc.Session(), awsc.Config(region, accountID, role))
+	instrument(c.Client)
+	return c
+}
+
+// LambdaClient returns client for region account and role
+func (awsc *ClientsStr) LambdaClient(region *string, accountID *string, role *string) LambdaAPI {
+	c := lambda.New(awsc.Session(), awsc.Config(region, accountID, role))
+	instrument(c.Client)
+	return c
+}
+
+// MeshClient returns client for region account and role
+func (awsc *ClientsStr) MeshClient(region *string, accountID *string, role *string) MeshAPI {
+	c := appmesh.New(awsc.Session(), awsc.Config(region, accountID, role))
+	instrument(c.Client)
+	return c
+}
+
+// PricingClient returns client for region account and role
+func (awsc *ClientsStr) PricingClient(region *string, accountID *string, role *string) PricingAPI {
+	c := pricing.New(awsc.Session(), awsc.Config(region, accountID, role))
+	instrument(c.Client)
+	return &cachedPricing{PricingAPI: c, key: cacheKey("pricing", region, accountID, role)}
+}
+
+// KMSClient returns client for region account and role
+func (awsc *ClientsStr) KMSClient(region *string, accountID *string, role *string) KMSAPI {
+	c := kms.New(awsc.Session(), awsc.Config(region, accountID, role))
+	instrument(c.Client)
+	return c
+}
+
+// DynamoDBClient returns client for region account and role
+func (awsc *ClientsStr) DynamoDBClient(region *string, accountID *string, role *string) DynamoDBAPI {
+	c := dynamodb.New(awsc.Session(), awsc.Config(region, accountID, role))
+	instrument(c.Client)
+	return c
+}
+
+// QuotasClient returns client for region account and role
+func (awsc *ClientsStr) QuotasClient(region *string, accountID *string, role *string) QuotasAPI {
+	c := servicequotas.New(awsc.Session(), awsc.Config(region, accountID, role))
+	instrument(c.Client)
+	return c
+}
+
+// ECRClient returns client for region account and role
+func (awsc *ClientsStr) ECRClient(region *string, accountID *string, role *string) ECRAPI {
+	c := ecr.New(awsc.Session(), awsc.Config(region, accountID, role))
+	instrument(c.Client)
+	return c
 }
@@ -0,0 +1,186 @@
+package bucket
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Error codes S3 returns for the checks
+const (
+	codeNotFound       = "NotFound"
+	codeNoSuchBucket   = "NoSuchBucket"
+	codeForbidden      = "Forbidden"
+	codeAccessDenied   = "AccessDenied"
+	codeBucketRegion   = "BucketRegionError"
+	codeBadDigest      = "BadDigest"
+	codeNoEncryption   = "ServerSideEncryptionConfigurationNotFoundError"
+	codeNoPublicAccess = "NoSuchPublicAccessBlockConfiguration"
+)
+
+// PreflightError lists every problem with the bucket, so they can all be fixed at once
+type PreflightError struct {
+	Bucket   string
+	Problems []string
+}
+
+func (e *PreflightError) Error() string {
+	return fmt.Sprintf("Bucket %v preflight failed:\n  - %v", e.Bucket, strings.Join(e.Problems, "\n  - "))
+}
+
+// Preflight checks the bucket exists in the region, encrypts by default, blocks public access, and that the
+// caller can upload to the paths, so misconfiguration fails before anything is uploaded
+// Settings the caller cannot read, and versioning not being enabled, are returned as warnings
+func Preflight(s3c aws.S3API, bucket string, region string, paths []string) ([]string, error) {
+	warnings := []string{}
+	failed := &PreflightError{Bucket: bucket}
+	problem := func(format string, args ...interface{}) {
+		failed.Problems = append(failed.Problems, fmt.Sprintf(format, args...))
+	}
+
+	// Nothing else can be checked without the bucket
+	if _, err := s3c.HeadBucket(&s3.HeadBucketInput{Bucket: &bucket}); err != nil {
+		switch errorCode(err) {
+		case codeNotFound, codeNoSuchBucket:
+			problem("does not exist, create it with odin stack or set the bucket of the context")
+		case codeForbidden, codeAccessDenied:
+			problem("cannot be accessed, the caller needs s3:ListBucket on arn:aws:s3:::%v, or it is owned by another account", bucket)
+		case codeBucketRegion, "MovedPermanently", "PermanentRedirect", "301":
+			problem("is not in %v, set the region of the context to the buckets region", region)
+		default:
+			problem("cannot be accessed %v", err.Error())
+		}
+		return warnings, failed
+	}
+
+	location, err := s3c.GetBucketLocation(&s3.GetBucketLocationInput{Bucket: &bucket})
+	if err != nil {
+		warnings = append(warnings, cannotCheck(bucket, "region", "s3:GetBucketLocation", err))
+	} else if actual := normalizeLocation(location.LocationConstraint); region != "" && actual != region {
+		problem("is in %v not %v, set the region of the context to %v or use a bucket in %v", actual, region, actual, region)
+	}
+
+	if _, err := s3c.GetBucketEncryption(&s3.GetBucketEncryptionInput{Bucket: &bucket}); err != nil {
+		if errorCode(err) == codeNoEncryption {
+			problem("has no default encryption, enable SSE-S3 or SSE-KMS default encryption with aws s3api put-bucket-encryption")
+		} else {
+			warnings = append(warnings, cannotCheck(bucket, "default encryption", "s3:GetEncryptionConfiguration", err))
+		}
+	}
+
+	block, err := s3c.GetPublicAccessBlock(&s3.GetPublicAccessBlockInput{Bucket: &bucket})
+	switch {
+	case errorCode(err) == codeNoPublicAccess:
+		problem("does not block public access, enable all four settings with aws s3api put-public-access-block")
+	case err != nil:
+		warnings = append(warnings, cannotCheck(bucket, "public access block", "s3:GetBucketPublicAccessBlock", err))
+	default:
+		if off := publicAccessOff(block.PublicAccessBlockConfiguration); len(off) > 0 {
+			problem("does not block public access, enable %v with aws s3api put-public-access-block", strings.Join(off, ", "))
+		}
+	}
+
+	versioning, err := s3c.GetBucketVersioning(&s3.GetBucketVersioningInput{Bucket: &bucket})
+	if err != nil {
+		warnings = append(warnings, cannotCheck(bucket, "versioning", "s3:GetBucketVersioning", err))
+	} else if to.Strs(versioning.Status) != s3.BucketVersioningStatusEnabled {
+		warnings = append(warnings, fmt.Sprintf("Bucket %v versioning is not enabled, overwritten release records cannot be recovered", bucket))
+	}
+
+	for _, path := range paths {
+		if err := canPut(s3c, bucket, path); err != nil {
+			problem("%v", err.Error())
+		}
+	}
+
+	if len(failed.Problems) > 0 {
+		return warnings, failed
+	}
+
+	return warnings, nil
+}
+
+// canPut checks the caller can s3:PutObject to the path without writing it
+// The request's Content-MD5 does not match its body, so S3 authorizes it then rejects it with BadDigest
+func canPut(s3c aws.S3API, bucket string, path string) error {
+	wrongMD5 := md5.Sum([]byte("not the body"))
+
+	_, err := s3c.PutObject(&s3.PutObjectInput{
+		Bucket:     &bucket,
+		Key:        &path,
+		Body:       bytes.NewReader([]byte("odin preflight")),
+		ContentMD5: to.Strp(base64.StdEncoding.EncodeToString(wrongMD5[:])),
+	})
+
+	switch errorCode(err) {
+	case codeBadDigest:
+		return nil
+	case codeForbidden, codeAccessDenied:
+		return fmt.Errorf("caller cannot upload to %v, it needs s3:PutObject on arn:aws:s3:::%v/%v", path, bucket, path)
+	case "":
+		// Written anyway, it is overwritten by the upload
+		return nil
+	default:
+		return fmt.Errorf("caller cannot upload to %v %v", path, err.Error())
+	}
+}
+
+func publicAccessOff(config *s3.PublicAccessBlockConfiguration) []string {
+	if config == nil {
+		config = &s3.PublicAccessBlockConfiguration{}
+	}
+
+	off := []string{}
+	settings := []struct {
+		name  string
+		value *bool
+	}{
+		{"BlockPublicAcls", config.BlockPublicAcls},
+		{"IgnorePublicAcls", config.IgnorePublicAcls},
+		{"BlockPublicPolicy", config.BlockPublicPolicy},
+		{"RestrictPublicBuckets", config.RestrictPublicBuckets},
+	}
+
+	for _, setting := range settings {
+		if setting.value == nil || !*setting.value {
+			off = append(off, setting.name)
+		}
+	}
+
+	return off
+}
+
+// normalizeLocation returns the region of a LocationConstraint, which is empty for us-east-1 and EU for eu-west-1
+func normalizeLocation(location *string) string {
+	switch to.Strs(location) {
+	case "":
+		return "us-east-1"
+	case "EU":
+		return "eu-west-1"
+	default:
+		return *location
+	}
+}
+
+func cannotCheck(bucket string, setting string, action string, err error) string {
+	return fmt.Sprintf("Bucket %v %v not checked, the caller needs %v (%v)", bucket, setting, action, errorCode(err))
+}
+
+func errorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code()
+	}
+
+	return err.Error()
+}
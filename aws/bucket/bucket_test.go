@@ -0,0 +1,148 @@
+package bucket
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+// bucketS3 is a bucket with its settings, errors are returned as the codes S3 returns
+type bucketS3 struct {
+	aws.S3API
+	headErr      string
+	location     string
+	encryption   string
+	publicAccess *s3.PublicAccessBlockConfiguration
+	versioning   string
+	deniedPaths  map[string]bool
+	configDenied bool
+}
+
+func newBucketS3() *bucketS3 {
+	return &bucketS3{
+		location:   "us-west-2",
+		encryption: "AES256",
+		publicAccess: &s3.PublicAccessBlockConfiguration{
+			BlockPublicAcls:       to.Boolp(true),
+			IgnorePublicAcls:      to.Boolp(true),
+			BlockPublicPolicy:     to.Boolp(true),
+			RestrictPublicBuckets: to.Boolp(true),
+		},
+		versioning:  "Enabled",
+		deniedPaths: map[string]bool{},
+	}
+}
+
+func (m *bucketS3) denied() error {
+	if m.configDenied {
+		return awserr.New("AccessDenied", "denied", nil)
+	}
+	return nil
+}
+
+func (m *bucketS3) HeadBucket(in *s3.HeadBucketInput) (*s3.HeadBucketOutput, error) {
+	if m.headErr != "" {
+		return nil, awserr.New(m.headErr, "head", nil)
+	}
+	return &s3.HeadBucketOutput{}, nil
+}
+
+func (m *bucketS3) GetBucketLocation(in *s3.GetBucketLocationInput) (*s3.GetBucketLocationOutput, error) {
+	return &s3.GetBucketLocationOutput{LocationConstraint: to.Strp(m.location)}, m.denied()
+}
+
+func (m *bucketS3) GetBucketEncryption(in *s3.GetBucketEncryptionInput) (*s3.GetBucketEncryptionOutput, error) {
+	if err := m.denied(); err != nil {
+		return nil, err
+	}
+
+	if m.encryption == "" {
+		return nil, awserr.New("ServerSideEncryptionConfigurationNotFoundError", "none", nil)
+	}
+	return &s3.GetBucketEncryptionOutput{}, nil
+}
+
+func (m *bucketS3) GetPublicAccessBlock(in *s3.GetPublicAccessBlockInput) (*s3.GetPublicAccessBlockOutput, error) {
+	if err := m.denied(); err != nil {
+		return nil, err
+	}
+
+	if m.publicAccess == nil {
+		return nil, awserr.New("NoSuchPublicAccessBlockConfiguration", "none", nil)
+	}
+	return &s3.GetPublicAccessBlockOutput{PublicAccessBlockConfiguration: m.publicAccess}, nil
+}
+
+func (m *bucketS3) GetBucketVersioning(in *s3.GetBucketVersioningInput) (*s3.GetBucketVersioningOutput, error) {
+	return &s3.GetBucketVersioningOutput{Status: to.Strp(m.versioning)}, m.denied()
+}
+
+func (m *bucketS3) PutObject(in *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	if m.deniedPaths[*in.Key] {
+		return nil, awserr.New("AccessDenied", "denied", nil)
+	}
+	return nil, awserr.New("BadDigest", "digest", nil)
+}
+
+var paths = []string{"account/project/config/release-1/release", "account/project/config/release-1/userdata"}
+
+func Test_Preflight(t *testing.T) {
+	warnings, err := Preflight(newBucketS3(), "coinbase-odin-account", "us-west-2", paths)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(warnings))
+}
+
+func Test_Preflight_Missing(t *testing.T) {
+	s3c := newBucketS3()
+	s3c.headErr = "NotFound"
+
+	_, err := Preflight(s3c, "coinbase-odin-account", "us-west-2", paths)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "does not exist")
+
+	s3c.headErr = "Forbidden"
+	_, err = Preflight(s3c, "coinbase-odin-account", "us-west-2", paths)
+	assert.Contains(t, err.Error(), "s3:ListBucket")
+}
+
+func Test_Preflight_Problems(t *testing.T) {
+	s3c := newBucketS3()
+	s3c.location = ""
+	s3c.encryption = ""
+	s3c.publicAccess.RestrictPublicBuckets = to.Boolp(false)
+	s3c.versioning = "Suspended"
+	s3c.deniedPaths[paths[1]] = true
+
+	warnings, err := Preflight(s3c, "coinbase-odin-account", "us-west-2", paths)
+	assert.Error(t, err)
+
+	// Every problem is reported at once
+	problems := err.(*PreflightError).Problems
+	assert.Equal(t, 4, len(problems))
+	assert.Contains(t, problems[0], "is in us-east-1 not us-west-2")
+	assert.Contains(t, problems[1], "no default encryption")
+	assert.Contains(t, problems[2], "enable RestrictPublicBuckets")
+	assert.Contains(t, problems[3], "s3:PutObject on arn:aws:s3:::coinbase-odin-account/"+paths[1])
+
+	assert.Equal(t, 1, len(warnings))
+	assert.Contains(t, warnings[0], "versioning is not enabled")
+
+	s3c.publicAccess = nil
+	_, err = Preflight(s3c, "coinbase-odin-account", "us-west-2", nil)
+	assert.Contains(t, err.Error(), "enable all four settings")
+}
+
+func Test_Preflight_ConfigDenied(t *testing.T) {
+	s3c := newBucketS3()
+	s3c.configDenied = true
+
+	// Settings the caller cannot read are not checked
+	warnings, err := Preflight(s3c, "coinbase-odin-account", "us-west-2", paths)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(warnings))
+	assert.Contains(t, warnings[1], "s3:GetEncryptionConfiguration")
+}
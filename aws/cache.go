@@ -0,0 +1,194 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	"github.com/coinbase/step/utils/to"
+)
+
+// CacheTTL is how long a describe response is reused, long enough to cover the polls of a health check
+// but short enough that resources changed during a long deploy are seen
+const CacheTTL = 5 * time.Minute
+
+// describeCache holds the responses of describe calls whose resources rarely change during a deploy:
+// subnets, security groups, load balancers, target groups, instance types and their prices.
+// It only caches within a scope, the execution being handled, so clients outside the deployer always call AWS
+var describeCache = &callCache{entries: map[string]*cacheEntry{}, now: time.Now}
+
+type callCache struct {
+	sync.Mutex
+	scope   string
+	entries map[string]*cacheEntry
+	now     func() time.Time
+}
+
+type cacheEntry struct {
+	output  interface{}
+	expires time.Time
+}
+
+// CacheScope caches describe calls for the execution, clearing those of any other execution
+// A Lambda handles one event at a time, so only the current execution is ever cached
+func CacheScope(scope string) {
+	describeCache.Lock()
+	defer describeCache.Unlock()
+
+	if scope != describeCache.scope {
+		describeCache.scope = scope
+		describeCache.entries = map[string]*cacheEntry{}
+	}
+}
+
+// cached returns the unexpired output of the call with the input, otherwise makes it and caches its output
+// Errors are never cached, and callers must not modify the output as it is shared
+func (c *callCache) cached(key string, input interface{}, call func() (interface{}, error)) (interface{}, error) {
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return call()
+	}
+	key = fmt.Sprintf("%v %s", key, raw)
+
+	c.Lock()
+	scope := c.scope
+	entry := c.entries[key]
+	c.Unlock()
+
+	if scope == "" {
+		return call()
+	}
+
+	if entry != nil && c.now().Before(entry.expires) {
+		return entry.output, nil
+	}
+
+	output, err := call()
+	if err != nil {
+		return nil, err
+	}
+
+	c.Lock()
+	if c.scope == scope {
+		c.entries[key] = &cacheEntry{output: output, expires: c.now().Add(CacheTTL)}
+	}
+	c.Unlock()
+
+	return output, nil
+}
+
+// cacheKey is the prefix of a clients calls, responses differ between regions, accounts and roles
+func cacheKey(service string, region *string, accountID *string, role *string) string {
+	return fmt.Sprintf("%v/%v/%v/%v", service, to.Strs(region), to.Strs(accountID), to.Strs(role))
+}
+
+// cachedEC2 caches the subnets, security groups and instance types
+type cachedEC2 struct {
+	EC2API
+	key string
+}
+
+// DescribeSubnets returns
+func (c *cachedEC2) DescribeSubnets(in *ec2.DescribeSubnetsInput) (*ec2.DescribeSubnetsOutput, error) {
+	out, err := describeCache.cached(c.key+" DescribeSubnets", in, func() (interface{}, error) {
+		return c.EC2API.DescribeSubnets(in)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.(*ec2.DescribeSubnetsOutput), nil
+}
+
+// DescribeSecurityGroups returns
+func (c *cachedEC2) DescribeSecurityGroups(in *ec2.DescribeSecurityGroupsInput) (*ec2.DescribeSecurityGroupsOutput, error) {
+	out, err := describeCache.cached(c.key+" DescribeSecurityGroups", in, func() (interface{}, error) {
+		return c.EC2API.DescribeSecurityGroups(in)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.(*ec2.DescribeSecurityGroupsOutput), nil
+}
+
+// DescribeInstanceTypes returns
+func (c *cachedEC2) DescribeInstanceTypes(in *ec2.DescribeInstanceTypesInput) (*ec2.DescribeInstanceTypesOutput, error) {
+	out, err := describeCache.cached(c.key+" DescribeInstanceTypes", in, func() (interface{}, error) {
+		return c.EC2API.DescribeInstanceTypes(in)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.(*ec2.DescribeInstanceTypesOutput), nil
+}
+
+// cachedELB caches the load balancers, not their instances health
+type cachedELB struct {
+	ELBAPI
+	key string
+}
+
+// DescribeLoadBalancers returns
+func (c *cachedELB) DescribeLoadBalancers(in *elb.DescribeLoadBalancersInput) (*elb.DescribeLoadBalancersOutput, error) {
+	out, err := describeCache.cached(c.key+" DescribeLoadBalancers", in, func() (interface{}, error) {
+		return c.ELBAPI.DescribeLoadBalancers(in)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.(*elb.DescribeLoadBalancersOutput), nil
+}
+
+// cachedALB caches the target groups, not their targets health
+type cachedALB struct {
+	ALBAPI
+	key string
+}
+
+// DescribeTargetGroups returns
+func (c *cachedALB) DescribeTargetGroups(in *elbv2.DescribeTargetGroupsInput) (*elbv2.DescribeTargetGroupsOutput, error) {
+	out, err := describeCache.cached(c.key+" DescribeTargetGroups", in, func() (interface{}, error) {
+		return c.ALBAPI.DescribeTargetGroups(in)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.(*elbv2.DescribeTargetGroupsOutput), nil
+}
+
+// cachedPricing caches the prices of instance types and volumes
+type cachedPricing struct {
+	PricingAPI
+	key string
+}
+
+// GetProducts returns
+func (c *cachedPricing) GetProducts(in *pricing.GetProductsInput) (*pricing.GetProductsOutput, error) {
+	out, err := describeCache.cached(c.key+" GetProducts", in, func() (interface{}, error) {
+		return c.PricingAPI.GetProducts(in)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.(*pricing.GetProductsOutput), nil
+}
+
+// UncachedEC2 returns the client a cached EC2 client wraps, for callers that need the SDK client
+func UncachedEC2(ec2c EC2API) EC2API {
+	if c, ok := ec2c.(*cachedEC2); ok {
+		return c.EC2API
+	}
+	return ec2c
+}
+
+// UncachedALB returns the client a cached ELBv2 client wraps, for callers that need the SDK client
+func UncachedALB(albc ALBAPI) ALBAPI {
+	if c, ok := albc.(*cachedALB); ok {
+		return c.ALBAPI
+	}
+	return albc
+}
@@ -0,0 +1,81 @@
+package aws
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingEC2 struct {
+	EC2API
+	calls int
+	err   error
+}
+
+func (c *countingEC2) DescribeSubnets(in *ec2.DescribeSubnetsInput) (*ec2.DescribeSubnetsOutput, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{{SubnetId: to.Strp("subnet-1")}}}, nil
+}
+
+func Test_CachedEC2(t *testing.T) {
+	now := time.Now()
+	describeCache.now = func() time.Time { return now }
+	defer func() { describeCache.now = time.Now }()
+	defer CacheScope("")
+
+	counting := &countingEC2{}
+	ec2c := &cachedEC2{EC2API: counting, key: cacheKey("ec2", to.Strp("us-east-1"), nil, nil)}
+	in := &ec2.DescribeSubnetsInput{SubnetIds: []*string{to.Strp("subnet-1")}}
+
+	// Without a scope every call is made
+	CacheScope("")
+	ec2c.DescribeSubnets(in)
+	ec2c.DescribeSubnets(in)
+	assert.Equal(t, 2, counting.calls)
+
+	CacheScope("release/uuid")
+	out, err := ec2c.DescribeSubnets(in)
+	assert.NoError(t, err)
+	assert.Equal(t, "subnet-1", *out.Subnets[0].SubnetId)
+	ec2c.DescribeSubnets(in)
+	assert.Equal(t, 3, counting.calls)
+
+	// Other inputs are cached separately
+	ec2c.DescribeSubnets(&ec2.DescribeSubnetsInput{SubnetIds: []*string{to.Strp("subnet-2")}})
+	assert.Equal(t, 4, counting.calls)
+
+	// Until they expire
+	now = now.Add(CacheTTL)
+	ec2c.DescribeSubnets(in)
+	assert.Equal(t, 5, counting.calls)
+
+	// Or another execution is handled
+	CacheScope("other/uuid")
+	ec2c.DescribeSubnets(in)
+	assert.Equal(t, 6, counting.calls)
+}
+
+func Test_CachedEC2_Errors(t *testing.T) {
+	defer CacheScope("")
+	CacheScope("release/uuid")
+
+	counting := &countingEC2{err: fmt.Errorf("Throttling")}
+	ec2c := &cachedEC2{EC2API: counting, key: cacheKey("ec2", nil, nil, nil)}
+
+	_, err := ec2c.DescribeSubnets(&ec2.DescribeSubnetsInput{})
+	assert.Error(t, err)
+	_, err = ec2c.DescribeSubnets(&ec2.DescribeSubnetsInput{})
+	assert.Error(t, err)
+	assert.Equal(t, 2, counting.calls)
+
+	// The client can be unwrapped
+	assert.Equal(t, counting, UncachedEC2(ec2c))
+	assert.Equal(t, counting, UncachedEC2(counting))
+}
@@ -0,0 +1,36 @@
+package aws
+
+import (
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// The AWS requests sent by ClientsStr clients, a Lambda handles one event at a time so these are per handler
+var (
+	callCount  int64
+	retryCount int64
+)
+
+// ResetCalls zeros the count of AWS calls and retries
+func ResetCalls() {
+	atomic.StoreInt64(&callCount, 0)
+	atomic.StoreInt64(&retryCount, 0)
+}
+
+// Calls returns the count of AWS calls, and of their retries, since the last ResetCalls
+func Calls() (int64, int64) {
+	return atomic.LoadInt64(&callCount), atomic.LoadInt64(&retryCount)
+}
+
+// countCalls counts every request the client sends, a retried request is counted once as a call
+func countCalls(c *client.Client) {
+	c.Handlers.Send.PushFront(func(r *request.Request) {
+		if r.RetryCount > 0 {
+			atomic.AddInt64(&retryCount, 1)
+			return
+		}
+		atomic.AddInt64(&callCount, 1)
+	})
+}
@@ -0,0 +1,93 @@
+package ecr
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// PullActions are the actions an instance profile requires to pull from a repository
+var PullActions = []*string{
+	to.Strp("ecr:BatchCheckLayerAvailability"),
+	to.Strp("ecr:BatchGetImage"),
+	to.Strp("ecr:GetDownloadUrlForLayer"),
+}
+
+// AuthActions are the actions an instance profile requires to login to a registry
+var AuthActions = []*string{
+	to.Strp("ecr:GetAuthorizationToken"),
+}
+
+// e.g. 000000000000.dkr.ecr.us-east-1.amazonaws.com/org/repo:tag
+var imageRegex = regexp.MustCompile(`^(\d{12})\.dkr\.ecr\.([a-z0-9-]+)\.amazonaws\.com/([a-z0-9][a-z0-9._/-]*)(:[\w][\w.-]*|@sha256:[a-f0-9]{64})?$`)
+
+// Image struct
+type Image struct {
+	URI        *string
+	AccountID  *string
+	Region     *string
+	Repository *string
+}
+
+// Parse returns the Image for an ECR image URI
+func Parse(uri *string) (*Image, error) {
+	if uri == nil {
+		return nil, fmt.Errorf("Container image is nil")
+	}
+
+	matches := imageRegex.FindStringSubmatch(*uri)
+	if matches == nil {
+		return nil, fmt.Errorf("Container image %q is not an ECR image", *uri)
+	}
+
+	return &Image{
+		URI:        uri,
+		AccountID:  to.Strp(matches[1]),
+		Region:     to.Strp(matches[2]),
+		Repository: to.Strp(matches[3]),
+	}, nil
+}
+
+// Registry returns the registry host of the image
+func (im *Image) Registry() *string {
+	return to.Strp(fmt.Sprintf("%v.dkr.ecr.%v.amazonaws.com", *im.AccountID, *im.Region))
+}
+
+// RepositoryArn returns the ARN of the images repository
+func (im *Image) RepositoryArn() *string {
+	return to.Strp(fmt.Sprintf("arn:aws:ecr:%v:%v:repository/%v", *im.Region, *im.AccountID, *im.Repository))
+}
+
+// PrePullScript returns shell commands that login to the registry and pull the image
+func (im *Image) PrePullScript() string {
+	login := fmt.Sprintf("aws ecr get-login-password --region %v | docker login --username AWS --password-stdin %v", *im.Region, *im.Registry())
+	return fmt.Sprintf("%v\ndocker pull %v\n", login, *im.URI)
+}
+
+// Published returns whether the image was pushed to its repository with the digest, "sha256:<hex>"
+func (im *Image) Published(ecrc aws.ECRAPI, digest *string) (bool, error) {
+	out, err := ecrc.DescribeImages(&ecr.DescribeImagesInput{
+		RegistryId:     im.AccountID,
+		RepositoryName: im.Repository,
+		ImageIds:       []*ecr.ImageIdentifier{&ecr.ImageIdentifier{ImageDigest: digest}},
+	})
+
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == ecr.ErrCodeImageNotFoundException {
+			return false, nil
+		}
+		return false, err
+	}
+
+	for _, image := range out.ImageDetails {
+		if to.Strs(image.ImageDigest) == to.Strs(digest) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,54 @@
+package ecr
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Parse(t *testing.T) {
+	im, err := Parse(to.Strp("000000000000.dkr.ecr.us-east-1.amazonaws.com/org/repo:v1.0"))
+	assert.NoError(t, err)
+	assert.Equal(t, "000000000000", *im.AccountID)
+	assert.Equal(t, "us-east-1", *im.Region)
+	assert.Equal(t, "org/repo", *im.Repository)
+	assert.Equal(t, "000000000000.dkr.ecr.us-east-1.amazonaws.com", *im.Registry())
+	assert.Equal(t, "arn:aws:ecr:us-east-1:000000000000:repository/org/repo", *im.RepositoryArn())
+
+	_, err = Parse(to.Strp("000000000000.dkr.ecr.us-east-1.amazonaws.com/repo"))
+	assert.NoError(t, err)
+
+	_, err = Parse(nil)
+	assert.Error(t, err)
+
+	_, err = Parse(to.Strp("nginx:latest"))
+	assert.Error(t, err)
+
+	_, err = Parse(to.Strp("docker.io/library/nginx"))
+	assert.Error(t, err)
+}
+
+func Test_PrePullScript(t *testing.T) {
+	im, err := Parse(to.Strp("000000000000.dkr.ecr.us-east-1.amazonaws.com/repo:v1"))
+	assert.NoError(t, err)
+	assert.Regexp(t, "docker login", im.PrePullScript())
+	assert.Regexp(t, "docker pull 000000000000.dkr.ecr.us-east-1.amazonaws.com/repo:v1", im.PrePullScript())
+}
+
+func Test_Image_Published(t *testing.T) {
+	ecrc := &mocks.ECRClient{}
+	ecrc.AddImage("org/repo", "sha256:abc")
+
+	im, err := Parse(to.Strp("000000000000.dkr.ecr.us-east-1.amazonaws.com/org/repo:v1.0"))
+	assert.NoError(t, err)
+
+	published, err := im.Published(ecrc, to.Strp("sha256:abc"))
+	assert.NoError(t, err)
+	assert.True(t, published)
+
+	published, err = im.Published(ecrc, to.Strp("sha256:def"))
+	assert.NoError(t, err)
+	assert.False(t, published)
+}
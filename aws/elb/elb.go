@@ -2,6 +2,7 @@ package elb
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	aws_elb "github.com/aws/aws-sdk-go/service/elb"
@@ -15,6 +16,7 @@ type LoadBalancer struct {
 	ConfigNameTag    *string
 	ServiceNameTag   *string
 	LoadBalancerName *string
+	VpcID            *string
 }
 
 // ProjectName returns tag
@@ -86,6 +88,75 @@ func instanceStates(elbc aws.ELBAPI, name *string, instances []string) ([]*aws_e
 	return healthOutput.InstanceStates, nil
 }
 
+///////
+// Attributes
+///////
+
+// Attributes returns the access log and idle timeout attributes of the ELB
+// They are keyed the same as ALB attributes, e.g. access_logs.s3.bucket
+func Attributes(elbc aws.ELBAPI, name *string) (map[string]string, error) {
+	out, err := elbc.DescribeLoadBalancerAttributes(&aws_elb.DescribeLoadBalancerAttributesInput{
+		LoadBalancerName: name,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := map[string]string{}
+	if lba := out.LoadBalancerAttributes; lba != nil {
+		if al := lba.AccessLog; al != nil {
+			attrs["access_logs.s3.enabled"] = fmt.Sprintf("%v", al.Enabled != nil && *al.Enabled)
+			attrs["access_logs.s3.bucket"] = to.Strs(al.S3BucketName)
+			attrs["access_logs.s3.prefix"] = to.Strs(al.S3BucketPrefix)
+		}
+
+		if cs := lba.ConnectionSettings; cs != nil && cs.IdleTimeout != nil {
+			attrs["idle_timeout.timeout_seconds"] = fmt.Sprintf("%v", *cs.IdleTimeout)
+		}
+	}
+
+	return attrs, nil
+}
+
+// SetAttributes modifies the access log and idle timeout attributes of the ELB
+// Attributes not given keep their current value
+func SetAttributes(elbc aws.ELBAPI, name *string, attrs map[string]string) error {
+	current, err := Attributes(elbc, name)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range attrs {
+		current[k] = v
+	}
+
+	lba := &aws_elb.LoadBalancerAttributes{}
+
+	if bucket := current["access_logs.s3.bucket"]; bucket != "" {
+		lba.AccessLog = &aws_elb.AccessLog{
+			Enabled:        to.Boolp(current["access_logs.s3.enabled"] == "true"),
+			S3BucketName:   to.Strp(bucket),
+			S3BucketPrefix: to.Strp(current["access_logs.s3.prefix"]),
+		}
+	}
+
+	if timeout := current["idle_timeout.timeout_seconds"]; timeout != "" {
+		seconds, err := strconv.ParseInt(timeout, 10, 64)
+		if err != nil {
+			return err
+		}
+		lba.ConnectionSettings = &aws_elb.ConnectionSettings{IdleTimeout: &seconds}
+	}
+
+	_, err = elbc.ModifyLoadBalancerAttributes(&aws_elb.ModifyLoadBalancerAttributesInput{
+		LoadBalancerName:       name,
+		LoadBalancerAttributes: lba,
+	})
+
+	return err
+}
+
 ///////
 // Find
 ///////
@@ -123,6 +194,7 @@ func find(elbc aws.ELBAPI, name *string) (*LoadBalancer, error) {
 		ConfigNameTag:    aws.FetchELBTag(tags, to.Strp("ConfigName")),
 		ServiceNameTag:   aws.FetchELBTag(tags, to.Strp("ServiceName")),
 		LoadBalancerName: elbDesc.LoadBalancerName,
+		VpcID:            elbDesc.VPCId,
 	}, nil
 }
 
@@ -0,0 +1,123 @@
+package envelope
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Version marks a document as sealed, so it is not mistaken for the plaintext document
+const Version = "odin-envelope-v1"
+
+// Envelope is a document encrypted with a KMS data key, the data key is encrypted with the KMS key
+type Envelope struct {
+	Version      string  `json:"envelope"`
+	KeyID        *string `json:"key_id"` // ARN of the KMS key
+	EncryptedKey []byte  `json:"encrypted_key"`
+	Nonce        []byte  `json:"nonce"`
+	Ciphertext   []byte  `json:"ciphertext"` // AES-256-GCM
+}
+
+// Seal encrypts the plaintext with a new data key from the KMS key
+// The context must be given again to open it, so a sealed document cannot be moved to where another is expected
+func Seal(kmsc aws.KMSAPI, keyID string, context map[string]*string, plaintext []byte) ([]byte, error) {
+	dataKey, err := kmsc.GenerateDataKey(&kms.GenerateDataKeyInput{
+		KeyId:             &keyID,
+		KeySpec:           to.Strp(kms.DataKeySpecAes256),
+		EncryptionContext: context,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer zero(dataKey.Plaintext)
+
+	gcm, err := newGCM(dataKey.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(&Envelope{
+		Version:      Version,
+		KeyID:        dataKey.KeyId,
+		EncryptedKey: dataKey.CiphertextBlob,
+		Nonce:        nonce,
+		Ciphertext:   gcm.Seal(nil, nonce, plaintext, nil),
+	})
+}
+
+// Open decrypts a sealed document with the context it was sealed with
+// If keyID is given KMS only decrypts data keys of that key
+func Open(kmsc aws.KMSAPI, keyID *string, context map[string]*string, sealed []byte) ([]byte, error) {
+	var envelope Envelope
+	if err := json.Unmarshal(sealed, &envelope); err != nil || envelope.Version != Version {
+		return nil, fmt.Errorf("Envelope invalid")
+	}
+
+	dataKey, err := kmsc.Decrypt(&kms.DecryptInput{
+		KeyId:             keyID,
+		CiphertextBlob:    envelope.EncryptedKey,
+		EncryptionContext: context,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("Error decrypting data key of %v with %v", to.Strs(envelope.KeyID), err.Error())
+	}
+
+	defer zero(dataKey.Plaintext)
+
+	gcm, err := newGCM(dataKey.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(envelope.Nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("Envelope nonce invalid")
+	}
+
+	plaintext, err := gcm.Open(nil, envelope.Nonce, envelope.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Envelope ciphertext invalid")
+	}
+
+	return plaintext, nil
+}
+
+// IsSealed returns whether the document is an envelope
+func IsSealed(raw []byte) bool {
+	// Cheap check first, documents are read whole anyway
+	if !bytes.Contains(raw, []byte(Version)) {
+		return false
+	}
+
+	var envelope Envelope
+	return json.Unmarshal(raw, &envelope) == nil && envelope.Version == Version
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("Data key invalid %v", err.Error())
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
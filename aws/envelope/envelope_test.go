@@ -0,0 +1,45 @@
+package envelope
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Seal_Open(t *testing.T) {
+	kmsc := &mocks.KMSClient{}
+	context := map[string]*string{"key": to.Strp("project/config/release-1/release")}
+
+	sealed, err := Seal(kmsc, "alias/odin-releases", context, []byte(`{"secret": "value"}`))
+	assert.NoError(t, err)
+	assert.True(t, IsSealed(sealed))
+	assert.NotContains(t, string(sealed), "secret")
+
+	plaintext, err := Open(kmsc, nil, context, sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"secret": "value"}`, string(plaintext))
+
+	plaintext, err = Open(kmsc, to.Strp("alias/odin-releases"), context, sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"secret": "value"}`, string(plaintext))
+
+	// Another key or context
+	_, err = Open(kmsc, to.Strp("alias/other"), context, sealed)
+	assert.Error(t, err)
+
+	_, err = Open(kmsc, nil, map[string]*string{"key": to.Strp("project/config/release-2/release")}, sealed)
+	assert.Error(t, err)
+
+	// Changed ciphertext
+	tampered := []byte(strings.Replace(string(sealed), `"ciphertext":"`, `"ciphertext":"AAAA`, 1))
+	_, err = Open(kmsc, nil, context, tampered)
+	assert.Error(t, err)
+
+	assert.False(t, IsSealed([]byte(`{"project_name": "project"}`)))
+
+	_, err = Open(kmsc, nil, context, []byte(`{"project_name": "project"}`))
+	assert.Error(t, err)
+}
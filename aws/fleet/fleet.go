@@ -0,0 +1,94 @@
+package fleet
+
+import (
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/alb"
+	"github.com/coinbase/odin/aws/elb"
+	"github.com/coinbase/step/utils/to"
+)
+
+// BatchSize is the most instance IDs sent in one request.
+// DescribeInstances and DescribeInstanceHealth reject or truncate requests with too many IDs,
+// so large fleets are described a batch at a time
+const BatchSize = 100
+
+// Fleet is the IDs of a services instances, described in batches with every page of every response read
+type Fleet []string
+
+// New returns the fleet of the instances
+func New(instances aws.Instances) Fleet {
+	return Fleet(instances.InstanceIDs())
+}
+
+// Batches splits the fleet into batches of at most size instances, an empty fleet has no batches
+func (f Fleet) Batches(size int) [][]string {
+	batches := [][]string{}
+	for start := 0; start < len(f); start += size {
+		end := start + size
+		if end > len(f) {
+			end = len(f)
+		}
+		batches = append(batches, f[start:end])
+	}
+	return batches
+}
+
+// EC2Instances calls fn with each of the fleets EC2 instances
+func (f Fleet) EC2Instances(ec2c aws.EC2API, fn func(*ec2.Instance)) error {
+	for _, batch := range f.Batches(BatchSize) {
+		ids := []*string{}
+		for _, id := range batch {
+			ids = append(ids, to.Strp(id))
+		}
+
+		err := ec2c.DescribeInstancesPages(&ec2.DescribeInstancesInput{
+			InstanceIds: ids,
+		}, func(page *ec2.DescribeInstancesOutput, _ bool) bool {
+			for _, r := range page.Reservations {
+				for _, i := range r.Instances {
+					fn(i)
+				}
+			}
+			return true
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ELBInstances returns the health of the fleets instances in the ELB
+func (f Fleet) ELBInstances(elbc aws.ELBAPI, name *string) (aws.Instances, error) {
+	all := aws.Instances{}
+	for _, batch := range f.Batches(BatchSize) {
+		instances, err := elb.GetInstances(elbc, name, batch)
+		if err != nil {
+			return nil, err
+		}
+
+		for id, state := range instances {
+			all[id] = state
+		}
+	}
+	return all, nil
+}
+
+// TargetGroupInstances returns the health of the fleets instances in the target group
+func (f Fleet) TargetGroupInstances(albc aws.ALBAPI, arn *string) (aws.Instances, error) {
+	all := aws.Instances{}
+	for _, batch := range f.Batches(BatchSize) {
+		instances, err := alb.GetInstances(albc, arn, batch)
+		if err != nil {
+			return nil, err
+		}
+
+		for id, state := range instances {
+			all[id] = state
+		}
+	}
+	return all, nil
+}
@@ -0,0 +1,137 @@
+package fleet
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+const fleetSize = 750
+
+// pagedEC2 rejects requests with more than BatchSize IDs like EC2, and returns 40 instances a page
+type pagedEC2 struct {
+	aws.EC2API
+	requests int
+}
+
+func (m *pagedEC2) DescribeInstancesPages(in *ec2.DescribeInstancesInput, fn func(*ec2.DescribeInstancesOutput, bool) bool) error {
+	m.requests++
+	if len(in.InstanceIds) > BatchSize {
+		return fmt.Errorf("Too many instance IDs")
+	}
+
+	for start := 0; start < len(in.InstanceIds); start += 40 {
+		end := start + 40
+		if end > len(in.InstanceIds) {
+			end = len(in.InstanceIds)
+		}
+
+		page := &ec2.DescribeInstancesOutput{Reservations: []*ec2.Reservation{{}}}
+		for _, id := range in.InstanceIds[start:end] {
+			page.Reservations[0].Instances = append(page.Reservations[0].Instances, &ec2.Instance{InstanceId: id})
+		}
+
+		if !fn(page, end == len(in.InstanceIds)) {
+			break
+		}
+	}
+	return nil
+}
+
+// truncatingELB returns the health of at most BatchSize of the requested instances
+type truncatingELB struct {
+	aws.ELBAPI
+}
+
+func (m *truncatingELB) DescribeInstanceHealth(in *elb.DescribeInstanceHealthInput) (*elb.DescribeInstanceHealthOutput, error) {
+	out := &elb.DescribeInstanceHealthOutput{}
+	for i, instance := range in.Instances {
+		if i == BatchSize {
+			break
+		}
+		out.InstanceStates = append(out.InstanceStates, &elb.InstanceState{InstanceId: instance.InstanceId, State: to.Strp("InService")})
+	}
+	return out, nil
+}
+
+type truncatingALB struct {
+	aws.ALBAPI
+}
+
+func (m *truncatingALB) DescribeTargetHealth(in *elbv2.DescribeTargetHealthInput) (*elbv2.DescribeTargetHealthOutput, error) {
+	out := &elbv2.DescribeTargetHealthOutput{}
+	for i, target := range in.Targets {
+		if i == BatchSize {
+			break
+		}
+		out.TargetHealthDescriptions = append(out.TargetHealthDescriptions, &elbv2.TargetHealthDescription{
+			Target:       &elbv2.TargetDescription{Id: target.Id},
+			TargetHealth: &elbv2.TargetHealth{State: to.Strp("healthy")},
+		})
+	}
+	return out, nil
+}
+
+func mockFleet() Fleet {
+	instances := aws.Instances{}
+	for i := 0; i < fleetSize; i++ {
+		instances.AddHealthCheckInstance(fmt.Sprintf("i-%v", i), true)
+	}
+	return New(instances)
+}
+
+func Test_Fleet_Batches(t *testing.T) {
+	fl := mockFleet()
+
+	batches := fl.Batches(BatchSize)
+	assert.Equal(t, 8, len(batches))
+	assert.Equal(t, BatchSize, len(batches[0]))
+	assert.Equal(t, fleetSize%BatchSize, len(batches[7]))
+
+	assert.Equal(t, 0, len(Fleet{}.Batches(BatchSize)))
+}
+
+func Test_Fleet_EC2Instances(t *testing.T) {
+	fl := mockFleet()
+	ec2c := &pagedEC2{}
+
+	seen := map[string]bool{}
+	assert.NoError(t, fl.EC2Instances(ec2c, func(i *ec2.Instance) {
+		seen[*i.InstanceId] = true
+	}))
+
+	assert.Equal(t, fleetSize, len(seen))
+	assert.Equal(t, 8, ec2c.requests)
+
+	// An empty fleet does not describe every instance in the account
+	ec2c.requests = 0
+	assert.NoError(t, Fleet{}.EC2Instances(ec2c, func(*ec2.Instance) {}))
+	assert.Equal(t, 0, ec2c.requests)
+}
+
+func Test_Fleet_Health(t *testing.T) {
+	fl := mockFleet()
+	all := aws.Instances{}
+	for _, id := range fl {
+		all.AddHealthCheckInstance(id, true)
+	}
+
+	elbInstances, err := fl.ELBInstances(&truncatingELB{}, to.Strp("elb"))
+	assert.NoError(t, err)
+	assert.Equal(t, fleetSize, len(elbInstances.HealthyIDs()))
+
+	tgInstances, err := fl.TargetGroupInstances(&truncatingALB{}, to.Strp("arn"))
+	assert.NoError(t, err)
+	assert.Equal(t, fleetSize, len(tgInstances.HealthyIDs()))
+
+	// No instance is missing from the merged health, so none is unhealthy
+	merged := all.MergeInstances(elbInstances).MergeInstances(tgInstances)
+	assert.Equal(t, fleetSize, len(merged.HealthyIDs()))
+	assert.Equal(t, 0, len(merged.UnhealthyIDs()))
+}
@@ -0,0 +1,308 @@
+package gc
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/asg"
+	"github.com/coinbase/odin/aws/lc"
+	"github.com/coinbase/odin/aws/lt"
+	"github.com/coinbase/step/utils/is"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Launch configurations and templates are named <project>-<config>-<created_at>-<service>
+var odinName = regexp.MustCompile(`-\d{4}-\d{2}-\d{2}T\d{2}-\d{2}-\d{2}Z-`)
+
+// LaunchTemplateVersions old versions of a launch template that is still used
+type LaunchTemplateVersions struct {
+	LaunchTemplateName *string
+	Versions           []*string
+}
+
+// Orphans are resources left behind by failed or interrupted releases
+type Orphans struct {
+	ASGs                   []*asg.ASG
+	LaunchConfigurations   []*string
+	LaunchTemplates        []*string
+	LaunchTemplateVersions []*LaunchTemplateVersions
+}
+
+//////
+// Find
+//////
+
+// Find returns the orphaned resources created before the cutoff
+// Only resources odin created and that nothing uses are returned: ASGs and launch templates by their tags,
+// and launch configurations, which cannot be tagged, by being named after the ID of a service in serviceIDs,
+// the release history in the releases bucket
+func Find(asgc aws.ASGAPI, ec2c aws.EC2API, cutoff time.Time, serviceIDs map[string]bool) (*Orphans, error) {
+	asgs, err := asg.All(asgc)
+	if err != nil {
+		return nil, err
+	}
+
+	usedLCs, usedLTs := map[string]bool{}, map[string]bool{}
+	for _, a := range asgs {
+		if a.LaunchConfigurationName != nil {
+			usedLCs[*a.LaunchConfigurationName] = true
+		}
+
+		if a.LaunchTemplateName != nil {
+			usedLTs[*a.LaunchTemplateName] = true
+		}
+	}
+
+	orphans := &Orphans{ASGs: detachedASGs(asgs, cutoff)}
+
+	// Detached ASGs are deleted with their launch configuration or template
+	for _, a := range orphans.ASGs {
+		if a.LaunchConfigurationName != nil {
+			usedLCs[*a.LaunchConfigurationName] = true
+		}
+
+		if a.LaunchTemplateName != nil {
+			usedLTs[*a.LaunchTemplateName] = true
+		}
+	}
+
+	if orphans.LaunchConfigurations, err = unusedLaunchConfigurations(asgc, usedLCs, cutoff, serviceIDs); err != nil {
+		return nil, err
+	}
+
+	if orphans.LaunchTemplates, orphans.LaunchTemplateVersions, err = unusedLaunchTemplates(ec2c, usedLTs, cutoff); err != nil {
+		return nil, err
+	}
+
+	return orphans, nil
+}
+
+// detachedASGs returns the ASGs that are no longer attached to their load balancers
+// while a different release of the same service is, e.g. when deleting them failed during clean up
+// Both must be created before the cutoff so ASGs being swapped by a running deploy are never returned
+func detachedASGs(asgs []*asg.ASG, cutoff time.Time) []*asg.ASG {
+	serving := map[string]map[string]bool{}
+	for _, a := range asgs {
+		if !odinASG(a) || !a.Attached() || !before(a.CreatedTime, cutoff) {
+			continue
+		}
+
+		key := serviceKey(a)
+		if serving[key] == nil {
+			serving[key] = map[string]bool{}
+		}
+		serving[key][*a.ReleaseID()] = true
+	}
+
+	detached := []*asg.ASG{}
+	for _, a := range asgs {
+		if !odinASG(a) || a.Attached() || !before(a.CreatedTime, cutoff) {
+			continue
+		}
+
+		for releaseID := range serving[serviceKey(a)] {
+			if releaseID != *a.ReleaseID() {
+				detached = append(detached, a)
+				break
+			}
+		}
+	}
+
+	sort.Slice(detached, func(i, j int) bool {
+		return *detached[i].AutoScalingGroupName < *detached[j].AutoScalingGroupName
+	})
+
+	return detached
+}
+
+func unusedLaunchConfigurations(asgc aws.ASGAPI, used map[string]bool, cutoff time.Time, serviceIDs map[string]bool) ([]*string, error) {
+	names := []*string{}
+
+	pagefn := func(page *autoscaling.DescribeLaunchConfigurationsOutput, lastPage bool) bool {
+		for _, config := range page.LaunchConfigurations {
+			if released(config.LaunchConfigurationName, serviceIDs) && unused(config.LaunchConfigurationName, config.CreatedTime, used, cutoff) {
+				names = append(names, config.LaunchConfigurationName)
+			}
+		}
+		return !lastPage
+	}
+
+	err := asgc.DescribeLaunchConfigurationsPages(&autoscaling.DescribeLaunchConfigurationsInput{}, pagefn)
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+func unusedLaunchTemplates(ec2c aws.EC2API, used map[string]bool, cutoff time.Time) ([]*string, []*LaunchTemplateVersions, error) {
+	names := []*string{}
+	oldVersions := []*LaunchTemplateVersions{}
+
+	input := &ec2.DescribeLaunchTemplatesInput{}
+	for {
+		output, err := ec2c.DescribeLaunchTemplates(input)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, template := range output.LaunchTemplates {
+			if !odinLaunchTemplate(template) {
+				continue
+			}
+
+			if unused(template.LaunchTemplateName, template.CreateTime, used, cutoff) {
+				names = append(names, template.LaunchTemplateName)
+				continue
+			}
+
+			if !used[to.Strs(template.LaunchTemplateName)] || template.LatestVersionNumber == nil || *template.LatestVersionNumber <= 1 {
+				continue
+			}
+
+			versions, err := oldLaunchTemplateVersions(ec2c, template, cutoff)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if len(versions) > 0 {
+				oldVersions = append(oldVersions, &LaunchTemplateVersions{
+					LaunchTemplateName: template.LaunchTemplateName,
+					Versions:           versions,
+				})
+			}
+		}
+
+		if output.NextToken == nil {
+			return names, oldVersions, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+// oldLaunchTemplateVersions returns the versions ASGs no longer launch from,
+// they launch from the latest version and the default version cannot be deleted
+func oldLaunchTemplateVersions(ec2c aws.EC2API, template *ec2.LaunchTemplate, cutoff time.Time) ([]*string, error) {
+	versions := []*string{}
+
+	input := &ec2.DescribeLaunchTemplateVersionsInput{LaunchTemplateName: template.LaunchTemplateName}
+	for {
+		output, err := ec2c.DescribeLaunchTemplateVersions(input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, version := range output.LaunchTemplateVersions {
+			if version.VersionNumber == nil || (version.DefaultVersion != nil && *version.DefaultVersion) {
+				continue
+			}
+
+			if *version.VersionNumber == *template.LatestVersionNumber || !before(version.CreateTime, cutoff) {
+				continue
+			}
+
+			versions = append(versions, to.Strp(fmt.Sprintf("%v", *version.VersionNumber)))
+		}
+
+		if output.NextToken == nil {
+			return versions, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+func odinASG(a *asg.ASG) bool {
+	return !is.EmptyStr(a.ProjectName()) && !is.EmptyStr(a.ConfigName()) &&
+		!is.EmptyStr(a.ServiceName()) && !is.EmptyStr(a.ReleaseID())
+}
+
+// odinLaunchTemplate returns whether the template has the tags odin gives the templates it creates
+func odinLaunchTemplate(template *ec2.LaunchTemplate) bool {
+	tags := map[string]string{}
+	for _, tag := range template.Tags {
+		tags[to.Strs(tag.Key)] = to.Strs(tag.Value)
+	}
+	return tags["ProjectName"] != "" && tags["ConfigName"] != "" && tags["ServiceName"] != ""
+}
+
+// released returns whether the launch configuration is named after a service ID,
+// launch configurations of services that fell back to another instance type have it as a suffix
+func released(name *string, serviceIDs map[string]bool) bool {
+	if name == nil {
+		return false
+	}
+
+	if serviceIDs[*name] {
+		return true
+	}
+
+	i := strings.LastIndex(*name, "-")
+	return i > 0 && serviceIDs[(*name)[:i]]
+}
+
+func serviceKey(a *asg.ASG) string {
+	return fmt.Sprintf("%v/%v/%v", *a.ProjectName(), *a.ConfigName(), *a.ServiceName())
+}
+
+func unused(name *string, createdAt *time.Time, used map[string]bool, cutoff time.Time) bool {
+	if name == nil || used[*name] || !odinName.MatchString(*name) {
+		return false
+	}
+	return before(createdAt, cutoff)
+}
+
+// before is false for unknown times to keep resources whose age is unknown
+func before(t *time.Time, cutoff time.Time) bool {
+	return t != nil && t.Before(cutoff)
+}
+
+//////
+// Destruction
+//////
+
+// Empty returns whether no orphans were found
+func (o *Orphans) Empty() bool {
+	return len(o.ASGs) == 0 && len(o.LaunchConfigurations) == 0 &&
+		len(o.LaunchTemplates) == 0 && len(o.LaunchTemplateVersions) == 0
+}
+
+// Delete deletes the orphans, detached ASGs are deleted with their launch configuration or template and alarms
+func (o *Orphans) Delete(asgc aws.ASGAPI, cwc aws.CWAPI, ec2c aws.EC2API) error {
+	for _, a := range o.ASGs {
+		if err := a.Delete(asgc, cwc); err != nil {
+			return err
+		}
+
+		if a.LaunchTemplateName != nil {
+			if err := lt.Teardown(ec2c, a.LaunchTemplateName); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, name := range o.LaunchConfigurations {
+		if err := lc.Teardown(asgc, name); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range o.LaunchTemplates {
+		if err := lt.Teardown(ec2c, name); err != nil {
+			return err
+		}
+	}
+
+	for _, ltv := range o.LaunchTemplateVersions {
+		if err := lt.TeardownVersions(ec2c, ltv.LaunchTemplateName, ltv.Versions); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package gc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/coinbase/odin/aws/lt"
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockASG(name string, project string, service string, releaseID string, createdAt time.Time, attached bool) *autoscaling.Group {
+	group := mocks.MakeMockASG(name, project, "config", service, releaseID)
+	group.CreatedTime = to.Timep(createdAt)
+	group.LaunchConfigurationName = to.Strp(name + "-2020-01-01T00-00-00Z-" + service)
+	if attached {
+		group.LoadBalancerNames = []*string{to.Strp("elb")}
+	}
+	return group
+}
+
+// tagOdin gives the launch template the tags odin creates it with
+func tagOdin(ec2c *mocks.EC2Client, name string, project string, service string) {
+	ec2c.TagLaunchTemplate(name, "ProjectName", project)
+	ec2c.TagLaunchTemplate(name, "ConfigName", "config")
+	ec2c.TagLaunchTemplate(name, "ServiceName", service)
+}
+
+func Test_Find_Delete(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-30 * 24 * time.Hour)
+	cutoff := now.Add(-7 * 24 * time.Hour)
+
+	asgc := &mocks.ASGClient{}
+	ec2c := &mocks.EC2Client{}
+
+	// Detached after a failed clean up
+	asgc.AddASG(mockASG("project-web-new", "project", "web", "new", old, true))
+	asgc.AddASG(mockASG("project-web-old", "project", "web", "old", old, false))
+
+	// A running deploy is swapping ASGs
+	asgc.AddASG(mockASG("running-web-old", "running", "web", "old", old, false))
+	asgc.AddASG(mockASG("running-web-new", "running", "web", "new", now, true))
+
+	// Workers are never attached
+	asgc.AddASG(mockASG("project-worker-old", "project", "worker", "old", old, false))
+
+	// Launched from a launch template with old versions
+	templated := mockASG("project-api-new", "project", "api", "new", old, true)
+	templated.LaunchConfigurationName = nil
+	templated.LaunchTemplate = lt.Specification(to.Strp("project-config-2020-01-01T00-00-00Z-api"))
+	asgc.AddASG(templated)
+
+	ec2c.AddLaunchTemplateVersion("project-config-2020-01-01T00-00-00Z-api", old)
+	ec2c.AddLaunchTemplateVersion("project-config-2020-01-01T00-00-00Z-api", old)
+	ec2c.AddLaunchTemplateVersion("project-config-2020-01-01T00-00-00Z-api", old)
+	tagOdin(ec2c, "project-config-2020-01-01T00-00-00Z-api", "project", "api")
+
+	asgc.AddLaunchConfiguration("project-config-2019-01-01T00-00-00Z-web", old)
+	asgc.AddLaunchConfiguration("project-config-2021-01-01T00-00-00Z-web", now)
+	asgc.AddLaunchConfiguration("project-web-new-2020-01-01T00-00-00Z-web", old)
+	asgc.AddLaunchConfiguration("manual", old)
+
+	ec2c.AddLaunchTemplateVersion("project-config-2019-01-01T00-00-00Z-api", old)
+	tagOdin(ec2c, "project-config-2019-01-01T00-00-00Z-api", "project", "api")
+	ec2c.AddLaunchTemplateVersion("manual", old)
+
+	serviceIDs := map[string]bool{"project-config-2019-01-01T00-00-00Z-web": true}
+
+	orphans, err := Find(asgc, ec2c, cutoff, serviceIDs)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, len(orphans.ASGs))
+	assert.Equal(t, "project-web-old", *orphans.ASGs[0].AutoScalingGroupName)
+	assert.Equal(t, []*string{to.Strp("project-config-2019-01-01T00-00-00Z-web")}, orphans.LaunchConfigurations)
+	assert.Equal(t, []*string{to.Strp("project-config-2019-01-01T00-00-00Z-api")}, orphans.LaunchTemplates)
+
+	assert.Equal(t, 1, len(orphans.LaunchTemplateVersions))
+	assert.Equal(t, []*string{to.Strp("2")}, orphans.LaunchTemplateVersions[0].Versions)
+
+	assert.NoError(t, orphans.Delete(asgc, &mocks.CWClient{}, ec2c))
+
+	assert.Equal(t, []string{"project-web-old"}, asgc.DeletedASGs)
+	assert.Equal(t, []string{
+		"project-web-old-2020-01-01T00-00-00Z-web",
+		"project-config-2019-01-01T00-00-00Z-web",
+	}, asgc.DeletedLaunchConfigurations)
+
+	assert.NotContains(t, ec2c.LaunchTemplateVersions, "project-config-2019-01-01T00-00-00Z-api")
+	assert.Equal(t, 2, len(ec2c.LaunchTemplateVersions["project-config-2020-01-01T00-00-00Z-api"]))
+}
+
+func Test_Find_Unattributed(t *testing.T) {
+	old := time.Now().Add(-30 * 24 * time.Hour)
+
+	asgc := &mocks.ASGClient{}
+	ec2c := &mocks.EC2Client{}
+
+	// Named like odin's but created by something else
+	asgc.AddLaunchConfiguration("other-team-2019-01-01T00-00-00Z-web", old)
+	ec2c.AddLaunchTemplateVersion("other-team-2019-01-01T00-00-00Z-api", old)
+	ec2c.AddLaunchTemplateVersion("other-team-2019-01-01T00-00-00Z-api", old)
+	ec2c.AddLaunchTemplateVersion("other-team-2019-01-01T00-00-00Z-api", old)
+
+	// A release in the history that fell back to another instance type
+	asgc.AddLaunchConfiguration("project-config-2019-01-01T00-00-00Z-web-c5.large", old)
+	serviceIDs := map[string]bool{"project-config-2019-01-01T00-00-00Z-web": true}
+
+	orphans, err := Find(asgc, ec2c, time.Now(), serviceIDs)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []*string{to.Strp("project-config-2019-01-01T00-00-00Z-web-c5.large")}, orphans.LaunchConfigurations)
+	assert.Empty(t, orphans.LaunchTemplates)
+	assert.Empty(t, orphans.LaunchTemplateVersions)
+
+	// Without a release history no launch configuration is attributed to odin
+	orphans, err = Find(asgc, ec2c, time.Now(), nil)
+	assert.NoError(t, err)
+	assert.True(t, orphans.Empty())
+}
+
+func Test_Find_Empty(t *testing.T) {
+	orphans, err := Find(&mocks.ASGClient{}, &mocks.EC2Client{}, time.Now(), nil)
+	assert.NoError(t, err)
+	assert.True(t, orphans.Empty())
+}
@@ -1,8 +1,14 @@
 package iam
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
 )
 
 //////
@@ -11,8 +17,17 @@ import (
 
 // Profile struct
 type Profile struct {
-	Path *string
-	Arn  *string
+	Path     *string
+	Arn      *string
+	RoleArns []*string
+	Roles    []*Role
+}
+
+// Role in an instance profile
+type Role struct {
+	Name      *string
+	Arn       *string
+	TrustsEC2 bool // Its trust policy allows EC2 to assume it
 }
 
 // Find returns profile with name
@@ -26,12 +41,80 @@ func Find(iamClient aws.IAMAPI, profileName *string) (*Profile, error) {
 	}
 
 	awsProfile := profileOutput.InstanceProfile
+
+	roleArns := []*string{}
+	roles := []*Role{}
+	for _, role := range awsProfile.Roles {
+		if role == nil || role.Arn == nil {
+			continue
+		}
+		roleArns = append(roleArns, role.Arn)
+		roles = append(roles, &Role{
+			Name:      role.RoleName,
+			Arn:       role.Arn,
+			TrustsEC2: trustsEC2(role.AssumeRolePolicyDocument),
+		})
+	}
+
 	return &Profile{
-		Path: awsProfile.Path,
-		Arn:  awsProfile.Arn,
+		Path:     awsProfile.Path,
+		Arn:      awsProfile.Arn,
+		RoleArns: roleArns,
+		Roles:    roles,
 	}, nil
 }
 
+// trustsEC2 returns whether the URL encoded trust policy allows ec2.amazonaws.com to assume the role
+func trustsEC2(document *string) bool {
+	if document == nil {
+		return false
+	}
+
+	raw, err := url.QueryUnescape(*document)
+	if err != nil {
+		return false
+	}
+
+	var policy struct {
+		Statement []struct {
+			Effect    string
+			Action    interface{}
+			Principal struct {
+				Service interface{}
+			}
+		}
+	}
+
+	if err := json.Unmarshal([]byte(raw), &policy); err != nil {
+		return false
+	}
+
+	for _, statement := range policy.Statement {
+		if statement.Effect == "Allow" &&
+			containsValue(statement.Action, "sts:AssumeRole") &&
+			containsValue(statement.Principal.Service, "ec2.amazonaws.com") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsValue returns whether a policy element, a string or list of strings, contains the value
+func containsValue(element interface{}, value string) bool {
+	switch e := element.(type) {
+	case string:
+		return e == value || e == "*"
+	case []interface{}:
+		for _, v := range e {
+			if s, ok := v.(string); ok && (s == value || s == "*") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 //////
 // ROLE
 //////
@@ -44,3 +127,30 @@ func RoleExists(iamc aws.IAMAPI, roleName *string) error {
 
 	return err
 }
+
+// SimulateAllowed errors if the role is not allowed to perform all actions on all resources
+func SimulateAllowed(iamc aws.IAMAPI, roleArn *string, actions []*string, resourceArns []*string) error {
+	output, err := iamc.SimulatePrincipalPolicy(&iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: roleArn,
+		ActionNames:     actions,
+		ResourceArns:    resourceArns,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	denied := []string{}
+	for _, result := range output.EvaluationResults {
+		if result.EvalDecision != nil && *result.EvalDecision == iam.PolicyEvaluationDecisionTypeAllowed {
+			continue
+		}
+		denied = append(denied, fmt.Sprintf("%v on %v", to.Strs(result.EvalActionName), to.Strs(result.EvalResourceName)))
+	}
+
+	if len(denied) > 0 {
+		return fmt.Errorf("Role %v is not allowed %v", to.Strs(roleArn), strings.Join(denied, ", "))
+	}
+
+	return nil
+}
@@ -19,3 +19,70 @@ func Test_Find(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "/path/", *profile.Path)
 }
+
+func Test_Find_RoleArns(t *testing.T) {
+	iamc := &mocks.IAMClient{}
+	iamc.AddGetInstanceProfile("asd", "/path/")
+	profile, err := Find(iamc, to.Strp("asd"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/path/asd-role"}, to.StrSlice(profile.RoleArns))
+}
+
+func Test_SimulateAllowed(t *testing.T) {
+	iamc := &mocks.IAMClient{}
+	actions := []*string{to.Strp("ecr:BatchGetImage")}
+	resources := []*string{to.Strp("arn:aws:ecr:us-east-1:000000000000:repository/repo")}
+
+	assert.NoError(t, SimulateAllowed(iamc, to.Strp("role"), actions, resources))
+
+	iamc.DenyAction("ecr:BatchGetImage")
+	assert.Error(t, SimulateAllowed(iamc, to.Strp("role"), actions, resources))
+}
+
+func Test_Find_Roles_TrustsEC2(t *testing.T) {
+	iamc := &mocks.IAMClient{}
+	iamc.AddGetInstanceProfile("asd", "/path/")
+	profile, err := Find(iamc, to.Strp("asd"))
+	assert.NoError(t, err)
+	assert.Equal(t, "asd-role", *profile.Roles[0].Name)
+	assert.True(t, profile.Roles[0].TrustsEC2)
+
+	assert.True(t, trustsEC2(to.Strp(`{"Statement":[{"Effect":"Allow","Principal":{"Service":["ecs.amazonaws.com","ec2.amazonaws.com"]},"Action":["sts:AssumeRole"]}]}`)))
+	assert.False(t, trustsEC2(to.Strp(`{"Statement":[{"Effect":"Allow","Principal":{"Service":"lambda.amazonaws.com"},"Action":"sts:AssumeRole"}]}`)))
+	assert.False(t, trustsEC2(to.Strp(`{"Statement":[{"Effect":"Deny","Principal":{"Service":"ec2.amazonaws.com"},"Action":"sts:AssumeRole"}]}`)))
+	assert.False(t, trustsEC2(nil))
+}
+
+func Test_EnsureRoleProfile(t *testing.T) {
+	boundary := "arn:aws:iam::000000000000:policy/boundary"
+	iamc := &mocks.IAMClient{}
+	assert.Error(t, EnsureRoleProfile(iamc, to.Strp("profile"), to.Strp("/path/"), to.Strp("role"), &boundary))
+
+	iamc.AddBoundedRole("role", "/path/", boundary)
+	assert.NoError(t, EnsureRoleProfile(iamc, to.Strp("profile"), to.Strp("/path/"), to.Strp("role"), &boundary))
+	assert.Equal(t, 1, len(iamc.AddedProfileRoles))
+	assert.Equal(t, 0, len(iamc.RemovedProfileRoles))
+
+	// The profile already has the role
+	iamc.AddBoundedRole("profile-role", "/path/", boundary)
+	assert.NoError(t, EnsureRoleProfile(iamc, to.Strp("profile"), to.Strp("/path/"), to.Strp("profile-role"), &boundary))
+	assert.Equal(t, 1, len(iamc.AddedProfileRoles))
+}
+
+func Test_EnsureRoleProfile_Unbounded(t *testing.T) {
+	boundary := "arn:aws:iam::000000000000:policy/boundary"
+	iamc := &mocks.IAMClient{}
+
+	// Roles outside the path are never added
+	iamc.AddBoundedRole("admin", "/", boundary)
+	assert.Error(t, EnsureRoleProfile(iamc, to.Strp("profile"), to.Strp("/path/"), to.Strp("admin"), &boundary))
+
+	// Nor are roles without the permissions boundary
+	iamc.AddGetRole("unbounded")
+	assert.Error(t, EnsureRoleProfile(iamc, to.Strp("profile"), to.Strp("/path/"), to.Strp("unbounded"), &boundary))
+
+	iamc.AddBoundedRole("other", "/path/", "arn:aws:iam::000000000000:policy/other")
+	assert.Error(t, EnsureRoleProfile(iamc, to.Strp("profile"), to.Strp("/path/"), to.Strp("other"), &boundary))
+
+	assert.Equal(t, 0, len(iamc.AddedProfileRoles))
+}
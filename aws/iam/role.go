@@ -0,0 +1,135 @@
+package iam
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+const ec2AssumeRolePolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"ec2.amazonaws.com"},"Action":"sts:AssumeRole"}]}`
+
+// EnsureProfile creates the role with the boundary and its instance profile if they do not exist,
+// then sets the roles inline policy
+func EnsureProfile(iamc aws.IAMAPI, name *string, path *string, boundaryArn *string, policy *string) error {
+	if err := RoleExists(iamc, name); err != nil {
+		if !isNotFound(err) {
+			return err
+		}
+
+		_, err := iamc.CreateRole(&iam.CreateRoleInput{
+			AssumeRolePolicyDocument: to.Strp(ec2AssumeRolePolicy),
+			Path:                     path,
+			PermissionsBoundary:      boundaryArn,
+			RoleName:                 name,
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := iamc.PutRolePolicy(&iam.PutRolePolicyInput{
+		RoleName:       name,
+		PolicyName:     to.Strp("odin"),
+		PolicyDocument: policy,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := Find(iamc, name); err == nil {
+		return nil
+	} else if !isNotFound(err) {
+		return err
+	}
+
+	_, err = iamc.CreateInstanceProfile(&iam.CreateInstanceProfileInput{
+		InstanceProfileName: name,
+		Path:                path,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	_, err = iamc.AddRoleToInstanceProfile(&iam.AddRoleToInstanceProfileInput{
+		InstanceProfileName: name,
+		RoleName:            name,
+	})
+
+	return err
+}
+
+// EnsureRoleProfile creates the instance profile if it does not exist, then makes the role its only role
+// The role must be under the path and bounded by the permissions boundary, as Odins own roles are
+func EnsureRoleProfile(iamc aws.IAMAPI, name *string, path *string, roleName *string, boundaryArn *string) error {
+	if err := checkBoundedRole(iamc, roleName, path, boundaryArn); err != nil {
+		return err
+	}
+
+	profile, err := Find(iamc, name)
+	if isNotFound(err) {
+		_, err = iamc.CreateInstanceProfile(&iam.CreateInstanceProfileInput{
+			InstanceProfileName: name,
+			Path:                path,
+		})
+
+		profile = &Profile{}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// An instance profile can only have one role
+	for _, role := range profile.Roles {
+		if to.Strs(role.Name) == *roleName {
+			return nil
+		}
+
+		_, err := iamc.RemoveRoleFromInstanceProfile(&iam.RemoveRoleFromInstanceProfileInput{
+			InstanceProfileName: name,
+			RoleName:            role.Name,
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = iamc.AddRoleToInstanceProfile(&iam.AddRoleToInstanceProfileInput{
+		InstanceProfileName: name,
+		RoleName:            roleName,
+	})
+
+	return err
+}
+
+// checkBoundedRole errors unless the role is under the path and bounded by the permissions boundary,
+// so the instances can never be given more than a role Odin could have created
+func checkBoundedRole(iamc aws.IAMAPI, roleName *string, path *string, boundaryArn *string) error {
+	out, err := iamc.GetRole(&iam.GetRoleInput{RoleName: roleName})
+	if err != nil {
+		return fmt.Errorf("Role %v %v", *roleName, err.Error())
+	}
+
+	role := out.Role
+	if role == nil || to.Strs(role.Path) != to.Strs(path) {
+		return fmt.Errorf("Role %v must have the path %v", *roleName, to.Strs(path))
+	}
+
+	if role.PermissionsBoundary == nil || to.Strs(role.PermissionsBoundary.PermissionsBoundaryArn) != to.Strs(boundaryArn) {
+		return fmt.Errorf("Role %v must have the permissions boundary %v", *roleName, to.Strs(boundaryArn))
+	}
+
+	return nil
+}
+
+func isNotFound(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == iam.ErrCodeNoSuchEntityException
+}
@@ -0,0 +1,239 @@
+package identity
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Presigned URLs expire quickly so they cannot be reused to prove identity later
+const presignExpiry = 15 * time.Minute
+
+// BindingHeader is signed into presigned URLs with what they prove the identity for, e.g. a release.
+// STS rejects the URL unless it is called with the same value, so it cannot be replayed for anything else
+const BindingHeader = "X-Odin-Binding"
+
+var stsHost = regexp.MustCompile(`^sts(\.[a-z0-9-]+)?\.amazonaws\.com$`)
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+type callerIdentityResponse struct {
+	Arn *string `xml:"GetCallerIdentityResult>Arn"`
+}
+
+// Presign returns a presigned sts:GetCallerIdentity URL that proves the callers identity for the binding
+func Presign(stsc aws.STSAPI, binding string) (*string, error) {
+	req, _ := stsc.GetCallerIdentityRequest(&sts.GetCallerIdentityInput{})
+
+	// Headers that do not start with X-Amz- stay headers, so the caller must send them for the signature to match
+	req.HTTPRequest.Header.Set(BindingHeader, binding)
+
+	presigned, err := req.Presign(presignExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &presigned, nil
+}
+
+// Verify calls the presigned URL with the binding and returns the ARN of the caller that signed it
+func Verify(presigned *string, binding string) (*string, error) {
+	u, err := validateURL(presigned)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(BindingHeader, binding)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Identity verification failed with status %v", resp.StatusCode)
+	}
+
+	return parseArn(body)
+}
+
+// Principals returns the ARNs an identity can be authorized as
+// An assumed role session is also its role, e.g. arn:aws:sts::000000000000:assumed-role/name/session is arn:aws:iam::000000000000:role/name
+func Principals(arn *string) []string {
+	if arn == nil {
+		return []string{}
+	}
+
+	principals := []string{*arn}
+
+	parts := strings.Split(*arn, ":")
+	if len(parts) != 6 || parts[2] != "sts" {
+		return principals
+	}
+
+	resource := strings.Split(parts[5], "/")
+	if len(resource) != 3 || resource[0] != "assumed-role" {
+		return principals
+	}
+
+	return append(principals, fmt.Sprintf("arn:%v:iam::%v:role/%v", parts[1], parts[4], resource[1]))
+}
+
+func validateURL(presigned *string) (*url.URL, error) {
+	u, err := url.Parse(to.Strs(presigned))
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "https" {
+		return nil, fmt.Errorf("Identity URL must be https")
+	}
+
+	// Only call STS, otherwise any server could return any identity
+	if !stsHost.MatchString(u.Host) {
+		return nil, fmt.Errorf("Identity URL host %v is not STS", u.Host)
+	}
+
+	if u.Query().Get("Action") != "GetCallerIdentity" {
+		return nil, fmt.Errorf("Identity URL must be GetCallerIdentity")
+	}
+
+	// Without the binding signed STS ignores the header, so the URL would prove the identity for anything
+	if !signsHeader(u, BindingHeader) {
+		return nil, fmt.Errorf("Identity URL must sign %v", BindingHeader)
+	}
+
+	return u, nil
+}
+
+func signsHeader(u *url.URL, header string) bool {
+	for _, signed := range strings.Split(u.Query().Get("X-Amz-SignedHeaders"), ";") {
+		if strings.EqualFold(signed, header) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseArn(body []byte) (*string, error) {
+	var resp callerIdentityResponse
+	if err := xml.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Arn == nil {
+		return nil, fmt.Errorf("Identity ARN not found")
+	}
+
+	return resp.Arn, nil
+}
+
+// Account returns the account ID of an identity ARN
+func Account(arn *string) *string {
+	parts := strings.Split(to.Strs(arn), ":")
+	if len(parts) != 6 || parts[4] == "" {
+		return nil
+	}
+
+	return to.Strp(parts[4])
+}
+
+// SessionName returns the session name of an assumed role ARN, e.g. the user or CI job that assumed it
+func SessionName(arn *string) *string {
+	parts := strings.Split(to.Strs(arn), ":")
+	if len(parts) != 6 || parts[2] != "sts" {
+		return nil
+	}
+
+	resource := strings.Split(parts[5], "/")
+	if len(resource) != 3 || resource[0] != "assumed-role" {
+		return nil
+	}
+
+	return to.Strp(resource[2])
+}
+
+// RoleName returns the role of an assumed role ARN, e.g. deployer for arn:aws:sts::000000000000:assumed-role/deployer/alice
+func RoleName(arn *string) *string {
+	parts := strings.Split(to.Strs(arn), ":")
+	if len(parts) != 6 || parts[2] != "sts" {
+		return nil
+	}
+
+	resource := strings.Split(parts[5], "/")
+	if len(resource) != 3 || resource[0] != "assumed-role" {
+		return nil
+	}
+
+	return to.Strp(resource[1])
+}
+
+// RoleTags returns the tags of the role of an assumed role ARN, read with iam:ListRoleTags in the IAM clients account
+// Identities that are not assumed roles, and roles that no longer exist, have no tags
+func RoleTags(iamc aws.IAMAPI, arn *string) (map[string]string, error) {
+	tags := map[string]string{}
+
+	roleName := RoleName(arn)
+	if roleName == nil {
+		return tags, nil
+	}
+
+	input := &iam.ListRoleTagsInput{RoleName: roleName}
+	for {
+		out, err := iamc.ListRoleTags(input)
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == iam.ErrCodeNoSuchEntityException {
+			return map[string]string{}, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tag := range out.Tags {
+			if tag.Key != nil && tag.Value != nil {
+				tags[*tag.Key] = *tag.Value
+			}
+		}
+
+		if out.IsTruncated == nil || !*out.IsTruncated || out.Marker == nil {
+			return tags, nil
+		}
+		input.Marker = out.Marker
+	}
+}
+
+// CallerRole returns the role of the caller, or its ARN if it has not assumed a role
+func CallerRole(stsc aws.STSAPI) (*string, error) {
+	out, err := stsc.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	principals := Principals(out.Arn)
+	if len(principals) == 0 {
+		return nil, fmt.Errorf("Caller identity ARN not found")
+	}
+
+	return to.Strp(principals[len(principals)-1]), nil
+}
@@ -0,0 +1,130 @@
+package identity
+
+import (
+	"testing"
+
+	sdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_validateURL(t *testing.T) {
+	_, err := validateURL(to.Strp("https://sts.amazonaws.com/?Action=GetCallerIdentity&Version=2011-06-15&X-Amz-SignedHeaders=host%3Bx-odin-binding"))
+	assert.NoError(t, err)
+
+	_, err = validateURL(to.Strp("https://sts.us-east-1.amazonaws.com/?Action=GetCallerIdentity&X-Amz-SignedHeaders=x-odin-binding"))
+	assert.NoError(t, err)
+
+	// Unbound URLs can be replayed
+	_, err = validateURL(to.Strp("https://sts.amazonaws.com/?Action=GetCallerIdentity&X-Amz-SignedHeaders=host"))
+	assert.Error(t, err)
+
+	_, err = validateURL(to.Strp("http://sts.amazonaws.com/?Action=GetCallerIdentity&X-Amz-SignedHeaders=x-odin-binding"))
+	assert.Error(t, err)
+
+	_, err = validateURL(to.Strp("https://sts.amazonaws.com.evil.com/?Action=GetCallerIdentity&X-Amz-SignedHeaders=x-odin-binding"))
+	assert.Error(t, err)
+
+	_, err = validateURL(to.Strp("https://evil.com/?Action=GetCallerIdentity&X-Amz-SignedHeaders=x-odin-binding"))
+	assert.Error(t, err)
+
+	_, err = validateURL(to.Strp("https://sts.amazonaws.com/?Action=AssumeRole&X-Amz-SignedHeaders=x-odin-binding"))
+	assert.Error(t, err)
+
+	_, err = validateURL(nil)
+	assert.Error(t, err)
+}
+
+func Test_Presign_Binding(t *testing.T) {
+	sess := session.Must(session.NewSession(&sdk.Config{
+		Region:      sdk.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+	}))
+
+	presigned, err := Presign(sts.New(sess), "project/config/release-1")
+	assert.NoError(t, err)
+
+	// The binding is a signed header, not a query parameter anyone could change
+	u, err := validateURL(presigned)
+	assert.NoError(t, err)
+	assert.Equal(t, "", u.Query().Get(BindingHeader))
+	assert.Contains(t, u.Query().Get("X-Amz-SignedHeaders"), "x-odin-binding")
+}
+
+func Test_parseArn(t *testing.T) {
+	arn, err := parseArn([]byte(`<GetCallerIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <GetCallerIdentityResult>
+    <Arn>arn:aws:iam::000000000000:user/alice</Arn>
+    <UserId>AIDA000000000000</UserId>
+    <Account>000000000000</Account>
+  </GetCallerIdentityResult>
+</GetCallerIdentityResponse>`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "arn:aws:iam::000000000000:user/alice", *arn)
+
+	_, err = parseArn([]byte(`<ErrorResponse></ErrorResponse>`))
+	assert.Error(t, err)
+}
+
+func Test_Principals(t *testing.T) {
+	assert.Equal(t, []string{}, Principals(nil))
+
+	assert.Equal(t,
+		[]string{"arn:aws:iam::000000000000:user/alice"},
+		Principals(to.Strp("arn:aws:iam::000000000000:user/alice")),
+	)
+
+	assert.Equal(t,
+		[]string{"arn:aws:sts::000000000000:assumed-role/deployer/alice", "arn:aws:iam::000000000000:role/deployer"},
+		Principals(to.Strp("arn:aws:sts::000000000000:assumed-role/deployer/alice")),
+	)
+}
+
+func Test_Account_SessionName(t *testing.T) {
+	user := to.Strp("arn:aws:iam::000000000000:user/alice")
+	session := to.Strp("arn:aws:sts::111111111111:assumed-role/deployer/alice")
+
+	assert.Equal(t, "000000000000", *Account(user))
+	assert.Nil(t, SessionName(user))
+
+	assert.Equal(t, "111111111111", *Account(session))
+	assert.Equal(t, "alice", *SessionName(session))
+
+	assert.Nil(t, Account(nil))
+	assert.Nil(t, SessionName(to.Strp("not-an-arn")))
+}
+
+func Test_RoleName_RoleTags(t *testing.T) {
+	session := to.Strp("arn:aws:sts::000000000000:assumed-role/deployer/alice")
+	user := to.Strp("arn:aws:iam::000000000000:user/alice")
+
+	assert.Equal(t, "deployer", *RoleName(session))
+	assert.Nil(t, RoleName(user))
+
+	iamc := &mocks.IAMClient{}
+	iamc.TagRole("deployer", "team", "payments")
+
+	tags, err := RoleTags(iamc, session)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "payments"}, tags)
+
+	// Users and deleted roles have no tags
+	tags, err = RoleTags(iamc, user)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{}, tags)
+
+	tags, err = RoleTags(iamc, to.Strp("arn:aws:sts::000000000000:assumed-role/deleted/alice"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{}, tags)
+}
+
+func Test_CallerRole(t *testing.T) {
+	role, err := CallerRole(&mocks.STSClient{})
+	assert.NoError(t, err)
+	assert.Equal(t, "arn:aws:iam::000000000000:role/coinbase-odin", *role)
+}
@@ -138,3 +138,12 @@ func stateCompare(s1 string, s2 string) string {
 	// Otherwise Unhealthy
 	return unhealthy
 }
+
+// AddHealthCheckInstance adds an instance with the result of a health check
+func (all Instances) AddHealthCheckInstance(id string, isHealthy bool) {
+	state := unhealthy
+	if isHealthy {
+		state = healthy
+	}
+	all[id] = state
+}
@@ -0,0 +1,31 @@
+package lc
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Architectures returns the image architectures the instance type can run, e.g. arm64 or x86_64
+// Each instance type is described on its own so the deployers describe cache keeps it per instance type
+func Architectures(ec2c aws.EC2API, instanceType *string) ([]string, error) {
+	if instanceType == nil {
+		return nil, fmt.Errorf("Instance type required")
+	}
+
+	output, err := ec2c.DescribeInstanceTypes(&ec2.DescribeInstanceTypesInput{
+		InstanceTypes: []*string{instanceType},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(output.InstanceTypes) != 1 || output.InstanceTypes[0].ProcessorInfo == nil {
+		return nil, fmt.Errorf("Instance type %v not found", *instanceType)
+	}
+
+	return to.StrSlice(output.InstanceTypes[0].ProcessorInfo.SupportedArchitectures), nil
+}
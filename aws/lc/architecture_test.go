@@ -0,0 +1,24 @@
+package lc
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Architectures(t *testing.T) {
+	ec2c := &mocks.EC2Client{InstanceTypeArchitectures: map[string][]string{"m8g.large": []string{"arm64"}}}
+
+	_, err := Architectures(ec2c, nil)
+	assert.Error(t, err)
+
+	archs, err := Architectures(ec2c, to.Strp("m8g.large"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"arm64"}, archs)
+
+	archs, err = Architectures(ec2c, to.Strp("c5.large"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"x86_64"}, archs)
+}
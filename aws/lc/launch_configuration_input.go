@@ -65,7 +65,7 @@ func (s *LaunchConfigInput) Create(asgc aws.ASGAPI) error {
 }
 
 // AddBlockDevice adds an EBS block device to the LC
-func (s *LaunchConfigInput) AddBlockDevice(ebsVolumeSize *int64, ebsVolumeType *string, ebsDeviceType *string) {
+func (s *LaunchConfigInput) AddBlockDevice(ebsVolumeSize *int64, ebsVolumeType *string, ebsDeviceType *string, ebsEncrypted *bool) {
 	if ebsVolumeSize == nil {
 		return
 	}
@@ -83,6 +83,7 @@ func (s *LaunchConfigInput) AddBlockDevice(ebsVolumeSize *int64, ebsVolumeType *
 		Ebs: &autoscaling.Ebs{
 			VolumeSize: ebsVolumeSize,
 			VolumeType: ebsVolumeType,
+			Encrypted:  ebsEncrypted,
 		},
 	}
 
@@ -5,13 +5,18 @@ import (
 
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
 )
 
 func Test_AddBlockDevice(t *testing.T) {
 	input := &LaunchConfigInput{&autoscaling.CreateLaunchConfigurationInput{}}
 
-	input.AddBlockDevice(to.Int64p(10), nil, nil)
-	input.AddBlockDevice(to.Int64p(10), to.Strp("asd"), nil)
-	input.AddBlockDevice(to.Int64p(10), nil, to.Strp("asd"))
+	input.AddBlockDevice(to.Int64p(10), nil, nil, nil)
+	input.AddBlockDevice(to.Int64p(10), to.Strp("asd"), nil, nil)
+	input.AddBlockDevice(to.Int64p(10), nil, to.Strp("asd"), nil)
+	input.AddBlockDevice(to.Int64p(10), nil, nil, to.Boolp(true))
+
+	assert.Equal(t, 4, len(input.BlockDeviceMappings))
+	assert.True(t, *input.BlockDeviceMappings[3].Ebs.Encrypted)
 
 }
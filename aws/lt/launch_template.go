@@ -0,0 +1,169 @@
+package lt
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// NetworkInterface options for the instances primary network interface,
+// which launch configurations cannot set so they require a launch template
+type NetworkInterface struct {
+	AssociatePublicIpAddress       *bool
+	Ipv6AddressCount               *int64
+	SecondaryPrivateIpAddressCount *int64
+}
+
+// Input input struct
+type Input struct {
+	*ec2.CreateLaunchTemplateInput
+}
+
+// FromLaunchConfig returns the launch template equivalent of the launch configuration with the network interface
+// The security groups move to the network interface as they cannot be set on both
+func FromLaunchConfig(lci *autoscaling.CreateLaunchConfigurationInput, ni *NetworkInterface) *Input {
+	data := &ec2.RequestLaunchTemplateData{
+		ImageId:      lci.ImageId,
+		InstanceType: lci.InstanceType,
+		UserData:     lci.UserData,
+		EbsOptimized: lci.EbsOptimized,
+		NetworkInterfaces: []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{
+			&ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{
+				DeviceIndex:                    to.Int64p(0),
+				DeleteOnTermination:            to.Boolp(true),
+				Groups:                         lci.SecurityGroups,
+				AssociatePublicIpAddress:       ni.AssociatePublicIpAddress,
+				Ipv6AddressCount:               ni.Ipv6AddressCount,
+				SecondaryPrivateIpAddressCount: ni.SecondaryPrivateIpAddressCount,
+			},
+		},
+	}
+
+	if lci.IamInstanceProfile != nil {
+		data.IamInstanceProfile = &ec2.LaunchTemplateIamInstanceProfileSpecificationRequest{Arn: lci.IamInstanceProfile}
+	}
+
+	if lci.InstanceMonitoring != nil {
+		data.Monitoring = &ec2.LaunchTemplatesMonitoringRequest{Enabled: lci.InstanceMonitoring.Enabled}
+	}
+
+	if lci.PlacementTenancy != nil {
+		data.Placement = &ec2.LaunchTemplatePlacementRequest{Tenancy: lci.PlacementTenancy}
+	}
+
+	if lci.SpotPrice != nil {
+		data.InstanceMarketOptions = &ec2.LaunchTemplateInstanceMarketOptionsRequest{
+			MarketType:  to.Strp(ec2.MarketTypeSpot),
+			SpotOptions: &ec2.LaunchTemplateSpotMarketOptionsRequest{MaxPrice: lci.SpotPrice},
+		}
+	}
+
+	for _, block := range lci.BlockDeviceMappings {
+		mapping := &ec2.LaunchTemplateBlockDeviceMappingRequest{DeviceName: block.DeviceName}
+		if block.Ebs != nil {
+			mapping.Ebs = &ec2.LaunchTemplateEbsBlockDeviceRequest{
+				VolumeSize:          block.Ebs.VolumeSize,
+				VolumeType:          block.Ebs.VolumeType,
+				Encrypted:           block.Ebs.Encrypted,
+				DeleteOnTermination: block.Ebs.DeleteOnTermination,
+				Iops:                block.Ebs.Iops,
+			}
+		}
+		data.BlockDeviceMappings = append(data.BlockDeviceMappings, mapping)
+	}
+
+	return &Input{&ec2.CreateLaunchTemplateInput{
+		LaunchTemplateName: lci.LaunchConfigurationName,
+		LaunchTemplateData: data,
+	}}
+}
+
+// AddTags tags the launch template, and the instances and volumes launched from it
+func (s *Input) AddTags(tags map[string]*string) {
+	if len(tags) == 0 {
+		return
+	}
+
+	keys := []string{}
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	ec2Tags := []*ec2.Tag{}
+	for _, key := range keys {
+		ec2Tags = append(ec2Tags, &ec2.Tag{Key: to.Strp(key), Value: tags[key]})
+	}
+
+	s.TagSpecifications = []*ec2.TagSpecification{
+		&ec2.TagSpecification{ResourceType: to.Strp("launch-template"), Tags: ec2Tags},
+	}
+
+	s.LaunchTemplateData.TagSpecifications = []*ec2.LaunchTemplateTagSpecificationRequest{
+		&ec2.LaunchTemplateTagSpecificationRequest{ResourceType: to.Strp(ec2.ResourceTypeInstance), Tags: ec2Tags},
+		&ec2.LaunchTemplateTagSpecificationRequest{ResourceType: to.Strp(ec2.ResourceTypeVolume), Tags: ec2Tags},
+	}
+}
+
+// RequireIMDSv2 requires instances to use session tokens to access the instance metadata service
+func (s *Input) RequireIMDSv2() {
+	s.LaunchTemplateData.MetadataOptions = &ec2.LaunchTemplateInstanceMetadataOptionsRequest{
+		HttpEndpoint: to.Strp("enabled"),
+		HttpTokens:   to.Strp("required"),
+	}
+}
+
+// UseHostResourceGroup launches instances onto the dedicated hosts of the host resource group
+func (s *Input) UseHostResourceGroup(arn *string) {
+	if s.LaunchTemplateData.Placement == nil {
+		s.LaunchTemplateData.Placement = &ec2.LaunchTemplatePlacementRequest{}
+	}
+	s.LaunchTemplateData.Placement.HostResourceGroupArn = arn
+}
+
+// IMDSv2Required returns whether instances launched from the template must use session tokens
+func (s *Input) IMDSv2Required() bool {
+	options := s.LaunchTemplateData.MetadataOptions
+	return options != nil && options.HttpTokens != nil && *options.HttpTokens == "required"
+}
+
+// Create tries to create the launch template
+func (s *Input) Create(ec2c aws.EC2API) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	_, err := ec2c.CreateLaunchTemplate(s.CreateLaunchTemplateInput)
+
+	return err
+}
+
+// Specification returns the ASGs reference to the launch template
+func Specification(name *string) *autoscaling.LaunchTemplateSpecification {
+	return &autoscaling.LaunchTemplateSpecification{
+		LaunchTemplateName: name,
+		Version:            to.Strp("$Latest"),
+	}
+}
+
+// Teardown deletes the launch template
+func Teardown(ec2c aws.EC2API, name *string) error {
+	_, err := ec2c.DeleteLaunchTemplate(&ec2.DeleteLaunchTemplateInput{
+		LaunchTemplateName: name,
+	})
+
+	return err
+}
+
+// TeardownVersions deletes versions of the launch template
+func TeardownVersions(ec2c aws.EC2API, name *string, versions []*string) error {
+	_, err := ec2c.DeleteLaunchTemplateVersions(&ec2.DeleteLaunchTemplateVersionsInput{
+		LaunchTemplateName: name,
+		Versions:           versions,
+	})
+
+	return err
+}
@@ -0,0 +1,67 @@
+package lt
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FromLaunchConfig(t *testing.T) {
+	input := FromLaunchConfig(&autoscaling.CreateLaunchConfigurationInput{
+		LaunchConfigurationName: to.Strp("name"),
+		ImageId:                 to.Strp("ami-1"),
+		InstanceType:            to.Strp("m5.large"),
+		SecurityGroups:          []*string{to.Strp("sg-1")},
+		IamInstanceProfile:      to.Strp("arn:aws:iam::000000000000:instance-profile/p"),
+		SpotPrice:               to.Strp("0.1"),
+		BlockDeviceMappings: []*autoscaling.BlockDeviceMapping{
+			&autoscaling.BlockDeviceMapping{DeviceName: to.Strp("/dev/xvda"), Ebs: &autoscaling.Ebs{VolumeSize: to.Int64p(20), VolumeType: to.Strp("io1"), Iops: to.Int64p(1000), DeleteOnTermination: to.Boolp(false)}},
+		},
+	}, &NetworkInterface{
+		AssociatePublicIpAddress:       to.Boolp(false),
+		Ipv6AddressCount:               to.Int64p(1),
+		SecondaryPrivateIpAddressCount: to.Int64p(2),
+	})
+
+	assert.Equal(t, "name", *input.LaunchTemplateName)
+
+	data := input.LaunchTemplateData
+	assert.Equal(t, "ami-1", *data.ImageId)
+	assert.Equal(t, "0.1", *data.InstanceMarketOptions.SpotOptions.MaxPrice)
+	assert.Equal(t, int64(20), *data.BlockDeviceMappings[0].Ebs.VolumeSize)
+	assert.Equal(t, int64(1000), *data.BlockDeviceMappings[0].Ebs.Iops)
+	assert.False(t, *data.BlockDeviceMappings[0].Ebs.DeleteOnTermination)
+
+	ni := data.NetworkInterfaces[0]
+	assert.Equal(t, []string{"sg-1"}, to.StrSlice(ni.Groups))
+	assert.Equal(t, int64(1), *ni.Ipv6AddressCount)
+	assert.Equal(t, int64(2), *ni.SecondaryPrivateIpAddressCount)
+	assert.False(t, *ni.AssociatePublicIpAddress)
+
+	input.AddTags(map[string]*string{"team": to.Strp("platform"), "ProjectName": to.Strp("project")})
+	assert.Equal(t, "launch-template", *input.TagSpecifications[0].ResourceType)
+	assert.Equal(t, "ProjectName", *input.TagSpecifications[0].Tags[0].Key)
+	assert.Equal(t, "volume", *data.TagSpecifications[1].ResourceType)
+	assert.Equal(t, "platform", *data.TagSpecifications[1].Tags[1].Value)
+
+	// Launch configurations cannot require IMDSv2, only the template can
+	assert.Nil(t, data.MetadataOptions)
+	assert.False(t, input.IMDSv2Required())
+	input.RequireIMDSv2()
+	assert.True(t, input.IMDSv2Required())
+	assert.Equal(t, "enabled", *data.MetadataOptions.HttpEndpoint)
+
+	assert.Nil(t, data.Placement)
+	input.UseHostResourceGroup(to.Strp("arn:aws:resource-groups:us-east-1:000000000000:group/hosts"))
+	assert.Equal(t, "arn:aws:resource-groups:us-east-1:000000000000:group/hosts", *data.Placement.HostResourceGroupArn)
+
+	ec2c := &mocks.EC2Client{}
+	assert.NoError(t, input.Create(ec2c))
+	assert.NotNil(t, ec2c.LaunchTemplates["name"])
+
+	assert.NoError(t, Teardown(ec2c, to.Strp("name")))
+	assert.Nil(t, ec2c.LaunchTemplates["name"])
+}
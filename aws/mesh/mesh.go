@@ -0,0 +1,170 @@
+package mesh
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/appmesh"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Weights maps virtual nodes to the relative weight of traffic routed to them
+type Weights map[string]int64
+
+// Heaviest returns the node receiving the most traffic, nil if none receives any
+func (w Weights) Heaviest() *string {
+	names := []string{}
+	for name := range w {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var heaviest *string
+	for _, name := range names {
+		if w[name] > 0 && (heaviest == nil || w[name] > w[*heaviest]) {
+			heaviest = to.Strp(name)
+		}
+	}
+
+	return heaviest
+}
+
+//////
+// App Mesh
+//////
+
+// Route identifies an App Mesh route
+type Route struct {
+	MeshName          *string
+	VirtualRouterName *string
+	RouteName         *string
+}
+
+// RouteWeights returns the weights of the routes HTTP targets
+func RouteWeights(meshc aws.MeshAPI, route *Route) (Weights, error) {
+	spec, err := describeSpec(meshc, route)
+	if err != nil {
+		return nil, err
+	}
+
+	weights := Weights{}
+	for _, target := range spec.HttpRoute.Action.WeightedTargets {
+		if target.VirtualNode != nil && target.Weight != nil {
+			weights[*target.VirtualNode] = *target.Weight
+		}
+	}
+
+	return weights, nil
+}
+
+// SetRouteWeights replaces the routes HTTP targets with the weights, keeping how it matches requests
+func SetRouteWeights(meshc aws.MeshAPI, route *Route, weights Weights) error {
+	spec, err := describeSpec(meshc, route)
+	if err != nil {
+		return err
+	}
+
+	names := []string{}
+	for name := range weights {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	targets := []*appmesh.WeightedTarget{}
+	for _, name := range names {
+		targets = append(targets, &appmesh.WeightedTarget{VirtualNode: to.Strp(name), Weight: to.Int64p(weights[name])})
+	}
+
+	spec.HttpRoute.Action.WeightedTargets = targets
+
+	_, err = meshc.UpdateRoute(&appmesh.UpdateRouteInput{
+		MeshName:          route.MeshName,
+		VirtualRouterName: route.VirtualRouterName,
+		RouteName:         route.RouteName,
+		Spec:              spec,
+	})
+
+	return err
+}
+
+// RouteActive returns whether the routes last update is active, so is being sent to the proxies
+func RouteActive(meshc aws.MeshAPI, route *Route) (bool, error) {
+	output, err := meshc.DescribeRoute(&appmesh.DescribeRouteInput{
+		MeshName:          route.MeshName,
+		VirtualRouterName: route.VirtualRouterName,
+		RouteName:         route.RouteName,
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	if output.Route == nil || output.Route.Status == nil {
+		return false, nil
+	}
+
+	return to.Strs(output.Route.Status.Status) == appmesh.RouteStatusCodeActive, nil
+}
+
+func describeSpec(meshc aws.MeshAPI, route *Route) (*appmesh.RouteSpec, error) {
+	output, err := meshc.DescribeRoute(&appmesh.DescribeRouteInput{
+		MeshName:          route.MeshName,
+		VirtualRouterName: route.VirtualRouterName,
+		RouteName:         route.RouteName,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if output.Route == nil || output.Route.Spec == nil || output.Route.Spec.HttpRoute == nil || output.Route.Spec.HttpRoute.Action == nil {
+		return nil, fmt.Errorf("Route %v is not an HTTP route", to.Strs(route.RouteName))
+	}
+
+	return output.Route.Spec, nil
+}
+
+//////
+// SSM
+//////
+
+// ParameterWeights returns the weights in the JSON parameter, which are empty if it does not exist yet
+func ParameterWeights(ssmc aws.SSMAPI, name *string) (Weights, error) {
+	output, err := ssmc.GetParameter(&ssm.GetParameterInput{Name: name})
+
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == ssm.ErrCodeParameterNotFound {
+		return Weights{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var weights Weights
+	if err := json.Unmarshal([]byte(to.Strs(output.Parameter.Value)), &weights); err != nil {
+		return nil, fmt.Errorf("Weights parameter %v invalid %v", to.Strs(name), err.Error())
+	}
+
+	return weights, nil
+}
+
+// SetParameterWeights writes the weights as JSON to the parameter for the mesh proxies to read
+func SetParameterWeights(ssmc aws.SSMAPI, name *string, weights Weights) error {
+	raw, err := json.Marshal(weights)
+	if err != nil {
+		return err
+	}
+
+	_, err = ssmc.PutParameter(&ssm.PutParameterInput{
+		Name:      name,
+		Value:     to.Strp(string(raw)),
+		Type:      to.Strp(ssm.ParameterTypeString),
+		Overwrite: to.Boolp(true),
+	})
+
+	return err
+}
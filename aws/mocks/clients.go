@@ -7,7 +7,7 @@ import (
 
 // MockClients struct
 type MockClients struct {
-	S3  *mocks.MockS3Client
+	S3  *S3Client
 	ASG *ASGClient
 	ELB *ELBClient
 	EC2 *EC2Client
@@ -16,12 +16,24 @@ type MockClients struct {
 	IAM *IAMClient
 	SNS *SNSClient
 	SFN *mocks.MockSFNClient
+	STS *STSClient
+	SSM *SSMClient
+	SM  *SMClient
+
+	Lambda  *LambdaClient
+	Mesh    *MeshClient
+	Pricing *PricingClient
+	KMS     *KMSClient
+
+	DynamoDB *DynamoDBClient
+	Quotas   *QuotasClient
+	ECR      *ECRClient
 }
 
 // MockAWS mock clients
 func MockAWS() *MockClients {
 	return &MockClients{
-		S3:  &mocks.MockS3Client{},
+		S3:  &S3Client{MockS3Client: &mocks.MockS3Client{}},
 		ASG: &ASGClient{},
 		ELB: &ELBClient{},
 		EC2: &EC2Client{},
@@ -30,6 +42,18 @@ func MockAWS() *MockClients {
 		IAM: &IAMClient{},
 		SNS: &SNSClient{},
 		SFN: &mocks.MockSFNClient{},
+		STS: &STSClient{},
+		SSM: &SSMClient{},
+		SM:  &SMClient{},
+
+		Lambda:  &LambdaClient{},
+		Mesh:    &MeshClient{},
+		Pricing: &PricingClient{},
+		KMS:     &KMSClient{},
+
+		DynamoDB: &DynamoDBClient{},
+		Quotas:   &QuotasClient{},
+		ECR:      &ECRClient{},
 	}
 }
 
@@ -77,3 +101,53 @@ func (a *MockClients) SNSClient(*string, *string, *string) aws.SNSAPI {
 func (a *MockClients) SFNClient(*string, *string, *string) aws.SFNAPI {
 	return a.SFN
 }
+
+// STSClient returns
+func (a *MockClients) STSClient(*string, *string, *string) aws.STSAPI {
+	return a.STS
+}
+
+// SSMClient returns
+func (a *MockClients) SSMClient(*string, *string, *string) aws.SSMAPI {
+	return a.SSM
+}
+
+// SMClient returns
+func (a *MockClients) SMClient(*string, *string, *string) aws.SMAPI {
+	return a.SM
+}
+
+// LambdaClient returns
+func (a *MockClients) LambdaClient(*string, *string, *string) aws.LambdaAPI {
+	return a.Lambda
+}
+
+// MeshClient returns
+func (a *MockClients) MeshClient(*string, *string, *string) aws.MeshAPI {
+	return a.Mesh
+}
+
+// PricingClient returns
+func (a *MockClients) PricingClient(*string, *string, *string) aws.PricingAPI {
+	return a.Pricing
+}
+
+// KMSClient returns
+func (a *MockClients) KMSClient(*string, *string, *string) aws.KMSAPI {
+	return a.KMS
+}
+
+// DynamoDBClient returns
+func (a *MockClients) DynamoDBClient(*string, *string, *string) aws.DynamoDBAPI {
+	return a.DynamoDB
+}
+
+// QuotasClient returns
+func (a *MockClients) QuotasClient(*string, *string, *string) aws.QuotasAPI {
+	return a.Quotas
+}
+
+// ECRClient returns
+func (a *MockClients) ECRClient(*string, *string, *string) aws.ECRAPI {
+	return a.ECR
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/elbv2"
 	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/weighted"
 	"github.com/coinbase/step/utils/to"
 )
 
@@ -13,6 +14,14 @@ type ALBClient struct {
 	DescribeTargetGroupsResp map[string]*DescribeTargetGroupsResponse
 	DescribeTagsResp         map[string]*DescribeV2TagsResponse
 	DescribeTargetHealthResp map[string]*DescribeTargetHealthResponse
+
+	// Attributes of target groups and load balancers by ARN
+	Attributes map[string]map[string]string
+
+	Rules []*elbv2.Rule
+
+	// RuleWeights are the weights of the target groups of each rule
+	RuleWeights map[string]map[string]int64
 }
 
 // DescribeTargetGroupsResponse return
@@ -58,7 +67,7 @@ func (m *ALBClient) AddTargetGroup(name string, projectName string, configName s
 	m.DescribeTargetGroupsResp[name] = &DescribeTargetGroupsResponse{
 		Resp: &elbv2.DescribeTargetGroupsOutput{
 			TargetGroups: []*elbv2.TargetGroup{
-				&elbv2.TargetGroup{TargetGroupName: &name, TargetGroupArn: &name},
+				&elbv2.TargetGroup{TargetGroupName: &name, TargetGroupArn: &name, VpcId: to.Strp("vpc-1"), LoadBalancerArns: []*string{to.Strp(name + "-lb")}},
 			},
 		},
 	}
@@ -78,6 +87,8 @@ func (m *ALBClient) AddTargetGroup(name string, projectName string, configName s
 		},
 	}
 
+	m.AddLoadBalancerTags(name+"-lb", projectName, configName)
+
 	m.DescribeTargetHealthResp[name] = &DescribeTargetHealthResponse{
 		Resp: &elbv2.DescribeTargetHealthOutput{
 			TargetHealthDescriptions: []*elbv2.TargetHealthDescription{
@@ -91,6 +102,24 @@ func (m *ALBClient) AddTargetGroup(name string, projectName string, configName s
 
 }
 
+// AddLoadBalancerTags sets the ProjectName and ConfigName tags of the load balancer
+func (m *ALBClient) AddLoadBalancerTags(arn string, projectName string, configName string) {
+	m.init()
+	m.DescribeTagsResp[arn] = &DescribeV2TagsResponse{
+		Resp: &elbv2.DescribeTagsOutput{
+			TagDescriptions: []*elbv2.TagDescription{
+				&elbv2.TagDescription{
+					ResourceArn: to.Strp(arn),
+					Tags: []*elbv2.Tag{
+						&elbv2.Tag{Key: to.Strp("ProjectName"), Value: to.Strp(projectName)},
+						&elbv2.Tag{Key: to.Strp("ConfigName"), Value: to.Strp(configName)},
+					},
+				},
+			},
+		},
+	}
+}
+
 // DescribeTargetGroups return
 func (m *ALBClient) DescribeTargetGroups(in *elbv2.DescribeTargetGroupsInput) (*elbv2.DescribeTargetGroupsOutput, error) {
 	m.init()
@@ -128,3 +157,94 @@ func (m *ALBClient) DescribeTargetHealth(in *elbv2.DescribeTargetHealthInput) (*
 
 	return resp.Resp, resp.Error
 }
+
+func (m *ALBClient) attributes(arn *string) map[string]string {
+	if m.Attributes == nil {
+		m.Attributes = map[string]map[string]string{}
+	}
+
+	if m.Attributes[*arn] == nil {
+		m.Attributes[*arn] = map[string]string{}
+	}
+
+	return m.Attributes[*arn]
+}
+
+// DescribeTargetGroupAttributes return
+func (m *ALBClient) DescribeTargetGroupAttributes(in *elbv2.DescribeTargetGroupAttributesInput) (*elbv2.DescribeTargetGroupAttributesOutput, error) {
+	out := &elbv2.DescribeTargetGroupAttributesOutput{}
+	for k, v := range m.attributes(in.TargetGroupArn) {
+		out.Attributes = append(out.Attributes, &elbv2.TargetGroupAttribute{Key: to.Strp(k), Value: to.Strp(v)})
+	}
+	return out, nil
+}
+
+// ModifyTargetGroupAttributes return
+func (m *ALBClient) ModifyTargetGroupAttributes(in *elbv2.ModifyTargetGroupAttributesInput) (*elbv2.ModifyTargetGroupAttributesOutput, error) {
+	attrs := m.attributes(in.TargetGroupArn)
+	for _, a := range in.Attributes {
+		attrs[*a.Key] = *a.Value
+	}
+	return &elbv2.ModifyTargetGroupAttributesOutput{}, nil
+}
+
+// DescribeLoadBalancerAttributes return
+func (m *ALBClient) DescribeLoadBalancerAttributes(in *elbv2.DescribeLoadBalancerAttributesInput) (*elbv2.DescribeLoadBalancerAttributesOutput, error) {
+	out := &elbv2.DescribeLoadBalancerAttributesOutput{}
+	for k, v := range m.attributes(in.LoadBalancerArn) {
+		out.Attributes = append(out.Attributes, &elbv2.LoadBalancerAttribute{Key: to.Strp(k), Value: to.Strp(v)})
+	}
+	return out, nil
+}
+
+// ModifyLoadBalancerAttributes return
+func (m *ALBClient) ModifyLoadBalancerAttributes(in *elbv2.ModifyLoadBalancerAttributesInput) (*elbv2.ModifyLoadBalancerAttributesOutput, error) {
+	attrs := m.attributes(in.LoadBalancerArn)
+	for _, a := range in.Attributes {
+		attrs[*a.Key] = *a.Value
+	}
+	return &elbv2.ModifyLoadBalancerAttributesOutput{}, nil
+}
+
+// AddForwardRule adds a listener rule forwarding to the target group
+func (m *ALBClient) AddForwardRule(ruleArn string, priority string, targetGroupArn string) {
+	m.Rules = append(m.Rules, &elbv2.Rule{
+		RuleArn:  to.Strp(ruleArn),
+		Priority: to.Strp(priority),
+		Actions: []*elbv2.Action{
+			&elbv2.Action{Type: to.Strp(elbv2.ActionTypeEnumForward), TargetGroupArn: to.Strp(targetGroupArn)},
+		},
+	})
+}
+
+// DescribeRules return
+func (m *ALBClient) DescribeRules(in *elbv2.DescribeRulesInput) (*elbv2.DescribeRulesOutput, error) {
+	return &elbv2.DescribeRulesOutput{Rules: m.Rules}, nil
+}
+
+// ModifyRule return
+func (m *ALBClient) ModifyRule(in *elbv2.ModifyRuleInput) (*elbv2.ModifyRuleOutput, error) {
+	for _, rule := range m.Rules {
+		if *rule.RuleArn == *in.RuleArn {
+			rule.Actions = in.Actions
+			return &elbv2.ModifyRuleOutput{Rules: []*elbv2.Rule{rule}}, nil
+		}
+	}
+
+	return nil, awserr.New(elbv2.ErrCodeRuleNotFoundException, "RuleNotFound", nil)
+}
+
+// ModifyRuleWeights return
+func (m *ALBClient) ModifyRuleWeights(in *weighted.ModifyRuleInput) (*weighted.ModifyRuleOutput, error) {
+	if m.RuleWeights == nil {
+		m.RuleWeights = map[string]map[string]int64{}
+	}
+
+	weights := map[string]int64{}
+	for _, tg := range in.Actions[0].ForwardConfig.TargetGroups {
+		weights[*tg.TargetGroupArn] = *tg.Weight
+	}
+
+	m.RuleWeights[*in.RuleArn] = weights
+	return &weighted.ModifyRuleOutput{}, nil
+}
@@ -0,0 +1,63 @@
+package mocks
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/appmesh"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// MeshClient returns
+type MeshClient struct {
+	aws.MeshAPI
+	Routes map[string]*appmesh.RouteData
+}
+
+func routeKey(mesh *string, router *string, route *string) string {
+	return fmt.Sprintf("%v/%v/%v", to.Strs(mesh), to.Strs(router), to.Strs(route))
+}
+
+// AddRoute adds an HTTP route with weighted targets
+func (m *MeshClient) AddRoute(mesh string, router string, route string, weights map[string]int64) {
+	if m.Routes == nil {
+		m.Routes = map[string]*appmesh.RouteData{}
+	}
+
+	targets := []*appmesh.WeightedTarget{}
+	for node, weight := range weights {
+		targets = append(targets, &appmesh.WeightedTarget{VirtualNode: to.Strp(node), Weight: to.Int64p(weight)})
+	}
+
+	m.Routes[routeKey(&mesh, &router, &route)] = &appmesh.RouteData{
+		MeshName:          to.Strp(mesh),
+		VirtualRouterName: to.Strp(router),
+		RouteName:         to.Strp(route),
+		Status:            &appmesh.RouteStatus{Status: to.Strp(appmesh.RouteStatusCodeActive)},
+		Spec: &appmesh.RouteSpec{
+			HttpRoute: &appmesh.HttpRoute{
+				Match:  &appmesh.HttpRouteMatch{Prefix: to.Strp("/")},
+				Action: &appmesh.HttpRouteAction{WeightedTargets: targets},
+			},
+		},
+	}
+}
+
+// DescribeRoute returns
+func (m *MeshClient) DescribeRoute(in *appmesh.DescribeRouteInput) (*appmesh.DescribeRouteOutput, error) {
+	route, ok := m.Routes[routeKey(in.MeshName, in.VirtualRouterName, in.RouteName)]
+	if !ok {
+		return nil, fmt.Errorf("NotFoundException")
+	}
+	return &appmesh.DescribeRouteOutput{Route: route}, nil
+}
+
+// UpdateRoute returns
+func (m *MeshClient) UpdateRoute(in *appmesh.UpdateRouteInput) (*appmesh.UpdateRouteOutput, error) {
+	route, ok := m.Routes[routeKey(in.MeshName, in.VirtualRouterName, in.RouteName)]
+	if !ok {
+		return nil, fmt.Errorf("NotFoundException")
+	}
+	route.Spec = in.Spec
+	return &appmesh.UpdateRouteOutput{Route: route}, nil
+}
@@ -2,9 +2,12 @@ package mocks
 
 import (
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/rebalance"
 	"github.com/coinbase/step/utils/to"
 )
 
@@ -32,6 +35,16 @@ type ASGClient struct {
 	DescribeAutoScalingGroupsPageResp []DescribeAutoScalingGroupResponse
 	DescribeLaunchConfigurationsResp  map[string]*DescribeLaunchConfigurationsResponse
 	DescribePoliciesResp              map[string]*DescribePoliciesResponse
+	AccountLimits                     *autoscaling.DescribeAccountLimitsOutput
+	CreateAutoScalingGroupError       error
+	DeleteAutoScalingGroupError       error
+	ScalingActivities                 []*autoscaling.Activity
+	UpdateAutoScalingGroupInputs      []*autoscaling.UpdateAutoScalingGroupInput
+	LifecycleHooks                    map[string][]*autoscaling.LifecycleHook
+	SetInstanceProtectionInputs       []*autoscaling.SetInstanceProtectionInput
+	CapacityRebalanceInputs           []*rebalance.UpdateAutoScalingGroupInput
+	DeletedASGs                       []string
+	DeletedLaunchConfigurations       []string
 }
 
 func (m *ASGClient) init() {
@@ -170,12 +183,17 @@ func (m *ASGClient) DescribeAutoScalingGroupsPages(input *autoscaling.DescribeAu
 
 // DeleteAutoScalingGroup returns
 func (m *ASGClient) DeleteAutoScalingGroup(input *autoscaling.DeleteAutoScalingGroupInput) (*autoscaling.DeleteAutoScalingGroupOutput, error) {
+	if m.DeleteAutoScalingGroupError != nil {
+		return nil, m.DeleteAutoScalingGroupError
+	}
+
+	m.DeletedASGs = append(m.DeletedASGs, *input.AutoScalingGroupName)
 	return nil, nil
 }
 
 // CreateAutoScalingGroup returns
 func (m *ASGClient) CreateAutoScalingGroup(input *autoscaling.CreateAutoScalingGroupInput) (*autoscaling.CreateAutoScalingGroupOutput, error) {
-	return nil, nil
+	return nil, m.CreateAutoScalingGroupError
 }
 
 // DescribeLaunchConfigurations returns
@@ -196,9 +214,45 @@ func (m *ASGClient) CreateLaunchConfiguration(input *autoscaling.CreateLaunchCon
 
 // DeleteLaunchConfiguration returns
 func (m *ASGClient) DeleteLaunchConfiguration(input *autoscaling.DeleteLaunchConfigurationInput) (*autoscaling.DeleteLaunchConfigurationOutput, error) {
+	m.DeletedLaunchConfigurations = append(m.DeletedLaunchConfigurations, *input.LaunchConfigurationName)
 	return nil, nil
 }
 
+// AddLaunchConfiguration adds a launch configuration not used by any ASG
+func (m *ASGClient) AddLaunchConfiguration(name string, createdAt time.Time) {
+	m.init()
+	m.DescribeLaunchConfigurationsResp[name] = &DescribeLaunchConfigurationsResponse{
+		Resp: &autoscaling.DescribeLaunchConfigurationsOutput{
+			LaunchConfigurations: []*autoscaling.LaunchConfiguration{
+				&autoscaling.LaunchConfiguration{LaunchConfigurationName: to.Strp(name), CreatedTime: to.Timep(createdAt)},
+			},
+		},
+	}
+}
+
+// DescribeLaunchConfigurationsPages returns every launch configuration in one page
+func (m *ASGClient) DescribeLaunchConfigurationsPages(input *autoscaling.DescribeLaunchConfigurationsInput, fn func(*autoscaling.DescribeLaunchConfigurationsOutput, bool) bool) error {
+	m.init()
+
+	names := []string{}
+	for name := range m.DescribeLaunchConfigurationsResp {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	page := &autoscaling.DescribeLaunchConfigurationsOutput{}
+	for _, name := range names {
+		resp := m.DescribeLaunchConfigurationsResp[name]
+		if resp.Error != nil {
+			return resp.Error
+		}
+		page.LaunchConfigurations = append(page.LaunchConfigurations, resp.Resp.LaunchConfigurations...)
+	}
+
+	fn(page, true)
+	return nil
+}
+
 // DescribePolicies returns
 func (m *ASGClient) DescribePolicies(in *autoscaling.DescribePoliciesInput) (*autoscaling.DescribePoliciesOutput, error) {
 	m.init()
@@ -213,3 +267,76 @@ func (m *ASGClient) DescribePolicies(in *autoscaling.DescribePoliciesInput) (*au
 func (m *ASGClient) PutScalingPolicy(input *autoscaling.PutScalingPolicyInput) (*autoscaling.PutScalingPolicyOutput, error) {
 	return &autoscaling.PutScalingPolicyOutput{PolicyARN: to.Strp("arn")}, nil
 }
+
+// DescribeAccountLimits returns
+func (m *ASGClient) DescribeAccountLimits(in *autoscaling.DescribeAccountLimitsInput) (*autoscaling.DescribeAccountLimitsOutput, error) {
+	if m.AccountLimits == nil {
+		return &autoscaling.DescribeAccountLimitsOutput{
+			MaxNumberOfAutoScalingGroups:    to.Int64p(200),
+			NumberOfAutoScalingGroups:       to.Int64p(0),
+			MaxNumberOfLaunchConfigurations: to.Int64p(200),
+			NumberOfLaunchConfigurations:    to.Int64p(0),
+		}, nil
+	}
+	return m.AccountLimits, nil
+}
+
+// DescribeScalingActivities returns
+func (m *ASGClient) DescribeScalingActivities(in *autoscaling.DescribeScalingActivitiesInput) (*autoscaling.DescribeScalingActivitiesOutput, error) {
+	return &autoscaling.DescribeScalingActivitiesOutput{Activities: m.ScalingActivities}, nil
+}
+
+// AddInsufficientCapacityActivity adds a failed scaling activity
+func (m *ASGClient) AddInsufficientCapacityActivity(startTime time.Time) {
+	m.ScalingActivities = append(m.ScalingActivities, &autoscaling.Activity{
+		StartTime:     &startTime,
+		StatusCode:    to.Strp(autoscaling.ScalingActivityStatusCodeFailed),
+		StatusMessage: to.Strp("We currently do not have sufficient capacity in the Availability Zone you requested. Launching EC2 instance failed. InsufficientInstanceCapacity"),
+	})
+}
+
+// UpdateAutoScalingGroup returns
+func (m *ASGClient) UpdateAutoScalingGroup(in *autoscaling.UpdateAutoScalingGroupInput) (*autoscaling.UpdateAutoScalingGroupOutput, error) {
+	m.UpdateAutoScalingGroupInputs = append(m.UpdateAutoScalingGroupInputs, in)
+	return &autoscaling.UpdateAutoScalingGroupOutput{}, nil
+}
+
+// AddTerminationHook adds a termination lifecycle hook to the ASG
+func (m *ASGClient) AddTerminationHook(asgName string, hookName string) {
+	if m.LifecycleHooks == nil {
+		m.LifecycleHooks = map[string][]*autoscaling.LifecycleHook{}
+	}
+
+	m.LifecycleHooks[asgName] = append(m.LifecycleHooks[asgName], &autoscaling.LifecycleHook{
+		AutoScalingGroupName: to.Strp(asgName),
+		LifecycleHookName:    to.Strp(hookName),
+		LifecycleTransition:  to.Strp("autoscaling:EC2_INSTANCE_TERMINATING"),
+	})
+}
+
+// DescribeLifecycleHooks returns
+func (m *ASGClient) DescribeLifecycleHooks(in *autoscaling.DescribeLifecycleHooksInput) (*autoscaling.DescribeLifecycleHooksOutput, error) {
+	return &autoscaling.DescribeLifecycleHooksOutput{LifecycleHooks: m.LifecycleHooks[*in.AutoScalingGroupName]}, nil
+}
+
+// SetInstanceProtection returns
+func (m *ASGClient) SetInstanceProtection(in *autoscaling.SetInstanceProtectionInput) (*autoscaling.SetInstanceProtectionOutput, error) {
+	m.SetInstanceProtectionInputs = append(m.SetInstanceProtectionInputs, in)
+	return &autoscaling.SetInstanceProtectionOutput{}, nil
+}
+
+// UpdateCapacityRebalance returns
+func (m *ASGClient) UpdateCapacityRebalance(in *rebalance.UpdateAutoScalingGroupInput) (*autoscaling.UpdateAutoScalingGroupOutput, error) {
+	m.CapacityRebalanceInputs = append(m.CapacityRebalanceInputs, in)
+	return &autoscaling.UpdateAutoScalingGroupOutput{}, nil
+}
+
+// AddSpotInterruptionActivity adds an activity terminating the instance for a Spot interruption
+func (m *ASGClient) AddSpotInterruptionActivity(instanceID string) {
+	m.ScalingActivities = append(m.ScalingActivities, &autoscaling.Activity{
+		StartTime:   to.Timep(time.Now()),
+		StatusCode:  to.Strp(autoscaling.ScalingActivityStatusCodeInProgress),
+		Description: to.Strp(fmt.Sprintf("Terminating EC2 instance: %v", instanceID)),
+		Cause:       to.Strp("At 2020-01-01T00:00:00Z an instance was taken out of service in response to an EC2 Spot Instance interruption notice."),
+	})
+}
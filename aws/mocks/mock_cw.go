@@ -3,11 +3,19 @@ package mocks
 import (
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
 )
 
 // CWClient struct
 type CWClient struct {
 	aws.CWAPI
+	MetricData []*cloudwatch.MetricDatum
+
+	// AlarmStates are the states of alarms by name
+	AlarmStates map[string]string
+
+	// MetricMathValues are the values of metric math expressions
+	MetricMathValues map[string]float64
 }
 
 // DeleteAlarms returns
@@ -19,3 +27,37 @@ func (m *CWClient) DeleteAlarms(input *cloudwatch.DeleteAlarmsInput) (*cloudwatc
 func (m *CWClient) PutMetricAlarm(input *cloudwatch.PutMetricAlarmInput) (*cloudwatch.PutMetricAlarmOutput, error) {
 	return nil, nil
 }
+
+// PutMetricData records the metrics
+func (m *CWClient) PutMetricData(input *cloudwatch.PutMetricDataInput) (*cloudwatch.PutMetricDataOutput, error) {
+	m.MetricData = append(m.MetricData, input.MetricData...)
+	return &cloudwatch.PutMetricDataOutput{}, nil
+}
+
+// DescribeAlarms returns
+func (m *CWClient) DescribeAlarms(input *cloudwatch.DescribeAlarmsInput) (*cloudwatch.DescribeAlarmsOutput, error) {
+	out := &cloudwatch.DescribeAlarmsOutput{}
+	for _, name := range input.AlarmNames {
+		if state, ok := m.AlarmStates[*name]; ok {
+			out.MetricAlarms = append(out.MetricAlarms, &cloudwatch.MetricAlarm{AlarmName: name, StateValue: to.Strp(state)})
+		}
+	}
+	return out, nil
+}
+
+// GetMetricData returns the value of the expression query
+func (m *CWClient) GetMetricData(input *cloudwatch.GetMetricDataInput) (*cloudwatch.GetMetricDataOutput, error) {
+	out := &cloudwatch.GetMetricDataOutput{}
+	for _, query := range input.MetricDataQueries {
+		if query.Expression == nil {
+			continue
+		}
+
+		result := &cloudwatch.MetricDataResult{Id: query.Id}
+		if value, ok := m.MetricMathValues[*query.Expression]; ok {
+			result.Values = []*float64{&value}
+		}
+		out.MetricDataResults = append(out.MetricDataResults, result)
+	}
+	return out, nil
+}
@@ -0,0 +1,252 @@
+package mocks
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// DynamoDBClient returns
+// Tables are keyed by a single string hash key, condition expressions support attribute_exists,
+// attribute_not_exists, = and < clauses joined by only AND or only OR
+type DynamoDBClient struct {
+	aws.DynamoDBAPI
+	HashKey string                                                    // Defaults to lock_id
+	Tables  map[string]map[string]map[string]*dynamodb.AttributeValue // Items by table and hash key
+}
+
+func (m *DynamoDBClient) init() {
+	if m.HashKey == "" {
+		m.HashKey = "lock_id"
+	}
+
+	if m.Tables == nil {
+		m.Tables = map[string]map[string]map[string]*dynamodb.AttributeValue{}
+	}
+}
+
+// table returns the items of the table, creating it
+func (m *DynamoDBClient) table(name *string) map[string]map[string]*dynamodb.AttributeValue {
+	m.init()
+	if m.Tables[*name] == nil {
+		m.Tables[*name] = map[string]map[string]*dynamodb.AttributeValue{}
+	}
+	return m.Tables[*name]
+}
+
+func (m *DynamoDBClient) hashKey(key map[string]*dynamodb.AttributeValue) (string, error) {
+	m.init()
+	value := key[m.HashKey]
+	if value == nil || value.S == nil {
+		return "", awserr.New("ValidationException", fmt.Sprintf("key %v missing", m.HashKey), nil)
+	}
+	return *value.S, nil
+}
+
+// GetItem returns
+func (m *DynamoDBClient) GetItem(in *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	key, err := m.hashKey(in.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynamodb.GetItemOutput{Item: m.table(in.TableName)[key]}, nil
+}
+
+// PutItem returns
+func (m *DynamoDBClient) PutItem(in *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	key, err := m.hashKey(in.Item)
+	if err != nil {
+		return nil, err
+	}
+
+	table := m.table(in.TableName)
+	if err := checkCondition(in.ConditionExpression, table[key], in.ExpressionAttributeValues); err != nil {
+		return nil, err
+	}
+
+	table[key] = in.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+// UpdateItem supports SET of values or if_not_exists(a, :v) + :w, then REMOVE
+func (m *DynamoDBClient) UpdateItem(in *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	key, err := m.hashKey(in.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	table := m.table(in.TableName)
+	item := table[key]
+	if err := checkCondition(in.ConditionExpression, item, in.ExpressionAttributeValues); err != nil {
+		return nil, err
+	}
+
+	updated := map[string]*dynamodb.AttributeValue{}
+	for name, value := range item {
+		updated[name] = value
+	}
+
+	for name, value := range in.Key {
+		updated[name] = value
+	}
+
+	set, remove := *in.UpdateExpression, ""
+	if i := strings.Index(set, "REMOVE "); i >= 0 {
+		set, remove = set[:i], set[i+len("REMOVE "):]
+	}
+
+	for _, clause := range splitClauses(strings.TrimPrefix(strings.TrimSpace(set), "SET ")) {
+		parts := strings.SplitN(clause, " = ", 2)
+		if len(parts) != 2 {
+			return nil, awserr.New("ValidationException", fmt.Sprintf("update %v unsupported", clause), nil)
+		}
+
+		value, err := updateValue(parts[1], item, in.ExpressionAttributeValues)
+		if err != nil {
+			return nil, err
+		}
+		updated[parts[0]] = value
+	}
+
+	for _, name := range splitClauses(remove) {
+		delete(updated, name)
+	}
+
+	table[key] = updated
+	return &dynamodb.UpdateItemOutput{Attributes: updated}, nil
+}
+
+// updateValue returns the value of a SET clause, a value or if_not_exists(a, :v) + :w
+func updateValue(expression string, item map[string]*dynamodb.AttributeValue, values map[string]*dynamodb.AttributeValue) (*dynamodb.AttributeValue, error) {
+	if !strings.HasPrefix(expression, "if_not_exists(") {
+		return values[expression], nil
+	}
+
+	var name, initial, increment string
+	if _, err := fmt.Sscanf(strings.NewReplacer("(", " ", ")", " ", ",", " ", "+", " ").Replace(expression), "if_not_exists %s %s %s", &name, &initial, &increment); err != nil {
+		return nil, awserr.New("ValidationException", fmt.Sprintf("update %v unsupported", expression), nil)
+	}
+
+	current := item[name]
+	if current == nil {
+		current = values[initial]
+	}
+
+	a, aerr := strconv.ParseInt(to.Strs(current.N), 10, 64)
+	b, berr := strconv.ParseInt(to.Strs(values[increment].N), 10, 64)
+	if aerr != nil || berr != nil {
+		return nil, awserr.New("ValidationException", fmt.Sprintf("update %v not numbers", expression), nil)
+	}
+
+	return &dynamodb.AttributeValue{N: to.Strp(strconv.FormatInt(a+b, 10))}, nil
+}
+
+// splitClauses splits the comma separated clauses of an update, ignoring commas inside functions
+func splitClauses(expression string) []string {
+	clauses, depth, start := []string{}, 0, 0
+	for i, c := range expression {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				clauses = append(clauses, strings.TrimSpace(expression[start:i]))
+				start = i + 1
+			}
+		}
+	}
+
+	if last := strings.TrimSpace(expression[start:]); last != "" {
+		clauses = append(clauses, last)
+	}
+	return clauses
+}
+
+// DeleteItem returns
+func (m *DynamoDBClient) DeleteItem(in *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+	key, err := m.hashKey(in.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	table := m.table(in.TableName)
+	if err := checkCondition(in.ConditionExpression, table[key], in.ExpressionAttributeValues); err != nil {
+		return nil, err
+	}
+
+	delete(table, key)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func checkCondition(expression *string, item map[string]*dynamodb.AttributeValue, values map[string]*dynamodb.AttributeValue) error {
+	if expression == nil {
+		return nil
+	}
+
+	or := strings.Contains(*expression, " OR ")
+	sep := " AND "
+	if or {
+		sep = " OR "
+	}
+
+	for _, clause := range strings.Split(*expression, sep) {
+		matched := matches(strings.TrimSpace(clause), item, values)
+		if or && matched {
+			return nil
+		}
+		if !or && !matched {
+			return conditionFailed()
+		}
+	}
+
+	if or {
+		return conditionFailed()
+	}
+	return nil
+}
+
+func conditionFailed() error {
+	return awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+}
+
+func matches(clause string, item map[string]*dynamodb.AttributeValue, values map[string]*dynamodb.AttributeValue) bool {
+	clause = strings.Trim(clause, "()")
+
+	if strings.HasPrefix(clause, "attribute_not_exists(") {
+		return item[strings.TrimSuffix(strings.TrimPrefix(clause, "attribute_not_exists("), ")")] == nil
+	}
+
+	if strings.HasPrefix(clause, "attribute_exists(") {
+		return item[strings.TrimSuffix(strings.TrimPrefix(clause, "attribute_exists("), ")")] != nil
+	}
+
+	for _, op := range []string{" = ", " < "} {
+		parts := strings.SplitN(clause, op, 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		actual, expected := item[parts[0]], values[parts[1]]
+		if actual == nil || expected == nil {
+			return false
+		}
+
+		if op == " = " {
+			return actual.String() == expected.String()
+		}
+
+		a, aerr := strconv.ParseFloat(to.Strs(actual.N), 64)
+		e, eerr := strconv.ParseFloat(to.Strs(expected.N), 64)
+		return aerr == nil && eerr == nil && a < e
+	}
+
+	return false
+}
@@ -1,8 +1,13 @@
 package mocks
 
 import (
+	"encoding/base64"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/coinbase/odin/aws"
 	"github.com/coinbase/step/utils/to"
@@ -32,12 +37,36 @@ type EC2Client struct {
 	DescribeSecurityGroupsResp map[string]*DescribeSecurityGroupsResponse
 	DescribeSubnetsResp        *DescribeSubnetsResponse
 	DescribeImagesResp         *DescribeImagesResponse
+	PlacementGroups            map[string]*ec2.PlacementGroup
+	PlacementGroupsInUse       map[string]bool
+	Instances                  []*ec2.Instance
+
+	CapacityReservations           map[string]*ec2.CreateCapacityReservationInput
+	CreateCapacityReservationError error
+
+	LaunchTemplates        map[string]*ec2.CreateLaunchTemplateInput
+	LaunchTemplateVersions map[string][]*ec2.LaunchTemplateVersion
+	LaunchTemplateTags     map[string][]*ec2.Tag
+
+	InstanceStatuses []*ec2.InstanceStatus
+	ConsoleOutputs   map[string]string // Instance ID to its console output
+
+	InstanceTypeArchitectures map[string][]string // Instance types that are not x86_64
+	InstanceTypeVCPUs         map[string]int64    // Instance types that do not have 2 vCPUs
 }
 
 func (m *EC2Client) init() {
 	if m.DescribeSecurityGroupsResp == nil {
 		m.DescribeSecurityGroupsResp = map[string]*DescribeSecurityGroupsResponse{}
 	}
+
+	if m.PlacementGroups == nil {
+		m.PlacementGroups = map[string]*ec2.PlacementGroup{}
+	}
+
+	if m.PlacementGroupsInUse == nil {
+		m.PlacementGroupsInUse = map[string]bool{}
+	}
 }
 
 // AddSecurityGroup returns
@@ -59,7 +88,8 @@ func (m *EC2Client) AddImage(nameTag string, id string) {
 		Resp: &ec2.DescribeImagesOutput{
 			Images: []*ec2.Image{
 				&ec2.Image{
-					ImageId: to.Strp(id),
+					ImageId:      to.Strp(id),
+					Architecture: to.Strp("x86_64"),
 					Tags: []*ec2.Tag{
 						&ec2.Tag{Key: to.Strp("Name"), Value: to.Strp(nameTag)},
 						&ec2.Tag{Key: to.Strp("DeployWith"), Value: to.Strp("odin")},
@@ -72,19 +102,22 @@ func (m *EC2Client) AddImage(nameTag string, id string) {
 
 // AddSubnet returns
 func (m *EC2Client) AddSubnet(nameTag string, id string) {
-	m.DescribeSubnetsResp = &DescribeSubnetsResponse{
-		Resp: &ec2.DescribeSubnetsOutput{
-			Subnets: []*ec2.Subnet{
-				&ec2.Subnet{
-					SubnetId: to.Strp(id),
-					Tags: []*ec2.Tag{
-						&ec2.Tag{Key: to.Strp("Name"), Value: to.Strp(nameTag)},
-						&ec2.Tag{Key: to.Strp("DeployWith"), Value: to.Strp("odin")},
-					},
-				},
+	if m.DescribeSubnetsResp == nil {
+		m.DescribeSubnetsResp = &DescribeSubnetsResponse{Resp: &ec2.DescribeSubnetsOutput{}}
+	}
+
+	m.DescribeSubnetsResp.Resp.Subnets = append(m.DescribeSubnetsResp.Resp.Subnets,
+		&ec2.Subnet{
+			SubnetId:            to.Strp(id),
+			MapPublicIpOnLaunch: to.Boolp(false),
+			AvailabilityZone:    to.Strp("us-east-1a"),
+			VpcId:               to.Strp("vpc-1"),
+			Tags: []*ec2.Tag{
+				&ec2.Tag{Key: to.Strp("Name"), Value: to.Strp(nameTag)},
+				&ec2.Tag{Key: to.Strp("DeployWith"), Value: to.Strp("odin")},
 			},
 		},
-	}
+	)
 }
 
 // DescribeSecurityGroups returns
@@ -102,6 +135,7 @@ func (m *EC2Client) DescribeSecurityGroups(in *ec2.DescribeSecurityGroupsInput)
 func MakeMockSecurityGroup(name string, projectName string, configName string, serviceName string) *ec2.SecurityGroup {
 	return &ec2.SecurityGroup{
 		GroupId: to.Strp("group-id"),
+		VpcId:   to.Strp("vpc-1"),
 		Tags: []*ec2.Tag{
 			&ec2.Tag{Key: to.Strp("Name"), Value: to.Strp(name)},
 			&ec2.Tag{Key: to.Strp("ProjectName"), Value: to.Strp(projectName)},
@@ -120,6 +154,35 @@ func (m *EC2Client) DescribeSubnets(in *ec2.DescribeSubnetsInput) (*ec2.Describe
 	return m.DescribeSubnetsResp.Resp, m.DescribeSubnetsResp.Error
 }
 
+// DescribeInstanceTypes returns x86_64 and 2 vCPUs for instance types not in InstanceTypeArchitectures or InstanceTypeVCPUs
+func (m *EC2Client) DescribeInstanceTypes(in *ec2.DescribeInstanceTypesInput) (*ec2.DescribeInstanceTypesOutput, error) {
+	types := []*ec2.InstanceTypeInfo{}
+	for _, instanceType := range in.InstanceTypes {
+		architectures, ok := m.InstanceTypeArchitectures[*instanceType]
+		if !ok {
+			architectures = []string{"x86_64"}
+		}
+
+		supported := []*string{}
+		for _, architecture := range architectures {
+			supported = append(supported, to.Strp(architecture))
+		}
+
+		vcpus, ok := m.InstanceTypeVCPUs[*instanceType]
+		if !ok {
+			vcpus = 2
+		}
+
+		types = append(types, &ec2.InstanceTypeInfo{
+			InstanceType:  instanceType,
+			ProcessorInfo: &ec2.ProcessorInfo{SupportedArchitectures: supported},
+			VCpuInfo:      &ec2.VCpuInfo{DefaultVCpus: to.Int64p(vcpus)},
+		})
+	}
+
+	return &ec2.DescribeInstanceTypesOutput{InstanceTypes: types}, nil
+}
+
 // DescribeImages returns
 func (m *EC2Client) DescribeImages(in *ec2.DescribeImagesInput) (*ec2.DescribeImagesOutput, error) {
 	if m.DescribeImagesResp == nil {
@@ -128,3 +191,264 @@ func (m *EC2Client) DescribeImages(in *ec2.DescribeImagesInput) (*ec2.DescribeIm
 
 	return m.DescribeImagesResp.Resp, m.DescribeImagesResp.Error
 }
+
+// AddPlacementGroup returns
+func (m *EC2Client) AddPlacementGroup(name string, strategy string) {
+	m.init()
+	m.PlacementGroups[name] = &ec2.PlacementGroup{
+		GroupName: to.Strp(name),
+		Strategy:  to.Strp(strategy),
+		State:     to.Strp("available"),
+	}
+}
+
+// DescribePlacementGroups returns
+func (m *EC2Client) DescribePlacementGroups(in *ec2.DescribePlacementGroupsInput) (*ec2.DescribePlacementGroupsOutput, error) {
+	m.init()
+	groups := []*ec2.PlacementGroup{}
+
+	for _, name := range in.GroupNames {
+		if group := m.PlacementGroups[*name]; group != nil {
+			groups = append(groups, group)
+		}
+	}
+
+	for _, filter := range in.Filters {
+		prefix := strings.TrimSuffix(*filter.Values[0], "*")
+		for name, group := range m.PlacementGroups {
+			if strings.HasPrefix(name, prefix) {
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	return &ec2.DescribePlacementGroupsOutput{PlacementGroups: groups}, nil
+}
+
+// CreatePlacementGroup returns
+func (m *EC2Client) CreatePlacementGroup(in *ec2.CreatePlacementGroupInput) (*ec2.CreatePlacementGroupOutput, error) {
+	m.AddPlacementGroup(*in.GroupName, *in.Strategy)
+	return &ec2.CreatePlacementGroupOutput{}, nil
+}
+
+// CreateLaunchTemplate returns
+func (m *EC2Client) CreateLaunchTemplate(in *ec2.CreateLaunchTemplateInput) (*ec2.CreateLaunchTemplateOutput, error) {
+	if m.LaunchTemplates == nil {
+		m.LaunchTemplates = map[string]*ec2.CreateLaunchTemplateInput{}
+	}
+	m.LaunchTemplates[*in.LaunchTemplateName] = in
+	m.AddLaunchTemplateVersion(*in.LaunchTemplateName, time.Now())
+
+	for _, spec := range in.TagSpecifications {
+		if to.Strs(spec.ResourceType) == "launch-template" {
+			for _, tag := range spec.Tags {
+				m.TagLaunchTemplate(*in.LaunchTemplateName, *tag.Key, *tag.Value)
+			}
+		}
+	}
+
+	return &ec2.CreateLaunchTemplateOutput{}, nil
+}
+
+// TagLaunchTemplate adds the tag to the launch template
+func (m *EC2Client) TagLaunchTemplate(name string, key string, value string) {
+	if m.LaunchTemplateTags == nil {
+		m.LaunchTemplateTags = map[string][]*ec2.Tag{}
+	}
+	m.LaunchTemplateTags[name] = append(m.LaunchTemplateTags[name], &ec2.Tag{Key: to.Strp(key), Value: to.Strp(value)})
+}
+
+// AddLaunchTemplateVersion adds the next version of the launch template, the first version is the default
+func (m *EC2Client) AddLaunchTemplateVersion(name string, createdAt time.Time) {
+	if m.LaunchTemplateVersions == nil {
+		m.LaunchTemplateVersions = map[string][]*ec2.LaunchTemplateVersion{}
+	}
+
+	versions := m.LaunchTemplateVersions[name]
+	number := int64(1)
+	if len(versions) > 0 {
+		number = *versions[len(versions)-1].VersionNumber + 1
+	}
+
+	m.LaunchTemplateVersions[name] = append(versions, &ec2.LaunchTemplateVersion{
+		LaunchTemplateName: to.Strp(name),
+		VersionNumber:      to.Int64p(number),
+		DefaultVersion:     to.Boolp(number == 1),
+		CreateTime:         to.Timep(createdAt),
+	})
+}
+
+// DescribeLaunchTemplates returns
+func (m *EC2Client) DescribeLaunchTemplates(in *ec2.DescribeLaunchTemplatesInput) (*ec2.DescribeLaunchTemplatesOutput, error) {
+	names := []string{}
+	for name := range m.LaunchTemplateVersions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	templates := []*ec2.LaunchTemplate{}
+	for _, name := range names {
+		versions := m.LaunchTemplateVersions[name]
+		if len(versions) == 0 {
+			continue
+		}
+
+		templates = append(templates, &ec2.LaunchTemplate{
+			LaunchTemplateName:   to.Strp(name),
+			CreateTime:           versions[0].CreateTime,
+			DefaultVersionNumber: to.Int64p(1),
+			LatestVersionNumber:  versions[len(versions)-1].VersionNumber,
+			Tags:                 m.LaunchTemplateTags[name],
+		})
+	}
+
+	return &ec2.DescribeLaunchTemplatesOutput{LaunchTemplates: templates}, nil
+}
+
+// DescribeLaunchTemplateVersions returns
+func (m *EC2Client) DescribeLaunchTemplateVersions(in *ec2.DescribeLaunchTemplateVersionsInput) (*ec2.DescribeLaunchTemplateVersionsOutput, error) {
+	return &ec2.DescribeLaunchTemplateVersionsOutput{
+		LaunchTemplateVersions: m.LaunchTemplateVersions[*in.LaunchTemplateName],
+	}, nil
+}
+
+// DeleteLaunchTemplateVersions returns
+func (m *EC2Client) DeleteLaunchTemplateVersions(in *ec2.DeleteLaunchTemplateVersionsInput) (*ec2.DeleteLaunchTemplateVersionsOutput, error) {
+	deleted := map[string]bool{}
+	for _, v := range in.Versions {
+		deleted[*v] = true
+	}
+
+	kept := []*ec2.LaunchTemplateVersion{}
+	for _, version := range m.LaunchTemplateVersions[*in.LaunchTemplateName] {
+		if !deleted[fmt.Sprintf("%v", *version.VersionNumber)] {
+			kept = append(kept, version)
+		}
+	}
+	m.LaunchTemplateVersions[*in.LaunchTemplateName] = kept
+
+	return &ec2.DeleteLaunchTemplateVersionsOutput{}, nil
+}
+
+// DeleteLaunchTemplate returns
+func (m *EC2Client) DeleteLaunchTemplate(in *ec2.DeleteLaunchTemplateInput) (*ec2.DeleteLaunchTemplateOutput, error) {
+	delete(m.LaunchTemplates, *in.LaunchTemplateName)
+	delete(m.LaunchTemplateVersions, *in.LaunchTemplateName)
+	return &ec2.DeleteLaunchTemplateOutput{}, nil
+}
+
+// DeletePlacementGroup returns
+func (m *EC2Client) DeletePlacementGroup(in *ec2.DeletePlacementGroupInput) (*ec2.DeletePlacementGroupOutput, error) {
+	m.init()
+	if m.PlacementGroupsInUse[*in.GroupName] {
+		return nil, awserr.New("InvalidPlacementGroup.InUse", "InUse", nil)
+	}
+
+	delete(m.PlacementGroups, *in.GroupName)
+	return &ec2.DeletePlacementGroupOutput{}, nil
+}
+
+// AddInstances adds count running On-Demand instances of the instance type
+func (m *EC2Client) AddInstances(instanceType string, count int) {
+	for i := 0; i < count; i++ {
+		m.Instances = append(m.Instances, &ec2.Instance{
+			InstanceId:   to.Strp(fmt.Sprintf("i-%v", len(m.Instances))),
+			InstanceType: to.Strp(instanceType),
+		})
+	}
+}
+
+// DescribeInstancesPages returns
+func (m *EC2Client) DescribeInstancesPages(in *ec2.DescribeInstancesInput, fn func(*ec2.DescribeInstancesOutput, bool) bool) error {
+	fn(&ec2.DescribeInstancesOutput{
+		Reservations: []*ec2.Reservation{&ec2.Reservation{Instances: m.Instances}},
+	}, true)
+	return nil
+}
+
+// CreateCapacityReservation returns
+func (m *EC2Client) CreateCapacityReservation(in *ec2.CreateCapacityReservationInput) (*ec2.CreateCapacityReservationOutput, error) {
+	if m.CreateCapacityReservationError != nil {
+		return nil, m.CreateCapacityReservationError
+	}
+
+	if m.CapacityReservations == nil {
+		m.CapacityReservations = map[string]*ec2.CreateCapacityReservationInput{}
+	}
+
+	id := fmt.Sprintf("cr-%v", len(m.CapacityReservations))
+	m.CapacityReservations[id] = in
+	return &ec2.CreateCapacityReservationOutput{
+		CapacityReservation: &ec2.CapacityReservation{CapacityReservationId: &id},
+	}, nil
+}
+
+// DescribeCapacityReservations returns the active reservations of the instance type filtered on
+func (m *EC2Client) DescribeCapacityReservations(in *ec2.DescribeCapacityReservationsInput) (*ec2.DescribeCapacityReservationsOutput, error) {
+	reservations := []*ec2.CapacityReservation{}
+	for id, cr := range m.CapacityReservations {
+		for _, filter := range in.Filters {
+			if *filter.Name == "instance-type" && *filter.Values[0] != *cr.InstanceType {
+				cr = nil
+				break
+			}
+		}
+
+		if cr == nil {
+			continue
+		}
+
+		tags := []*ec2.Tag{}
+		for _, spec := range cr.TagSpecifications {
+			tags = append(tags, spec.Tags...)
+		}
+
+		reservations = append(reservations, &ec2.CapacityReservation{
+			CapacityReservationId: to.Strp(id),
+			InstanceType:          cr.InstanceType,
+			State:                 to.Strp("active"),
+			Tags:                  tags,
+		})
+	}
+
+	return &ec2.DescribeCapacityReservationsOutput{CapacityReservations: reservations}, nil
+}
+
+// CancelCapacityReservation returns
+func (m *EC2Client) CancelCapacityReservation(in *ec2.CancelCapacityReservationInput) (*ec2.CancelCapacityReservationOutput, error) {
+	delete(m.CapacityReservations, *in.CapacityReservationId)
+	return &ec2.CancelCapacityReservationOutput{Return: to.Boolp(true)}, nil
+}
+
+// AddInstance adds a running instance with a private IP
+func (m *EC2Client) AddInstance(id string, privateIP string) {
+	m.Instances = append(m.Instances, &ec2.Instance{InstanceId: to.Strp(id), PrivateIpAddress: to.Strp(privateIP)})
+}
+
+// DescribeInstanceStatus returns the statuses of the requested instances
+func (m *EC2Client) DescribeInstanceStatus(in *ec2.DescribeInstanceStatusInput) (*ec2.DescribeInstanceStatusOutput, error) {
+	ids := map[string]bool{}
+	for _, id := range in.InstanceIds {
+		ids[*id] = true
+	}
+
+	out := &ec2.DescribeInstanceStatusOutput{}
+	for _, status := range m.InstanceStatuses {
+		if ids[to.Strs(status.InstanceId)] {
+			out.InstanceStatuses = append(out.InstanceStatuses, status)
+		}
+	}
+	return out, nil
+}
+
+// GetConsoleOutput returns the base64 encoded console output of the instance
+func (m *EC2Client) GetConsoleOutput(in *ec2.GetConsoleOutputInput) (*ec2.GetConsoleOutputOutput, error) {
+	output, ok := m.ConsoleOutputs[*in.InstanceId]
+	if !ok {
+		return &ec2.GetConsoleOutputOutput{InstanceId: in.InstanceId}, nil
+	}
+	return &ec2.GetConsoleOutputOutput{
+		InstanceId: in.InstanceId,
+		Output:     to.Strp(base64.StdEncoding.EncodeToString([]byte(output))),
+	}, nil
+}
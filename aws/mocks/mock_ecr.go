@@ -0,0 +1,52 @@
+package mocks
+
+import (
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// ECRClient returns
+type ECRClient struct {
+	aws.ECRAPI
+	Images map[string][]string // Digests pushed to each repository
+}
+
+func (m *ECRClient) init() {
+	if m.Images == nil {
+		m.Images = map[string][]string{}
+	}
+}
+
+// AddImage pushes an image with the digest to the repository
+func (m *ECRClient) AddImage(repository string, digest string) {
+	m.init()
+	m.Images[repository] = append(m.Images[repository], digest)
+}
+
+// DescribeImages returns the images of the repository with the digests
+func (m *ECRClient) DescribeImages(in *ecr.DescribeImagesInput) (*ecr.DescribeImagesOutput, error) {
+	m.init()
+	out := &ecr.DescribeImagesOutput{}
+
+	for _, id := range in.ImageIds {
+		found := false
+		for _, digest := range m.Images[*in.RepositoryName] {
+			if id.ImageDigest != nil && *id.ImageDigest == digest {
+				out.ImageDetails = append(out.ImageDetails, &ecr.ImageDetail{
+					RegistryId:     in.RegistryId,
+					RepositoryName: in.RepositoryName,
+					ImageDigest:    to.Strp(digest),
+				})
+				found = true
+			}
+		}
+
+		if !found {
+			return nil, awserr.New(ecr.ErrCodeImageNotFoundException, "image not found", nil)
+		}
+	}
+
+	return out, nil
+}
@@ -31,6 +31,7 @@ type ELBClient struct {
 	DescribeLoadBalancersResp  map[string]*DescribeLoadBalancersResponse
 	DescribeTagsResp           map[string]*DescribeTagsResponse
 	DescribeInstanceHealthResp map[string]*DescribeInstanceHealthResponse
+	Attributes                 map[string]*elb.LoadBalancerAttributes
 }
 
 // AWSELBNotFoundError returns
@@ -58,7 +59,7 @@ func (m *ELBClient) AddELB(name string, projectName string, configName string, s
 	m.DescribeLoadBalancersResp[name] = &DescribeLoadBalancersResponse{
 		Resp: &elb.DescribeLoadBalancersOutput{
 			LoadBalancerDescriptions: []*elb.LoadBalancerDescription{
-				&elb.LoadBalancerDescription{LoadBalancerName: &name},
+				&elb.LoadBalancerDescription{LoadBalancerName: &name, VPCId: to.Strp("vpc-1")},
 			},
 		},
 	}
@@ -126,3 +127,21 @@ func (m *ELBClient) DescribeInstanceHealth(in *elb.DescribeInstanceHealthInput)
 	}
 	return resp.Resp, resp.Error
 }
+
+// DescribeLoadBalancerAttributes returns
+func (m *ELBClient) DescribeLoadBalancerAttributes(in *elb.DescribeLoadBalancerAttributesInput) (*elb.DescribeLoadBalancerAttributesOutput, error) {
+	attrs := m.Attributes[*in.LoadBalancerName]
+	if attrs == nil {
+		attrs = &elb.LoadBalancerAttributes{}
+	}
+	return &elb.DescribeLoadBalancerAttributesOutput{LoadBalancerAttributes: attrs}, nil
+}
+
+// ModifyLoadBalancerAttributes returns
+func (m *ELBClient) ModifyLoadBalancerAttributes(in *elb.ModifyLoadBalancerAttributesInput) (*elb.ModifyLoadBalancerAttributesOutput, error) {
+	if m.Attributes == nil {
+		m.Attributes = map[string]*elb.LoadBalancerAttributes{}
+	}
+	m.Attributes[*in.LoadBalancerName] = in.LoadBalancerAttributes
+	return &elb.ModifyLoadBalancerAttributesOutput{}, nil
+}
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"fmt"
+	"net/url"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/iam"
@@ -26,8 +27,19 @@ type IAMClient struct {
 	aws.IAMAPI
 	GetInstanceProfileResp map[string]*GetInstanceProfileResponse
 	GetRoleResp            map[string]*GetRoleResponse
+	DeniedActions          map[string]bool
+
+	CreatedRoles []*iam.CreateRoleInput
+	RolePolicies map[string]string
+	RoleTags     map[string][]*iam.Tag
+
+	AddedProfileRoles   []*iam.AddRoleToInstanceProfileInput
+	RemovedProfileRoles []*iam.RemoveRoleFromInstanceProfileInput
 }
 
+// EC2TrustPolicy is the URL encoded trust policy of mock roles
+var EC2TrustPolicy = url.QueryEscape(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"ec2.amazonaws.com"},"Action":"sts:AssumeRole"}]}`)
+
 func (m *IAMClient) init() {
 	if m.GetInstanceProfileResp == nil {
 		m.GetInstanceProfileResp = map[string]*GetInstanceProfileResponse{}
@@ -36,6 +48,10 @@ func (m *IAMClient) init() {
 	if m.GetRoleResp == nil {
 		m.GetRoleResp = map[string]*GetRoleResponse{}
 	}
+
+	if m.DeniedActions == nil {
+		m.DeniedActions = map[string]bool{}
+	}
 }
 
 // AWSProfileNotFoundError returns
@@ -51,6 +67,13 @@ func (m *IAMClient) AddGetInstanceProfile(profileName string, path string) {
 			InstanceProfile: &iam.InstanceProfile{
 				Arn:  to.Strp(fmt.Sprintf("%v%v", path, profileName)),
 				Path: to.Strp(path),
+				Roles: []*iam.Role{
+					&iam.Role{
+						Arn:                      to.Strp(fmt.Sprintf("%v%v-role", path, profileName)),
+						RoleName:                 to.Strp(fmt.Sprintf("%v-role", profileName)),
+						AssumeRolePolicyDocument: to.Strp(EC2TrustPolicy),
+					},
+				},
 			},
 		},
 	}
@@ -68,6 +91,42 @@ func (m *IAMClient) AddGetRole(roleName string) {
 	}
 }
 
+// AddBoundedRole adds a role with the path and permissions boundary
+func (m *IAMClient) AddBoundedRole(roleName string, path string, boundaryArn string) {
+	m.init()
+	m.GetRoleResp[roleName] = &GetRoleResponse{
+		Resp: &iam.GetRoleOutput{
+			Role: &iam.Role{
+				Arn:      to.Strp(roleName),
+				RoleName: to.Strp(roleName),
+				Path:     to.Strp(path),
+				PermissionsBoundary: &iam.AttachedPermissionsBoundary{
+					PermissionsBoundaryArn:  to.Strp(boundaryArn),
+					PermissionsBoundaryType: to.Strp(iam.PermissionsBoundaryAttachmentTypePolicy),
+				},
+			},
+		},
+	}
+}
+
+// TagRole tags the role, it only exists for ListRoleTags if it was added with AddGetRole or tagged
+func (m *IAMClient) TagRole(roleName string, key string, value string) {
+	if m.RoleTags == nil {
+		m.RoleTags = map[string][]*iam.Tag{}
+	}
+	m.RoleTags[roleName] = append(m.RoleTags[roleName], &iam.Tag{Key: to.Strp(key), Value: to.Strp(value)})
+}
+
+// ListRoleTags returns
+func (m *IAMClient) ListRoleTags(in *iam.ListRoleTagsInput) (*iam.ListRoleTagsOutput, error) {
+	m.init()
+	tags, tagged := m.RoleTags[*in.RoleName]
+	if !tagged && m.GetRoleResp[*in.RoleName] == nil {
+		return nil, AWSProfileNotFoundError()
+	}
+	return &iam.ListRoleTagsOutput{Tags: tags, IsTruncated: to.Boolp(false)}, nil
+}
+
 // GetInstanceProfile returns
 func (m *IAMClient) GetInstanceProfile(in *iam.GetInstanceProfileInput) (*iam.GetInstanceProfileOutput, error) {
 	m.init()
@@ -87,3 +146,64 @@ func (m *IAMClient) GetRole(in *iam.GetRoleInput) (*iam.GetRoleOutput, error) {
 	}
 	return resp.Resp, resp.Error
 }
+
+// DenyAction returns
+func (m *IAMClient) DenyAction(action string) {
+	m.init()
+	m.DeniedActions[action] = true
+}
+
+// SimulatePrincipalPolicy returns
+func (m *IAMClient) SimulatePrincipalPolicy(in *iam.SimulatePrincipalPolicyInput) (*iam.SimulatePolicyResponse, error) {
+	m.init()
+	results := []*iam.EvaluationResult{}
+	for _, action := range in.ActionNames {
+		for _, resource := range in.ResourceArns {
+			decision := iam.PolicyEvaluationDecisionTypeAllowed
+			if m.DeniedActions[*action] {
+				decision = iam.PolicyEvaluationDecisionTypeImplicitDeny
+			}
+
+			results = append(results, &iam.EvaluationResult{
+				EvalActionName:   action,
+				EvalResourceName: resource,
+				EvalDecision:     to.Strp(decision),
+			})
+		}
+	}
+	return &iam.SimulatePolicyResponse{EvaluationResults: results}, nil
+}
+
+// CreateRole returns
+func (m *IAMClient) CreateRole(in *iam.CreateRoleInput) (*iam.CreateRoleOutput, error) {
+	m.CreatedRoles = append(m.CreatedRoles, in)
+	m.AddBoundedRole(*in.RoleName, to.Strs(in.Path), to.Strs(in.PermissionsBoundary))
+	return &iam.CreateRoleOutput{Role: &iam.Role{RoleName: in.RoleName, Path: in.Path}}, nil
+}
+
+// PutRolePolicy returns
+func (m *IAMClient) PutRolePolicy(in *iam.PutRolePolicyInput) (*iam.PutRolePolicyOutput, error) {
+	if m.RolePolicies == nil {
+		m.RolePolicies = map[string]string{}
+	}
+	m.RolePolicies[*in.RoleName] = *in.PolicyDocument
+	return &iam.PutRolePolicyOutput{}, nil
+}
+
+// CreateInstanceProfile returns
+func (m *IAMClient) CreateInstanceProfile(in *iam.CreateInstanceProfileInput) (*iam.CreateInstanceProfileOutput, error) {
+	m.AddGetInstanceProfile(*in.InstanceProfileName, *in.Path)
+	return &iam.CreateInstanceProfileOutput{}, nil
+}
+
+// AddRoleToInstanceProfile returns
+func (m *IAMClient) AddRoleToInstanceProfile(in *iam.AddRoleToInstanceProfileInput) (*iam.AddRoleToInstanceProfileOutput, error) {
+	m.AddedProfileRoles = append(m.AddedProfileRoles, in)
+	return &iam.AddRoleToInstanceProfileOutput{}, nil
+}
+
+// RemoveRoleFromInstanceProfile returns
+func (m *IAMClient) RemoveRoleFromInstanceProfile(in *iam.RemoveRoleFromInstanceProfileInput) (*iam.RemoveRoleFromInstanceProfileOutput, error) {
+	m.RemovedProfileRoles = append(m.RemovedProfileRoles, in)
+	return &iam.RemoveRoleFromInstanceProfileOutput{}, nil
+}
@@ -0,0 +1,103 @@
+package mocks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// KMSClient returns
+// Signatures are HMACs keyed by the keys ARN, so only verify with the key that signed them
+// Encrypted data keys are the plaintext key with its keys ARN and context, so only decrypt with both
+type KMSClient struct {
+	aws.KMSAPI
+	Policies map[string]string // Key policies by key ID
+}
+
+// GetKeyPolicy returns
+func (m *KMSClient) GetKeyPolicy(in *kms.GetKeyPolicyInput) (*kms.GetKeyPolicyOutput, error) {
+	policy, ok := m.Policies[to.Strs(in.KeyId)]
+	if !ok {
+		return nil, awserr.New(kms.ErrCodeNotFoundException, "key not found", nil)
+	}
+
+	return &kms.GetKeyPolicyOutput{Policy: to.Strp(policy)}, nil
+}
+
+// keyARN returns the ARN of a key ID, alias or ARN
+func keyARN(keyID *string) string {
+	id := to.Strs(keyID)
+	if strings.HasPrefix(id, "arn:") {
+		return id
+	}
+	return fmt.Sprintf("arn:aws:kms:us-east-1:000000000000:key/%v", strings.TrimPrefix(id, "alias/"))
+}
+
+func mockSignature(keyID *string, message []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(keyARN(keyID)))
+	mac.Write(message)
+	return mac.Sum(nil)
+}
+
+// Sign returns
+func (m *KMSClient) Sign(in *kms.SignInput) (*kms.SignOutput, error) {
+	return &kms.SignOutput{
+		KeyId:            to.Strp(keyARN(in.KeyId)),
+		Signature:        mockSignature(in.KeyId, in.Message),
+		SigningAlgorithm: in.SigningAlgorithm,
+	}, nil
+}
+
+// Verify returns
+func (m *KMSClient) Verify(in *kms.VerifyInput) (*kms.VerifyOutput, error) {
+	if !hmac.Equal(in.Signature, mockSignature(in.KeyId, in.Message)) {
+		return nil, awserr.New(kms.ErrCodeKMSInvalidSignatureException, "signature invalid", nil)
+	}
+
+	return &kms.VerifyOutput{KeyId: to.Strp(keyARN(in.KeyId)), SignatureValid: to.Boolp(true), SigningAlgorithm: in.SigningAlgorithm}, nil
+}
+
+// mockDataKey is the "encrypted" data key
+type mockDataKey struct {
+	KeyID     string
+	Context   map[string]*string
+	Plaintext []byte
+}
+
+// GenerateDataKey returns
+func (m *KMSClient) GenerateDataKey(in *kms.GenerateDataKeyInput) (*kms.GenerateDataKeyOutput, error) {
+	plaintext := mockSignature(in.KeyId, []byte("data key"))
+	blob, _ := json.Marshal(&mockDataKey{KeyID: keyARN(in.KeyId), Context: in.EncryptionContext, Plaintext: plaintext})
+
+	return &kms.GenerateDataKeyOutput{
+		KeyId:          to.Strp(keyARN(in.KeyId)),
+		Plaintext:      plaintext,
+		CiphertextBlob: blob,
+	}, nil
+}
+
+// Decrypt returns
+func (m *KMSClient) Decrypt(in *kms.DecryptInput) (*kms.DecryptOutput, error) {
+	var dataKey mockDataKey
+	if err := json.Unmarshal(in.CiphertextBlob, &dataKey); err != nil {
+		return nil, awserr.New(kms.ErrCodeInvalidCiphertextException, "ciphertext invalid", nil)
+	}
+
+	if in.KeyId != nil && keyARN(in.KeyId) != dataKey.KeyID {
+		return nil, awserr.New(kms.ErrCodeIncorrectKeyException, "incorrect key", nil)
+	}
+
+	if !reflect.DeepEqual(in.EncryptionContext, dataKey.Context) {
+		return nil, awserr.New(kms.ErrCodeInvalidCiphertextException, "encryption context does not match", nil)
+	}
+
+	return &kms.DecryptOutput{KeyId: to.Strp(dataKey.KeyID), Plaintext: dataKey.Plaintext}, nil
+}
@@ -0,0 +1,61 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// LambdaClient returns
+type LambdaClient struct {
+	aws.LambdaAPI
+	Invoked   []*lambda.InvokeInput
+	Deadlines []bool // Whether each invocation had a deadline
+	Failures  map[string]string
+	Responses map[string]string
+}
+
+// RespondFunction makes invoking the function return the payload
+func (m *LambdaClient) RespondFunction(name string, payload string) {
+	if m.Responses == nil {
+		m.Responses = map[string]string{}
+	}
+	m.Responses[name] = payload
+}
+
+// FailFunction makes invoking the function return a function error with the message
+func (m *LambdaClient) FailFunction(name string, message string) {
+	if m.Failures == nil {
+		m.Failures = map[string]string{}
+	}
+	m.Failures[name] = message
+}
+
+// Invoke records the invocation
+func (m *LambdaClient) Invoke(in *lambda.InvokeInput) (*lambda.InvokeOutput, error) {
+	m.Invoked = append(m.Invoked, in)
+
+	if message, ok := m.Failures[*in.FunctionName]; ok {
+		return &lambda.InvokeOutput{
+			StatusCode:    to.Int64p(200),
+			FunctionError: to.Strp("Unhandled"),
+			Payload:       []byte(`{"errorMessage":"` + message + `"}`),
+		}, nil
+	}
+
+	if payload, ok := m.Responses[*in.FunctionName]; ok {
+		return &lambda.InvokeOutput{StatusCode: to.Int64p(200), Payload: []byte(payload)}, nil
+	}
+
+	return &lambda.InvokeOutput{StatusCode: to.Int64p(200), Payload: []byte(`{}`)}, nil
+}
+
+// InvokeWithContext records whether the invocation had a deadline, then invokes
+func (m *LambdaClient) InvokeWithContext(ctx context.Context, in *lambda.InvokeInput, _ ...request.Option) (*lambda.InvokeOutput, error) {
+	_, ok := ctx.Deadline()
+	m.Deadlines = append(m.Deadlines, ok)
+	return m.Invoke(in)
+}
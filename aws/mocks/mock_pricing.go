@@ -0,0 +1,53 @@
+package mocks
+
+import (
+	"fmt"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	"github.com/coinbase/odin/aws"
+)
+
+// PricingClient returns
+type PricingClient struct {
+	aws.PricingAPI
+	Prices map[string]float64 // Prices by instance type or EBS volume type
+}
+
+// AddPrice adds the price of an instance type or EBS volume type
+func (m *PricingClient) AddPrice(name string, price float64) {
+	if m.Prices == nil {
+		m.Prices = map[string]float64{}
+	}
+	m.Prices[name] = price
+}
+
+// GetProducts returns
+func (m *PricingClient) GetProducts(in *pricing.GetProductsInput) (*pricing.GetProductsOutput, error) {
+	for _, filter := range in.Filters {
+		if *filter.Field != "instanceType" && *filter.Field != "volumeApiName" {
+			continue
+		}
+
+		price, ok := m.Prices[*filter.Value]
+		if !ok {
+			return &pricing.GetProductsOutput{PriceList: []awssdk.JSONValue{}}, nil
+		}
+
+		return &pricing.GetProductsOutput{PriceList: []awssdk.JSONValue{
+			awssdk.JSONValue{"terms": map[string]interface{}{
+				"OnDemand": map[string]interface{}{
+					"SKU.OFFER": map[string]interface{}{
+						"priceDimensions": map[string]interface{}{
+							"SKU.OFFER.RATE": map[string]interface{}{
+								"pricePerUnit": map[string]interface{}{"USD": fmt.Sprintf("%v", price)},
+							},
+						},
+					},
+				},
+			}},
+		}}, nil
+	}
+
+	return &pricing.GetProductsOutput{PriceList: []awssdk.JSONValue{}}, nil
+}
@@ -0,0 +1,29 @@
+package mocks
+
+import (
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/servicequotas"
+	"github.com/coinbase/odin/aws"
+)
+
+// QuotasClient returns
+type QuotasClient struct {
+	aws.QuotasAPI
+	Quotas map[string]float64 // Values of EC2 quotas by quota code
+}
+
+// GetServiceQuota returns the quota, or NoSuchResourceException if it has not been set
+func (m *QuotasClient) GetServiceQuota(in *servicequotas.GetServiceQuotaInput) (*servicequotas.GetServiceQuotaOutput, error) {
+	value, ok := m.Quotas[*in.QuotaCode]
+	if !ok {
+		return nil, awserr.New(servicequotas.ErrCodeNoSuchResourceException, "NoSuchResource", nil)
+	}
+
+	return &servicequotas.GetServiceQuotaOutput{
+		Quota: &servicequotas.ServiceQuota{
+			ServiceCode: in.ServiceCode,
+			QuotaCode:   in.QuotaCode,
+			Value:       &value,
+		},
+	}, nil
+}
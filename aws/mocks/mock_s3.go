@@ -0,0 +1,87 @@
+package mocks
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+)
+
+// S3Client adds ETags and If-Match conditional writes to steps S3 mock
+type S3Client struct {
+	*mocks.MockS3Client
+
+	// BeforeConditionalWrite is called once before the next conditional write, to race it with another write
+	BeforeConditionalWrite func()
+}
+
+// GetObject returns the object with the MD5 of its body as its ETag, as S3 does for simple uploads
+func (m *S3Client) GetObject(in *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	out, err := m.MockS3Client.GetObject(in)
+	if err != nil || out == nil || out.Body == nil {
+		return out, err
+	}
+
+	defer out.Body.Close()
+	raw, err := ioutil.ReadAll(out.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	out.Body = ioutil.NopCloser(bytes.NewReader(raw))
+	out.ETag = to.Strp(fmt.Sprintf("%q", fmt.Sprintf("%x", md5.Sum(raw))))
+	return out, nil
+}
+
+// PutObjectWithContext puts the object if it matches the If-Match header
+func (m *S3Client) PutObjectWithContext(_ context.Context, in *s3.PutObjectInput, opts ...request.Option) (*s3.PutObjectOutput, error) {
+	if err := m.checkIfMatch(in.Bucket, in.Key, opts); err != nil {
+		return nil, err
+	}
+	return m.MockS3Client.PutObject(in)
+}
+
+// DeleteObjectWithContext deletes the object if it matches the If-Match header
+func (m *S3Client) DeleteObjectWithContext(_ context.Context, in *s3.DeleteObjectInput, opts ...request.Option) (*s3.DeleteObjectOutput, error) {
+	if err := m.checkIfMatch(in.Bucket, in.Key, opts); err != nil {
+		return nil, err
+	}
+	return m.MockS3Client.DeleteObject(in)
+}
+
+// checkIfMatch returns a PreconditionFailed error if the objects ETag is not the If-Match header the options set
+func (m *S3Client) checkIfMatch(bucket *string, key *string, opts []request.Option) error {
+	if race := m.BeforeConditionalWrite; race != nil {
+		m.BeforeConditionalWrite = nil
+		race()
+	}
+
+	r := &request.Request{HTTPRequest: &http.Request{Header: http.Header{}}}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	etag := r.HTTPRequest.Header.Get("If-Match")
+	if etag == "" {
+		return nil
+	}
+
+	out, err := m.GetObject(&s3.GetObjectInput{Bucket: bucket, Key: key})
+	if err != nil {
+		return err
+	}
+
+	if to.Strs(out.ETag) != etag {
+		return awserr.New("PreconditionFailed", "At least one of the pre-conditions you specified did not hold", nil)
+	}
+
+	return nil
+}
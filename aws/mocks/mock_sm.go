@@ -0,0 +1,37 @@
+package mocks
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/coinbase/odin/aws"
+)
+
+// SMClient returns
+type SMClient struct {
+	aws.SMAPI
+	Secrets map[string]string
+}
+
+func (m *SMClient) init() {
+	if m.Secrets == nil {
+		m.Secrets = map[string]string{}
+	}
+}
+
+// AddSecret adds a secret string
+func (m *SMClient) AddSecret(id string, value string) {
+	m.init()
+	m.Secrets[id] = value
+}
+
+// GetSecretValue returns
+func (m *SMClient) GetSecretValue(in *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+	m.init()
+	value, ok := m.Secrets[*in.SecretId]
+	if !ok {
+		return nil, fmt.Errorf("ResourceNotFoundException")
+	}
+
+	return &secretsmanager.GetSecretValueOutput{Name: in.SecretId, SecretString: &value}, nil
+}
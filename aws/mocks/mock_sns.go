@@ -3,14 +3,22 @@ package mocks
 import (
 	"github.com/aws/aws-sdk-go/service/sns"
 	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
 )
 
 // SNSClient returns
 type SNSClient struct {
 	aws.SNSAPI
+	Published []*sns.PublishInput
 }
 
 // GetTopicAttributes returns
 func (m *SNSClient) GetTopicAttributes(in *sns.GetTopicAttributesInput) (*sns.GetTopicAttributesOutput, error) {
 	return nil, nil
 }
+
+// Publish records the message
+func (m *SNSClient) Publish(in *sns.PublishInput) (*sns.PublishOutput, error) {
+	m.Published = append(m.Published, in)
+	return &sns.PublishOutput{MessageId: to.Strp("message-id")}, nil
+}
@@ -0,0 +1,84 @@
+package mocks
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/coinbase/odin/aws"
+)
+
+// SSMClient returns
+type SSMClient struct {
+	aws.SSMAPI
+	Parameters map[string]string
+	// SecureParameters are the names of the SecureString parameters
+	SecureParameters map[string]bool
+
+	SentCommands []*ssm.SendCommandInput
+	// CommandStatus is the status of the last command on each instance
+	CommandStatus map[string]string
+}
+
+func (m *SSMClient) init() {
+	if m.Parameters == nil {
+		m.Parameters = map[string]string{}
+	}
+
+	if m.SecureParameters == nil {
+		m.SecureParameters = map[string]bool{}
+	}
+}
+
+// AddParameter adds a parameter
+func (m *SSMClient) AddParameter(name string, value string) {
+	m.init()
+	m.Parameters[name] = value
+}
+
+// AddSecureParameter adds a SecureString parameter
+func (m *SSMClient) AddSecureParameter(name string, value string) {
+	m.AddParameter(name, value)
+	m.SecureParameters[name] = true
+}
+
+// GetParameter returns
+func (m *SSMClient) GetParameter(in *ssm.GetParameterInput) (*ssm.GetParameterOutput, error) {
+	m.init()
+	value, ok := m.Parameters[*in.Name]
+	if !ok {
+		return nil, awserr.New(ssm.ErrCodeParameterNotFound, "ParameterNotFound", nil)
+	}
+
+	paramType := ssm.ParameterTypeString
+	if m.SecureParameters[*in.Name] {
+		paramType = ssm.ParameterTypeSecureString
+	}
+
+	return &ssm.GetParameterOutput{
+		Parameter: &ssm.Parameter{Name: in.Name, Value: &value, Type: &paramType},
+	}, nil
+}
+
+// PutParameter sets the parameter
+func (m *SSMClient) PutParameter(in *ssm.PutParameterInput) (*ssm.PutParameterOutput, error) {
+	m.AddParameter(*in.Name, *in.Value)
+	return &ssm.PutParameterOutput{}, nil
+}
+
+// SendCommand records the command
+func (m *SSMClient) SendCommand(in *ssm.SendCommandInput) (*ssm.SendCommandOutput, error) {
+	m.SentCommands = append(m.SentCommands, in)
+	id := fmt.Sprintf("command-%v", len(m.SentCommands))
+	return &ssm.SendCommandOutput{Command: &ssm.Command{CommandId: &id}}, nil
+}
+
+// GetCommandInvocation returns
+func (m *SSMClient) GetCommandInvocation(in *ssm.GetCommandInvocationInput) (*ssm.GetCommandInvocationOutput, error) {
+	status, ok := m.CommandStatus[*in.InstanceId]
+	if !ok {
+		return nil, fmt.Errorf("InvocationDoesNotExist")
+	}
+
+	return &ssm.GetCommandInvocationOutput{CommandId: in.CommandId, InstanceId: in.InstanceId, Status: &status}, nil
+}
@@ -0,0 +1,20 @@
+package mocks
+
+import (
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// STSClient returns
+type STSClient struct {
+	aws.STSAPI
+}
+
+// GetCallerIdentity returns the deployers Lambda role
+func (m *STSClient) GetCallerIdentity(in *sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error) {
+	return &sts.GetCallerIdentityOutput{
+		Account: to.Strp("000000000000"),
+		Arn:     to.Strp("arn:aws:sts::000000000000:assumed-role/coinbase-odin/lambda"),
+	}, nil
+}
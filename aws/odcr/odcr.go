@@ -0,0 +1,81 @@
+package odcr
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Reserve creates an open reservation so matching instances launched in the zone use it
+// It ends at endDate, so capacity is not paid for forever if it is never cancelled
+func Reserve(ec2c aws.EC2API, instanceType *string, zone *string, count int64, name *string, endDate time.Time) (*string, error) {
+	out, err := ec2c.CreateCapacityReservation(&ec2.CreateCapacityReservationInput{
+		AvailabilityZone:      zone,
+		EndDateType:           to.Strp(ec2.EndDateTypeLimited),
+		EndDate:               &endDate,
+		InstanceCount:         &count,
+		InstanceMatchCriteria: to.Strp(ec2.InstanceMatchCriteriaOpen),
+		InstancePlatform:      to.Strp(ec2.CapacityReservationInstancePlatformLinuxUnix),
+		InstanceType:          instanceType,
+		TagSpecifications: []*ec2.TagSpecification{
+			&ec2.TagSpecification{
+				ResourceType: to.Strp(ec2.ResourceTypeCapacityReservation),
+				Tags:         []*ec2.Tag{&ec2.Tag{Key: to.Strp("Name"), Value: name}},
+			},
+		},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if out.CapacityReservation == nil || out.CapacityReservation.CapacityReservationId == nil {
+		return nil, fmt.Errorf("Capacity reservation for %v in %v has no ID", to.Strs(instanceType), to.Strs(zone))
+	}
+
+	return out.CapacityReservation.CapacityReservationId, nil
+}
+
+// FindActive returns the IDs of the active reservations of the instance type with the Name tag
+// A deploy that fails while reserving loses the IDs it created, so they are found again by name
+func FindActive(ec2c aws.EC2API, instanceType *string, name *string) ([]*string, error) {
+	ids := []*string{}
+	if instanceType == nil || name == nil {
+		return ids, nil
+	}
+
+	in := &ec2.DescribeCapacityReservationsInput{
+		Filters: []*ec2.Filter{
+			&ec2.Filter{Name: to.Strp("instance-type"), Values: []*string{instanceType}},
+			&ec2.Filter{Name: to.Strp("state"), Values: []*string{to.Strp(ec2.CapacityReservationStateActive)}},
+		},
+	}
+
+	for {
+		out, err := ec2c.DescribeCapacityReservations(in)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, cr := range out.CapacityReservations {
+			if cr.CapacityReservationId != nil && to.Strs(aws.FetchEc2Tag(cr.Tags, to.Strp("Name"))) == to.Strs(name) {
+				ids = append(ids, cr.CapacityReservationId)
+			}
+		}
+
+		if out.NextToken == nil {
+			return ids, nil
+		}
+
+		in.NextToken = out.NextToken
+	}
+}
+
+// Cancel releases the reserved capacity
+func Cancel(ec2c aws.EC2API, id *string) error {
+	_, err := ec2c.CancelCapacityReservation(&ec2.CancelCapacityReservationInput{CapacityReservationId: id})
+	return err
+}
@@ -0,0 +1,155 @@
+package pg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+const errCodeInUse = "InvalidPlacementGroup.InUse"
+
+// Transient groups are named <prefix><created_at>-<service>
+var transientSuffix = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}-\d{2}-\d{2}Z-`)
+
+// PlacementGroup struct
+type PlacementGroup struct {
+	GroupName *string
+	Strategy  *string
+	State     *string
+}
+
+// SafeName returns a valid placement group name from a name
+func SafeName(name *string) *string {
+	if name == nil {
+		return nil
+	}
+	return to.Strp(strings.Replace(*name, "/", "-", -1))
+}
+
+//////
+// Find
+//////
+
+// Find returns the placement group with name
+func Find(ec2c aws.EC2API, name *string) (*PlacementGroup, error) {
+	groups, err := find(ec2c, &ec2.DescribePlacementGroupsInput{GroupNames: []*string{name}})
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(groups) {
+	case 0:
+		return nil, fmt.Errorf("PlacementGroup %v not found", to.Strs(name))
+	case 1:
+		return groups[0], nil
+	default:
+		return nil, fmt.Errorf("Too many PlacementGroups found for %v", to.Strs(name))
+	}
+}
+
+func findWithPrefix(ec2c aws.EC2API, prefix *string) ([]*PlacementGroup, error) {
+	return find(ec2c, &ec2.DescribePlacementGroupsInput{
+		Filters: []*ec2.Filter{
+			&ec2.Filter{
+				Name:   to.Strp("group-name"),
+				Values: []*string{to.Strp(fmt.Sprintf("%v*", *prefix))},
+			},
+		},
+	})
+}
+
+func find(ec2c aws.EC2API, in *ec2.DescribePlacementGroupsInput) ([]*PlacementGroup, error) {
+	output, err := ec2c.DescribePlacementGroups(in)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []*PlacementGroup{}
+	for _, group := range output.PlacementGroups {
+		if group == nil {
+			continue
+		}
+
+		groups = append(groups, &PlacementGroup{
+			GroupName: group.GroupName,
+			Strategy:  group.Strategy,
+			State:     group.State,
+		})
+	}
+
+	return groups, nil
+}
+
+//////
+// Create
+//////
+
+// Create creates a placement group
+func Create(ec2c aws.EC2API, name *string, strategy *string, partitionCount *int64) error {
+	_, err := ec2c.CreatePlacementGroup(&ec2.CreatePlacementGroupInput{
+		GroupName:      name,
+		Strategy:       strategy,
+		PartitionCount: partitionCount,
+	})
+
+	return err
+}
+
+//////
+// Destruction
+//////
+
+// TeardownTransient deletes all placement groups starting with prefix that are not excluded
+// Groups that are still in use by terminating instances are skipped and removed by a later teardown
+func TeardownTransient(ec2c aws.EC2API, prefix *string, exclude []*string) error {
+	groups, err := findWithPrefix(ec2c, prefix)
+	if err != nil {
+		return err
+	}
+
+	excluded := map[string]bool{}
+	for _, name := range exclude {
+		if name != nil {
+			excluded[*name] = true
+		}
+	}
+
+	for _, group := range groups {
+		if group.GroupName == nil || excluded[*group.GroupName] {
+			continue
+		}
+
+		if !strings.HasPrefix(*group.GroupName, *prefix) {
+			// Extra safe
+			continue
+		}
+
+		if !transientSuffix.MatchString(strings.TrimPrefix(*group.GroupName, *prefix)) {
+			// Another configs group that shares the prefix, or not created by odin
+			continue
+		}
+
+		if err := teardown(ec2c, group.GroupName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func teardown(ec2c aws.EC2API, name *string) error {
+	_, err := ec2c.DeletePlacementGroup(&ec2.DeletePlacementGroupInput{GroupName: name})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == errCodeInUse {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
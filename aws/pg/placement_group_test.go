@@ -0,0 +1,51 @@
+package pg
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SafeName(t *testing.T) {
+	assert.Equal(t, "org-project-config", *SafeName(to.Strp("org/project-config")))
+	assert.Nil(t, SafeName(nil))
+}
+
+func Test_Find(t *testing.T) {
+	ec2c := &mocks.EC2Client{}
+	_, err := Find(ec2c, to.Strp("group"))
+	assert.Error(t, err)
+
+	ec2c.AddPlacementGroup("group", "cluster")
+	group, err := Find(ec2c, to.Strp("group"))
+	assert.NoError(t, err)
+	assert.Equal(t, "cluster", *group.Strategy)
+}
+
+func Test_TeardownTransient(t *testing.T) {
+	ec2c := &mocks.EC2Client{}
+	names := []string{
+		"project-config-2018-01-01T00-00-00Z-web",
+		"project-config-2018-01-02T00-00-00Z-web",
+		"project-config-2018-01-03T00-00-00Z-web",
+		"project-config-other-2018-01-01T00-00-00Z-web",
+		"other-config-2018-01-01T00-00-00Z-web",
+	}
+
+	for _, name := range names {
+		assert.NoError(t, Create(ec2c, to.Strp(name), to.Strp("spread"), nil))
+	}
+
+	ec2c.PlacementGroupsInUse[names[1]] = true
+
+	err := TeardownTransient(ec2c, to.Strp("project-config-"), []*string{to.Strp(names[2])})
+	assert.NoError(t, err)
+
+	assert.Nil(t, ec2c.PlacementGroups[names[0]])
+	assert.NotNil(t, ec2c.PlacementGroups[names[1]]) // In Use
+	assert.NotNil(t, ec2c.PlacementGroups[names[2]]) // Excluded
+	assert.NotNil(t, ec2c.PlacementGroups[names[3]]) // Other Config
+	assert.NotNil(t, ec2c.PlacementGroups[names[4]]) // Other Project
+}
@@ -0,0 +1,87 @@
+package pricing
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/service/pricing"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Region the Price List API is called in, it is only in a few regions but has the prices of every region
+const Region = "us-east-1"
+
+// HoursPerMonth is the hours AWS uses to turn hourly prices into monthly prices
+const HoursPerMonth = 730
+
+// InstanceHourly returns the On-Demand Linux price per hour in USD of the instance type in the region
+func InstanceHourly(pricingc aws.PricingAPI, region string, instanceType string) (float64, error) {
+	return onDemandPrice(pricingc, map[string]string{
+		"regionCode":      region,
+		"instanceType":    instanceType,
+		"operatingSystem": "Linux",
+		"tenancy":         "Shared",
+		"preInstalledSw":  "NA",
+		"capacitystatus":  "Used",
+	})
+}
+
+// VolumeMonthlyPerGB returns the price per GB month in USD of the EBS volume type in the region
+func VolumeMonthlyPerGB(pricingc aws.PricingAPI, region string, volumeType string) (float64, error) {
+	return onDemandPrice(pricingc, map[string]string{
+		"regionCode":    region,
+		"productFamily": "Storage",
+		"volumeApiName": volumeType,
+	})
+}
+
+func onDemandPrice(pricingc aws.PricingAPI, attributes map[string]string) (float64, error) {
+	filters := []*pricing.Filter{}
+	for field, value := range attributes {
+		filters = append(filters, &pricing.Filter{
+			Type:  to.Strp(pricing.FilterTypeTermMatch),
+			Field: to.Strp(field),
+			Value: to.Strp(value),
+		})
+	}
+
+	output, err := pricingc.GetProducts(&pricing.GetProductsInput{
+		ServiceCode: to.Strp("AmazonEC2"),
+		Filters:     filters,
+		MaxResults:  to.Int64p(1),
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(output.PriceList) == 0 {
+		return 0, fmt.Errorf("No price found for %v", attributes)
+	}
+
+	return parseOnDemandPrice(output.PriceList[0])
+}
+
+// parseOnDemandPrice returns the USD price of a product in the price list,
+// which is nested under terms.OnDemand.<offer>.priceDimensions.<dimension>.pricePerUnit.USD
+func parseOnDemandPrice(product map[string]interface{}) (float64, error) {
+	terms, _ := product["terms"].(map[string]interface{})
+	offers, _ := terms["OnDemand"].(map[string]interface{})
+
+	for _, offer := range offers {
+		offer, _ := offer.(map[string]interface{})
+		dimensions, _ := offer["priceDimensions"].(map[string]interface{})
+
+		for _, dimension := range dimensions {
+			dimension, _ := dimension.(map[string]interface{})
+			perUnit, _ := dimension["pricePerUnit"].(map[string]interface{})
+
+			if usd, ok := perUnit["USD"].(string); ok {
+				return strconv.ParseFloat(usd, 64)
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("No On-Demand USD price in price list")
+}
@@ -0,0 +1,30 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_InstanceHourly(t *testing.T) {
+	pricingc := &mocks.PricingClient{}
+	pricingc.AddPrice("m5.large", 0.096)
+	pricingc.AddPrice("gp3", 0.08)
+
+	price, err := InstanceHourly(pricingc, "us-east-1", "m5.large")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.096, price)
+
+	price, err = VolumeMonthlyPerGB(pricingc, "us-east-1", "gp3")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.08, price)
+
+	_, err = InstanceHourly(pricingc, "us-east-1", "m5.unknown")
+	assert.Error(t, err)
+}
+
+func Test_parseOnDemandPrice(t *testing.T) {
+	_, err := parseOnDemandPrice(map[string]interface{}{"terms": map[string]interface{}{}})
+	assert.Error(t, err)
+}
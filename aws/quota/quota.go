@@ -0,0 +1,284 @@
+package quota
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/servicequotas"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Usage is the resources a release will create
+type Usage struct {
+	ASGs                 int64
+	LaunchConfigurations int64
+	Instances            map[string]int64 // On-Demand instances by instance type
+}
+
+// AddInstances adds count On-Demand instances of the instance type
+func (usage *Usage) AddInstances(instanceType *string, count int) {
+	if instanceType == nil || count <= 0 {
+		return
+	}
+
+	if usage.Instances == nil {
+		usage.Instances = map[string]int64{}
+	}
+
+	usage.Instances[*instanceType] += int64(count)
+}
+
+// EC2 counts running On-Demand instances in vCPUs, with a quota for each group of instance families
+// https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/ec2-resource-limits.html
+const (
+	standardQuota = "L-1216C47A" // A, C, D, H, I, M, R, T and Z
+	fQuota        = "L-74FC7D96"
+	gQuota        = "L-DB2E81BA" // G and VT
+	infQuota      = "L-1945791B"
+	pQuota        = "L-417A185B"
+	xQuota        = "L-7295265B"
+	dlQuota       = "L-6E869C2A"
+	trnQuota      = "L-2C3B7624"
+	hpcQuota      = "L-F7808C92"
+)
+
+// familyQuotas are checked before the first letter of the instance type, as their first letter is another group
+var familyQuotas = []struct {
+	prefix string
+	code   string
+}{
+	{"inf", infQuota},
+	{"vt", gQuota},
+	{"dl", dlQuota},
+	{"trn", trnQuota},
+	{"hpc", hpcQuota},
+	{"mac", ""}, // Dedicated hosts only
+	{"u-", ""},  // Dedicated hosts only
+}
+
+var letterQuotas = map[byte]string{
+	'a': standardQuota, 'c': standardQuota, 'd': standardQuota, 'h': standardQuota, 'i': standardQuota,
+	'm': standardQuota, 'r': standardQuota, 't': standardQuota, 'z': standardQuota,
+	'f': fQuota, 'g': gQuota, 'p': pQuota, 'x': xQuota,
+}
+
+// QuotaCode returns the code of the On-Demand vCPU quota for the instance type, empty if it has none
+func QuotaCode(instanceType string) string {
+	for _, family := range familyQuotas {
+		if strings.HasPrefix(instanceType, family.prefix) {
+			return family.code
+		}
+	}
+
+	if instanceType == "" {
+		return ""
+	}
+
+	return letterQuotas[instanceType[0]]
+}
+
+// Validate errors if creating usage would exceed the regions limits
+func Validate(asgc aws.ASGAPI, ec2c aws.EC2API, sqc aws.QuotasAPI, usage *Usage) error {
+	if err := validateASGLimits(asgc, usage); err != nil {
+		return err
+	}
+
+	return validateVCPUQuotas(ec2c, sqc, usage)
+}
+
+func validateASGLimits(asgc aws.ASGAPI, usage *Usage) error {
+	limits, err := asgc.DescribeAccountLimits(&autoscaling.DescribeAccountLimitsInput{})
+	if err != nil {
+		return err
+	}
+
+	if err := check("AutoScaling Groups", limits.NumberOfAutoScalingGroups, limits.MaxNumberOfAutoScalingGroups, usage.ASGs); err != nil {
+		return err
+	}
+
+	return check("Launch Configurations", limits.NumberOfLaunchConfigurations, limits.MaxNumberOfLaunchConfigurations, usage.LaunchConfigurations)
+}
+
+func validateVCPUQuotas(ec2c aws.EC2API, sqc aws.QuotasAPI, usage *Usage) error {
+	if len(usage.Instances) == 0 {
+		return nil
+	}
+
+	vcpus := newVCPUs(ec2c)
+
+	needed := map[string]int64{}
+	for instanceType, count := range usage.Instances {
+		code := QuotaCode(instanceType)
+		if code == "" {
+			continue
+		}
+
+		n, err := vcpus.of(instanceType)
+		if err != nil {
+			return err
+		}
+
+		needed[code] += n * count
+	}
+
+	if len(needed) == 0 {
+		return nil
+	}
+
+	running, err := runningVCPUs(ec2c, vcpus)
+	if err != nil {
+		return err
+	}
+
+	// Sorted so the same quota is reported first each time
+	codes := []string{}
+	for code := range needed {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		max, err := vcpuQuota(sqc, code)
+		if err != nil {
+			return err
+		}
+
+		current := running[code]
+		if err := check(fmt.Sprintf("On-Demand vCPUs (%v)", code), &current, max, needed[code]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExceededError is returned when a deploy needs more than a quota allows
+type ExceededError struct {
+	Name    string
+	Current int64
+	Needed  int64
+	Limit   int64
+}
+
+// Error returns error
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("Quota exceeded for %v: %v in use, %v needed, limit %v", e.Name, e.Current, e.Needed, e.Limit)
+}
+
+func check(name string, current *int64, max *int64, needed int64) error {
+	if current == nil || max == nil {
+		return nil
+	}
+
+	if *current+needed > *max {
+		return &ExceededError{Name: name, Current: *current, Needed: needed, Limit: *max}
+	}
+
+	return nil
+}
+
+// vcpuQuota returns the applied value of the quota, nil if the region does not have it
+func vcpuQuota(sqc aws.QuotasAPI, code string) (*int64, error) {
+	output, err := sqc.GetServiceQuota(&servicequotas.GetServiceQuotaInput{
+		ServiceCode: to.Strp("ec2"),
+		QuotaCode:   to.Strp(code),
+	})
+
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == servicequotas.ErrCodeNoSuchResourceException {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if output.Quota == nil || output.Quota.Value == nil {
+		return nil, nil
+	}
+
+	max := int64(*output.Quota.Value)
+	return &max, nil
+}
+
+// vcpus looks up and keeps the default vCPUs of instance types
+type vcpus struct {
+	ec2c  aws.EC2API
+	types map[string]int64
+}
+
+func newVCPUs(ec2c aws.EC2API) *vcpus {
+	return &vcpus{ec2c: ec2c, types: map[string]int64{}}
+}
+
+func (v *vcpus) of(instanceType string) (int64, error) {
+	if n, ok := v.types[instanceType]; ok {
+		return n, nil
+	}
+
+	output, err := v.ec2c.DescribeInstanceTypes(&ec2.DescribeInstanceTypesInput{
+		InstanceTypes: []*string{to.Strp(instanceType)},
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(output.InstanceTypes) != 1 || output.InstanceTypes[0].VCpuInfo == nil || output.InstanceTypes[0].VCpuInfo.DefaultVCpus == nil {
+		return 0, fmt.Errorf("Instance type %v not found", instanceType)
+	}
+
+	v.types[instanceType] = *output.InstanceTypes[0].VCpuInfo.DefaultVCpus
+	return v.types[instanceType], nil
+}
+
+// runningVCPUs returns the vCPUs of the running On-Demand instances by quota code
+func runningVCPUs(ec2c aws.EC2API, vcpus *vcpus) (map[string]int64, error) {
+	running := map[string]int64{}
+	var lookupErr error
+
+	err := ec2c.DescribeInstancesPages(&ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			&ec2.Filter{
+				Name:   to.Strp("instance-state-name"),
+				Values: []*string{to.Strp("pending"), to.Strp("running")},
+			},
+		},
+	}, func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				// Spot instances have their own quotas
+				if instance.InstanceLifecycle != nil || instance.InstanceType == nil {
+					continue
+				}
+
+				code := QuotaCode(*instance.InstanceType)
+				if code == "" {
+					continue
+				}
+
+				if cpu := instance.CpuOptions; cpu != nil && cpu.CoreCount != nil && cpu.ThreadsPerCore != nil {
+					running[code] += *cpu.CoreCount * *cpu.ThreadsPerCore
+					continue
+				}
+
+				n, err := vcpus.of(*instance.InstanceType)
+				if err != nil {
+					lookupErr = err
+					return false
+				}
+				running[code] += n
+			}
+		}
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return running, lookupErr
+}
@@ -0,0 +1,85 @@
+package quota
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Validate(t *testing.T) {
+	asgc := &mocks.ASGClient{}
+	ec2c := &mocks.EC2Client{}
+	sqc := &mocks.QuotasClient{}
+
+	usage := &Usage{ASGs: 1, LaunchConfigurations: 1}
+	usage.AddInstances(to.Strp("m5.large"), 1)
+	assert.NoError(t, Validate(asgc, ec2c, sqc, usage))
+}
+
+func Test_Validate_ASGLimits(t *testing.T) {
+	asgc := &mocks.ASGClient{}
+	ec2c := &mocks.EC2Client{}
+	sqc := &mocks.QuotasClient{}
+
+	asgc.AccountLimits = &autoscaling.DescribeAccountLimitsOutput{
+		MaxNumberOfAutoScalingGroups:    to.Int64p(10),
+		NumberOfAutoScalingGroups:       to.Int64p(9),
+		MaxNumberOfLaunchConfigurations: to.Int64p(10),
+		NumberOfLaunchConfigurations:    to.Int64p(0),
+	}
+
+	assert.NoError(t, Validate(asgc, ec2c, sqc, &Usage{ASGs: 1, LaunchConfigurations: 1}))
+
+	err := Validate(asgc, ec2c, sqc, &Usage{ASGs: 2, LaunchConfigurations: 2})
+	assert.Error(t, err)
+	assert.Regexp(t, "AutoScaling Groups", err.Error())
+}
+
+func Test_Validate_VCPUQuota(t *testing.T) {
+	asgc := &mocks.ASGClient{}
+	ec2c := &mocks.EC2Client{InstanceTypeVCPUs: map[string]int64{"m5.xlarge": 4}}
+	sqc := &mocks.QuotasClient{Quotas: map[string]float64{standardQuota: 9}}
+
+	ec2c.AddInstances("m5.large", 3)
+	ec2c.AddInstances("p3.2xlarge", 10) // Another quota
+
+	usage := &Usage{}
+	usage.AddInstances(to.Strp("m5.large"), 1)
+	assert.NoError(t, Validate(asgc, ec2c, sqc, usage))
+
+	usage = &Usage{}
+	usage.AddInstances(to.Strp("m5.xlarge"), 1)
+	err := Validate(asgc, ec2c, sqc, usage)
+	assert.Error(t, err)
+	assert.Regexp(t, "On-Demand vCPUs", err.Error())
+
+	// Running instances with fewer CPU cores use fewer vCPUs
+	ec2c.Instances[0].CpuOptions = &ec2.CpuOptions{CoreCount: to.Int64p(1), ThreadsPerCore: to.Int64p(1)}
+	assert.NoError(t, Validate(asgc, ec2c, sqc, usage))
+
+	// Spot instances do not count
+	ec2c.Instances[1].InstanceLifecycle = to.Strp("spot")
+	usage.AddInstances(to.Strp("m5.large"), 1)
+	assert.NoError(t, Validate(asgc, ec2c, sqc, usage))
+
+	// Regions without the quota are not checked
+	sqc.Quotas = nil
+	usage.AddInstances(to.Strp("m5.large"), 100)
+	assert.NoError(t, Validate(asgc, ec2c, sqc, usage))
+}
+
+func Test_QuotaCode(t *testing.T) {
+	assert.Equal(t, standardQuota, QuotaCode("m5.large"))
+	assert.Equal(t, standardQuota, QuotaCode("t3a.micro"))
+	assert.Equal(t, standardQuota, QuotaCode("im4gn.large"))
+	assert.Equal(t, gQuota, QuotaCode("g4dn.xlarge"))
+	assert.Equal(t, gQuota, QuotaCode("vt1.3xlarge"))
+	assert.Equal(t, infQuota, QuotaCode("inf1.xlarge"))
+	assert.Equal(t, pQuota, QuotaCode("p3.2xlarge"))
+	assert.Equal(t, "", QuotaCode("mac1.metal"))
+	assert.Equal(t, "", QuotaCode(""))
+}
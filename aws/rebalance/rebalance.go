@@ -0,0 +1,64 @@
+package rebalance
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// The pinned aws-sdk-go predates Capacity Rebalancing,
+// so UpdateAutoScalingGroup with it mirrors the AutoScaling API and is sent with the AutoScaling clients query protocol.
+
+// UpdateAutoScalingGroupInput mirrors the AutoScaling API
+type UpdateAutoScalingGroupInput struct {
+	_ struct{} `type:"structure"`
+
+	AutoScalingGroupName *string `min:"1" type:"string" required:"true"`
+	CapacityRebalance    *bool   `type:"boolean"`
+}
+
+// API is the subset of the AutoScaling API for Capacity Rebalancing
+type API interface {
+	UpdateCapacityRebalance(*UpdateAutoScalingGroupInput) (*autoscaling.UpdateAutoScalingGroupOutput, error)
+}
+
+// Client returns the Capacity Rebalancing API of the AutoScaling client
+func Client(asgc aws.ASGAPI) (API, error) {
+	if api, ok := asgc.(API); ok {
+		return api, nil
+	}
+
+	if c, ok := asgc.(*autoscaling.AutoScaling); ok {
+		return &queryClient{c}, nil
+	}
+
+	return nil, fmt.Errorf("AutoScaling client does not support Capacity Rebalancing")
+}
+
+type queryClient struct {
+	*autoscaling.AutoScaling
+}
+
+func (c *queryClient) UpdateCapacityRebalance(in *UpdateAutoScalingGroupInput) (*autoscaling.UpdateAutoScalingGroupOutput, error) {
+	out := &autoscaling.UpdateAutoScalingGroupOutput{}
+	req := c.NewRequest(&request.Operation{Name: "UpdateAutoScalingGroup", HTTPMethod: "POST", HTTPPath: "/"}, in, out)
+	return out, req.Send()
+}
+
+// Enable turns on Capacity Rebalancing so the ASG replaces Spot instances at risk of interruption
+func Enable(asgc aws.ASGAPI, asgName *string) error {
+	api, err := Client(asgc)
+	if err != nil {
+		return err
+	}
+
+	_, err = api.UpdateCapacityRebalance(&UpdateAutoScalingGroupInput{
+		AutoScalingGroupName: asgName,
+		CapacityRebalance:    to.Boolp(true),
+	})
+
+	return err
+}
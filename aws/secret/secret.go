@@ -0,0 +1,69 @@
+package secret
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// References are limited to these prefixes so a release cannot read arbitrary secrets
+const (
+	SSMPrefix = "ssm:/odin/"
+	SMPrefix  = "secretsmanager:odin/"
+)
+
+// ValidateReference returns an error if ref is not an odin SSM parameter or Secrets Manager secret
+func ValidateReference(ref *string) error {
+	if ref == nil {
+		return fmt.Errorf("Secret reference is nil")
+	}
+
+	if !strings.HasPrefix(*ref, SSMPrefix) && !strings.HasPrefix(*ref, SMPrefix) {
+		return fmt.Errorf("Secret reference %q must start with %q or %q", *ref, SSMPrefix, SMPrefix)
+	}
+
+	return nil
+}
+
+// Get returns the value of a reference
+// "ssm:/odin/<name>" is a (SecureString) SSM parameter, "secretsmanager:odin/<name>" is a secret string
+func Get(ssmc aws.SSMAPI, smc aws.SMAPI, ref *string) (*string, error) {
+	if err := ValidateReference(ref); err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(*ref, "ssm:") {
+		out, err := ssmc.GetParameter(&ssm.GetParameterInput{
+			Name:           to.Strp(strings.TrimPrefix(*ref, "ssm:")),
+			WithDecryption: to.Boolp(true),
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		if out.Parameter == nil || out.Parameter.Value == nil {
+			return nil, fmt.Errorf("Secret %q has no value", *ref)
+		}
+
+		return out.Parameter.Value, nil
+	}
+
+	out, err := smc.GetSecretValue(&secretsmanager.GetSecretValueInput{
+		SecretId: to.Strp(strings.TrimPrefix(*ref, "secretsmanager:")),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if out.SecretString == nil {
+		return nil, fmt.Errorf("Secret %q has no value", *ref)
+	}
+
+	return out.SecretString, nil
+}
@@ -0,0 +1,36 @@
+package secret
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ValidateReference(t *testing.T) {
+	assert.NoError(t, ValidateReference(to.Strp("ssm:/odin/slack")))
+	assert.NoError(t, ValidateReference(to.Strp("secretsmanager:odin/slack")))
+
+	assert.Error(t, ValidateReference(nil))
+	assert.Error(t, ValidateReference(to.Strp("ssm:/db/password")))
+	assert.Error(t, ValidateReference(to.Strp("secretsmanager:db/password")))
+	assert.Error(t, ValidateReference(to.Strp("https://hooks.slack.com/services/x")))
+}
+
+func Test_Get(t *testing.T) {
+	awsc := mocks.MockAWS()
+	awsc.SSM.AddParameter("/odin/slack", "https://hooks.slack.com/services/ssm")
+	awsc.SM.AddSecret("odin/hook", "https://example.com/hook")
+
+	value, err := Get(awsc.SSM, awsc.SM, to.Strp("ssm:/odin/slack"))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://hooks.slack.com/services/ssm", *value)
+
+	value, err = Get(awsc.SSM, awsc.SM, to.Strp("secretsmanager:odin/hook"))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/hook", *value)
+
+	_, err = Get(awsc.SSM, awsc.SM, to.Strp("ssm:/odin/missing"))
+	assert.Error(t, err)
+}
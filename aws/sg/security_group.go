@@ -2,6 +2,7 @@ package sg
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/coinbase/odin/aws"
@@ -15,6 +16,9 @@ type SecurityGroup struct {
 	ConfigNameTag  *string
 	ServiceNameTag *string
 	GroupID        *string
+	GroupName      *string
+	VpcID          *string
+	OpenIngress    bool // An ingress rule allows 0.0.0.0/0 or ::/0
 }
 
 // ProjectName returns tag
@@ -32,68 +36,177 @@ func (s *SecurityGroup) ServiceName() *string {
 	return s.ServiceNameTag
 }
 
+// Name returns the Name tag, or the group name if it is not tagged
 func (s *SecurityGroup) Name() *string {
+	if s.NameTag == nil {
+		return s.GroupName
+	}
 	return s.NameTag
 }
 
-// Find returns the security groups with tags
-func Find(ec2Client aws.EC2API, nameTags []*string) ([]*SecurityGroup, error) {
-	output, err := ec2Client.DescribeSecurityGroups(&ec2.DescribeSecurityGroupsInput{
-		Filters: []*ec2.Filter{
-			&ec2.Filter{
-				Name:   to.Strp("tag:Name"),
-				Values: nameTags,
-			}}})
-
+// Find returns the security groups with the Name tags, group names or IDs e.g. sg-1234567,
+// only in the VPC if it is given because group names are unique per VPC
+func Find(ec2Client aws.EC2API, names []*string, vpcID *string) ([]*SecurityGroup, error) {
+	sgs, err := describe(ec2Client, "tag:Name", names, vpcID)
 	if err != nil {
 		return nil, err
 	}
 
-	sgs := newSGs(output.SecurityGroups)
+	// Fall back to group IDs and names for those without a matching Name tag
+	ids, groupNames := splitIDsNames(unmatched(sgs, names))
+
+	if len(ids) > 0 {
+		byID, err := describe(ec2Client, "group-id", ids, vpcID)
+		if err != nil {
+			return nil, err
+		}
+		sgs = appendNew(sgs, byID)
+	}
+
+	if len(groupNames) > 0 {
+		byName, err := describe(ec2Client, "group-name", groupNames, vpcID)
+		if err != nil {
+			return nil, err
+		}
+		sgs = appendNew(sgs, byName)
+	}
 
-	// Need to validate that each Name tag matches Exactly one Security Group
-	for _, nameTag := range nameTags {
+	// Need to validate that each name matches Exactly one Security Group
+	for _, name := range names {
 		matches := 0
 		for _, sg := range sgs {
-			if sg.NameTag == nil {
-				return nil, fmt.Errorf("SecurityGroup '%v': incorrect Name Tag", *nameTag)
+			if sg.matches(*name) {
+				matches++
 			}
+		}
 
-			if *sg.NameTag == *nameTag {
-				matches += 1
-			}
+		if matches > 1 {
+			return nil, fmt.Errorf("SecurityGroup '%v': too many found", *name)
 		}
+	}
 
-		switch matches {
-		case 0:
-			return nil, fmt.Errorf("SecurityGroup '%v': not found", *nameTag)
-		case 1:
-			// Do nothing
-		default:
-			return nil, fmt.Errorf("SecurityGroup '%v': too many found", *nameTag)
+	if missing := unmatched(sgs, names); len(missing) > 0 {
+		if vpcID != nil {
+			return nil, fmt.Errorf("SecurityGroups not found in %v: %v", *vpcID, strings.Join(to.StrSlice(missing), ", "))
 		}
+		return nil, fmt.Errorf("SecurityGroups not found: %v", strings.Join(to.StrSlice(missing), ", "))
 	}
 
-	if len(sgs) != len(nameTags) {
+	if len(sgs) != len(names) {
 		// Last assurance that no additional security groups were found
-		return nil, fmt.Errorf("SecurityGroup: found %v required %v", len(sgs), len(nameTags))
+		return nil, fmt.Errorf("SecurityGroup: found %v required %v", len(sgs), len(names))
 	}
 
 	return sgs, nil
 }
 
+func describe(ec2Client aws.EC2API, filter string, values []*string, vpcID *string) ([]*SecurityGroup, error) {
+	filters := []*ec2.Filter{
+		&ec2.Filter{
+			Name:   to.Strp(filter),
+			Values: values,
+		},
+	}
+
+	if vpcID != nil {
+		filters = append(filters, &ec2.Filter{Name: to.Strp("vpc-id"), Values: []*string{vpcID}})
+	}
+
+	output, err := ec2Client.DescribeSecurityGroups(&ec2.DescribeSecurityGroupsInput{Filters: filters})
+	if err != nil {
+		return nil, err
+	}
+
+	return newSGs(output.SecurityGroups), nil
+}
+
+// matches returns whether the name is the security groups Name tag, group name or ID
+func (s *SecurityGroup) matches(name string) bool {
+	return to.Strs(s.NameTag) == name || to.Strs(s.GroupName) == name || to.Strs(s.GroupID) == name
+}
+
+// unmatched returns the names that do not match any security group
+func unmatched(sgs []*SecurityGroup, names []*string) []*string {
+	missing := []*string{}
+	for _, name := range names {
+		found := false
+		for _, sg := range sgs {
+			found = found || sg.matches(*name)
+		}
+
+		if !found {
+			missing = append(missing, name)
+		}
+	}
+
+	return missing
+}
+
+// appendNew appends the security groups that are not already found
+func appendNew(sgs []*SecurityGroup, more []*SecurityGroup) []*SecurityGroup {
+	for _, m := range more {
+		found := false
+		for _, sg := range sgs {
+			found = found || to.Strs(sg.GroupID) == to.Strs(m.GroupID)
+		}
+
+		if !found {
+			sgs = append(sgs, m)
+		}
+	}
+
+	return sgs
+}
+
+// splitIDsNames returns list of ids, and list of names
+func splitIDsNames(names []*string) ([]*string, []*string) {
+	ids := []*string{}
+	groupNames := []*string{}
+	for _, name := range names {
+		if strings.HasPrefix(*name, "sg-") {
+			ids = append(ids, name)
+		} else {
+			groupNames = append(groupNames, name)
+		}
+	}
+
+	return ids, groupNames
+}
+
 func newSGs(output []*ec2.SecurityGroup) []*SecurityGroup {
 	sgs := []*SecurityGroup{}
 
 	for _, sg := range output {
 		sgs = append(sgs, &SecurityGroup{
 			GroupID:        sg.GroupId,
+			GroupName:      sg.GroupName,
+			VpcID:          sg.VpcId,
 			NameTag:        aws.FetchEc2Tag(sg.Tags, to.Strp("Name")),
 			ProjectNameTag: aws.FetchEc2Tag(sg.Tags, to.Strp("ProjectName")),
 			ConfigNameTag:  aws.FetchEc2Tag(sg.Tags, to.Strp("ConfigName")),
 			ServiceNameTag: aws.FetchEc2Tag(sg.Tags, to.Strp("ServiceName")),
+			OpenIngress:    openIngress(sg.IpPermissions),
 		})
 	}
 
 	return sgs
 }
+
+// openIngress returns whether any of the ingress rules allow the whole internet
+func openIngress(permissions []*ec2.IpPermission) bool {
+	for _, permission := range permissions {
+		for _, r := range permission.IpRanges {
+			if to.Strs(r.CidrIp) == "0.0.0.0/0" {
+				return true
+			}
+		}
+
+		for _, r := range permission.Ipv6Ranges {
+			if to.Strs(r.CidrIpv6) == "::/0" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -3,6 +3,7 @@ package sg
 import (
 	"testing"
 
+	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/coinbase/odin/aws/mocks"
 	"github.com/coinbase/step/utils/to"
 	"github.com/stretchr/testify/assert"
@@ -11,12 +12,43 @@ import (
 func Test_Find(t *testing.T) {
 	//func Find(ec2Client aws.EC2API, name_tags []*string) ([]*SecurityGroup, error) {
 	ec2c := &mocks.EC2Client{}
-	_, err := Find(ec2c, []*string{to.Strp("sg1")})
+	_, err := Find(ec2c, []*string{to.Strp("sg1")}, nil)
 	assert.Error(t, err)
 
 	ec2c.AddSecurityGroup("sg1", "project_name", "config_name", "service_name", nil)
 
-	sgs, err := Find(ec2c, []*string{to.Strp("sg1")})
+	sgs, err := Find(ec2c, []*string{to.Strp("sg1")}, to.Strp("vpc-1"))
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(sgs))
 }
+
+func Test_Find_Missing(t *testing.T) {
+	ec2c := &mocks.EC2Client{}
+	ec2c.AddSecurityGroup("sg1", "project_name", "config_name", "service_name", nil)
+
+	_, err := Find(ec2c, []*string{to.Strp("sg1"), to.Strp("sg2"), to.Strp("sg-3")}, to.Strp("vpc-1"))
+	assert.EqualError(t, err, "SecurityGroups not found in vpc-1: sg2, sg-3")
+}
+
+func Test_splitIDsNames(t *testing.T) {
+	ids, names := splitIDsNames([]*string{to.Strp("sg-1234567"), to.Strp("web-sg")})
+	assert.Equal(t, []string{"sg-1234567"}, to.StrSlice(ids))
+	assert.Equal(t, []string{"web-sg"}, to.StrSlice(names))
+}
+
+func Test_Find_OpenIngress(t *testing.T) {
+	ec2c := &mocks.EC2Client{}
+	ec2c.AddSecurityGroup("sg1", "project_name", "config_name", "service_name", nil)
+
+	sgs, err := Find(ec2c, []*string{to.Strp("sg1")}, nil)
+	assert.NoError(t, err)
+	assert.False(t, sgs[0].OpenIngress)
+
+	ec2c.DescribeSecurityGroupsResp["sg1"].Resp.SecurityGroups[0].IpPermissions = []*ec2.IpPermission{
+		&ec2.IpPermission{Ipv6Ranges: []*ec2.Ipv6Range{&ec2.Ipv6Range{CidrIpv6: to.Strp("::/0")}}},
+	}
+
+	sgs, err = Find(ec2c, []*string{to.Strp("sg1")}, nil)
+	assert.NoError(t, err)
+	assert.True(t, sgs[0].OpenIngress)
+}
@@ -0,0 +1,71 @@
+package signing
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// DefaultAlgorithm is used for keys created with the ECC_NIST_P256 key spec
+const DefaultAlgorithm = kms.SigningAlgorithmSpecEcdsaSha256
+
+// Sign signs the hex SHA256 digest with the KMS asymmetric key, returning the base64 signature and the keys ARN
+func Sign(kmsc aws.KMSAPI, keyID string, algorithm string, digest string) (*string, *string, error) {
+	raw, err := hex.DecodeString(digest)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Digest invalid %v", err.Error())
+	}
+
+	out, err := kmsc.Sign(&kms.SignInput{
+		KeyId:            &keyID,
+		Message:          raw,
+		MessageType:      to.Strp(kms.MessageTypeDigest),
+		SigningAlgorithm: &algorithm,
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return to.Strp(base64.StdEncoding.EncodeToString(out.Signature)), out.KeyId, nil
+}
+
+// Verify verifies the base64 signature of the hex SHA256 digest, returning the ARN of the key that signed it
+func Verify(kmsc aws.KMSAPI, keyID string, algorithm string, digest string, signature string) (*string, error) {
+	raw, err := hex.DecodeString(digest)
+	if err != nil {
+		return nil, fmt.Errorf("Digest invalid %v", err.Error())
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, fmt.Errorf("Signature invalid %v", err.Error())
+	}
+
+	out, err := kmsc.Verify(&kms.VerifyInput{
+		KeyId:            &keyID,
+		Message:          raw,
+		MessageType:      to.Strp(kms.MessageTypeDigest),
+		Signature:        sig,
+		SigningAlgorithm: &algorithm,
+	})
+
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == kms.ErrCodeKMSInvalidSignatureException {
+		return nil, fmt.Errorf("Signature invalid for key %v", keyID)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if out.SignatureValid == nil || !*out.SignatureValid {
+		return nil, fmt.Errorf("Signature invalid for key %v", keyID)
+	}
+
+	return out.KeyId, nil
+}
@@ -0,0 +1,35 @@
+package signing
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Sign_Verify(t *testing.T) {
+	kmsc := &mocks.KMSClient{}
+	digest := to.SHA256Str(to.Strp("release"))
+
+	signature, keyARN, err := Sign(kmsc, "alias/odin-signing", DefaultAlgorithm, digest)
+	assert.NoError(t, err)
+	assert.Equal(t, "arn:aws:kms:us-east-1:000000000000:key/odin-signing", *keyARN)
+
+	signer, err := Verify(kmsc, *keyARN, DefaultAlgorithm, digest, *signature)
+	assert.NoError(t, err)
+	assert.Equal(t, *keyARN, *signer)
+
+	// Another digest or key
+	_, err = Verify(kmsc, *keyARN, DefaultAlgorithm, to.SHA256Str(to.Strp("changed")), *signature)
+	assert.Error(t, err)
+
+	_, err = Verify(kmsc, "alias/other", DefaultAlgorithm, digest, *signature)
+	assert.Error(t, err)
+
+	_, err = Verify(kmsc, *keyARN, DefaultAlgorithm, digest, "not base64!")
+	assert.Error(t, err)
+
+	_, _, err = Sign(kmsc, "alias/odin-signing", DefaultAlgorithm, "not hex")
+	assert.Error(t, err)
+}
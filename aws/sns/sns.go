@@ -3,6 +3,7 @@ package sns
 import (
 	"github.com/aws/aws-sdk-go/service/sns"
 	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
 )
 
 // TopicExists errors if SNS topic doesn't exists
@@ -13,3 +14,26 @@ func TopicExists(snsc aws.SNSAPI, topicARN *string) error {
 
 	return err
 }
+
+// Publish sends the message to the topic with string attributes subscribers can filter on
+func Publish(snsc aws.SNSAPI, topicARN *string, message *string, attributes map[string]*string) error {
+	attrs := map[string]*sns.MessageAttributeValue{}
+	for name, value := range attributes {
+		if value == nil {
+			continue
+		}
+
+		attrs[name] = &sns.MessageAttributeValue{
+			DataType:    to.Strp("String"),
+			StringValue: value,
+		}
+	}
+
+	_, err := snsc.Publish(&sns.PublishInput{
+		TopicArn:          topicARN,
+		Message:           message,
+		MessageAttributes: attrs,
+	})
+
+	return err
+}
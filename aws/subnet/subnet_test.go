@@ -49,3 +49,45 @@ func Test_splitIDsTags(t *testing.T) {
 	assert.Equal(t, 1, len(i))
 	assert.Equal(t, 1, len(ts))
 }
+
+func Test_FindByTag(t *testing.T) {
+	ec2c := &mocks.EC2Client{}
+	ec2c.AddSubnet("private-subnet1", "subnet-b")
+	ec2c.AddSubnet("private-subnet2", "subnet-a")
+
+	sns, err := FindByTag(ec2c, "odin:tier=private")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(sns))
+	assert.Equal(t, "subnet-a", *sns[0].SubnetID)
+	assert.Equal(t, 1, len(BalanceAZs(sns)))
+
+	_, err = FindByTag(&mocks.EC2Client{}, "odin:tier=private")
+	assert.Error(t, err)
+}
+
+func Test_BalanceAZs(t *testing.T) {
+	sns := BalanceAZs([]*Subnet{
+		&Subnet{SubnetID: to.Strp("subnet-1"), AvailabilityZone: to.Strp("us-east-1a")},
+		&Subnet{SubnetID: to.Strp("subnet-2"), AvailabilityZone: to.Strp("us-east-1a")},
+		&Subnet{SubnetID: to.Strp("subnet-3"), AvailabilityZone: to.Strp("us-east-1b")},
+	})
+
+	assert.Equal(t, 2, len(sns))
+	assert.Equal(t, "subnet-1", *sns[0].SubnetID)
+	assert.Equal(t, "subnet-3", *sns[1].SubnetID)
+}
+
+func Test_VPC(t *testing.T) {
+	vpc, err := VPC([]*Subnet{
+		&Subnet{SubnetID: to.Strp("subnet-1"), VpcID: to.Strp("vpc-1")},
+		&Subnet{SubnetID: to.Strp("subnet-2"), VpcID: to.Strp("vpc-1")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "vpc-1", *vpc)
+
+	_, err = VPC([]*Subnet{
+		&Subnet{SubnetID: to.Strp("subnet-1"), VpcID: to.Strp("vpc-1")},
+		&Subnet{SubnetID: to.Strp("subnet-2"), VpcID: to.Strp("vpc-2")},
+	})
+	assert.Error(t, err)
+}
@@ -2,6 +2,8 @@ package subnet
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/coinbase/odin/aws"
@@ -10,8 +12,11 @@ import (
 
 // Subnet struct
 type Subnet struct {
-	SubnetID      *string
-	DeployWithTag *string
+	SubnetID            *string
+	DeployWithTag       *string
+	MapPublicIPOnLaunch *bool
+	AvailabilityZone    *string
+	VpcID               *string
 }
 
 // Find returns a list of subnets for either ids or tags NO MIXING , e.g. subnet-00000000 OR privatea
@@ -43,6 +48,66 @@ func Find(ec2Client aws.EC2API, nameTagsOrIDs []*string) ([]*Subnet, error) {
 	return subnets, nil
 }
 
+// FindByTag returns the subnets with a tag, e.g. odin:tier=private, or with the tag key if there is no value
+func FindByTag(ec2Client aws.EC2API, tag string) ([]*Subnet, error) {
+	filter := &ec2.Filter{Name: to.Strp("tag-key"), Values: []*string{to.Strp(tag)}}
+	if i := strings.Index(tag, "="); i >= 0 {
+		filter = &ec2.Filter{Name: to.Strp("tag:" + tag[:i]), Values: []*string{to.Strp(tag[i+1:])}}
+	}
+
+	subnets, err := find(ec2Client, &ec2.DescribeSubnetsInput{Filters: []*ec2.Filter{filter}})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(subnets) == 0 {
+		return nil, fmt.Errorf("No Subnets Found with tag %q", tag)
+	}
+
+	// Sorted so the same subnets always resolve to the same IDs
+	sort.Slice(subnets, func(i, j int) bool {
+		return *subnets[i].SubnetID < *subnets[j].SubnetID
+	})
+
+	return subnets, nil
+}
+
+// BalanceAZs returns the first subnet in each availability zone
+func BalanceAZs(subnets []*Subnet) []*Subnet {
+	azs := map[string]bool{}
+	balanced := []*Subnet{}
+
+	for _, subnet := range subnets {
+		az := to.Strs(subnet.AvailabilityZone)
+		if azs[az] {
+			continue
+		}
+
+		azs[az] = true
+		balanced = append(balanced, subnet)
+	}
+
+	return balanced
+}
+
+// VPC returns the VPC of the subnets, which must all be in the same VPC
+func VPC(subnets []*Subnet) (*string, error) {
+	var vpcID *string
+	for _, subnet := range subnets {
+		if subnet.VpcID == nil {
+			continue
+		}
+
+		if vpcID != nil && *vpcID != *subnet.VpcID {
+			return nil, fmt.Errorf("Subnets must be in the same VPC, found %v and %v", *vpcID, *subnet.VpcID)
+		}
+
+		vpcID = subnet.VpcID
+	}
+
+	return vpcID, nil
+}
+
 // isID sees if a string is
 func isID(name string) bool {
 	if len(name) < 8 {
@@ -94,6 +159,9 @@ func find(ec2Client aws.EC2API, in *ec2.DescribeSubnetsInput) ([]*Subnet, error)
 		subnets = append(subnets, &Subnet{
 			subnet.SubnetId,
 			aws.FetchEc2Tag(subnet.Tags, to.Strp("DeployWith")),
+			subnet.MapPublicIpOnLaunch,
+			subnet.AvailabilityZone,
+			subnet.VpcId,
 		})
 	}
 
@@ -0,0 +1,72 @@
+package aws
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// Throttled calls are retried more times, and for longer, than other errors,
+// as the health checks of large fleets can exceed the Auto Scaling and ELB rate limits for a while
+const (
+	throttleRetries   = 10
+	throttleBaseDelay = 500 * time.Millisecond
+	throttleMaxDelay  = 20 * time.Second
+)
+
+// throttleRetryer backs off throttled calls exponentially with full jitter so the Lambdas polling an account
+// spread out their retries, retrying other errors as the SDK does
+type throttleRetryer struct {
+	client.DefaultRetryer
+}
+
+// retryThrottles replaces the clients default retryer, keeping its retries of other errors
+func retryThrottles(c *client.Client) {
+	if d, ok := c.Retryer.(client.DefaultRetryer); ok {
+		c.Retryer = throttleRetryer{d}
+	}
+}
+
+// MaxRetries returns
+func (r throttleRetryer) MaxRetries() int {
+	if r.NumMaxRetries > throttleRetries {
+		return r.NumMaxRetries
+	}
+	return throttleRetries
+}
+
+// ShouldRetry returns
+func (r throttleRetryer) ShouldRetry(req *request.Request) bool {
+	if req.IsErrorThrottle() {
+		return true
+	}
+	return req.RetryCount < r.NumMaxRetries && r.DefaultRetryer.ShouldRetry(req)
+}
+
+// RetryRules returns
+func (r throttleRetryer) RetryRules(req *request.Request) time.Duration {
+	if !req.IsErrorThrottle() {
+		return r.DefaultRetryer.RetryRules(req)
+	}
+	return throttleDelay(req.RetryCount)
+}
+
+var (
+	jitterMu sync.Mutex
+	jitter   = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// throttleDelay returns a random delay up to the base doubled for each retry, capped at the max
+func throttleDelay(retry int) time.Duration {
+	ceiling := throttleMaxDelay
+	if retry < 16 && throttleBaseDelay<<uint(retry) < ceiling {
+		ceiling = throttleBaseDelay << uint(retry)
+	}
+
+	jitterMu.Lock()
+	defer jitterMu.Unlock()
+	return time.Duration(jitter.Int63n(int64(ceiling))) + time.Millisecond
+}
@@ -0,0 +1,51 @@
+package aws
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_throttleRetryer(t *testing.T) {
+	r := throttleRetryer{client.DefaultRetryer{NumMaxRetries: 3}}
+	assert.Equal(t, throttleRetries, r.MaxRetries())
+
+	throttled := &request.Request{
+		Error:        awserr.New("Throttling", "Rate exceeded", nil),
+		HTTPResponse: &http.Response{StatusCode: 400},
+		RetryCount:   5,
+	}
+	assert.True(t, r.ShouldRetry(throttled))
+	assert.True(t, r.RetryRules(throttled) <= throttleBaseDelay<<5+time.Millisecond)
+
+	// Other errors are retried as many times as before
+	failed := &request.Request{
+		Error:        awserr.New("InternalError", "", nil),
+		HTTPResponse: &http.Response{StatusCode: 500},
+		RetryCount:   2,
+	}
+	assert.True(t, r.ShouldRetry(failed))
+	failed.RetryCount = 3
+	assert.False(t, r.ShouldRetry(failed))
+
+	invalid := &request.Request{
+		Error:        awserr.New("ValidationError", "", nil),
+		HTTPResponse: &http.Response{StatusCode: 400},
+	}
+	assert.False(t, r.ShouldRetry(invalid))
+}
+
+func Test_throttleDelay(t *testing.T) {
+	for retry := 0; retry < 20; retry++ {
+		delay := throttleDelay(retry)
+		assert.True(t, delay > 0)
+		assert.True(t, delay <= throttleMaxDelay+time.Millisecond)
+	}
+
+	assert.True(t, throttleDelay(0) <= throttleBaseDelay+time.Millisecond)
+}
@@ -0,0 +1,97 @@
+package weighted
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// The pinned aws-sdk-go predates weighted target groups,
+// so these types mirror the ELBv2 API and requests are sent with the ELBv2 clients query protocol.
+
+// TargetGroupTuple mirrors the ELBv2 API
+type TargetGroupTuple struct {
+	_ struct{} `type:"structure"`
+
+	TargetGroupArn *string `type:"string"`
+	Weight         *int64  `type:"integer"`
+}
+
+// ForwardActionConfig mirrors the ELBv2 API
+type ForwardActionConfig struct {
+	_ struct{} `type:"structure"`
+
+	TargetGroups []*TargetGroupTuple `type:"list"`
+}
+
+// Action mirrors the ELBv2 API
+type Action struct {
+	_ struct{} `type:"structure"`
+
+	ForwardConfig *ForwardActionConfig `type:"structure"`
+	Type          *string              `type:"string" required:"true"`
+}
+
+// ModifyRuleInput mirrors the ELBv2 API
+type ModifyRuleInput struct {
+	_ struct{} `type:"structure"`
+
+	Actions []*Action `type:"list"`
+	RuleArn *string   `type:"string" required:"true"`
+}
+
+// ModifyRuleOutput mirrors the ELBv2 API
+type ModifyRuleOutput struct {
+	_ struct{} `type:"structure"`
+}
+
+// API is the subset of the ELBv2 API for weighted target groups
+type API interface {
+	ModifyRuleWeights(*ModifyRuleInput) (*ModifyRuleOutput, error)
+}
+
+// Client returns the weighted target group API of the ELBv2 client
+func Client(albc aws.ALBAPI) (API, error) {
+	if api, ok := albc.(API); ok {
+		return api, nil
+	}
+
+	if c, ok := aws.UncachedALB(albc).(*elbv2.ELBV2); ok {
+		return &queryClient{c}, nil
+	}
+
+	return nil, fmt.Errorf("ELBv2 client does not support weighted target groups")
+}
+
+type queryClient struct {
+	*elbv2.ELBV2
+}
+
+func (c *queryClient) ModifyRuleWeights(in *ModifyRuleInput) (*ModifyRuleOutput, error) {
+	out := &ModifyRuleOutput{}
+	req := c.NewRequest(&request.Operation{Name: "ModifyRule", HTTPMethod: "POST", HTTPPath: "/"}, in, out)
+	return out, req.Send()
+}
+
+// Forward sets the rule to forward the weight (0-100) of requests to the next target group and the rest to the active one
+func Forward(api API, ruleArn *string, activeArn *string, nextArn *string, weight int64) error {
+	_, err := api.ModifyRuleWeights(&ModifyRuleInput{
+		RuleArn: ruleArn,
+		Actions: []*Action{
+			&Action{
+				Type: to.Strp(elbv2.ActionTypeEnumForward),
+				ForwardConfig: &ForwardActionConfig{
+					TargetGroups: []*TargetGroupTuple{
+						&TargetGroupTuple{TargetGroupArn: activeArn, Weight: to.Int64p(100 - weight)},
+						&TargetGroupTuple{TargetGroupArn: nextArn, Weight: to.Int64p(weight)},
+					},
+				},
+			},
+		},
+	})
+
+	return err
+}
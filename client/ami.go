@@ -0,0 +1,37 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/ami"
+)
+
+// AMILatest prints the ID of the latest AMI matching the filter, e.g. to record in a release file
+func AMILatest(step_fn *string, filter *string) error {
+	if filter == nil || *filter == "" {
+		return fmt.Errorf("Usage: odin ami latest <filter>")
+	}
+
+	env, err := currentEnvironment(step_fn)
+	if err != nil {
+		return err
+	}
+
+	return latestAMI(env.awsc, *filter)
+}
+
+func latestAMI(awsc aws.Clients, filter string) error {
+	im, err := ami.Latest(awsc.EC2Client(nil, nil, nil), filter)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		emit(&Event{Type: "ami", Resources: []string{*im.ImageID}})
+		return nil
+	}
+
+	fmt.Println(*im.ImageID)
+	return nil
+}
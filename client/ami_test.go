@@ -0,0 +1,18 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LatestAMI(t *testing.T) {
+	awsc := mocks.MockAWS()
+	awsc.EC2.AddImage("web", "ami-654321")
+	awsc.EC2.DescribeImagesResp.Resp.Images[0].CreationDate = to.Strp("2020-01-01T00:00:00.000Z")
+
+	assert.NoError(t, latestAMI(awsc, "name=web-*"))
+	assert.Error(t, latestAMI(awsc, "owner=self"))
+}
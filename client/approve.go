@@ -0,0 +1,54 @@
+package client
+
+import (
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Approve approves the healthy release waiting at its approval gate so it cuts over, or rejects it so it rolls back
+func Approve(step_fn *string, releaseFile *string, releaseID *string, approved bool, reason string) error {
+	env, err := currentEnvironment(step_fn)
+	if err != nil {
+		return err
+	}
+
+	release, err := releaseFromFile(releaseFile, env)
+	if err != nil {
+		return err
+	}
+
+	// The approval is for a single release, a later release of the project config is not approved
+	release.ReleaseID = releaseID
+
+	return approve(env.awsc, release, env.deployerARN, approved, reason)
+}
+
+func approve(awsc aws.Clients, release *models.Release, deployerARN *string, approved bool, reason string) error {
+	exec, err := runningExecution(awsc, release, deployerARN)
+	if err != nil {
+		return err
+	}
+
+	if reason == "" {
+		reason = "Odin client rejected deploy"
+		if approved {
+			reason = "Odin client approved deploy"
+		}
+	}
+
+	if err := release.Approve(awsc.S3Client(nil, nil, nil), approved, to.Strp(reason)); err != nil {
+		return err
+	}
+
+	if !approved {
+		printExecution("rejected", exec)
+		return nil
+	}
+
+	printExecution("approved", exec)
+
+	exec.WaitForExecution(awsc.SFNClient(nil, nil, nil), 1, waiter)
+	printDone()
+	return nil
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Approve_Reject(t *testing.T) {
+	awsc := mocks.MockAWS()
+	r := minimalRelease(t)
+	r.Release.SetDefaults(to.Strp("region"), to.Strp("accountid"), "")
+	r.Approval = &models.ApprovalConfig{Timeout: to.Intp(60)}
+
+	// Nothing to approve without a running deploy
+	assert.Error(t, approve(awsc, r, to.Strp("deployerARN"), false, ""))
+
+	awsc.SFN.ListExecutionsResp = &sfn.ListExecutionsOutput{
+		Executions: []*sfn.ExecutionListItem{
+			&sfn.ExecutionListItem{
+				Name:         r.ExecutionName(),
+				ExecutionArn: to.Strp("arn"),
+				StartDate:    to.Timep(time.Now()),
+			},
+		},
+	}
+
+	assert.NoError(t, approve(awsc, r, to.Strp("deployerARN"), false, "bad"))
+
+	_, err := r.CheckApproval(awsc.S3, time.Now())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "rejected: bad")
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+)
+
+// auditRelease records the client and host uploading the release, and the git commit of its release files
+// Without release files, e.g. executing a pushed release, the commit recorded before is kept
+func auditRelease(release *models.Release, releaseFiles []string) {
+	if release.Audit == nil {
+		release.Audit = &models.Audit{}
+	}
+
+	release.Audit.ClientVersion = to.Strp(Version)
+
+	if hostname, err := os.Hostname(); err == nil {
+		release.Audit.Hostname = to.Strp(hostname)
+	}
+
+	if len(releaseFiles) > 0 {
+		release.Audit.GitSHA = gitSHA(releaseFiles)
+	}
+}
+
+// gitSHA returns the commit of the first files repository, suffixed -dirty if the files or their userdata
+// have uncommitted changes, or nil if it is not in git
+func gitSHA(files []string) *string {
+	dir := filepath.Dir(files[0])
+	paths := []string{}
+	for _, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return nil
+		}
+		paths = append(paths, abs, abs+".userdata")
+	}
+
+	git := func(args ...string) (string, error) {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+		return strings.TrimSpace(string(out)), err
+	}
+
+	sha, err := git("rev-parse", "HEAD")
+	if err != nil || sha == "" {
+		return nil
+	}
+
+	if status, err := git(append([]string{"status", "--porcelain", "--"}, paths...)...); err != nil || status != "" {
+		sha += "-dirty"
+	}
+
+	return &sha
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_auditRelease(t *testing.T) {
+	dir, err := ioutil.TempDir("", "odin-audit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "release.json")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("{}"), 0644))
+
+	r := minimalRelease(t)
+
+	// Not in git
+	auditRelease(r, []string{file})
+	assert.Equal(t, Version, *r.Audit.ClientVersion)
+	assert.NotNil(t, r.Audit.Hostname)
+	assert.Nil(t, r.Audit.GitSHA)
+
+	git := func(args ...string) string {
+		out, err := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=odin", "-c", "user.email=odin@example.com"}, args...)...).Output()
+		assert.NoError(t, err)
+		return strings.TrimSpace(string(out))
+	}
+
+	git("init", "-q")
+	git("add", "release.json")
+	git("commit", "-q", "-m", "release")
+	head := git("rev-parse", "HEAD")
+
+	auditRelease(r, []string{file})
+	assert.Equal(t, head, *r.Audit.GitSHA)
+
+	// Uncommitted userdata makes the release dirty
+	assert.NoError(t, ioutil.WriteFile(file+".userdata", []byte("#cloud_config"), 0644))
+	auditRelease(r, []string{file})
+	assert.Equal(t, head+"-dirty", *r.Audit.GitSHA)
+
+	// Without files the commit is kept
+	auditRelease(r, nil)
+	assert.Equal(t, head+"-dirty", *r.Audit.GitSHA)
+}
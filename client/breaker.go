@@ -0,0 +1,41 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+)
+
+// ResetBreaker resets the circuit breaker of the releases project config so it can be deployed again
+func ResetBreaker(step_fn *string, releaseFile *string) error {
+	env, err := currentEnvironment(step_fn)
+	if err != nil {
+		return err
+	}
+
+	release, err := releaseFromFile(releaseFile, env)
+	if err != nil {
+		return err
+	}
+
+	return resetBreaker(env.awsc, release)
+}
+
+func resetBreaker(awsc aws.Clients, release *models.Release) error {
+	breaker, err := release.Breaker(awsc.S3Client(nil, nil, nil))
+	if err != nil {
+		return err
+	}
+
+	if err := release.ResetBreaker(awsc.S3Client(nil, nil, nil)); err != nil {
+		return err
+	}
+
+	if !jsonOutput {
+		fmt.Printf("Reset circuit breaker of %v/%v after %v failures (%v)\n", to.Strs(release.ProjectName), to.Strs(release.ConfigName), breaker.Failures, to.Strs(breaker.Reason))
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ResetBreaker(t *testing.T) {
+	awsc := mocks.MockAWS()
+	r := minimalRelease(t)
+
+	r.Release.SetDefaults(to.Strp("region"), to.Strp("accountid"), "")
+
+	awsc.S3.AddGetObject(*r.BreakerPath(), `{"failures": 3, "tripped": true, "reason": "broken"}`, nil)
+	assert.Error(t, r.CheckBreaker(awsc.S3))
+
+	assert.NoError(t, resetBreaker(awsc, r))
+	assert.NoError(t, r.CheckBreaker(awsc.S3))
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/identity"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Canary deploys a small release through the deployer to check the deployer itself works,
+// recording the result as a CloudWatch metric. With an interval it keeps deploying the release.
+func Canary(step_fn *string, releaseFile *string, interval time.Duration) error {
+	env, err := currentEnvironment(step_fn)
+	if err != nil {
+		return err
+	}
+
+	for {
+		err := canaryFromFile(env, releaseFile)
+		if interval == 0 {
+			return err
+		}
+
+		if err != nil {
+			PrintError(err)
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+func canaryFromFile(env *environment, releaseFile *string) error {
+	// Each canary is a new release with its own ID and creation time
+	release, err := releaseFromFile(releaseFile, env)
+	if err != nil {
+		return err
+	}
+
+	if err := env.preflightBucket(release, release.ReleasePath(), release.UserDataPath()); err != nil {
+		return err
+	}
+
+	if release.Identity, err = identity.Presign(env.awsc.STSClient(nil, nil, nil), release.IdentityBinding()); err != nil {
+		return err
+	}
+
+	env.requestSignature(release)
+
+	return canary(env.awsc, release, env.deployerARN)
+}
+
+func canary(awsc aws.Clients, release *models.Release, deployerARN *string) error {
+	status, err := deployAndWait(awsc, release, deployerARN)
+
+	failed := err != nil || to.Strs(status) != "SUCCEEDED"
+	if merr := release.PutCanaryMetric(awsc.CWClient(nil, nil, nil), failed); merr != nil {
+		return merr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if failed {
+		return fmt.Errorf("Canary deploy of %v/%v %v", to.Strs(release.ProjectName), to.Strs(release.ConfigName), to.Strs(status))
+	}
+
+	return nil
+}
+
+// deployAndWait deploys the release and returns the final status of its execution, and a *FailedError if it failed
+func deployAndWait(awsc aws.Clients, release *models.Release, deployerARN *string) (*string, error) {
+	exec, err := startDeploy(awsc, release, deployerARN)
+	if err != nil {
+		return nil, err
+	}
+
+	printExecution("started", exec)
+
+	return waitForExecution(awsc.SFNClient(nil, nil, nil), exec)
+}
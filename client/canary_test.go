@@ -0,0 +1,21 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Canary_PutsMetric(t *testing.T) {
+	awsc := mocks.MockAWS()
+	r := minimalRelease(t)
+	r.Release.SetDefaults(to.Strp("region"), to.Strp("accountid"), "")
+	r.SetUserData(to.Strp("#cloud_config"))
+
+	canary(awsc, r, to.Strp("deployerARN"))
+
+	assert.Equal(t, 1, len(awsc.CW.MetricData))
+	assert.Equal(t, "CanaryFailed", *awsc.CW.MetricData[0].MetricName)
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/is"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Cleanup deletes the old ASGs of the project config a deploy did not finish deleting, with planOnly only listing them
+// The release ID to keep defaults to the project configs deployed release
+func Cleanup(step_fn *string, projectName *string, configName *string, releaseID *string, planOnly bool) error {
+	if is.EmptyStr(projectName) || is.EmptyStr(configName) {
+		return fmt.Errorf("Usage: odin cleanup [--plan] <project_name> <config_name> [<release_id>]")
+	}
+
+	env, err := currentEnvironment(step_fn)
+	if err != nil {
+		return err
+	}
+
+	release := recordsRelease(env, projectName, configName)
+	if !is.EmptyStr(releaseID) {
+		release.ReleaseID = releaseID
+	}
+
+	return cleanup(env.awsc, env.locker(), release, planOnly)
+}
+
+func cleanup(awsc aws.Clients, locker models.Locker, release *models.Release, planOnly bool) error {
+	plan, err := release.PlanCleanup(awsc.S3Client(nil, nil, nil), locker, awsc.ASGClient(nil, nil, nil))
+	if err != nil {
+		return err
+	}
+
+	resources := cleanupResources(plan)
+
+	if !planOnly {
+		if err := release.Cleanup(plan, awsc.ASGClient(nil, nil, nil), awsc.CWClient(nil, nil, nil), awsc.EC2Client(nil, nil, nil)); err != nil {
+			return err
+		}
+	}
+
+	if jsonOutput {
+		eventType := "cleaned"
+		if planOnly {
+			eventType = "cleanup_plan"
+		}
+		emit(&Event{Type: eventType, Resources: resources})
+		return nil
+	}
+
+	verb := "Deleted"
+	if planOnly {
+		verb = "Would delete"
+	}
+
+	fmt.Printf("%v %v resources of %v/%v keeping release %v\n", verb, len(resources), *release.ProjectName, *release.ConfigName, to.Strs(plan.Release))
+	for _, resource := range resources {
+		fmt.Printf("  %v\n", resource)
+	}
+
+	if planOnly && plan.Locked {
+		fmt.Println("A deploy holds the lock, so the cleanup cannot run until it finishes")
+	}
+
+	return nil
+}
+
+// cleanupResources describes each resource of the plan as "<type> <name>", ELBs and target groups are only detached
+func cleanupResources(plan *models.CleanupPlan) []string {
+	resources := []string{}
+
+	for _, a := range plan.ASGs {
+		resources = append(resources, fmt.Sprintf("asg %v", to.Strs(a.Name)))
+
+		if a.LaunchConfiguration != nil {
+			resources = append(resources, fmt.Sprintf("launch_configuration %v", *a.LaunchConfiguration))
+		}
+
+		if a.LaunchTemplate != nil {
+			resources = append(resources, fmt.Sprintf("launch_template %v", *a.LaunchTemplate))
+		}
+
+		if len(a.LoadBalancers) > 0 {
+			resources = append(resources, fmt.Sprintf("detach elbs %v", strings.Join(to.StrSlice(a.LoadBalancers), ",")))
+		}
+
+		if len(a.TargetGroups) > 0 {
+			resources = append(resources, fmt.Sprintf("detach target_groups %v", strings.Join(to.StrSlice(a.TargetGroups), ",")))
+		}
+	}
+
+	return resources
+}
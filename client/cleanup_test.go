@@ -0,0 +1,30 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Cleanup(t *testing.T) {
+	awsc := mocks.MockAWS()
+	r := minimalRelease(t)
+	r.Release.SetDefaults(to.Strp("region"), to.Strp("accountid"), "")
+
+	awsc.ASG.AddPreviousRuntimeResources("project", "config", "web", "old-release")
+	awsc.ASG.AddPreviousRuntimeResources("project", "config", "web", "rr")
+
+	// Only the deployed release can be kept
+	assert.Error(t, cleanup(awsc, &models.S3Locker{S3: awsc.S3}, r, true))
+	assert.NoError(t, r.SaveLinkage(awsc.S3))
+
+	// The plan only lists
+	assert.NoError(t, cleanup(awsc, &models.S3Locker{S3: awsc.S3}, r, true))
+	assert.Equal(t, 0, len(awsc.ASG.DeletedASGs))
+
+	assert.NoError(t, cleanup(awsc, &models.S3Locker{S3: awsc.S3}, r, false))
+	assert.Equal(t, []string{"project-config-web-old-release"}, awsc.ASG.DeletedASGs)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math"
+	"path/filepath"
 	"sort"
 	"strings"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"github.com/coinbase/step/execution"
 	"github.com/coinbase/step/utils/is"
 	"github.com/coinbase/step/utils/to"
+	yaml "gopkg.in/yaml.v2"
 )
 
 // executionPrefix returns
@@ -45,7 +47,7 @@ func validateClientAttributes(release *models.Release) error {
 		return fmt.Errorf("Bucket must be defined")
 	}
 
-	return nil
+	return release.ValidateHooks()
 }
 
 func prepareRelease(release *models.Release, region *string, accountID *string) {
@@ -62,6 +64,12 @@ func parseRelease(releaseFile string) (*models.Release, error) {
 		return nil, err
 	}
 
+	if isYAML(releaseFile) {
+		if rawRelease, err = yamlToJSON(rawRelease); err != nil {
+			return nil, err
+		}
+	}
+
 	var release models.Release
 	if err := json.Unmarshal(rawRelease, &release); err != nil {
 		return nil, err
@@ -70,6 +78,59 @@ func parseRelease(releaseFile string) (*models.Release, error) {
 	return &release, nil
 }
 
+func isYAML(releaseFile string) bool {
+	ext := strings.ToLower(filepath.Ext(releaseFile))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// yamlToJSON converts a YAML release to JSON, so it is parsed with the same checks for unknown keys
+func yamlToJSON(raw []byte) ([]byte, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+
+	converted, err := jsonValue(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(converted)
+}
+
+// jsonValue converts the maps YAML decodes, which can have any key, to maps with string keys
+func jsonValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for key, item := range v {
+			k, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("YAML key %v must be a string, quote it", key)
+			}
+
+			converted, err := jsonValue(item)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = converted
+		}
+		return m, nil
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			converted, err := jsonValue(item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = converted
+		}
+		return list, nil
+	default:
+		return v, nil
+	}
+}
+
 func parseUserData(releaseFile string) (*string, error) {
 	userdataFile := fmt.Sprintf("%v.userdata", releaseFile)
 	rawUserData, err := ioutil.ReadFile(userdataFile)
@@ -81,24 +142,45 @@ func parseUserData(releaseFile string) (*string, error) {
 	return to.Strp(string(rawUserData)), nil
 }
 
-func releaseFromFile(releaseFile *string, region *string, accountID *string) (*models.Release, error) {
-	release, err := parseRelease(*releaseFile)
+func releaseFromFile(releaseFile *string, env *environment) (*models.Release, error) {
+	return releaseFromFiles([]string{*releaseFile}, env)
+}
+
+// releaseFromFiles builds the release from a base release file merged with overlays
+func releaseFromFiles(releaseFiles []string, env *environment) (*models.Release, error) {
+	if len(releaseFiles) == 0 {
+		return nil, fmt.Errorf("Release file must be given")
+	}
+
+	release, err := parseReleaseFiles(releaseFiles, newInterpolator(env.awsc.SSMClient(nil, nil, nil)))
 	if err != nil {
 		return nil, err
 	}
 
-	userdata, err := parseUserData(*releaseFile)
+	userdata, err := parseUserDataFiles(releaseFiles)
 	if err != nil {
 		return nil, err
 	}
 
+	auditRelease(release, releaseFiles)
+
+	return completeRelease(release, userdata, env)
+}
+
+// completeRelease sets the releases userdata and the environments defaults, then validates it
+func completeRelease(release *models.Release, userdata *string, env *environment) (*models.Release, error) {
 	release.SetUserData(userdata)
 	release.UserDataSHA256 = to.Strp(to.SHA256Str(userdata))
 
-	prepareRelease(release, region, accountID)
+	if release.Bucket == nil {
+		release.Bucket = env.bucket
+	}
+
+	prepareRelease(release, env.region, env.accountID)
+	release.UseStore(models.StoreOf(env.awsc))
 
 	if err := validateClientAttributes(release); err != nil {
-		return nil, err
+		return nil, &ValidationError{err.Error()}
 	}
 
 	return release, nil
@@ -119,6 +201,14 @@ func waiter(ed *execution.Execution, sd *execution.StateDetails, err error) erro
 		return fmt.Errorf("Unexpected Error %v", err.Error())
 	}
 
+	if jsonOutput {
+		return emitStateEvent(ed, sd)
+	}
+
+	if watch != nil {
+		return watch.render(ed, sd)
+	}
+
 	spinnerCounter++
 
 	ws, err := waiterStr(ed.Status, sd)
@@ -134,16 +224,14 @@ func waiter(ed *execution.Execution, sd *execution.StateDetails, err error) erro
 func waiterStr(status *string, sd *execution.StateDetails) (string, error) {
 	newLine := fmt.Sprintf("%s(%s)", *status, stateName(sd))
 
-	var release models.Release
-	if sd.LastOutput != nil {
-		if err := json.Unmarshal([]byte(*sd.LastOutput), &release); err != nil {
-			return "", err
-		}
+	release, err := outputRelease(sd)
+	if err != nil {
+		return "", err
 	}
 	// Checks it has correctly unmarshalled
 	if release.ProjectName != nil {
 		if release.Error != nil {
-			newLine = fmt.Sprintf("%v Error %v(%v)", newLine, *release.Error.Error, *release.Error.Cause)
+			newLine = fmt.Sprintf("%v Error %v %v(%v)", newLine, to.Strs(models.ErrorCode(release.Error)), *release.Error.Error, *release.Error.Cause)
 		} else {
 			sh := []string{}
 			for name, service := range release.Services {
@@ -0,0 +1,250 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+)
+
+// completionFlag is a flag of a command, and whether it is followed by a value
+type completionFlag struct {
+	name  string
+	value bool
+}
+
+// completionCommands are the commands and their flags, which are completed before files
+var completionCommands = map[string][]completionFlag{
+	"approve":       {{"--reject", false}, {"--reason", true}},
+	"ami":           {},
+	"attach":        {},
+	"canary":        {{"--interval", true}},
+	"cleanup":       {{"--plan", false}},
+	"completion":    {},
+	"context":       {},
+	"deploy":        {{"--dry-run", false}, {"--wait-for-lock", true}, {"--confirm-cost", false}, {"--yes", false}, {"--watch", false}, {"-f", true}},
+	"destroy":       {{"--yes", false}},
+	"execute":       {{"--wait-for-lock", true}},
+	"export":        {},
+	"failover":      {},
+	"fails":         {},
+	"gc":            {{"--dry-run", false}, {"--min-age", true}},
+	"halt":          {{"--service", true}},
+	"import":        {},
+	"json":          {},
+	"pause":         {},
+	"push":          {{"-f", true}},
+	"release-notes": {},
+	"reset-breaker": {},
+	"resume":        {},
+	"self-update":   {{"--version", true}},
+	"server":        {{"--addr", true}, {"--tls-cert", true}, {"--tls-key", true}},
+	"simulate":      {{"--fail", true}},
+	"stack":         {{"--format", true}},
+	"status":        {},
+	"unlock":        {{"--force", false}},
+	"unpause":       {},
+	"validate":      {{"--schema", false}, {"-f", true}},
+	"version":       {},
+}
+
+// projectConfigCommands take a project name then a config name
+var projectConfigCommands = map[string]bool{
+	"cleanup":       true,
+	"destroy":       true,
+	"export":        true,
+	"failover":      true,
+	"release-notes": true,
+	"status":        true,
+	"unlock":        true,
+}
+
+// Completion prints the completion script for bash, zsh or fish
+func Completion(shell *string) error {
+	switch to.Strs(shell) {
+	case "bash":
+		fmt.Print(bashCompletion)
+	case "zsh":
+		fmt.Print(zshCompletion)
+	case "fish":
+		fmt.Print(fishCompletion)
+	default:
+		return fmt.Errorf("Usage: odin completion bash|zsh|fish")
+	}
+
+	return nil
+}
+
+// Complete prints the candidates for the next word after the words already typed, one per line
+// It is called by the completion scripts, so errors only mean there is nothing to complete
+func Complete(words []string) error {
+	env, _ := currentEnvironment(nil)
+
+	projects := func() (map[string][]string, error) {
+		if env == nil || env.bucket == nil {
+			return nil, fmt.Errorf("No bucket")
+		}
+		return projectConfigs(models.StoreOf(env.awsc)(env.awsc.S3Client(nil, nil, nil), env.bucket), env.accountID)
+	}
+
+	contexts := func() []string {
+		config, err := LoadConfig(ConfigPath())
+		if err != nil {
+			return nil
+		}
+
+		names := []string{}
+		for name := range config.Contexts {
+			names = append(names, name)
+		}
+		return names
+	}
+
+	for _, candidate := range completions(words, projects, contexts) {
+		fmt.Println(candidate)
+	}
+
+	return nil
+}
+
+// completions returns the sorted candidates for the word after words
+func completions(words []string, projects func() (map[string][]string, error), contexts func() []string) []string {
+	if len(words) == 0 {
+		commands := map[string]bool{}
+		for command := range completionCommands {
+			commands[command] = true
+		}
+		return sortedSet(commands)
+	}
+
+	command := words[0]
+	flags, ok := completionCommands[command]
+	if !ok {
+		return nil
+	}
+
+	// The positional arguments typed so far, skipping flags and their values
+	args := []string{}
+	for i := 1; i < len(words); i++ {
+		if !strings.HasPrefix(words[i], "-") {
+			args = append(args, words[i])
+			continue
+		}
+
+		for _, f := range flags {
+			if f.value && strings.TrimLeft(words[i], "-") == strings.TrimLeft(f.name, "-") {
+				if i == len(words)-1 {
+					return nil // The flags value is next
+				}
+				i++
+			}
+		}
+	}
+
+	candidates := []string{}
+	for _, f := range flags {
+		candidates = append(candidates, f.name)
+	}
+
+	switch {
+	case projectConfigCommands[command] && len(args) < 2:
+		names, err := projects()
+		if err != nil {
+			break
+		}
+
+		if len(args) == 0 {
+			for project := range names {
+				candidates = append(candidates, project)
+			}
+		} else {
+			candidates = append(candidates, names[args[0]]...)
+		}
+	case command == "ami" && len(args) == 0:
+		candidates = append(candidates, "latest")
+	case command == "completion" && len(args) == 0:
+		candidates = append(candidates, "bash", "zsh", "fish")
+	case command == "context" && len(args) == 0:
+		candidates = append(candidates, "use")
+	case command == "context" && len(args) == 1 && args[0] == "use":
+		candidates = append(candidates, contexts()...)
+	}
+
+	sort.Strings(candidates)
+	return candidates
+}
+
+// projectConfigs returns the config names of each project with releases in the bucket
+// Records are stored under <account>/<project_name>/<config_name>/<release_id>/release
+func projectConfigs(store models.ArtifactStore, accountID *string) (map[string][]string, error) {
+	prefix := to.Strs(accountID) + "/"
+	found := map[string]map[string]bool{}
+
+	artifacts, err := store.List(to.Strp(prefix))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, artifact := range artifacts {
+		key := to.Strs(artifact.Path)
+		if !strings.HasPrefix(key, prefix) || !strings.HasSuffix(key, "/release") {
+			continue
+		}
+
+		// project names can contain a "/", the config is the last part before the release ID
+		parts := strings.Split(strings.TrimPrefix(key, prefix), "/")
+		if len(parts) < 4 {
+			continue
+		}
+
+		project := strings.Join(parts[:len(parts)-3], "/")
+		config := parts[len(parts)-3]
+		if found[project] == nil {
+			found[project] = map[string]bool{}
+		}
+		found[project][config] = true
+	}
+
+	names := map[string][]string{}
+	for project, configs := range found {
+		names[project] = sortedSet(configs)
+	}
+
+	return names, nil
+}
+
+func sortedSet(set map[string]bool) []string {
+	keys := []string{}
+	for key := range set {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+const bashCompletion = `# odin bash completion, e.g. source <(odin completion bash)
+_odin() {
+  local cur="${COMP_WORDS[COMP_CWORD]}"
+  local candidates
+  candidates=$(odin __complete "${COMP_WORDS[@]:1:COMP_CWORD-1}" 2>/dev/null)
+  COMPREPLY=($(compgen -W "$candidates" -- "$cur"))
+}
+complete -o default -F _odin odin
+`
+
+const zshCompletion = `#compdef odin
+# odin zsh completion, e.g. source <(odin completion zsh)
+_odin() {
+  local -a candidates
+  candidates=(${(f)"$(odin __complete ${words[2,CURRENT-1]} 2>/dev/null)"})
+  compadd -a candidates || _files
+}
+compdef _odin odin
+`
+
+const fishCompletion = `# odin fish completion, e.g. odin completion fish | source
+complete -c odin -a '(odin __complete (commandline -opc)[2..-1] 2>/dev/null)'
+`
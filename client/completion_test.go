@@ -0,0 +1,47 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ProjectConfigs(t *testing.T) {
+	s3c := &listingS3{MockS3Client: &mocks.MockS3Client{}}
+	s3c.add("000000000000/coinbase/null/development/release-1/release", "{}")
+	s3c.add("000000000000/coinbase/null/production/release-2/release", "{}")
+	s3c.add("000000000000/coinbase/null/production/release-2/userdata", "#cloud_config")
+	s3c.add("000000000000/web/staging/lock", "lock")
+	s3c.add("111111111111/other/config/release-1/release", "{}")
+
+	names, err := projectConfigs(models.NewS3Store(s3c, to.Strp("bucket")), to.Strp("000000000000"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{"coinbase/null": []string{"development", "production"}}, names)
+}
+
+func Test_Completions(t *testing.T) {
+	projects := func() (map[string][]string, error) {
+		return map[string][]string{"coinbase/null": []string{"development", "production"}}, nil
+	}
+	contexts := func() []string { return []string{"staging"} }
+
+	commands := completions(nil, projects, contexts)
+	assert.Contains(t, commands, "deploy")
+	assert.Contains(t, commands, "status")
+
+	assert.Equal(t, []string{"coinbase/null"}, completions([]string{"status"}, projects, contexts))
+	assert.Equal(t, []string{"development", "production"}, completions([]string{"status", "coinbase/null"}, projects, contexts))
+	assert.Equal(t, []string{"--yes", "coinbase/null"}, completions([]string{"destroy"}, projects, contexts))
+
+	// Flags are completed before files, except after a flag that takes a value
+	assert.Contains(t, completions([]string{"deploy"}, projects, contexts), "--dry-run")
+	assert.Nil(t, completions([]string{"deploy", "--wait-for-lock"}, projects, contexts))
+	assert.Contains(t, completions([]string{"deploy", "--wait-for-lock", "30m"}, projects, contexts), "--yes")
+
+	assert.Equal(t, []string{"use"}, completions([]string{"context"}, projects, contexts))
+	assert.Equal(t, []string{"staging"}, completions([]string{"context", "use"}, projects, contexts))
+	assert.Nil(t, completions([]string{"unknown"}, projects, contexts))
+}
@@ -0,0 +1,363 @@
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Context is a named environment the client deploys to
+type Context struct {
+	Account  *string `yaml:"account,omitempty"`
+	Region   *string `yaml:"region,omitempty"`
+	Role     *string `yaml:"role,omitempty"`
+	Deployer *string `yaml:"deployer,omitempty"` // Step function name or ARN
+	Bucket   *string `yaml:"bucket,omitempty"`
+
+	// CostThreshold is the most USD a release can increase the estimated monthly cost by without --confirm-cost
+	CostThreshold *float64 `yaml:"cost_threshold,omitempty"`
+
+	// SigningKey is the KMS asymmetric key releases are signed with, the algorithm defaults to ECDSA_SHA_256
+	SigningKey       *string `yaml:"signing_key,omitempty"`
+	SigningAlgorithm *string `yaml:"signing_algorithm,omitempty"`
+
+	// Preflight checks the bucket before releases are uploaded to it, defaults to true
+	Preflight *bool `yaml:"preflight,omitempty"`
+
+	// LockTable is the DynamoDB table of the deployers locks, set if its ODIN_LOCK_TABLE is
+	LockTable *string `yaml:"lock_table,omitempty"`
+}
+
+// Config is the clients config file
+type Config struct {
+	CurrentContext *string             `yaml:"current_context,omitempty"`
+	Contexts       map[string]*Context `yaml:"contexts,omitempty"`
+	UpdateURL      *string             `yaml:"update_url,omitempty"` // Endpoint used by self-update
+	Telemetry      *TelemetryConfig    `yaml:"telemetry,omitempty"`
+}
+
+// ConfigPath returns ODIN_CONFIG or ~/.odin/config.yaml
+func ConfigPath() string {
+	if path := os.Getenv("ODIN_CONFIG"); path != "" {
+		return path
+	}
+
+	return filepath.Join(os.Getenv("HOME"), ".odin", "config.yaml")
+}
+
+// LoadConfig reads the config file, a missing file is an empty config
+func LoadConfig(path string) (*Config, error) {
+	config := &Config{}
+
+	raw, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(raw, config); err != nil {
+		return nil, fmt.Errorf("Config %v invalid %v", path, err.Error())
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("Config %v invalid %v", path, err.Error())
+	}
+
+	return config, nil
+}
+
+// Save writes the config file
+func (c *Config) Save(path string) error {
+	raw, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, raw, 0600)
+}
+
+// Validate returns
+func (c *Config) Validate() error {
+	for name, ctx := range c.Contexts {
+		if ctx == nil {
+			return fmt.Errorf("Context %v is nil", name)
+		}
+
+		// Roles are assumed in the account
+		if ctx.Role != nil && ctx.Account == nil {
+			return fmt.Errorf("Context %v role requires account", name)
+		}
+
+		if ctx.CostThreshold != nil && *ctx.CostThreshold < 0 {
+			return fmt.Errorf("Context %v cost_threshold must not be negative", name)
+		}
+	}
+
+	if c.CurrentContext != nil && c.Contexts[*c.CurrentContext] == nil {
+		return fmt.Errorf("Current context %v not found", *c.CurrentContext)
+	}
+
+	return nil
+}
+
+// Use sets the current context
+func (c *Config) Use(name string) error {
+	if c.Contexts[name] == nil {
+		return fmt.Errorf("Context %v not found", name)
+	}
+
+	c.CurrentContext = &name
+	return nil
+}
+
+// Current returns the current context, or an empty context if none is set
+func (c *Config) Current() *Context {
+	if c.CurrentContext == nil || c.Contexts[*c.CurrentContext] == nil {
+		return &Context{}
+	}
+
+	return c.Contexts[*c.CurrentContext]
+}
+
+// UseContext sets the current context in the config file
+func UseContext(name *string) error {
+	if name == nil || *name == "" {
+		return fmt.Errorf("Context name must be defined")
+	}
+
+	path := ConfigPath()
+	config, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	if err := config.Use(*name); err != nil {
+		return err
+	}
+
+	return config.Save(path)
+}
+
+// ListContexts prints the contexts marking the current one
+func ListContexts() error {
+	config, err := LoadConfig(ConfigPath())
+	if err != nil {
+		return err
+	}
+
+	names := []string{}
+	for name := range config.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		current := " "
+		if config.CurrentContext != nil && *config.CurrentContext == name {
+			current = "*"
+		}
+		fmt.Printf("%v %v\n", current, name)
+	}
+
+	return nil
+}
+
+//////////
+// Environment
+//////////
+
+type environment struct {
+	awsc          aws.Clients
+	region        *string
+	accountID     *string
+	bucket        *string
+	deployerARN   *string
+	costThreshold *float64
+	lockTable     *string
+
+	signingKey       *string
+	signingAlgorithm *string
+
+	preflight     bool
+	preflighted   map[string]bool // Buckets and project configs that passed preflight
+	preflightLock sync.Mutex      // The server preflights concurrent deploys
+}
+
+// currentEnvironment merges the current context over the default AWS environment
+// stepFn (ODIN_STEP) takes precedence over the contexts deployer
+func currentEnvironment(stepFn *string) (*environment, error) {
+	config, err := LoadConfig(ConfigPath())
+	if err != nil {
+		return nil, err
+	}
+
+	return config.Current().environment(stepFn), nil
+}
+
+func (ctx *Context) environment(stepFn *string) *environment {
+	region, accountID := ctx.Region, ctx.Account
+	if region == nil || accountID == nil {
+		defaultRegion, defaultAccountID := to.RegionAccount()
+
+		if region == nil {
+			region = defaultRegion
+		}
+
+		if accountID == nil {
+			accountID = defaultAccountID
+		}
+	}
+
+	deployer := ctx.Deployer
+	if stepFn != nil {
+		deployer = stepFn
+	}
+
+	if deployer == nil {
+		deployer = to.Strp("coinbase-odin")
+	}
+
+	deployerARN := deployer
+	if !strings.HasPrefix(*deployer, "arn:") {
+		deployerARN = to.StepArn(region, accountID, deployer)
+	}
+
+	var awsc aws.Clients = &aws.ClientsStr{}
+	if ctx.Role != nil || ctx.Region != nil {
+		awsc = &contextClients{awsc, region, accountID, ctx.Role}
+	}
+
+	stateClients = awsc
+
+	return &environment{
+		awsc:          awsc,
+		region:        region,
+		accountID:     accountID,
+		bucket:        ctx.Bucket,
+		deployerARN:   deployerARN,
+		costThreshold: ctx.CostThreshold,
+		lockTable:     ctx.LockTable,
+
+		signingKey:       ctx.SigningKey,
+		signingAlgorithm: ctx.SigningAlgorithm,
+
+		preflight:   ctx.Preflight == nil || *ctx.Preflight,
+		preflighted: map[string]bool{},
+	}
+}
+
+// requestSignature has the release signed with the contexts signing key when it is uploaded
+func (env *environment) requestSignature(release *models.Release) {
+	if env.signingKey != nil {
+		release.Signature = &models.Signature{KeyID: env.signingKey, Algorithm: env.signingAlgorithm}
+	}
+}
+
+// locker returns the locker the deployer uses, its lock table or the bucket
+func (env *environment) locker() models.Locker {
+	return models.NewLocker(env.awsc.S3Client(nil, nil, nil), env.awsc.DynamoDBClient(nil, nil, nil), env.lockTable)
+}
+
+// contextClients creates every client in the contexts region, account and role
+type contextClients struct {
+	aws.Clients
+	region    *string
+	accountID *string
+	role      *string
+}
+
+// S3Client returns
+func (c *contextClients) S3Client(*string, *string, *string) aws.S3API {
+	return c.Clients.S3Client(c.region, c.accountID, c.role)
+}
+
+// ASGClient returns
+func (c *contextClients) ASGClient(*string, *string, *string) aws.ASGAPI {
+	return c.Clients.ASGClient(c.region, c.accountID, c.role)
+}
+
+// ELBClient returns
+func (c *contextClients) ELBClient(*string, *string, *string) aws.ELBAPI {
+	return c.Clients.ELBClient(c.region, c.accountID, c.role)
+}
+
+// EC2Client returns
+func (c *contextClients) EC2Client(*string, *string, *string) aws.EC2API {
+	return c.Clients.EC2Client(c.region, c.accountID, c.role)
+}
+
+// ALBClient returns
+func (c *contextClients) ALBClient(*string, *string, *string) aws.ALBAPI {
+	return c.Clients.ALBClient(c.region, c.accountID, c.role)
+}
+
+// CWClient returns
+func (c *contextClients) CWClient(*string, *string, *string) aws.CWAPI {
+	return c.Clients.CWClient(c.region, c.accountID, c.role)
+}
+
+// IAMClient returns
+func (c *contextClients) IAMClient(*string, *string, *string) aws.IAMAPI {
+	return c.Clients.IAMClient(c.region, c.accountID, c.role)
+}
+
+// SNSClient returns
+func (c *contextClients) SNSClient(*string, *string, *string) aws.SNSAPI {
+	return c.Clients.SNSClient(c.region, c.accountID, c.role)
+}
+
+// SFNClient returns
+func (c *contextClients) SFNClient(*string, *string, *string) aws.SFNAPI {
+	return c.Clients.SFNClient(c.region, c.accountID, c.role)
+}
+
+// SSMClient returns
+func (c *contextClients) SSMClient(*string, *string, *string) aws.SSMAPI {
+	return c.Clients.SSMClient(c.region, c.accountID, c.role)
+}
+
+// KMSClient returns
+func (c *contextClients) KMSClient(*string, *string, *string) aws.KMSAPI {
+	return c.Clients.KMSClient(c.region, c.accountID, c.role)
+}
+
+// DynamoDBClient returns
+func (c *contextClients) DynamoDBClient(*string, *string, *string) aws.DynamoDBAPI {
+	return c.Clients.DynamoDBClient(c.region, c.accountID, c.role)
+}
+
+// PricingClient returns a client in the region given, as the Price List API is only in a few regions
+func (c *contextClients) PricingClient(region *string, _ *string, _ *string) aws.PricingAPI {
+	return c.Clients.PricingClient(region, c.accountID, c.role)
+}
+
+// STSClient returns
+func (c *contextClients) STSClient(*string, *string, *string) aws.STSAPI {
+	return c.Clients.STSClient(c.region, c.accountID, c.role)
+}
+
+// QuotasClient returns
+func (c *contextClients) QuotasClient(*string, *string, *string) aws.QuotasAPI {
+	return c.Clients.QuotasClient(c.region, c.accountID, c.role)
+}
+
+// ArtifactStore returns the store of the wrapped clients, so they can keep artifacts somewhere other than S3
+func (c *contextClients) ArtifactStore(s3c aws.S3API, bucket *string) models.ArtifactStore {
+	return models.StoreOf(c.Clients)(s3c, bucket)
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfig(t *testing.T, raw string) string {
+	dir, err := ioutil.TempDir("", "odin")
+	assert.NoError(t, err)
+
+	path := filepath.Join(dir, "config.yaml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(raw), 0600))
+	return path
+}
+
+func Test_LoadConfig_Missing(t *testing.T) {
+	config, err := LoadConfig("/does/not/exist/config.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, &Context{}, config.Current())
+}
+
+func Test_LoadConfig_UseSave(t *testing.T) {
+	path := writeConfig(t, `
+contexts:
+  dev:
+    region: us-east-1
+    account: "000000000000"
+  prod:
+    region: us-west-2
+    account: "111111111111"
+    role: deployer
+    deployer: arn:aws:states:us-west-2:111111111111:stateMachine:odin
+    bucket: prod-odin
+`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, &Context{}, config.Current())
+
+	assert.Error(t, config.Use("staging"))
+	assert.NoError(t, config.Use("prod"))
+	assert.NoError(t, config.Save(path))
+
+	config, err = LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "prod", *config.CurrentContext)
+	assert.Equal(t, "prod-odin", *config.Current().Bucket)
+
+	env := config.Current().environment(nil)
+	assert.Equal(t, "us-west-2", *env.region)
+	assert.Equal(t, "111111111111", *env.accountID)
+	assert.Equal(t, "arn:aws:states:us-west-2:111111111111:stateMachine:odin", *env.deployerARN)
+
+	// ODIN_STEP overrides the contexts deployer
+	env = config.Current().environment(to.Strp("other-odin"))
+	assert.Equal(t, *to.StepArn(to.Strp("us-west-2"), to.Strp("111111111111"), to.Strp("other-odin")), *env.deployerARN)
+}
+
+func Test_LoadConfig_Invalid(t *testing.T) {
+	path := writeConfig(t, `
+contexts:
+  prod:
+    role: deployer
+`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	_, err := LoadConfig(path)
+	assert.Error(t, err)
+
+	path = writeConfig(t, `current_context: prod`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	_, err = LoadConfig(path)
+	assert.Error(t, err)
+}
@@ -2,56 +2,161 @@ package client
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/sfn/sfniface"
 	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/identity"
 	"github.com/coinbase/odin/deployer/models"
-	"github.com/coinbase/step/aws/s3"
 	"github.com/coinbase/step/execution"
 	"github.com/coinbase/step/utils/to"
 )
 
-// Deploy attempts to deploy release
-func Deploy(step_fn *string, releaseFile *string) error {
-	region, accountID := to.RegionAccount()
-	release, err := releaseFromFile(releaseFile, region, accountID)
+// Deploy attempts to deploy release, built from the release files with each merged over the files before it
+// With waitForLock the release is queued for up to that long if another release holds the lock
+// If the context has a cost threshold, a release over it is only deployed with confirmCost
+// In a terminal the deploy is confirmed after printing its summary, unless yes
+func Deploy(step_fn *string, releaseFiles []string, waitForLock time.Duration, confirmCost bool, yes bool) error {
+	env, err := currentEnvironment(step_fn)
 	if err != nil {
 		return err
 	}
 
-	deployerARN := to.StepArn(region, accountID, step_fn)
+	checkThreshold := env.costThreshold != nil && !confirmCost
+	prompt := !yes && !jsonOutput && interactive()
 
-	return deploy(&aws.ClientsStr{}, release, deployerARN)
-}
+	if checkThreshold || prompt {
+		// The plan sets defaults and resources, so is made from its own copy of the release
+		planned, err := releaseFromFiles(releaseFiles, env)
+		if err != nil {
+			return err
+		}
+
+		// Before asking to confirm a deploy that cannot be uploaded
+		if err := env.preflightBucket(planned, planned.ReleasePath(), planned.UserDataPath()); err != nil {
+			return err
+		}
+
+		p, err := plan(env.awsc, planned)
+		if err != nil {
+			return err
+		}
+
+		if checkThreshold {
+			if err := checkPlanCost(p, *env.costThreshold); err != nil {
+				return err
+			}
+		}
+
+		if prompt {
+			if err := confirmDeploy(planned, p); err != nil {
+				return err
+			}
+		}
+	}
+
+	release, err := releaseFromFiles(releaseFiles, env)
+	if err != nil {
+		return err
+	}
+
+	if err := env.preflightBucket(release, release.ReleasePath(), release.UserDataPath()); err != nil {
+		return err
+	}
+
+	if waitForLock > 0 {
+		release.LockWait = to.Intp(int(waitForLock.Seconds()))
+	}
+
+	// Prove who is deploying to the deployers RBAC
+	if release.Identity, err = identity.Presign(env.awsc.STSClient(nil, nil, nil), release.IdentityBinding()); err != nil {
+		return err
+	}
 
-func kMSKey() *string {
-	// TODO: allow customization of the KMS key from the command line utility
-	return to.Strp("alias/aws/s3")
+	env.requestSignature(release)
+
+	return deploy(env.awsc, release, env.deployerARN)
 }
 
 func deploy(awsc aws.Clients, release *models.Release, deployerARN *string) error {
-	// Uploading the Release to S3 to match SHAs
-	if err := s3.PutStruct(awsc.S3Client(nil, nil, nil), release.Bucket, release.ReleasePath(), release); err != nil {
+	// The execution is named first so the hooks can find it
+	nameExecution(release, deployerARN)
+
+	if err := runHooks(release, models.HookPreDeploy, nil); err != nil {
 		return err
 	}
 
+	status, err := deployAndWait(awsc, release, deployerARN)
+
+	if herr := runPostHooks(release, status, err); herr != nil {
+		if err != nil {
+			PrintError(herr) // The deploys error is returned
+		} else {
+			err = herr
+		}
+	}
+
+	return err
+}
+
+// startDeploy uploads the release and its userdata then starts its execution
+func startDeploy(awsc aws.Clients, release *models.Release, deployerARN *string) (*execution.Execution, error) {
+	name := nameExecution(release, deployerARN)
+
+	if err := signRelease(awsc, release); err != nil {
+		return nil, err
+	}
+
+	// Uploading the Release to S3 to match SHAs
+	if err := models.PutArtifact(releaseStore(awsc, release), release.ReleasePath(), release.WithoutIdentity()); err != nil {
+		return nil, err
+	}
+
 	// Uploading the encrypted Userdata to S3
-	if err := s3.PutSecure(awsc.S3Client(nil, nil, nil), release.Bucket, release.UserDataPath(), release.UserData(), kMSKey()); err != nil {
-		return err
+	if err := userDataStore(awsc, release).PutSecure(release.UserDataPath(), []byte(to.Strs(release.UserData())), release.UserDataKMSKeyID()); err != nil {
+		return nil, err
 	}
 
-	exec, err := findOrCreateExec(awsc.SFNClient(nil, nil, nil), deployerARN, release)
-	if err != nil {
-		return err
+	return findOrCreateExec(awsc.SFNClient(nil, nil, nil), deployerARN, release, name)
+}
+
+// releaseS3 returns the S3 client to upload and read the release with, which is envelope encrypted if it has a release_kms_key
+func releaseS3(awsc aws.Clients, release *models.Release) aws.S3API {
+	return release.EnvelopeS3(awsc.S3Client(nil, nil, nil), awsc.KMSClient(nil, nil, nil))
+}
+
+// releaseStore returns the store of the clients to upload the release to, with the releaseS3 client
+func releaseStore(awsc aws.Clients, release *models.Release) models.ArtifactStore {
+	release.UseStore(models.StoreOf(awsc))
+	return release.Store(releaseS3(awsc, release))
+}
+
+// userDataStore returns the store of the clients to upload the userdata to, which is encrypted by the store not the client
+func userDataStore(awsc aws.Clients, release *models.Release) models.ArtifactStore {
+	release.UseStore(models.StoreOf(awsc))
+	return release.Store(awsc.S3Client(nil, nil, nil))
+}
+
+// signRelease signs the release if a signature was requested, once nothing else will change it before it is uploaded
+func signRelease(awsc aws.Clients, release *models.Release) error {
+	if release.Signature == nil {
+		return nil
 	}
 
-	// Execute every second
-	exec.WaitForExecution(awsc.SFNClient(nil, nil, nil), 1, waiter)
-	fmt.Println("")
-	return nil
+	return release.Sign(awsc.KMSClient(nil, nil, nil))
+}
+
+// nameExecution names the releases execution and records its ARN, so the deployer can write it to the lock
+// It must be called before the release is uploaded
+func nameExecution(release *models.Release, deployerARN *string) *string {
+	name := release.ExecutionName()
+	arn := fmt.Sprintf("%v:%v", strings.Replace(to.Strs(deployerARN), ":stateMachine:", ":execution:", 1), *name)
+	release.ExecutionArn = &arn
+	return name
 }
 
-func findOrCreateExec(sfnc sfniface.SFNAPI, deployer *string, release *models.Release) (*execution.Execution, error) {
+func findOrCreateExec(sfnc sfniface.SFNAPI, deployer *string, release *models.Release, name *string) (*execution.Execution, error) {
 	exec, err := execution.FindExecution(sfnc, deployer, release.ExecutionPrefix())
 	if err != nil {
 		return nil, err
@@ -61,5 +166,5 @@ func findOrCreateExec(sfnc sfniface.SFNAPI, deployer *string, release *models.Re
 		return exec, nil
 	}
 
-	return execution.StartExecution(sfnc, deployer, release.ExecutionName(), release)
+	return execution.StartExecution(sfnc, deployer, name, release)
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/odin/aws/signing"
 	"github.com/coinbase/step/utils/to"
 	"github.com/stretchr/testify/assert"
 )
@@ -17,3 +18,21 @@ func Test_Deploy(t *testing.T) {
 	err := deploy(awsc, r, to.Strp("deployerARN"))
 	assert.NoError(t, err)
 }
+
+func Test_Deploy_Signed(t *testing.T) {
+	awsc := mocks.MockAWS()
+	r := minimalRelease(t)
+	r.Release.SetDefaults(to.Strp("region"), to.Strp("accountid"), "")
+	r.SetUserData(to.Strp("#cloud_config"))
+
+	env := &environment{signingKey: to.Strp("alias/odin-signing")}
+	env.requestSignature(r)
+
+	assert.NoError(t, deploy(awsc, r, to.Strp("deployerARN")))
+	assert.NotNil(t, r.Signature.Value)
+	assert.Equal(t, signing.DefaultAlgorithm, *r.Signature.Algorithm)
+
+	// The deployer verifies the release as it was uploaded
+	assert.NoError(t, r.VerifySignature(awsc.KMS, r.SigningDigest(), []string{"alias/odin-signing"}))
+	assert.Equal(t, "arn:aws:kms:us-east-1:000000000000:key/odin-signing", *r.Signature.Signer)
+}
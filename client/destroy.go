@@ -0,0 +1,97 @@
+package client
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/execution"
+	"github.com/coinbase/step/utils/is"
+	"github.com/coinbase/step/utils/to"
+)
+
+// confirmInput is read for confirmation of destructive commands
+var confirmInput io.Reader = os.Stdin
+
+// Destroy decommissions the project config, halting any running deploy then deleting all its ASGs
+func Destroy(step_fn *string, projectName *string, configName *string, yes bool) error {
+	if is.EmptyStr(projectName) || is.EmptyStr(configName) {
+		return fmt.Errorf("Usage: odin destroy [--yes] <project_name> <config_name>")
+	}
+
+	env, err := currentEnvironment(step_fn)
+	if err != nil {
+		return err
+	}
+
+	release := recordsRelease(env, projectName, configName)
+
+	if !yes {
+		if err := confirm(fmt.Sprintf("%v/%v", *projectName, *configName)); err != nil {
+			return err
+		}
+	}
+
+	return destroy(env.awsc, env.locker(), release, env.deployerARN)
+}
+
+// confirm requires the name of what is being destroyed to be typed
+func confirm(name string) error {
+	fmt.Printf("This deletes every ASG of %v in this account. Type %v to confirm: ", name, name)
+
+	scanner := bufio.NewScanner(confirmInput)
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != name {
+		return fmt.Errorf("Not confirmed, nothing was destroyed")
+	}
+
+	return nil
+}
+
+func destroy(awsc aws.Clients, locker models.Locker, release *models.Release, deployerARN *string) error {
+	// A running deploy would recreate ASGs, so halt it and wait for it to roll back
+	exec, err := execution.FindExecution(awsc.SFNClient(nil, nil, nil), deployerARN, release.ExecutionPrefix())
+	if err != nil {
+		return err
+	}
+
+	if exec != nil && exec.Status != nil && *exec.Status == "RUNNING" {
+		if err := release.Halt(awsc.S3Client(nil, nil, nil), to.Strp("Odin client destroying config")); err != nil {
+			return err
+		}
+
+		printExecution("halted", exec)
+		exec.WaitForExecution(awsc.SFNClient(nil, nil, nil), 1, waiter)
+		printDone()
+	}
+
+	// Hold the lock for the whole teardown so no deploy starts until the tombstone is written
+	release.UUID = to.TimeUUID("destroy-")
+	if err := locker.Grab(release, time.Now()); err != nil {
+		return fmt.Errorf("Cannot lock %v/%v to destroy it, another deploy holds the lock: %v", *release.ProjectName, *release.ConfigName, err.Error())
+	}
+	defer locker.Release(release)
+
+	tombstone, err := release.Destroy(
+		awsc.ASGClient(nil, nil, nil),
+		awsc.CWClient(nil, nil, nil),
+		awsc.EC2Client(nil, nil, nil),
+		awsc.S3Client(nil, nil, nil),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		emit(&Event{Type: "destroyed", Tombstone: tombstone})
+		return nil
+	}
+
+	fmt.Printf("Destroyed %v/%v, deleted %v ASGs %v\n", *release.ProjectName, *release.ConfigName, len(tombstone.ASGs), strings.Join(tombstone.ASGs, ", "))
+	return nil
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Confirm(t *testing.T) {
+	input := confirmInput
+	defer func() { confirmInput = input }()
+
+	confirmInput = strings.NewReader("project/config\n")
+	assert.NoError(t, confirm("project/config"))
+
+	confirmInput = strings.NewReader("yes\n")
+	assert.Error(t, confirm("project/config"))
+
+	confirmInput = strings.NewReader("")
+	assert.Error(t, confirm("project/config"))
+}
+
+func Test_Destroy(t *testing.T) {
+	awsc := mocks.MockAWS()
+	r := minimalRelease(t)
+	r.Release.SetDefaults(to.Strp("region"), to.Strp("accountid"), "")
+
+	awsc.ASG.AddPreviousRuntimeResources("project", "config", "web", "old-release")
+
+	locker := &models.S3Locker{S3: awsc.S3}
+	assert.NoError(t, destroy(awsc, locker, r, to.Strp("deployerARN")))
+	assert.Equal(t, []string{"project-config-web-old-release"}, awsc.ASG.DeletedASGs)
+
+	// The lock is released once the tombstone is written
+	lock, err := locker.Get(r)
+	assert.NoError(t, err)
+	assert.Nil(t, lock)
+
+	_, err = awsc.S3.GetObject(&s3.GetObjectInput{Bucket: r.Bucket, Key: r.TombstonePath()})
+	assert.NoError(t, err)
+}
+
+func Test_Destroy_Locked(t *testing.T) {
+	awsc := mocks.MockAWS()
+	r := minimalRelease(t)
+	r.Release.SetDefaults(to.Strp("region"), to.Strp("accountid"), "")
+
+	awsc.ASG.AddPreviousRuntimeResources("project", "config", "web", "old-release")
+	awsc.S3.AddGetObject(*r.RootDir()+"/lock", `{"uuid": "holder"}`, nil)
+
+	// Nothing is torn down while a deploy holds the lock
+	err := destroy(awsc, &models.S3Locker{S3: awsc.S3}, r, to.Strp("deployerARN"))
+	assert.Error(t, err)
+	assert.Regexp(t, "another deploy holds the lock", err.Error())
+	assert.Equal(t, 0, len(awsc.ASG.DeletedASGs))
+}
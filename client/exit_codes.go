@@ -0,0 +1,130 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/sfn/sfniface"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/execution"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Exit codes of odin, stable so CI can branch on why a command failed
+const (
+	ExitOK             = 0
+	ExitError          = 1 // Any other error, e.g. the release file could not be read
+	ExitValidation     = 2 // The release is invalid
+	ExitLockHeld       = 3 // Another release holds the lock
+	ExitHalted         = 4 // The deploy was halted
+	ExitHealthTimeout  = 5 // The instances were not healthy before the timeout
+	ExitQuota          = 6 // An AWS quota was exceeded or requests were throttled
+	ExitRolledBack     = 7 // The deploy failed for another reason and was rolled back
+	ExitRollbackFailed = 8 // The rollback failed too, resources may be left behind
+)
+
+// ValidationError is a release that is invalid before it is sent to the deployer
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// FailedError is a release that failed in the deployer
+type FailedError struct {
+	Status     string
+	Code       *string // Stable code of the releases error, see models.ErrorCode
+	Message    string
+	RolledBack bool // The execution ended in FailureClean
+}
+
+func (e *FailedError) Error() string {
+	if e.Code != nil {
+		return fmt.Sprintf("Deploy %v %v: %v", e.Status, *e.Code, e.Message)
+	}
+	return fmt.Sprintf("Deploy %v: %v", e.Status, e.Message)
+}
+
+// ExitCode returns the exit code for the error of a command
+func ExitCode(err error) int {
+	switch e := err.(type) {
+	case nil:
+		return ExitOK
+	case *ValidationError:
+		return ExitValidation
+	case *FailedError:
+		code := to.Strs(e.Code)
+		if !e.RolledBack || code == models.ErrorCodeCleanUp {
+			return ExitRollbackFailed
+		}
+
+		switch code {
+		case models.ErrorCodeValidation, models.ErrorCodeValidationSHA:
+			return ExitValidation
+		case models.ErrorCodeLock:
+			return ExitLockHeld
+		case models.ErrorCodeHalt:
+			return ExitHalted
+		case models.ErrorCodeHealthTimeout:
+			return ExitHealthTimeout
+		case models.ErrorCodeQuota, models.ErrorCodeThrottle:
+			return ExitQuota
+		default:
+			return ExitRolledBack
+		}
+	default:
+		return ExitError
+	}
+}
+
+// waitForExecution follows the execution until it completes, returning a *FailedError if it failed
+func waitForExecution(sfnc sfniface.SFNAPI, exec *execution.Execution) (*string, error) {
+	status := exec.Status
+	var last *execution.StateDetails
+
+	exec.WaitForExecution(sfnc, 1, func(ed *execution.Execution, sd *execution.StateDetails, err error) error {
+		if ed != nil {
+			status = ed.Status
+		}
+		if sd != nil {
+			last = sd
+		}
+		return waiter(ed, sd, err)
+	})
+
+	printDone()
+
+	switch to.Strs(status) {
+	case "FAILED", "TIMED_OUT", "ABORTED":
+		return status, failedError(*status, last)
+	}
+
+	return status, nil
+}
+
+// failedError describes the failed execution from its last output
+func failedError(status string, sd *execution.StateDetails) *FailedError {
+	failed := &FailedError{Status: status, Message: "the release failed"}
+	if sd == nil {
+		return failed
+	}
+
+	failed.RolledBack = to.Strs(sd.LastStateName) == "FailureClean"
+
+	release, err := outputRelease(sd)
+	if err != nil || release.Error == nil {
+		return failed
+	}
+
+	failed.Code = models.ErrorCode(release.Error)
+	failed.Message = fmt.Sprintf("%v %v", to.Strs(release.Error.Error), to.Strs(release.Error.Cause))
+
+	// Why the services were unhealthy, so it does not have to be found in the console
+	if summary := release.DiagnosticsSummary(); len(summary) > 0 {
+		failed.Message = fmt.Sprintf("%v\n%v", failed.Message, strings.Join(summary, "\n"))
+	}
+
+	return failed
+}
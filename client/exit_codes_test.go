@@ -0,0 +1,68 @@
+package client
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/bifrost"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExitCode(t *testing.T) {
+	failed := func(code string, rolledBack bool) error {
+		return &FailedError{Status: "FAILED", Code: to.Strp(code), RolledBack: rolledBack}
+	}
+
+	assert.Equal(t, ExitOK, ExitCode(nil))
+	assert.Equal(t, ExitError, ExitCode(fmt.Errorf("open release.json: no such file")))
+	assert.Equal(t, ExitValidation, ExitCode(&ValidationError{"ConfigName must be defined"}))
+
+	assert.Equal(t, ExitValidation, ExitCode(failed(models.ErrorCodeValidation, true)))
+	assert.Equal(t, ExitLockHeld, ExitCode(failed(models.ErrorCodeLock, true)))
+	assert.Equal(t, ExitHalted, ExitCode(failed(models.ErrorCodeHalt, true)))
+	assert.Equal(t, ExitHealthTimeout, ExitCode(failed(models.ErrorCodeHealthTimeout, true)))
+	assert.Equal(t, ExitQuota, ExitCode(failed(models.ErrorCodeQuota, true)))
+	assert.Equal(t, ExitQuota, ExitCode(failed(models.ErrorCodeThrottle, true)))
+	assert.Equal(t, ExitRolledBack, ExitCode(failed(models.ErrorCodeHealth, true)))
+	assert.Equal(t, ExitRollbackFailed, ExitCode(failed(models.ErrorCodeHealth, false)))
+	assert.Equal(t, ExitRollbackFailed, ExitCode(failed(models.ErrorCodeCleanUp, true)))
+}
+
+func Test_FailedError(t *testing.T) {
+	r := minimalRelease(t)
+	r.Error = &bifrost.ReleaseError{Error: to.Strp("HaltError"), Cause: to.Strp("Timeout in healthy phase after 600 seconds")}
+	sd := createStateDetails(r, "ReleaseLockFailure")
+	sd.LastStateName = to.Strp("FailureClean")
+
+	failed := failedError("FAILED", sd)
+	assert.True(t, failed.RolledBack)
+	assert.Equal(t, models.ErrorCodeHealthTimeout, *failed.Code)
+	assert.Equal(t, ExitHealthTimeout, ExitCode(failed))
+	assert.Contains(t, failed.Error(), "E_HEALTH_TIMEOUT")
+
+	sd.LastStateName = to.Strp("FailureDirty")
+	assert.Equal(t, ExitRollbackFailed, ExitCode(failedError("FAILED", sd)))
+
+	assert.Equal(t, ExitRollbackFailed, ExitCode(failedError("ABORTED", nil)))
+}
+
+func Test_FailedError_Diagnostics(t *testing.T) {
+	r := minimalRelease(t)
+	r.Error = &bifrost.ReleaseError{Error: to.Strp("HealthError"), Cause: to.Strp("unhealthy")}
+	r.Diagnostics = map[string]*models.Diagnostics{
+		"web": {
+			Instances: []*models.InstanceDiagnostics{{
+				InstanceID:     to.Strp("i-1"),
+				State:          to.Strp("running"),
+				SystemStatus:   to.Strp("ok"),
+				InstanceStatus: to.Strp("impaired"),
+				LoadBalancers:  []string{"web-elb OutOfService Instance"},
+			}},
+		},
+	}
+
+	failed := failedError("FAILED", createStateDetails(r, "ReleaseLockFailure"))
+	assert.Contains(t, failed.Error(), "web: i-1 running system:ok instance:impaired (web-elb OutOfService Instance)")
+}
@@ -0,0 +1,231 @@
+package client
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/is"
+	"github.com/coinbase/step/utils/to"
+)
+
+// manifestName is the archive entry describing what was exported
+const manifestName = "manifest.json"
+
+// exportManifest records where an export came from so it can be imported into another account
+type exportManifest struct {
+	ProjectName  *string `json:"project_name"`
+	ConfigName   *string `json:"config_name"`
+	AwsAccountID *string `json:"aws_account_id"`
+	Bucket       *string `json:"bucket"`
+}
+
+// Export writes every release record of the project config in the current context to a tar.gz archive
+func Export(projectName *string, configName *string, archiveFile *string) error {
+	if is.EmptyStr(projectName) || is.EmptyStr(configName) || is.EmptyStr(archiveFile) {
+		return fmt.Errorf("Usage: odin export <project_name> <config_name> <archive_file>")
+	}
+
+	env, err := currentEnvironment(nil)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(*archiveFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	count, err := export(env.awsc.S3Client(nil, nil, nil), recordsRelease(env, projectName, configName), f)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %v records to %v\n", count, *archiveFile)
+	return nil
+}
+
+// Import restores an archive from Export into the bucket and account of the current context
+func Import(archiveFile *string) error {
+	if is.EmptyStr(archiveFile) {
+		return fmt.Errorf("Usage: odin import <archive_file>")
+	}
+
+	env, err := currentEnvironment(nil)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(*archiveFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	count, err := importRecords(env.awsc.S3Client(nil, nil, nil), env, f)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported %v records from %v\n", count, *archiveFile)
+	return nil
+}
+
+// recordsRelease is a release with only the fields needed to find the project configs records
+func recordsRelease(env *environment, projectName *string, configName *string) *models.Release {
+	release := &models.Release{}
+	release.ProjectName = projectName
+	release.ConfigName = configName
+	release.Bucket = env.bucket
+	release.Release.SetDefaults(env.region, env.accountID, "coinbase-odin-")
+	release.UseStore(models.StoreOf(env.awsc))
+	return release
+}
+
+// export archives every object under the releases root directory, except the lock
+// A lock belongs to a running deploy and would block deploys wherever it is restored
+func export(s3c aws.S3API, release *models.Release, w io.Writer) (int, error) {
+	root := *release.RootDir() + "/"
+
+	allKeys, err := listRecords(s3c, release)
+	if err != nil {
+		return 0, err
+	}
+
+	keys := []string{}
+	for _, key := range allKeys {
+		if path.Base(key) != "lock" {
+			keys = append(keys, key)
+		}
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifest, err := json.Marshal(&exportManifest{
+		ProjectName:  release.ProjectName,
+		ConfigName:   release.ConfigName,
+		AwsAccountID: release.AwsAccountID,
+		Bucket:       release.Bucket,
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if err := writeTarEntry(tw, manifestName, manifest); err != nil {
+		return 0, err
+	}
+
+	for _, key := range keys {
+		body, err := release.Store(s3c).Get(to.Strp(key))
+		if err != nil {
+			return 0, err
+		}
+
+		// Entries are relative to the root so they can be restored under another account
+		if err := writeTarEntry(tw, path.Join("records", strings.TrimPrefix(key, root)), body); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return 0, err
+	}
+
+	return len(keys), gz.Close()
+}
+
+// listRecords returns the path of every artifact under the releases root directory
+func listRecords(s3c aws.S3API, release *models.Release) ([]string, error) {
+	artifacts, err := release.Store(s3c).List(to.Strp(*release.RootDir() + "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	for _, artifact := range artifacts {
+		keys = append(keys, *artifact.Path)
+	}
+
+	return keys, nil
+}
+
+func writeTarEntry(tw *tar.Writer, name string, body []byte) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(body))}); err != nil {
+		return err
+	}
+
+	_, err := tw.Write(body)
+	return err
+}
+
+// importRecords writes each record of the archive under the project configs root in the environments bucket
+func importRecords(s3c aws.S3API, env *environment, r io.Reader) (int, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, err
+	}
+
+	tr := tar.NewReader(gz)
+
+	var release *models.Release
+	count := 0
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return count, err
+		}
+
+		body, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return count, err
+		}
+
+		if hdr.Name == manifestName {
+			var manifest exportManifest
+			if err := json.Unmarshal(body, &manifest); err != nil {
+				return count, err
+			}
+
+			if is.EmptyStr(manifest.ProjectName) || is.EmptyStr(manifest.ConfigName) {
+				return count, fmt.Errorf("Archive manifest must have project_name and config_name")
+			}
+
+			release = recordsRelease(env, manifest.ProjectName, manifest.ConfigName)
+			continue
+		}
+
+		if release == nil {
+			return count, fmt.Errorf("Archive must start with %v", manifestName)
+		}
+
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "records/"))
+		if strings.HasPrefix(name, "..") || path.IsAbs(name) {
+			return count, fmt.Errorf("Archive entry %q is outside the records directory", hdr.Name)
+		}
+
+		// Userdata can contain secrets so every record is encrypted
+		key := fmt.Sprintf("%v/%v", *release.RootDir(), name)
+		if err := release.Store(s3c).PutSecure(&key, body, to.Strp(models.DefaultUserDataKMSKey)); err != nil {
+			return count, err
+		}
+
+		count++
+	}
+
+	return count, nil
+}
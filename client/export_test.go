@@ -0,0 +1,61 @@
+package client
+
+import (
+	"bytes"
+	"testing"
+
+	as3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+// listingS3 lists the keys added to the mock
+type listingS3 struct {
+	*mocks.MockS3Client
+	keys []string
+}
+
+func (m *listingS3) add(key string, body string) {
+	m.keys = append(m.keys, key)
+	m.AddGetObject(key, body, nil)
+}
+
+func (m *listingS3) ListObjectsV2Pages(in *as3.ListObjectsV2Input, fn func(*as3.ListObjectsV2Output, bool) bool) error {
+	out := &as3.ListObjectsV2Output{}
+	for _, key := range m.keys {
+		out.Contents = append(out.Contents, &as3.Object{Key: to.Strp(key)})
+	}
+	fn(out, true)
+	return nil
+}
+
+func Test_Export_Import(t *testing.T) {
+	source := &environment{region: to.Strp("us-east-1"), accountID: to.Strp("000000000000")}
+	s3c := &listingS3{MockS3Client: &mocks.MockS3Client{}}
+	s3c.add("000000000000/project/config/release-1/release", `{"release_id":"release-1"}`)
+	s3c.add("000000000000/project/config/release-1/userdata", "#cloud_config")
+	s3c.add("000000000000/project/config/lock", "lock")
+
+	var archive bytes.Buffer
+	count, err := export(s3c, recordsRelease(source, to.Strp("project"), to.Strp("config")), &archive)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	dr := &environment{region: to.Strp("us-west-2"), accountID: to.Strp("111111111111"), bucket: to.Strp("dr-bucket")}
+	drs3c := &mocks.MockS3Client{}
+	count, err = importRecords(drs3c, dr, &archive)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	body, err := s3.Get(drs3c, to.Strp("dr-bucket"), to.Strp("111111111111/project/config/release-1/userdata"))
+	assert.NoError(t, err)
+	assert.Equal(t, "#cloud_config", string(*body))
+}
+
+func Test_Import_RequiresManifest(t *testing.T) {
+	env := &environment{region: to.Strp("us-east-1"), accountID: to.Strp("000000000000")}
+	_, err := importRecords(&mocks.MockS3Client{}, env, bytes.NewReader([]byte("not an archive")))
+	assert.Error(t, err)
+}
@@ -0,0 +1,224 @@
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/identity"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/is"
+	"github.com/coinbase/step/utils/to"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// FailoverConfig maps a project config onto a disaster recovery context
+type FailoverConfig struct {
+	Context *string `yaml:"context"` // Context of the secondary region
+	// Names replaces AMI, subnet, security group, ELB, target group, profile,
+	// container and lifecycle names that differ in the secondary region
+	Names map[string]string `yaml:"names,omitempty"`
+}
+
+// Failover redeploys the latest release of the project config in the current context to the failover context
+func Failover(projectName *string, configName *string, failoverFile *string) error {
+	if is.EmptyStr(projectName) || is.EmptyStr(configName) || is.EmptyStr(failoverFile) {
+		return fmt.Errorf("Usage: odin failover <project_name> <config_name> <failover_file>")
+	}
+
+	config, err := LoadConfig(ConfigPath())
+	if err != nil {
+		return err
+	}
+
+	fc, err := loadFailoverConfig(*failoverFile)
+	if err != nil {
+		return err
+	}
+
+	drContext := config.Contexts[*fc.Context]
+	if drContext == nil {
+		return fmt.Errorf("Failover context %v not found", *fc.Context)
+	}
+
+	source := config.Current().environment(nil)
+	release, err := latestRelease(models.DecryptingS3(source.awsc.S3Client(nil, nil, nil), source.awsc.KMSClient(nil, nil, nil)), recordsRelease(source, projectName, configName))
+	if err != nil {
+		return err
+	}
+
+	dr := drContext.environment(nil)
+	failoverRelease(release, fc.Names, dr)
+
+	if err := dr.preflightBucket(release, release.ReleasePath(), release.UserDataPath()); err != nil {
+		return err
+	}
+
+	if release.Identity, err = identity.Presign(dr.awsc.STSClient(nil, nil, nil), release.IdentityBinding()); err != nil {
+		return err
+	}
+
+	auditRelease(release, nil)
+	dr.requestSignature(release)
+
+	fmt.Printf("Failing over %v to %v in %v\n", *release.ReleaseID, *release.AwsAccountID, *release.AwsRegion)
+	return deploy(dr.awsc, release, dr.deployerARN)
+}
+
+func loadFailoverConfig(failoverFile string) (*FailoverConfig, error) {
+	raw, err := ioutil.ReadFile(failoverFile)
+	if err != nil {
+		return nil, err
+	}
+
+	fc := &FailoverConfig{}
+	if err := yaml.Unmarshal(raw, fc); err != nil {
+		return nil, err
+	}
+
+	if is.EmptyStr(fc.Context) {
+		return nil, fmt.Errorf("Failover file %v must define context", failoverFile)
+	}
+
+	return fc, nil
+}
+
+// latestRelease returns the most recently created successful release record with its userdata
+// Failed and halted releases are skipped so a failover never deploys a release that did not deploy
+func latestRelease(s3c aws.S3API, root *models.Release) (*models.Release, error) {
+	releases, err := loadReleases(s3c, root)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *models.Release
+	for i := len(releases) - 1; i >= 0; i-- {
+		if releases[i].Success != nil && *releases[i].Success {
+			latest = releases[i]
+			break
+		}
+	}
+
+	if latest == nil {
+		return nil, fmt.Errorf("No successful releases found for %v", *root.RootDir())
+	}
+
+	if err := latest.DownloadUserData(s3c); err != nil {
+		return nil, err
+	}
+
+	return latest, nil
+}
+
+// loadReleases returns the release records of the project config oldest first
+// A release that finished is read from its record, which has whether it succeeded
+func loadReleases(s3c aws.S3API, root *models.Release) ([]*models.Release, error) {
+	keys, err := listRecords(s3c, root)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := map[string]bool{}
+	for _, key := range keys {
+		if path.Base(key) == "record" {
+			recorded[path.Dir(key)] = true
+		}
+	}
+
+	releases := []*models.Release{}
+	for _, key := range keys {
+		if path.Base(key) != "release" {
+			continue
+		}
+
+		if recorded[path.Dir(key)] {
+			key = path.Join(path.Dir(key), "record")
+		}
+
+		var release models.Release
+		if err := models.GetArtifact(root.Store(s3c), to.Strp(key), &release); err != nil {
+			return nil, err
+		}
+
+		if release.CreatedAt == nil {
+			continue
+		}
+
+		// Records are stored under the root, so its userdata is found even if they omit where
+		if release.Bucket == nil {
+			release.Bucket = root.Bucket
+		}
+		if release.AwsAccountID == nil {
+			release.AwsAccountID = root.AwsAccountID
+		}
+		if release.ProjectName == nil {
+			release.ProjectName = root.ProjectName
+		}
+		if release.ConfigName == nil {
+			release.ConfigName = root.ConfigName
+		}
+
+		release.UseStoreOf(root)
+		releases = append(releases, &release)
+	}
+
+	sort.SliceStable(releases, func(i, j int) bool {
+		return releases[i].CreatedAt.Before(*releases[j].CreatedAt)
+	})
+
+	return releases, nil
+}
+
+// failoverRelease turns a release record into a new release for the failover environment
+func failoverRelease(release *models.Release, names map[string]string, env *environment) {
+	rename := func(s *string) *string {
+		if s == nil {
+			return nil
+		}
+
+		if n, ok := names[*s]; ok {
+			return to.Strp(n)
+		}
+		return s
+	}
+
+	renameAll := func(ss []*string) []*string {
+		out := []*string{}
+		for _, s := range ss {
+			out = append(out, rename(s))
+		}
+		return out
+	}
+
+	release.Image = rename(release.Image)
+	release.Subnets = renameAll(release.Subnets)
+
+	for _, lc := range release.LifeCycleHooks {
+		if lc == nil {
+			continue
+		}
+
+		lc.Role, lc.SNS = rename(lc.Role), rename(lc.SNS)
+		lc.RoleARN, lc.NotificationTargetARN = nil, nil // Recalculated for the new account and region
+	}
+
+	for _, service := range release.Services {
+		if service == nil {
+			continue
+		}
+
+		service.Profile = rename(service.Profile)
+		service.SecurityGroups = renameAll(service.SecurityGroups)
+		service.ELBs = renameAll(service.ELBs)
+		service.TargetGroups = renameAll(service.TargetGroups)
+		service.Containers = renameAll(service.Containers)
+	}
+
+	// Move the release to the failover account, region and bucket
+	release.AwsAccountID, release.AwsRegion, release.Bucket = nil, nil, env.bucket
+	release.UserDataSHA256 = to.Strp(to.SHA256Str(release.UserData()))
+	prepareRelease(release, env.region, env.accountID)
+	release.UseStore(models.StoreOf(env.awsc))
+}
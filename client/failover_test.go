@@ -0,0 +1,60 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LatestRelease(t *testing.T) {
+	env := &environment{region: to.Strp("us-east-1"), accountID: to.Strp("000000000000")}
+	s3c := &listingS3{MockS3Client: &mocks.MockS3Client{}}
+	s3c.add("000000000000/project/config/release-1/release", `{"release_id":"release-1","created_at":"2018-01-01T00:00:00Z"}`)
+	s3c.add("000000000000/project/config/release-1/record", `{"release_id":"release-1","created_at":"2018-01-01T00:00:00Z","success":true}`)
+	s3c.add("000000000000/project/config/release-2/release", `{"release_id":"release-2","created_at":"2018-02-01T00:00:00Z"}`)
+	s3c.add("000000000000/project/config/release-2/record", `{"release_id":"release-2","created_at":"2018-02-01T00:00:00Z","success":true}`)
+	s3c.add("000000000000/project/config/release-2/userdata", "#cloud_config")
+	s3c.add("000000000000/project/config/release-3/release", `{"release_id":"release-3","created_at":"2018-03-01T00:00:00Z","success":false}`)
+	s3c.add("000000000000/project/config/release-4/release", `{"release_id":"release-4","created_at":"2018-04-01T00:00:00Z"}`)
+
+	root := recordsRelease(env, to.Strp("project"), to.Strp("config"))
+	release, err := latestRelease(s3c, root)
+	assert.NoError(t, err)
+	assert.Equal(t, "release-2", *release.ReleaseID)
+	assert.Equal(t, "#cloud_config", *release.UserData())
+}
+
+func Test_LatestRelease_None(t *testing.T) {
+	env := &environment{region: to.Strp("us-east-1"), accountID: to.Strp("000000000000")}
+	s3c := &listingS3{MockS3Client: &mocks.MockS3Client{}}
+
+	_, err := latestRelease(s3c, recordsRelease(env, to.Strp("project"), to.Strp("config")))
+	assert.Error(t, err)
+
+	// Only failed releases, which have no record
+	s3c.add("000000000000/project/config/release-1/release", `{"release_id":"release-1","created_at":"2018-01-01T00:00:00Z","success":false}`)
+	_, err = latestRelease(s3c, recordsRelease(env, to.Strp("project"), to.Strp("config")))
+	assert.Error(t, err)
+}
+
+func Test_FailoverRelease(t *testing.T) {
+	release := minimalRelease(t)
+	release.Release.SetDefaults(to.Strp("us-east-1"), to.Strp("000000000000"), "coinbase-odin-")
+	release.SetUserData(to.Strp("#cloud_config"))
+
+	dr := &environment{region: to.Strp("us-west-2"), accountID: to.Strp("111111111111")}
+	failoverRelease(release, map[string]string{
+		"ami-123456": "ami-west",
+		"subnet-1":   "dr-subnet-1",
+	}, dr)
+
+	assert.Equal(t, "ami-west", *release.Image)
+	assert.Equal(t, []string{"dr-subnet-1"}, to.StrSlice(release.Subnets))
+	assert.Equal(t, []string{"web-sg"}, to.StrSlice(release.Services["web"].SecurityGroups))
+	assert.Equal(t, "us-west-2", *release.AwsRegion)
+	assert.Equal(t, "111111111111", *release.AwsAccountID)
+	assert.NotEqual(t, "rr", *release.ReleaseID)
+	assert.Equal(t, to.SHA256Str(to.Strp("#cloud_config")), *release.UserDataSHA256)
+}
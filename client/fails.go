@@ -26,13 +26,12 @@ type FailedRelease struct {
 
 // List the recent failures and their causes
 func Failures(step_fn *string) error {
-	region, accountID := to.RegionAccount()
-
-	deployerARN := to.StepArn(region, accountID, step_fn)
-
-	awsc := &aws.ClientsStr{}
+	env, err := currentEnvironment(step_fn)
+	if err != nil {
+		return err
+	}
 
-	return failures(awsc.SFNClient(nil, nil, nil), deployerARN)
+	return failures(env.awsc.SFNClient(nil, nil, nil), env.deployerARN)
 }
 
 func failures(sfnc aws.SFNAPI, arn *string) error {
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/gc"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+)
+
+// GC deletes orphaned resources odin created that are older than minAge, with dryRun only listing them
+func GC(step_fn *string, minAge time.Duration, dryRun bool) error {
+	if minAge <= 0 {
+		return fmt.Errorf("Usage: odin gc [--dry-run] [--min-age <duration>], min-age must be positive")
+	}
+
+	env, err := currentEnvironment(step_fn)
+	if err != nil {
+		return err
+	}
+
+	s3c := models.DecryptingS3(env.awsc.S3Client(nil, nil, nil), env.awsc.KMSClient(nil, nil, nil))
+	serviceIDs, err := releasedServiceIDs(models.StoreOf(env.awsc)(s3c, env.bucket), env.accountID)
+	if err != nil {
+		return err
+	}
+
+	return collect(env.awsc, serviceIDs, time.Now().Add(-minAge), dryRun)
+}
+
+// releasedServiceIDs returns the service IDs of every release of the account in the bucket,
+// launch configurations are only attributed to odin if they are named after one
+func releasedServiceIDs(store models.ArtifactStore, accountID *string) (map[string]bool, error) {
+	artifacts, err := store.List(to.Strp(to.Strs(accountID) + "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	serviceIDs := map[string]bool{}
+	for _, artifact := range artifacts {
+		if path.Base(to.Strs(artifact.Path)) != "release" {
+			continue
+		}
+
+		var release models.Release
+		if err := models.GetArtifact(store, artifact.Path, &release); err != nil {
+			return nil, err
+		}
+
+		for _, id := range release.ServiceIDs() {
+			serviceIDs[id] = true
+		}
+	}
+
+	return serviceIDs, nil
+}
+
+func collect(awsc aws.Clients, serviceIDs map[string]bool, cutoff time.Time, dryRun bool) error {
+	orphans, err := gc.Find(awsc.ASGClient(nil, nil, nil), awsc.EC2Client(nil, nil, nil), cutoff, serviceIDs)
+	if err != nil {
+		return err
+	}
+
+	resources := orphanResources(orphans)
+
+	if !dryRun {
+		if err := orphans.Delete(awsc.ASGClient(nil, nil, nil), awsc.CWClient(nil, nil, nil), awsc.EC2Client(nil, nil, nil)); err != nil {
+			return err
+		}
+	}
+
+	if jsonOutput {
+		eventType := "collected"
+		if dryRun {
+			eventType = "orphans"
+		}
+		emit(&Event{Type: eventType, Resources: resources})
+		return nil
+	}
+
+	verb := "Deleted"
+	if dryRun {
+		verb = "Found"
+	}
+
+	fmt.Printf("%v %v orphaned resources created before %v\n", verb, len(resources), cutoff.UTC().Format(time.RFC3339))
+	for _, resource := range resources {
+		fmt.Printf("  %v\n", resource)
+	}
+
+	return nil
+}
+
+// orphanResources describes each orphan as "<type> <name>"
+func orphanResources(orphans *gc.Orphans) []string {
+	resources := []string{}
+
+	for _, a := range orphans.ASGs {
+		resources = append(resources, fmt.Sprintf("asg %v", to.Strs(a.AutoScalingGroupName)))
+	}
+
+	for _, name := range orphans.LaunchConfigurations {
+		resources = append(resources, fmt.Sprintf("launch_configuration %v", to.Strs(name)))
+	}
+
+	for _, name := range orphans.LaunchTemplates {
+		resources = append(resources, fmt.Sprintf("launch_template %v", to.Strs(name)))
+	}
+
+	for _, ltv := range orphans.LaunchTemplateVersions {
+		for _, version := range ltv.Versions {
+			resources = append(resources, fmt.Sprintf("launch_template_version %v:%v", to.Strs(ltv.LaunchTemplateName), to.Strs(version)))
+		}
+	}
+
+	return resources
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/odin/deployer/models"
+	stepmocks "github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Collect(t *testing.T) {
+	awsc := mocks.MockAWS()
+	awsc.ASG.AddLaunchConfiguration("project-config-2019-01-01T00-00-00Z-web", time.Now().Add(-time.Hour))
+	awsc.ASG.AddLaunchConfiguration("other-config-2019-01-01T00-00-00Z-web", time.Now().Add(-time.Hour))
+	serviceIDs := map[string]bool{"project-config-2019-01-01T00-00-00Z-web": true}
+
+	// Dry run only lists
+	assert.NoError(t, collect(awsc, serviceIDs, time.Now(), true))
+	assert.Equal(t, 0, len(awsc.ASG.DeletedLaunchConfigurations))
+
+	// Only launch configurations of released services are deleted
+	assert.NoError(t, collect(awsc, serviceIDs, time.Now(), false))
+	assert.Equal(t, []string{"project-config-2019-01-01T00-00-00Z-web"}, awsc.ASG.DeletedLaunchConfigurations)
+}
+
+func Test_ReleasedServiceIDs(t *testing.T) {
+	s3c := &listingS3{MockS3Client: &stepmocks.MockS3Client{}}
+	s3c.add("000000000000/project/config/release-1/release", `{
+		"project_name": "project", "config_name": "config", "created_at": "2019-01-01T00:00:00Z",
+		"services": {"web": {}, "worker": {}}
+	}`)
+	s3c.add("000000000000/project/config/release-1/userdata", "#cloud_config")
+	s3c.add("000000000000/project/config/lock", "{}")
+
+	serviceIDs, err := releasedServiceIDs(models.NewS3Store(s3c, to.Strp("bucket")), to.Strp("000000000000"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{
+		"project-config-2019-01-01T00-00-00Z-web":    true,
+		"project-config-2019-01-01T00-00-00Z-worker": true,
+	}, serviceIDs)
+}
@@ -6,37 +6,58 @@ import (
 	"github.com/coinbase/odin/aws"
 	"github.com/coinbase/odin/deployer/models"
 	"github.com/coinbase/step/execution"
+	"github.com/coinbase/step/utils/is"
 	"github.com/coinbase/step/utils/to"
 )
 
-// Halt attempts to halt release
-func Halt(step_fn *string, releaseFile *string) error {
-	region, accountID := to.RegionAccount()
-	release, err := releaseFromFile(releaseFile, region, accountID)
+// Halt attempts to halt release, with a serviceName only that service is halted and the others continue
+func Halt(step_fn *string, releaseFile *string, serviceName *string) error {
+	env, err := currentEnvironment(step_fn)
 	if err != nil {
 		return err
 	}
 
-	deployerARN := to.StepArn(region, accountID, step_fn)
+	release, err := releaseFromFile(releaseFile, env)
+	if err != nil {
+		return err
+	}
 
-	return halt(&aws.ClientsStr{}, release, deployerARN)
+	return halt(env.awsc, release, env.deployerARN, serviceName)
 }
 
-func halt(awsc aws.Clients, release *models.Release, deployerARN *string) error {
-	exec, err := execution.FindExecution(awsc.SFNClient(nil, nil, nil), deployerARN, release.ExecutionPrefix())
+func halt(awsc aws.Clients, release *models.Release, deployerARN *string, serviceName *string) error {
+	exec, err := haltExecution(awsc, release, deployerARN, serviceName)
 	if err != nil {
 		return err
 	}
 
-	if exec == nil {
-		return fmt.Errorf("Cannot find current execution of release with prefix %q", release.ExecutionPrefix())
+	if !is.EmptyStr(serviceName) {
+		// The release continues with its other services
+		printExecution("service_halted", exec)
+		return nil
 	}
 
-	if err := release.Halt(awsc.S3Client(nil, nil, nil), to.Strp("Odin client Halted deploy")); err != nil {
-		return err
-	}
+	printExecution("halted", exec)
 
 	exec.WaitForExecution(awsc.SFNClient(nil, nil, nil), 1, waiter)
-	fmt.Println("")
+	printDone()
 	return nil
 }
+
+// haltExecution halts the current execution of the release, or only its service, without waiting for it to stop
+func haltExecution(awsc aws.Clients, release *models.Release, deployerARN *string, serviceName *string) (*execution.Execution, error) {
+	exec, err := execution.FindExecution(awsc.SFNClient(nil, nil, nil), deployerARN, release.ExecutionPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	if exec == nil {
+		return nil, &noExecutionError{fmt.Sprintf("Cannot find current execution of release with prefix %q", release.ExecutionPrefix())}
+	}
+
+	if !is.EmptyStr(serviceName) {
+		return exec, release.HaltService(awsc.S3Client(nil, nil, nil), *serviceName, to.Strp("Odin client Halted service"))
+	}
+
+	return exec, release.Halt(awsc.S3Client(nil, nil, nil), to.Strp("Odin client Halted deploy"))
+}
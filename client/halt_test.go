@@ -26,6 +26,27 @@ func Test_Halt(t *testing.T) {
 		},
 	}
 
-	err := halt(awsc, r, to.Strp("deployerARN"))
+	err := halt(awsc, r, to.Strp("deployerARN"), nil)
 	assert.NoError(t, err)
 }
+
+func Test_Halt_Service(t *testing.T) {
+	awsc := mocks.MockAWS()
+	r := minimalRelease(t)
+
+	r.Release.SetDefaults(to.Strp("region"), to.Strp("accountid"), "")
+
+	awsc.SFN.ListExecutionsResp = &sfn.ListExecutionsOutput{
+		Executions: []*sfn.ExecutionListItem{
+			&sfn.ExecutionListItem{
+				Name:         r.ExecutionName(),
+				ExecutionArn: to.Strp("arn"),
+				StartDate:    to.Timep(time.Now()),
+			},
+		},
+	}
+
+	assert.Error(t, halt(awsc, r, to.Strp("deployerARN"), to.Strp("missing")))
+
+	assert.NoError(t, halt(awsc, r, to.Strp("deployerARN"), to.Strp("web")))
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+)
+
+// hookOutput receives the output of hooks, it is stderr when printing JSON so the events can still be parsed
+func hookOutput() io.Writer {
+	if jsonOutput {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// runHooks runs the releases commands of the hook point in order, stopping at the first that fails
+func runHooks(release *models.Release, point string, status *string) error {
+	for _, command := range release.HookCommands(point) {
+		cmd := exec.Command("sh", "-c", *command)
+		cmd.Env = append(os.Environ(), release.HookEnv(point, status)...)
+		cmd.Stdout = hookOutput()
+		cmd.Stderr = os.Stderr
+
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("Hook %v %q failed: %v", point, to.Strs(command), err.Error())
+		}
+	}
+
+	return nil
+}
+
+// runPostHooks runs the post_success or post_failure hooks depending on the executions final status
+func runPostHooks(release *models.Release, status *string, err error) error {
+	if err == nil && to.Strs(status) == "SUCCEEDED" {
+		return runHooks(release, models.HookPostSuccess, status)
+	}
+
+	return runHooks(release, models.HookPostFailure, status)
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Deploy_Hooks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "odin-hooks")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	out := filepath.Join(dir, "hooks")
+
+	awsc := mocks.MockAWS()
+	r := minimalRelease(t)
+	r.Release.SetDefaults(to.Strp("region"), to.Strp("accountid"), "")
+	r.SetUserData(to.Strp("#cloud_config"))
+	r.Hooks = &models.HooksConfig{
+		PreDeploy:   []*string{to.Strp("echo $ODIN_HOOK $ODIN_RELEASE_ID >> " + out)},
+		PostSuccess: []*string{to.Strp("echo $ODIN_HOOK $ODIN_STATUS >> " + out)},
+		PostFailure: []*string{to.Strp("echo $ODIN_HOOK $ODIN_STATUS >> " + out)},
+	}
+
+	assert.NoError(t, deploy(awsc, r, to.Strp("deployerARN")))
+
+	raw, err := ioutil.ReadFile(out)
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	assert.Equal(t, 2, len(lines))
+	assert.Equal(t, "pre_deploy rr", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "post_"))
+}
+
+func Test_Deploy_PreDeployHookFails(t *testing.T) {
+	awsc := mocks.MockAWS()
+	r := minimalRelease(t)
+	r.Release.SetDefaults(to.Strp("region"), to.Strp("accountid"), "")
+	r.SetUserData(to.Strp("#cloud_config"))
+	r.Hooks = &models.HooksConfig{PreDeploy: []*string{to.Strp("exit 1")}}
+
+	err := deploy(awsc, r, to.Strp("deployerARN"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Hook pre_deploy")
+
+	// The release is never uploaded
+	_, err = s3.Get(awsc.S3, r.Bucket, r.ReleasePath())
+	assert.Error(t, err)
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+)
+
+// referencePattern matches ${ssm:/path} and ${env:VAR} references, and $${ which escapes a literal ${
+var referencePattern = regexp.MustCompile(`\$\$\{|\$\{(ssm|env):([^}]*)\}`)
+
+// interpolator resolves the references in the strings of a release file, recording what they resolved to
+type interpolator struct {
+	ssmc      aws.SSMAPI
+	ssmPrefix string                      // Only parameters under this path are resolved
+	lookupEnv func(string) (string, bool) // nil if environment variables are not resolved
+	resolved  map[string]*models.Interpolation
+}
+
+func newInterpolator(ssmc aws.SSMAPI) *interpolator {
+	return &interpolator{ssmc: ssmc, lookupEnv: os.LookupEnv, resolved: map[string]*models.Interpolation{}}
+}
+
+// newServerInterpolator only resolves the odin parameters, so callers of odin server cannot read its environment or other secrets
+func newServerInterpolator(ssmc aws.SSMAPI) *interpolator {
+	return &interpolator{ssmc: ssmc, ssmPrefix: "/odin/", resolved: map[string]*models.Interpolation{}}
+}
+
+// interpolate replaces the references in every string value of the document
+func (in *interpolator) interpolate(doc interface{}) (interface{}, error) {
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			resolved, err := in.interpolate(value)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = resolved
+		}
+		return v, nil
+	case []interface{}:
+		for i, value := range v {
+			resolved, err := in.interpolate(value)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = resolved
+		}
+		return v, nil
+	case string:
+		return in.interpolateString(v)
+	default:
+		return v, nil
+	}
+}
+
+func (in *interpolator) interpolateString(s string) (string, error) {
+	var err error
+	interpolated := referencePattern.ReplaceAllStringFunc(s, func(match string) string {
+		if match == "$${" {
+			return "${"
+		}
+
+		parts := referencePattern.FindStringSubmatch(match)
+		value, rerr := in.resolve(parts[1], parts[2])
+		if rerr != nil && err == nil {
+			err = rerr
+		}
+		return value
+	})
+
+	return interpolated, err
+}
+
+// resolve returns the value of a reference, only recording the hash of SecureString parameters and environment variables
+func (in *interpolator) resolve(scheme string, name string) (string, error) {
+	ref := fmt.Sprintf("%v:%v", scheme, name)
+	if name == "" {
+		return "", fmt.Errorf("Interpolation ${%v} must have a name", ref)
+	}
+
+	var value string
+	secret := true
+
+	switch scheme {
+	case "ssm":
+		if !strings.HasPrefix(name, in.ssmPrefix) {
+			return "", fmt.Errorf("Interpolation ${%v} must be under %v", ref, in.ssmPrefix)
+		}
+
+		out, err := in.ssmc.GetParameter(&ssm.GetParameterInput{Name: to.Strp(name), WithDecryption: to.Boolp(true)})
+		if err != nil {
+			return "", fmt.Errorf("Interpolation ${%v} %v", ref, err.Error())
+		}
+
+		if out.Parameter == nil || out.Parameter.Value == nil {
+			return "", fmt.Errorf("Interpolation ${%v} has no value", ref)
+		}
+
+		value = *out.Parameter.Value
+		secret = to.Strs(out.Parameter.Type) == ssm.ParameterTypeSecureString
+	case "env":
+		// Environment variables often hold CI credentials, so are treated as secret
+		if in.lookupEnv == nil {
+			return "", fmt.Errorf("Interpolation ${%v} environment variables are not resolved here", ref)
+		}
+
+		v, ok := in.lookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("Interpolation ${%v} environment variable is not set", ref)
+		}
+		value = v
+	}
+
+	interpolation := &models.Interpolation{SHA256: to.Strp(to.SHA256Str(&value))}
+	if !secret {
+		interpolation.Value = to.Strp(value)
+	}
+
+	in.resolved[ref] = interpolation
+	return value, nil
+}
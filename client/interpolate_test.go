@@ -0,0 +1,68 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseReleaseFiles_Interpolation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "odin-interpolate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := writeReleaseFile(t, dir, "release.yml", `
+project_name: coinbase/null
+config_name: ${env:ODIN_CONFIG}
+ami: ${ssm:/odin/null/ami}
+subnets: ["${ssm:/odin/null/subnet}"]
+services:
+  web:
+    instance_type: t2.nano
+    tags:
+      token: ${ssm:/odin/null/token}
+      literal: $${env:NOT_RESOLVED}
+`)
+
+	awsc := mocks.MockAWS()
+	awsc.SSM.AddParameter("/odin/null/ami", "ami-123456")
+	awsc.SSM.AddParameter("/odin/null/subnet", "subnet-1")
+	awsc.SSM.AddSecureParameter("/odin/null/token", "secret")
+
+	in := newInterpolator(awsc.SSM)
+	in.lookupEnv = func(name string) (string, bool) {
+		return map[string]string{"ODIN_CONFIG": "production"}[name], name == "ODIN_CONFIG"
+	}
+
+	release, err := parseReleaseFiles([]string{file}, in)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "production", *release.ConfigName)
+	assert.Equal(t, "ami-123456", *release.Image)
+	assert.Equal(t, []string{"subnet-1"}, to.StrSlice(release.Subnets))
+	assert.Equal(t, "secret", *release.Services["web"].Tags["token"])
+	assert.Equal(t, "${env:NOT_RESOLVED}", *release.Services["web"].Tags["literal"])
+
+	// Values are recorded unless they are secret
+	assert.Equal(t, "ami-123456", *release.Interpolations["ssm:/odin/null/ami"].Value)
+	assert.Nil(t, release.Interpolations["ssm:/odin/null/token"].Value)
+	assert.Equal(t, to.SHA256Str(to.Strp("secret")), *release.Interpolations["ssm:/odin/null/token"].SHA256)
+	assert.Nil(t, release.Interpolations["env:ODIN_CONFIG"].Value)
+	assert.NoError(t, release.ValidateInterpolations())
+
+	// The deployer checks the parameters have not changed
+	assert.NoError(t, release.VerifyInterpolations(awsc.SSM))
+	awsc.SSM.AddParameter("/odin/null/ami", "ami-654321")
+	assert.Error(t, release.VerifyInterpolations(awsc.SSM))
+
+	// Missing references fail
+	in = newInterpolator(awsc.SSM)
+	in.lookupEnv = func(string) (string, bool) { return "", false }
+	_, err = parseReleaseFiles([]string{file}, in)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "env:ODIN_CONFIG")
+}
@@ -0,0 +1,140 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/execution"
+	"github.com/coinbase/step/utils/to"
+)
+
+// jsonOutput prints events as JSON lines instead of human readable log lines
+var jsonOutput = false
+
+// lastEvent is used to only print state events when they change
+var lastEvent = ""
+
+// SetOutput sets the clients output format to either text or json
+func SetOutput(format string) error {
+	switch format {
+	case "text":
+		jsonOutput = false
+	case "json":
+		jsonOutput = true
+	default:
+		return fmt.Errorf("Output must be either text or json")
+	}
+	return nil
+}
+
+// Event is a machine readable client event
+type Event struct {
+	Time           time.Time                       `json:"time"`
+	Type           string                          `json:"type"`
+	ExecutionArn   *string                         `json:"execution_arn,omitempty"`
+	Status         *string                         `json:"status,omitempty"`
+	State          string                          `json:"state,omitempty"`
+	Error          *string                         `json:"error,omitempty"`
+	ErrorCode      *string                         `json:"error_code,omitempty"`
+	ExitCode       *int                            `json:"exit_code,omitempty"`
+	Cause          *string                         `json:"cause,omitempty"`
+	Services       map[string]*models.HealthReport `json:"services,omitempty"`
+	Tombstone      *models.Tombstone               `json:"tombstone,omitempty"`
+	Resources      []string                        `json:"resources,omitempty"`
+	Lock           *models.Lock                    `json:"lock,omitempty"`
+	Notes          []string                        `json:"notes,omitempty"`
+	RegistrationID *string                         `json:"registration_id,omitempty"`
+	ReleaseSHA256  *string                         `json:"release_sha256,omitempty"`
+}
+
+func emit(event *Event) {
+	event.Time = time.Now().UTC()
+	raw, _ := json.Marshal(event)
+	fmt.Println(string(raw))
+}
+
+// PrintError prints an error in the current output format
+func PrintError(err error) {
+	if !jsonOutput {
+		fmt.Println(err.Error())
+		return
+	}
+
+	emit(errorEvent(err))
+}
+
+// errorEvent describes the error with its exit code
+func errorEvent(err error) *Event {
+	msg := err.Error()
+	event := &Event{Type: "error", Error: &msg, ExitCode: to.Intp(ExitCode(err))}
+	if failed, ok := err.(*FailedError); ok {
+		event.ErrorCode = failed.Code
+	}
+	return event
+}
+
+// printExecution prints the execution being followed
+func printExecution(eventType string, exec *execution.Execution) {
+	if jsonOutput {
+		emit(&Event{Type: eventType, ExecutionArn: exec.ExecutionArn, Status: exec.Status})
+	}
+}
+
+// printDone ends the output of a followed execution
+func printDone() {
+	if !jsonOutput {
+		fmt.Println("")
+	}
+}
+
+func stateEvent(ed *execution.Execution, sd *execution.StateDetails) (*Event, error) {
+	event := &Event{
+		Type:         "state",
+		ExecutionArn: ed.ExecutionArn,
+		Status:       ed.Status,
+		State:        stateName(sd),
+	}
+
+	release, err := outputRelease(sd)
+	if err != nil {
+		return nil, err
+	}
+
+	if release.Error != nil {
+		event.Error = release.Error.Error
+		event.ErrorCode = models.ErrorCode(release.Error)
+		event.Cause = release.Error.Cause
+	}
+
+	for name, service := range release.Services {
+		if service == nil || service.HealthReport == nil {
+			continue
+		}
+
+		if event.Services == nil {
+			event.Services = map[string]*models.HealthReport{}
+		}
+		event.Services[name] = service.HealthReport
+	}
+
+	return event, nil
+}
+
+// emitStateEvent prints the state event if it has changed since the last one
+func emitStateEvent(ed *execution.Execution, sd *execution.StateDetails) error {
+	event, err := stateEvent(ed, sd)
+	if err != nil {
+		return err
+	}
+
+	raw, _ := json.Marshal(event)
+	if string(raw) == lastEvent {
+		return nil
+	}
+	lastEvent = string(raw)
+
+	emit(event)
+	return nil
+}
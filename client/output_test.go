@@ -0,0 +1,73 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/bifrost"
+	"github.com/coinbase/step/execution"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SetOutput(t *testing.T) {
+	defer SetOutput("text")
+
+	assert.NoError(t, SetOutput("json"))
+	assert.True(t, jsonOutput)
+
+	assert.NoError(t, SetOutput("text"))
+	assert.False(t, jsonOutput)
+
+	assert.Error(t, SetOutput("yaml"))
+}
+
+func Test_stateEvent(t *testing.T) {
+	r := minimalRelease(t)
+	r.Services["web"].HealthReport = &models.HealthReport{Healthy: to.Intp(1)}
+
+	exec := &execution.Execution{ExecutionArn: to.Strp("arn"), Status: to.Strp("RUNNING")}
+	event, err := stateEvent(exec, createStateDetails(r, "CheckHealthy"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "state", event.Type)
+	assert.Equal(t, "arn", *event.ExecutionArn)
+	assert.Equal(t, "CheckHealthy", event.State)
+	assert.Equal(t, 1, *event.Services["web"].Healthy)
+	assert.Nil(t, event.Error)
+
+	r.Error = &bifrost.ReleaseError{Error: to.Strp("DeployError"), Cause: to.Strp("cause")}
+	event, err = stateEvent(exec, createStateDetails(r, "CleanUpFailure"))
+	assert.NoError(t, err)
+	assert.Equal(t, "DeployError", *event.Error)
+	assert.Equal(t, "E_DEPLOY", *event.ErrorCode)
+	assert.Equal(t, "cause", *event.Cause)
+}
+
+func Test_stateEvent_StateRef(t *testing.T) {
+	awsc := mocks.MockAWS()
+	stateClients = awsc
+	defer func() { stateClients = nil }()
+
+	r := minimalRelease(t)
+	r.Bucket = to.Strp("bucket")
+	r.AwsAccountID = to.Strp("000000000000")
+	r.Services["web"].HealthReport = &models.HealthReport{Healthy: to.Intp(1)}
+
+	ref, err := r.Offload(awsc.S3)
+	assert.NoError(t, err)
+
+	// The services health is loaded from the release the reference points to
+	exec := &execution.Execution{ExecutionArn: to.Strp("arn"), Status: to.Strp("RUNNING")}
+	event, err := stateEvent(exec, createStateDetails(ref, "CheckHealthy"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, *event.Services["web"].Healthy)
+
+	// The error is read from the reference
+	ref.Error = &bifrost.ReleaseError{Error: to.Strp("DeployError"), Cause: to.Strp("cause")}
+	event, err = stateEvent(exec, createStateDetails(ref, "CleanUpFailure"))
+	assert.NoError(t, err)
+	assert.Equal(t, "DeployError", *event.Error)
+	assert.Equal(t, 1, *event.Services["web"].Healthy)
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/coinbase/odin/deployer/models"
+)
+
+// parseReleaseFiles merges each file over the files before it, so a base release can have per-environment overlays
+// Objects are merged key by key, other values like lists replace the previous value, and null removes the key
+// With an interpolator the references in the merged release are resolved and recorded in the release
+func parseReleaseFiles(releaseFiles []string, in *interpolator) (*models.Release, error) {
+	if len(releaseFiles) == 1 && in == nil {
+		return parseRelease(releaseFiles[0])
+	}
+
+	var merged interface{}
+	for _, file := range releaseFiles {
+		doc, err := parseReleaseDocument(file)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := doc.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("%v must be an object", file)
+		}
+
+		merged = mergeDocuments(merged, doc)
+	}
+
+	return releaseFromDocument(merged, in)
+}
+
+// releaseFromDocument builds the release from an untyped release document, resolving its references with the interpolator
+func releaseFromDocument(doc interface{}, in *interpolator) (*models.Release, error) {
+	merged := doc
+	if in != nil {
+		var err error
+		if merged, err = in.interpolate(merged); err != nil {
+			return nil, err
+		}
+	}
+
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+
+	var release models.Release
+	if err := json.Unmarshal(raw, &release); err != nil {
+		return nil, err
+	}
+
+	// The client records what it resolved, not the release file
+	release.Interpolations = nil
+	if in != nil && len(in.resolved) > 0 {
+		release.Interpolations = in.resolved
+	}
+
+	return &release, nil
+}
+
+// parseReleaseDocument reads a JSON or YAML release file without its types, to be merged
+func parseReleaseDocument(releaseFile string) (interface{}, error) {
+	raw, err := ioutil.ReadFile(releaseFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if isYAML(releaseFile) {
+		if raw, err = yamlToJSON(raw); err != nil {
+			return nil, err
+		}
+	}
+
+	// Numbers are kept as written so large integers are not rounded
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%v %v", releaseFile, err.Error())
+	}
+
+	return doc, nil
+}
+
+// mergeDocuments returns the overlay merged over the base, like a JSON merge patch (RFC 7396)
+func mergeDocuments(base interface{}, overlay interface{}) interface{} {
+	overlayMap, ok := overlay.(map[string]interface{})
+	if !ok {
+		return overlay
+	}
+
+	merged := map[string]interface{}{}
+	if baseMap, ok := base.(map[string]interface{}); ok {
+		for key, value := range baseMap {
+			merged[key] = value
+		}
+	}
+
+	for key, value := range overlayMap {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+
+		merged[key] = mergeDocuments(merged[key], value)
+	}
+
+	return merged
+}
+
+// parseUserDataFiles returns the userdata of the last file that has a userdata file,
+// so overlays can replace the base releases userdata
+func parseUserDataFiles(releaseFiles []string) (*string, error) {
+	for i := len(releaseFiles) - 1; i > 0; i-- {
+		userdata, err := parseUserData(releaseFiles[i])
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		return userdata, err
+	}
+
+	return parseUserData(releaseFiles[0])
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseReleaseFiles_Overlay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "odin-overlay")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	base := writeReleaseFile(t, dir, "base.yml", `
+project_name: coinbase/null
+config_name: staging
+ami: ubuntu
+subnets: ["staging-subnet-a", "staging-subnet-b"]
+timeout: 1800
+services:
+  web:
+    instance_type: t2.nano
+    security_groups: ["web-sg"]
+    autoscaling:
+      min_size: 1
+      max_size: 2
+  worker:
+    instance_type: t2.nano
+`)
+	writeReleaseFile(t, dir, "base.yml.userdata", "#base")
+
+	prod := writeReleaseFile(t, dir, "prod.json", `{
+		"config_name": "production",
+		"subnets": ["prod-subnet-a"],
+		"services": {
+			"web": {"instance_type": "m5.large", "autoscaling": {"max_size": 10}},
+			"worker": null
+		}
+	}`)
+
+	release, err := parseReleaseFiles([]string{base, prod}, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "production", *release.ConfigName)
+	assert.Equal(t, "ubuntu", *release.Image)
+	assert.Equal(t, 1800, *release.Timeout)
+	assert.Equal(t, []string{"prod-subnet-a"}, to.StrSlice(release.Subnets)) // Lists are replaced
+
+	web := release.Services["web"]
+	assert.Equal(t, "m5.large", *web.InstanceType)
+	assert.Equal(t, []string{"web-sg"}, to.StrSlice(web.SecurityGroups))
+	assert.Equal(t, int64(1), *web.Autoscaling.MinSize) // Objects are merged
+	assert.Equal(t, int64(10), *web.Autoscaling.MaxSize)
+
+	_, ok := release.Services["worker"]
+	assert.False(t, ok) // null removes
+
+	// The base userdata is used unless an overlay has its own
+	userdata, err := parseUserDataFiles([]string{base, prod})
+	assert.NoError(t, err)
+	assert.Equal(t, "#base", *userdata)
+
+	writeReleaseFile(t, dir, "prod.json.userdata", "#prod")
+	userdata, err = parseUserDataFiles([]string{base, prod})
+	assert.NoError(t, err)
+	assert.Equal(t, "#prod", *userdata)
+
+	// Unknown keys in overlays are rejected
+	typo := writeReleaseFile(t, dir, "typo.yml", "services: {web: {instance_typo: m5.large}}")
+	_, err = parseReleaseFiles([]string{base, typo}, nil)
+	assert.Error(t, err)
+
+	assert.NoError(t, validateFile(base, prod))
+}
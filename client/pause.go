@@ -0,0 +1,90 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/execution"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Pause holds the running deploy of the release once its new ASGs are healthy, until it is resumed
+func Pause(step_fn *string, releaseFile *string) error {
+	env, err := currentEnvironment(step_fn)
+	if err != nil {
+		return err
+	}
+
+	release, err := releaseFromFile(releaseFile, env)
+	if err != nil {
+		return err
+	}
+
+	return pause(env.awsc, release, env.deployerARN)
+}
+
+// Unpause lets the paused deploy of the release continue
+func Unpause(step_fn *string, releaseFile *string) error {
+	env, err := currentEnvironment(step_fn)
+	if err != nil {
+		return err
+	}
+
+	release, err := releaseFromFile(releaseFile, env)
+	if err != nil {
+		return err
+	}
+
+	return unpause(env.awsc, release, env.deployerARN)
+}
+
+func pause(awsc aws.Clients, release *models.Release, deployerARN *string) error {
+	exec, err := runningExecution(awsc, release, deployerARN)
+	if err != nil {
+		return err
+	}
+
+	if err := release.Pause(awsc.S3Client(nil, nil, nil), to.Strp("Odin client paused deploy")); err != nil {
+		return err
+	}
+
+	printExecution("paused", exec)
+	return nil
+}
+
+func unpause(awsc aws.Clients, release *models.Release, deployerARN *string) error {
+	exec, err := runningExecution(awsc, release, deployerARN)
+	if err != nil {
+		return err
+	}
+
+	if err := release.Unpause(awsc.S3Client(nil, nil, nil)); err != nil {
+		return err
+	}
+
+	printExecution("unpaused", exec)
+
+	exec.WaitForExecution(awsc.SFNClient(nil, nil, nil), 1, waiter)
+	printDone()
+	return nil
+}
+
+// runningExecution returns the running deploy of the releases project config
+// A pause left without a running deploy would hold the next one, so it is refused
+func runningExecution(awsc aws.Clients, release *models.Release, deployerARN *string) (*execution.Execution, error) {
+	exec, err := execution.FindExecution(awsc.SFNClient(nil, nil, nil), deployerARN, release.ExecutionPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	if exec == nil {
+		return nil, fmt.Errorf("Cannot find current execution of release with prefix %q", release.ExecutionPrefix())
+	}
+
+	if exec.Status == nil || *exec.Status != "RUNNING" {
+		return nil, fmt.Errorf("Execution %v is %v not RUNNING", to.Strs(exec.ExecutionArn), to.Strs(exec.Status))
+	}
+
+	return exec, nil
+}
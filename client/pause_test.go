@@ -0,0 +1,48 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Pause(t *testing.T) {
+	awsc := mocks.MockAWS()
+	r := minimalRelease(t)
+
+	r.Release.SetDefaults(to.Strp("region"), to.Strp("accountid"), "")
+
+	// Nothing to pause without a running deploy
+	assert.Error(t, pause(awsc, r, to.Strp("deployerARN")))
+
+	awsc.SFN.ListExecutionsResp = &sfn.ListExecutionsOutput{
+		Executions: []*sfn.ExecutionListItem{
+			&sfn.ExecutionListItem{
+				Name:         r.ExecutionName(),
+				ExecutionArn: to.Strp("arn"),
+				StartDate:    to.Timep(time.Now()),
+				Status:       to.Strp("SUCCEEDED"),
+			},
+		},
+	}
+
+	// A finished deploy cannot be paused, or the pause would hold the next one
+	assert.Error(t, pause(awsc, r, to.Strp("deployerARN")))
+	assert.NoError(t, r.CheckPaused(awsc.S3))
+	assert.False(t, r.IsPaused())
+
+	awsc.SFN.ListExecutionsResp.Executions[0].Status = to.Strp("RUNNING")
+	assert.NoError(t, pause(awsc, r, to.Strp("deployerARN")))
+
+	assert.NoError(t, r.CheckPaused(awsc.S3))
+	assert.True(t, r.IsPaused())
+
+	assert.NoError(t, unpause(awsc, r, to.Strp("deployerARN")))
+
+	assert.NoError(t, r.CheckPaused(awsc.S3))
+	assert.False(t, r.IsPaused())
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/pricing"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Plan validates the release and its resources, then prints the ASGs a deploy would create and terminate
+// with their estimated cost. It does not upload the release, grab the lock, or start the deployer
+func Plan(step_fn *string, releaseFiles []string) error {
+	env, err := currentEnvironment(step_fn)
+	if err != nil {
+		return err
+	}
+
+	release, err := releaseFromFiles(releaseFiles, env)
+	if err != nil {
+		return err
+	}
+
+	p, err := plan(env.awsc, release)
+	if err != nil {
+		return err
+	}
+
+	out, err := to.PrettyJSON(p)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(out)
+	return nil
+}
+
+// plan runs the deployers validations with the callers credentials
+func plan(awsc aws.Clients, release *models.Release) (*models.Plan, error) {
+	release.SetDefaults()
+
+	if err := release.ValidateConfiguration(); err != nil {
+		return nil, err
+	}
+
+	resources, err := release.FetchResources(
+		awsc.ASGClient(nil, nil, nil),
+		awsc.EC2Client(nil, nil, nil),
+		awsc.ELBClient(nil, nil, nil),
+		awsc.ALBClient(nil, nil, nil),
+		awsc.IAMClient(nil, nil, nil),
+		awsc.SNSClient(nil, nil, nil),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := release.ValidateResources(resources); err != nil {
+		return nil, err
+	}
+
+	release.UpdateWithResources(resources)
+
+	if err := release.ValidateQuotas(awsc.ASGClient(nil, nil, nil), awsc.EC2Client(nil, nil, nil)); err != nil {
+		return nil, err
+	}
+
+	p, err := release.Plan(awsc.ASGClient(nil, nil, nil))
+	if err != nil {
+		return nil, err
+	}
+
+	release.EstimateCost(p, awsc.PricingClient(to.Strp(pricing.Region), nil, nil), models.DecryptingS3(awsc.S3Client(nil, nil, nil), awsc.KMSClient(nil, nil, nil)))
+
+	return p, nil
+}
+
+// checkCost errors if the release increases the estimated monthly cost by more than the threshold,
+// or its cost cannot be estimated, so the deploy must be confirmed with --confirm-cost
+func checkCost(awsc aws.Clients, release *models.Release, threshold float64) error {
+	p, err := plan(awsc, release)
+	if err != nil {
+		return err
+	}
+
+	return checkPlanCost(p, threshold)
+}
+
+func checkPlanCost(p *models.Plan, threshold float64) error {
+	if len(p.Cost.Unpriced) > 0 {
+		return fmt.Errorf("Cost could not be estimated (%v), deploy with --confirm-cost", strings.Join(p.Cost.Unpriced, ", "))
+	}
+
+	if p.Cost.MonthlyDelta > threshold {
+		return fmt.Errorf(
+			"Release increases the estimated monthly cost by $%.2f (from $%.2f to $%.2f), over the $%.2f threshold, deploy with --confirm-cost",
+			p.Cost.MonthlyDelta, p.Cost.Current.Monthly, p.Cost.New.Monthly, threshold,
+		)
+	}
+
+	return nil
+}
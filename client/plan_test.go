@@ -0,0 +1,58 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Plan(t *testing.T) {
+	release := models.MockRelease(t)
+	models.MockPrepareRelease(release)
+	awsc := models.MockAwsClients(release)
+
+	p, err := plan(awsc, release)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(p.Create))
+	assert.Equal(t, 1, len(p.Terminate))
+}
+
+func Test_Plan_BadResources(t *testing.T) {
+	release := models.MockRelease(t)
+	models.MockPrepareRelease(release)
+	awsc := models.MockAwsClients(release)
+	awsc.ELB.AddELB("web-elb", *release.ProjectName, *release.ConfigName, "noop")
+
+	_, err := plan(awsc, release)
+	assert.Error(t, err)
+}
+
+func Test_Plan_Cost(t *testing.T) {
+	release := models.MockRelease(t)
+	models.MockPrepareRelease(release)
+	awsc := models.MockAwsClients(release)
+
+	// Without prices the ASGs are left out of the estimate
+	p, err := plan(awsc, release)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(p.Cost.Unpriced))
+	assert.Error(t, checkCost(awsc, release, 1000))
+
+	awsc.Pricing.AddPrice("t2.small", 0.023)
+	awsc.Pricing.AddPrice("gp2", 0.10)
+	awsc.S3.AddGetObject(*release.RootDir()+"/old-release/release", `{"services": {"web": {"instance_type": "t2.small"}}}`, nil)
+
+	p, err = plan(awsc, release)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(p.Cost.Unpriced))
+	assert.Equal(t, "t2.small", *p.Terminate[0].InstanceType)
+	assert.Equal(t, 28.79, p.Cost.New.Monthly) // t2.small and 120GB of gp2
+	assert.Equal(t, 28.79, p.Cost.MonthlyDelta)
+
+	err = checkCost(awsc, release, 10)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--confirm-cost")
+
+	assert.NoError(t, checkCost(awsc, release, 50))
+}
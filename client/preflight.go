@@ -0,0 +1,43 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/coinbase/odin/aws/bucket"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+)
+
+// preflightBucket checks the releases bucket before anything is uploaded to it, so a misconfigured bucket or missing
+// permission fails with what to fix instead of AccessDenied part way through an upload
+// Each bucket and project config is only checked once, e.g. by each canary or server deploy
+func (env *environment) preflightBucket(release *models.Release, paths ...*string) error {
+	if !env.preflight {
+		return nil
+	}
+
+	env.preflightLock.Lock()
+	defer env.preflightLock.Unlock()
+
+	checked := fmt.Sprintf("%v/%v", to.Strs(release.Bucket), *release.RootDir())
+	if env.preflighted[checked] {
+		return nil
+	}
+
+	keys := []string{}
+	for _, path := range paths {
+		keys = append(keys, *path)
+	}
+
+	warnings, err := bucket.Preflight(env.awsc.S3Client(nil, nil, nil), to.Strs(release.Bucket), to.Strs(release.AwsRegion), keys)
+	for _, warning := range warnings {
+		fmt.Fprintf(hookOutput(), "Warning: %v\n", warning)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	env.preflighted[checked] = true
+	return nil
+}
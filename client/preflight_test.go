@@ -0,0 +1,66 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/mocks"
+	stepmocks "github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+// missingBucketS3 is a bucket that does not exist
+type missingBucketS3 struct {
+	*stepmocks.MockS3Client
+	heads int
+}
+
+func (m *missingBucketS3) HeadBucket(in *s3.HeadBucketInput) (*s3.HeadBucketOutput, error) {
+	m.heads++
+	return nil, awserr.New("NotFound", "not found", nil)
+}
+
+// bucketClients returns its own S3 client
+type bucketClients struct {
+	*mocks.MockClients
+	s3c aws.S3API
+}
+
+func (c *bucketClients) S3Client(*string, *string, *string) aws.S3API {
+	return c.s3c
+}
+
+func Test_Environment_PreflightBucket(t *testing.T) {
+	release := minimalRelease(t)
+	release.Bucket = to.Strp("coinbase-odin-accountid")
+	prepareRelease(release, to.Strp("region"), to.Strp("accountid"))
+
+	s3c := &missingBucketS3{MockS3Client: &stepmocks.MockS3Client{}}
+	env := &environment{awsc: &bucketClients{mocks.MockAWS(), s3c}, preflighted: map[string]bool{}}
+
+	// Turned off with preflight: false in the context
+	assert.NoError(t, env.preflightBucket(release, release.ReleasePath()))
+	assert.Equal(t, 0, s3c.heads)
+
+	env.preflight = true
+	err := env.preflightBucket(release, release.ReleasePath())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Bucket coinbase-odin-accountid preflight failed")
+	assert.Contains(t, err.Error(), "does not exist")
+	assert.Equal(t, ExitError, ExitCode(err))
+
+	// Failures are checked again
+	assert.Error(t, env.preflightBucket(release, release.ReleasePath()))
+	assert.Equal(t, 2, s3c.heads)
+}
+
+func Test_Context_Preflight(t *testing.T) {
+	ctx := &Context{Account: to.Strp("111111111111"), Region: to.Strp("us-west-2")}
+	assert.True(t, ctx.environment(nil).preflight)
+
+	ctx.Preflight = to.Boolp(false)
+	assert.False(t, ctx.environment(nil).preflight)
+}
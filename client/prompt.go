@@ -0,0 +1,99 @@
+package client
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+)
+
+// interactive is true if the client is run from a terminal, so CI is never prompted
+var interactive = func() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// confirmDeploy prints what the release deploys and asks for it to be confirmed
+func confirmDeploy(release *models.Release, p *models.Plan) error {
+	for _, line := range deploySummary(release, p) {
+		fmt.Println(line)
+	}
+
+	fmt.Print("Deploy? [y/N]: ")
+
+	scanner := bufio.NewScanner(confirmInput)
+	if !scanner.Scan() {
+		return fmt.Errorf("Not confirmed, nothing was deployed")
+	}
+
+	switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+	case "y", "yes":
+		return nil
+	default:
+		return fmt.Errorf("Not confirmed, nothing was deployed")
+	}
+}
+
+// deploySummary describes the project config, AMI and the change in each services desired capacity
+func deploySummary(release *models.Release, p *models.Plan) []string {
+	image := to.Strs(release.Image)
+	for _, planned := range p.Create {
+		if planned.Image != nil && *planned.Image != image {
+			image = fmt.Sprintf("%v (%v)", *planned.Image, image)
+			break
+		}
+	}
+
+	lines := []string{
+		fmt.Sprintf("Project: %v", to.Strs(release.ProjectName)),
+		fmt.Sprintf("Config:  %v", to.Strs(release.ConfigName)),
+		fmt.Sprintf("AMI:     %v", image),
+	}
+
+	current, next := map[string]int64{}, map[string]int64{}
+	for _, planned := range p.Terminate {
+		current[to.Strs(planned.ServiceName)] += capacityOf(planned)
+	}
+
+	for _, planned := range p.Create {
+		next[to.Strs(planned.ServiceName)] += capacityOf(planned)
+	}
+
+	names := []string{}
+	for name := range next {
+		names = append(names, name)
+	}
+
+	for name := range current {
+		if _, ok := next[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var currentTotal, nextTotal int64
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("  %v: %v -> %v instances (%+d)", name, current[name], next[name], next[name]-current[name]))
+		currentTotal += current[name]
+		nextTotal += next[name]
+	}
+
+	lines = append(lines, fmt.Sprintf("Capacity: %v -> %v instances (%+d)", currentTotal, nextTotal, nextTotal-currentTotal))
+
+	if p.Cost != nil && len(p.Cost.Unpriced) == 0 {
+		lines = append(lines, fmt.Sprintf("Cost:     $%.2f -> $%.2f a month (%+.2f)", p.Cost.Current.Monthly, p.Cost.New.Monthly, p.Cost.MonthlyDelta))
+	}
+
+	return lines
+}
+
+func capacityOf(planned *models.PlannedASG) int64 {
+	if planned.DesiredCapacity == nil {
+		return 0
+	}
+	return *planned.DesiredCapacity
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DeploySummary(t *testing.T) {
+	release := models.MockRelease(t)
+	p := &models.Plan{
+		Create: []*models.PlannedASG{
+			&models.PlannedASG{ServiceName: to.Strp("web"), DesiredCapacity: to.Int64p(3), Image: to.Strp("ami-123456")},
+		},
+		Terminate: []*models.PlannedASG{
+			&models.PlannedASG{ServiceName: to.Strp("web"), DesiredCapacity: to.Int64p(2)},
+			&models.PlannedASG{ServiceName: to.Strp("worker"), DesiredCapacity: to.Int64p(1)},
+		},
+	}
+
+	summary := strings.Join(deploySummary(release, p), "\n")
+	assert.Contains(t, summary, "Project: project")
+	assert.Contains(t, summary, "Config:  config")
+	assert.Contains(t, summary, "AMI:     ami-123456")
+	assert.Contains(t, summary, "web: 2 -> 3 instances (+1)")
+	assert.Contains(t, summary, "worker: 1 -> 0 instances (-1)")
+	assert.Contains(t, summary, "Capacity: 3 -> 3 instances (+0)")
+}
+
+func Test_ConfirmDeploy(t *testing.T) {
+	input := confirmInput
+	defer func() { confirmInput = input }()
+
+	release := models.MockRelease(t)
+	p := &models.Plan{}
+
+	confirmInput = strings.NewReader("y\n")
+	assert.NoError(t, confirmDeploy(release, p))
+
+	confirmInput = strings.NewReader("no\n")
+	assert.Error(t, confirmDeploy(release, p))
+
+	confirmInput = strings.NewReader("")
+	assert.Error(t, confirmDeploy(release, p))
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/identity"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Push uploads the release and its userdata without deploying it, printing the registration to execute it with
+func Push(step_fn *string, releaseFiles []string) error {
+	env, err := currentEnvironment(step_fn)
+	if err != nil {
+		return err
+	}
+
+	release, err := releaseFromFiles(releaseFiles, env)
+	if err != nil {
+		return err
+	}
+
+	if err := env.preflightBucket(release, release.UserDataPath()); err != nil {
+		return err
+	}
+
+	registration, err := push(env.awsc, release, env.bucket)
+	if err != nil {
+		return err
+	}
+
+	printRegistration("pushed", registration)
+	return nil
+}
+
+// push uploads the userdata, then registers the release in the contexts bucket so it can be found by its ID
+func push(awsc aws.Clients, release *models.Release, bucket *string) (*models.Registration, error) {
+	if err := userDataStore(awsc, release).PutSecure(release.UserDataPath(), []byte(to.Strs(release.UserData())), release.UserDataKMSKeyID()); err != nil {
+		return nil, err
+	}
+
+	return release.Register(models.StoreOf(awsc)(releaseS3(awsc, release), bucket), time.Now())
+}
+
+// Execute deploys a pushed release, the identity executing it is the one checked by the deployers RBAC
+func Execute(step_fn *string, registrationID *string, waitForLock time.Duration) error {
+	env, err := currentEnvironment(step_fn)
+	if err != nil {
+		return err
+	}
+
+	release, err := executeRelease(env.awsc, env.bucket, env.accountID, registrationID)
+	if err != nil {
+		return err
+	}
+
+	if err := env.preflightBucket(release, release.ReleasePath()); err != nil {
+		return err
+	}
+
+	if waitForLock > 0 {
+		release.LockWait = to.Intp(int(waitForLock.Seconds()))
+	}
+
+	// Prove who is executing to the deployers RBAC
+	if release.Identity, err = identity.Presign(env.awsc.STSClient(nil, nil, nil), release.IdentityBinding()); err != nil {
+		return err
+	}
+
+	auditRelease(release, nil)
+	env.requestSignature(release)
+
+	return execute(env.awsc, release, env.deployerARN)
+}
+
+// executeRelease loads the pushed release, it is created when executed as the deployer validates its age
+func executeRelease(awsc aws.Clients, bucket *string, accountID *string, registrationID *string) (*models.Release, error) {
+	registration, err := models.LoadRegistration(
+		models.StoreOf(awsc)(models.DecryptingS3(awsc.S3Client(nil, nil, nil), awsc.KMSClient(nil, nil, nil)), bucket),
+		accountID, registrationID)
+	if err != nil {
+		return nil, err
+	}
+
+	printRegistration("executing", registration)
+
+	release := registration.Release
+	release.CreatedAt = to.Timep(time.Now())
+
+	return release, nil
+}
+
+func execute(awsc aws.Clients, release *models.Release, deployerARN *string) error {
+	name := nameExecution(release, deployerARN)
+
+	if err := signRelease(awsc, release); err != nil {
+		return err
+	}
+
+	// The userdata was uploaded when pushed, only the Release is uploaded to match SHAs
+	if err := models.PutArtifact(releaseStore(awsc, release), release.ReleasePath(), release.WithoutIdentity()); err != nil {
+		return err
+	}
+
+	exec, err := findOrCreateExec(awsc.SFNClient(nil, nil, nil), deployerARN, release, name)
+	if err != nil {
+		return err
+	}
+
+	printExecution("started", exec)
+
+	_, err = waitForExecution(awsc.SFNClient(nil, nil, nil), exec)
+	return err
+}
+
+func printRegistration(eventType string, registration *models.Registration) {
+	if jsonOutput {
+		emit(&Event{Type: eventType, RegistrationID: registration.RegistrationID, ReleaseSHA256: registration.ReleaseSHA256})
+		return
+	}
+
+	fmt.Printf("%v %v/%v registration %v sha256 %v\n",
+		eventType,
+		to.Strs(registration.Release.ProjectName),
+		to.Strs(registration.Release.ConfigName),
+		to.Strs(registration.RegistrationID),
+		to.Strs(registration.ReleaseSHA256),
+	)
+}
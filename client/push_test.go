@@ -0,0 +1,56 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Push_Execute(t *testing.T) {
+	awsc := mocks.MockAWS()
+	r := minimalRelease(t)
+	r.Release.SetDefaults(to.Strp("region"), to.Strp("accountid"), "")
+	r.SetUserData(to.Strp("#cloud_config"))
+
+	registration, err := push(awsc, r, r.Bucket)
+	assert.NoError(t, err)
+	assert.Equal(t, r.ReleaseID, registration.RegistrationID)
+	assert.NotNil(t, registration.ReleaseSHA256)
+
+	// Pushing does not upload the release to be deployed
+	_, err = s3.Get(awsc.S3, r.Bucket, r.ReleasePath())
+	assert.Error(t, err)
+
+	release, err := executeRelease(awsc, r.Bucket, r.AwsAccountID, registration.RegistrationID)
+	assert.NoError(t, err)
+	assert.Equal(t, "project", *release.ProjectName)
+
+	assert.NoError(t, execute(awsc, release, to.Strp("deployerARN")))
+
+	_, err = s3.Get(awsc.S3, r.Bucket, r.ReleasePath())
+	assert.NoError(t, err)
+}
+
+func Test_Execute_NotPushed(t *testing.T) {
+	awsc := mocks.MockAWS()
+
+	_, err := executeRelease(awsc, to.Strp("bucket"), to.Strp("accountid"), to.Strp("release-1"))
+	assert.Error(t, err)
+}
+
+func Test_Execute_ModifiedRegistration(t *testing.T) {
+	awsc := mocks.MockAWS()
+	r := minimalRelease(t)
+	r.Release.SetDefaults(to.Strp("region"), to.Strp("accountid"), "")
+
+	registration := &models.Registration{RegistrationID: r.ReleaseID, ReleaseSHA256: to.Strp("not-the-sha"), Release: r}
+	assert.NoError(t, s3.PutStruct(awsc.S3, r.Bucket, models.RegistrationPath(r.AwsAccountID, r.ReleaseID), registration))
+
+	_, err := executeRelease(awsc, r.Bucket, r.AwsAccountID, r.ReleaseID)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match its SHA256")
+}
@@ -0,0 +1,290 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/ami"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/is"
+	"github.com/coinbase/step/utils/to"
+)
+
+// ReleaseNotes prints a summary of what a release changed from the release deployed before it, for change tickets
+func ReleaseNotes(step_fn *string, projectName *string, configName *string, releaseID *string) error {
+	if is.EmptyStr(projectName) || is.EmptyStr(configName) || is.EmptyStr(releaseID) {
+		return fmt.Errorf("Usage: odin release-notes <project_name> <config_name> <release_id>")
+	}
+
+	env, err := currentEnvironment(step_fn)
+	if err != nil {
+		return err
+	}
+
+	notes, err := releaseNotes(env.awsc.S3Client(nil, nil, nil), env.awsc.EC2Client(nil, nil, nil), recordsRelease(env, projectName, configName), *releaseID)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		emit(&Event{Type: "release_notes", Notes: notes})
+		return nil
+	}
+
+	fmt.Println(strings.Join(notes, "\n"))
+	return nil
+}
+
+func releaseNotes(s3c aws.S3API, ec2c aws.EC2API, root *models.Release, releaseID string) ([]string, error) {
+	releases, err := loadReleases(s3c, root)
+	if err != nil {
+		return nil, err
+	}
+
+	// The previous release is the last one that succeeded before it
+	var release, previous *models.Release
+	for _, r := range releases {
+		if to.Strs(r.ReleaseID) == releaseID {
+			release = r
+			break
+		}
+
+		if r.Success != nil && *r.Success {
+			previous = r
+		}
+	}
+
+	if release == nil {
+		return nil, fmt.Errorf("Release %v not found in %v", releaseID, *root.RootDir())
+	}
+
+	notes := []string{
+		fmt.Sprintf("Release %v of %v/%v", releaseID, to.Strs(root.ProjectName), to.Strs(root.ConfigName)),
+		fmt.Sprintf("Created: %v", release.CreatedAt.UTC().Format(time.RFC3339)),
+		fmt.Sprintf("Result: %v", releaseResult(release)),
+	}
+
+	if previous != nil {
+		notes = append(notes, fmt.Sprintf("Previous: %v", *previous.ReleaseID))
+	} else {
+		notes = append(notes, "Previous: none, this is the first release")
+		previous = &models.Release{}
+	}
+
+	if len(release.Annotations) > 0 {
+		notes = append(notes, "", "Annotations:")
+		for _, annotation := range release.Annotations {
+			notes = append(notes, fmt.Sprintf("  %v", to.Strs(annotation)))
+		}
+	}
+
+	imageNotes, err := imageNotes(ec2c, previous, release)
+	if err != nil {
+		return nil, err
+	}
+	notes = append(notes, imageNotes...)
+
+	userdataNotes, err := userdataNotes(s3c, previous, release)
+	if err != nil {
+		return nil, err
+	}
+	notes = append(notes, userdataNotes...)
+
+	notes = append(notes, capacityNotes(previous, release)...)
+
+	return notes, nil
+}
+
+func releaseResult(release *models.Release) string {
+	switch {
+	case release.Success != nil && *release.Success:
+		return "succeeded"
+	case release.Error != nil:
+		return fmt.Sprintf("failed %v", to.Strs(release.Error.Error))
+	default:
+		return "not completed"
+	}
+}
+
+// releaseImage returns the AMI ID the releases services launched with, or its ami if it was never resolved
+func releaseImage(release *models.Release) *string {
+	names := []string{}
+	for name := range release.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if s := release.Services[name]; s != nil && s.Resources != nil && s.Resources.Image != nil {
+			return s.Resources.Image
+		}
+	}
+
+	return release.Image
+}
+
+// imageNotes describes the AMI change with the images name, description and tags, which carry its build info
+func imageNotes(ec2c aws.EC2API, previous *models.Release, release *models.Release) ([]string, error) {
+	before, after := releaseImage(previous), releaseImage(release)
+
+	if to.Strs(before) == to.Strs(after) {
+		return []string{"", fmt.Sprintf("AMI: %v (unchanged)", to.Strs(after))}, nil
+	}
+
+	notes := []string{"", fmt.Sprintf("AMI: %v -> %v", orNone(before), orNone(after))}
+
+	if after == nil {
+		return notes, nil
+	}
+
+	image, err := ami.Describe(ec2c, after)
+	if err != nil {
+		return nil, err
+	}
+
+	if image == nil {
+		return append(notes, "  Image not found, it may have been deregistered"), nil
+	}
+
+	if image.Name != nil {
+		notes = append(notes, fmt.Sprintf("  Name: %v", *image.Name))
+	}
+
+	if image.Description != nil {
+		notes = append(notes, fmt.Sprintf("  Description: %v", *image.Description))
+	}
+
+	if image.CreationDate != nil {
+		notes = append(notes, fmt.Sprintf("  Built: %v", *image.CreationDate))
+	}
+
+	return append(notes, imageTags(image.Tags)...), nil
+}
+
+func imageTags(tags []*ec2.Tag) []string {
+	notes := []string{}
+	for _, tag := range tags {
+		if tag.Key != nil && *tag.Key != "Name" {
+			notes = append(notes, fmt.Sprintf("  %v: %v", *tag.Key, to.Strs(tag.Value)))
+		}
+	}
+	sort.Strings(notes)
+	return notes
+}
+
+// userdataNotes summarizes the lines added to and removed from the userdata
+func userdataNotes(s3c aws.S3API, previous *models.Release, release *models.Release) ([]string, error) {
+	if to.Strs(previous.UserDataSHA256) == to.Strs(release.UserDataSHA256) {
+		return []string{"", "Userdata: unchanged"}, nil
+	}
+
+	after, err := releaseUserData(s3c, release)
+	if err != nil {
+		return nil, err
+	}
+
+	before := []string{}
+	if previous.ReleaseID != nil {
+		if before, err = releaseUserData(s3c, previous); err != nil {
+			return nil, err
+		}
+	}
+
+	added, removed := lineChanges(before, after)
+
+	return []string{"", fmt.Sprintf("Userdata: %v lines added, %v removed (sha256 %v -> %v)",
+		added, removed, orNone(previous.UserDataSHA256), orNone(release.UserDataSHA256))}, nil
+}
+
+func releaseUserData(s3c aws.S3API, release *models.Release) ([]string, error) {
+	if err := release.DownloadUserData(s3c); err != nil {
+		return nil, err
+	}
+	return strings.Split(to.Strs(release.UserData()), "\n"), nil
+}
+
+// lineChanges counts the lines in after but not before, and in before but not after
+func lineChanges(before []string, after []string) (int, int) {
+	counts := map[string]int{}
+	for _, line := range before {
+		counts[line]++
+	}
+
+	added := 0
+	for _, line := range after {
+		if counts[line] > 0 {
+			counts[line]--
+		} else {
+			added++
+		}
+	}
+
+	removed := 0
+	for _, count := range counts {
+		removed += count
+	}
+
+	return added, removed
+}
+
+// capacityNotes lists the services added, removed, or whose instance type or size changed
+func capacityNotes(previous *models.Release, release *models.Release) []string {
+	names := map[string]bool{}
+	for name := range previous.Services {
+		names[name] = true
+	}
+	for name := range release.Services {
+		names[name] = true
+	}
+
+	sorted := []string{}
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	changes := []string{}
+	for _, name := range sorted {
+		before, after := previous.Services[name], release.Services[name]
+
+		switch {
+		case before == nil:
+			changes = append(changes, fmt.Sprintf("  %v: added, %v", name, serviceCapacity(after)))
+		case after == nil:
+			changes = append(changes, fmt.Sprintf("  %v: removed", name))
+		case serviceCapacity(before) != serviceCapacity(after):
+			changes = append(changes, fmt.Sprintf("  %v: %v -> %v", name, serviceCapacity(before), serviceCapacity(after)))
+		}
+	}
+
+	if len(changes) == 0 {
+		return []string{"", "Capacity: unchanged"}
+	}
+
+	return append([]string{"", "Capacity:"}, changes...)
+}
+
+func serviceCapacity(service *models.Service) string {
+	minSize, maxSize := "?", "?"
+	if a := service.Autoscaling; a != nil {
+		if a.MinSize != nil {
+			minSize = fmt.Sprintf("%v", *a.MinSize)
+		}
+		if a.MaxSize != nil {
+			maxSize = fmt.Sprintf("%v", *a.MaxSize)
+		}
+	}
+
+	return fmt.Sprintf("%v min %v max %v", to.Strs(service.InstanceType), minSize, maxSize)
+}
+
+func orNone(s *string) string {
+	if s == nil {
+		return "none"
+	}
+	return *s
+}
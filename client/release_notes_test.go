@@ -0,0 +1,56 @@
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	stepmocks "github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LineChanges(t *testing.T) {
+	added, removed := lineChanges([]string{"a", "b", "b"}, []string{"b", "c", "d"})
+	assert.Equal(t, 2, added)
+	assert.Equal(t, 2, removed)
+}
+
+func Test_ReleaseNotes(t *testing.T) {
+	env := &environment{region: to.Strp("us-east-1"), accountID: to.Strp("000000000000")}
+	s3c := &listingS3{MockS3Client: &stepmocks.MockS3Client{}}
+	// A release that finished is read from its record
+	s3c.add("000000000000/project/config/release-1/release", `{"release_id": "release-1", "created_at": "2018-01-01T00:00:00Z"}`)
+	s3c.add("000000000000/project/config/release-1/record", `{
+		"release_id": "release-1", "created_at": "2018-01-01T00:00:00Z", "success": true, "user_data_sha256": "one",
+		"services": {"web": {"instance_type": "t2.small", "autoscaling": {"min_size": 1, "max_size": 1}, "resources": {"image": "ami-old"}}}
+	}`)
+	s3c.add("000000000000/project/config/release-1/userdata", "#cloud_config\nruncmd:\n  - old")
+	s3c.add("000000000000/project/config/release-2/release", `{
+		"release_id": "release-2", "created_at": "2018-02-01T00:00:00Z", "user_data_sha256": "two",
+		"annotations": ["Upgrade nginx, CHANGE-123"],
+		"services": {
+			"web": {"instance_type": "t2.medium", "autoscaling": {"min_size": 2, "max_size": 4}, "resources": {"image": "ami-new"}},
+			"worker": {"instance_type": "t2.small", "autoscaling": {"min_size": 1, "max_size": 1}}
+		}
+	}`)
+	s3c.add("000000000000/project/config/release-2/userdata", "#cloud_config\nruncmd:\n  - new\n  - newer")
+
+	ec2c := &mocks.EC2Client{}
+	ec2c.AddImage("ubuntu", "ami-new")
+
+	notes, err := releaseNotes(s3c, ec2c, recordsRelease(env, to.Strp("project"), to.Strp("config")), "release-2")
+	assert.NoError(t, err)
+
+	text := strings.Join(notes, "\n")
+	assert.Contains(t, text, "Previous: release-1")
+	assert.Contains(t, text, "Upgrade nginx, CHANGE-123")
+	assert.Contains(t, text, "AMI: ami-old -> ami-new")
+	assert.Contains(t, text, "DeployWith: odin")
+	assert.Contains(t, text, "Userdata: 2 lines added, 1 removed")
+	assert.Contains(t, text, "web: t2.small min 1 max 1 -> t2.medium min 2 max 4")
+	assert.Contains(t, text, "worker: added, t2.small min 1 max 1")
+
+	_, err = releaseNotes(s3c, ec2c, recordsRelease(env, to.Strp("project"), to.Strp("config")), "release-3")
+	assert.Error(t, err)
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/identity"
+	"github.com/coinbase/odin/deployer/models"
+)
+
+// Resume restarts a failed release from its last checkpoint, reusing the ASGs it created
+func Resume(step_fn *string, releaseFile *string, releaseID *string) error {
+	env, err := currentEnvironment(step_fn)
+	if err != nil {
+		return err
+	}
+
+	release, err := releaseFromFile(releaseFile, env)
+	if err != nil {
+		return err
+	}
+
+	release.ReleaseID = releaseID
+
+	checkpoint, err := resumeRelease(env.awsc, release)
+	if err != nil {
+		return err
+	}
+
+	if err := env.preflightBucket(checkpoint, checkpoint.ReleasePath()); err != nil {
+		return err
+	}
+
+	// Prove who is resuming to the deployers RBAC
+	if checkpoint.Identity, err = identity.Presign(env.awsc.STSClient(nil, nil, nil), checkpoint.IdentityBinding()); err != nil {
+		return err
+	}
+
+	auditRelease(checkpoint, nil)
+	env.requestSignature(checkpoint)
+
+	return resume(env.awsc, checkpoint, env.deployerARN)
+}
+
+// resumeRelease loads the releases checkpoint and prepares it to be deployed again
+func resumeRelease(awsc aws.Clients, release *models.Release) (*models.Release, error) {
+	checkpoint, err := release.LoadCheckpoint(releaseS3(awsc, release))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkpoint.PrepareResume(); err != nil {
+		return nil, err
+	}
+
+	return checkpoint, nil
+}
+
+func resume(awsc aws.Clients, release *models.Release, deployerARN *string) error {
+	name := nameExecution(release, deployerARN)
+
+	if err := signRelease(awsc, release); err != nil {
+		return err
+	}
+
+	// The userdata is already uploaded, only the Release is replaced to match SHAs
+	if err := models.PutArtifact(releaseStore(awsc, release), release.ReleasePath(), release.WithoutIdentity()); err != nil {
+		return err
+	}
+
+	exec, err := findOrCreateExec(awsc.SFNClient(nil, nil, nil), deployerARN, release, name)
+	if err != nil {
+		return err
+	}
+
+	printExecution("resumed", exec)
+
+	exec.WaitForExecution(awsc.SFNClient(nil, nil, nil), 1, waiter)
+	printDone()
+	return nil
+}
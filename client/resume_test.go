@@ -0,0 +1,32 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ResumeRelease(t *testing.T) {
+	awsc := mocks.MockAWS()
+	r := minimalRelease(t)
+
+	r.Release.SetDefaults(to.Strp("region"), to.Strp("accountid"), "")
+
+	// Nothing to resume without a checkpoint
+	_, err := resumeRelease(awsc, r)
+	assert.Error(t, err)
+
+	awsc.S3.AddGetObject(*r.CheckpointPath(), `{"release_id": "rr", "checkpoint": "CleanUpSuccess"}`, nil)
+	_, err = resumeRelease(awsc, r)
+	assert.Error(t, err)
+
+	awsc.S3.AddGetObject(*r.CheckpointPath(), `{"release_id": "rr", "checkpoint": "CheckHealthy", "success": false}`, nil)
+	checkpoint, err := resumeRelease(awsc, r)
+	assert.NoError(t, err)
+	assert.True(t, checkpoint.Resuming())
+	assert.Nil(t, checkpoint.Success)
+	assert.Equal(t, models.CheckpointHealthy, *checkpoint.Checkpoint)
+}
@@ -0,0 +1,420 @@
+package client
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/coinbase/odin/aws/identity"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/is"
+	"github.com/coinbase/step/utils/to"
+)
+
+// maxRequestBytes limits request bodies, a release with its userdata is far smaller
+const maxRequestBytes = 10 << 20
+
+// Timeouts of the servers connections, a deploy request uploads the release so writes get longer
+const (
+	serverReadTimeout  = 30 * time.Second
+	serverWriteTimeout = 2 * time.Minute
+	serverIdleTimeout  = 2 * time.Minute
+)
+
+// server serves the deploy, halt and status operations over HTTP so deploy portals and bots do not shell out to odin
+type server struct {
+	env      *environment
+	callers  map[string]*principal // Bearer token to who it authenticates
+	identity func(binding string) (*string, error)
+	log      io.Writer
+}
+
+// principal is the caller a token authenticates and the project configs it may deploy, halt and read
+type principal struct {
+	Name    string
+	Allowed []string // project/config patterns, * matches any project or config
+}
+
+// allows returns whether the principal may operate on the project config
+func (p *principal) allows(projectName *string, configName *string) bool {
+	name := fmt.Sprintf("%v/%v", to.Strs(projectName), to.Strs(configName))
+	for _, pattern := range p.Allowed {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// forbiddenError is a caller operating on a project config it is not allowed
+type forbiddenError struct {
+	message string
+}
+
+func (e *forbiddenError) Error() string {
+	return e.message
+}
+
+// deployRequest is the body of POST /v1/deploy
+type deployRequest struct {
+	Release     json.RawMessage `json:"release"`
+	UserData    *string         `json:"userdata,omitempty"`
+	WaitForLock *int            `json:"wait_for_lock,omitempty"` // Seconds to wait for another release to release the lock
+	GitSHA      *string         `json:"git_sha,omitempty"`       // Commit the caller built the release from, recorded in its audit
+}
+
+// haltRequest is the body of POST /v1/halt
+type haltRequest struct {
+	ProjectName *string `json:"project_name"`
+	ConfigName  *string `json:"config_name"`
+	ServiceName *string `json:"service_name,omitempty"` // Halt only this service, the others continue to deploy
+}
+
+// Serve listens on addr until it fails, authenticating callers with tokens, a comma separated list of name:allowlist=token
+// Without a TLS certificate and key it only listens on loopback addresses, as tokens would be sent in the clear
+func Serve(step_fn *string, addr string, tokens string, tlsCert string, tlsKey string) error {
+	callers, err := parseTokens(tokens)
+	if err != nil {
+		return err
+	}
+
+	if (tlsCert == "") != (tlsKey == "") {
+		return fmt.Errorf("odin server requires both --tls-cert and --tls-key")
+	}
+
+	if tlsCert == "" && !loopback(addr) {
+		return fmt.Errorf("odin server only listens on %v with --tls-cert and --tls-key, or on a loopback address behind a TLS proxy", addr)
+	}
+
+	env, err := currentEnvironment(step_fn)
+	if err != nil {
+		return err
+	}
+
+	s := newServer(env, callers)
+	hs := &http.Server{
+		Addr:         addr,
+		Handler:      s.handler(),
+		ReadTimeout:  serverReadTimeout,
+		WriteTimeout: serverWriteTimeout,
+		IdleTimeout:  serverIdleTimeout,
+	}
+
+	fmt.Printf("odin server listening on %v\n", addr)
+	if tlsCert != "" {
+		return hs.ListenAndServeTLS(tlsCert, tlsKey)
+	}
+	return hs.ListenAndServe()
+}
+
+// loopback returns whether addr only listens on the local host, an empty host listens on every interface
+func loopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func newServer(env *environment, callers map[string]*principal) *server {
+	return &server{
+		env:     env,
+		callers: callers,
+		identity: func(binding string) (*string, error) {
+			// Releases are deployed as the servers role
+			return identity.Presign(env.awsc.STSClient(nil, nil, nil), binding)
+		},
+		log: os.Stdout,
+	}
+}
+
+// parseTokens parses name:allowlist=token pairs, the allowlist is | separated project/config patterns
+// e.g. portal:deploy-test/*|web/production=<token>, the name is logged with each request and recorded in its audit
+func parseTokens(tokens string) (map[string]*principal, error) {
+	callers := map[string]*principal{}
+	for _, pair := range strings.Split(tokens, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("Server tokens must be name:allowlist=token pairs")
+		}
+
+		names := strings.SplitN(parts[0], ":", 2)
+		if len(names) != 2 || names[0] == "" || names[1] == "" {
+			return nil, fmt.Errorf("Server token %v requires an allowlist of project/config patterns, e.g. %v:deploy-test/*", names[0], names[0])
+		}
+
+		caller := &principal{Name: names[0], Allowed: strings.Split(names[1], "|")}
+		for _, pattern := range caller.Allowed {
+			if _, err := path.Match(pattern, ""); err != nil || strings.Count(pattern, "/") != 1 {
+				return nil, fmt.Errorf("Server token %v allowlist pattern %q must be project/config", caller.Name, pattern)
+			}
+		}
+
+		callers[parts[1]] = caller
+	}
+
+	if len(callers) == 0 {
+		return nil, fmt.Errorf("odin server requires ODIN_SERVER_TOKENS, e.g. portal:deploy-test/*=<token>,bot:*/*=<token>")
+	}
+
+	return callers, nil
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/v1/deploy", s.authenticated(http.MethodPost, s.deploy))
+	mux.HandleFunc("/v1/halt", s.authenticated(http.MethodPost, s.halt))
+	mux.HandleFunc("/v1/status", s.authenticated(http.MethodGet, s.status))
+	return mux
+}
+
+// authenticated only calls the operation for requests with the method and a callers bearer token
+func (s *server) authenticated(method string, operation func(r *http.Request, caller *principal) (int, *Event, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		caller, ok := s.caller(r)
+		if !ok {
+			writeEvent(w, http.StatusUnauthorized, errorEvent(fmt.Errorf("Unauthorized")))
+			return
+		}
+
+		if r.Method != method {
+			writeEvent(w, http.StatusMethodNotAllowed, errorEvent(fmt.Errorf("%v requires %v", r.URL.Path, method)))
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestBytes)
+
+		code, event, err := operation(r, caller)
+		if err != nil {
+			code, event = errorStatus(err), errorEvent(err)
+		}
+
+		fmt.Fprintf(s.log, "%v %v %v %v %v\n", time.Now().UTC().Format(time.RFC3339), caller.Name, r.URL.Path, code, to.Strs(event.ExecutionArn))
+		writeEvent(w, code, event)
+	}
+}
+
+// caller returns the principal of the requests bearer token
+func (s *server) caller(r *http.Request) (*principal, bool) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil, false
+	}
+
+	given := []byte(strings.TrimPrefix(header, "Bearer "))
+	for token, caller := range s.callers {
+		if subtle.ConstantTimeCompare(given, []byte(token)) == 1 {
+			return caller, true
+		}
+	}
+
+	return nil, false
+}
+
+// authorize errors if the caller is not allowed the project config
+func authorize(caller *principal, projectName *string, configName *string) error {
+	if !caller.allows(projectName, configName) {
+		return &forbiddenError{fmt.Sprintf("%v is not allowed %v/%v", caller.Name, to.Strs(projectName), to.Strs(configName))}
+	}
+	return nil
+}
+
+// deploy starts the release without waiting for it, callers follow it with status
+func (s *server) deploy(r *http.Request, caller *principal) (int, *Event, error) {
+	var req deployRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return 0, nil, &ValidationError{fmt.Sprintf("Request invalid: %v", err.Error())}
+	}
+
+	// Numbers are kept as written so large integers are not rounded
+	dec := json.NewDecoder(bytes.NewReader(req.Release))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return 0, nil, &ValidationError{fmt.Sprintf("Release invalid: %v", err.Error())}
+	}
+
+	if _, ok := doc.(map[string]interface{}); !ok {
+		return 0, nil, &ValidationError{"Release must be an object"}
+	}
+
+	release, err := releaseFromDocument(doc, newServerInterpolator(s.env.awsc.SSMClient(nil, nil, nil)))
+	if err != nil {
+		return 0, nil, &ValidationError{fmt.Sprintf("Release invalid: %v", err.Error())}
+	}
+
+	if err := authorize(caller, release.ProjectName, release.ConfigName); err != nil {
+		return 0, nil, err
+	}
+
+	// Hooks are shell commands, which callers must not run on the server
+	if release.Hooks != nil {
+		return 0, nil, &ValidationError{"Release hooks are not run by odin server"}
+	}
+
+	userdata := req.UserData
+	if userdata == nil {
+		userdata = to.Strp("")
+	}
+
+	if release, err = completeRelease(release, userdata, s.env); err != nil {
+		return 0, nil, err
+	}
+
+	if err := s.env.preflightBucket(release, release.ReleasePath(), release.UserDataPath()); err != nil {
+		return 0, nil, err
+	}
+
+	if req.WaitForLock != nil && *req.WaitForLock > 0 {
+		release.LockWait = req.WaitForLock
+	}
+
+	if release.Identity, err = s.identity(release.IdentityBinding()); err != nil {
+		return 0, nil, err
+	}
+
+	// The release is presigned as the server, so the audit records which caller asked for it
+	auditRelease(release, nil)
+	release.Audit.GitSHA = req.GitSHA
+	release.Audit.Principal = to.Strp(caller.Name)
+	s.env.requestSignature(release)
+
+	exec, err := startDeploy(s.env.awsc, release, s.env.deployerARN)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusAccepted, &Event{Type: "started", ExecutionArn: exec.ExecutionArn, Status: exec.Status}, nil
+}
+
+// halt halts the current execution of a project config, or only one of its services
+func (s *server) halt(r *http.Request, caller *principal) (int, *Event, error) {
+	var req haltRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return 0, nil, &ValidationError{fmt.Sprintf("Request invalid: %v", err.Error())}
+	}
+
+	release, err := s.projectConfig(caller, req.ProjectName, req.ConfigName)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if !is.EmptyStr(req.ServiceName) {
+		// Service halts are written to the running releases directory
+		if release, err = s.runningRelease(release); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	exec, err := haltExecution(s.env.awsc, release, s.env.deployerARN, req.ServiceName)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	eventType := "halted"
+	if !is.EmptyStr(req.ServiceName) {
+		eventType = "service_halted"
+	}
+
+	return http.StatusOK, &Event{Type: eventType, ExecutionArn: exec.ExecutionArn, Status: exec.Status}, nil
+}
+
+// status returns the state of the current execution of a project config
+func (s *server) status(r *http.Request, caller *principal) (int, *Event, error) {
+	query := r.URL.Query()
+
+	release, err := s.projectConfig(caller, to.Strp(query.Get("project_name")), to.Strp(query.Get("config_name")))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	exec, sd, err := currentState(s.env.awsc, release, s.env.deployerARN)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	event, err := stateEvent(exec, sd)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusOK, event, nil
+}
+
+// projectConfig returns a release of the project config in the servers environment, to find and halt its execution
+func (s *server) projectConfig(caller *principal, projectName *string, configName *string) (*models.Release, error) {
+	if is.EmptyStr(projectName) || is.EmptyStr(configName) {
+		return nil, &ValidationError{"project_name and config_name must be given"}
+	}
+
+	if err := authorize(caller, projectName, configName); err != nil {
+		return nil, err
+	}
+
+	release := &models.Release{}
+	release.ProjectName = projectName
+	release.ConfigName = configName
+	release.Bucket = s.env.bucket
+
+	prepareRelease(release, s.env.region, s.env.accountID)
+	release.UseStore(models.StoreOf(s.env.awsc))
+	return release, nil
+}
+
+// runningRelease returns the release of the project configs current execution
+func (s *server) runningRelease(release *models.Release) (*models.Release, error) {
+	_, sd, err := currentState(s.env.awsc, release, s.env.deployerARN)
+	if err != nil {
+		return nil, err
+	}
+
+	var running models.Release
+	if sd.LastOutput == nil || json.Unmarshal([]byte(*sd.LastOutput), &running) != nil || running.ReleaseID == nil {
+		return nil, fmt.Errorf("Cannot read the running release of %v", release.ExecutionPrefix())
+	}
+
+	return &running, nil
+}
+
+// errorStatus is the HTTP status of an operations error
+func errorStatus(err error) int {
+	switch err.(type) {
+	case *ValidationError:
+		return http.StatusBadRequest
+	case *forbiddenError:
+		return http.StatusForbidden
+	case *noExecutionError:
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeEvent(w http.ResponseWriter, code int, event *Event) {
+	event.Time = time.Now().UTC()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(event)
+}
@@ -0,0 +1,232 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func testServer(t *testing.T) (*mocks.MockClients, *httptest.Server) {
+	awsc, s := newTestServer(t)
+	return awsc, httptest.NewServer(s.handler())
+}
+
+func newTestServer(t *testing.T) (*mocks.MockClients, *server) {
+	awsc := mocks.MockAWS()
+	env := &environment{
+		awsc:        awsc,
+		region:      to.Strp("region"),
+		accountID:   to.Strp("accountid"),
+		bucket:      to.Strp("bucket"),
+		deployerARN: to.Strp("arn:aws:states:region:accountid:stateMachine:coinbase-odin"),
+	}
+
+	s := newServer(env, map[string]*principal{
+		"token":   {Name: "portal", Allowed: []string{"project/*"}},
+		"limited": {Name: "bot", Allowed: []string{"other/config"}},
+	})
+	s.identity = func(string) (*string, error) { return to.Strp("https://sts.amazonaws.com/?signed"), nil }
+	s.log = ioutil.Discard
+
+	return awsc, s
+}
+
+func serverRequest(t *testing.T, method string, url string, token string, body string) (int, *Event) {
+	req, err := http.NewRequest(method, url, bytes.NewBufferString(body))
+	assert.NoError(t, err)
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var event Event
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&event))
+	return resp.StatusCode, &event
+}
+
+func Test_Server_Auth(t *testing.T) {
+	_, server := testServer(t)
+	defer server.Close()
+
+	code, event := serverRequest(t, "GET", server.URL+"/v1/status?project_name=project&config_name=config", "", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, "error", event.Type)
+
+	code, _ = serverRequest(t, "GET", server.URL+"/v1/status?project_name=project&config_name=config", "wrong", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, _ = serverRequest(t, "GET", server.URL+"/v1/deploy", "token", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+}
+
+func Test_Server_Allowlist(t *testing.T) {
+	_, server := testServer(t)
+	defer server.Close()
+
+	// Callers can only deploy, halt or read the project configs they are allowed
+	code, event := serverRequest(t, "GET", server.URL+"/v1/status?project_name=project&config_name=config", "limited", "")
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Contains(t, *event.Error, "bot is not allowed project/config")
+
+	code, _ = serverRequest(t, "POST", server.URL+"/v1/halt", "limited", `{"project_name": "project", "config_name": "config"}`)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, _ = serverRequest(t, "POST", server.URL+"/v1/deploy", "limited", `{"release": {"project_name": "project", "config_name": "config"}}`)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// Allowed project configs are only missing their execution
+	code, _ = serverRequest(t, "GET", server.URL+"/v1/status?project_name=other&config_name=config", "limited", "")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func Test_Server_Deploy(t *testing.T) {
+	awsc, s := newTestServer(t)
+
+	// The identity is bound to the project/config/release_id the release is uploaded under
+	var binding string
+	s.identity = func(b string) (*string, error) {
+		binding = b
+		return to.Strp("https://sts.amazonaws.com/?signed"), nil
+	}
+
+	server := httptest.NewServer(s.handler())
+	defer server.Close()
+
+	body := `{
+		"release": {"project_name": "project", "config_name": "config", "ami": "ami-123456", "subnets": ["subnet-1"],
+			"services": {"web": {"instance_type": "t2.small", "security_groups": ["web-sg"]}}},
+		"userdata": "#cloud_config",
+		"wait_for_lock": 60
+	}`
+
+	code, event := serverRequest(t, "POST", server.URL+"/v1/deploy", "token", body)
+	assert.Equal(t, http.StatusAccepted, code)
+	assert.Equal(t, "started", event.Type)
+	assert.NotNil(t, event.ExecutionArn)
+
+	// The audit records which caller the server deployed the release for
+	var uploaded models.Release
+	assert.NoError(t, models.GetArtifact(models.NewS3Store(awsc.S3, to.Strp("bucket")), to.Strp("accountid/"+binding+"/release"), &uploaded))
+	assert.Equal(t, "portal", *uploaded.Audit.Principal)
+}
+
+func Test_Server_Deploy_Invalid(t *testing.T) {
+	_, server := testServer(t)
+	defer server.Close()
+
+	code, event := serverRequest(t, "POST", server.URL+"/v1/deploy", "token", `{"release": {"project_name": "project"}}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, ExitValidation, *event.ExitCode)
+
+	// Callers cannot run commands or read the servers environment
+	code, event = serverRequest(t, "POST", server.URL+"/v1/deploy", "token", `{"release": {"project_name": "project", "config_name": "config", "hooks": {"pre_deploy": ["id"]}}}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, *event.Error, "hooks")
+
+	code, event = serverRequest(t, "POST", server.URL+"/v1/deploy", "token", `{"release": {"project_name": "${env:HOME}", "config_name": "config"}}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, *event.Error, "environment variables")
+
+	code, event = serverRequest(t, "POST", server.URL+"/v1/deploy", "token", `{"release": {"project_name": "${ssm:/secrets/db}", "config_name": "config"}}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, *event.Error, "/odin/")
+}
+
+func Test_Server_Status_Halt(t *testing.T) {
+	awsc, server := testServer(t)
+	defer server.Close()
+
+	code, _ := serverRequest(t, "GET", server.URL+"/v1/status?project_name=project", "token", "")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = serverRequest(t, "GET", server.URL+"/v1/status?project_name=project&config_name=config", "token", "")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	r := minimalRelease(t)
+	r.Release.SetDefaults(to.Strp("region"), to.Strp("accountid"), "")
+
+	awsc.SFN.ListExecutionsResp = &sfn.ListExecutionsOutput{
+		Executions: []*sfn.ExecutionListItem{
+			&sfn.ExecutionListItem{
+				Name:         r.ExecutionName(),
+				ExecutionArn: to.Strp("arn"),
+				StartDate:    to.Timep(time.Now()),
+			},
+		},
+	}
+
+	code, event := serverRequest(t, "GET", server.URL+"/v1/status?project_name=project&config_name=config", "token", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "state", event.Type)
+	assert.Equal(t, "arn", *event.ExecutionArn)
+
+	code, event = serverRequest(t, "POST", server.URL+"/v1/halt", "token", `{"project_name": "project", "config_name": "config"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "halted", event.Type)
+}
+
+func Test_ParseTokens(t *testing.T) {
+	callers, err := parseTokens("portal:deploy-test/*|web/production=abc, bot:*/*=d=ef")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]*principal{
+		"abc":  {Name: "portal", Allowed: []string{"deploy-test/*", "web/production"}},
+		"d=ef": {Name: "bot", Allowed: []string{"*/*"}},
+	}, callers)
+
+	assert.True(t, callers["abc"].allows(to.Strp("deploy-test"), to.Strp("development")))
+	assert.True(t, callers["abc"].allows(to.Strp("web"), to.Strp("production")))
+	assert.False(t, callers["abc"].allows(to.Strp("web"), to.Strp("development")))
+	assert.True(t, callers["d=ef"].allows(to.Strp("web"), to.Strp("development")))
+
+	_, err = parseTokens("")
+	assert.Error(t, err)
+
+	_, err = parseTokens("abc")
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "abc")
+
+	// Every token requires an allowlist of project/config patterns
+	_, err = parseTokens("portal=abc")
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "abc")
+
+	_, err = parseTokens("portal:deploy-test=abc")
+	assert.Error(t, err)
+
+	_, err = parseTokens("portal:[/config=abc")
+	assert.Error(t, err)
+}
+
+func Test_Loopback(t *testing.T) {
+	assert.True(t, loopback("127.0.0.1:8080"))
+	assert.True(t, loopback("localhost:8080"))
+	assert.True(t, loopback("[::1]:8080"))
+
+	assert.False(t, loopback(":8080"))
+	assert.False(t, loopback("0.0.0.0:8080"))
+	assert.False(t, loopback("10.0.0.1:8080"))
+	assert.False(t, loopback("8080"))
+}
+
+func Test_Serve_Requires_TLS(t *testing.T) {
+	err := Serve(nil, ":8080", "portal:*/*=abc", "", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--tls-cert")
+
+	err = Serve(nil, "127.0.0.1:8080", "portal:*/*=abc", "cert.pem", "")
+	assert.Error(t, err)
+}
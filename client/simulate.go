@@ -0,0 +1,47 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coinbase/odin/deployer"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Simulate runs the release through the deployers state machine locally against mocked AWS resources
+// failure injects one of the deployers simulated failures, empty simulates a successful deploy
+func Simulate(releaseFile *string, failure string) error {
+	// Nothing is called in AWS so the region and account only need to be valid
+	env := &environment{region: to.Strp("us-east-1"), accountID: to.Strp("000000000000")}
+
+	release, err := releaseFromFile(releaseFile, env)
+	if err != nil {
+		return err
+	}
+
+	sim, err := deployer.Simulate(release, failure)
+	if err != nil {
+		return err
+	}
+
+	printSimulation(sim)
+	return nil
+}
+
+func printSimulation(sim *deployer.Simulation) {
+	var errMsg *string
+	if sim.Error != nil {
+		errMsg = to.Strp(sim.Error.Error())
+	}
+
+	if jsonOutput {
+		emit(&Event{Type: "simulation", State: strings.Join(sim.Path, ","), Error: errMsg})
+		return
+	}
+
+	fmt.Println(strings.Join(sim.Path, " -> "))
+	fmt.Println(sim.Output)
+	if errMsg != nil {
+		fmt.Printf("Error: %v\n", *errMsg)
+	}
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/coinbase/odin/deployer/stack"
+	"github.com/coinbase/step/utils/is"
+)
+
+// Stack writes the deployer stack as a Terraform module or CDK construct into dir
+func Stack(format *string, dir *string) error {
+	if is.EmptyStr(format) || is.EmptyStr(dir) {
+		return fmt.Errorf("Usage: odin stack [--format terraform|cdk|geo] <dir>")
+	}
+
+	files, err := stack.Default().Generate(*format)
+	if err != nil {
+		return err
+	}
+
+	written, err := writeFiles(*dir, files)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		emit(&Event{Type: "stack", Resources: written})
+		return nil
+	}
+
+	for _, path := range written {
+		fmt.Printf("Wrote %v\n", path)
+	}
+
+	return nil
+}
+
+// writeFiles writes the files by their path relative to dir, returning the written paths in order
+func writeFiles(dir string, files map[string][]byte) ([]string, error) {
+	paths := []string{}
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	written := []string{}
+	for _, path := range paths {
+		full := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			return nil, err
+		}
+
+		if err := ioutil.WriteFile(full, files[path], 0644); err != nil {
+			return nil, err
+		}
+
+		written = append(written, full)
+	}
+
+	return written, nil
+}
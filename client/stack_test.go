@@ -0,0 +1,27 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Stack(t *testing.T) {
+	dir, err := ioutil.TempDir("", "odin-stack")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, Stack(to.Strp("terraform"), &dir))
+
+	_, err = os.Stat(filepath.Join(dir, "odin", "main.tf.json"))
+	assert.NoError(t, err)
+
+	_, err = os.Stat(filepath.Join(dir, "assumed", "main.tf.json"))
+	assert.NoError(t, err)
+
+	assert.Error(t, Stack(to.Strp("pulumi"), &dir))
+}
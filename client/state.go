@@ -0,0 +1,53 @@
+package client
+
+import (
+	"encoding/json"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/execution"
+)
+
+// stateClients load the releases that states pass by reference, set with the current environment
+var stateClients aws.Clients
+
+// lastState caches the last loaded release as executions are polled far more often than they change state
+var lastState struct {
+	sha     string
+	release *models.Release
+}
+
+// outputRelease returns the release a state output, loading it from S3 if the state passed a reference to it
+// If it cannot be loaded the reference is returned, which still has the releases identity, error and health
+func outputRelease(sd *execution.StateDetails) (*models.Release, error) {
+	var release models.Release
+	if sd.LastOutput != nil {
+		if err := json.Unmarshal([]byte(*sd.LastOutput), &release); err != nil {
+			return nil, err
+		}
+	}
+
+	ref := release.StateRef
+	if ref == nil || ref.SHA256 == nil || stateClients == nil {
+		return &release, nil
+	}
+
+	// Errors are written to the reference, so only releases without one are cached
+	if release.Error == nil && lastState.release != nil && lastState.sha == *ref.SHA256 {
+		return lastState.release, nil
+	}
+
+	s3c := models.DecryptingS3(stateClients.S3Client(nil, nil, nil), stateClients.KMSClient(nil, nil, nil))
+	release.UseStore(models.StoreOf(stateClients))
+	hydrated, err := release.Hydrate(s3c)
+	if err != nil {
+		return &release, nil
+	}
+
+	if release.Error == nil {
+		lastState.sha = *ref.SHA256
+		lastState.release = hydrated
+	}
+
+	return hydrated, nil
+}
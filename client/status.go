@@ -0,0 +1,105 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/execution"
+	"github.com/coinbase/step/utils/is"
+)
+
+// Status prints the state of the current execution for the project config
+func Status(step_fn *string, projectName *string, configName *string) error {
+	if is.EmptyStr(projectName) || is.EmptyStr(configName) {
+		return fmt.Errorf("Usage: odin status <project_name> <config_name>")
+	}
+
+	env, err := currentEnvironment(step_fn)
+	if err != nil {
+		return err
+	}
+
+	release := &models.Release{}
+	release.ProjectName = projectName
+	release.ConfigName = configName
+
+	return status(env.awsc, release, env.deployerARN)
+}
+
+// Attach follows a running execution until it completes
+func Attach(step_fn *string, executionArn *string) error {
+	if is.EmptyStr(executionArn) {
+		return fmt.Errorf("Usage: odin attach <execution_arn>")
+	}
+
+	env, err := currentEnvironment(step_fn)
+	if err != nil {
+		return err
+	}
+
+	return attach(env.awsc, executionArn)
+}
+
+func attach(awsc aws.Clients, executionArn *string) error {
+	exec := &execution.Execution{ExecutionArn: executionArn}
+
+	printExecution("attached", exec)
+
+	exec.WaitForExecution(awsc.SFNClient(nil, nil, nil), 1, waiter)
+	printDone()
+	return nil
+}
+
+func status(awsc aws.Clients, release *models.Release, deployerARN *string) error {
+	exec, sd, err := currentState(awsc, release, deployerARN)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		event, err := stateEvent(exec, sd)
+		if err != nil {
+			return err
+		}
+		emit(event)
+		return nil
+	}
+
+	ws, err := waiterStr(exec.Status, sd)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(ws)
+	fmt.Printf("odin attach %v\n", *exec.ExecutionArn)
+	return nil
+}
+
+// currentState returns the current execution of the project config and its last state
+func currentState(awsc aws.Clients, release *models.Release, deployerARN *string) (*execution.Execution, *execution.StateDetails, error) {
+	exec, err := execution.FindExecution(awsc.SFNClient(nil, nil, nil), deployerARN, release.ExecutionPrefix())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if exec == nil {
+		return nil, nil, &noExecutionError{fmt.Sprintf("Cannot find current execution with prefix %q", release.ExecutionPrefix())}
+	}
+
+	sd, err := exec.GetStateDetails(awsc.SFNClient(nil, nil, nil))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return exec, sd, nil
+}
+
+// noExecutionError is a project config without a current execution
+type noExecutionError struct {
+	message string
+}
+
+func (e *noExecutionError) Error() string {
+	return e.message
+}
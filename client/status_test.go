@@ -0,0 +1,43 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Status(t *testing.T) {
+	awsc := mocks.MockAWS()
+	r := minimalRelease(t)
+
+	r.Release.SetDefaults(to.Strp("region"), to.Strp("accountid"), "")
+
+	// No execution
+	assert.Error(t, status(awsc, r, to.Strp("deployerARN")))
+
+	awsc.SFN.ListExecutionsResp = &sfn.ListExecutionsOutput{
+		Executions: []*sfn.ExecutionListItem{
+			&sfn.ExecutionListItem{
+				Name:         r.ExecutionName(),
+				ExecutionArn: to.Strp("arn"),
+				StartDate:    to.Timep(time.Now()),
+			},
+		},
+	}
+
+	assert.NoError(t, status(awsc, r, to.Strp("deployerARN")))
+}
+
+func Test_Attach(t *testing.T) {
+	awsc := mocks.MockAWS()
+	assert.NoError(t, attach(awsc, to.Strp("arn")))
+}
+
+func Test_Status_Usage(t *testing.T) {
+	assert.Error(t, Status(nil, to.Strp("project"), to.Strp("")))
+	assert.Error(t, Attach(nil, nil))
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+var telemetryHTTPClient = &http.Client{Timeout: 2 * time.Second}
+
+// TelemetryConfig opts in to sending anonymous command usage to an endpoint
+type TelemetryConfig struct {
+	Enabled  *bool   `yaml:"enabled,omitempty"`
+	Endpoint *string `yaml:"endpoint,omitempty"`
+}
+
+// telemetryEvent is anonymous, it contains no identities, project names or error messages
+type telemetryEvent struct {
+	Command         string `json:"command"`
+	Version         string `json:"version"`
+	OS              string `json:"os"`
+	Arch            string `json:"arch"`
+	Success         bool   `json:"success"`
+	FailureCategory string `json:"failure_category,omitempty"`
+	DurationMS      int64  `json:"duration_ms"`
+}
+
+func (t *TelemetryConfig) enabled() bool {
+	return t != nil && t.Enabled != nil && *t.Enabled && t.Endpoint != nil
+}
+
+// ReportCommand sends a usage event if telemetry is enabled, it never fails the command
+func ReportCommand(command string, start time.Time, err error) {
+	config, cerr := LoadConfig(ConfigPath())
+	if cerr != nil || !config.Telemetry.enabled() {
+		return
+	}
+
+	reportCommand(*config.Telemetry.Endpoint, newTelemetryEvent(command, start, err))
+}
+
+func newTelemetryEvent(command string, start time.Time, err error) *telemetryEvent {
+	return &telemetryEvent{
+		Command:         command,
+		Version:         Version,
+		OS:              runtime.GOOS,
+		Arch:            runtime.GOARCH,
+		Success:         err == nil,
+		FailureCategory: failureCategory(err),
+		DurationMS:      int64(time.Since(start) / time.Millisecond),
+	}
+}
+
+func reportCommand(endpoint string, event *telemetryEvent) {
+	raw, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	resp, err := telemetryHTTPClient.Post(endpoint, "application/json", bytes.NewReader(raw))
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+}
+
+// failureCategory groups errors without including their messages, AWS errors use their code,
+// failed deploys the stable error code of the deployer, and everything else the exit code of odin
+func failureCategory(err error) string {
+	switch e := err.(type) {
+	case nil:
+		return ""
+	case awserr.Error:
+		return "aws:" + e.Code()
+	case *FailedError:
+		if e.Code != nil {
+			return *e.Code
+		}
+	}
+
+	return fmt.Sprintf("exit:%v", ExitCode(err))
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TelemetryConfig_enabled(t *testing.T) {
+	var config *TelemetryConfig
+	assert.False(t, config.enabled())
+	assert.False(t, (&TelemetryConfig{Enabled: to.Boolp(true)}).enabled())
+	assert.False(t, (&TelemetryConfig{Enabled: to.Boolp(false), Endpoint: to.Strp("url")}).enabled())
+	assert.True(t, (&TelemetryConfig{Enabled: to.Boolp(true), Endpoint: to.Strp("url")}).enabled())
+}
+
+func Test_failureCategory(t *testing.T) {
+	tests := []struct {
+		err      error
+		category string
+	}{
+		{nil, ""},
+		{awserr.New("AccessDenied", "project/config secret", nil), "aws:AccessDenied"},
+		{awserr.New("Throttling", "secret", nil), "aws:Throttling"},
+		{&FailedError{Status: "FAILED", Code: to.Strp(models.ErrorCodeHealthTimeout), Message: "project/config secret", RolledBack: true}, "E_HEALTH_TIMEOUT"},
+		{&FailedError{Status: "FAILED", Code: to.Strp(models.ErrorCodeLock), RolledBack: true}, "E_LOCK"},
+		{&FailedError{Status: "ABORTED", Message: "secret"}, "exit:8"},
+		{&ValidationError{"project/config secret"}, "exit:2"},
+		{fmt.Errorf("project/config secret"), "exit:1"},
+	}
+
+	for _, test := range tests {
+		category := failureCategory(test.err)
+		assert.Equal(t, test.category, category, "%v", test.err)
+		assert.NotContains(t, category, "secret")
+	}
+}
+
+func Test_reportCommand(t *testing.T) {
+	events := make(chan *telemetryEvent, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event telemetryEvent
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		events <- &event
+	}))
+	defer server.Close()
+
+	reportCommand(server.URL, newTelemetryEvent("deploy", time.Now(), fmt.Errorf("error")))
+
+	event := <-events
+	assert.Equal(t, "deploy", event.Command)
+	assert.False(t, event.Success)
+	assert.Equal(t, "exit:1", event.FailureCategory)
+
+	// Unreachable endpoints are ignored
+	reportCommand("http://127.0.0.1:0", newTelemetryEvent("deploy", time.Now(), nil))
+}
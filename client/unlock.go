@@ -0,0 +1,92 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/is"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Unlock shows who holds the project configs lock, with force deleting it if the execution holding it is dead
+func Unlock(step_fn *string, projectName *string, configName *string, force bool) error {
+	if is.EmptyStr(projectName) || is.EmptyStr(configName) {
+		return fmt.Errorf("Usage: odin unlock <project_name> <config_name> [--force]")
+	}
+
+	env, err := currentEnvironment(step_fn)
+	if err != nil {
+		return err
+	}
+
+	return unlock(env.awsc, env.locker(), recordsRelease(env, projectName, configName), force, time.Now())
+}
+
+func unlock(awsc aws.Clients, locker models.Locker, release *models.Release, force bool, now time.Time) error {
+	lock, err := locker.Get(release)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%v/%v", *release.ProjectName, *release.ConfigName)
+
+	if lock == nil {
+		return fmt.Errorf("%v is not locked", name)
+	}
+
+	if err := checkLockDead(awsc, lock, now); err != nil {
+		return err
+	}
+
+	if !force {
+		if !jsonOutput {
+			fmt.Printf("%v is locked by %v, run with --force to delete the lock\n", name, lock)
+		}
+		return nil
+	}
+
+	if err := locker.ForceUnlock(release); err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		emit(&Event{Type: "unlocked", ExecutionArn: lock.ExecutionArn, Lock: lock})
+		return nil
+	}
+
+	fmt.Printf("Deleted lock of %v held by %v\n", name, lock)
+	return nil
+}
+
+// checkLockDead returns an error unless the execution holding the lock has stopped
+// Locks without an execution, grabbed by older deployers, can only be checked by their TTL if they have one
+func checkLockDead(awsc aws.Clients, lock *models.Lock, now time.Time) error {
+	if lock.ExecutionArn == nil {
+		if lock.AcquiredAt != nil && !lock.Expired(now) {
+			return fmt.Errorf("Lock held by %v has not expired", lock)
+		}
+		return nil
+	}
+
+	output, err := awsc.SFNClient(nil, nil, nil).DescribeExecution(&sfn.DescribeExecutionInput{
+		ExecutionArn: lock.ExecutionArn,
+	})
+
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == sfn.ErrCodeExecutionDoesNotExist {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if to.Strs(output.Status) == sfn.ExecutionStatusRunning {
+		return fmt.Errorf("Lock held by running execution %v, halt it instead", *lock.ExecutionArn)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Unlock(t *testing.T) {
+	awsc := mocks.MockAWS()
+	r := minimalRelease(t)
+	r.Release.SetDefaults(to.Strp("region"), to.Strp("accountid"), "")
+	now := time.Now()
+
+	// Not locked
+	assert.Error(t, unlock(awsc, &models.S3Locker{S3: awsc.S3}, r, true, now))
+
+	// A lock within its TTL is not deleted
+	acquired := now.Add(-time.Hour).UTC().Format(time.RFC3339)
+	awsc.S3.AddGetObject(*r.RootDir()+"/lock", `{"uuid": "holder", "release_id": "release-1", "acquired_at": "`+acquired+`", "ttl": 7200}`, nil)
+	assert.Error(t, unlock(awsc, &models.S3Locker{S3: awsc.S3}, r, true, now))
+
+	// Once expired it is
+	assert.NoError(t, unlock(awsc, &models.S3Locker{S3: awsc.S3}, r, true, now.Add(2*time.Hour)))
+
+	// Locks from older deployers without metadata can be deleted
+	awsc.S3.AddGetObject(*r.RootDir()+"/lock", `{"uuid": "holder"}`, nil)
+	assert.NoError(t, unlock(awsc, &models.S3Locker{S3: awsc.S3}, r, false, now))
+	assert.NoError(t, unlock(awsc, &models.S3Locker{S3: awsc.S3}, r, true, now))
+}
@@ -0,0 +1,246 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Version of the client, set at build time with -ldflags "-X github.com/coinbase/odin/client.Version=v1.0.0"
+var Version = "dev"
+
+// UpdatePublicKey is the base64 DER ECDSA P-256 public key checksums.txt is signed with, pinned at build time
+// with -ldflags "-X github.com/coinbase/odin/client.UpdatePublicKey=<key>", self-update refuses to run without it
+var UpdatePublicKey = ""
+
+// semver matches release versions, they are part of the downloads URLs so nothing else is allowed
+var semver = regexp.MustCompile(`^v?(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+var defaultUpdateURL = "https://github.com/coinbase/odin/releases/download"
+
+var updateHTTPClient = &http.Client{Timeout: 60 * time.Second}
+
+// SelfUpdate replaces the running odin binary with version, or the latest version if nil
+// The endpoint has the goreleaser layout <url>/latest, <url>/<version>/checksums.txt and <url>/<version>/odin_<os>_<arch>,
+// with checksums.txt signed in <url>/<version>/checksums.txt.sig
+func SelfUpdate(version *string) error {
+	config, err := LoadConfig(ConfigPath())
+	if err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	updated, err := selfUpdate(config.updateURL(), version, executable)
+	if err != nil {
+		return err
+	}
+
+	if updated == nil {
+		fmt.Printf("odin %v is up to date\n", Version)
+		return nil
+	}
+
+	fmt.Printf("odin updated from %v to %v\n", Version, *updated)
+	return nil
+}
+
+func (c *Config) updateURL() string {
+	if url := os.Getenv("ODIN_UPDATE_URL"); url != "" {
+		return url
+	}
+
+	if c.UpdateURL != nil {
+		return *c.UpdateURL
+	}
+
+	return defaultUpdateURL
+}
+
+// selfUpdate returns the version installed, or nil if already on that version
+func selfUpdate(baseURL string, version *string, executable string) (*string, error) {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	if !strings.HasPrefix(baseURL, "https://") {
+		return nil, fmt.Errorf("Update URL %v must be https", baseURL)
+	}
+
+	publicKey, err := parseUpdatePublicKey(UpdatePublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if version == nil || *version == "" {
+		latest, err := download(fmt.Sprintf("%v/latest", baseURL))
+		if err != nil {
+			return nil, err
+		}
+
+		v := strings.TrimSpace(string(latest))
+		version = &v
+	}
+
+	if !semver.MatchString(*version) {
+		return nil, fmt.Errorf("Version %q is not a semantic version, e.g. v1.2.3", *version)
+	}
+
+	if *version == Version {
+		return nil, nil
+	}
+
+	binaryName := fmt.Sprintf("odin_%v_%v", runtime.GOOS, runtime.GOARCH)
+
+	checksums, err := download(fmt.Sprintf("%v/%v/checksums.txt", baseURL, *version))
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := download(fmt.Sprintf("%v/%v/checksums.txt.sig", baseURL, *version))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyChecksums(publicKey, checksums, signature); err != nil {
+		return nil, fmt.Errorf("Signature of checksums.txt for %v invalid: %v", *version, err.Error())
+	}
+
+	expected, err := findChecksum(checksums, binaryName)
+	if err != nil {
+		return nil, err
+	}
+
+	binary, err := download(fmt.Sprintf("%v/%v/%v", baseURL, *version, binaryName))
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(binary)
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		return nil, fmt.Errorf("Checksum for %v %v incorrect expected %v, got %v", binaryName, *version, expected, actual)
+	}
+
+	if err := replaceExecutable(executable, binary); err != nil {
+		return nil, err
+	}
+
+	return version, nil
+}
+
+func download(url string) ([]byte, error) {
+	resp, err := updateHTTPClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Download %v failed with status %v", url, resp.StatusCode)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// parseUpdatePublicKey parses the pinned key checksums.txt must be signed with
+func parseUpdatePublicKey(raw string) (*ecdsa.PublicKey, error) {
+	if raw == "" {
+		return nil, fmt.Errorf("This odin was built without UpdatePublicKey so cannot verify updates, reinstall it")
+	}
+
+	der, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("UpdatePublicKey invalid %v", err.Error())
+	}
+
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("UpdatePublicKey invalid %v", err.Error())
+	}
+
+	publicKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("UpdatePublicKey must be an ECDSA key")
+	}
+
+	return publicKey, nil
+}
+
+// ecdsaSignature is the ASN.1 form of an ECDSA signature
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// verifyChecksums verifies the base64 ASN.1 ECDSA signature over the SHA256 of checksums.txt,
+// the format of cosign sign-blob and openssl dgst -sha256 -sign piped through base64
+func verifyChecksums(publicKey *ecdsa.PublicKey, checksums []byte, signature []byte) error {
+	der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return err
+	}
+
+	var sig ecdsaSignature
+	if rest, err := asn1.Unmarshal(der, &sig); err != nil || len(rest) > 0 || sig.R == nil || sig.S == nil {
+		return fmt.Errorf("signature is not ASN.1 ECDSA")
+	}
+
+	digest := sha256.Sum256(checksums)
+	if !ecdsa.Verify(publicKey, digest[:], sig.R, sig.S) {
+		return fmt.Errorf("signature does not match UpdatePublicKey")
+	}
+
+	return nil
+}
+
+// findChecksum parses sha256sum output, i.e. lines of "<sha256>  <file>"
+func findChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == name {
+			return fields[0], nil
+		}
+	}
+
+	return "", fmt.Errorf("Checksum for %v not found", name)
+}
+
+// replaceExecutable writes the binary next to the executable then renames it, so a failure never leaves a partial binary
+func replaceExecutable(executable string, binary []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(executable), ".odin-update-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), executable)
+}
@@ -0,0 +1,204 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+// updateServer serves version v2.0.0 over TLS with checksums.txt signed by key
+func updateServer(t *testing.T, key *ecdsa.PrivateKey, binary string, checksum string) *httptest.Server {
+	name := fmt.Sprintf("odin_%v_%v", runtime.GOOS, runtime.GOARCH)
+	checksums := fmt.Sprintf("%v  %v\n", checksum, name)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "v2.0.0")
+	})
+	mux.HandleFunc("/v2.0.0/checksums.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, checksums)
+	})
+	mux.HandleFunc("/v2.0.0/checksums.txt.sig", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, signChecksums(t, key, checksums))
+	})
+	mux.HandleFunc("/v2.0.0/"+name, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, binary)
+	})
+
+	// Updates are only downloaded over https, so trust the servers certificate
+	server := httptest.NewTLSServer(mux)
+	updateHTTPClient = server.Client()
+	return server
+}
+
+// pinUpdateKey generates a key and pins its public key until the returned func is called
+func pinUpdateKey(t *testing.T) (*ecdsa.PrivateKey, func()) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+
+	pinned := UpdatePublicKey
+	UpdatePublicKey = base64.StdEncoding.EncodeToString(der)
+	return key, func() { UpdatePublicKey = pinned }
+}
+
+func signChecksums(t *testing.T, key *ecdsa.PrivateKey, checksums string) string {
+	digest := sha256.Sum256([]byte(checksums))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	assert.NoError(t, err)
+
+	der, err := asn1.Marshal(ecdsaSignature{R: r, S: s})
+	assert.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(der)
+}
+
+func tempExecutable(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "odin")
+	assert.NoError(t, err)
+
+	executable := filepath.Join(dir, "odin")
+	assert.NoError(t, ioutil.WriteFile(executable, []byte("old"), 0755))
+	return executable
+}
+
+func Test_selfUpdate(t *testing.T) {
+	key, unpin := pinUpdateKey(t)
+	defer unpin()
+
+	sum := sha256.Sum256([]byte("new"))
+	server := updateServer(t, key, "new", hex.EncodeToString(sum[:]))
+	defer server.Close()
+
+	executable := tempExecutable(t)
+	defer os.RemoveAll(filepath.Dir(executable))
+
+	version, err := selfUpdate(server.URL, nil, executable)
+	assert.NoError(t, err)
+	assert.Equal(t, "v2.0.0", *version)
+
+	raw, err := ioutil.ReadFile(executable)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", string(raw))
+}
+
+func Test_selfUpdate_PinnedVersion(t *testing.T) {
+	key, unpin := pinUpdateKey(t)
+	defer unpin()
+
+	sum := sha256.Sum256([]byte("new"))
+	server := updateServer(t, key, "new", hex.EncodeToString(sum[:]))
+	defer server.Close()
+
+	executable := tempExecutable(t)
+	defer os.RemoveAll(filepath.Dir(executable))
+
+	_, err := selfUpdate(server.URL, to.Strp("v3.0.0"), executable)
+	assert.Error(t, err)
+
+	current := Version
+	defer func() { Version = current }()
+	Version = "v1.0.0"
+
+	version, err := selfUpdate(server.URL, to.Strp("v1.0.0"), executable)
+	assert.NoError(t, err)
+	assert.Nil(t, version)
+}
+
+func Test_selfUpdate_InvalidVersion(t *testing.T) {
+	key, unpin := pinUpdateKey(t)
+	defer unpin()
+
+	server := updateServer(t, key, "new", "0000")
+	defer server.Close()
+
+	executable := tempExecutable(t)
+	defer os.RemoveAll(filepath.Dir(executable))
+
+	// Versions are part of the URLs, so only semantic versions are downloaded
+	for _, version := range []string{"v2", "../v2.0.0", "v2.0.0/odin", "latest", "dev"} {
+		_, err := selfUpdate(server.URL, to.Strp(version), executable)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "semantic version")
+	}
+}
+
+func Test_selfUpdate_RequiresHTTPSAndKey(t *testing.T) {
+	key, unpin := pinUpdateKey(t)
+	defer unpin()
+
+	executable := tempExecutable(t)
+	defer os.RemoveAll(filepath.Dir(executable))
+
+	_, err := selfUpdate("http://example.com/releases", nil, executable)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "https")
+
+	sum := sha256.Sum256([]byte("new"))
+	server := updateServer(t, key, "new", hex.EncodeToString(sum[:]))
+	defer server.Close()
+
+	UpdatePublicKey = ""
+	_, err = selfUpdate(server.URL, nil, executable)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "UpdatePublicKey")
+}
+
+func Test_selfUpdate_BadSignature(t *testing.T) {
+	_, unpin := pinUpdateKey(t)
+	defer unpin()
+
+	// Signed by a key other than the pinned one
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	sum := sha256.Sum256([]byte("new"))
+	server := updateServer(t, other, "new", hex.EncodeToString(sum[:]))
+	defer server.Close()
+
+	executable := tempExecutable(t)
+	defer os.RemoveAll(filepath.Dir(executable))
+
+	_, err = selfUpdate(server.URL, nil, executable)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Signature")
+
+	raw, err := ioutil.ReadFile(executable)
+	assert.NoError(t, err)
+	assert.Equal(t, "old", string(raw))
+}
+
+func Test_selfUpdate_BadChecksum(t *testing.T) {
+	key, unpin := pinUpdateKey(t)
+	defer unpin()
+
+	server := updateServer(t, key, "new", "0000")
+	defer server.Close()
+
+	executable := tempExecutable(t)
+	defer os.RemoveAll(filepath.Dir(executable))
+
+	_, err := selfUpdate(server.URL, nil, executable)
+	assert.Error(t, err)
+
+	raw, err := ioutil.ReadFile(executable)
+	assert.NoError(t, err)
+	assert.Equal(t, "old", string(raw))
+}
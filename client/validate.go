@@ -0,0 +1,78 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+)
+
+// ValidateFile checks the structure, types and keys of JSON or YAML release files, merged in order,
+// and the configuration the deployer validates without calling AWS
+func ValidateFile(releaseFiles []string) error {
+	if len(releaseFiles) == 0 {
+		return fmt.Errorf("Usage: odin validate [-f <release_file>]... <release_file>")
+	}
+
+	if err := validateFile(releaseFiles...); err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		emit(&Event{Type: "valid", Resources: releaseFiles})
+		return nil
+	}
+
+	fmt.Printf("%v is valid\n", strings.Join(releaseFiles, " + "))
+	return nil
+}
+
+// PrintSchema prints the JSON Schema of release files, e.g. for editors to complete and check them
+func PrintSchema() error {
+	schema, err := to.PrettyJSON(models.ReleaseSchema())
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(schema)
+	return nil
+}
+
+func validateFile(releaseFiles ...string) error {
+	releaseFile := strings.Join(releaseFiles, " + ")
+
+	// References are not resolved, so nothing touches AWS
+	release, err := parseReleaseFiles(releaseFiles, nil)
+	if err != nil {
+		return &ValidationError{fmt.Sprintf("%v invalid: %v", releaseFile, err.Error())}
+	}
+
+	// The userdata is optional here, it is only required to deploy
+	userdata, err := parseUserDataFiles(releaseFiles)
+	if os.IsNotExist(err) {
+		userdata = to.Strp("")
+	} else if err != nil {
+		return err
+	}
+
+	release.SetUserData(userdata)
+
+	// The region, account and bucket can come from the context, so are only placeholders if they are missing
+	prepareRelease(release, to.Strp("us-east-1"), to.Strp("000000000000"))
+	if release.Bucket == nil {
+		release.Bucket = to.Strp("bucket")
+	}
+	release.SetDefaults()
+
+	if err := validateClientAttributes(release); err != nil {
+		return &ValidationError{fmt.Sprintf("%v invalid: %v", releaseFile, err.Error())}
+	}
+
+	if err := release.ValidateConfiguration(); err != nil {
+		return &ValidationError{fmt.Sprintf("%v invalid: %v", releaseFile, err.Error())}
+	}
+
+	return nil
+}
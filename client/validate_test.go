@@ -0,0 +1,62 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeReleaseFile(t *testing.T, dir string, name string, body string) string {
+	file := filepath.Join(dir, name)
+	assert.NoError(t, ioutil.WriteFile(file, []byte(body), 0600))
+	return file
+}
+
+func Test_ValidateFile_YAML(t *testing.T) {
+	dir, err := ioutil.TempDir("", "odin-validate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := writeReleaseFile(t, dir, "release.yaml", `
+project_name: coinbase/null
+config_name: development
+ami: ubuntu
+subnets:
+  tag: odin:tier=private
+services:
+  web:
+    instance_type: t2.nano
+    security_groups: ["ec2::default"]
+`)
+
+	assert.NoError(t, validateFile(file))
+
+	release, err := parseRelease(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "odin:tier=private", *release.SubnetTag.Tag)
+	assert.Equal(t, "t2.nano", *release.Services["web"].InstanceType)
+
+	// Unknown keys are rejected like in JSON
+	file = writeReleaseFile(t, dir, "unknown.yml", `
+project_name: coinbase/null
+config_name: development
+instance_typo: t2.nano
+`)
+
+	err = validateFile(file)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "instance_typo")
+
+	// Types are checked
+	file = writeReleaseFile(t, dir, "types.json", `{"project_name": "coinbase/null", "timeout": "ten"}`)
+	assert.Error(t, validateFile(file))
+
+	// Configuration is checked
+	file = writeReleaseFile(t, dir, "config.json", `{"project_name": "coinbase/null"}`)
+	err = validateFile(file)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ConfigName")
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/execution"
+	"github.com/coinbase/step/utils/to"
+)
+
+// watch is set to render followed executions as a view refreshed in place, instead of a single status line
+var watch *watchView
+
+// maxWatchErrors is the number of recent errors the view shows
+const maxWatchErrors = 5
+
+// SetWatch renders followed executions as a live view, it has no effect with json output
+func SetWatch(enabled bool) {
+	watch = nil
+	if enabled {
+		watch = &watchView{out: os.Stdout, now: time.Now}
+	}
+}
+
+// watchView redraws the deploy's state, instance counts and recent errors each time the execution is polled
+type watchView struct {
+	out       io.Writer
+	now       func() time.Time
+	lines     int      // Lines drawn last time, to be redrawn
+	errors    []string // Errors seen with when, oldest first
+	lastError string
+}
+
+func (w *watchView) render(ed *execution.Execution, sd *execution.StateDetails) error {
+	lines, err := w.frame(ed, sd)
+	if err != nil {
+		return err
+	}
+
+	// Move back to the top of the last frame and clear it
+	if w.lines > 0 {
+		fmt.Fprintf(w.out, "\x1b[%dA\x1b[J", w.lines)
+	}
+
+	for _, line := range lines {
+		fmt.Fprintln(w.out, line)
+	}
+	w.lines = len(lines)
+
+	return nil
+}
+
+// frame returns the lines of the view for the executions latest state
+func (w *watchView) frame(ed *execution.Execution, sd *execution.StateDetails) ([]string, error) {
+	release, err := outputRelease(sd)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []string{
+		fmt.Sprintf("%v %v/%v %v", spinner(), to.Strs(release.ProjectName), to.Strs(release.ConfigName), to.Strs(release.ReleaseID)),
+		fmt.Sprintf("State:    %v (%v)", to.Strs(ed.Status), stateName(sd)),
+	}
+	spinnerCounter++
+
+	if release.CreatedAt != nil {
+		elapsed := w.now().Sub(*release.CreatedAt).Truncate(time.Second)
+		timeout := "unknown"
+		if release.Timeout != nil {
+			timeout = (time.Duration(*release.Timeout) * time.Second).String()
+		}
+		lines = append(lines, fmt.Sprintf("Elapsed:  %v of %v timeout", elapsed, timeout))
+	}
+
+	names := []string{}
+	for name := range release.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(names) > 0 {
+		lines = append(lines, "Services:")
+	}
+
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("  %-16v %v", name, healthCounts(release.Services[name])))
+	}
+
+	if release.Error != nil {
+		e := fmt.Sprintf("%v: %v", to.Strs(release.Error.Error), to.Strs(release.Error.Cause))
+		if code := models.ErrorCode(release.Error); code != nil {
+			e = fmt.Sprintf("%v %v", *code, e)
+		}
+		w.addError(e)
+	}
+
+	if len(w.errors) > 0 {
+		lines = append(lines, "Recent errors:")
+		for _, e := range w.errors {
+			lines = append(lines, "  "+e)
+		}
+	}
+
+	return lines, nil
+}
+
+// healthCounts describes the services instances, those terminating were found unhealthy
+func healthCounts(service *models.Service) string {
+	if service == nil || service.HealthReport == nil {
+		return "waiting"
+	}
+
+	r := service.HealthReport
+	healthy, launching, terminating := intOrZero(r.Healthy), intOrZero(r.Launching), intOrZero(r.Terminating)
+	pending := launching - healthy - terminating
+	if pending < 0 {
+		pending = 0
+	}
+
+	return fmt.Sprintf("pending %v  healthy %v  unhealthy %v  (%v healthy of %v needed)",
+		pending, healthy, terminating, healthy, intOrZero(r.TargetHealthy))
+}
+
+// addError records the error unless it is the last one seen, keeping the most recent
+func (w *watchView) addError(e string) {
+	if e == w.lastError {
+		return
+	}
+	w.lastError = e
+
+	w.errors = append(w.errors, fmt.Sprintf("%v %v", w.now().Format("15:04:05"), e))
+	if len(w.errors) > maxWatchErrors {
+		w.errors = w.errors[len(w.errors)-maxWatchErrors:]
+	}
+}
+
+func intOrZero(i *int) int {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/bifrost"
+	"github.com/coinbase/step/execution"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WatchView(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 3, 12, 0, time.UTC)
+	out := &bytes.Buffer{}
+	w := &watchView{out: out, now: func() time.Time { return now }}
+
+	r := minimalRelease(t)
+	r.CreatedAt = to.Timep(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	r.Timeout = to.Intp(600)
+	r.Services["web"].HealthReport = &models.HealthReport{
+		TargetHealthy:  to.Intp(4),
+		TargetLaunched: to.Intp(5),
+		Healthy:        to.Intp(3),
+		Launching:      to.Intp(5),
+		Terminating:    to.Intp(1),
+	}
+
+	exec := &execution.Execution{Status: to.Strp("RUNNING")}
+	assert.NoError(t, w.render(exec, createStateDetails(r, "WaitForHealthy")))
+
+	frame := out.String()
+	assert.Contains(t, frame, "project/config rr")
+	assert.Contains(t, frame, "State:    RUNNING (WaitForHealthy)")
+	assert.Contains(t, frame, "Elapsed:  3m12s of 10m0s timeout")
+	assert.Contains(t, frame, "pending 1  healthy 3  unhealthy 1  (3 healthy of 4 needed)")
+	assert.NotContains(t, frame, "Recent errors")
+	assert.Equal(t, 5, w.lines)
+
+	// The next frame redraws over the last one, and errors are kept once
+	r.Error = &bifrost.ReleaseError{Error: to.Strp("DeployError"), Cause: to.Strp("cause")}
+	out.Reset()
+	assert.NoError(t, w.render(exec, createStateDetails(r, "CleanUpFailure")))
+	assert.NoError(t, w.render(exec, createStateDetails(r, "CleanUpFailure")))
+
+	assert.True(t, strings.HasPrefix(out.String(), "\x1b[5A\x1b[J"))
+	assert.Equal(t, 1, len(w.errors))
+	assert.Contains(t, w.errors[0], "00:03:12")
+	assert.Contains(t, w.errors[0], "DeployError: cause")
+}
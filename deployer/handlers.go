@@ -2,8 +2,12 @@ package deployer
 
 import (
 	"context"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/identity"
 	"github.com/coinbase/odin/deployer/models"
 	"github.com/coinbase/step/errors"
 	"github.com/coinbase/step/utils/to"
@@ -12,6 +16,16 @@ import (
 // DeployHandler function type
 type DeployHandler func(context.Context, *models.Release) (*models.Release, error)
 
+// LockWaitError is retried by the Lock state while the release waits for another release to release the lock
+type LockWaitError struct {
+	Cause string
+}
+
+// Error returns error
+func (e *LockWaitError) Error() string {
+	return e.Cause
+}
+
 ////////////
 // HANDLERS
 ////////////
@@ -21,16 +35,131 @@ var assumedRole = to.Strp("coinbase-odin-assumed")
 // Validate checks the release for issues
 func Validate(awsc aws.Clients) DeployHandler {
 	return func(ctx context.Context, release *models.Release) (*models.Release, error) {
+		// The identity is only verified here so it is never saved or passed on
+		presigned := release.TakeIdentity()
+
 		// Assign the release its SHA before anything alters it
 		release.ReleaseSHA256 = to.SHA256Struct(release)
+		digest := release.SigningDigest()
 
 		// Default the releases Account and Region to where the Lambda is running
 		region, account := to.AwsRegionAccountFromContext(ctx)
 		release.Release.SetDefaults(region, account, "coinbase-odin-")
 		release.SetDefaults() // Fill in all the blank Attributes
 
-		if err := release.Validate(awsc.S3Client(nil, nil, nil)); err != nil {
-			return nil, &errors.BadReleaseError{err.Error()}
+		// The budget is configured on the Lambda as it depends on the Lambdas timeout
+		budget, err := models.ParseValidationBudget(os.Getenv("ODIN_VALIDATION_BUDGET"))
+		if err != nil {
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+		}
+		timer := release.NewValidationTimer("Validate", budget)
+
+		if err := timer.Time("release", func() error {
+			return release.Validate(releaseS3(awsc, release))
+		}); err != nil {
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+		}
+
+		// The audit trail is written before RBAC and the other checks so rejected releases are recorded too
+		if err := timer.Time("audit", func() error {
+			verifyErr := release.VerifyAudit(presigned, identity.Verify, time.Now())
+			if verifyErr == nil {
+				// A replayed identity must not overwrite the record of the execution it was used for
+				if err := release.ValidateIdentityUnused(awsc.S3Client(nil, nil, nil)); err != nil {
+					return err
+				}
+			}
+			if err := release.WriteAudit(awsc.S3Client(nil, nil, nil)); err != nil {
+				return err
+			}
+			return verifyErr
+		}); err != nil {
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+		}
+
+		// RBAC is configured on the Lambda so it cannot be changed by those deploying
+		rbac, err := models.ParseRBAC(os.Getenv("ODIN_RBAC"))
+		if err != nil {
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+		}
+
+		if err := release.ValidateAuthorization(rbac, awsc.IAMClient(nil, nil, nil), account); err != nil {
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+		}
+
+		// Signing keys are also configured on the Lambda so releases cannot choose who they trust
+		signingKeys := models.ParseSigningKeys(os.Getenv("ODIN_SIGNING_KEYS"))
+		if err := timer.Time("signature", func() error {
+			return release.VerifySignature(awsc.KMSClient(nil, nil, nil), digest, signingKeys)
+		}); err != nil {
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+		}
+
+		// Userdata keys are configured on the Lambda so releases cannot opt out of a customer managed key
+		userdataKeys := models.ParseUserDataKMSKeys(os.Getenv("ODIN_USERDATA_KMS_KEYS"))
+		if len(userdataKeys) > 0 {
+			if err := timer.Time("user_data_kms_key", func() error {
+				role, err := identity.CallerRole(awsc.STSClient(nil, nil, nil))
+				if err != nil {
+					return err
+				}
+				return release.ValidateUserDataKMSKey(awsc.S3Client(nil, nil, nil), awsc.KMSClient(nil, nil, nil), role, userdataKeys)
+			}); err != nil {
+				return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+			}
+		}
+
+		// The circuit breaker is also configured on the Lambda so pipelines cannot turn it off
+		maxFailures, err := models.ParseBreakerFailures(os.Getenv("ODIN_CIRCUIT_BREAKER"))
+		if err != nil {
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+		}
+
+		if maxFailures > 0 {
+			if err := timer.Time("circuit_breaker", func() error {
+				return release.CheckBreaker(awsc.S3Client(nil, nil, nil))
+			}); err != nil {
+				return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+			}
+		}
+
+		// Experimental features are rolled out by allowing them on the Lambda for more project configs
+		features, err := models.ParseFeatureAllowlist(os.Getenv("ODIN_FEATURES"))
+		if err != nil {
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+		}
+
+		if err := release.ValidateFeatures(features); err != nil {
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+		}
+
+		if err := timer.Time("interpolations", func() error {
+			return release.VerifyInterpolations(awsc.SSMClient(nil, nil, nil))
+		}); err != nil {
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+		}
+
+		// Deploy windows are checked before the release takes the lock
+		if err := timer.Time("deploy_windows", func() error {
+			policy, err := models.LoadDeployWindowPolicy(os.Getenv("ODIN_DEPLOY_WINDOWS"), awsc.SSMClient(nil, nil, nil), awsc.SMClient(nil, nil, nil))
+			if err != nil {
+				return err
+			}
+			return release.CheckDeployWindow(policy, time.Now())
+		}); err != nil {
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+		}
+
+		if release.HasPlugins() {
+			if err := timer.Time("plugins", func() error {
+				registry, err := models.LoadPluginRegistry(awsc.SSMClient(nil, nil, nil), awsc.SMClient(nil, nil, nil))
+				if err != nil {
+					return err
+				}
+				return release.ValidatePlugins(registry)
+			}); err != nil {
+				return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+			}
 		}
 
 		return release, nil
@@ -41,7 +170,21 @@ func Validate(awsc aws.Clients) DeployHandler {
 func Lock(awsc aws.Clients) DeployHandler {
 	return func(ctx context.Context, release *models.Release) (*models.Release, error) {
 		release.SetDefaults()
-		return release, release.GrabLock(awsc.S3Client(nil, nil, nil))
+
+		if err := locker(awsc).Grab(release, time.Now()); err != nil {
+			// The Lock state retries with backoff while the release is queued for the lock
+			if release.WaitsForLock(time.Now()) {
+				return release, &LockWaitError{models.ErrorCause(err)}
+			}
+			return release, err
+		}
+
+		// Destroy holds the lock while it tears down, so a tombstone written since Validate is seen once it is grabbed
+		if err := release.ValidateNotDestroyed(awsc.S3Client(nil, nil, nil)); err != nil {
+			return release, &errors.BadReleaseError{models.ErrorCause(err)}
+		}
+
+		return release, nil
 	}
 }
 
@@ -51,26 +194,125 @@ func ValidateResources(awsc aws.Clients) DeployHandler {
 	return func(_ context.Context, release *models.Release) (*models.Release, error) {
 		release.SetDefaults() // Wire up non-serialized relationships
 
-		// Fetch all Resource Objecgs from AWS, i.e. Security Group, ELBs, Albs, IAM Profile
-		resources, err := release.FetchResources(
-			awsc.ASGClient(release.AwsRegion, release.AwsAccountID, assumedRole),
-			awsc.EC2Client(release.AwsRegion, release.AwsAccountID, assumedRole),
-			awsc.ELBClient(release.AwsRegion, release.AwsAccountID, assumedRole),
-			awsc.ALBClient(release.AwsRegion, release.AwsAccountID, assumedRole),
-			awsc.IAMClient(release.AwsRegion, release.AwsAccountID, assumedRole),
-			awsc.SNSClient(release.AwsRegion, release.AwsAccountID, assumedRole),
-		)
+		if err := locker(awsc).Renew(release, time.Now()); err != nil {
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+		}
 
+		budget, err := models.ParseValidationBudget(os.Getenv("ODIN_VALIDATION_BUDGET"))
 		if err != nil {
-			return nil, &errors.BadReleaseError{err.Error()}
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+		}
+		timer := release.NewValidationTimer("ValidateResources", budget)
+
+		// Create the instance profiles from templates so they are found with the other resources
+		if err := timer.Time("template_profiles", func() error {
+			return release.CreateTemplateProfiles(
+				awsc.IAMClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+				awsc.SSMClient(nil, nil, nil),
+				awsc.SMClient(nil, nil, nil),
+			)
+		}); err != nil {
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+		}
+
+		// Old ASGs are found through the deployed release, falling back to their tags if none is recorded
+		if err := timer.Time("previous_release", func() error {
+			return release.LinkPreviousRelease(awsc.S3Client(nil, nil, nil))
+		}); err != nil {
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+		}
+
+		// Fetch all Resource Objecgs from AWS, i.e. Security Group, ELBs, Albs, IAM Profile
+		var resources map[string]*models.ServiceResources
+		if err := timer.Time("fetch_resources", func() error {
+			resources, err = release.FetchResources(
+				awsc.ASGClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+				awsc.EC2Client(release.AwsRegion, release.AwsAccountID, assumedRole),
+				awsc.ELBClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+				awsc.ALBClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+				awsc.IAMClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+				awsc.SNSClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+			)
+			return err
+		}); err != nil {
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
 		}
 
 		if err := release.ValidateResources(resources); err != nil {
-			return nil, &errors.BadReleaseError{err.Error()}
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+		}
+
+		// Security groups are only known once they are fetched, so the org policy is evaluated again
+		if err := timer.Time("org_policy", func() error {
+			return release.ValidateOrgPolicy(awsc.S3Client(nil, nil, nil), resources)
+		}); err != nil {
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+		}
+
+		if err := timer.Time("load_balancer_attributes", func() error {
+			return release.AssertLoadBalancerAttributes(
+				resources,
+				awsc.ELBClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+				awsc.ALBClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+			)
+		}); err != nil {
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+		}
+
+		// Set before the new ASGs attach to the target groups
+		if err := timer.Time("slow_start", func() error {
+			return release.SetSlowStart(resources, awsc.ALBClient(release.AwsRegion, release.AwsAccountID, assumedRole))
+		}); err != nil {
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
 		}
 
 		release.UpdateWithResources(resources)
 
+		// The new ASG registers as whichever mesh virtual node is not getting traffic
+		if err := timer.Time("meshes", func() error {
+			return release.FetchMeshes(
+				awsc.MeshClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+				awsc.SSMClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+			)
+		}); err != nil {
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+		}
+
+		// Verifiers are configured on the Lambda so those deploying cannot skip them
+		if err := timer.Time("verifiers", func() error {
+			verifiers, err := models.ParseVerifiers(
+				os.Getenv("ODIN_VERIFIERS"),
+				awsc.SSMClient(nil, nil, nil),
+				awsc.SMClient(nil, nil, nil),
+				awsc.S3Client(nil, nil, nil),
+				awsc.ECRClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+			)
+			if err != nil {
+				return err
+			}
+			return release.Verify(verifiers)
+		}); err != nil {
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+		}
+
+		// Fail before creating anything that would hit an account limit mid deploy
+		if err := timer.Time("quotas", func() error {
+			return release.ValidateQuotas(
+				awsc.ASGClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+				awsc.EC2Client(release.AwsRegion, release.AwsAccountID, assumedRole),
+				awsc.QuotasClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+			)
+		}); err != nil {
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+		}
+
+		// Nothing has been created yet, so a failing plugin or veto only rejects the release
+		if err := timer.Time("lambda_hooks", func() error {
+			return runHooks(awsc, release, models.LambdaHookPreCreate)
+		}); err != nil {
+			return nil, &errors.BadReleaseError{models.ErrorCause(err)}
+		}
+
 		return release, nil
 	}
 }
@@ -81,20 +323,45 @@ func Deploy(awsc aws.Clients) DeployHandler {
 	return func(_ context.Context, release *models.Release) (*models.Release, error) {
 		// Wire up non-serialized relationships with UserData
 		if err := release.SetDefaultsWithUserData(awsc.S3Client(nil, nil, nil)); err != nil {
-			return nil, &errors.HaltError{err.Error()}
+			return nil, &errors.HaltError{models.ErrorCause(err)}
 		}
 
 		if err := release.IsHalt(awsc.S3Client(nil, nil, nil)); err != nil {
-			return nil, &errors.HaltError{err.Error()}
+			notify(awsc, release, models.NotifyHalted)
+			return nil, &errors.HaltError{models.ErrorCause(err)}
 		}
 
-		if err := release.CreateResources(
+		if err := locker(awsc).Renew(release, time.Now()); err != nil {
+			return nil, &errors.HaltError{models.ErrorCause(err)}
+		}
+
+		release.DeployStartedAt = to.Timep(time.Now())
+		release.StartPhase(models.PhaseCreateResources)
+		notify(awsc, release, models.NotifyStarted)
+
+		if release.Resuming() {
+			// Reuse the ASGs created before the release failed
+			if err := release.ResumeResources(
+				awsc.ASGClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+			); err != nil {
+				return nil, &errors.HaltError{models.ErrorCause(err)}
+			}
+		} else if err := release.CreateResources(
 			awsc.ASGClient(release.AwsRegion, release.AwsAccountID, assumedRole),
 			awsc.CWClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+			awsc.EC2Client(release.AwsRegion, release.AwsAccountID, assumedRole),
 		); err != nil {
-			return nil, &errors.DeployError{err.Error()}
+			return nil, &errors.DeployError{models.ErrorCause(err)}
 		}
 
+		if err := release.CheckPhaseTimeout(); err != nil {
+			return nil, &errors.DeployError{models.ErrorCause(err)}
+		}
+
+		release.StartPhase(models.PhaseLaunch)
+
+		release.SaveCheckpoint(releaseS3(awsc, release), models.CheckpointDeploy) // Cannot be resumed, failures from here are rolled back
+
 		return release, nil
 	}
 }
@@ -104,27 +371,148 @@ func CheckHealthy(awsc aws.Clients) DeployHandler {
 	return func(_ context.Context, release *models.Release) (*models.Release, error) {
 		release.SetDefaults() // Wire up non-serialized relationships
 
+		// A paused release is held before it rolls out more zones, shifts traffic or deletes the old ASGs,
+		// and its timeouts are stopped until it is unpaused
+		if err := release.UpdatePaused(awsc.S3Client(nil, nil, nil), time.Now()); err != nil {
+			return nil, &errors.HealthError{models.ErrorCause(err)}
+		}
+
 		if err := release.IsHalt(awsc.S3Client(nil, nil, nil)); err != nil {
-			return nil, &errors.HaltError{err.Error()}
+			notify(awsc, release, models.NotifyHalted)
+			return nil, &errors.HaltError{models.ErrorCause(err)}
+		}
+
+		// A phase timeout immediately stops checking and fails the deploy
+		if err := release.CheckPhaseTimeout(); err != nil {
+			return nil, &errors.HaltError{models.ErrorCause(err)}
 		}
 
-		err := release.UpdateHealthy(
+		// Another deploy may have stolen the lock if this one stopped renewing it
+		if err := locker(awsc).Renew(release, time.Now()); err != nil {
+			return nil, &errors.HaltError{models.ErrorCause(err)}
+		}
+
+		activity := release.HealthActivity()
+
+		// Halted services have their new ASG deleted while the others continue
+		err := release.HaltServices(
+			awsc.S3Client(nil, nil, nil),
 			awsc.ASGClient(release.AwsRegion, release.AwsAccountID, assumedRole),
-			awsc.ELBClient(release.AwsRegion, release.AwsAccountID, assumedRole),
 			awsc.ALBClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+			awsc.CWClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+			awsc.EC2Client(release.AwsRegion, release.AwsAccountID, assumedRole),
 		)
 
+		if err == nil {
+			err = release.UpdateHealthy(
+				awsc.ASGClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+				awsc.ELBClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+				awsc.ALBClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+				awsc.EC2Client(release.AwsRegion, release.AwsAccountID, assumedRole),
+				awsc.SSMClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+			)
+		}
+
+		// Healthy services rolled out by zone add the next zone before the release is healthy
+		if err == nil && !release.IsPaused() {
+			err = release.RolloutAZs(
+				awsc.ASGClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+				awsc.CWClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+				time.Now(),
+			)
+		}
+
+		// Healthy services behind weighted listener rules shift traffic before the release is healthy
+		if err == nil && !release.IsPaused() {
+			err = release.ShiftTraffic(
+				awsc.ALBClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+				awsc.CWClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+				time.Now(),
+			)
+		}
+
+		// Healthy services in a service mesh dial traffic to their new virtual node, and wait for the proxies to converge, before the release is healthy
+		if err == nil && !release.IsPaused() {
+			err = release.DialMeshes(
+				awsc.MeshClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+				awsc.SSMClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+				awsc.CWClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+				time.Now(),
+			)
+		}
+
+		// Healthy services with metric health checks bake before the release is healthy
+		if err == nil && !release.IsPaused() {
+			err = release.CheckMetrics(
+				awsc.CWClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+				time.Now(),
+			)
+		}
+
+		// Once every deployed service is healthy the services that depend on them are deployed
+		if err == nil && !release.IsPaused() {
+			err = release.DeployNextLevel(
+				awsc.ASGClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+				awsc.CWClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+				awsc.EC2Client(release.AwsRegion, release.AwsAccountID, assumedRole),
+			)
+		}
+
 		if err != nil {
 			switch err.(type) {
 			case *models.HaltError:
 				// This will immediately stop checking and fail the deploy
-				return nil, &errors.HaltError{err.Error()}
+				notify(awsc, release, models.NotifyHalted)
+				return nil, &errors.HaltError{models.ErrorCause(err)}
 			default:
 				// This will retry a few times, as it might just be an AWS issue
-				return nil, &errors.HealthError{err.Error()}
+				return nil, &errors.HealthError{models.ErrorCause(err)}
 			}
 		}
 
+		if release.IsPaused() {
+			release.Healthy = to.Boolp(false)
+		}
+
+		// A healthy release with an approval gate waits for odin approve before it cuts over
+		if *release.Healthy {
+			requested, err := release.CheckApproval(awsc.S3Client(nil, nil, nil), time.Now())
+			if requested {
+				notify(awsc, release, models.NotifyApprovalRequested)
+				if release.Approval.Topic != nil {
+					release.PublishEvent(awsc.SNSClient(nil, nil, nil), release.Approval.Topic, models.NotifyApprovalRequested) // Best effort
+				}
+			}
+
+			if err != nil {
+				switch err.(type) {
+				case *models.HaltError:
+					notify(awsc, release, models.NotifyHalted)
+					return nil, &errors.HaltError{models.ErrorCause(err)}
+				default:
+					return nil, &errors.HealthError{models.ErrorCause(err)}
+				}
+			}
+		}
+
+		release.UpdatePhase()
+
+		// Check again soon after instances change, otherwise back off
+		release.UpdateWaitForHealthy(activity)
+
+		if *release.Healthy {
+			// The new fleet may already be serving traffic through shared load balancers, but a failing plugin
+			// or veto still rolls it back before listener rules are cut over and the old fleet is torn down,
+			// as afterwards there is no fleet to roll back to
+			for _, hook := range []string{models.LambdaHookPostHealthy, models.LambdaHookPreTeardown} {
+				if err := runHooks(awsc, release, hook); err != nil {
+					return nil, &errors.HaltError{models.ErrorCause(err)}
+				}
+			}
+
+			release.SaveCheckpoint(releaseS3(awsc, release), models.CheckpointHealthy)
+		}
+
 		return release, nil
 	}
 }
@@ -134,20 +522,84 @@ func CleanUpSuccess(awsc aws.Clients) DeployHandler {
 	return func(_ context.Context, release *models.Release) (*models.Release, error) {
 		release.SetDefaults() // Wire up non-serialized relationships
 
+		// The drain phase starts when the release is healthy, so this bounds the retries
+		release.StartPhase(models.PhaseDrain)
+		if err := release.CheckPhaseTimeout(); err != nil {
+			return nil, &errors.CleanUpError{models.ErrorCause(err)}
+		}
+
+		// Never delete old ASGs if another deploy holds the lock
+		if err := locker(awsc).Renew(release, time.Now()); err != nil {
+			return nil, &errors.CleanUpError{models.ErrorCause(err)}
+		}
+
+		// The new fleet has launched so its reserved capacity is no longer needed
+		if err := release.ReleaseCapacity(
+			awsc.EC2Client(release.AwsRegion, release.AwsAccountID, assumedRole),
+		); err != nil {
+			return nil, &errors.CleanUpError{models.ErrorCause(err)}
+		}
+
+		// Move shared ALB listener rules to the new target groups before the old ASGs are removed
+		if err := release.Cutover(
+			awsc.ALBClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+		); err != nil {
+			return nil, &errors.CleanUpError{models.ErrorCause(err)}
+		}
+
+		// Instances protected until the release is healthy can now be scaled in
+		if err := release.RemoveScaleInProtection(
+			awsc.ASGClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+		); err != nil {
+			return nil, &errors.CleanUpError{models.ErrorCause(err)}
+		}
+
 		if err := release.SuccessfulTearDown(
 			awsc.ASGClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+			awsc.ELBClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+			awsc.ALBClient(release.AwsRegion, release.AwsAccountID, assumedRole),
 			awsc.CWClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+			awsc.EC2Client(release.AwsRegion, release.AwsAccountID, assumedRole),
 		); err != nil {
-			return nil, &errors.CleanUpError{err.Error()}
+			return nil, &errors.CleanUpError{models.ErrorCause(err)}
 		}
 
-		if err := release.ReleaseLock(awsc.S3Client(nil, nil, nil)); err != nil {
-			return nil, &errors.LockError{err.Error()}
+		// The composition is for capacity analysis so never fails the deploy
+		release.UpdateComposition(
+			awsc.ASGClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+			awsc.EC2Client(release.AwsRegion, release.AwsAccountID, assumedRole),
+		)
+
+		release.Success = to.Boolp(true) // Wait till the end to mark success
+		release.UpdateServiceStatus()    // Record which services were halted
+
+		// Failover and release notes read the record, so it is retried while the lock is still held
+		if err := release.SaveRecord(releaseS3(awsc, release)); err != nil {
+			return nil, &errors.CleanUpError{models.ErrorCause(err)}
+		}
+
+		if err := locker(awsc).Release(release); err != nil {
+			return nil, &errors.LockError{models.ErrorCause(err)}
 		}
 
 		release.RemoveHalt(awsc.S3Client(nil, nil, nil)) // Delete Halt
+		release.Unpause(awsc.S3Client(nil, nil, nil))    // Delete Pause
 
-		release.Success = to.Boolp(true) // Wait till the end to mark success
+		// If this fails the next release links to the one before, whose chain still includes these ASGs
+		release.SaveLinkage(awsc.S3Client(nil, nil, nil))
+
+		release.RecordSuccess(awsc.S3Client(nil, nil, nil)) // Reset the circuit breaker failures
+
+		release.SaveCheckpoint(releaseS3(awsc, release), models.CheckpointCleanUpSuccess) // Cannot be resumed
+
+		// Pruning old releases keeps the bucket from growing, so it never fails the deploy
+		if retention, err := models.ParseRetention(os.Getenv("ODIN_RETENTION")); err == nil && retention != nil {
+			release.PruneReleases(awsc.S3Client(nil, nil, nil), retention, time.Now())
+		}
+
+		notify(awsc, release, models.NotifyHealthy)
+
+		runPlugins(awsc, release, models.PluginPostCleanUp) // The deploy is done so this cannot fail it
 
 		return release, nil
 	}
@@ -159,14 +611,80 @@ func CleanUpFailure(awsc aws.Clients) DeployHandler {
 		release.SetDefaults() // Wire up non-serialized relationships
 
 		release.Success = to.Boolp(false) // Quickly Mark Failure
+		release.SetErrorCode()
+
+		// The releases own ASGs are deleted even if it lost the lock
+		locker(awsc).Renew(release, time.Now())
+
+		// Why the services were unhealthy is gathered before their instances are terminated, once as failed attempts are retried
+		switch to.Strs(release.ErrorCode) {
+		case models.ErrorCodeHealth, models.ErrorCodeHealthTimeout:
+			if release.Diagnostics == nil {
+				release.GatherDiagnostics(
+					awsc.ASGClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+					awsc.ELBClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+					awsc.ALBClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+					awsc.EC2Client(release.AwsRegion, release.AwsAccountID, assumedRole),
+				)
+			}
+		}
+
+		notify(awsc, release, models.NotifyFailed)
+
+		// The release is rolled back whatever the hooks return
+		release.InvokeLambdaHooks(
+			awsc.LambdaClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+			models.LambdaHookOnFailure,
+		)
+
+		// Failed attempts are retried from the same input, so the teardown is timed from the first attempt
+		if err := release.StartTearDown(awsc.S3Client(nil, nil, nil)); err != nil {
+			return nil, &errors.CleanUpError{models.ErrorCause(err)}
+		}
+
+		// Send traffic back to the old ASGs before the new ones are removed
+		if err := release.RollbackTraffic(
+			awsc.ALBClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+		); err != nil {
+			return nil, &errors.CleanUpError{models.ErrorCause(err)}
+		}
+
+		if err := release.RollbackMeshes(
+			awsc.MeshClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+			awsc.SSMClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+		); err != nil {
+			return nil, &errors.CleanUpError{models.ErrorCause(err)}
+		}
+
+		// Cancelled first so a teardown that keeps failing does not keep paying for them
+		if err := release.ReleaseCapacity(
+			awsc.EC2Client(release.AwsRegion, release.AwsAccountID, assumedRole),
+		); err != nil {
+			return nil, &errors.CleanUpError{models.ErrorCause(err)}
+		}
 
 		if err := release.UnsuccessfulTearDown(
 			awsc.ASGClient(release.AwsRegion, release.AwsAccountID, assumedRole),
 			awsc.CWClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+			awsc.EC2Client(release.AwsRegion, release.AwsAccountID, assumedRole),
 		); err != nil {
-			return nil, &errors.CleanUpError{err.Error()}
+			if terr := release.CheckPhaseTimeout(); terr != nil {
+				return nil, &errors.CleanUpError{terr.Error() + ": " + err.Error()}
+			}
+			return nil, &errors.CleanUpError{models.ErrorCause(err)}
+		}
+
+		// Only count the failure once it is cleaned up, as failed attempts are retried
+		if maxFailures, _ := models.ParseBreakerFailures(os.Getenv("ODIN_CIRCUIT_BREAKER")); maxFailures > 0 {
+			release.RecordFailure(awsc.S3Client(nil, nil, nil), maxFailures)
 		}
 
+		release.SaveCheckpoint(releaseS3(awsc, release), models.CheckpointCleanUpFailure) // Cannot be resumed
+
+		notify(awsc, release, models.NotifyRolledBack)
+
+		runPlugins(awsc, release, models.PluginPostCleanUp) // The rollback is done so this cannot fail it
+
 		return release, nil
 	}
 }
@@ -175,13 +693,174 @@ func CleanUpFailure(awsc aws.Clients) DeployHandler {
 func ReleaseLockFailure(awsc aws.Clients) DeployHandler {
 	return func(_ context.Context, release *models.Release) (*models.Release, error) {
 		release.SetDefaults() // Wire up non-serialized relationships
+		release.SetErrorCode()
 
-		if err := release.ReleaseLock(awsc.S3Client(nil, nil, nil)); err != nil {
-			return nil, &errors.LockError{err.Error()}
+		if err := locker(awsc).Release(release); err != nil {
+			return nil, &errors.LockError{models.ErrorCause(err)}
 		}
 
 		release.RemoveHalt(awsc.S3Client(nil, nil, nil)) // Delete Halt
+		release.Unpause(awsc.S3Client(nil, nil, nil))    // Delete Pause
 
 		return release, nil
 	}
 }
+
+// withOffload passes the state the release its input references, and returns a reference to the release it outputs
+// Executions start with the whole release, which Validate checks against the uploaded one, so it is never a reference
+func withOffload(awsc aws.Clients, state string, handler DeployHandler) DeployHandler {
+	return func(ctx context.Context, release *models.Release) (*models.Release, error) {
+		if release == nil {
+			return handler(ctx, release)
+		}
+
+		if state == "Validate" && release.StateRef != nil {
+			return nil, &errors.BadReleaseError{"Release must not have a state_ref"}
+		}
+
+		release.UseStore(models.StoreOf(awsc))
+		hydrated, err := release.Hydrate(models.DecryptingS3(awsc.S3Client(nil, nil, nil), awsc.KMSClient(nil, nil, nil)))
+		if err != nil {
+			return nil, err
+		}
+
+		// The state machine discards the output of states that error
+		out, err := handler(ctx, hydrated)
+		if err != nil || out == nil {
+			return out, err
+		}
+
+		out.UseStore(models.StoreOf(awsc))
+		return out.Offload(releaseS3(awsc, out))
+	}
+}
+
+// withCacheScope caches the describe calls of the releases execution, so the subnets, security groups and
+// load balancers polled by every CheckHealthy are described once in a while instead of once a state
+func withCacheScope(handler DeployHandler) DeployHandler {
+	return func(ctx context.Context, release *models.Release) (*models.Release, error) {
+		if release != nil {
+			aws.CacheScope(to.Strs(release.ReleaseID) + "/" + to.Strs(release.UUID))
+		}
+		return handler(ctx, release)
+	}
+}
+
+// withStateMetrics emits EMF metrics of the handlers duration and AWS calls when running in Lambda,
+// where they are extracted from the logs without calls to CloudWatch that could fail the deploy
+func withStateMetrics(state string, handler DeployHandler) DeployHandler {
+	return func(ctx context.Context, release *models.Release) (*models.Release, error) {
+		aws.ResetCalls()
+		start := time.Now()
+
+		out, err := handler(ctx, release)
+
+		if release != nil && os.Getenv("AWS_LAMBDA_FUNCTION_NAME") != "" {
+			calls, retries := aws.Calls()
+			release.EmitStateMetrics(&models.StateMetrics{
+				State:    state,
+				Duration: time.Since(start),
+				Calls:    calls,
+				Retries:  retries,
+				Failed:   err != nil,
+			})
+		}
+
+		return out, err
+	}
+}
+
+// withDeployLog appends the state to the releases deploy log in S3 so a deploy can be followed without its Lambda logs
+// Releases that failed to validate are not logged, as their path cannot be trusted
+func withDeployLog(awsc aws.Clients, state string, handler DeployHandler) DeployHandler {
+	return func(ctx context.Context, release *models.Release) (*models.Release, error) {
+		start := time.Now()
+
+		out, err := handler(ctx, release)
+
+		if release == nil || (state == "Validate" && err != nil) {
+			return out, err
+		}
+
+		logged := release
+		if out != nil {
+			logged = out
+		}
+
+		if logged.Bucket != nil && logged.AwsAccountID != nil && logged.ReleaseID != nil {
+			logged.AppendLog(awsc.S3Client(nil, nil, nil), logged.NewLogEntry(state, start, err)) // Best effort
+		}
+
+		return out, err
+	}
+}
+
+// notify sends the event to the webhooks in the release and ODIN_NOTIFICATIONS,
+// publishes it to the ODIN_EVENTS_TOPIC SNS topic, records its metrics, and marks healthy deploys in APM tools
+// Notifications are best effort and never fail the deploy
+func notify(awsc aws.Clients, release *models.Release, event string) {
+	release.PutMetrics(awsc.CWClient(nil, nil, nil), os.Getenv("ODIN_STATSD"), event)
+
+	if topic := os.Getenv("ODIN_EVENTS_TOPIC"); topic != "" {
+		release.PublishEvent(awsc.SNSClient(nil, nil, nil), &topic, event)
+	}
+
+	deployerRefs := []*string{}
+	for _, ref := range strings.Split(os.Getenv("ODIN_NOTIFICATIONS"), ",") {
+		if ref = strings.TrimSpace(ref); ref != "" {
+			deployerRefs = append(deployerRefs, to.Strp(ref))
+		}
+	}
+
+	release.Notify(awsc.SSMClient(nil, nil, nil), awsc.SMClient(nil, nil, nil), event, deployerRefs)
+
+	if event == models.NotifyHealthy {
+		release.CreateDeployMarkers(awsc.SSMClient(nil, nil, nil), awsc.SMClient(nil, nil, nil), &models.MarkerKeys{
+			Datadog:  envRef("ODIN_DATADOG_API_KEY"),
+			NewRelic: envRef("ODIN_NEWRELIC_API_KEY"),
+		})
+	}
+}
+
+// releaseS3 returns the S3 client to read and write the release with, which is envelope encrypted if it has a release_kms_key
+func releaseS3(awsc aws.Clients, release *models.Release) aws.S3API {
+	return release.EnvelopeS3(awsc.S3Client(nil, nil, nil), awsc.KMSClient(nil, nil, nil))
+}
+
+// locker returns the DynamoDB locker of ODIN_LOCK_TABLE, or the S3 locker of the releases bucket if it is not set
+func locker(awsc aws.Clients) models.Locker {
+	return models.NewLocker(awsc.S3Client(nil, nil, nil), awsc.DynamoDBClient(nil, nil, nil), envRef("ODIN_LOCK_TABLE"))
+}
+
+// runHooks invokes the plugins of the transitions point, then the releases Lambda hooks through the assumed role
+func runHooks(awsc aws.Clients, release *models.Release, hook string) error {
+	if point := models.HookPluginPoint(hook); point != nil {
+		if err := runPlugins(awsc, release, *point); err != nil {
+			return err
+		}
+	}
+
+	return release.InvokeLambdaHooks(awsc.LambdaClient(release.AwsRegion, release.AwsAccountID, assumedRole), hook)
+}
+
+// runPlugins invokes the plugins the release opted in to at the point, the registry is only read if it has any
+func runPlugins(awsc aws.Clients, release *models.Release, point string) error {
+	if !release.HasPlugins() {
+		return nil
+	}
+
+	registry, err := models.LoadPluginRegistry(awsc.SSMClient(nil, nil, nil), awsc.SMClient(nil, nil, nil))
+	if err != nil {
+		return err
+	}
+
+	return release.RunPlugins(awsc.LambdaClient(nil, nil, nil), registry, point)
+}
+
+// envRef returns the secret reference in the environment variable, or nil if it is not set
+func envRef(name string) *string {
+	if ref := os.Getenv(name); ref != "" {
+		return &ref
+	}
+	return nil
+}
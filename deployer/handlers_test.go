@@ -2,6 +2,7 @@ package deployer
 
 import (
 	"fmt"
+	"os"
 	"testing"
 
 	"github.com/aws/aws-sdk-go/service/autoscaling"
@@ -11,6 +12,19 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// Test that lock rejects a project config destroyed since it was validated
+func Test_Lock_Destroyed(t *testing.T) {
+	release := models.MockRelease(t)
+	models.MockPrepareRelease(release)
+
+	awsc := models.MockAwsClients(release)
+	awsc.S3.AddGetObject(*release.TombstonePath(), `{"destroyed_at": "2019-01-01T00:00:00Z"}`, nil)
+
+	_, err := Lock(awsc)(nil, release)
+	assert.Error(t, err)
+	assert.Regexp(t, "destroyed at 2019-01-01T00:00:00Z", err.Error())
+}
+
 // Test that validate resources fetches the correct resources
 func Test_ValidateResources_FetchesCorrectResources(t *testing.T) {
 	release := models.MockRelease(t)
@@ -109,3 +123,71 @@ func Test_CheckHealthy_Terming(t *testing.T) {
 	_, err := CheckHealthy(awsc)(nil, release)
 	assert.Error(t, err)
 }
+
+// Test that validate resources fails if the profile cannot pull the containers
+func Test_ValidateResources_ContainerPullDenied(t *testing.T) {
+	release := models.MockRelease(t)
+	models.MockPrepareRelease(release)
+	release.Services["web"].Containers = []*string{to.Strp("000000000000.dkr.ecr.us-east-1.amazonaws.com/repo:v1")}
+
+	awsc := models.MockAwsClients(release)
+	_, err := ValidateResources(awsc)(nil, release)
+	assert.NoError(t, err)
+
+	awsc.IAM.DenyAction("ecr:BatchGetImage")
+	_, err = ValidateResources(awsc)(nil, release)
+	assert.Error(t, err)
+}
+
+// Test that validate resources fails if the release would exceed the On-Demand vCPU quota
+func Test_ValidateResources_QuotaExceeded(t *testing.T) {
+	release := models.MockRelease(t)
+	models.MockPrepareRelease(release)
+
+	awsc := models.MockAwsClients(release)
+	_, err := ValidateResources(awsc)(nil, release)
+	assert.NoError(t, err)
+
+	// The release launches one t2.small with 2 vCPUs
+	awsc.Quotas.Quotas = map[string]float64{"L-1216C47A": 100}
+	awsc.EC2.AddInstances("t2.small", 49)
+	_, err = ValidateResources(awsc)(nil, release)
+	assert.NoError(t, err)
+
+	awsc.EC2.AddInstances("t2.small", 1)
+	_, err = ValidateResources(awsc)(nil, release)
+	assert.Error(t, err)
+}
+
+func Test_Notify_PublishesEvent(t *testing.T) {
+	release := models.MockRelease(t)
+	awsc := mocks.MockAWS()
+
+	notify(awsc, release, models.NotifyStarted)
+	assert.Equal(t, 0, len(awsc.SNS.Published))
+
+	os.Setenv("ODIN_EVENTS_TOPIC", "arn:aws:sns:us-east-1:000000000000:odin-events")
+	defer os.Unsetenv("ODIN_EVENTS_TOPIC")
+
+	notify(awsc, release, models.NotifyStarted)
+	assert.Equal(t, 1, len(awsc.SNS.Published))
+}
+
+func Test_ValidateResources_PostValidatePlugin(t *testing.T) {
+	release := models.MockRelease(t)
+	models.MockPrepareRelease(release)
+	release.Plugins = []*string{to.Strp("scanner")}
+
+	awsc := models.MockAwsClients(release)
+	scanner := "arn:aws:lambda:us-east-1:000000000000:function:odin-plugin-scanner"
+	awsc.SSM.AddParameter("/odin/plugins", `{"scanner": {"function_arn": "`+scanner+`", "points": ["post-validate"]}}`)
+
+	_, err := ValidateResources(awsc)(nil, release)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(awsc.Lambda.Invoked))
+	assert.Equal(t, []bool{true}, awsc.Lambda.Deadlines)
+
+	awsc.Lambda.FailFunction(scanner, "rejected")
+	_, err = ValidateResources(awsc)(nil, release)
+	assert.Error(t, err)
+}
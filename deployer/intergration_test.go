@@ -1,10 +1,15 @@
 package deployer
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
 	"testing"
 
+	"github.com/coinbase/odin/aws/envelope"
 	"github.com/coinbase/odin/aws/mocks"
 	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/aws/s3"
 	"github.com/coinbase/step/utils/to"
 	"github.com/stretchr/testify/assert"
 )
@@ -25,6 +30,71 @@ func Test_Successful_Execution_Works_With_Minimal_Release(t *testing.T) {
 	assertSuccessfulExecution(t, release)
 }
 
+func Test_Successful_Execution_Passes_State_References(t *testing.T) {
+	release := models.MockRelease(t)
+	awsc := models.MockAwsClients(release)
+
+	exec, err := createTestStateMachine(t, awsc).Execute(release)
+	assert.NoError(t, err)
+	assert.Equal(t, true, exec.Output["success"])
+
+	// Only the reference is output, the services are in the saved state
+	assert.NotNil(t, exec.Output["state_ref"])
+	assert.Nil(t, exec.Output["services"])
+
+	var final models.Release
+	raw, err := s3.Get(awsc.S3, release.Bucket, release.StatePath())
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(*raw, &final))
+	assert.Equal(t, len(release.Services), len(final.Services))
+	assert.Equal(t, true, *final.Success)
+}
+
+func Test_Successful_Execution_Works_With_Encrypted_Release(t *testing.T) {
+	release := models.MockRelease(t)
+	release.ReleaseKMSKey = to.Strp("alias/odin-releases")
+	awsc := models.MockAwsClients(release)
+
+	// The client uploads the release envelope encrypted
+	assert.NoError(t, s3.PutStruct(release.EnvelopeS3(awsc.S3, awsc.KMS), release.Bucket, release.ReleasePath(), release))
+
+	exec, err := createTestStateMachine(t, awsc).Execute(release)
+	assert.NoError(t, err)
+	assert.Equal(t, true, exec.Output["success"])
+
+	// The deployer stores the final release encrypted too
+	raw, err := s3.Get(awsc.S3, release.Bucket, release.ReleasePath())
+	assert.NoError(t, err)
+	assert.True(t, envelope.IsSealed(*raw))
+}
+
+func Test_Successful_Execution_Works_With_Lock_Table(t *testing.T) {
+	os.Setenv("ODIN_LOCK_TABLE", "odin-locks")
+	defer os.Unsetenv("ODIN_LOCK_TABLE")
+
+	release := models.MockRelease(t)
+	awsc := models.MockAwsClients(release)
+
+	exec, err := createTestStateMachine(t, awsc).Execute(release)
+	assert.NoError(t, err)
+	assert.Equal(t, true, exec.Output["success"])
+
+	// The lock was held in the table not the bucket, and released keeping its fence
+	item := awsc.DynamoDB.Tables["odin-locks"][*release.Bucket+"/"+*release.RootDir()+"/lock"]
+	assert.NotNil(t, item)
+	assert.Nil(t, item["holder_uuid"])
+	assert.Equal(t, "1", *item["fence"].N)
+
+	// The fence was carried in the release passed between states
+	var final models.Release
+	raw, err := s3.Get(awsc.S3, release.Bucket, release.StatePath())
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(*raw, &final))
+	assert.Equal(t, int64(1), *final.LockFence)
+	_, err = s3.Get(awsc.S3, release.Bucket, to.Strp(*release.RootDir()+"/lock"))
+	assert.Error(t, err)
+}
+
 ///////////////
 // Unsuccessful Tests
 ///////////////
@@ -49,6 +119,20 @@ func Test_UnsuccessfulDeploy_Bad_Userdata_SHA(t *testing.T) {
 	}, exec.Path())
 }
 
+func Test_UnsuccessfulDeploy_Plaintext_Encrypted_Release(t *testing.T) {
+	release := models.MockMinimalRelease(t)
+	release.ReleaseKMSKey = to.Strp("alias/odin-releases")
+
+	// MockAwsClients uploads the release in plaintext
+	exec, err := createTestStateMachine(t, models.MockAwsClients(release)).Execute(release)
+
+	assert.Error(t, err)
+	assert.Equal(t, []string{
+		"Validate",
+		"FailureClean",
+	}, exec.Path())
+}
+
 func Test_UnsuccessfulDeploy_Execution_Works(t *testing.T) {
 	release := models.MockRelease(t)
 	release.Timeout = to.Intp(-10) // This will cause immediate timeout
@@ -213,6 +297,13 @@ func Test_Execution_CheckHealthy_Never_Healthy_ELB(t *testing.T) {
 
 	assert.Regexp(t, "Timeout", exec.LastOutputJSON)
 	assert.Regexp(t, "success\": false", exec.LastOutputJSON)
+
+	// Why the instance was unhealthy was gathered before it was terminated
+	var final models.Release
+	raw, err := s3.Get(maws.S3, release.Bucket, release.StatePath())
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(*raw, &final))
+	assert.Equal(t, "InstanceId1", *final.Diagnostics["web"].Instances[0].InstanceID)
 }
 
 func Test_Execution_CheckHealthy_Never_Healthy_TG(t *testing.T) {
@@ -247,3 +338,117 @@ func Test_Execution_CheckHealthy_Never_Healthy_TG(t *testing.T) {
 	assert.Regexp(t, "Timeout", exec.LastOutputJSON)
 	assert.Regexp(t, "success\": false", exec.LastOutputJSON)
 }
+
+func Test_Resumed_Execution_Reuses_ASGs(t *testing.T) {
+	release := models.MockRelease(t)
+	release.Resumed = to.Boolp(true)
+	release.Checkpoint = to.Strp(models.CheckpointHealthy)
+	release.Services["web"].CreatedASG = to.Strp("resumed-web-asg")
+
+	awsc := models.MockAwsClients(release)
+	awsc.ASG.AddASG(mocks.MakeMockASG("resumed-web-asg", *release.ProjectName, *release.ConfigName, "web", *release.ReleaseID))
+	awsc.ASG.CreateAutoScalingGroupError = fmt.Errorf("ASG must be reused")
+
+	stateMachine := createTestStateMachine(t, awsc)
+
+	exec, err := stateMachine.Execute(release)
+	assert.NoError(t, err)
+	assert.Equal(t, true, exec.Output["success"])
+
+	assert.Equal(t, []string{
+		"Validate",
+		"Lock",
+		"ValidateResources",
+		"Deploy",
+		"WaitForDeploy",
+		"WaitForHealthy",
+		"CheckHealthy",
+		"Healthy?",
+		"CleanUpSuccess",
+		"Success",
+	}, exec.Path())
+}
+
+func Test_Failed_Execution_Is_Resumed(t *testing.T) {
+	release := models.MockRelease(t)
+	awsc := models.MockAwsClients(release)
+	previous := fmt.Sprintf("%v-%v-web-old-release", *release.ProjectName, *release.ConfigName)
+
+	// Deleting the old ASG fails once the release is healthy, so it ends dirty with its new ASG kept
+	awsc.ASG.DeleteAutoScalingGroupError = fmt.Errorf("DeleteAutoScalingGroup failed")
+
+	exec, err := createTestStateMachine(t, awsc).Execute(release)
+	assert.Error(t, err)
+
+	ep := exec.Path()
+	assert.Equal(t, []string{"CleanUpSuccess", "FailureDirty"}, ep[len(ep)-2:])
+
+	// odin resume loads the checkpoint saved once the release was healthy
+	checkpoint, err := release.LoadCheckpoint(awsc.S3)
+	assert.NoError(t, err)
+	assert.NoError(t, checkpoint.PrepareResume())
+
+	created := checkpoint.Services["web"].CreatedASG
+	assert.NotNil(t, created)
+
+	// The mock does not keep the ASGs it creates
+	awsc.ASG.AddASG(mocks.MakeMockASG(*created, *release.ProjectName, *release.ConfigName, "web", *release.ReleaseID))
+	awsc.ASG.CreateAutoScalingGroupError = fmt.Errorf("ASG must be reused")
+	awsc.ASG.DeleteAutoScalingGroupError = nil
+
+	// The failed executions lock expires as it is no longer renewed
+	assert.NoError(t, checkpoint.ForceUnlock(awsc.S3))
+
+	// odin resume uploads the checkpoint as the release
+	raw, err := json.Marshal(checkpoint)
+	assert.NoError(t, err)
+	awsc.S3.AddGetObject(*checkpoint.ReleasePath(), string(raw), nil)
+
+	exec, err = createTestStateMachine(t, awsc).Execute(checkpoint)
+	assert.NoError(t, err)
+	assert.Equal(t, true, exec.Output["success"])
+
+	assert.Contains(t, awsc.ASG.DeletedASGs, previous)
+	assert.NotContains(t, awsc.ASG.DeletedASGs, *created)
+}
+
+func Test_Failed_Before_Healthy_Execution_Is_Not_Resumed(t *testing.T) {
+	release := models.MockRelease(t)
+	awsc := models.MockAwsClients(release)
+
+	// Terminating instances halt the deploy, so the created ASGs are rolled back
+	awsc.ASG.DescribeAutoScalingGroupsPageResp = nil
+	termingASG := mocks.MakeMockASG("odin", *release.ProjectName, *release.ConfigName, "web", "Old release")
+	termingASG.Instances[0].LifecycleState = to.Strp("Terminating")
+	awsc.ASG.AddASG(termingASG)
+
+	exec, err := createTestStateMachine(t, awsc).Execute(release)
+	assert.Error(t, err)
+
+	ep := exec.Path()
+	assert.Equal(t, []string{"CleanUpFailure", "ReleaseLockFailure", "FailureClean"}, ep[len(ep)-3:])
+
+	checkpoint, err := release.LoadCheckpoint(awsc.S3)
+	assert.NoError(t, err)
+	assert.Error(t, checkpoint.PrepareResume())
+}
+
+func Test_Resumed_Execution_Without_ASGs(t *testing.T) {
+	release := models.MockRelease(t)
+	release.Resumed = to.Boolp(true)
+	release.Services["web"].CreatedASG = to.Strp("deleted-web-asg")
+
+	stateMachine := createTestStateMachine(t, models.MockAwsClients(release))
+
+	exec, err := stateMachine.Execute(release)
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{
+		"Validate",
+		"Lock",
+		"ValidateResources",
+		"Deploy",
+		"ReleaseLockFailure",
+		"FailureClean",
+	}, exec.Path())
+}
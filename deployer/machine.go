@@ -8,6 +8,9 @@ import (
 )
 
 // StateMachine returns
+// Each state handles every service of the release rather than fanning out a Map state per service:
+// the step machine the deployer is executed and tested with has no Map state, and the lock,
+// SHA validation and clean up are of the whole release so its services succeed or fail together
 func StateMachine() (*machine.StateMachine, error) {
 	stateMachine, err := machine.FromJSON([]byte(`{
     "Comment": "ASG Deployer",
@@ -32,10 +35,17 @@ func StateMachine() (*machine.StateMachine, error) {
         "Resource": "arn:aws:lambda:{{aws_region}}:{{aws_account}}:function:{{lambda_name}}",
         "Comment": "Grab Lock",
         "Next": "ValidateResources",
+        "Retry": [{
+          "Comment": "Queued for the lock, try again until lock_wait has passed",
+          "ErrorEquals": ["LockWaitError"],
+          "MaxAttempts": 40,
+          "IntervalSeconds": 10,
+          "BackoffRate": 1.1
+        }],
         "Catch": [
           {
             "Comment": "Bad Input, straight to Failure Clean",
-            "ErrorEquals": ["LockExistsError"],
+            "ErrorEquals": ["LockExistsError", "LockWaitError"],
             "ResultPath": "$.error",
             "Next": "FailureClean"
           },
@@ -212,14 +222,20 @@ func TaskHandlers() *handler.TaskHandlers {
 
 // CreateTaskFunctinons returns
 func CreateTaskFunctinons(awsc aws.Clients) *handler.TaskHandlers {
+	// Each state is passed a reference to the release, caches describe calls for its execution,
+	// emits its metrics and is appended to the deploy log
+	wrap := func(state string, h DeployHandler) DeployHandler {
+		return withOffload(awsc, state, withCacheScope(withStateMetrics(state, withDeployLog(awsc, state, h))))
+	}
+
 	tm := handler.TaskHandlers{}
-	tm["Validate"] = Validate(awsc)
-	tm["Lock"] = Lock(awsc)
-	tm["ValidateResources"] = ValidateResources(awsc)
-	tm["Deploy"] = Deploy(awsc)
-	tm["CheckHealthy"] = CheckHealthy(awsc)
-	tm["CleanUpSuccess"] = CleanUpSuccess(awsc)
-	tm["CleanUpFailure"] = CleanUpFailure(awsc)
-	tm["ReleaseLockFailure"] = ReleaseLockFailure(awsc)
+	tm["Validate"] = wrap("Validate", Validate(awsc))
+	tm["Lock"] = wrap("Lock", Lock(awsc))
+	tm["ValidateResources"] = wrap("ValidateResources", ValidateResources(awsc))
+	tm["Deploy"] = wrap("Deploy", Deploy(awsc))
+	tm["CheckHealthy"] = wrap("CheckHealthy", CheckHealthy(awsc))
+	tm["CleanUpSuccess"] = wrap("CleanUpSuccess", CleanUpSuccess(awsc))
+	tm["CleanUpFailure"] = wrap("CleanUpFailure", CleanUpFailure(awsc))
+	tm["ReleaseLockFailure"] = wrap("ReleaseLockFailure", ReleaseLockFailure(awsc))
 	return &tm
 }
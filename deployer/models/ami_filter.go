@@ -0,0 +1,41 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/ami"
+)
+
+// ValidateAMIFilter validates the release selects its AMI either by ami or ami_filter
+func (release *Release) ValidateAMIFilter() error {
+	if release.AMIFilter == nil {
+		return nil
+	}
+
+	if release.Image != nil {
+		return fmt.Errorf("Only one of ami and ami_filter can be defined")
+	}
+
+	if _, _, err := ami.ParseFilter(*release.AMIFilter); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ResolveImage sets the releases ami to the latest AMI matching its ami_filter,
+// so the release records the exact AMI it deployed
+func (release *Release) ResolveImage(ec2c aws.EC2API) error {
+	if release.AMIFilter == nil {
+		return nil
+	}
+
+	im, err := ami.Latest(ec2c, *release.AMIFilter)
+	if err != nil {
+		return err
+	}
+
+	release.Image = im.ImageID
+	return nil
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_ResolveImage(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	// Both ami and ami_filter are not allowed
+	release.AMIFilter = to.Strp("name=web-*")
+	assert.Error(t, release.ValidateConfiguration())
+
+	release.Image = nil
+	assert.NoError(t, release.ValidateConfiguration())
+
+	release.AMIFilter = to.Strp("owner=111111111111")
+	assert.Error(t, release.ValidateConfiguration())
+
+	release.AMIFilter = to.Strp("name=web-*,tag:baked-by=imagepipeline")
+
+	ec2c := &mocks.EC2Client{}
+	ec2c.AddImage("web", "ami-654321")
+	ec2c.DescribeImagesResp.Resp.Images[0].CreationDate = to.Strp("2020-01-01T00:00:00.000Z")
+
+	assert.NoError(t, release.ResolveImage(ec2c))
+	assert.Equal(t, "ami-654321", *release.Image)
+}
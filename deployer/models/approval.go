@@ -0,0 +1,124 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// The deployer can only publish to odin- topics
+var approvalTopicArn = regexp.MustCompile(`^arn:aws:sns:[a-z0-9-]+:[0-9]+:odin-[a-zA-Z0-9_-]+$`)
+
+// ApprovalConfig holds a healthy release before it cuts over and deletes the old ASGs, until it is approved
+type ApprovalConfig struct {
+	Timeout *int    `json:"timeout,omitempty"` // Seconds to wait for approval before the release is rolled back
+	Topic   *string `json:"topic,omitempty"`   // SNS topic the approval request is also published to
+}
+
+// ApprovalRecord is written by the client to approve or reject a release
+type ApprovalRecord struct {
+	Approved   bool       `json:"approved"`
+	Reason     *string    `json:"reason,omitempty"`
+	ApprovedAt *time.Time `json:"approved_at,omitempty"`
+}
+
+// SetDefaults assigns default values
+func (approval *ApprovalConfig) SetDefaults() {
+	if approval.Timeout == nil {
+		approval.Timeout = to.Intp(3600)
+	}
+}
+
+// ValidateAttributes validates attributes
+func (approval *ApprovalConfig) ValidateAttributes() error {
+	if approval.Timeout == nil || *approval.Timeout <= 0 {
+		return fmt.Errorf("Approval timeout must be greater than 0")
+	}
+
+	if approval.Topic != nil && !approvalTopicArn.MatchString(*approval.Topic) {
+		return fmt.Errorf("Approval topic %q must be the ARN of an odin- SNS topic", *approval.Topic)
+	}
+
+	return nil
+}
+
+// ValidateApproval validates the approval, which must time out before the release does
+func (release *Release) ValidateApproval() error {
+	if release.Approval == nil {
+		return nil
+	}
+
+	if err := release.Approval.ValidateAttributes(); err != nil {
+		return err
+	}
+
+	if release.Timeout != nil && *release.Approval.Timeout >= *release.Timeout {
+		return fmt.Errorf("Approval timeout must be less than the release timeout")
+	}
+
+	return nil
+}
+
+func (release *Release) approvalPath() *string {
+	s := fmt.Sprintf("%v/approval", *release.ReleaseDir())
+	return &s
+}
+
+// Approve approves or rejects the release, a rejected release is rolled back
+func (release *Release) Approve(s3c aws.S3API, approved bool, reason *string) error {
+	return PutArtifact(release.Store(s3c), release.approvalPath(), &ApprovalRecord{
+		Approved:   approved,
+		Reason:     reason,
+		ApprovedAt: to.Timep(time.Now()),
+	})
+}
+
+// IsApproved returns whether the release can cut over, releases without an approval gate always can
+func (release *Release) IsApproved() bool {
+	return release.Approval == nil || (release.Approved != nil && *release.Approved)
+}
+
+// CheckApproval holds the healthy release until it is approved, returning whether approval was just requested
+// It errors if the release was rejected, or not approved within the timeout
+func (release *Release) CheckApproval(s3c aws.S3API, now time.Time) (bool, error) {
+	if release.IsApproved() {
+		return false, nil
+	}
+
+	requested := false
+	if release.ApprovalRequestedAt == nil {
+		release.ApprovalRequestedAt = to.Timep(now)
+		requested = true
+	}
+
+	raw, err := release.Store(s3c).Get(release.approvalPath())
+	if IsNotFound(err) {
+		timeout := time.Duration(*release.Approval.Timeout) * time.Second
+		if now.Sub(*release.ApprovalRequestedAt) > timeout {
+			return requested, &HaltError{fmt.Errorf("%v not approved within %v", release.ErrorPrefix(), timeout)}
+		}
+
+		release.Healthy = to.Boolp(false)
+		return requested, nil
+	}
+
+	if err != nil {
+		return requested, err
+	}
+
+	var record ApprovalRecord
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return requested, fmt.Errorf("Approval invalid %v", err.Error())
+	}
+
+	if !record.Approved {
+		return requested, &HaltError{fmt.Errorf("%v rejected: %v", release.ErrorPrefix(), to.Strs(record.Reason))}
+	}
+
+	release.Approved = to.Boolp(true)
+	return requested, nil
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_ValidateApproval(t *testing.T) {
+	release := MockRelease(t)
+	assert.NoError(t, release.ValidateApproval())
+
+	release.Timeout = to.Intp(7200)
+	release.Approval = &ApprovalConfig{}
+	release.Approval.SetDefaults()
+	assert.NoError(t, release.ValidateApproval())
+
+	release.Approval.Topic = to.Strp("arn:aws:sns:us-east-1:000000000000:odin-approvals")
+	assert.NoError(t, release.ValidateApproval())
+
+	release.Approval.Topic = to.Strp("arn:aws:sns:us-east-1:000000000000:approvals")
+	assert.Error(t, release.ValidateApproval())
+
+	release.Timeout = to.Intp(600)
+	release.Approval = &ApprovalConfig{Timeout: to.Intp(600)}
+	assert.Error(t, release.ValidateApproval())
+}
+
+func Test_Release_CheckApproval(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	awsc := MockAwsClients(release)
+
+	// Releases without an approval gate are approved
+	requested, err := release.CheckApproval(awsc.S3, time.Now())
+	assert.NoError(t, err)
+	assert.False(t, requested)
+
+	release.Approval = &ApprovalConfig{Timeout: to.Intp(60)}
+	release.Healthy = to.Boolp(true)
+
+	now := time.Now()
+	requested, err = release.CheckApproval(awsc.S3, now)
+	assert.NoError(t, err)
+	assert.True(t, requested)
+	assert.False(t, *release.Healthy)
+
+	// Approval is only requested once
+	release.Healthy = to.Boolp(true)
+	requested, err = release.CheckApproval(awsc.S3, now.Add(30*time.Second))
+	assert.NoError(t, err)
+	assert.False(t, requested)
+	assert.False(t, *release.Healthy)
+
+	assert.NoError(t, release.Approve(awsc.S3, true, to.Strp("lgtm")))
+
+	release.Healthy = to.Boolp(true)
+	_, err = release.CheckApproval(awsc.S3, now.Add(40*time.Second))
+	assert.NoError(t, err)
+	assert.True(t, *release.Healthy)
+	assert.True(t, release.IsApproved())
+}
+
+func Test_Release_CheckApproval_TimeoutAndReject(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	awsc := MockAwsClients(release)
+
+	release.Approval = &ApprovalConfig{Timeout: to.Intp(60)}
+	release.ApprovalRequestedAt = to.Timep(time.Now())
+
+	_, err := release.CheckApproval(awsc.S3, time.Now().Add(2*time.Minute))
+	assert.IsType(t, &HaltError{}, err)
+	assert.Contains(t, err.Error(), "not approved within")
+
+	assert.NoError(t, release.Approve(awsc.S3, false, to.Strp("wrong ami")))
+
+	_, err = release.CheckApproval(awsc.S3, time.Now())
+	assert.IsType(t, &HaltError{}, err)
+	assert.Contains(t, err.Error(), "rejected: wrong ami")
+}
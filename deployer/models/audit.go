@@ -0,0 +1,125 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/identity"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Audit records who deployed the release and from where
+// The client reports where it ran, the deployer sets the caller from the releases verified Identity
+type Audit struct {
+	ClientVersion *string `json:"client_version,omitempty"`
+	Hostname      *string `json:"hostname,omitempty"`
+	GitSHA        *string `json:"git_sha,omitempty"`   // Commit of the release file, suffixed -dirty if it had uncommitted changes
+	Principal     *string `json:"principal,omitempty"` // Caller of odin server the release was deployed for, as it is presigned by the server
+
+	CallerARN   *string    `json:"caller_arn,omitempty"`
+	Account     *string    `json:"account,omitempty"`
+	SessionName *string    `json:"session_name,omitempty"`
+	VerifiedAt  *time.Time `json:"verified_at,omitempty"`
+
+	IdentitySHA256 *string `json:"identity_sha256,omitempty"` // Of the identity verified, so it can only be used once
+}
+
+// AuditRecord is the audit trail of a release written to S3 by the deployer
+type AuditRecord struct {
+	ProjectName  *string `json:"project_name"`
+	ConfigName   *string `json:"config_name"`
+	ReleaseID    *string `json:"release_id"`
+	ExecutionArn *string `json:"execution_arn,omitempty"`
+	*Audit
+}
+
+// AuditPath returns the path of the releases audit record
+func (release *Release) AuditPath() *string {
+	s := fmt.Sprintf("%v/audit.json", *release.ReleaseDir())
+	return &s
+}
+
+// IdentityBinding is what the releases identity is bound to, so it only proves who created this release
+func (release *Release) IdentityBinding() string {
+	return fmt.Sprintf("%v/%v/%v", to.Strs(release.ProjectName), to.Strs(release.ConfigName), to.Strs(release.ReleaseID))
+}
+
+// TakeIdentity removes the identity from the release and returns it
+// It is only verified while validating, so is never saved with the release or passed to later states and plugins
+func (release *Release) TakeIdentity() *string {
+	presigned := release.Identity
+	release.Identity = nil
+	return presigned
+}
+
+// WithoutIdentity returns a copy of the release without its identity, which is only sent in the executions input
+func (release *Release) WithoutIdentity() *Release {
+	copied := *release
+	copied.Identity = nil
+	return &copied
+}
+
+// VerifyAudit sets the releases caller from its presigned identity, verified with verify for the releases binding
+// Releases without an identity, e.g. from older clients, only have what the client reported
+func (release *Release) VerifyAudit(presigned *string, verify func(*string, string) (*string, error), now time.Time) error {
+	if release.Audit == nil {
+		release.Audit = &Audit{}
+	}
+
+	// Only the deployer records the caller
+	audit := release.Audit
+	audit.CallerARN, audit.Account, audit.SessionName, audit.VerifiedAt, audit.IdentitySHA256 = nil, nil, nil, nil, nil
+
+	if presigned == nil {
+		return nil
+	}
+
+	arn, err := verify(presigned, release.IdentityBinding())
+	if err != nil {
+		return fmt.Errorf("Identity verification failed %v", err.Error())
+	}
+
+	audit.CallerARN = arn
+	audit.Account = identity.Account(arn)
+	audit.SessionName = identity.SessionName(arn)
+	audit.VerifiedAt = to.Timep(now)
+	audit.IdentitySHA256 = to.Strp(to.SHA256Str(presigned))
+
+	return nil
+}
+
+// ValidateIdentityUnused returns an error if the releases audit record was already verified with the same identity,
+// as it is being replayed within its expiry
+func (release *Release) ValidateIdentityUnused(s3c aws.S3API) error {
+	if release.Audit == nil || release.Audit.IdentitySHA256 == nil {
+		return nil
+	}
+
+	var record AuditRecord
+	err := GetArtifact(release.Store(s3c), release.AuditPath(), &record)
+	if IsNotFound(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if record.Audit != nil && to.Strs(record.IdentitySHA256) == *release.Audit.IdentitySHA256 {
+		return fmt.Errorf("Identity was already used by execution %v", to.Strs(record.ExecutionArn))
+	}
+
+	return nil
+}
+
+// WriteAudit persists the releases audit record next to the release
+func (release *Release) WriteAudit(s3c aws.S3API) error {
+	return PutArtifact(release.Store(s3c), release.AuditPath(), &AuditRecord{
+		ProjectName:  release.ProjectName,
+		ConfigName:   release.ConfigName,
+		ReleaseID:    release.ReleaseID,
+		ExecutionArn: release.ExecutionArn,
+		Audit:        release.Audit,
+	})
+}
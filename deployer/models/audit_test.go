@@ -0,0 +1,73 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_VerifyAudit(t *testing.T) {
+	release := MockRelease(t)
+	awsc := MockAwsClients(release)
+	MockPrepareRelease(release)
+
+	var binding string
+	verified := func(_ *string, b string) (*string, error) {
+		binding = b
+		return to.Strp("arn:aws:sts::000000000000:assumed-role/deployer/alice"), nil
+	}
+
+	// The client cannot claim to be someone else
+	release.Audit = &Audit{ClientVersion: to.Strp("v1.2.3"), CallerARN: to.Strp("arn:aws:iam::000000000000:user/bob")}
+	assert.NoError(t, release.VerifyAudit(nil, verified, time.Now()))
+	assert.Nil(t, release.Audit.CallerARN)
+	assert.Equal(t, "v1.2.3", *release.Audit.ClientVersion)
+
+	presigned := to.Strp("https://sts.amazonaws.com/?Action=GetCallerIdentity")
+	assert.NoError(t, release.VerifyAudit(presigned, verified, time.Now()))
+	assert.Equal(t, release.IdentityBinding(), binding)
+	assert.Equal(t, "arn:aws:sts::000000000000:assumed-role/deployer/alice", *release.Audit.CallerARN)
+	assert.Equal(t, "000000000000", *release.Audit.Account)
+	assert.Equal(t, "alice", *release.Audit.SessionName)
+	assert.NotNil(t, release.Audit.VerifiedAt)
+
+	assert.NoError(t, release.ValidateIdentityUnused(awsc.S3))
+	assert.NoError(t, release.WriteAudit(awsc.S3))
+
+	raw, err := s3.Get(awsc.S3, release.Bucket, release.AuditPath())
+	assert.NoError(t, err)
+
+	var record AuditRecord
+	assert.NoError(t, json.Unmarshal(*raw, &record))
+	assert.Equal(t, "project", *record.ProjectName)
+	assert.Equal(t, "alice", *record.SessionName)
+
+	// The same identity cannot be replayed
+	assert.NoError(t, release.VerifyAudit(presigned, verified, time.Now()))
+	assert.Error(t, release.ValidateIdentityUnused(awsc.S3))
+
+	assert.NoError(t, release.VerifyAudit(to.Strp("https://sts.amazonaws.com/?Action=GetCallerIdentity&X-Amz-Date=1"), verified, time.Now()))
+	assert.NoError(t, release.ValidateIdentityUnused(awsc.S3))
+
+	failed := func(*string, string) (*string, error) { return nil, fmt.Errorf("status 403") }
+	assert.Error(t, release.VerifyAudit(presigned, failed, time.Now()))
+	assert.Nil(t, release.Audit.CallerARN)
+}
+
+func Test_Release_Identity_Not_Persisted(t *testing.T) {
+	release := MockRelease(t)
+	release.Identity = to.Strp("https://sts.amazonaws.com/?Action=GetCallerIdentity")
+	digest := release.SigningDigest()
+
+	assert.Nil(t, release.WithoutIdentity().Identity)
+	assert.NotNil(t, release.Identity)
+
+	assert.Equal(t, "https://sts.amazonaws.com/?Action=GetCallerIdentity", *release.TakeIdentity())
+	assert.Nil(t, release.Identity)
+	assert.Equal(t, *digest, *release.SigningDigest())
+}
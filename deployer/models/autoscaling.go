@@ -3,6 +3,9 @@ package models
 import (
 	"fmt"
 
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/asg"
+	"github.com/coinbase/odin/aws/rebalance"
 	"github.com/coinbase/step/utils/is"
 	"github.com/coinbase/step/utils/to"
 )
@@ -16,6 +19,79 @@ type AutoScalingConfig struct {
 	HealthCheckGracePeriod *int64    `json:"health_check_grace_period,omitempty"`
 	Spread                 *float64  `json:"spread,omitempty"`
 	Policies               []*Policy `json:"policies,omitempty"`
+
+	// ScaleUp launches the new ASG at a fraction of its capacity and steps it up
+	ScaleUp *ScaleUpConfig `json:"scale_up,omitempty"`
+
+	// Override the number of healthy instances derived from spread
+	MinHealthyPercentage     *int64 `json:"min_healthy_percentage,omitempty"`
+	HealthyInstancesRequired *int64 `json:"healthy_instances_required,omitempty"`
+
+	// Protect the new instances from scale in, either always or only until the release is healthy
+	NewInstancesProtectedFromScaleIn *bool `json:"new_instances_protected_from_scale_in,omitempty"`
+	ProtectUntilHealthy              *bool `json:"protect_until_healthy,omitempty"`
+
+	// CapacityRebalance replaces Spot instances at elevated risk of interruption
+	CapacityRebalance *bool `json:"capacity_rebalance,omitempty"`
+}
+
+// RemoveScaleInProtection removes the scale in protection of new ASGs that are only protected until the release is healthy
+func (release *Release) RemoveScaleInProtection(asgc aws.ASGAPI) error {
+	for _, service := range release.Services {
+		protect := service.Autoscaling.ProtectUntilHealthy
+		if protect == nil || !*protect || service.CreatedASG == nil {
+			continue
+		}
+
+		if err := asg.RemoveScaleInProtection(asgc, service.CreatedASG); err != nil {
+			return fmt.Errorf("%v %v", service.errorPrefix(), err.Error())
+		}
+	}
+
+	return nil
+}
+
+// enableCapacityRebalance enables Capacity Rebalancing on the created ASG
+func (service *Service) enableCapacityRebalance(asgc aws.ASGAPI) error {
+	if !service.Autoscaling.capacityRebalance() {
+		return nil
+	}
+
+	if err := rebalance.Enable(asgc, service.CreatedASG); err != nil {
+		return fmt.Errorf("%v %v", service.errorPrefix(), err.Error())
+	}
+
+	return nil
+}
+
+// removeInterrupted removes the instances terminating for a Spot interruption
+// so they do not count toward max_terms, the ASG replaces them
+func (service *Service) removeInterrupted(asgc aws.ASGAPI, all aws.Instances) (aws.Instances, error) {
+	if service.SpotPrice == nil || len(all.TerminatingIDs()) == 0 {
+		return all, nil
+	}
+
+	interrupted, err := asg.InterruptedInstances(asgc, service.CreatedASG)
+	if err != nil {
+		return nil, err
+	}
+
+	for id := range interrupted {
+		delete(all, id)
+	}
+
+	return all, nil
+}
+
+// capacityRebalance returns whether the new ASG has Capacity Rebalancing enabled
+func (a *AutoScalingConfig) capacityRebalance() bool {
+	return a.CapacityRebalance != nil && *a.CapacityRebalance
+}
+
+// protectedFromScaleIn returns whether the new ASGs instances are launched protected from scale in
+func (a *AutoScalingConfig) protectedFromScaleIn() bool {
+	return (a.NewInstancesProtectedFromScaleIn != nil && *a.NewInstancesProtectedFromScaleIn) ||
+		(a.ProtectUntilHealthy != nil && *a.ProtectUntilHealthy)
 }
 
 // MinSizeInt returns min size
@@ -64,6 +140,28 @@ func (a *AutoScalingConfig) ValidateAttributes() error {
 		return fmt.Errorf("Spread must be between 0 and 1")
 	}
 
+	if a.MinHealthyPercentage != nil && a.HealthyInstancesRequired != nil {
+		return fmt.Errorf("Only one of MinHealthyPercentage and HealthyInstancesRequired can be defined")
+	}
+
+	if a.MinHealthyPercentage != nil && (*a.MinHealthyPercentage < 1 || *a.MinHealthyPercentage > 100) {
+		return fmt.Errorf("MinHealthyPercentage must be between 1 and 100")
+	}
+
+	if a.NewInstancesProtectedFromScaleIn != nil && *a.NewInstancesProtectedFromScaleIn && a.ProtectUntilHealthy != nil && *a.ProtectUntilHealthy {
+		return fmt.Errorf("Only one of NewInstancesProtectedFromScaleIn and ProtectUntilHealthy can be true")
+	}
+
+	if a.HealthyInstancesRequired != nil && (*a.HealthyInstancesRequired < 1 || *a.HealthyInstancesRequired > *a.MaxSize) {
+		return fmt.Errorf("HealthyInstancesRequired must be between 1 and MaxSize")
+	}
+
+	if a.ScaleUp != nil {
+		if err := a.ScaleUp.ValidateAttributes(); err != nil {
+			return err
+		}
+	}
+
 	policyNames := []*string{}
 
 	for _, p := range a.Policies {
@@ -120,6 +218,10 @@ func (a *AutoScalingConfig) SetDefaults(serviceID *string, timeout *int) error {
 		))
 	}
 
+	if a.ScaleUp != nil {
+		a.ScaleUp.SetDefaults()
+	}
+
 	for _, p := range a.Policies {
 		if p != nil {
 			p.SetDefaults(serviceID)
@@ -141,7 +243,17 @@ func (a *AutoScalingConfig) TargetCapacity(previousDesiredCapacity *int64) int {
 
 // TargetHealthy returns target healthy
 func (a *AutoScalingConfig) TargetHealthy(previousDesiredCapacity *int64) int {
-	return targetHealthy(a.MinSizeInt(), a.DesiredCapacity(previousDesiredCapacity), *a.Spread)
+	dc := a.DesiredCapacity(previousDesiredCapacity)
+
+	// Never require more instances than are launched
+	switch {
+	case a.HealthyInstancesRequired != nil:
+		return min(int(*a.HealthyInstancesRequired), a.TargetCapacity(previousDesiredCapacity))
+	case a.MinHealthyPercentage != nil:
+		return min(minHealthy(dc, *a.MinHealthyPercentage), a.TargetCapacity(previousDesiredCapacity))
+	}
+
+	return targetHealthy(a.MinSizeInt(), dc, *a.Spread)
 }
 
 // MATH
@@ -158,6 +270,11 @@ func targetHealthy(minSize int, dc int, spread float64) int {
 	return max(minSize, th)
 }
 
+// minHealthy rounds up so at least one instance is always required
+func minHealthy(dc int, percentage int64) int {
+	return max(1, (dc*int(percentage)+99)/100)
+}
+
 func targetCapacity(maxSize int, dc int, spread float64) int {
 	tc := percent(dc, (1 + spread))
 	return min(maxSize, tc)
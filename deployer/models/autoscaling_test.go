@@ -3,6 +3,8 @@ package models
 import (
 	"testing"
 
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/mocks"
 	"github.com/coinbase/step/utils/to"
 	"github.com/stretchr/testify/assert"
 )
@@ -85,3 +87,100 @@ func Test_TargetHealthy(t *testing.T) {
 	assert.Equal(t, 4, asg.TargetHealthy(to.Int64p(8)))
 	assert.Equal(t, 5, asg.TargetHealthy(to.Int64p(10)))
 }
+
+func Test_TargetHealthy_Overrides(t *testing.T) {
+	asg := &AutoScalingConfig{MinSize: to.Int64p(10), MaxSize: to.Int64p(20), Spread: to.Float64p(0), MinHealthyPercentage: to.Int64p(90)}
+	assert.NoError(t, asg.ValidateAttributes())
+	assert.Equal(t, 9, asg.TargetHealthy(nil))
+	assert.Equal(t, 14, asg.TargetHealthy(to.Int64p(15)))
+
+	asg.MinHealthyPercentage = to.Int64p(1)
+	assert.Equal(t, 1, asg.TargetHealthy(nil))
+
+	asg.MinHealthyPercentage = nil
+	asg.HealthyInstancesRequired = to.Int64p(8)
+	assert.NoError(t, asg.ValidateAttributes())
+	assert.Equal(t, 8, asg.TargetHealthy(nil))
+
+	// Bounded by the instances launched
+	asg.HealthyInstancesRequired = to.Int64p(20)
+	assert.Equal(t, 10, asg.TargetHealthy(nil))
+
+	asg.MinHealthyPercentage = to.Int64p(90)
+	assert.Error(t, asg.ValidateAttributes())
+
+	asg.MinHealthyPercentage = nil
+	asg.HealthyInstancesRequired = to.Int64p(21)
+	assert.Error(t, asg.ValidateAttributes())
+
+	asg.HealthyInstancesRequired = nil
+	asg.MinHealthyPercentage = to.Int64p(101)
+	assert.Error(t, asg.ValidateAttributes())
+}
+
+func Test_Autoscaling_ScaleInProtection(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	service := release.Services["web"]
+	assert.Nil(t, service.createInput().NewInstancesProtectedFromScaleIn)
+
+	service.Autoscaling.NewInstancesProtectedFromScaleIn = to.Boolp(true)
+	service.Autoscaling.ProtectUntilHealthy = to.Boolp(true)
+	assert.Error(t, service.Autoscaling.ValidateAttributes())
+
+	service.Autoscaling.NewInstancesProtectedFromScaleIn = nil
+	assert.NoError(t, service.Autoscaling.ValidateAttributes())
+	assert.True(t, *service.createInput().NewInstancesProtectedFromScaleIn)
+
+	service.CreatedASG = service.ServiceID()
+	asgc := &mocks.ASGClient{}
+	asgc.AddASG(mocks.MakeMockASG(*service.CreatedASG, *release.ProjectName, *release.ConfigName, "web", *release.ReleaseID))
+
+	assert.NoError(t, release.RemoveScaleInProtection(asgc))
+	assert.False(t, *asgc.UpdateAutoScalingGroupInputs[0].NewInstancesProtectedFromScaleIn)
+	assert.Equal(t, 1, len(asgc.SetInstanceProtectionInputs))
+	assert.False(t, *asgc.SetInstanceProtectionInputs[0].ProtectedFromScaleIn)
+}
+
+func Test_Autoscaling_CapacityRebalance(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	service := release.Services["web"]
+	service.Autoscaling.CapacityRebalance = to.Boolp(true)
+	assert.Error(t, service.ValidateAttributes())
+
+	service.SpotPrice = to.Strp("0.1")
+	assert.NoError(t, service.ValidateAttributes())
+
+	service.CreatedASG = service.ServiceID()
+	asgc := &mocks.ASGClient{}
+	assert.NoError(t, service.enableCapacityRebalance(asgc))
+	assert.Equal(t, *service.CreatedASG, *asgc.CapacityRebalanceInputs[0].AutoScalingGroupName)
+	assert.True(t, *asgc.CapacityRebalanceInputs[0].CapacityRebalance)
+}
+
+func Test_Autoscaling_SpotInterruptions(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	service := release.Services["web"]
+	service.CreatedASG = service.ServiceID()
+
+	asgc := &mocks.ASGClient{}
+	asgc.AddSpotInterruptionActivity("i-interrupted")
+
+	all := aws.Instances{"i-interrupted": "terminating", "i-healthy": "healthy"}
+
+	// Without a spot price interruptions are failures
+	all, err := service.removeInterrupted(asgc, all)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(all.TerminatingIDs()))
+
+	service.SpotPrice = to.Strp("0.1")
+	all, err = service.removeInterrupted(asgc, all)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(all.TerminatingIDs()))
+	assert.Equal(t, []string{"i-healthy"}, all.HealthyIDs())
+}
@@ -0,0 +1,192 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/alarms"
+	"github.com/coinbase/odin/aws/asg"
+	"github.com/coinbase/step/utils/to"
+)
+
+const azPlaceholder = "{{AVAILABILITY_ZONE}}"
+
+// AZRolloutConfig brings the new ASG up one availability zone at a time
+// Each zone must be healthy and held for the bake period before the next is added,
+// and the deploy halts if any alarm breaches, limiting the blast radius of zonal failures
+type AZRolloutConfig struct {
+	Bake   *int      `json:"bake,omitempty"`   // Seconds each zone is held healthy before the next zone is added
+	Alarms []*string `json:"alarms,omitempty"` // CloudWatch alarm names that roll the deploy back, {{AVAILABILITY_ZONE}} is replaced with each rolled out zone
+
+	// Generated
+	Zones     *int       `json:"zones,omitempty"` // Number of zones rolled out
+	HealthyAt *time.Time `json:"healthy_at,omitempty"`
+}
+
+// SetDefaults assigns default values
+func (a *AZRolloutConfig) SetDefaults() {
+	if a.Bake == nil {
+		a.Bake = to.Intp(300)
+	}
+}
+
+// ValidateAttributes validates attributes
+func (a *AZRolloutConfig) ValidateAttributes() error {
+	if a.Bake == nil || *a.Bake < 0 {
+		return fmt.Errorf("AZRollout bake must be 0 or greater")
+	}
+
+	for _, alarm := range a.Alarms {
+		if alarm == nil || *alarm == "" {
+			return fmt.Errorf("AZRollout alarm names must be defined")
+		}
+	}
+
+	return nil
+}
+
+func (a *AZRolloutConfig) zones() int {
+	if a.Zones == nil {
+		return 1
+	}
+	return *a.Zones
+}
+
+func (a *AZRolloutConfig) baked(now time.Time) bool {
+	return a.HealthyAt != nil && now.Sub(*a.HealthyAt) >= time.Duration(*a.Bake)*time.Second
+}
+
+func (service *Service) validateAZRollout() error {
+	if service.AZRollout == nil {
+		return nil
+	}
+
+	if service.Autoscaling.ScaleUp != nil {
+		return fmt.Errorf("Only one of az_rollout and autoscaling scale_up can be defined")
+	}
+
+	return service.AZRollout.ValidateAttributes()
+}
+
+// rollingOutAZs returns whether the service is brought up by zone, services in a single zone are not
+func (service *Service) rollingOutAZs() bool {
+	return service.AZRollout != nil && service.Resources != nil && len(service.Resources.AvailabilityZones) > 1
+}
+
+// rolledOutAZs returns the zones the new ASG launches into
+func (service *Service) rolledOutAZs() []*string {
+	azs := service.Resources.AvailabilityZones
+	return azs[:min(service.AZRollout.zones(), len(azs))]
+}
+
+// zoneCapacity scales capacity to the zones rolled out, rounding up
+func (service *Service) zoneCapacity(capacity int) int {
+	if !service.rollingOutAZs() {
+		return capacity
+	}
+
+	zones := len(service.Resources.AvailabilityZones)
+	return (capacity*len(service.rolledOutAZs()) + zones - 1) / zones
+}
+
+// rolloutSubnetIds returns the subnets in the rolled out zones
+func (service *Service) rolloutSubnetIds() *string {
+	if !service.rollingOutAZs() {
+		return service.SubnetIds()
+	}
+
+	rolledOut := map[string]bool{}
+	for _, az := range service.rolledOutAZs() {
+		rolledOut[*az] = true
+	}
+
+	subnets := []string{}
+	for _, id := range service.Resources.Subnets {
+		if az := service.Resources.SubnetAvailabilityZones[to.Strs(id)]; az != nil && rolledOut[*az] {
+			subnets = append(subnets, *id)
+		}
+	}
+
+	return to.Strp(strings.Join(subnets, ","))
+}
+
+// azRolloutAlarms returns the alarms of each rolled out zone
+func (service *Service) azRolloutAlarms() []*string {
+	names := []*string{}
+	for _, alarm := range service.AZRollout.Alarms {
+		if !strings.Contains(*alarm, azPlaceholder) {
+			names = append(names, alarm)
+			continue
+		}
+
+		for _, az := range service.rolledOutAZs() {
+			names = append(names, to.Strp(strings.Replace(*alarm, azPlaceholder, *az, -1)))
+		}
+	}
+	return names
+}
+
+// rolloutAZs checks the alarms then adds the next zone once the current zones are healthy and baked
+// The service is only healthy once every zone has been rolled out and baked
+func (service *Service) rolloutAZs(asgc aws.ASGAPI, cwc aws.CWAPI, now time.Time) error {
+	rollout := service.AZRollout
+
+	breached, err := alarms.InAlarm(cwc, service.azRolloutAlarms())
+	if err != nil {
+		return err // This might retry
+	}
+
+	if len(breached) > 0 {
+		azs := service.rolledOutAZs()
+		err := fmt.Errorf("%v Alarms breached while rolling out %v %v", service.errorPrefix(), to.Strs(azs[len(azs)-1]), strings.Join(breached, ","))
+		return &HaltError{err} // This will immediately stop deploying
+	}
+
+	if !service.Healthy {
+		// The bake restarts once the zones are healthy again
+		rollout.HealthyAt = nil
+		return nil
+	}
+
+	if rollout.HealthyAt == nil {
+		rollout.HealthyAt = &now
+	}
+
+	last := rollout.zones() >= len(service.Resources.AvailabilityZones)
+	if last || !rollout.baked(now) {
+		service.Healthy = last && rollout.baked(now)
+		return nil
+	}
+
+	zones := rollout.zones() + 1
+	rollout.Zones = &zones
+	rollout.HealthyAt = nil
+
+	capacity := service.launchCapacity()
+	if err := asg.UpdateSubnets(asgc, service.CreatedASG, service.rolloutSubnetIds(), int64(min(service.Autoscaling.MinSizeInt(), capacity)), int64(capacity)); err != nil {
+		return err // This might retry
+	}
+
+	service.Healthy = false
+	return nil
+}
+
+// RolloutAZs rolls out the next zone of every healthy service brought up by zone
+func (release *Release) RolloutAZs(asgc aws.ASGAPI, cwc aws.CWAPI, now time.Time) error {
+	healthy := true
+
+	for _, service := range release.Services {
+		if service.rollingOutAZs() && service.CreatedASG != nil {
+			if err := service.rolloutAZs(asgc, cwc, now); err != nil {
+				return err
+			}
+		}
+
+		healthy = healthy && service.Healthy
+	}
+
+	release.Healthy = &healthy
+	return nil
+}
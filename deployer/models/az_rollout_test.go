@@ -0,0 +1,107 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockAZRolloutService(t *testing.T) *Service {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	service := release.Services["web"]
+	service.Autoscaling.MinSize = to.Int64p(6)
+	service.Autoscaling.MaxSize = to.Int64p(6)
+	service.AZRollout = &AZRolloutConfig{Alarms: []*string{to.Strp("errors-{{AVAILABILITY_ZONE}}")}}
+	service.AZRollout.SetDefaults()
+	service.CreatedASG = service.ServiceID()
+
+	service.Resources.Subnets = []*string{to.Strp("subnet-a"), to.Strp("subnet-b"), to.Strp("subnet-c")}
+	service.Resources.AvailabilityZones = []*string{to.Strp("us-east-1a"), to.Strp("us-east-1b"), to.Strp("us-east-1c")}
+	service.Resources.SubnetAvailabilityZones = map[string]*string{
+		"subnet-a": to.Strp("us-east-1a"),
+		"subnet-b": to.Strp("us-east-1b"),
+		"subnet-c": to.Strp("us-east-1c"),
+	}
+
+	return service
+}
+
+func Test_AZRolloutConfig_ValidateAttributes(t *testing.T) {
+	a := &AZRolloutConfig{}
+	a.SetDefaults()
+	assert.NoError(t, a.ValidateAttributes())
+	assert.Equal(t, 300, *a.Bake)
+
+	a.Bake = to.Intp(-1)
+	assert.Error(t, a.ValidateAttributes())
+
+	service := mockAZRolloutService(t)
+	service.Autoscaling.ScaleUp = &ScaleUpConfig{Percentage: to.Int64p(25)}
+	assert.Error(t, service.validateAZRollout())
+}
+
+func Test_Service_RolloutAZs(t *testing.T) {
+	service := mockAZRolloutService(t)
+	start := time.Now()
+
+	// The ASG starts in the first zone with its share of the capacity
+	input := service.createInput()
+	assert.Equal(t, "subnet-a", *input.VPCZoneIdentifier)
+	assert.Equal(t, int64(2), *input.DesiredCapacity)
+	assert.Equal(t, int64(2), *input.MinSize)
+
+	asgc := &mocks.ASGClient{}
+	cwc := &mocks.CWClient{}
+
+	// Not until the zone is healthy
+	assert.NoError(t, service.rolloutAZs(asgc, cwc, start))
+	assert.Nil(t, service.AZRollout.HealthyAt)
+
+	// Not until the zone has baked
+	service.Healthy = true
+	assert.NoError(t, service.rolloutAZs(asgc, cwc, start))
+	assert.False(t, service.Healthy)
+	assert.Equal(t, 0, len(asgc.UpdateAutoScalingGroupInputs))
+
+	service.Healthy = true
+	assert.NoError(t, service.rolloutAZs(asgc, cwc, start.Add(5*time.Minute)))
+	assert.False(t, service.Healthy)
+	assert.Equal(t, "subnet-a,subnet-b", *asgc.UpdateAutoScalingGroupInputs[0].VPCZoneIdentifier)
+	assert.Equal(t, int64(4), *asgc.UpdateAutoScalingGroupInputs[0].DesiredCapacity)
+	assert.Equal(t, []*string{to.Strp("errors-us-east-1a"), to.Strp("errors-us-east-1b")}, service.azRolloutAlarms())
+
+	service.Healthy = true
+	assert.NoError(t, service.rolloutAZs(asgc, cwc, start.Add(6*time.Minute)))
+	service.Healthy = true
+	assert.NoError(t, service.rolloutAZs(asgc, cwc, start.Add(11*time.Minute)))
+	assert.Equal(t, "subnet-a,subnet-b,subnet-c", *asgc.UpdateAutoScalingGroupInputs[1].VPCZoneIdentifier)
+	assert.Equal(t, int64(6), *asgc.UpdateAutoScalingGroupInputs[1].DesiredCapacity)
+	assert.True(t, service.scaledUp())
+
+	// The last zone must also bake
+	service.Healthy = true
+	assert.NoError(t, service.rolloutAZs(asgc, cwc, start.Add(12*time.Minute)))
+	assert.False(t, service.Healthy)
+
+	service.Healthy = true
+	assert.NoError(t, service.rolloutAZs(asgc, cwc, start.Add(17*time.Minute)))
+	assert.True(t, service.Healthy)
+	assert.Equal(t, 2, len(asgc.UpdateAutoScalingGroupInputs))
+}
+
+func Test_Service_RolloutAZs_Alarm(t *testing.T) {
+	service := mockAZRolloutService(t)
+	service.Healthy = true
+
+	cwc := &mocks.CWClient{AlarmStates: map[string]string{"errors-us-east-1a": cloudwatch.StateValueAlarm}}
+
+	err := service.rolloutAZs(&mocks.ASGClient{}, cwc, time.Now())
+	assert.IsType(t, &HaltError{}, err)
+	assert.Contains(t, err.Error(), "us-east-1a")
+}
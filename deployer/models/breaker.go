@@ -0,0 +1,115 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Breaker counts the consecutive failed deploys of a project config
+// Once tripped new deploys are refused until it is reset with the client
+type Breaker struct {
+	Failures  int        `json:"failures"`
+	Tripped   bool       `json:"tripped"`
+	Reason    *string    `json:"reason,omitempty"`
+	TrippedAt *time.Time `json:"tripped_at,omitempty"`
+}
+
+// ParseBreakerFailures parses the number of consecutive failures that trips the breaker, an empty string disables it
+func ParseBreakerFailures(raw string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+
+	failures, err := strconv.Atoi(raw)
+	if err != nil || failures < 0 {
+		return 0, fmt.Errorf("Circuit breaker failures %q must be a positive number", raw)
+	}
+
+	return failures, nil
+}
+
+// BreakerPath returns the path of the project configs breaker
+func (release *Release) BreakerPath() *string {
+	s := fmt.Sprintf("%v/breaker", *release.RootDir())
+	return &s
+}
+
+// Breaker returns the breaker of the project config, which is empty if it has never failed
+func (release *Release) Breaker(s3c aws.S3API) (*Breaker, error) {
+	raw, err := release.Store(s3c).Get(release.BreakerPath())
+	if IsNotFound(err) {
+		return &Breaker{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var breaker Breaker
+	if err := json.Unmarshal(raw, &breaker); err != nil {
+		return nil, fmt.Errorf("Circuit breaker invalid %v", err.Error())
+	}
+
+	return &breaker, nil
+}
+
+// CheckBreaker returns an error if the project configs breaker is tripped
+func (release *Release) CheckBreaker(s3c aws.S3API) error {
+	breaker, err := release.Breaker(s3c)
+	if err != nil {
+		return err
+	}
+
+	if breaker.Tripped {
+		return fmt.Errorf("Circuit breaker tripped: %v, run odin reset-breaker to deploy", to.Strs(breaker.Reason))
+	}
+
+	return nil
+}
+
+// RecordFailure counts the failed deploy and trips the breaker once there are maxFailures in a row
+func (release *Release) RecordFailure(s3c aws.S3API, maxFailures int) error {
+	breaker, err := release.Breaker(s3c)
+	if err != nil {
+		return err
+	}
+
+	breaker.Failures++
+
+	if !breaker.Tripped && breaker.Failures >= maxFailures {
+		cause := "unknown"
+		if release.Error != nil && release.Error.Cause != nil {
+			cause = *release.Error.Cause
+		}
+
+		breaker.Tripped = true
+		breaker.Reason = to.Strp(fmt.Sprintf("%v consecutive failed deploys, last release %v failed with %v", breaker.Failures, to.Strs(release.ReleaseID), cause))
+		breaker.TrippedAt = to.Timep(time.Now())
+	}
+
+	return PutArtifact(release.Store(s3c), release.BreakerPath(), breaker)
+}
+
+// RecordSuccess resets the count of consecutive failed deploys
+func (release *Release) RecordSuccess(s3c aws.S3API) error {
+	breaker, err := release.Breaker(s3c)
+	if err != nil {
+		return err
+	}
+
+	if breaker.Failures == 0 {
+		return nil
+	}
+
+	return release.ResetBreaker(s3c)
+}
+
+// ResetBreaker closes the project configs breaker so it can be deployed again
+func (release *Release) ResetBreaker(s3c aws.S3API) error {
+	return PutArtifact(release.Store(s3c), release.BreakerPath(), &Breaker{})
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseBreakerFailures(t *testing.T) {
+	failures, err := ParseBreakerFailures("")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, failures)
+
+	failures, err = ParseBreakerFailures("3")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, failures)
+
+	_, err = ParseBreakerFailures("three")
+	assert.Error(t, err)
+}
+
+func Test_Release_Breaker_Trips(t *testing.T) {
+	release := MockRelease(t)
+	awsc := MockAwsClients(release)
+
+	assert.NoError(t, release.RecordFailure(awsc.S3, 2))
+	assert.NoError(t, release.CheckBreaker(awsc.S3))
+
+	assert.NoError(t, release.RecordFailure(awsc.S3, 2))
+	assert.Error(t, release.CheckBreaker(awsc.S3))
+
+	breaker, err := release.Breaker(awsc.S3)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, breaker.Failures)
+	assert.NotNil(t, breaker.Reason)
+
+	assert.NoError(t, release.RecordSuccess(awsc.S3))
+	assert.NoError(t, release.CheckBreaker(awsc.S3))
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/odcr"
+)
+
+// CapacityReservationEnabled returns whether capacity is reserved before the service launches
+func (service *Service) CapacityReservationEnabled() bool {
+	return service.CapacityReservation != nil && *service.CapacityReservation
+}
+
+func (service *Service) validateCapacityReservation() error {
+	if !service.CapacityReservationEnabled() {
+		return nil
+	}
+
+	// Capacity reservations are only used by On-Demand instances
+	if service.SpotPrice != nil {
+		return fmt.Errorf("capacity_reservation cannot be used with spot_price")
+	}
+
+	return nil
+}
+
+// reserveCapacity reserves the services target capacity spread across its availability zones
+// The reservations are open, so the new ASGs instances use them when they launch
+func (service *Service) reserveCapacity(ec2c aws.EC2API) error {
+	if !service.CapacityReservationEnabled() {
+		return nil
+	}
+
+	azs := service.Resources.AvailabilityZones
+	if len(azs) == 0 {
+		return fmt.Errorf("capacity_reservation requires the subnets availability zones")
+	}
+
+	// Round up so the ASG can launch its full capacity however it balances zones
+	perAZ := int64((service.targetCapacity() + len(azs) - 1) / len(azs))
+	if perAZ < 1 {
+		return nil
+	}
+
+	for _, az := range azs {
+		id, err := odcr.Reserve(ec2c, service.InstanceType, az, perAZ, service.ServiceID(), service.capacityReservationEnd())
+		if err != nil {
+			return fmt.Errorf("Capacity reservation for %v in %v failed %v", *service.InstanceType, *az, err.Error())
+		}
+
+		service.CapacityReservationIDs = append(service.CapacityReservationIDs, id)
+	}
+
+	return nil
+}
+
+// capacityReservationEnd is an hour after the release times out, so the teardown has time to cancel them first
+func (service *Service) capacityReservationEnd() time.Time {
+	return service.CreatedAt().Add(time.Duration(*service.release.Timeout)*time.Second + time.Hour)
+}
+
+// ReleaseCapacity cancels the capacity reservations of the release once the new fleet has launched or failed
+// Reservations are also found by the services ID, as a failed deploy does not return the IDs it reserved
+func (release *Release) ReleaseCapacity(ec2c aws.EC2API) error {
+	for _, service := range release.Services {
+		if service == nil || !service.CapacityReservationEnabled() {
+			continue
+		}
+
+		found, err := odcr.FindActive(ec2c, service.InstanceType, service.ServiceID())
+		if err != nil {
+			return err
+		}
+
+		cancelled := map[string]bool{}
+		for _, id := range append(service.CapacityReservationIDs, found...) {
+			if id == nil || cancelled[*id] {
+				continue
+			}
+
+			if err := odcr.Cancel(ec2c, id); err != nil {
+				return err
+			}
+			cancelled[*id] = true
+		}
+
+		service.CapacityReservationIDs = nil
+	}
+
+	return nil
+}
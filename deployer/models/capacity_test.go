@@ -0,0 +1,87 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Service_CapacityReservation(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	service := release.Services["web"]
+	service.CapacityReservation = to.Boolp(true)
+	service.Resources.AvailabilityZones = []*string{to.Strp("us-east-1a"), to.Strp("us-east-1b")}
+
+	ec2c := &mocks.EC2Client{}
+	assert.NoError(t, service.reserveCapacity(ec2c))
+	assert.Equal(t, 2, len(service.CapacityReservationIDs))
+	assert.Equal(t, 2, len(ec2c.CapacityReservations))
+
+	for _, in := range ec2c.CapacityReservations {
+		assert.Equal(t, "open", *in.InstanceMatchCriteria)
+		assert.Equal(t, *service.InstanceType, *in.InstanceType)
+		assert.Equal(t, "limited", *in.EndDateType)
+		assert.True(t, in.EndDate.After(*release.CreatedAt))
+	}
+
+	assert.NoError(t, release.ReleaseCapacity(ec2c))
+	assert.Equal(t, 0, len(ec2c.CapacityReservations))
+	assert.Nil(t, service.CapacityReservationIDs)
+}
+
+func Test_Release_ReleaseCapacity_LostIDs(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	service := release.Services["web"]
+	service.CapacityReservation = to.Boolp(true)
+	service.Resources.AvailabilityZones = []*string{to.Strp("us-east-1a"), to.Strp("us-east-1b")}
+
+	ec2c := &mocks.EC2Client{}
+	assert.NoError(t, service.reserveCapacity(ec2c))
+
+	// Another releases reservation of the same instance type is kept
+	other := MockRelease(t)
+	other.ReleaseID = to.Strp("other")
+	other.CreatedAt = to.Timep(release.CreatedAt.Add(time.Minute))
+	MockPrepareRelease(other)
+	other.Services["web"].CapacityReservation = to.Boolp(true)
+	other.Services["web"].Resources.AvailabilityZones = []*string{to.Strp("us-east-1a")}
+	assert.NoError(t, other.Services["web"].reserveCapacity(ec2c))
+	assert.Equal(t, 3, len(ec2c.CapacityReservations))
+
+	// A failed deploy does not return the IDs it reserved
+	service.CapacityReservationIDs = nil
+	assert.NoError(t, release.ReleaseCapacity(ec2c))
+	assert.Equal(t, 1, len(ec2c.CapacityReservations))
+}
+
+func Test_Service_CapacityReservation_Insufficient(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	service := release.Services["web"]
+	service.CapacityReservation = to.Boolp(true)
+	service.Resources.AvailabilityZones = []*string{to.Strp("us-east-1a")}
+
+	ec2c := &mocks.EC2Client{CreateCapacityReservationError: fmt.Errorf("InsufficientInstanceCapacity")}
+	assert.Error(t, service.reserveCapacity(ec2c))
+}
+
+func Test_Service_CapacityReservation_Validate(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	service := release.Services["web"]
+	service.CapacityReservation = to.Boolp(true)
+	assert.NoError(t, service.validateCapacityReservation())
+
+	service.SpotPrice = to.Strp("0.1")
+	assert.Error(t, service.validateCapacityReservation())
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/asg"
+	"github.com/coinbase/step/utils/to"
+)
+
+// States that save a checkpoint of the release when they succeed
+const (
+	CheckpointDeploy         = "Deploy"
+	CheckpointHealthy        = "CheckHealthy"
+	CheckpointCleanUpSuccess = "CleanUpSuccess"
+	CheckpointCleanUpFailure = "CleanUpFailure"
+)
+
+// CheckpointPath returns the path of the releases last checkpoint
+func (release *Release) CheckpointPath() *string {
+	s := fmt.Sprintf("%v/checkpoint", *release.ReleaseDir())
+	return &s
+}
+
+// SaveCheckpoint saves the release as it was when the state succeeded
+func (release *Release) SaveCheckpoint(s3c aws.S3API, state string) error {
+	release.Checkpoint = &state
+	return PutArtifact(release.Store(s3c), release.CheckpointPath(), release)
+}
+
+// LoadCheckpoint returns the release saved at its last checkpoint
+func (release *Release) LoadCheckpoint(s3c aws.S3API) (*Release, error) {
+	raw, err := release.Store(s3c).Get(release.CheckpointPath())
+	if IsNotFound(err) {
+		return nil, fmt.Errorf("Release %v has no checkpoint, it failed before creating resources so deploy a new release", to.Strs(release.ReleaseID))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var checkpoint Release
+	if err := json.Unmarshal(raw, &checkpoint); err != nil {
+		return nil, fmt.Errorf("Checkpoint invalid %v", err.Error())
+	}
+
+	checkpoint.UseStoreOf(release)
+	return &checkpoint, nil
+}
+
+// PrepareResume resets a checkpoint so it can be started again as a resumed release
+// Only healthy releases keep their ASGs when they fail, as failing before then rolls them back
+func (release *Release) PrepareResume() error {
+	switch to.Strs(release.Checkpoint) {
+	case CheckpointHealthy:
+	case CheckpointDeploy:
+		return fmt.Errorf("Release %v failed before it was healthy so its ASGs were rolled back, deploy a new release", to.Strs(release.ReleaseID))
+	case CheckpointCleanUpSuccess:
+		return fmt.Errorf("Release %v already succeeded", to.Strs(release.ReleaseID))
+	case CheckpointCleanUpFailure:
+		return fmt.Errorf("Release %v was rolled back, deploy a new release", to.Strs(release.ReleaseID))
+	default:
+		return fmt.Errorf("Release %v checkpoint %q cannot be resumed", to.Strs(release.ReleaseID), to.Strs(release.Checkpoint))
+	}
+
+	release.Resumed = to.Boolp(true)
+	release.CreatedAt = to.Timep(time.Now())
+	release.Success = nil
+	release.Error = nil
+	release.ErrorCode = nil
+
+	return nil
+}
+
+// Resuming returns whether the release reuses the ASGs of its checkpoint
+func (release *Release) Resuming() bool {
+	return release.Resumed != nil && *release.Resumed
+}
+
+// resumesASGs returns whether the ASGs are exactly the created ASGs of a resuming release
+func (release *Release) resumesASGs(asgs []*asg.ASG) bool {
+	if !release.Resuming() || len(asgs) != len(release.Services) {
+		return false
+	}
+
+	created := map[string]bool{}
+	for _, service := range release.Services {
+		if service.CreatedASG != nil {
+			created[*service.CreatedASG] = true
+		}
+	}
+
+	for _, a := range asgs {
+		if !created[to.Strs(a.AutoScalingGroupName)] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ResumeResources checks the ASGs created before the release failed still exist so they can be reused
+func (release *Release) ResumeResources(asgc aws.ASGAPI) error {
+	asgs, err := asg.ForProjectConfigReleaseID(asgc, release.ProjectName, release.ConfigName, release.ReleaseID)
+	if err != nil {
+		return err
+	}
+
+	if !release.resumesASGs(asgs) {
+		return fmt.Errorf("%v ASGs created by the release no longer exist, deploy a new release", release.ErrorPrefix())
+	}
+
+	return nil
+}
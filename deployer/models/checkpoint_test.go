@@ -0,0 +1,66 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_Checkpoint(t *testing.T) {
+	release := MockRelease(t)
+	awsc := MockAwsClients(release)
+
+	_, err := release.LoadCheckpoint(awsc.S3)
+	assert.Error(t, err)
+
+	release.Services["web"].CreatedASG = to.Strp("web-asg")
+	assert.NoError(t, release.SaveCheckpoint(awsc.S3, CheckpointHealthy))
+
+	checkpoint, err := release.LoadCheckpoint(awsc.S3)
+	assert.NoError(t, err)
+	assert.Equal(t, CheckpointHealthy, *checkpoint.Checkpoint)
+	assert.Equal(t, "web-asg", *checkpoint.Services["web"].CreatedASG)
+
+	assert.NoError(t, checkpoint.PrepareResume())
+	assert.True(t, checkpoint.Resuming())
+}
+
+func Test_Release_PrepareResume(t *testing.T) {
+	release := MockRelease(t)
+	assert.Error(t, release.PrepareResume())
+
+	release.Checkpoint = to.Strp(CheckpointCleanUpSuccess)
+	assert.Error(t, release.PrepareResume())
+
+	release.Checkpoint = to.Strp(CheckpointCleanUpFailure)
+	assert.Error(t, release.PrepareResume())
+
+	// Failing after the deploy checkpoint rolls back the ASGs it created
+	release.Checkpoint = to.Strp(CheckpointDeploy)
+	assert.Error(t, release.PrepareResume())
+
+	release.Checkpoint = to.Strp(CheckpointHealthy)
+	release.Success = to.Boolp(false)
+	assert.NoError(t, release.PrepareResume())
+	assert.Nil(t, release.Success)
+}
+
+func Test_Release_ResumeResources(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	release.Services["web"].CreatedASG = to.Strp("web-asg")
+
+	asgc := &mocks.ASGClient{}
+	asgc.AddASG(mocks.MakeMockASG("web-asg", *release.ProjectName, *release.ConfigName, "web", *release.ReleaseID))
+
+	// Only a resumed release can reuse ASGs
+	assert.Error(t, release.ResumeResources(asgc))
+
+	release.Resumed = to.Boolp(true)
+	assert.NoError(t, release.ResumeResources(asgc))
+
+	release.Services["web"].CreatedASG = to.Strp("other-asg")
+	assert.Error(t, release.ResumeResources(asgc))
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/asg"
+	"github.com/coinbase/odin/aws/lt"
+	"github.com/coinbase/step/utils/to"
+)
+
+// CleanupPlan is what cleaning up a project config removes, the old ASGs a deploy of Release would have deleted
+type CleanupPlan struct {
+	Release *string       `json:"release"` // The deployed release that is kept
+	ASGs    []*CleanupASG `json:"asgs"`
+	Locked  bool          `json:"locked"` // A deploy holds the lock, so the cleanup cannot be executed
+
+	asgs []*asg.ASG
+}
+
+// CleanupASG is an old ASG, deleted with its launch configuration or template and alarms
+// Its ELBs and target groups are not created by Odin so they are only detached
+type CleanupASG struct {
+	Name                *string   `json:"name,omitempty"`
+	ServiceName         *string   `json:"service_name,omitempty"`
+	ReleaseID           *string   `json:"release_id,omitempty"`
+	LaunchConfiguration *string   `json:"launch_configuration,omitempty"`
+	LaunchTemplate      *string   `json:"launch_template,omitempty"`
+	LoadBalancers       []*string `json:"load_balancers,omitempty"`
+	TargetGroups        []*string `json:"target_groups,omitempty"`
+}
+
+// PlanCleanup returns the old ASGs of the project config, keeping those of the release
+// Only the deployed release recorded in the linkage can be kept, which is the default if the release has no ID,
+// as keeping any other release would delete the ASGs that are serving
+func (release *Release) PlanCleanup(s3c aws.S3API, locker Locker, asgc aws.ASGAPI) (*CleanupPlan, error) {
+	linkage, err := release.LoadLinkage(s3c)
+	if err != nil {
+		return nil, err
+	}
+
+	if linkage == nil || linkage.ReleaseID == nil {
+		return nil, fmt.Errorf("No deployed release is recorded for %v/%v, so none can be kept", to.Strs(release.ProjectName), to.Strs(release.ConfigName))
+	}
+
+	if release.ReleaseID == nil {
+		release.ReleaseID = linkage.ReleaseID
+	}
+
+	if *release.ReleaseID != *linkage.ReleaseID {
+		return nil, fmt.Errorf("Release %v is not the deployed release %v of %v/%v", *release.ReleaseID, *linkage.ReleaseID, to.Strs(release.ProjectName), to.Strs(release.ConfigName))
+	}
+
+	// Keep following the chain of releases the kept release replaced
+	release.PreviousReleaseID = linkage.PreviousReleaseID
+
+	asgs, err := release.previousASGs(asgc)
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := locker.Get(release)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &CleanupPlan{Release: release.ReleaseID, ASGs: []*CleanupASG{}, Locked: lock != nil, asgs: asgs}
+	for _, a := range asgs {
+		plan.ASGs = append(plan.ASGs, &CleanupASG{
+			Name:                a.AutoScalingGroupName,
+			ServiceName:         a.ServiceName(),
+			ReleaseID:           a.ReleaseID(),
+			LaunchConfiguration: a.LaunchConfigurationName,
+			LaunchTemplate:      a.LaunchTemplateName,
+			LoadBalancers:       a.LoadBalancerNames,
+			TargetGroups:        a.TargetGroupARNs,
+		})
+	}
+
+	return plan, nil
+}
+
+// Cleanup deletes the old ASGs of the plan, it refuses if a deploy holds the lock as it may be using them
+func (release *Release) Cleanup(plan *CleanupPlan, asgc aws.ASGAPI, cwc aws.CWAPI, ec2c aws.EC2API) error {
+	if plan.Locked {
+		return fmt.Errorf("%v/%v is locked by a deploy, wait for it or unlock it before cleaning up", to.Strs(release.ProjectName), to.Strs(release.ConfigName))
+	}
+
+	if err := release.checkOwnership(plan.asgs); err != nil {
+		return err
+	}
+
+	for _, a := range plan.asgs {
+		if to.Strs(a.ReleaseID()) == to.Strs(plan.Release) {
+			return fmt.Errorf("Bad ReleaseID")
+		}
+
+		if err := a.Teardown(asgc, cwc); err != nil {
+			return err
+		}
+
+		if a.LaunchTemplateName != nil {
+			if err := lt.Teardown(ec2c, a.LaunchTemplateName); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
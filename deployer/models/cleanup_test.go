@@ -0,0 +1,70 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_PlanCleanup(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	awsc := MockAwsClients(release)
+	awsc.ASG.AddASG(mocks.MakeMockASG("deployed-web", *release.ProjectName, *release.ConfigName, "web", *release.ReleaseID))
+
+	root := &Release{}
+	root.ProjectName, root.ConfigName, root.Bucket = release.ProjectName, release.ConfigName, release.Bucket
+	root.AwsAccountID, root.AwsRegion = release.AwsAccountID, release.AwsRegion
+
+	// The release to keep must be given until one is recorded as deployed
+	_, err := root.PlanCleanup(awsc.S3, &S3Locker{S3: awsc.S3}, awsc.ASG)
+	assert.Error(t, err)
+
+	release.Services["web"].CreatedASG = to.Strp("deployed-web")
+	assert.NoError(t, release.SaveLinkage(awsc.S3))
+
+	plan, err := root.PlanCleanup(awsc.S3, &S3Locker{S3: awsc.S3}, awsc.ASG)
+	assert.NoError(t, err)
+	assert.Equal(t, *release.ReleaseID, *plan.Release)
+	assert.Equal(t, 1, len(plan.ASGs))
+	assert.Equal(t, "project-config-web-old-release", *plan.ASGs[0].Name)
+	assert.False(t, plan.Locked)
+
+	assert.NoError(t, root.Cleanup(plan, awsc.ASG, awsc.CW, awsc.EC2))
+	assert.Equal(t, []string{"project-config-web-old-release"}, awsc.ASG.DeletedASGs)
+}
+
+func Test_Release_PlanCleanup_Not_Deployed(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	awsc := MockAwsClients(release)
+	assert.NoError(t, release.SaveLinkage(awsc.S3))
+
+	// Keeping any release but the deployed one would delete the serving ASGs
+	other := MockRelease(t)
+	other.ReleaseID = to.Strp("other-release")
+	MockPrepareRelease(other)
+
+	_, err := other.PlanCleanup(awsc.S3, &S3Locker{S3: awsc.S3}, awsc.ASG)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is not the deployed release")
+	assert.Equal(t, 0, len(awsc.ASG.DeletedASGs))
+}
+
+func Test_Release_Cleanup_Locked(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	awsc := MockAwsClients(release)
+	assert.NoError(t, release.SaveLinkage(awsc.S3))
+
+	assert.NoError(t, release.GrabLock(awsc.S3))
+
+	plan, err := release.PlanCleanup(awsc.S3, &S3Locker{S3: awsc.S3}, awsc.ASG)
+	assert.NoError(t, err)
+	assert.True(t, plan.Locked)
+
+	assert.Error(t, release.Cleanup(plan, awsc.ASG, awsc.CW, awsc.EC2))
+	assert.Equal(t, 0, len(awsc.ASG.DeletedASGs))
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/asg"
+	"github.com/coinbase/odin/aws/fleet"
+	"github.com/coinbase/step/utils/to"
+)
+
+// FleetComposition is the fleet a service actually launched
+type FleetComposition struct {
+	Instances         *int           `json:"instances,omitempty"`
+	AvailabilityZones map[string]int `json:"availability_zones,omitempty"` // Instances per AZ
+	InstanceTypes     map[string]int `json:"instance_types,omitempty"`     // Instances per instance type
+	Spot              *int           `json:"spot,omitempty"`
+	OnDemand          *int           `json:"on_demand,omitempty"`
+}
+
+// UpdateComposition records the fleet composition of every service
+func (release *Release) UpdateComposition(asgc aws.ASGAPI, ec2c aws.EC2API) error {
+	for _, service := range release.Services {
+		if service.IsHalted() {
+			continue
+		}
+
+		if err := service.UpdateComposition(asgc, ec2c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecordPath returns the path of the release as it finished
+// It is kept apart from the release path so the release the client uploaded and Validate checked is never replaced
+func (release *Release) RecordPath() *string {
+	s := fmt.Sprintf("%v/record", *release.ReleaseDir())
+	return &s
+}
+
+// SaveRecord saves the release as it finished, with its success and composition
+func (release *Release) SaveRecord(s3c aws.S3API) error {
+	return PutArtifact(release.Store(s3c), release.RecordPath(), release)
+}
+
+// UpdateComposition records the instances in the created ASG
+func (service *Service) UpdateComposition(asgc aws.ASGAPI, ec2c aws.EC2API) error {
+	instances, err := asg.GetInstances(asgc, service.CreatedASG)
+	if err != nil {
+		return err
+	}
+
+	ids := append(instances.HealthyIDs(), instances.UnhealthyIDs()...)
+
+	composition := &FleetComposition{
+		Instances:         to.Intp(0),
+		AvailabilityZones: map[string]int{},
+		InstanceTypes:     map[string]int{},
+		Spot:              to.Intp(0),
+		OnDemand:          to.Intp(0),
+	}
+
+	if err := fleet.Fleet(ids).EC2Instances(ec2c, composition.add); err != nil {
+		return err
+	}
+
+	service.Composition = composition
+	return nil
+}
+
+func (fc *FleetComposition) add(i *ec2.Instance) {
+	*fc.Instances++
+
+	if i.Placement != nil && i.Placement.AvailabilityZone != nil {
+		fc.AvailabilityZones[*i.Placement.AvailabilityZone]++
+	}
+
+	if i.InstanceType != nil {
+		fc.InstanceTypes[*i.InstanceType]++
+	}
+
+	if i.InstanceLifecycle != nil && *i.InstanceLifecycle == ec2.InstanceLifecycleTypeSpot {
+		*fc.Spot++
+	} else {
+		*fc.OnDemand++
+	}
+}
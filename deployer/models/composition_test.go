@@ -0,0 +1,62 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_UpdateComposition(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	awsc := MockAwsClients(release)
+	assert.NoError(t, release.CreateResources(awsc.ASG, awsc.CW, awsc.EC2))
+
+	awsc.EC2.Instances = []*ec2.Instance{
+		&ec2.Instance{
+			InstanceId:   to.Strp("InstanceId1"),
+			InstanceType: to.Strp("c5.large"),
+			Placement:    &ec2.Placement{AvailabilityZone: to.Strp("us-east-1a")},
+		},
+		&ec2.Instance{
+			InstanceId:        to.Strp("InstanceId2"),
+			InstanceType:      to.Strp("m5.large"),
+			InstanceLifecycle: to.Strp("spot"),
+			Placement:         &ec2.Placement{AvailabilityZone: to.Strp("us-east-1b")},
+		},
+	}
+
+	assert.NoError(t, release.UpdateComposition(awsc.ASG, awsc.EC2))
+
+	composition := release.Services["web"].Composition
+	assert.Equal(t, 2, *composition.Instances)
+	assert.Equal(t, map[string]int{"us-east-1a": 1, "us-east-1b": 1}, composition.AvailabilityZones)
+	assert.Equal(t, map[string]int{"c5.large": 1, "m5.large": 1}, composition.InstanceTypes)
+	assert.Equal(t, 1, *composition.Spot)
+	assert.Equal(t, 1, *composition.OnDemand)
+}
+
+func Test_Release_SaveRecord(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	awsc := MockAwsClients(release)
+
+	uploaded, err := s3.Get(awsc.S3, release.Bucket, release.ReleasePath())
+	assert.NoError(t, err)
+
+	release.Success = to.Boolp(true)
+	assert.NoError(t, release.SaveRecord(awsc.S3))
+
+	// The release Validate checked is never replaced
+	raw, err := s3.Get(awsc.S3, release.Bucket, release.ReleasePath())
+	assert.NoError(t, err)
+	assert.Equal(t, string(*uploaded), string(*raw))
+
+	var record Release
+	assert.NoError(t, GetArtifact(release.Store(awsc.S3), release.RecordPath(), &record))
+	assert.True(t, *record.Success)
+}
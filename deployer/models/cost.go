@@ -0,0 +1,150 @@
+package models
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/pricing"
+	"github.com/coinbase/step/utils/to"
+)
+
+// CostEstimate is an estimated On-Demand cost in USD
+type CostEstimate struct {
+	Hourly  float64 `json:"hourly"`
+	Monthly float64 `json:"monthly"`
+}
+
+// PlanCost compares the cost of the ASGs a release creates to the ASGs it terminates
+type PlanCost struct {
+	Current      *CostEstimate `json:"current"`
+	New          *CostEstimate `json:"new"`
+	HourlyDelta  float64       `json:"hourly_delta"`
+	MonthlyDelta float64       `json:"monthly_delta"`
+	Unpriced     []string      `json:"unpriced,omitempty"` // ASGs left out of the estimate, and why
+}
+
+// EstimateCost estimates the cost of the plans ASGs from the Price List API
+// The instance type and EBS volumes of the ASGs it terminates are read from the release that created them
+func (release *Release) EstimateCost(plan *Plan, pricingc aws.PricingAPI, s3c aws.S3API) *PlanCost {
+	cost := &PlanCost{Current: &CostEstimate{}, New: &CostEstimate{}}
+	prices := map[string]float64{}
+
+	for _, planned := range plan.Terminate {
+		release.fillFromRecord(s3c, planned)
+	}
+
+	add := func(total *CostEstimate, planned *PlannedASG) {
+		estimate, err := planned.estimate(pricingc, to.Strs(release.AwsRegion), prices)
+		if err != nil {
+			cost.Unpriced = append(cost.Unpriced, fmt.Sprintf("%v: %v", to.Strs(planned.Name), err.Error()))
+			return
+		}
+
+		planned.Cost = estimate
+		total.Hourly += estimate.Hourly
+		total.Monthly += estimate.Monthly
+	}
+
+	for _, planned := range plan.Create {
+		add(cost.New, planned)
+	}
+
+	for _, planned := range plan.Terminate {
+		add(cost.Current, planned)
+	}
+
+	cost.Current.round()
+	cost.New.round()
+	cost.HourlyDelta = roundCents(cost.New.Hourly - cost.Current.Hourly)
+	cost.MonthlyDelta = roundCents(cost.New.Monthly - cost.Current.Monthly)
+
+	plan.Cost = cost
+	return cost
+}
+
+// fillFromRecord sets the instance type and EBS volume of an old ASG from the record of the release that created it
+func (release *Release) fillFromRecord(s3c aws.S3API, planned *PlannedASG) {
+	if planned.ReleaseID == nil || planned.ServiceName == nil {
+		return
+	}
+
+	key := fmt.Sprintf("%v/%v/release", *release.RootDir(), *planned.ReleaseID)
+	var previous Release
+	if err := GetArtifact(release.Store(s3c), &key, &previous); err != nil {
+		return
+	}
+
+	if service := previous.Services[*planned.ServiceName]; service != nil {
+		planned.InstanceType = service.InstanceType
+		planned.EBSVolumeSize = service.EBSVolumeSize
+		planned.EBSVolumeType = service.EBSVolumeType
+	}
+}
+
+// estimate returns the cost of the ASGs instances and their EBS volumes at its steady desired capacity
+func (planned *PlannedASG) estimate(pricingc aws.PricingAPI, region string, prices map[string]float64) (*CostEstimate, error) {
+	if planned.InstanceType == nil {
+		return nil, fmt.Errorf("instance type unknown")
+	}
+
+	price := func(key string, lookup func() (float64, error)) (float64, error) {
+		if p, ok := prices[key]; ok {
+			return p, nil
+		}
+
+		p, err := lookup()
+		if err != nil {
+			return 0, err
+		}
+
+		prices[key] = p
+		return p, nil
+	}
+
+	hourly, err := price("instance:"+*planned.InstanceType, func() (float64, error) {
+		return pricing.InstanceHourly(pricingc, region, *planned.InstanceType)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if planned.EBSVolumeSize != nil {
+		volumeType := to.Strs(planned.EBSVolumeType)
+		if volumeType == "" {
+			volumeType = "gp2" // The launch configurations default
+		}
+
+		perGB, err := price("volume:"+volumeType, func() (float64, error) {
+			return pricing.VolumeMonthlyPerGB(pricingc, region, volumeType)
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		hourly += perGB * float64(*planned.EBSVolumeSize) / pricing.HoursPerMonth
+	}
+
+	capacity := 0.0
+	if planned.steadyCapacity != nil {
+		capacity = float64(*planned.steadyCapacity)
+	} else if planned.DesiredCapacity != nil {
+		capacity = float64(*planned.DesiredCapacity)
+	}
+
+	estimate := &CostEstimate{Hourly: hourly * capacity, Monthly: hourly * capacity * pricing.HoursPerMonth}
+	estimate.round()
+
+	return estimate, nil
+}
+
+func (estimate *CostEstimate) round() {
+	estimate.Hourly = math.Round(estimate.Hourly*10000) / 10000
+	estimate.Monthly = roundCents(estimate.Monthly)
+}
+
+func roundCents(usd float64) float64 {
+	return math.Round(usd*100) / 100
+}
@@ -0,0 +1,155 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// serviceLevels orders the services by their depends_on, each level only depends on the levels before it
+// Services in the same level are deployed together, services without dependencies are in the first level
+func (release *Release) serviceLevels() ([][]string, error) {
+	levels := [][]string{}
+	placed := map[string]bool{}
+
+	for len(placed) < len(release.Services) {
+		level := []string{}
+		for name, service := range release.Services {
+			if placed[name] {
+				continue
+			}
+
+			ready := true
+			for _, dep := range service.DependsOn {
+				ready = ready && placed[*dep]
+			}
+
+			if ready {
+				level = append(level, name)
+			}
+		}
+
+		if len(level) == 0 {
+			return nil, fmt.Errorf("Services depends_on has a cycle")
+		}
+
+		sort.Strings(level)
+		for _, name := range level {
+			placed[name] = true
+		}
+
+		levels = append(levels, level)
+	}
+
+	return levels, nil
+}
+
+// ValidateDependsOn validates the services depend on other services in the release without a cycle
+func (release *Release) ValidateDependsOn() error {
+	for name, service := range release.Services {
+		for _, dep := range service.DependsOn {
+			if dep == nil || *dep == name {
+				return fmt.Errorf("Service %v cannot depend on itself", name)
+			}
+
+			if _, ok := release.Services[*dep]; !ok {
+				return fmt.Errorf("Service %v depends_on %v which is not in the release", name, *dep)
+			}
+		}
+	}
+
+	_, err := release.serviceLevels()
+	return err
+}
+
+// deployLevel returns the level currently being deployed
+func (release *Release) deployLevel() int {
+	if release.DeployLevel == nil {
+		return 0
+	}
+	return *release.DeployLevel
+}
+
+// levelServices returns the services of the level, in order
+func (release *Release) levelServices(level int) ([]*Service, error) {
+	levels, err := release.serviceLevels()
+	if err != nil {
+		return nil, err
+	}
+
+	services := []*Service{}
+	if level >= len(levels) {
+		return services, nil
+	}
+
+	for _, name := range levels[level] {
+		services = append(services, release.Services[name])
+	}
+
+	return services, nil
+}
+
+// deploying returns whether the services level has been deployed, later levels wait for it to be healthy
+func (release *Release) deploying(service *Service) bool {
+	levels, err := release.serviceLevels()
+	if err != nil {
+		return true
+	}
+
+	for level, names := range levels {
+		for _, name := range names {
+			if release.Services[name] == service {
+				return level <= release.deployLevel()
+			}
+		}
+	}
+
+	return true
+}
+
+// DeployNextLevel creates the resources of the next level of services once every deployed service is healthy
+// The release is only healthy once the last level is
+func (release *Release) DeployNextLevel(asgc aws.ASGAPI, cwc aws.CWAPI, ec2c aws.EC2API) error {
+	levels, err := release.serviceLevels()
+	if err != nil {
+		return err
+	}
+
+	next := release.deployLevel() + 1
+	if next >= len(levels) {
+		return nil
+	}
+
+	for _, service := range release.Services {
+		if release.deploying(service) && !service.Healthy {
+			return nil
+		}
+	}
+
+	services, err := release.levelServices(next)
+	if err != nil {
+		return err
+	}
+
+	for _, service := range services {
+		// Services halted before they were created are never deployed
+		if service.IsHalted() {
+			continue
+		}
+
+		if err := service.CreateResources(asgc, cwc, ec2c); err != nil {
+			return &HaltError{err} // Retrying could create the services again
+		}
+	}
+
+	release.DeployLevel = &next
+	release.Healthy = to.Boolp(false)
+
+	// Each level has the launch timeout
+	release.Phase = nil
+	release.StartPhase(PhaseLaunch)
+
+	return nil
+}
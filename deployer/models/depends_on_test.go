@@ -0,0 +1,69 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockDependentRelease(t *testing.T) *Release {
+	release := MockRelease(t)
+
+	raw, err := json.Marshal(release.Services["web"])
+	assert.NoError(t, err)
+
+	var worker Service
+	assert.NoError(t, json.Unmarshal(raw, &worker))
+	release.Services["worker"] = &worker
+	release.Services["web"].DependsOn = []*string{to.Strp("worker")}
+
+	MockPrepareRelease(release)
+	return release
+}
+
+func Test_Release_ValidateDependsOn(t *testing.T) {
+	release := mockDependentRelease(t)
+	assert.NoError(t, release.ValidateDependsOn())
+
+	levels, err := release.serviceLevels()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{[]string{"worker"}, []string{"web"}}, levels)
+
+	release.Services["web"].DependsOn = []*string{to.Strp("web")}
+	assert.Error(t, release.ValidateDependsOn())
+
+	release.Services["web"].DependsOn = []*string{to.Strp("missing")}
+	assert.Error(t, release.ValidateDependsOn())
+
+	release.Services["web"].DependsOn = []*string{to.Strp("worker")}
+	release.Services["worker"].DependsOn = []*string{to.Strp("web")}
+	assert.Error(t, release.ValidateDependsOn())
+}
+
+func Test_Release_DeployNextLevel(t *testing.T) {
+	release := mockDependentRelease(t)
+	awsc := MockAwsClients(release)
+
+	// Only the worker is created first
+	assert.NoError(t, release.CreateResources(awsc.ASG, awsc.CW, awsc.EC2))
+	assert.NotNil(t, release.Services["worker"].CreatedASG)
+	assert.Nil(t, release.Services["web"].CreatedASG)
+	assert.False(t, release.deploying(release.Services["web"]))
+
+	// The web service waits for the worker to be healthy
+	assert.NoError(t, release.DeployNextLevel(awsc.ASG, awsc.CW, awsc.EC2))
+	assert.Nil(t, release.Services["web"].CreatedASG)
+
+	release.Services["worker"].Healthy = true
+	assert.NoError(t, release.DeployNextLevel(awsc.ASG, awsc.CW, awsc.EC2))
+	assert.NotNil(t, release.Services["web"].CreatedASG)
+	assert.Equal(t, 1, release.deployLevel())
+	assert.False(t, *release.Healthy)
+
+	// The last level has nothing after it
+	release.Services["web"].Healthy = true
+	assert.NoError(t, release.DeployNextLevel(awsc.ASG, awsc.CW, awsc.EC2))
+	assert.Equal(t, 1, release.deployLevel())
+}
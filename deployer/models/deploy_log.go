@@ -0,0 +1,194 @@
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// LogEntry is a line of the releases deploy log, written each time a state of the deploy runs
+type LogEntry struct {
+	Time     *time.Time `json:"time"`
+	State    *string    `json:"state"`
+	Duration *float64   `json:"duration,omitempty"` // Seconds the state took
+	Phase    *string    `json:"phase,omitempty"`
+	Healthy  *bool      `json:"healthy,omitempty"`
+	Success  *bool      `json:"success,omitempty"`
+	Error    *string    `json:"error,omitempty"` // The state failed with the error
+
+	Services map[string]*ServiceLogEntry `json:"services,omitempty"`
+}
+
+// ServiceLogEntry is the state of a service when the entry was written
+type ServiceLogEntry struct {
+	ASG     *string       `json:"asg,omitempty"`
+	Healthy bool          `json:"healthy"`
+	Halted  bool          `json:"halted,omitempty"`
+	Health  *HealthReport `json:"health,omitempty"` // Instance counts of the services last health check
+
+	Launched   []string `json:"launched,omitempty"`   // Instances created since the services last entry
+	Terminated []string `json:"terminated,omitempty"` // Instances terminating or gone since the services last entry
+}
+
+// DeployLogPath returns the path of the releases deploy log
+func (release *Release) DeployLogPath() *string {
+	s := fmt.Sprintf("%v/log.jsonl", *release.ReleaseDir())
+	return &s
+}
+
+// NewLogEntry returns the entry of the state that ran for the duration, err is the error it failed with
+func (release *Release) NewLogEntry(state string, start time.Time, err error) *LogEntry {
+	entry := &LogEntry{
+		Time:     to.Timep(start),
+		State:    to.Strp(state),
+		Duration: to.Float64p(time.Since(start).Seconds()),
+		Phase:    release.Phase,
+		Healthy:  release.Healthy,
+		Success:  release.Success,
+		Services: map[string]*ServiceLogEntry{},
+	}
+
+	if err != nil {
+		entry.Error = to.Strp(err.Error())
+	}
+
+	for name, service := range release.Services {
+		if service == nil {
+			continue
+		}
+
+		entry.Services[name] = &ServiceLogEntry{
+			ASG:     service.CreatedASG,
+			Healthy: service.Healthy,
+			Halted:  service.IsHalted(),
+			Health:  service.HealthReport,
+		}
+	}
+
+	return entry
+}
+
+// AppendLog appends the entry to the releases deploy log
+// Artifacts cannot be appended to, but only one state of a release runs at a time so the log is rewritten
+func (release *Release) AppendLog(s3c aws.S3API, entry *LogEntry) error {
+	raw, err := release.readDeployLog(s3c)
+	if err != nil {
+		return err
+	}
+
+	entry.diffInstances(lastInstances(raw))
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	raw = append(raw, line...)
+	raw = append(raw, '\n')
+
+	return release.Store(s3c).Put(release.DeployLogPath(), raw)
+}
+
+// LoadDeployLog returns the entries of the releases deploy log, in the order they were written
+func (release *Release) LoadDeployLog(s3c aws.S3API) ([]*LogEntry, error) {
+	raw, err := release.readDeployLog(s3c)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDeployLog(raw)
+}
+
+func (release *Release) readDeployLog(s3c aws.S3API) ([]byte, error) {
+	raw, err := release.Store(s3c).Get(release.DeployLogPath())
+	if IsNotFound(err) {
+		return []byte{}, nil
+	}
+
+	return raw, err
+}
+
+func parseDeployLog(raw []byte) ([]*LogEntry, error) {
+	entries := []*LogEntry{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var entry LogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("Deploy log invalid %v", err.Error())
+		}
+		entries = append(entries, &entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+// lastInstances returns the instances of each service in the last entry that had any
+// An invalid log has no instances, so every instance in the next entry is launched
+func lastInstances(raw []byte) map[string][]string {
+	last := map[string][]string{}
+
+	entries, err := parseDeployLog(raw)
+	if err != nil {
+		return last
+	}
+
+	for _, entry := range entries {
+		for name, service := range entry.Services {
+			if service != nil && service.Health != nil && service.Health.InstanceIDs != nil {
+				last[name] = service.Health.InstanceIDs
+			}
+		}
+	}
+
+	return last
+}
+
+// diffInstances records the instances launched and terminated since the previous instances of each service
+func (entry *LogEntry) diffInstances(previous map[string][]string) {
+	for name, service := range entry.Services {
+		if service.Health == nil || service.Health.InstanceIDs == nil {
+			continue
+		}
+
+		before := map[string]bool{}
+		for _, id := range previous[name] {
+			before[id] = true
+		}
+
+		now := map[string]bool{}
+		for _, id := range service.Health.InstanceIDs {
+			now[id] = true
+			if !before[id] {
+				service.Launched = append(service.Launched, id)
+			}
+		}
+
+		terminated := map[string]bool{}
+		for _, id := range service.Health.TerminatingIDs {
+			terminated[id] = true
+		}
+
+		for id := range before {
+			if !now[id] {
+				terminated[id] = true
+			}
+		}
+
+		for id := range terminated {
+			service.Terminated = append(service.Terminated, id)
+		}
+		sort.Strings(service.Terminated)
+	}
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_AppendLog(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	awsc := MockAwsClients(release)
+
+	entries, err := release.LoadDeployLog(awsc.S3)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(entries))
+
+	web := release.Services["web"]
+	web.HealthReport = &HealthReport{Healthy: to.Intp(0), InstanceIDs: []string{"i-1", "i-2"}}
+	assert.NoError(t, release.AppendLog(awsc.S3, release.NewLogEntry("CheckHealthy", time.Now(), nil)))
+
+	web.HealthReport = &HealthReport{
+		Healthy:        to.Intp(1),
+		InstanceIDs:    []string{"i-1", "i-3"},
+		TerminatingIDs: []string{"i-1"},
+	}
+	assert.NoError(t, release.AppendLog(awsc.S3, release.NewLogEntry("CheckHealthy", time.Now(), fmt.Errorf("unhealthy"))))
+
+	entries, err = release.LoadDeployLog(awsc.S3)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(entries))
+
+	assert.Equal(t, "CheckHealthy", *entries[0].State)
+	assert.Nil(t, entries[0].Error)
+	assert.Equal(t, []string{"i-1", "i-2"}, entries[0].Services["web"].Launched)
+	assert.Nil(t, entries[0].Services["web"].Terminated)
+
+	assert.Equal(t, "unhealthy", *entries[1].Error)
+	assert.Equal(t, 1, *entries[1].Services["web"].Health.Healthy)
+	assert.Equal(t, []string{"i-3"}, entries[1].Services["web"].Launched)
+	assert.Equal(t, []string{"i-1", "i-2"}, entries[1].Services["web"].Terminated)
+}
@@ -0,0 +1,232 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/secret"
+	"github.com/coinbase/step/utils/to"
+)
+
+// DeployWindowPolicy restricts when project configs can be deployed
+type DeployWindowPolicy struct {
+	Windows []*DeployWindow `json:"windows,omitempty"`
+	Freezes []*Freeze       `json:"freezes,omitempty"`
+}
+
+// DeployWindow is a time of day on days of the week deploys are allowed
+// A project config matched by any window can only be deployed in the windows that match it
+type DeployWindow struct {
+	Days     []string `json:"days,omitempty"`     // mon, tue, ... empty is every day
+	Start    string   `json:"start"`              // HH:MM
+	End      string   `json:"end"`                // HH:MM, before start the window ends the next day
+	Timezone string   `json:"timezone,omitempty"` // IANA timezone, UTC by default
+	Projects []string `json:"projects,omitempty"` // <project_name>/<config_name> patterns, empty matches all
+}
+
+// Freeze is a period no deploys are allowed
+type Freeze struct {
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Reason   string    `json:"reason,omitempty"`
+	Projects []string  `json:"projects,omitempty"` // <project_name>/<config_name> patterns, empty matches all
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// LoadDeployWindowPolicy reads the policy from the secret reference, an empty reference has no policy
+func LoadDeployWindowPolicy(ref string, ssmc aws.SSMAPI, smc aws.SMAPI) (*DeployWindowPolicy, error) {
+	if ref == "" {
+		return nil, nil
+	}
+
+	raw, err := secret.Get(ssmc, smc, &ref)
+	if err != nil {
+		return nil, fmt.Errorf("Deploy windows %v", err.Error())
+	}
+
+	var policy DeployWindowPolicy
+	if err := json.Unmarshal([]byte(*raw), &policy); err != nil {
+		return nil, fmt.Errorf("Deploy windows invalid %v", err.Error())
+	}
+
+	if err := policy.validate(); err != nil {
+		return nil, fmt.Errorf("Deploy windows invalid %v", err.Error())
+	}
+
+	return &policy, nil
+}
+
+func (policy *DeployWindowPolicy) validate() error {
+	for _, window := range policy.Windows {
+		if window == nil {
+			return fmt.Errorf("window is null")
+		}
+
+		for _, day := range window.Days {
+			if _, ok := weekdays[strings.ToLower(day)]; !ok {
+				return fmt.Errorf("day %q unknown", day)
+			}
+		}
+
+		if _, err := minuteOfDay(window.Start); err != nil {
+			return err
+		}
+
+		if _, err := minuteOfDay(window.End); err != nil {
+			return err
+		}
+
+		if _, err := time.LoadLocation(window.Timezone); err != nil {
+			return fmt.Errorf("timezone %q unknown", window.Timezone)
+		}
+
+		if err := validatePatterns(window.Projects); err != nil {
+			return err
+		}
+	}
+
+	for _, freeze := range policy.Freezes {
+		if freeze == nil {
+			return fmt.Errorf("freeze is null")
+		}
+
+		if !freeze.End.After(freeze.Start) {
+			return fmt.Errorf("freeze %q must end after it starts", freeze.Reason)
+		}
+
+		if err := validatePatterns(freeze.Projects); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validatePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("pattern %q invalid %v", pattern, err.Error())
+		}
+	}
+	return nil
+}
+
+// minuteOfDay parses HH:MM
+func minuteOfDay(hhmm string) (int, error) {
+	t, err := time.Parse("15:04", hhmm)
+	if err != nil {
+		return 0, fmt.Errorf("time %q must be HH:MM", hhmm)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// matchesProject returns whether any pattern matches the project config, no patterns match every project config
+func matchesProject(patterns []string, projectConfig string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, projectConfig); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// contains returns whether the time is in the window, the policy is validated so parsing cannot fail
+func (window *DeployWindow) contains(now time.Time) bool {
+	loc, _ := time.LoadLocation(window.Timezone)
+	now = now.In(loc)
+
+	start, _ := minuteOfDay(window.Start)
+	end, _ := minuteOfDay(window.End)
+	minute := now.Hour()*60 + now.Minute()
+
+	day := now.Weekday()
+	inTime := minute >= start && minute < end
+	if end <= start {
+		// The window wraps past midnight, so early in the morning it started the day before
+		inTime = minute >= start || minute < end
+		if minute < end {
+			day = (day + 6) % 7
+		}
+	}
+
+	if !inTime {
+		return false
+	}
+
+	if len(window.Days) == 0 {
+		return true
+	}
+
+	for _, d := range window.Days {
+		if weekdays[strings.ToLower(d)] == day {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidateFreezeOverride validates a release overriding the deploy windows records why
+func (release *Release) ValidateFreezeOverride() error {
+	if release.OverridesFreeze() && strings.TrimSpace(to.Strs(release.OverrideReason)) == "" {
+		return fmt.Errorf("override_freeze requires an override_reason")
+	}
+	return nil
+}
+
+// OverridesFreeze returns whether the release is deployed outside the deploy windows
+func (release *Release) OverridesFreeze() bool {
+	return release.OverrideFreeze != nil && *release.OverrideFreeze
+}
+
+// CheckDeployWindow errors if the release is not in a deploy window of its project config, or is in a freeze,
+// unless it overrides the freeze
+func (release *Release) CheckDeployWindow(policy *DeployWindowPolicy, now time.Time) error {
+	if policy == nil || release.OverridesFreeze() {
+		return nil
+	}
+
+	projectConfig := fmt.Sprintf("%v/%v", to.Strs(release.ProjectName), to.Strs(release.ConfigName))
+
+	for _, freeze := range policy.Freezes {
+		if !matchesProject(freeze.Projects, projectConfig) {
+			continue
+		}
+
+		if !now.Before(freeze.Start) && now.Before(freeze.End) {
+			return fmt.Errorf("%v is frozen until %v: %v", projectConfig, freeze.End.Format(time.RFC3339), freeze.Reason)
+		}
+	}
+
+	matched := false
+	for _, window := range policy.Windows {
+		if !matchesProject(window.Projects, projectConfig) {
+			continue
+		}
+
+		if window.contains(now) {
+			return nil
+		}
+
+		matched = true
+	}
+
+	if matched {
+		return fmt.Errorf("%v is outside its deploy windows", projectConfig)
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoadDeployWindowPolicy(t *testing.T) {
+	awsc := mocks.MockAWS()
+
+	policy, err := LoadDeployWindowPolicy("", awsc.SSM, awsc.SM)
+	assert.NoError(t, err)
+	assert.Nil(t, policy)
+
+	awsc.SSM.AddParameter("/odin/deploy-windows", `{"windows": [{"days": ["mon"], "start": "09:00", "end": "17:00"}]}`)
+	policy, err = LoadDeployWindowPolicy("ssm:/odin/deploy-windows", awsc.SSM, awsc.SM)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(policy.Windows))
+
+	awsc.SSM.AddParameter("/odin/deploy-windows", `{"windows": [{"days": ["someday"], "start": "09:00", "end": "17:00"}]}`)
+	_, err = LoadDeployWindowPolicy("ssm:/odin/deploy-windows", awsc.SSM, awsc.SM)
+	assert.Error(t, err)
+
+	awsc.SSM.AddParameter("/odin/deploy-windows", `{"windows": [{"start": "9am", "end": "17:00"}]}`)
+	_, err = LoadDeployWindowPolicy("ssm:/odin/deploy-windows", awsc.SSM, awsc.SM)
+	assert.Error(t, err)
+
+	awsc.SSM.AddParameter("/odin/deploy-windows", `{"freezes": [{"start": "2020-01-02T00:00:00Z", "end": "2020-01-01T00:00:00Z"}]}`)
+	_, err = LoadDeployWindowPolicy("ssm:/odin/deploy-windows", awsc.SSM, awsc.SM)
+	assert.Error(t, err)
+}
+
+func Test_Release_CheckDeployWindow(t *testing.T) {
+	release := MockRelease(t)
+	release.ProjectName = to.Strp("project")
+	release.ConfigName = to.Strp("development")
+
+	policy := &DeployWindowPolicy{
+		Windows: []*DeployWindow{
+			&DeployWindow{Days: []string{"Mon", "tue"}, Start: "09:00", End: "17:00", Projects: []string{"project/*"}},
+			&DeployWindow{Start: "22:00", End: "02:00", Projects: []string{"other/*"}},
+		},
+	}
+
+	monday := time.Date(2020, 1, 6, 10, 0, 0, 0, time.UTC)
+	assert.NoError(t, release.CheckDeployWindow(nil, monday))
+	assert.NoError(t, release.CheckDeployWindow(policy, monday))
+	assert.Error(t, release.CheckDeployWindow(policy, monday.Add(8*time.Hour)))
+	assert.Error(t, release.CheckDeployWindow(policy, monday.Add(-24*time.Hour)))
+
+	// Windows wrap past midnight
+	release.ProjectName = to.Strp("other")
+	assert.NoError(t, release.CheckDeployWindow(policy, monday.Add(13*time.Hour)))
+	assert.NoError(t, release.CheckDeployWindow(policy, monday.Add(15*time.Hour)))
+	assert.Error(t, release.CheckDeployWindow(policy, monday.Add(17*time.Hour)))
+
+	// Project configs no window matches can always be deployed
+	release.ProjectName = to.Strp("unmatched")
+	assert.NoError(t, release.CheckDeployWindow(policy, monday.Add(8*time.Hour)))
+
+	policy.Freezes = []*Freeze{
+		&Freeze{Start: monday.Add(-time.Hour), End: monday.Add(time.Hour), Reason: "holidays"},
+	}
+	err := release.CheckDeployWindow(policy, monday)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "holidays")
+	assert.NoError(t, release.CheckDeployWindow(policy, monday.Add(time.Hour)))
+
+	release.OverrideFreeze = to.Boolp(true)
+	assert.Error(t, release.ValidateFreezeOverride())
+
+	release.OverrideReason = to.Strp("incident fix")
+	assert.NoError(t, release.ValidateFreezeOverride())
+	assert.NoError(t, release.CheckDeployWindow(policy, monday))
+}
+
+func Test_DeployWindow_Timezone(t *testing.T) {
+	window := &DeployWindow{Start: "09:00", End: "17:00", Timezone: "America/New_York"}
+
+	assert.False(t, window.contains(time.Date(2020, 1, 6, 10, 0, 0, 0, time.UTC)))
+	assert.True(t, window.contains(time.Date(2020, 1, 6, 15, 0, 0, 0, time.UTC)))
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/asg"
+	"github.com/coinbase/odin/aws/lt"
+	"github.com/coinbase/odin/aws/pg"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Tombstone records that a project config was decommissioned and what was deleted
+type Tombstone struct {
+	ProjectName *string    `json:"project_name"`
+	ConfigName  *string    `json:"config_name"`
+	DestroyedAt *time.Time `json:"destroyed_at"`
+	ASGs        []string   `json:"asgs"`
+}
+
+// TombstonePath returns the path of the project configs tombstone
+func (release *Release) TombstonePath() *string {
+	s := fmt.Sprintf("%v/tombstone", *release.RootDir())
+	return &s
+}
+
+// ValidateNotDestroyed errors if the project config has a tombstone, it is only deployed again once the tombstone is deleted
+func (release *Release) ValidateNotDestroyed(s3c aws.S3API) error {
+	var tombstone Tombstone
+	err := GetArtifact(release.Store(s3c), release.TombstonePath(), &tombstone)
+	if IsNotFound(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	destroyedAt := "unknown"
+	if tombstone.DestroyedAt != nil {
+		destroyedAt = tombstone.DestroyedAt.UTC().Format(time.RFC3339)
+	}
+
+	return fmt.Errorf("%v %v/%v was destroyed at %v, delete its tombstone %v to deploy it again",
+		release.ErrorPrefix(), to.Strs(release.ProjectName), to.Strs(release.ConfigName), destroyedAt, *release.TombstonePath())
+}
+
+// Destroy deletes every ASG of the project config with its launch configuration or template and alarms,
+// force deleting the ASGs also removes their scheduled actions, then writes a tombstone of what was deleted
+func (release *Release) Destroy(asgc aws.ASGAPI, cwc aws.CWAPI, ec2c aws.EC2API, s3c aws.S3API) (*Tombstone, error) {
+	asgs, err := asg.ForProjectConfig(asgc, release.ProjectName, release.ConfigName)
+	if err != nil {
+		return nil, err
+	}
+
+	tombstone := &Tombstone{
+		ProjectName: release.ProjectName,
+		ConfigName:  release.ConfigName,
+		ASGs:        []string{},
+	}
+
+	for _, a := range asgs {
+		if err := a.Teardown(asgc, cwc); err != nil {
+			return nil, err
+		}
+
+		if a.LaunchTemplateName != nil {
+			if err := lt.Teardown(ec2c, a.LaunchTemplateName); err != nil {
+				return nil, err
+			}
+		}
+
+		tombstone.ASGs = append(tombstone.ASGs, to.Strs(a.AutoScalingGroupName))
+	}
+
+	// Placement groups still used by terminating instances cannot be deleted yet and are skipped
+	if err := pg.TeardownTransient(ec2c, release.placementGroupPrefix(), nil); err != nil {
+		return nil, err
+	}
+
+	tombstone.DestroyedAt = to.Timep(time.Now())
+
+	if err := PutArtifact(release.Store(s3c), release.TombstonePath(), tombstone); err != nil {
+		return nil, err
+	}
+
+	return tombstone, nil
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_Destroy(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	awsc := MockAwsClients(release)
+	awsc.ASG.AddASG(mocks.MakeMockASG("other-config-web-release", "other", "config", "web", "release"))
+
+	tombstone, err := release.Destroy(awsc.ASG, awsc.CW, awsc.EC2, awsc.S3)
+	assert.NoError(t, err)
+
+	// Only the project configs ASGs are deleted
+	assert.Equal(t, []string{"project-config-web-old-release"}, tombstone.ASGs)
+	assert.Equal(t, tombstone.ASGs, awsc.ASG.DeletedASGs)
+	assert.NotNil(t, tombstone.DestroyedAt)
+
+	raw, err := s3.Get(awsc.S3, release.Bucket, release.TombstonePath())
+	assert.NoError(t, err)
+
+	var saved Tombstone
+	assert.NoError(t, json.Unmarshal(*raw, &saved))
+	assert.Equal(t, tombstone.ASGs, saved.ASGs)
+}
+
+func Test_Release_ValidateNotDestroyed(t *testing.T) {
+	release := MockRelease(t)
+	awsc := MockAwsClients(release)
+	release.ReleaseSHA256 = to.SHA256Struct(release)
+	MockPrepareRelease(release)
+
+	assert.NoError(t, release.Validate(awsc.S3))
+
+	_, err := release.Destroy(awsc.ASG, awsc.CW, awsc.EC2, awsc.S3)
+	assert.NoError(t, err)
+
+	// A destroyed project config is rejected until its tombstone is deleted
+	err = release.Validate(awsc.S3)
+	assert.Error(t, err)
+	assert.Regexp(t, "project/config was destroyed at .*, delete its tombstone", err.Error())
+}
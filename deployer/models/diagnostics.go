@@ -0,0 +1,252 @@
+package models
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/asg"
+	"github.com/coinbase/odin/aws/fleet"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Diagnostics are bounded so they fit in the release and its error message
+const (
+	maxDiagnosedActivities = 10
+	maxDiagnosedInstances  = 5
+	consoleTailLines       = 20
+)
+
+// Diagnostics is why a service was not healthy, gathered before its instances are terminated
+type Diagnostics struct {
+	Activities []*ActivityDiagnostics `json:"activities,omitempty"` // Its ASGs recent scaling activities, newest first
+	Instances  []*InstanceDiagnostics `json:"instances,omitempty"`  // Its instances that were not healthy
+}
+
+// ActivityDiagnostics is a scaling activity of the ASG
+type ActivityDiagnostics struct {
+	StartTime     *time.Time `json:"start_time,omitempty"`
+	StatusCode    *string    `json:"status_code,omitempty"`
+	Description   *string    `json:"description,omitempty"`
+	StatusMessage *string    `json:"status_message,omitempty"`
+}
+
+// InstanceDiagnostics is the state of an instance that was not healthy wherever it is checked
+type InstanceDiagnostics struct {
+	InstanceID     *string  `json:"instance_id,omitempty"`
+	State          *string  `json:"state,omitempty"`           // EC2 state, e.g. running
+	SystemStatus   *string  `json:"system_status,omitempty"`   // EC2 system status check
+	InstanceStatus *string  `json:"instance_status,omitempty"` // EC2 instance status check
+	LoadBalancers  []string `json:"load_balancers,omitempty"`  // Its health and reason in each ELB and target group
+	ConsoleOutput  *string  `json:"console_output,omitempty"`  // The last lines of its console output
+}
+
+// GatherDiagnostics records the diagnostics of every service that is not healthy
+// Best effort, as the release is failing whatever they show
+func (release *Release) GatherDiagnostics(asgc aws.ASGAPI, elbc aws.ELBAPI, albc aws.ALBAPI, ec2c aws.EC2API) {
+	for name, service := range release.Services {
+		if service == nil || service.CreatedASG == nil || service.Healthy {
+			continue
+		}
+
+		if release.Diagnostics == nil {
+			release.Diagnostics = map[string]*Diagnostics{}
+		}
+		release.Diagnostics[name] = service.gatherDiagnostics(asgc, elbc, albc, ec2c)
+	}
+}
+
+func (service *Service) gatherDiagnostics(asgc aws.ASGAPI, elbc aws.ELBAPI, albc aws.ALBAPI, ec2c aws.EC2API) *Diagnostics {
+	diagnostics := &Diagnostics{}
+
+	out, err := asgc.DescribeScalingActivities(&autoscaling.DescribeScalingActivitiesInput{
+		AutoScalingGroupName: service.CreatedASG,
+		MaxRecords:           to.Int64p(maxDiagnosedActivities),
+	})
+
+	if err == nil {
+		for _, activity := range out.Activities {
+			diagnostics.Activities = append(diagnostics.Activities, &ActivityDiagnostics{
+				StartTime:     activity.StartTime,
+				StatusCode:    activity.StatusCode,
+				Description:   activity.Description,
+				StatusMessage: activity.StatusMessage,
+			})
+		}
+	}
+
+	instances, err := asg.GetInstances(asgc, service.CreatedASG)
+	if err != nil {
+		return diagnostics
+	}
+
+	// Instances healthy in the ASG can still be unhealthy in its load balancers
+	fl := fleet.New(instances)
+	if service.Resources != nil {
+		for _, name := range service.Resources.ELBs {
+			if elbInstances, err := fl.ELBInstances(elbc, name); err == nil {
+				instances = instances.MergeInstances(elbInstances)
+			}
+		}
+	}
+
+	for _, arn := range service.targetGroupArns() {
+		if tgInstances, err := fl.TargetGroupInstances(albc, arn); err == nil {
+			instances = instances.MergeInstances(tgInstances)
+		}
+	}
+
+	ids := append(instances.UnhealthyIDs(), instances.TerminatingIDs()...)
+	sort.Strings(ids)
+	if len(ids) > maxDiagnosedInstances {
+		ids = ids[:maxDiagnosedInstances]
+	}
+
+	if len(ids) == 0 {
+		return diagnostics
+	}
+
+	byID := map[string]*InstanceDiagnostics{}
+	for _, id := range ids {
+		byID[id] = &InstanceDiagnostics{InstanceID: to.Strp(id)}
+		diagnostics.Instances = append(diagnostics.Instances, byID[id])
+	}
+
+	statuses, err := ec2c.DescribeInstanceStatus(&ec2.DescribeInstanceStatusInput{
+		InstanceIds:         strps(ids),
+		IncludeAllInstances: to.Boolp(true),
+	})
+
+	if err == nil {
+		for _, status := range statuses.InstanceStatuses {
+			d := byID[to.Strs(status.InstanceId)]
+			if d == nil {
+				continue
+			}
+
+			if status.InstanceState != nil {
+				d.State = status.InstanceState.Name
+			}
+			if status.SystemStatus != nil {
+				d.SystemStatus = status.SystemStatus.Status
+			}
+			if status.InstanceStatus != nil {
+				d.InstanceStatus = status.InstanceStatus.Status
+			}
+		}
+	}
+
+	if service.Resources != nil {
+		for _, name := range service.Resources.ELBs {
+			health, err := elbc.DescribeInstanceHealth(&elb.DescribeInstanceHealthInput{LoadBalancerName: name})
+			if err != nil {
+				continue
+			}
+
+			for _, state := range health.InstanceStates {
+				if d := byID[to.Strs(state.InstanceId)]; d != nil {
+					d.LoadBalancers = append(d.LoadBalancers, diagnosisStr(*name, state.State, state.ReasonCode, state.Description))
+				}
+			}
+		}
+	}
+
+	for _, arn := range service.targetGroupArns() {
+		targets := []*elbv2.TargetDescription{}
+		for _, id := range ids {
+			targets = append(targets, &elbv2.TargetDescription{Id: to.Strp(id)})
+		}
+
+		health, err := albc.DescribeTargetHealth(&elbv2.DescribeTargetHealthInput{TargetGroupArn: arn, Targets: targets})
+		if err != nil {
+			continue
+		}
+
+		for _, thd := range health.TargetHealthDescriptions {
+			if thd.Target == nil || thd.TargetHealth == nil {
+				continue
+			}
+
+			if d := byID[to.Strs(thd.Target.Id)]; d != nil {
+				d.LoadBalancers = append(d.LoadBalancers, diagnosisStr(*arn, thd.TargetHealth.State, thd.TargetHealth.Reason, thd.TargetHealth.Description))
+			}
+		}
+	}
+
+	for _, d := range diagnostics.Instances {
+		d.ConsoleOutput = consoleTail(ec2c, d.InstanceID)
+	}
+
+	return diagnostics
+}
+
+func diagnosisStr(name string, state *string, reason *string, description *string) string {
+	parts := []string{name, to.Strs(state)}
+	if reason != nil {
+		parts = append(parts, *reason)
+	}
+	if description != nil {
+		parts = append(parts, *description)
+	}
+	return strings.Join(parts, " ")
+}
+
+// consoleTail returns the last lines of the instances console output, which EC2 only has once it has booted
+func consoleTail(ec2c aws.EC2API, id *string) *string {
+	out, err := ec2c.GetConsoleOutput(&ec2.GetConsoleOutputInput{InstanceId: id, Latest: to.Boolp(true)})
+	if err != nil || out.Output == nil {
+		return nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(*out.Output)
+	if err != nil {
+		return nil
+	}
+
+	lines := strings.Split(strings.TrimRight(string(raw), "\n"), "\n")
+	if len(lines) > consoleTailLines {
+		lines = lines[len(lines)-consoleTailLines:]
+	}
+
+	return to.Strp(strings.Join(lines, "\n"))
+}
+
+// DiagnosticsSummary returns a line for each unhealthy instance and failed scaling activity, to append to the releases error
+func (release *Release) DiagnosticsSummary() []string {
+	names := []string{}
+	for name := range release.Diagnostics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := []string{}
+	for _, name := range names {
+		diagnostics := release.Diagnostics[name]
+		if diagnostics == nil {
+			continue
+		}
+
+		for _, activity := range diagnostics.Activities {
+			if to.Strs(activity.StatusCode) == autoscaling.ScalingActivityStatusCodeFailed {
+				lines = append(lines, fmt.Sprintf("%v: scaling failed: %v", name, to.Strs(activity.StatusMessage)))
+			}
+		}
+
+		for _, d := range diagnostics.Instances {
+			line := fmt.Sprintf("%v: %v %v system:%v instance:%v", name, to.Strs(d.InstanceID), to.Strs(d.State), to.Strs(d.SystemStatus), to.Strs(d.InstanceStatus))
+			if len(d.LoadBalancers) > 0 {
+				line = fmt.Sprintf("%v (%v)", line, strings.Join(d.LoadBalancers, "; "))
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_GatherDiagnostics(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	awsc := MockAwsClients(release)
+	assert.NoError(t, release.CreateResources(awsc.ASG, awsc.CW, awsc.EC2))
+
+	// The instance is healthy in its ASG but not its ELB
+	web := release.Services["web"]
+	web.Resources.ELBs = []*string{to.Strp("web-elb")}
+	awsc.ELB.DescribeInstanceHealthResp["web-elb"] = &mocks.DescribeInstanceHealthResponse{
+		Resp: &elb.DescribeInstanceHealthOutput{
+			InstanceStates: []*elb.InstanceState{{
+				InstanceId:  to.Strp("InstanceId1"),
+				State:       to.Strp("OutOfService"),
+				ReasonCode:  to.Strp("Instance"),
+				Description: to.Strp("Instance has failed at least the UnhealthyThreshold number of health checks consecutively."),
+			}},
+		},
+	}
+
+	awsc.EC2.InstanceStatuses = []*ec2.InstanceStatus{{
+		InstanceId:     to.Strp("InstanceId1"),
+		InstanceState:  &ec2.InstanceState{Name: to.Strp("running")},
+		SystemStatus:   &ec2.InstanceStatusSummary{Status: to.Strp("ok")},
+		InstanceStatus: &ec2.InstanceStatusSummary{Status: to.Strp("impaired")},
+	}}
+
+	console := []string{}
+	for i := 0; i < 30; i++ {
+		console = append(console, fmt.Sprintf("boot line %v", i))
+	}
+	awsc.EC2.ConsoleOutputs = map[string]string{"InstanceId1": strings.Join(console, "\n")}
+	awsc.ASG.AddInsufficientCapacityActivity(time.Now())
+
+	release.GatherDiagnostics(awsc.ASG, awsc.ELB, awsc.ALB, awsc.EC2)
+
+	diagnostics := release.Diagnostics["web"]
+	assert.NotNil(t, diagnostics)
+	assert.Equal(t, 1, len(diagnostics.Activities))
+	assert.Equal(t, 1, len(diagnostics.Instances))
+
+	instance := diagnostics.Instances[0]
+	assert.Equal(t, "InstanceId1", *instance.InstanceID)
+	assert.Equal(t, "running", *instance.State)
+	assert.Equal(t, "impaired", *instance.InstanceStatus)
+	assert.Regexp(t, "web-elb OutOfService Instance", instance.LoadBalancers[0])
+
+	// Only the tail of the console output is kept
+	lines := strings.Split(*instance.ConsoleOutput, "\n")
+	assert.Equal(t, consoleTailLines, len(lines))
+	assert.Equal(t, "boot line 29", lines[len(lines)-1])
+
+	summary := release.DiagnosticsSummary()
+	assert.Equal(t, 2, len(summary))
+	assert.Regexp(t, "web: scaling failed: .*InsufficientInstanceCapacity", summary[0])
+	assert.Regexp(t, "web: InstanceId1 running system:ok instance:impaired \\(web-elb OutOfService", summary[1])
+}
+
+func Test_Release_GatherDiagnostics_Healthy(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	awsc := MockAwsClients(release)
+	assert.NoError(t, release.CreateResources(awsc.ASG, awsc.CW, awsc.EC2))
+
+	// Healthy services are not diagnosed
+	release.Services["web"].Healthy = true
+	release.GatherDiagnostics(awsc.ASG, awsc.ELB, awsc.ALB, awsc.EC2)
+	assert.Nil(t, release.Diagnostics)
+	assert.Equal(t, 0, len(release.DiagnosticsSummary()))
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/coinbase/step/utils/to"
+)
+
+// emfWriter receives the EMF records, in Lambda stdout goes to CloudWatch Logs which extracts the metrics
+var emfWriter io.Writer = os.Stdout
+
+// StateMetrics are measured while a state machine task handles the release
+type StateMetrics struct {
+	State    string
+	Duration time.Duration
+	Calls    int64 // AWS API calls made by the task
+	Retries  int64 // AWS API calls retried by the SDK
+	Failed   bool
+}
+
+// EMFRecords returns CloudWatch Embedded Metric Format records of the states metrics,
+// with a record per service of its launching instances while checking health
+func (release *Release) EMFRecords(m *StateMetrics, now time.Time) []map[string]interface{} {
+	if release.ProjectName == nil || release.ConfigName == nil {
+		return nil // Dimensions must have values
+	}
+
+	failed := 0.0
+	if m.Failed {
+		failed = 1.0
+	}
+
+	records := []map[string]interface{}{
+		release.emfRecord(now, map[string]string{"State": m.State}, []*Metric{
+			&Metric{Name: "StateDuration", Unit: "Seconds", Value: m.Duration.Seconds()},
+			&Metric{Name: "AWSCalls", Unit: "Count", Value: float64(m.Calls)},
+			&Metric{Name: "AWSRetries", Unit: "Count", Value: float64(m.Retries)},
+			&Metric{Name: "StateErrors", Unit: "Count", Value: failed},
+		}),
+	}
+
+	if m.State != "CheckHealthy" {
+		return records
+	}
+
+	names := []string{}
+	for name := range release.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		service := release.Services[name]
+		if service == nil || service.HealthReport == nil || service.HealthReport.Launching == nil {
+			continue
+		}
+
+		records = append(records, release.emfRecord(now, map[string]string{"ServiceName": name}, []*Metric{
+			&Metric{Name: "InstancesLaunching", Unit: "Count", Value: float64(*service.HealthReport.Launching)},
+		}))
+	}
+
+	return records
+}
+
+// emfRecord returns a record of the metrics dimensioned by project, config and the extra dimensions
+func (release *Release) emfRecord(now time.Time, extra map[string]string, metrics []*Metric) map[string]interface{} {
+	record := map[string]interface{}{
+		"ProjectName": *release.ProjectName,
+		"ConfigName":  *release.ConfigName,
+		"ReleaseID":   to.Strs(release.ReleaseID), // Not a dimension, but searchable in the logs
+	}
+
+	dimensions := []string{"ProjectName", "ConfigName"}
+	for _, key := range sortedKeys(extra) {
+		dimensions = append(dimensions, key)
+		record[key] = extra[key]
+	}
+
+	definitions := []map[string]string{}
+	for _, metric := range metrics {
+		definitions = append(definitions, map[string]string{"Name": metric.Name, "Unit": metric.Unit})
+		record[metric.Name] = metric.Value
+	}
+
+	record["_aws"] = map[string]interface{}{
+		"Timestamp": now.UnixNano() / int64(time.Millisecond),
+		"CloudWatchMetrics": []map[string]interface{}{
+			map[string]interface{}{
+				"Namespace":  MetricsNamespace,
+				"Dimensions": [][]string{dimensions},
+				"Metrics":    definitions,
+			},
+		},
+	}
+
+	return record
+}
+
+// EmitStateMetrics writes the EMF records of the states metrics, one JSON object per line
+func (release *Release) EmitStateMetrics(m *StateMetrics) error {
+	for _, record := range release.EMFRecords(m, time.Now()) {
+		raw, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+
+		if _, err := emfWriter.Write(append(raw, '\n')); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
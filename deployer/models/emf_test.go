@@ -0,0 +1,74 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_EMFRecords(t *testing.T) {
+	release := MockRelease(t)
+	release.Services["web"].HealthReport = &HealthReport{Launching: to.Intp(2)}
+
+	now := time.Unix(1500000000, 0)
+	records := release.EMFRecords(&StateMetrics{
+		State:    "CheckHealthy",
+		Duration: 1500 * time.Millisecond,
+		Calls:    12,
+		Retries:  1,
+	}, now)
+
+	assert.Equal(t, 2, len(records))
+
+	state := records[0]
+	assert.Equal(t, "CheckHealthy", state["State"])
+	assert.Equal(t, 1.5, state["StateDuration"])
+	assert.Equal(t, 12.0, state["AWSCalls"])
+	assert.Equal(t, 1.0, state["AWSRetries"])
+	assert.Equal(t, 0.0, state["StateErrors"])
+
+	meta := state["_aws"].(map[string]interface{})
+	assert.Equal(t, int64(1500000000000), meta["Timestamp"])
+	cwm := meta["CloudWatchMetrics"].([]map[string]interface{})[0]
+	assert.Equal(t, "Odin", cwm["Namespace"])
+	assert.Equal(t, [][]string{[]string{"ProjectName", "ConfigName", "State"}}, cwm["Dimensions"])
+
+	service := records[1]
+	assert.Equal(t, "web", service["ServiceName"])
+	assert.Equal(t, 2.0, service["InstancesLaunching"])
+}
+
+func Test_Release_EMFRecords_Services_Only_CheckHealthy(t *testing.T) {
+	release := MockRelease(t)
+	release.Services["web"].HealthReport = &HealthReport{Launching: to.Intp(2)}
+
+	records := release.EMFRecords(&StateMetrics{State: "Deploy", Failed: true}, time.Now())
+	assert.Equal(t, 1, len(records))
+	assert.Equal(t, 1.0, records[0]["StateErrors"])
+
+	release.ProjectName = nil
+	assert.Equal(t, 0, len(release.EMFRecords(&StateMetrics{State: "Deploy"}, time.Now())))
+}
+
+func Test_Release_EmitStateMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	writer := emfWriter
+	emfWriter = &buf
+	defer func() { emfWriter = writer }()
+
+	release := MockRelease(t)
+	assert.NoError(t, release.EmitStateMetrics(&StateMetrics{State: "Lock"}))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, 1, len(lines))
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "Lock", record["State"])
+	assert.Equal(t, "rr", record["ReleaseID"])
+}
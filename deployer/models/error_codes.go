@@ -0,0 +1,149 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/coinbase/odin/aws/quota"
+	"github.com/coinbase/step/bifrost"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Stable error codes of failed releases, so callers can handle each cause without matching messages
+const (
+	ErrorCodeValidationSHA = "E_VALIDATION_SHA"
+	ErrorCodeValidation    = "E_VALIDATION"
+	ErrorCodeLock          = "E_LOCK"
+	ErrorCodeDeploy        = "E_DEPLOY"
+	ErrorCodeHealthTimeout = "E_HEALTH_TIMEOUT"
+	ErrorCodeHealth        = "E_HEALTH"
+	ErrorCodeHalt          = "E_HALT"
+	ErrorCodeCleanUp       = "E_CLEANUP"
+	ErrorCodeThrottle      = "E_THROTTLE"
+	ErrorCodeQuota         = "E_QUOTA"
+	ErrorCodeUnknown       = "E_UNKNOWN"
+)
+
+// AWS error codes of exceeded quotas
+var quotaErrorCodes = map[string]bool{
+	"LimitExceeded":                 true,
+	"LimitExceededException":        true,
+	"InstanceLimitExceeded":         true,
+	"VcpuLimitExceeded":             true,
+	"ServiceQuotaExceededException": true,
+}
+
+// codePrefix matches the error code ErrorCause puts before the message
+var codePrefix = regexp.MustCompile(`^(E_[A-Z_]+): `)
+
+// ErrorCoder is an error created with its stable error code
+type ErrorCoder interface {
+	ErrorCode() string
+}
+
+// CodedError is an error with the error code of its cause
+type CodedError struct {
+	Code  string
+	Cause string
+}
+
+// Error returns error
+func (e *CodedError) Error() string {
+	return e.Cause
+}
+
+// ErrorCode returns the error code
+func (e *CodedError) ErrorCode() string {
+	return e.Code
+}
+
+// errorCodeOf returns the error code the error was created with, or "" if it has none
+func errorCodeOf(err error) string {
+	switch e := err.(type) {
+	case ErrorCoder:
+		return e.ErrorCode()
+	case *quota.ExceededError:
+		return ErrorCodeQuota
+	case awserr.Error:
+		if request.IsErrorThrottle(err) {
+			return ErrorCodeThrottle
+		}
+		if quotaErrorCodes[e.Code()] {
+			return ErrorCodeQuota
+		}
+	}
+
+	return ""
+}
+
+// prefixError prefixes the errors message, keeping its error code
+func prefixError(prefix string, err error) error {
+	cause := fmt.Sprintf("%v %v", prefix, err.Error())
+	if code := errorCodeOf(err); code != "" {
+		return &CodedError{Code: code, Cause: cause}
+	}
+	return fmt.Errorf("%v", cause)
+}
+
+// ErrorCause returns the cause of the handlers error, starting with its error code if it was created with one
+// Only the type and message of an error leave the Lambda, so the code is carried in the message
+func ErrorCause(err error) string {
+	if code := errorCodeOf(err); code != "" {
+		return fmt.Sprintf("%v: %v", code, err.Error())
+	}
+	return err.Error()
+}
+
+// ErrorCode returns the error code of a releases error, or nil if it has not failed
+// The code the error was created with is used, otherwise the code of its type
+func ErrorCode(releaseError *bifrost.ReleaseError) *string {
+	if releaseError == nil || releaseError.Error == nil {
+		return nil
+	}
+
+	if match := codePrefix.FindStringSubmatch(errorMessage(releaseError.Cause)); match != nil {
+		return to.Strp(match[1])
+	}
+
+	switch *releaseError.Error {
+	case "BadReleaseError":
+		return to.Strp(ErrorCodeValidation)
+	case "LockExistsError", "LockError":
+		return to.Strp(ErrorCodeLock)
+	case "DeployError":
+		return to.Strp(ErrorCodeDeploy)
+	case "HaltError":
+		return to.Strp(ErrorCodeHalt)
+	case "HealthError":
+		return to.Strp(ErrorCodeHealth)
+	case "CleanUpError":
+		return to.Strp(ErrorCodeCleanUp)
+	}
+
+	return to.Strp(ErrorCodeUnknown)
+}
+
+// errorMessage returns the errorMessage of a Lambda error cause, or the cause if it is not one
+func errorMessage(cause *string) string {
+	if cause == nil {
+		return ""
+	}
+
+	var lambdaError struct {
+		ErrorMessage string `json:"errorMessage"`
+	}
+
+	if err := json.Unmarshal([]byte(*cause), &lambdaError); err != nil || lambdaError.ErrorMessage == "" {
+		return *cause
+	}
+
+	return lambdaError.ErrorMessage
+}
+
+// SetErrorCode records the error code of the releases error in its output
+func (release *Release) SetErrorCode() {
+	release.ErrorCode = ErrorCode(release.Error)
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/coinbase/odin/aws/quota"
+	"github.com/coinbase/step/bifrost"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ErrorCode(t *testing.T) {
+	code := func(errorType string, err error) string {
+		cause := `{"errorMessage": "` + ErrorCause(err) + `", "errorType": "` + errorType + `"}`
+		return *ErrorCode(&bifrost.ReleaseError{Error: to.Strp(errorType), Cause: to.Strp(cause)})
+	}
+
+	assert.Nil(t, ErrorCode(nil))
+
+	sha := &CodedError{Code: ErrorCodeValidationSHA, Cause: "UserData SHA incorrect"}
+	assert.Equal(t, ErrorCodeValidationSHA, code("BadReleaseError", sha))
+	assert.Equal(t, ErrorCodeValidationSHA, code("BadReleaseError", prefixError("Release(1):", sha)))
+	assert.Equal(t, ErrorCodeValidation, code("BadReleaseError", fmt.Errorf("ServiceName must be defined")))
+
+	assert.Equal(t, ErrorCodeHealthTimeout, code("HaltError", &PhaseTimeoutError{Phase: PhaseHealthy, Timeout: 600}))
+	assert.Equal(t, ErrorCodeHealthTimeout, code("HaltError", &HaltError{&PhaseTimeoutError{Phase: PhaseLaunch, Timeout: 600}}))
+	assert.Equal(t, ErrorCodeHalt, code("HaltError", &HaltError{fmt.Errorf("Found terming instances")}))
+	assert.Equal(t, ErrorCodeLock, code("LockExistsError", fmt.Errorf("Lock Already Exists")))
+
+	assert.Equal(t, ErrorCodeThrottle, code("DeployError", awserr.New("Throttling", "Rate exceeded", nil)))
+	assert.Equal(t, ErrorCodeQuota, code("DeployError", awserr.New("VcpuLimitExceeded", "You have requested more vCPU capacity", nil)))
+	assert.Equal(t, ErrorCodeQuota, code("BadReleaseError", prefixError("Release(1):", &quota.ExceededError{Name: "auto scaling groups", Current: 200, Needed: 1, Limit: 200})))
+
+	// Codes are never guessed from messages
+	assert.Equal(t, ErrorCodeDeploy, code("DeployError", fmt.Errorf("Throttling: Rate exceeded")))
+	assert.Equal(t, ErrorCodeHalt, code("HaltError", fmt.Errorf("Timeout waiting")))
+
+	assert.Equal(t, ErrorCodeUnknown, *ErrorCode(&bifrost.ReleaseError{Error: to.Strp("States.Timeout"), Cause: to.Strp("")}))
+	assert.Equal(t, ErrorCodeDeploy, *ErrorCode(&bifrost.ReleaseError{Error: to.Strp("DeployError"), Cause: to.Strp("not json")}))
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/sns"
+	"github.com/coinbase/step/utils/to"
+)
+
+// ReleaseEvent is published to the deployers SNS topic on each deploy transition
+type ReleaseEvent struct {
+	Event   string    `json:"event"`
+	Time    time.Time `json:"time"`
+	Release *Release  `json:"release"`
+}
+
+// PublishEvent publishes the event with a snapshot of the release to the topic
+// project_name, config_name and event are message attributes for subscription filter policies
+func (release *Release) PublishEvent(snsc aws.SNSAPI, topicARN *string, event string) error {
+	raw, err := json.Marshal(&ReleaseEvent{
+		Event:   event,
+		Time:    time.Now().UTC(),
+		Release: release,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return sns.Publish(snsc, topicARN, to.Strp(string(raw)), map[string]*string{
+		"event":        to.Strp(event),
+		"project_name": release.ProjectName,
+		"config_name":  release.ConfigName,
+	})
+}
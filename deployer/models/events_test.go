@@ -0,0 +1,27 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_PublishEvent(t *testing.T) {
+	release := MockRelease(t)
+	awsc := mocks.MockAWS()
+
+	assert.NoError(t, release.PublishEvent(awsc.SNS, to.Strp("arn:aws:sns:us-east-1:000000000000:odin-events"), NotifyHealthy))
+	assert.Equal(t, 1, len(awsc.SNS.Published))
+
+	published := awsc.SNS.Published[0]
+	assert.Equal(t, "healthy", *published.MessageAttributes["event"].StringValue)
+	assert.Equal(t, *release.ProjectName, *published.MessageAttributes["project_name"].StringValue)
+
+	var event ReleaseEvent
+	assert.NoError(t, json.Unmarshal([]byte(*published.Message), &event))
+	assert.Equal(t, NotifyHealthy, event.Event)
+	assert.Equal(t, *release.ReleaseID, *event.Release.ReleaseID)
+}
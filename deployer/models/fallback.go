@@ -0,0 +1,50 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/asg"
+	"github.com/coinbase/odin/aws/lc"
+	"github.com/coinbase/step/utils/to"
+)
+
+// launchConfigurationName is the ServiceID, with the instance type once it has fallen back
+func (service *Service) launchConfigurationName() *string {
+	if service.InstanceTypeFallbackAt == nil || service.ServiceID() == nil {
+		return service.ServiceID()
+	}
+
+	return to.Strp(fmt.Sprintf("%v-%v", *service.ServiceID(), to.Strs(service.InstanceType)))
+}
+
+// fallbackInstanceType switches the ASG to the next fallback instance type
+// if launching instances failed with InsufficientInstanceCapacity since the last switch
+func (service *Service) fallbackInstanceType(asgc aws.ASGAPI) error {
+	if len(service.InstanceTypeFallbacks) == 0 {
+		return nil
+	}
+
+	insufficient, err := asg.InsufficientCapacity(asgc, service.CreatedASG, service.InstanceTypeFallbackAt)
+	if err != nil || !insufficient {
+		return err
+	}
+
+	previous := service.launchConfigurationName()
+
+	service.InstanceType = service.InstanceTypeFallbacks[0]
+	service.InstanceTypeFallbacks = service.InstanceTypeFallbacks[1:]
+	service.InstanceTypeFallbackAt = to.Timep(time.Now())
+
+	if err := service.createLaunchConfiguration(asgc); err != nil {
+		return err
+	}
+
+	if err := asg.UpdateLaunchConfiguration(asgc, service.CreatedASG, service.launchConfigurationName()); err != nil {
+		return err
+	}
+
+	// The ASGs teardown only deletes its current launch configuration
+	return lc.Teardown(asgc, previous)
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Service_FallbackInstanceType(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	service := release.Services["web"]
+	service.CreatedASG = service.ServiceID()
+	service.InstanceTypeFallbacks = []*string{to.Strp("m5.large"), to.Strp("c5.large")}
+
+	asgc := &mocks.ASGClient{}
+
+	// No failures keeps the instance type
+	assert.NoError(t, service.fallbackInstanceType(asgc))
+	assert.Equal(t, 2, len(service.InstanceTypeFallbacks))
+	assert.Equal(t, *service.ServiceID(), *service.launchConfigurationName())
+
+	asgc.AddInsufficientCapacityActivity(time.Now().Add(-time.Minute))
+	assert.NoError(t, service.fallbackInstanceType(asgc))
+	assert.Equal(t, "m5.large", *service.InstanceType)
+	assert.Equal(t, []string{"c5.large"}, to.StrSlice(service.InstanceTypeFallbacks))
+	assert.Equal(t, *service.ServiceID()+"-m5.large", *asgc.UpdateAutoScalingGroupInputs[0].LaunchConfigurationName)
+
+	// The failure before the switch is ignored
+	assert.NoError(t, service.fallbackInstanceType(asgc))
+	assert.Equal(t, "m5.large", *service.InstanceType)
+
+	asgc.AddInsufficientCapacityActivity(time.Now().Add(time.Minute))
+	assert.NoError(t, service.fallbackInstanceType(asgc))
+	assert.Equal(t, "c5.large", *service.InstanceType)
+	assert.Equal(t, 0, len(service.InstanceTypeFallbacks))
+}
+
+func Test_Service_FallbackInstanceType_Architecture(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	service := release.Services["web"]
+	service.InstanceTypeFallbacks = []*string{to.Strp("m6g.large")}
+
+	awsc := MockAwsClients(release)
+	awsc.EC2.InstanceTypeArchitectures = map[string][]string{"m6g.large": []string{"arm64"}}
+	resources, err := release.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS)
+	assert.NoError(t, err)
+	assert.Error(t, release.ValidateResources(resources))
+}
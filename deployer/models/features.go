@@ -0,0 +1,91 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/coinbase/step/utils/to"
+)
+
+// Experimental behaviors a release can enable with features, once the deployer allows it
+const (
+	// FeatureLaunchTemplates launches every service from a launch template instead of a launch configuration
+	FeatureLaunchTemplates = "launch_templates"
+)
+
+var knownFeatures = map[string]bool{
+	FeatureLaunchTemplates: true,
+}
+
+// FeatureAllowlist maps features to the project configs that can enable them
+// Each pattern is matched against <project_name>/<config_name>, e.g. "coinbase/*"
+type FeatureAllowlist map[string][]string
+
+// ParseFeatureAllowlist parses the allowlist, an empty string allows no features
+func ParseFeatureAllowlist(raw string) (FeatureAllowlist, error) {
+	if raw == "" {
+		return FeatureAllowlist{}, nil
+	}
+
+	var allowlist FeatureAllowlist
+	if err := json.Unmarshal([]byte(raw), &allowlist); err != nil {
+		return nil, fmt.Errorf("Features invalid %v", err.Error())
+	}
+
+	for feature, patterns := range allowlist {
+		if !knownFeatures[feature] {
+			return nil, fmt.Errorf("Feature %q unknown", feature)
+		}
+
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("Feature pattern %q for %v invalid %v", pattern, feature, err.Error())
+			}
+		}
+	}
+
+	return allowlist, nil
+}
+
+// Allowed returns whether the project config can enable the feature
+func (allowlist FeatureAllowlist) Allowed(feature string, projectName *string, configName *string) bool {
+	projectConfig := fmt.Sprintf("%v/%v", to.Strs(projectName), to.Strs(configName))
+
+	for _, pattern := range allowlist[feature] {
+		if ok, _ := path.Match(pattern, projectConfig); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidateFeatures errors if the release enables a feature the deployer does not allow for its project config
+func (release *Release) ValidateFeatures(allowlist FeatureAllowlist) error {
+	features := []string{}
+	for feature, enabled := range release.Features {
+		if enabled {
+			features = append(features, feature)
+		}
+	}
+	sort.Strings(features)
+
+	for _, feature := range features {
+		if !knownFeatures[feature] {
+			return fmt.Errorf("Feature %q unknown", feature)
+		}
+
+		if !allowlist.Allowed(feature, release.ProjectName, release.ConfigName) {
+			return fmt.Errorf("Feature %q is not allowed for %v/%v", feature, to.Strs(release.ProjectName), to.Strs(release.ConfigName))
+		}
+	}
+
+	return nil
+}
+
+// FeatureEnabled returns whether the release enabled the feature
+func (release *Release) FeatureEnabled(feature string) bool {
+	return release.Features[feature]
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseFeatureAllowlist(t *testing.T) {
+	allowlist, err := ParseFeatureAllowlist("")
+	assert.NoError(t, err)
+	assert.False(t, allowlist.Allowed(FeatureLaunchTemplates, to.Strp("project"), to.Strp("config")))
+
+	allowlist, err = ParseFeatureAllowlist(`{"launch_templates": ["project/*"]}`)
+	assert.NoError(t, err)
+	assert.True(t, allowlist.Allowed(FeatureLaunchTemplates, to.Strp("project"), to.Strp("config")))
+	assert.False(t, allowlist.Allowed(FeatureLaunchTemplates, to.Strp("other"), to.Strp("config")))
+
+	_, err = ParseFeatureAllowlist(`{"unknown": ["*/*"]}`)
+	assert.Error(t, err)
+
+	_, err = ParseFeatureAllowlist(`{"launch_templates": ["["]}`)
+	assert.Error(t, err)
+
+	_, err = ParseFeatureAllowlist(`launch_templates`)
+	assert.Error(t, err)
+}
+
+func Test_Release_ValidateFeatures(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	assert.NoError(t, release.ValidateFeatures(FeatureAllowlist{}))
+
+	release.Features = map[string]bool{FeatureLaunchTemplates: true}
+	assert.Error(t, release.ValidateFeatures(FeatureAllowlist{}))
+	assert.Error(t, release.ValidateFeatures(FeatureAllowlist{FeatureLaunchTemplates: []string{"other/*"}}))
+	assert.NoError(t, release.ValidateFeatures(FeatureAllowlist{FeatureLaunchTemplates: []string{"*/*"}}))
+
+	// Disabled features are not checked
+	release.Features = map[string]bool{"unknown": false}
+	assert.NoError(t, release.ValidateFeatures(FeatureAllowlist{}))
+
+	release.Features = map[string]bool{"unknown": true}
+	assert.Error(t, release.ValidateFeatures(FeatureAllowlist{"unknown": []string{"*/*"}}))
+}
+
+func Test_Release_FeatureLaunchTemplates(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	service := release.Services["web"]
+
+	assert.False(t, service.usesLaunchTemplate())
+
+	release.Features = map[string]bool{FeatureLaunchTemplates: true}
+	assert.True(t, service.usesLaunchTemplate())
+
+	awsc := MockAwsClients(release)
+	assert.NoError(t, service.CreateResources(awsc.ASG, awsc.CW, awsc.EC2))
+	assert.NotNil(t, awsc.EC2.LaunchTemplates[*service.ServiceID()])
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coinbase/step/utils/to"
+)
+
+// HardeningPreset is a named set of security defaults enforced on every service in a release
+type HardeningPreset struct {
+	EBSEncrypted     bool     // EBS volumes must be encrypted
+	NoPublicIP       bool     // Instances must not have a public IP
+	IMDSv2           bool     // Instances must use session tokens for the metadata service, so launch from a launch template
+	SSMOnly          bool     // Instances are accessed through SSM so require an instance profile
+	RequiredUserData []string // Strings, e.g. security agents, the userdata must contain
+}
+
+// Presets are defined in the deployer so they are reviewed once, not in each release
+var hardeningPresets = map[string]*HardeningPreset{
+	"baseline": &HardeningPreset{
+		EBSEncrypted: true,
+		NoPublicIP:   true,
+		IMDSv2:       true,
+	},
+	"cis": &HardeningPreset{
+		EBSEncrypted:     true,
+		NoPublicIP:       true,
+		IMDSv2:           true,
+		SSMOnly:          true,
+		RequiredUserData: []string{"amazon-ssm-agent"},
+	},
+}
+
+// HardeningPreset returns the releases hardening preset
+func (release *Release) HardeningPreset() *HardeningPreset {
+	if release.Hardening == nil {
+		return nil
+	}
+	return hardeningPresets[*release.Hardening]
+}
+
+// ValidateHardening validates the hardening preset exists
+func (release *Release) ValidateHardening() error {
+	if release.Hardening == nil {
+		return nil
+	}
+
+	if release.HardeningPreset() == nil {
+		return fmt.Errorf("Hardening preset %q not found", *release.Hardening)
+	}
+
+	return nil
+}
+
+// SetDefaults merges the presets values into the service where they are not defined
+func (preset *HardeningPreset) SetDefaults(service *Service) {
+	if preset.EBSEncrypted && service.EBSEncrypted == nil {
+		service.EBSEncrypted = to.Boolp(true)
+	}
+
+	if preset.IMDSv2 && service.IMDSv2 == nil {
+		service.IMDSv2 = to.Boolp(true)
+	}
+}
+
+// Validate validates the service complies with the preset
+func (preset *HardeningPreset) Validate(service *Service, userdata *string) error {
+	if preset.EBSEncrypted {
+		if service.EBSVolumeSize == nil {
+			return fmt.Errorf("Hardening requires ebs_volume_size so the volume can be encrypted")
+		}
+
+		if service.EBSEncrypted == nil || !*service.EBSEncrypted {
+			return fmt.Errorf("Hardening requires ebs_encrypted")
+		}
+	}
+
+	if publicIP := service.associatePublicIP(); preset.NoPublicIP && publicIP != nil && *publicIP {
+		return fmt.Errorf("Hardening does not allow associate_public_ip_address")
+	}
+
+	// Checked on the launch template the instances are launched from, not only the services setting
+	if preset.IMDSv2 && (!service.usesLaunchTemplate() || !service.createLaunchTemplateInput().IMDSv2Required()) {
+		return fmt.Errorf("Hardening requires imdsv2")
+	}
+
+	if preset.SSMOnly && service.Profile == nil {
+		return fmt.Errorf("Hardening requires a profile for SSM access")
+	}
+
+	for _, required := range preset.RequiredUserData {
+		if !strings.Contains(to.Strs(userdata), required) {
+			return fmt.Errorf("Hardening requires %q in the UserData", required)
+		}
+	}
+
+	return nil
+}
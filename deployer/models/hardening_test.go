@@ -0,0 +1,65 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_Hardening_Unknown(t *testing.T) {
+	r := MockRelease(t)
+	r.Hardening = to.Strp("unknown")
+	MockPrepareRelease(r)
+
+	assert.Error(t, r.ValidateHardening())
+}
+
+func Test_Release_Hardening_Baseline(t *testing.T) {
+	r := MockRelease(t)
+	r.Hardening = to.Strp("baseline")
+	MockPrepareRelease(r)
+
+	service := r.Services["web"]
+	assert.NoError(t, r.ValidateHardening())
+	assert.NoError(t, r.ValidateServices())
+
+	// Defaults are merged in
+	assert.True(t, *service.EBSEncrypted)
+	assert.False(t, *service.AssociatePublicIpAddress)
+	assert.True(t, *service.createLaunchConfigurationInput().BlockDeviceMappings[0].Ebs.Encrypted)
+
+	// IMDSv2 is required by the launch template the service launches from
+	assert.True(t, *service.IMDSv2)
+	assert.True(t, service.usesLaunchTemplate())
+	assert.Equal(t, "required", *service.createLaunchTemplateInput().LaunchTemplateData.MetadataOptions.HttpTokens)
+
+	service.IMDSv2 = to.Boolp(false)
+	assert.Error(t, r.ValidateServices())
+	service.IMDSv2 = to.Boolp(true)
+
+	service.AssociatePublicIpAddress = to.Boolp(true)
+	assert.Error(t, r.ValidateServices())
+
+	service.AssociatePublicIpAddress = nil
+	service.EBSEncrypted = to.Boolp(false)
+	assert.Error(t, r.ValidateServices())
+
+	service.EBSEncrypted = nil
+	service.EBSVolumeSize = nil
+	assert.Error(t, r.ValidateServices())
+}
+
+func Test_Release_Hardening_CIS(t *testing.T) {
+	r := MockRelease(t)
+	r.Hardening = to.Strp("cis")
+	MockPrepareRelease(r)
+
+	assert.Error(t, r.ValidateServices()) // No SSM agent in the UserData
+
+	r.SetUserData(to.Strp("#cloud_config\npackages:\n - amazon-ssm-agent"))
+	assert.NoError(t, r.ValidateServices())
+
+	r.Services["web"].Profile = nil
+	assert.Error(t, r.ValidateServices())
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"fmt"
+)
+
+// Backoffs of the wait between health checks
+const (
+	HealthPollAdaptive    = "adaptive"    // Doubles after each check, back to the interval when instances change
+	HealthPollExponential = "exponential" // Doubles after each check
+	HealthPollLinear      = "linear"      // Adds the interval after each check
+	HealthPollFixed       = "fixed"       // Always the interval
+)
+
+// maxHealthPollInterval is the longest wait between health checks, well within the lock TTL
+const maxHealthPollInterval = 300
+
+// HealthPollConfig is how often WaitForHealthy checks the health of the services, in seconds
+// By default checks start 5 seconds apart and adaptively back off to a cap that grows with the release timeout
+type HealthPollConfig struct {
+	Interval    *int    `json:"interval,omitempty"`
+	MaxInterval *int    `json:"max_interval,omitempty"`
+	Backoff     *string `json:"backoff,omitempty"`
+}
+
+// ValidateAttributes validates attributes
+func (p *HealthPollConfig) ValidateAttributes() error {
+	if p.Interval != nil && (*p.Interval < minWaitForHealthy || *p.Interval > maxHealthPollInterval) {
+		return fmt.Errorf("HealthPoll interval must be between %v and %v", minWaitForHealthy, maxHealthPollInterval)
+	}
+
+	if p.MaxInterval != nil && (*p.MaxInterval < p.interval() || *p.MaxInterval > maxHealthPollInterval) {
+		return fmt.Errorf("HealthPoll max_interval must be between the interval and %v", maxHealthPollInterval)
+	}
+
+	switch p.backoff() {
+	case HealthPollAdaptive, HealthPollExponential, HealthPollLinear, HealthPollFixed:
+	default:
+		return fmt.Errorf("HealthPoll backoff must be one of adaptive, exponential, linear or fixed")
+	}
+
+	return nil
+}
+
+func (p *HealthPollConfig) interval() int {
+	if p == nil || p.Interval == nil {
+		return minWaitForHealthy
+	}
+	return *p.Interval
+}
+
+func (p *HealthPollConfig) backoff() string {
+	if p == nil || p.Backoff == nil {
+		return HealthPollAdaptive
+	}
+	return *p.Backoff
+}
+
+// next returns the wait after a check that waited wait, before it is capped
+func (p *HealthPollConfig) next(wait int, activity bool) int {
+	switch p.backoff() {
+	case HealthPollFixed:
+		return p.interval()
+	case HealthPollLinear:
+		return wait + p.interval()
+	case HealthPollExponential:
+		return wait * 2
+	}
+
+	// Instances are launching or becoming healthy so the deploy is likely close to changing again
+	if activity {
+		return p.interval()
+	}
+	return wait * 2
+}
+
+// ValidateHealthPoll validates the releases health poll
+func (release *Release) ValidateHealthPoll() error {
+	if release.HealthPoll == nil {
+		return nil
+	}
+	return release.HealthPoll.ValidateAttributes()
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HealthPollConfig_ValidateAttributes(t *testing.T) {
+	assert.NoError(t, (&HealthPollConfig{}).ValidateAttributes())
+	assert.NoError(t, (&HealthPollConfig{Interval: to.Intp(10), MaxInterval: to.Intp(90), Backoff: to.Strp("linear")}).ValidateAttributes())
+
+	assert.Error(t, (&HealthPollConfig{Interval: to.Intp(1)}).ValidateAttributes())
+	assert.Error(t, (&HealthPollConfig{Interval: to.Intp(600)}).ValidateAttributes())
+	assert.Error(t, (&HealthPollConfig{Interval: to.Intp(30), MaxInterval: to.Intp(20)}).ValidateAttributes())
+	assert.Error(t, (&HealthPollConfig{MaxInterval: to.Intp(600)}).ValidateAttributes())
+	assert.Error(t, (&HealthPollConfig{Backoff: to.Strp("random")}).ValidateAttributes())
+}
+
+func Test_Release_HealthPoll_Validate(t *testing.T) {
+	r := MockRelease(t)
+	MockPrepareRelease(r)
+	r.HealthPoll = &HealthPollConfig{Interval: to.Intp(2)}
+	assert.Error(t, r.ValidateConfiguration())
+
+	// Checking every 5 seconds for 48 hours is too many state transitions
+	r = MockRelease(t)
+	r.Timeout = to.Intp(172800)
+	r.HealthPoll = &HealthPollConfig{Interval: to.Intp(5), Backoff: to.Strp(HealthPollFixed)}
+	MockPrepareRelease(r)
+	assert.Error(t, r.ValidateConfiguration())
+
+	// Even when the cap is long, activity resets the wait to the interval
+	r.HealthPoll = &HealthPollConfig{Interval: to.Intp(5), MaxInterval: to.Intp(300)}
+	assert.Error(t, r.ValidateConfiguration())
+
+	// The interval can be as short as the budget allows
+	r.HealthPoll = &HealthPollConfig{Interval: to.Intp(87), MaxInterval: to.Intp(300)}
+	assert.NoError(t, r.ValidateConfiguration())
+
+	r.HealthPoll = &HealthPollConfig{Interval: to.Intp(86), MaxInterval: to.Intp(300)}
+	assert.Error(t, r.ValidateConfiguration())
+}
+
+func Test_Release_UpdateWaitForHealthy_Bounds(t *testing.T) {
+	// By default the floor is raised so 48 hours of checks stay within the state transitions
+	r := MockRelease(t)
+	r.Timeout = to.Intp(172800)
+	MockPrepareRelease(r)
+	assert.NoError(t, r.ValidateConfiguration())
+	assert.Equal(t, 87, *r.WaitForHealthy)
+	assert.Equal(t, 120, r.maxWaitForHealthy())
+
+	activity := r.HealthActivity()
+
+	// Backing off stops at the cap
+	r.UpdateWaitForHealthy(activity)
+	assert.Equal(t, 120, *r.WaitForHealthy)
+	r.UpdateWaitForHealthy(activity)
+	assert.Equal(t, 120, *r.WaitForHealthy)
+
+	// Activity resets the wait no lower than the floor
+	r.Services["web"].HealthReport = &HealthReport{Healthy: to.Intp(1), Launching: to.Intp(2)}
+	r.UpdateWaitForHealthy(activity)
+	assert.Equal(t, 87, *r.WaitForHealthy)
+}
+
+func Test_Release_UpdateWaitForHealthy_HealthPoll(t *testing.T) {
+	waits := func(poll *HealthPollConfig, checks int) []int {
+		r := MockRelease(t)
+		r.Timeout = to.Intp(3600)
+		r.HealthPoll = poll
+		MockPrepareRelease(r)
+
+		activity := r.HealthActivity()
+		waits := []int{*r.WaitForHealthy}
+		for i := 0; i < checks; i++ {
+			r.UpdateWaitForHealthy(activity)
+			waits = append(waits, *r.WaitForHealthy)
+		}
+		return waits
+	}
+
+	assert.Equal(t, []int{5, 10, 20, 40, 60}, waits(nil, 4))
+	assert.Equal(t, []int{10, 20, 30, 30}, waits(&HealthPollConfig{Interval: to.Intp(10), MaxInterval: to.Intp(30)}, 3))
+	assert.Equal(t, []int{10, 20, 30, 40}, waits(&HealthPollConfig{Interval: to.Intp(10), Backoff: to.Strp(HealthPollLinear)}, 3))
+	assert.Equal(t, []int{20, 20, 20}, waits(&HealthPollConfig{Interval: to.Intp(20), Backoff: to.Strp(HealthPollFixed)}, 2))
+
+	// The default cap is never shorter than the interval
+	assert.Equal(t, []int{90, 90}, waits(&HealthPollConfig{Interval: to.Intp(90)}, 1))
+
+	// Activity resets an adaptive wait but not an exponential one
+	r := MockRelease(t)
+	r.Timeout = to.Intp(3600)
+	r.HealthPoll = &HealthPollConfig{Interval: to.Intp(10), Backoff: to.Strp(HealthPollExponential)}
+	MockPrepareRelease(r)
+	r.WaitForHealthy = to.Intp(40)
+	activity := r.HealthActivity()
+	r.Services["web"].HealthReport = &HealthReport{Healthy: to.Intp(1), Launching: to.Intp(2)}
+	r.UpdateWaitForHealthy(activity)
+	assert.Equal(t, 60, *r.WaitForHealthy)
+
+	r.HealthPoll.Backoff = nil
+	r.UpdateWaitForHealthy(activity)
+	assert.Equal(t, 10, *r.WaitForHealthy)
+}
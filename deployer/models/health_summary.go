@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/coinbase/step/utils/to"
+)
+
+// HealthSummary is the result of the latest health check of every service
+type HealthSummary struct {
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+	Duration  *float64   `json:"duration,omitempty"` // Seconds the services took to check
+
+	// Totals of the checked services
+	TargetHealthy *int `json:"target_healthy,omitempty"`
+	Healthy       *int `json:"healthy,omitempty"`
+	Launching     *int `json:"launching,omitempty"`
+	Terminating   *int `json:"terminating,omitempty"`
+
+	Services map[string]*ServiceHealthSummary `json:"services,omitempty"`
+}
+
+// ServiceHealthSummary is the result of a services latest health check
+type ServiceHealthSummary struct {
+	Healthy bool    `json:"healthy"`
+	Error   *string `json:"error,omitempty"` // The check failed, it is retried unless it halted the release
+}
+
+// updateHealthSummary summarizes the health checks of the services, errs are the services errors in order
+func (release *Release) updateHealthSummary(names []string, errs []error, start time.Time) {
+	summary := &HealthSummary{
+		CheckedAt: to.Timep(start),
+		Duration:  to.Float64p(time.Since(start).Seconds()),
+		Services:  map[string]*ServiceHealthSummary{},
+	}
+
+	targetHealthy, healthy, launching, terminating := 0, 0, 0, 0
+	for i, name := range names {
+		service := release.Services[name]
+
+		s := &ServiceHealthSummary{Healthy: service.Healthy}
+		if errs[i] != nil {
+			s.Healthy = false
+			s.Error = to.Strp(errs[i].Error())
+		}
+		summary.Services[name] = s
+
+		if report := service.HealthReport; report != nil {
+			targetHealthy += intValue(report.TargetHealthy)
+			healthy += intValue(report.Healthy)
+			launching += intValue(report.Launching)
+			terminating += intValue(report.Terminating)
+		}
+	}
+
+	summary.TargetHealthy = &targetHealthy
+	summary.Healthy = &healthy
+	summary.Launching = &launching
+	summary.Terminating = &terminating
+
+	release.HealthSummary = summary
+}
@@ -0,0 +1,224 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/fleet"
+	"github.com/coinbase/step/utils/to"
+)
+
+// HealthCheck decides the health of instances beyond the ASG, ELB and target group checks
+// Only instances that are already healthy are checked
+type HealthCheck interface {
+	Instances(ids []string) (aws.Instances, error)
+}
+
+// HealthCheckConfig struct
+type HealthCheckConfig struct {
+	Type *string `json:"type,omitempty"` // http or script
+
+	// http checks GET http://<private_ip>:<port><path> returns 2xx from the deployer
+	Path *string `json:"path,omitempty"`
+	Port *int64  `json:"port,omitempty"`
+
+	// script runs the command on the instances with SSM Run Command and checks it succeeds
+	Command *string `json:"command,omitempty"`
+
+	// Generated
+	CommandIDs map[string]string `json:"command_ids,omitempty"` // Instance ID to the ID of the command sent to it
+}
+
+// SetDefaults assigns default values
+func (hc *HealthCheckConfig) SetDefaults() {
+	if hc.Type != nil && *hc.Type == "http" && hc.Path == nil {
+		hc.Path = to.Strp("/")
+	}
+}
+
+// ValidateAttributes validates attributes
+func (hc *HealthCheckConfig) ValidateAttributes() error {
+	if hc.Type == nil {
+		return fmt.Errorf("HealthCheck type must be defined")
+	}
+
+	switch *hc.Type {
+	case "http":
+		if hc.Port == nil || *hc.Port < 1 || *hc.Port > 65535 {
+			return fmt.Errorf("HealthCheck http requires a port")
+		}
+
+		if !strings.HasPrefix(to.Strs(hc.Path), "/") {
+			return fmt.Errorf("HealthCheck http path must start with /")
+		}
+	case "script":
+		if hc.Command == nil || *hc.Command == "" {
+			return fmt.Errorf("HealthCheck script requires a command")
+		}
+	default:
+		return fmt.Errorf("HealthCheck type must be http or script")
+	}
+
+	return nil
+}
+
+// Provider returns the HealthCheck for the type
+func (hc *HealthCheckConfig) Provider(ec2c aws.EC2API, ssmc aws.SSMAPI) (HealthCheck, error) {
+	switch to.Strs(hc.Type) {
+	case "http":
+		return &httpHealthCheck{ec2c: ec2c, config: hc}, nil
+	case "script":
+		return &scriptHealthCheck{ssmc: ssmc, config: hc}, nil
+	}
+
+	return nil, fmt.Errorf("Unknown HealthCheck type %v", to.Strs(hc.Type))
+}
+
+//////////
+// http
+//////////
+
+var healthCheckClient = &http.Client{Timeout: 2 * time.Second}
+
+type httpHealthCheck struct {
+	ec2c   aws.EC2API
+	config *HealthCheckConfig
+}
+
+func (h *httpHealthCheck) Instances(ids []string) (aws.Instances, error) {
+	instances := aws.Instances{}
+	if len(ids) == 0 {
+		return instances, nil
+	}
+
+	ips := map[string]string{}
+	err := fleet.Fleet(ids).EC2Instances(h.ec2c, func(i *ec2.Instance) {
+		if i.InstanceId != nil && i.PrivateIpAddress != nil {
+			ips[*i.InstanceId] = *i.PrivateIpAddress
+		}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		ip, ok := ips[id]
+		instances.AddHealthCheckInstance(id, ok && h.healthy(ip))
+	}
+
+	return instances, nil
+}
+
+func (h *httpHealthCheck) healthy(ip string) bool {
+	resp, err := healthCheckClient.Get(fmt.Sprintf("http://%v:%v%v", ip, *h.config.Port, *h.config.Path))
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
+
+//////////
+// script
+//////////
+
+// maxCommandInstances is the most instances SSM SendCommand accepts
+const maxCommandInstances = 50
+
+// scriptHealthCheck sends the command on one check and reads its results on the next ones
+// The command is resent once it has finished without every instance succeeding
+type scriptHealthCheck struct {
+	ssmc   aws.SSMAPI
+	config *HealthCheckConfig
+}
+
+func (s *scriptHealthCheck) Instances(ids []string) (aws.Instances, error) {
+	instances := aws.Instances{}
+	if len(ids) == 0 {
+		return instances, nil
+	}
+
+	if len(s.config.CommandIDs) == 0 {
+		if err := s.send(ids); err != nil {
+			return nil, err
+		}
+
+		for _, id := range ids {
+			instances.AddHealthCheckInstance(id, false)
+		}
+		return instances, nil
+	}
+
+	running := false
+	for _, id := range ids {
+		// Instances launched after the command was sent have no invocation
+		status := ""
+		if commandID, ok := s.config.CommandIDs[id]; ok {
+			out, err := s.ssmc.GetCommandInvocation(&ssm.GetCommandInvocationInput{
+				CommandId:  to.Strp(commandID),
+				InstanceId: to.Strp(id),
+			})
+
+			if err == nil && out.Status != nil {
+				status = *out.Status
+			}
+		}
+
+		switch status {
+		case ssm.CommandInvocationStatusPending, ssm.CommandInvocationStatusInProgress, ssm.CommandInvocationStatusDelayed:
+			running = true
+		}
+
+		instances.AddHealthCheckInstance(id, status == ssm.CommandInvocationStatusSuccess)
+	}
+
+	if !running && len(instances.UnhealthyIDs()) > 0 {
+		s.config.CommandIDs = nil // Resend on the next check
+	}
+
+	return instances, nil
+}
+
+// send sends the command to the instances in batches SendCommand accepts
+func (s *scriptHealthCheck) send(ids []string) error {
+	commandIDs := map[string]string{}
+
+	for start := 0; start < len(ids); start += maxCommandInstances {
+		end := start + maxCommandInstances
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		out, err := s.ssmc.SendCommand(&ssm.SendCommandInput{
+			DocumentName: to.Strp("AWS-RunShellScript"),
+			InstanceIds:  strps(ids[start:end]),
+			Parameters:   map[string][]*string{"commands": []*string{s.config.Command}},
+		})
+
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids[start:end] {
+			commandIDs[id] = *out.Command.CommandId
+		}
+	}
+
+	s.config.CommandIDs = commandIDs
+	return nil
+}
+
+func strps(strs []string) []*string {
+	ptrs := []*string{}
+	for _, s := range strs {
+		ptrs = append(ptrs, to.Strp(s))
+	}
+	return ptrs
+}
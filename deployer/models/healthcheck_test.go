@@ -0,0 +1,123 @@
+package models
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HealthCheckConfig_ValidateAttributes(t *testing.T) {
+	hc := &HealthCheckConfig{Type: to.Strp("http"), Port: to.Int64p(8080)}
+	hc.SetDefaults()
+	assert.NoError(t, hc.ValidateAttributes())
+	assert.Equal(t, "/", *hc.Path)
+
+	hc.Path = to.Strp("healthz")
+	assert.Error(t, hc.ValidateAttributes())
+
+	assert.Error(t, (&HealthCheckConfig{Type: to.Strp("http")}).ValidateAttributes())
+	assert.Error(t, (&HealthCheckConfig{Type: to.Strp("script")}).ValidateAttributes())
+	assert.Error(t, (&HealthCheckConfig{Type: to.Strp("elb")}).ValidateAttributes())
+	assert.NoError(t, (&HealthCheckConfig{Type: to.Strp("script"), Command: to.Strp("true")}).ValidateAttributes())
+}
+
+func Test_HealthCheck_HTTP(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/healthz", r.URL.Path)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	assert.NoError(t, err)
+	portNum, _ := strconv.ParseInt(port, 10, 64)
+
+	ec2c := &mocks.EC2Client{}
+	ec2c.AddInstance("i-1", host)
+
+	hc := &HealthCheckConfig{Type: to.Strp("http"), Path: to.Strp("/healthz"), Port: to.Int64p(portNum)}
+	provider, err := hc.Provider(ec2c, &mocks.SSMClient{})
+	assert.NoError(t, err)
+
+	instances, err := provider.Instances([]string{"i-1", "i-2"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"i-1"}, instances.HealthyIDs())
+	assert.Equal(t, []string{"i-2"}, instances.UnhealthyIDs())
+
+	status = http.StatusServiceUnavailable
+	instances, err = provider.Instances([]string{"i-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"i-1"}, instances.UnhealthyIDs())
+}
+
+func Test_HealthCheck_Script(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	service := release.Services["web"]
+	service.HealthCheck = &HealthCheckConfig{Type: to.Strp("script"), Command: to.Strp("/bin/healthy")}
+
+	awsc := MockAwsClients(release)
+	assert.NoError(t, release.CreateResources(awsc.ASG, awsc.CW, awsc.EC2))
+
+	// The first check sends the command
+	assert.NoError(t, release.UpdateHealthy(awsc.ASG, awsc.ELB, awsc.ALB, awsc.EC2, awsc.SSM))
+	assert.Equal(t, 1, len(awsc.SSM.SentCommands))
+	assert.Equal(t, "/bin/healthy", *awsc.SSM.SentCommands[0].Parameters["commands"][0])
+	assert.Equal(t, 0, *service.HealthReport.Healthy)
+
+	awsc.SSM.CommandStatus = map[string]string{"InstanceId1": "InProgress"}
+	assert.NoError(t, release.UpdateHealthy(awsc.ASG, awsc.ELB, awsc.ALB, awsc.EC2, awsc.SSM))
+	assert.Equal(t, 0, *service.HealthReport.Healthy)
+	assert.Equal(t, "command-1", service.HealthCheck.CommandIDs["InstanceId1"])
+
+	awsc.SSM.CommandStatus["InstanceId1"] = "Success"
+	assert.NoError(t, release.UpdateHealthy(awsc.ASG, awsc.ELB, awsc.ALB, awsc.EC2, awsc.SSM))
+	assert.Equal(t, 1, *service.HealthReport.Healthy)
+
+	// A failed command is sent again on the next check
+	awsc.SSM.CommandStatus["InstanceId1"] = "Failed"
+	assert.NoError(t, release.UpdateHealthy(awsc.ASG, awsc.ELB, awsc.ALB, awsc.EC2, awsc.SSM))
+	assert.Nil(t, service.HealthCheck.CommandIDs)
+
+	assert.NoError(t, release.UpdateHealthy(awsc.ASG, awsc.ELB, awsc.ALB, awsc.EC2, awsc.SSM))
+	assert.Equal(t, 2, len(awsc.SSM.SentCommands))
+}
+
+func Test_HealthCheck_Script_Batches(t *testing.T) {
+	ssmc := &mocks.SSMClient{}
+	provider := &scriptHealthCheck{ssmc: ssmc, config: &HealthCheckConfig{Type: to.Strp("script"), Command: to.Strp("/bin/healthy")}}
+
+	ids := []string{}
+	for i := 0; i < 120; i++ {
+		ids = append(ids, fmt.Sprintf("i-%v", i))
+	}
+
+	_, err := provider.Instances(ids)
+	assert.NoError(t, err)
+
+	// SendCommand accepts at most 50 instances
+	assert.Equal(t, 3, len(ssmc.SentCommands))
+	assert.Equal(t, 50, len(ssmc.SentCommands[0].InstanceIds))
+	assert.Equal(t, 50, len(ssmc.SentCommands[1].InstanceIds))
+	assert.Equal(t, 20, len(ssmc.SentCommands[2].InstanceIds))
+
+	// Each instance is read from the command sent to it
+	ssmc.CommandStatus = map[string]string{}
+	for _, id := range ids {
+		ssmc.CommandStatus[id] = "Success"
+	}
+	assert.Equal(t, "command-3", provider.config.CommandIDs["i-119"])
+
+	instances, err := provider.Instances(ids)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(instances.UnhealthyIDs()))
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coinbase/step/utils/to"
+)
+
+// Points the odin client runs hooks at
+const (
+	HookPreDeploy   = "pre_deploy"
+	HookPostSuccess = "post_success"
+	HookPostFailure = "post_failure"
+)
+
+// HooksConfig are the commands run by the odin client at each point of the deploy
+// Each command is run with sh -c, in order, stopping at the first that fails
+type HooksConfig struct {
+	PreDeploy   []*string `json:"pre_deploy,omitempty"`   // Run before the release is uploaded, failing stops the deploy
+	PostSuccess []*string `json:"post_success,omitempty"` // Run once the deploy succeeded
+	PostFailure []*string `json:"post_failure,omitempty"` // Run once the deploy failed or was halted
+}
+
+// ValidateAttributes validates the hooks have commands
+func (hooks *HooksConfig) ValidateAttributes() error {
+	for point, commands := range hooks.Commands() {
+		for _, command := range commands {
+			if command == nil || strings.TrimSpace(*command) == "" {
+				return fmt.Errorf("Hooks %v command must not be empty", point)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Commands returns the commands of each point
+func (hooks *HooksConfig) Commands() map[string][]*string {
+	return map[string][]*string{
+		HookPreDeploy:   hooks.PreDeploy,
+		HookPostSuccess: hooks.PostSuccess,
+		HookPostFailure: hooks.PostFailure,
+	}
+}
+
+// ValidateHooks validates the releases hooks
+func (release *Release) ValidateHooks() error {
+	if release.Hooks == nil {
+		return nil
+	}
+
+	return release.Hooks.ValidateAttributes()
+}
+
+// HookCommands returns the releases commands of the hook point
+func (release *Release) HookCommands(point string) []*string {
+	if release.Hooks == nil {
+		return nil
+	}
+
+	return release.Hooks.Commands()[point]
+}
+
+// HookEnv returns the environment variables describing the release to its hooks, status is the executions final status
+func (release *Release) HookEnv(point string, status *string) []string {
+	vars := map[string]string{
+		"ODIN_HOOK":           point,
+		"ODIN_PROJECT_NAME":   to.Strs(release.ProjectName),
+		"ODIN_CONFIG_NAME":    to.Strs(release.ConfigName),
+		"ODIN_RELEASE_ID":     to.Strs(release.ReleaseID),
+		"ODIN_AWS_ACCOUNT_ID": to.Strs(release.AwsAccountID),
+		"ODIN_AWS_REGION":     to.Strs(release.AwsRegion),
+		"ODIN_AMI":            to.Strs(release.Image),
+		"ODIN_EXECUTION_ARN":  to.Strs(release.ExecutionArn),
+		"ODIN_STATUS":         to.Strs(status),
+	}
+
+	env := []string{}
+	for _, key := range sortedKeys(vars) {
+		env = append(env, fmt.Sprintf("%v=%v", key, vars[key]))
+	}
+
+	return env
+}
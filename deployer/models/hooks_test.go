@@ -0,0 +1,28 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_ValidateHooks(t *testing.T) {
+	release := MockRelease(t)
+	assert.NoError(t, release.ValidateHooks())
+
+	release.Hooks = &HooksConfig{PreDeploy: []*string{to.Strp("./smoke-test.sh")}}
+	assert.NoError(t, release.ValidateHooks())
+
+	release.Hooks = &HooksConfig{PostSuccess: []*string{to.Strp(" ")}}
+	assert.Error(t, release.ValidateHooks())
+}
+
+func Test_Release_HookEnv(t *testing.T) {
+	release := MockRelease(t)
+
+	env := release.HookEnv(HookPostFailure, to.Strp("FAILED"))
+	assert.Contains(t, env, "ODIN_HOOK=post_failure")
+	assert.Contains(t, env, "ODIN_RELEASE_ID=rr")
+	assert.Contains(t, env, "ODIN_STATUS=FAILED")
+}
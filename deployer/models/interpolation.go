@@ -0,0 +1,61 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/secret"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Interpolation records the value the client resolved a ${ssm:/path} or ${env:VAR} reference in the release file to
+type Interpolation struct {
+	Value  *string `json:"value,omitempty"` // Only recorded if the value is not secret
+	SHA256 *string `json:"sha256,omitempty"`
+}
+
+// ValidateInterpolations validates the recorded interpolations are keyed by reference and have a hash
+func (release *Release) ValidateInterpolations() error {
+	for ref, i := range release.Interpolations {
+		if !strings.HasPrefix(ref, "ssm:") && !strings.HasPrefix(ref, "env:") {
+			return fmt.Errorf("Interpolation %q must be an ssm: or env: reference", ref)
+		}
+
+		if i == nil || i.SHA256 == nil {
+			return fmt.Errorf("Interpolation %q must have a sha256", ref)
+		}
+
+		if i.Value != nil && to.SHA256Str(i.Value) != *i.SHA256 {
+			return &CodedError{Code: ErrorCodeValidationSHA, Cause: fmt.Sprintf("Interpolation %q value does not match its sha256", ref)}
+		}
+	}
+
+	return nil
+}
+
+// VerifyInterpolations checks the odin SSM parameters the release was built with have not changed since,
+// other references cannot be read by the deployer so are only recorded
+func (release *Release) VerifyInterpolations(ssmc aws.SSMAPI) error {
+	refs := []string{}
+	for ref := range release.Interpolations {
+		if strings.HasPrefix(ref, secret.SSMPrefix) {
+			refs = append(refs, ref)
+		}
+	}
+	sort.Strings(refs)
+
+	for _, ref := range refs {
+		value, err := secret.Get(ssmc, nil, to.Strp(ref))
+		if err != nil {
+			return fmt.Errorf("Interpolation %q %v", ref, err.Error())
+		}
+
+		if to.SHA256Str(value) != to.Strs(release.Interpolations[ref].SHA256) {
+			return fmt.Errorf("Interpolation %q changed since the release was built", ref)
+		}
+	}
+
+	return nil
+}
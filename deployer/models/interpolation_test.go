@@ -0,0 +1,34 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_Interpolations(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	release.Interpolations = map[string]*Interpolation{
+		"ssm:/odin/web/ami": &Interpolation{Value: to.Strp("ami-123456"), SHA256: to.Strp(to.SHA256Str(to.Strp("ami-123456")))},
+		"ssm:/team/web/key": &Interpolation{SHA256: to.Strp(to.SHA256Str(to.Strp("key")))},
+	}
+	assert.NoError(t, release.ValidateConfiguration())
+
+	ssmc := &mocks.SSMClient{}
+	ssmc.AddParameter("/odin/web/ami", "ami-123456")
+	assert.NoError(t, release.VerifyInterpolations(ssmc)) // Only odin parameters are verified
+
+	ssmc.AddParameter("/odin/web/ami", "ami-654321")
+	assert.Error(t, release.VerifyInterpolations(ssmc))
+
+	release.Interpolations["vault:web/key"] = &Interpolation{SHA256: to.Strp("sha")}
+	assert.Error(t, release.ValidateConfiguration())
+
+	delete(release.Interpolations, "vault:web/key")
+	release.Interpolations["env:VERSION"] = &Interpolation{Value: to.Strp("1.2"), SHA256: to.Strp("sha")}
+	assert.Error(t, release.ValidateConfiguration())
+}
@@ -0,0 +1,217 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Transitions of a deploy where the deployer invokes the releases Lambda hooks
+const (
+	LambdaHookPreCreate   = "pre_create"   // Resources are valid, vetoing rejects the release
+	LambdaHookPostHealthy = "post_healthy" // The new fleet is healthy, vetoing rolls it back
+	LambdaHookPreTeardown = "pre_teardown" // The old fleet is about to be deleted, vetoing rolls back the new fleet
+	LambdaHookOnFailure   = "on_failure"   // The release failed and is being rolled back, it cannot be vetoed
+)
+
+// LambdaInvokeTimeout bounds each plugin and Lambda hook invocation, so a slow function fails the transition
+// and leaves the state time to be retried or caught, instead of timing out the deployers Lambda
+const LambdaInvokeTimeout = 60 * time.Second
+
+// Lambda hooks are invoked through the assumed role, which can only invoke odin- functions in the releases account
+var lambdaHookArn = regexp.MustCompile(`^arn:aws:lambda:([a-z0-9-]+):([0-9]+):function:odin-[a-zA-Z0-9_-]+$`)
+
+// LambdaHooksConfig are the Lambda functions invoked at each transition, in order
+type LambdaHooksConfig struct {
+	PreCreate   []*string `json:"pre_create,omitempty"`
+	PostHealthy []*string `json:"post_healthy,omitempty"`
+	PreTeardown []*string `json:"pre_teardown,omitempty"`
+	OnFailure   []*string `json:"on_failure,omitempty"`
+}
+
+// LambdaHookRequest is the JSON payload Lambda hooks are invoked with
+type LambdaHookRequest struct {
+	Hook    string       `json:"hook"`
+	Release *HookRelease `json:"release"`
+}
+
+// HookRelease is the part of the release plugins and Lambda hooks are invoked with
+// It leaves out what only the deployer reads, like the releases identity, signature and user data
+type HookRelease struct {
+	AwsAccountID *string                 `json:"aws_account_id,omitempty"`
+	AwsRegion    *string                 `json:"aws_region,omitempty"`
+	ProjectName  *string                 `json:"project_name,omitempty"`
+	ConfigName   *string                 `json:"config_name,omitempty"`
+	ReleaseID    *string                 `json:"release_id,omitempty"`
+	Image        *string                 `json:"ami,omitempty"`
+	Tags         map[string]*string      `json:"tags,omitempty"`
+	Audit        *Audit                  `json:"audit,omitempty"`
+	Success      *bool                   `json:"success,omitempty"`
+	ErrorCode    *string                 `json:"error_code,omitempty"`
+	Services     map[string]*HookService `json:"services,omitempty"`
+}
+
+// HookService is the part of each service plugins and Lambda hooks are invoked with
+type HookService struct {
+	InstanceType *string `json:"instance_type,omitempty"`
+	CreatedASG   *string `json:"created_asg,omitempty"`
+	Healthy      bool    `json:"healthy"`
+	Halted       *bool   `json:"halted,omitempty"`
+}
+
+// LambdaHookResponse is the result of a Lambda hook, the deploy only continues if it is allowed
+type LambdaHookResponse struct {
+	Allow  bool    `json:"allow"`
+	Reason *string `json:"reason,omitempty"`
+}
+
+// functions returns the functions of the transition
+func (hooks *LambdaHooksConfig) functions(hook string) []*string {
+	switch hook {
+	case LambdaHookPreCreate:
+		return hooks.PreCreate
+	case LambdaHookPostHealthy:
+		return hooks.PostHealthy
+	case LambdaHookPreTeardown:
+		return hooks.PreTeardown
+	case LambdaHookOnFailure:
+		return hooks.OnFailure
+	}
+	return nil
+}
+
+// ValidateLambdaHooks validates the hooks are odin- functions in the releases account and region
+func (release *Release) ValidateLambdaHooks() error {
+	if release.LambdaHooks == nil {
+		return nil
+	}
+
+	for _, hook := range []string{LambdaHookPreCreate, LambdaHookPostHealthy, LambdaHookPreTeardown, LambdaHookOnFailure} {
+		for _, arn := range release.LambdaHooks.functions(hook) {
+			match := lambdaHookArn.FindStringSubmatch(to.Strs(arn))
+			if match == nil {
+				return fmt.Errorf("Lambda hook %v %q must be the ARN of an odin- function", hook, to.Strs(arn))
+			}
+
+			if match[1] != to.Strs(release.AwsRegion) || match[2] != to.Strs(release.AwsAccountID) {
+				return fmt.Errorf("Lambda hook %v %q must be in the releases account and region", hook, to.Strs(arn))
+			}
+		}
+	}
+
+	return nil
+}
+
+// InvokeLambdaHooks invokes the releases hooks of the transition with the release, in order
+// A hook vetoes the deploy unless it returns {"allow": true}, on_failure hooks are all invoked and cannot veto
+func (release *Release) InvokeLambdaHooks(lambdac aws.LambdaAPI, hook string) error {
+	if release.LambdaHooks == nil {
+		return nil
+	}
+
+	var failed error
+	for _, arn := range release.LambdaHooks.functions(hook) {
+		err := release.invokeLambdaHook(lambdac, arn, hook)
+		if err == nil {
+			continue
+		}
+
+		if hook != LambdaHookOnFailure {
+			return err
+		}
+
+		if failed == nil {
+			failed = err
+		}
+	}
+
+	return failed
+}
+
+func (release *Release) invokeLambdaHook(lambdac aws.LambdaAPI, arn *string, hook string) error {
+	payload, err := invokeLambda(lambdac, arn, &LambdaHookRequest{Hook: hook, Release: release.hookRelease()})
+	if err != nil {
+		return fmt.Errorf("Lambda hook %v at %v failed %v", to.Strs(arn), hook, err.Error())
+	}
+
+	var response LambdaHookResponse
+	if err := json.Unmarshal(payload, &response); err != nil {
+		return fmt.Errorf("Lambda hook %v at %v response invalid %v", to.Strs(arn), hook, err.Error())
+	}
+
+	if !response.Allow {
+		return fmt.Errorf("Lambda hook %v at %v vetoed the release: %v", to.Strs(arn), hook, to.Strs(response.Reason))
+	}
+
+	return nil
+}
+
+// hookRelease returns the part of the release plugins and Lambda hooks are invoked with
+func (release *Release) hookRelease() *HookRelease {
+	hr := &HookRelease{
+		AwsAccountID: release.AwsAccountID,
+		AwsRegion:    release.AwsRegion,
+		ProjectName:  release.ProjectName,
+		ConfigName:   release.ConfigName,
+		ReleaseID:    release.ReleaseID,
+		Image:        release.Image,
+		Tags:         release.Tags,
+		Audit:        release.Audit,
+		Success:      release.Success,
+		ErrorCode:    release.ErrorCode,
+		Services:     map[string]*HookService{},
+	}
+
+	for name, service := range release.Services {
+		if service == nil {
+			continue
+		}
+
+		hr.Services[name] = &HookService{
+			InstanceType: service.InstanceType,
+			CreatedASG:   service.CreatedASG,
+			Healthy:      service.Healthy,
+			Halted:       service.Halted,
+		}
+	}
+
+	return hr
+}
+
+// invokeLambda synchronously invokes the function with the request within LambdaInvokeTimeout, returning its response payload
+func invokeLambda(lambdac aws.LambdaAPI, functionArn *string, request interface{}) ([]byte, error) {
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), LambdaInvokeTimeout)
+	defer cancel()
+
+	output, err := lambdac.InvokeWithContext(ctx, &lambda.InvokeInput{
+		FunctionName:   functionArn,
+		InvocationType: to.Strp(lambda.InvocationTypeRequestResponse),
+		Payload:        payload,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if output.FunctionError != nil {
+		var response struct {
+			ErrorMessage string `json:"errorMessage"`
+		}
+		json.Unmarshal(output.Payload, &response)
+		return nil, fmt.Errorf("%v %v", *output.FunctionError, strings.TrimSpace(response.ErrorMessage))
+	}
+
+	return output.Payload, nil
+}
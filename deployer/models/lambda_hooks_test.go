@@ -0,0 +1,57 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+const policyHook = "arn:aws:lambda:region:000000:function:odin-policy"
+
+func Test_Release_ValidateLambdaHooks(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	assert.NoError(t, release.ValidateLambdaHooks())
+
+	release.LambdaHooks = &LambdaHooksConfig{PreTeardown: []*string{to.Strp(policyHook)}}
+	assert.NoError(t, release.ValidateLambdaHooks())
+
+	// Only odin- functions can be invoked
+	release.LambdaHooks = &LambdaHooksConfig{PreTeardown: []*string{to.Strp("arn:aws:lambda:region:000000:function:policy")}}
+	assert.Error(t, release.ValidateLambdaHooks())
+
+	// In the releases account
+	release.LambdaHooks = &LambdaHooksConfig{PreCreate: []*string{to.Strp("arn:aws:lambda:region:111111:function:odin-policy")}}
+	assert.Error(t, release.ValidateLambdaHooks())
+}
+
+func Test_Release_InvokeLambdaHooks(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	release.LambdaHooks = &LambdaHooksConfig{PreTeardown: []*string{to.Strp(policyHook)}}
+
+	lambdac := &mocks.LambdaClient{}
+
+	// Other transitions have no hooks
+	assert.NoError(t, release.InvokeLambdaHooks(lambdac, LambdaHookPreCreate))
+	assert.Equal(t, 0, len(lambdac.Invoked))
+
+	// Hooks must allow the release
+	assert.Error(t, release.InvokeLambdaHooks(lambdac, LambdaHookPreTeardown))
+
+	lambdac.RespondFunction(policyHook, `{"allow": false, "reason": "change freeze"}`)
+	err := release.InvokeLambdaHooks(lambdac, LambdaHookPreTeardown)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "vetoed the release: change freeze")
+
+	lambdac.RespondFunction(policyHook, `{"allow": true}`)
+	assert.NoError(t, release.InvokeLambdaHooks(lambdac, LambdaHookPreTeardown))
+
+	var request LambdaHookRequest
+	assert.NoError(t, json.Unmarshal(lambdac.Invoked[2].Payload, &request))
+	assert.Equal(t, LambdaHookPreTeardown, request.Hook)
+	assert.Equal(t, "rr", *request.Release.ReleaseID)
+}
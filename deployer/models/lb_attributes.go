@@ -0,0 +1,208 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/alb"
+	"github.com/coinbase/odin/aws/elb"
+	"github.com/coinbase/step/utils/to"
+)
+
+// LoadBalancerAttributesConfig are the attributes asserted on a services ELBs, target groups and their ALBs
+type LoadBalancerAttributesConfig struct {
+	AccessLogsBucket    *string `json:"access_logs_bucket,omitempty"`
+	AccessLogsPrefix    *string `json:"access_logs_prefix,omitempty"`
+	IdleTimeout         *int64  `json:"idle_timeout,omitempty"`
+	DeregistrationDelay *int64  `json:"deregistration_delay,omitempty"`
+	DropInvalidHeaders  *bool   `json:"drop_invalid_headers,omitempty"` // ALBs only
+
+	// Drift is fail to fail the release, or fix to set the attributes
+	Drift *string `json:"drift,omitempty"`
+}
+
+// SetDefaults assigns default values
+func (a *LoadBalancerAttributesConfig) SetDefaults() {
+	if a.Drift == nil {
+		a.Drift = to.Strp("fail")
+	}
+}
+
+// ValidateAttributes validates attributes
+func (a *LoadBalancerAttributesConfig) ValidateAttributes() error {
+	if a.Drift == nil || (*a.Drift != "fail" && *a.Drift != "fix") {
+		return fmt.Errorf("LoadBalancerAttributes drift must be fail or fix")
+	}
+
+	if a.AccessLogsPrefix != nil && a.AccessLogsBucket == nil {
+		return fmt.Errorf("LoadBalancerAttributes access_logs_prefix requires access_logs_bucket")
+	}
+
+	if a.IdleTimeout != nil && (*a.IdleTimeout < 1 || *a.IdleTimeout > 4000) {
+		return fmt.Errorf("LoadBalancerAttributes idle_timeout must be between 1 and 4000")
+	}
+
+	if a.DeregistrationDelay != nil && (*a.DeregistrationDelay < 0 || *a.DeregistrationDelay > 3600) {
+		return fmt.Errorf("LoadBalancerAttributes deregistration_delay must be between 0 and 3600")
+	}
+
+	return nil
+}
+
+// elbAttributes are the attributes of classic ELBs
+func (a *LoadBalancerAttributesConfig) elbAttributes() map[string]string {
+	attrs := map[string]string{}
+
+	if a.AccessLogsBucket != nil {
+		attrs["access_logs.s3.enabled"] = "true"
+		attrs["access_logs.s3.bucket"] = *a.AccessLogsBucket
+		attrs["access_logs.s3.prefix"] = to.Strs(a.AccessLogsPrefix)
+	}
+
+	if a.IdleTimeout != nil {
+		attrs["idle_timeout.timeout_seconds"] = fmt.Sprintf("%v", *a.IdleTimeout)
+	}
+
+	return attrs
+}
+
+// albAttributes are the attributes of the load balancers of target groups
+func (a *LoadBalancerAttributesConfig) albAttributes() map[string]string {
+	attrs := a.elbAttributes()
+
+	if a.DropInvalidHeaders != nil {
+		attrs["routing.http.drop_invalid_header_fields.enabled"] = fmt.Sprintf("%v", *a.DropInvalidHeaders)
+	}
+
+	return attrs
+}
+
+func (a *LoadBalancerAttributesConfig) targetGroupAttributes() map[string]string {
+	attrs := map[string]string{}
+
+	if a.DeregistrationDelay != nil {
+		attrs["deregistration_delay.timeout_seconds"] = fmt.Sprintf("%v", *a.DeregistrationDelay)
+	}
+
+	return attrs
+}
+
+// assert compares the desired attributes with the actual, then fails or fixes any drift
+// Drift is only fixed if owned returns no error, so a release never modifies another project configs resources
+func (a *LoadBalancerAttributesConfig) assert(name string, desired map[string]string, owned func() error, get func() (map[string]string, error), set func(map[string]string) error) error {
+	if len(desired) == 0 {
+		return nil
+	}
+
+	actual, err := get()
+	if err != nil {
+		return err
+	}
+
+	drifted := map[string]string{}
+	diffs := []string{}
+	for k, v := range desired {
+		if actual[k] != v {
+			drifted[k] = v
+			diffs = append(diffs, fmt.Sprintf("%v is %q expected %q", k, actual[k], v))
+		}
+	}
+
+	if len(drifted) == 0 {
+		return nil
+	}
+
+	if *a.Drift == "fix" {
+		if err := owned(); err != nil {
+			return fmt.Errorf("%v attributes cannot be fixed: %v", name, err.Error())
+		}
+		return set(drifted)
+	}
+
+	sort.Strings(diffs)
+	return fmt.Errorf("%v attributes drifted: %v", name, strings.Join(diffs, ", "))
+}
+
+// AssertLoadBalancerAttributes fails or fixes drift in the attributes of the services load balancers and target groups
+func (release *Release) AssertLoadBalancerAttributes(resources map[string]*ServiceResources, elbc aws.ELBAPI, albc aws.ALBAPI) error {
+	for name, service := range release.Services {
+		if sr := resources[name]; sr != nil {
+			if err := service.assertLoadBalancerAttributes(sr, elbc, albc); err != nil {
+				return fmt.Errorf("%v %v", service.errorPrefix(), err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+func (service *Service) assertLoadBalancerAttributes(sr *ServiceResources, elbc aws.ELBAPI, albc aws.ALBAPI) error {
+	a := service.LoadBalancerAttributes
+	if a == nil {
+		return nil
+	}
+
+	for _, lb := range sr.ELBs {
+		name := lb.LoadBalancerName
+		err := a.assert(fmt.Sprintf("ELB(%v)", *name), a.elbAttributes(),
+			func() error { return service.ownsTagged(lb.ProjectName(), lb.ConfigName()) },
+			func() (map[string]string, error) { return elb.Attributes(elbc, name) },
+			func(attrs map[string]string) error { return elb.SetAttributes(elbc, name, attrs) },
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	// Target groups can share a load balancer so only check it once
+	lbArns := map[string]bool{}
+	for _, tg := range sr.TargetGroups {
+		arn := tg.TargetGroupArn
+		err := a.assert(fmt.Sprintf("TargetGroup(%v)", to.Strs(tg.TargetGroupName)), a.targetGroupAttributes(),
+			func() error { return service.ownsTagged(tg.ProjectName(), tg.ConfigName()) },
+			func() (map[string]string, error) { return alb.TargetGroupAttributes(albc, arn) },
+			func(attrs map[string]string) error { return alb.SetTargetGroupAttributes(albc, arn, attrs) },
+		)
+
+		if err != nil {
+			return err
+		}
+
+		for _, lbArn := range tg.LoadBalancerArns {
+			if lbArn == nil || lbArns[*lbArn] {
+				continue
+			}
+			lbArns[*lbArn] = true
+
+			arn := lbArn
+			err := a.assert(fmt.Sprintf("ALB(%v)", *arn), a.albAttributes(),
+				func() error {
+					projectName, configName, err := alb.LoadBalancerTags(albc, arn)
+					if err != nil {
+						return err
+					}
+					return service.ownsTagged(projectName, configName)
+				},
+				func() (map[string]string, error) { return alb.LoadBalancerAttributes(albc, arn) },
+				func(attrs map[string]string) error { return alb.SetLoadBalancerAttributes(albc, arn, attrs) },
+			)
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ownsTagged returns an error unless the tags are the services project and config
+func (service *Service) ownsTagged(projectName *string, configName *string) error {
+	if to.Strs(projectName) != to.Strs(service.ProjectName()) || to.Strs(configName) != to.Strs(service.ConfigName()) {
+		return fmt.Errorf("tagged %v/%v not %v/%v", to.Strs(projectName), to.Strs(configName), to.Strs(service.ProjectName()), to.Strs(service.ConfigName()))
+	}
+	return nil
+}
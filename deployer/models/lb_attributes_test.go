@@ -0,0 +1,93 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoadBalancerAttributesConfig_ValidateAttributes(t *testing.T) {
+	a := &LoadBalancerAttributesConfig{}
+	a.SetDefaults()
+	assert.NoError(t, a.ValidateAttributes())
+	assert.Equal(t, "fail", *a.Drift)
+
+	a.Drift = to.Strp("ignore")
+	assert.Error(t, a.ValidateAttributes())
+
+	a.Drift = to.Strp("fix")
+	a.AccessLogsPrefix = to.Strp("logs")
+	assert.Error(t, a.ValidateAttributes())
+
+	a.AccessLogsBucket = to.Strp("bucket")
+	assert.NoError(t, a.ValidateAttributes())
+
+	a.IdleTimeout = to.Int64p(0)
+	assert.Error(t, a.ValidateAttributes())
+}
+
+func Test_Release_AssertLoadBalancerAttributes(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	service := release.Services["web"]
+	service.LoadBalancerAttributes = &LoadBalancerAttributesConfig{
+		AccessLogsBucket:    to.Strp("logs-bucket"),
+		IdleTimeout:         to.Int64p(120),
+		DeregistrationDelay: to.Int64p(30),
+		DropInvalidHeaders:  to.Boolp(true),
+	}
+	service.LoadBalancerAttributes.SetDefaults()
+
+	awsc := MockAwsClients(release)
+	resources, err := release.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS)
+	assert.NoError(t, err)
+
+	// Drift fails by default
+	err = release.AssertLoadBalancerAttributes(resources, awsc.ELB, awsc.ALB)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "access_logs.s3.bucket")
+
+	service.LoadBalancerAttributes.Drift = to.Strp("fix")
+
+	// Load balancers of another project config are never modified
+	awsc.ALB.AddLoadBalancerTags("web-elb-target-lb", "other", "config")
+	err = release.AssertLoadBalancerAttributes(resources, awsc.ELB, awsc.ALB)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot be fixed")
+	assert.Equal(t, "", awsc.ALB.Attributes["web-elb-target-lb"]["routing.http.drop_invalid_header_fields.enabled"])
+
+	awsc.ALB.AddLoadBalancerTags("web-elb-target-lb", *release.ProjectName, *release.ConfigName)
+	assert.NoError(t, release.AssertLoadBalancerAttributes(resources, awsc.ELB, awsc.ALB))
+
+	assert.Equal(t, "logs-bucket", *awsc.ELB.Attributes["web-elb"].AccessLog.S3BucketName)
+	assert.Equal(t, int64(120), *awsc.ELB.Attributes["web-elb"].ConnectionSettings.IdleTimeout)
+	assert.Equal(t, "30", awsc.ALB.Attributes["web-elb-target"]["deregistration_delay.timeout_seconds"])
+	assert.Equal(t, "true", awsc.ALB.Attributes["web-elb-target-lb"]["routing.http.drop_invalid_header_fields.enabled"])
+
+	// No drift once fixed
+	service.LoadBalancerAttributes.Drift = to.Strp("fail")
+	assert.NoError(t, release.AssertLoadBalancerAttributes(resources, awsc.ELB, awsc.ALB))
+
+	awsc.ELB.Attributes["web-elb"].ConnectionSettings = &elb.ConnectionSettings{IdleTimeout: to.Int64p(60)}
+	assert.Error(t, release.AssertLoadBalancerAttributes(resources, awsc.ELB, awsc.ALB))
+}
+
+func Test_Release_AssertLoadBalancerAttributes_NotOwned(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	service := release.Services["web"]
+	service.LoadBalancerAttributes = &LoadBalancerAttributesConfig{DeregistrationDelay: to.Int64p(30), Drift: to.Strp("fix")}
+
+	awsc := MockAwsClients(release)
+	resources, err := release.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS)
+	assert.NoError(t, err)
+
+	// A target group whose tags have since changed is not fixed
+	resources["web"].TargetGroups[0].ConfigNameTag = to.Strp("other")
+	assert.Error(t, release.AssertLoadBalancerAttributes(resources, awsc.ELB, awsc.ALB))
+	assert.Equal(t, "", awsc.ALB.Attributes["web-elb-target"]["deregistration_delay.timeout_seconds"])
+}
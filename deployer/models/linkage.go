@@ -0,0 +1,179 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/asg"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Linkage records the deployed release of a project config, the release it replaced and the ASGs it created
+// The next release links to it, so its old ASGs are found by the chain of releases instead of only by their tags
+type Linkage struct {
+	ReleaseID         *string            `json:"release_id,omitempty"`
+	PreviousReleaseID *string            `json:"previous_release_id,omitempty"`
+	ASGs              map[string]*string `json:"asgs,omitempty"` // ASG names by service
+}
+
+func (release *Release) linkagePath() *string {
+	s := fmt.Sprintf("%v/linkage", *release.RootDir())
+	return &s
+}
+
+// LoadLinkage returns the linkage of the project configs deployed release, nil if none has been recorded
+func (release *Release) LoadLinkage(s3c aws.S3API) (*Linkage, error) {
+	raw, err := release.Store(s3c).Get(release.linkagePath())
+	if IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var linkage Linkage
+	if err := json.Unmarshal(raw, &linkage); err != nil {
+		return nil, fmt.Errorf("Linkage invalid %v", err.Error())
+	}
+
+	return &linkage, nil
+}
+
+// LinkPreviousRelease links the release to the project configs deployed release and its ASGs
+// Project configs deployed before linkage was recorded find their old ASGs by tags
+// The link is only ever set from the linkage, never from what the client sent
+func (release *Release) LinkPreviousRelease(s3c aws.S3API) error {
+	release.PreviousReleaseID, release.PreviousASGs = nil, nil
+
+	linkage, err := release.LoadLinkage(s3c)
+	if err != nil {
+		return err
+	}
+
+	if linkage == nil || linkage.ReleaseID == nil || *linkage.ReleaseID == to.Strs(release.ReleaseID) {
+		return nil
+	}
+
+	release.PreviousReleaseID = linkage.ReleaseID
+	release.PreviousASGs = linkage.ASGs
+
+	return nil
+}
+
+// SaveLinkage records the release as the project configs deployed release
+func (release *Release) SaveLinkage(s3c aws.S3API) error {
+	asgs := map[string]*string{}
+	for name, service := range release.Services {
+		if service == nil {
+			continue
+		}
+
+		// Halted services are still running their old ASG
+		if service.IsHalted() && service.KeptASG != nil {
+			asgs[name] = service.KeptASG
+		} else if service.CreatedASG != nil {
+			asgs[name] = service.CreatedASG
+		}
+	}
+
+	return PutArtifact(release.Store(s3c), release.linkagePath(), &Linkage{
+		ReleaseID:         release.ReleaseID,
+		PreviousReleaseID: release.PreviousReleaseID,
+		ASGs:              asgs,
+	})
+}
+
+// linked returns whether the ASG was created by the previous release in the chain,
+// or by a later release that replaced it but did not finish cleaning up
+func (release *Release) linked(a *asg.ASG) bool {
+	if release.PreviousReleaseID == nil {
+		return false
+	}
+
+	for _, name := range release.PreviousASGs {
+		if name != nil && *name == to.Strs(a.AutoScalingGroupName) {
+			return true
+		}
+	}
+
+	previous := *release.PreviousReleaseID
+	return to.Strs(a.ReleaseID()) == previous || to.Strs(a.PreviousReleaseID()) == previous
+}
+
+// previousASGs returns the old ASGs that are replaced by the release
+// With a linked release they are the ASGs in the chain, even if they were renamed,
+// and ASGs that only share the project config tags, e.g. created by hand, are ignored
+// ASGs without the releases project and config tags are never replaced, even if the linkage names them
+func (release *Release) previousASGs(asgc aws.ASGAPI) ([]*asg.ASG, error) {
+	tagged, err := asg.ForProjectConfigNOTReleaseID(asgc, release.ProjectName, release.ConfigName, release.ReleaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if release.PreviousReleaseID == nil {
+		return tagged, nil
+	}
+
+	names := []*string{}
+	for _, name := range release.PreviousASGs {
+		names = append(names, name)
+	}
+
+	named, err := asg.ForNames(asgc, names)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	asgs := []*asg.ASG{}
+	for _, a := range append(named, tagged...) {
+		name := to.Strs(a.AutoScalingGroupName)
+		if seen[name] || to.Strs(a.ReleaseID()) == to.Strs(release.ReleaseID) || !release.linked(a) || !release.ownsASG(a) {
+			continue
+		}
+
+		seen[name] = true
+		asgs = append(asgs, a)
+	}
+
+	return asgs, nil
+}
+
+// ownsASG returns whether the ASG has the releases project and config tags
+func (release *Release) ownsASG(a *asg.ASG) bool {
+	return to.Strs(a.ProjectName()) == to.Strs(release.ProjectName) && to.Strs(a.ConfigName()) == to.Strs(release.ConfigName)
+}
+
+// previousASGServiceMap returns the previous ASG of each service
+// Will error if there is an ASG without a service name || two ASGs for a service
+func (release *Release) previousASGServiceMap(asgs []*asg.ASG) (map[string]*asg.ASG, error) {
+	// Renamed ASGs are recorded against their service in the linkage, even if their tags changed
+	services := map[string]string{}
+	for service, name := range release.PreviousASGs {
+		if name != nil {
+			services[*name] = service
+		}
+	}
+
+	prevASGs := map[string]*asg.ASG{}
+	for _, a := range asgs {
+		sn := to.Strs(a.ServiceName())
+		if service, ok := services[to.Strs(a.AutoScalingGroupName)]; ok {
+			sn = service
+		}
+
+		if sn == "" {
+			return nil, fmt.Errorf("Autoscaling Group found for Project with No Service Name %v", to.Strs(a.ServiceID()))
+		}
+
+		if _, ok := prevASGs[sn]; ok {
+			return nil, fmt.Errorf("Found multiple ASGs for service %v -- %v", sn, to.Strs(a.ServiceID()))
+		}
+
+		prevASGs[sn] = a
+	}
+
+	return prevASGs, nil
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_Linkage(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	awsc := MockAwsClients(release)
+
+	// Nothing is linked until a release succeeds
+	assert.NoError(t, release.LinkPreviousRelease(awsc.S3))
+	assert.Nil(t, release.PreviousReleaseID)
+
+	release.Services["web"].CreatedASG = to.Strp("web-asg")
+	assert.NoError(t, release.SaveLinkage(awsc.S3))
+
+	next := MockRelease(t)
+	next.ReleaseID = to.Strp("next")
+	MockPrepareRelease(next)
+
+	assert.NoError(t, next.LinkPreviousRelease(awsc.S3))
+	assert.Equal(t, *release.ReleaseID, *next.PreviousReleaseID)
+	assert.Equal(t, "web-asg", *next.PreviousASGs["web"])
+
+	tags := map[string]string{}
+	for _, tag := range next.Services["web"].createInput().Tags {
+		tags[*tag.Key] = *tag.Value
+	}
+	assert.Equal(t, *release.ReleaseID, tags["PreviousReleaseID"])
+}
+
+func Test_Release_PreviousASGs_Linked(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	awsc := mocks.MockAWS()
+	asgc := awsc.ASG
+	asgc.AddPreviousRuntimeResources(*release.ProjectName, *release.ConfigName, "web", "old-release")
+	asgc.AddPreviousRuntimeResources(*release.ProjectName, *release.ConfigName, "web", "hand-made")
+
+	// Without linkage every ASG with the project config tags is old
+	asgs, err := release.previousASGs(asgc)
+	assert.NoError(t, err)
+
+	_, err = release.previousASGServiceMap(asgs)
+	assert.Error(t, err)
+
+	// A renamed ASG with a different service name is still found through the linkage
+	renamed := mocks.MakeMockASG("renamed", *release.ProjectName, *release.ConfigName, "other", "old-release")
+	asgc.AddASG(renamed)
+
+	// But never an ASG of another project config, even if the linkage names it
+	foreign := mocks.MakeMockASG("foreign", "other-project", "other-config", "worker", "old-release")
+	asgc.AddASG(foreign)
+
+	release.PreviousReleaseID = to.Strp("old-release")
+	release.PreviousASGs = map[string]*string{"worker": to.Strp("renamed"), "other": to.Strp("foreign")}
+
+	asgs, err = release.previousASGs(asgc)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(asgs))
+
+	prev, err := release.previousASGServiceMap(asgs)
+	assert.NoError(t, err)
+	assert.Equal(t, "renamed", *prev["worker"].AutoScalingGroupName)
+	assert.Equal(t, "old-release", *prev["web"].ReleaseID())
+
+	// A release that did not finish cleaning up links to the same previous release
+	unfinished := mocks.MakeMockASG("unfinished", *release.ProjectName, *release.ConfigName, "web", "unfinished")
+	unfinished.Tags = append(unfinished.Tags, &autoscaling.TagDescription{Key: to.Strp("PreviousReleaseID"), Value: to.Strp("old-release")})
+	asgc.AddASG(unfinished)
+
+	asgs, err = release.previousASGs(asgc)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(asgs))
+
+	assert.NoError(t, release.SuccessfulTearDown(asgc, awsc.ELB, awsc.ALB, awsc.CW, awsc.EC2))
+	assert.NotContains(t, asgc.DeletedASGs, "project-config-web-hand-made")
+	assert.Contains(t, asgc.DeletedASGs, "renamed")
+	assert.NotContains(t, asgc.DeletedASGs, "foreign")
+}
+
+func Test_Release_Validate_Clears_Linkage(t *testing.T) {
+	release := MockRelease(t)
+	awsc := MockAwsClients(release)
+
+	// A client cannot pick which ASGs are replaced
+	release.PreviousReleaseID = to.Strp("other-release")
+	release.PreviousASGs = map[string]*string{"web": to.Strp("other-project-asg")}
+	release.ReleaseSHA256 = to.SHA256Struct(release)
+	MockPrepareRelease(release)
+
+	assert.NoError(t, release.Validate(awsc.S3))
+	assert.Nil(t, release.PreviousReleaseID)
+	assert.Nil(t, release.PreviousASGs)
+
+	// Nor keep them when nothing is linked
+	release.PreviousReleaseID = to.Strp("other-release")
+	assert.NoError(t, release.LinkPreviousRelease(awsc.S3))
+	assert.Nil(t, release.PreviousReleaseID)
+}
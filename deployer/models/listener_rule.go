@@ -0,0 +1,118 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/alb"
+	"github.com/coinbase/step/utils/is"
+)
+
+// ListenerRuleConfig cuts over a rule on a shared ALB between a blue and green target group
+// The new ASG is attached to the target group the rule is not forwarding to,
+// and once it is healthy the rule is changed to forward to it
+type ListenerRuleConfig struct {
+	RuleArn      *string   `json:"rule_arn,omitempty"`
+	ListenerArn  *string   `json:"listener_arn,omitempty"`
+	Priority     *string   `json:"priority,omitempty"`
+	TargetGroups []*string `json:"target_groups,omitempty"` // Names of the blue and green target groups
+
+	// Shift moves traffic to the new target group gradually instead of all at once
+	Shift *ShiftConfig `json:"shift,omitempty"`
+
+	// Generated
+	FoundRuleArn         *string `json:"found_rule_arn,omitempty"`
+	ActiveTargetGroupArn *string `json:"active_target_group_arn,omitempty"`
+	NextTargetGroupArn   *string `json:"next_target_group_arn,omitempty"`
+}
+
+// ValidateAttributes validates attributes
+func (l *ListenerRuleConfig) ValidateAttributes(service *Service) error {
+	if l.RuleArn == nil && (l.ListenerArn == nil || l.Priority == nil) {
+		return fmt.Errorf("ListenerRule requires rule_arn or listener_arn and priority")
+	}
+
+	if len(l.TargetGroups) != 2 || !is.UniqueStrp(l.TargetGroups) {
+		return fmt.Errorf("ListenerRule requires two unique target_groups")
+	}
+
+	for _, tg := range l.TargetGroups {
+		for _, serviceTG := range service.TargetGroups {
+			if serviceTG != nil && *tg == *serviceTG {
+				return fmt.Errorf("ListenerRule target group %v cannot also be in the service target_groups", *tg)
+			}
+		}
+	}
+
+	if l.Shift != nil {
+		if err := l.Shift.ValidateAttributes(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FetchResources finds the rule and which of the target groups it forwards to
+// The target groups must belong to the service, so the rule must too
+func (l *ListenerRuleConfig) FetchResources(albc aws.ALBAPI, service *Service) error {
+	tgs, err := alb.FindAll(albc, l.TargetGroups)
+	if err != nil {
+		return err
+	}
+
+	for _, tg := range tgs {
+		if err := ValidateTargetGroup(service, tg); err != nil {
+			return err
+		}
+	}
+
+	rule, err := alb.FindRule(albc, l.RuleArn, l.ListenerArn, l.Priority)
+	if err != nil {
+		return err
+	}
+
+	forward, err := alb.ForwardTargetGroup(rule)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case is.EmptyStr(forward):
+		return fmt.Errorf("Listener Rule %v does not forward to a target group", *rule.RuleArn)
+	case *forward == *tgs[0].TargetGroupArn:
+		l.ActiveTargetGroupArn, l.NextTargetGroupArn = tgs[0].TargetGroupArn, tgs[1].TargetGroupArn
+	case *forward == *tgs[1].TargetGroupArn:
+		l.ActiveTargetGroupArn, l.NextTargetGroupArn = tgs[1].TargetGroupArn, tgs[0].TargetGroupArn
+	default:
+		return fmt.Errorf("Listener Rule %v forwards to %v which is not one of the services target groups", *rule.RuleArn, *forward)
+	}
+
+	l.FoundRuleArn = rule.RuleArn
+	return nil
+}
+
+// targetGroupArns returns the target groups the new ASG is attached to and checked for health
+func (service *Service) targetGroupArns() []*string {
+	arns := service.Resources.TargetGroups
+	if service.ListenerRule != nil && service.ListenerRule.NextTargetGroupArn != nil {
+		arns = append(append([]*string{}, arns...), service.ListenerRule.NextTargetGroupArn)
+	}
+	return arns
+}
+
+// Cutover changes every services listener rule to forward to the new ASGs target group
+func (release *Release) Cutover(albc aws.ALBAPI) error {
+	for _, service := range release.Services {
+		l := service.ListenerRule
+		if l == nil || l.FoundRuleArn == nil || l.NextTargetGroupArn == nil || service.IsHalted() {
+			continue
+		}
+
+		if err := alb.SetForwardTargetGroup(albc, l.FoundRuleArn, l.NextTargetGroupArn); err != nil {
+			return fmt.Errorf("%v %v", service.errorPrefix(), err.Error())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ListenerRuleConfig_ValidateAttributes(t *testing.T) {
+	service := &Service{TargetGroups: []*string{to.Strp("web-elb-target")}}
+
+	l := &ListenerRuleConfig{TargetGroups: []*string{to.Strp("web-blue"), to.Strp("web-green")}}
+	assert.Error(t, l.ValidateAttributes(service))
+
+	l.ListenerArn = to.Strp("listener")
+	l.Priority = to.Strp("10")
+	assert.NoError(t, l.ValidateAttributes(service))
+
+	l.TargetGroups = []*string{to.Strp("web-blue"), to.Strp("web-blue")}
+	assert.Error(t, l.ValidateAttributes(service))
+
+	l.TargetGroups = []*string{to.Strp("web-blue"), to.Strp("web-elb-target")}
+	assert.Error(t, l.ValidateAttributes(service))
+}
+
+func Test_Release_ListenerRule_Cutover(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	service := release.Services["web"]
+	service.ListenerRule = &ListenerRuleConfig{
+		ListenerArn:  to.Strp("listener"),
+		Priority:     to.Strp("10"),
+		TargetGroups: []*string{to.Strp("web-blue"), to.Strp("web-green")},
+	}
+
+	awsc := MockAwsClients(release)
+	awsc.ALB.AddTargetGroup("web-blue", *release.ProjectName, *release.ConfigName, "web")
+	awsc.ALB.AddTargetGroup("web-green", *release.ProjectName, *release.ConfigName, "web")
+	awsc.ALB.AddForwardRule("rule", "10", "web-blue")
+
+	resources, err := release.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS)
+	assert.NoError(t, err)
+	assert.NoError(t, release.ValidateResources(resources))
+	release.UpdateWithResources(resources)
+
+	assert.Equal(t, "web-blue", *service.ListenerRule.ActiveTargetGroupArn)
+	assert.Equal(t, "web-green", *service.ListenerRule.NextTargetGroupArn)
+	assert.Equal(t, []string{"web-elb-target", "web-green"}, to.StrSlice(service.createInput().TargetGroupARNs))
+
+	assert.NoError(t, release.Cutover(awsc.ALB))
+	assert.Equal(t, "web-green", *awsc.ALB.Rules[0].Actions[0].TargetGroupArn)
+}
+
+func Test_Release_ListenerRule_OtherTargetGroup(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	release.Services["web"].ListenerRule = &ListenerRuleConfig{
+		RuleArn:      to.Strp("rule"),
+		TargetGroups: []*string{to.Strp("web-blue"), to.Strp("web-green")},
+	}
+
+	awsc := MockAwsClients(release)
+	awsc.ALB.AddTargetGroup("web-blue", *release.ProjectName, *release.ConfigName, "web")
+	awsc.ALB.AddTargetGroup("web-green", *release.ProjectName, *release.ConfigName, "web")
+	awsc.ALB.AddForwardRule("rule", "10", "other-project-target")
+
+	_, err := release.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS)
+	assert.Error(t, err)
+}
+
+func Test_ShiftConfig_ValidateAttributes(t *testing.T) {
+	s := &ShiftConfig{}
+	s.SetDefaults()
+	assert.NoError(t, s.ValidateAttributes())
+
+	s.Weights = []int64{50, 10, 100}
+	assert.Error(t, s.ValidateAttributes())
+
+	s.Weights = []int64{10, 50}
+	assert.Error(t, s.ValidateAttributes())
+}
+
+func Test_Release_ListenerRule_ShiftTraffic(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	service := release.Services["web"]
+	service.ListenerRule = &ListenerRuleConfig{
+		RuleArn:      to.Strp("rule"),
+		TargetGroups: []*string{to.Strp("web-blue"), to.Strp("web-green")},
+		Shift:        &ShiftConfig{Bake: to.Intp(60), Alarms: []*string{to.Strp("errors")}},
+	}
+	service.ListenerRule.Shift.SetDefaults()
+
+	awsc := MockAwsClients(release)
+	awsc.ALB.AddTargetGroup("web-blue", *release.ProjectName, *release.ConfigName, "web")
+	awsc.ALB.AddTargetGroup("web-green", *release.ProjectName, *release.ConfigName, "web")
+	awsc.ALB.AddForwardRule("rule", "10", "web-blue")
+	awsc.CW.AlarmStates = map[string]string{"errors": "OK"}
+
+	resources, err := release.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS)
+	assert.NoError(t, err)
+	release.UpdateWithResources(resources)
+
+	now := time.Now()
+	for i, weight := range []int64{10, 10, 50, 50, 100, 100} {
+		service.Healthy = true
+		assert.NoError(t, release.ShiftTraffic(awsc.ALB, awsc.CW, now.Add(time.Duration(i*30)*time.Second)))
+		assert.Equal(t, weight, awsc.ALB.RuleWeights["rule"]["web-green"])
+		assert.Equal(t, 100-weight, awsc.ALB.RuleWeights["rule"]["web-blue"])
+	}
+
+	assert.False(t, *release.Healthy)
+
+	service.Healthy = true
+	assert.NoError(t, release.ShiftTraffic(awsc.ALB, awsc.CW, now.Add(3*time.Minute)))
+	assert.True(t, *release.Healthy)
+}
+
+func Test_Release_ListenerRule_ShiftTraffic_Alarm(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	service := release.Services["web"]
+	service.ListenerRule = &ListenerRuleConfig{
+		RuleArn:      to.Strp("rule"),
+		TargetGroups: []*string{to.Strp("web-blue"), to.Strp("web-green")},
+		Shift:        &ShiftConfig{Alarms: []*string{to.Strp("errors")}},
+	}
+	service.ListenerRule.Shift.SetDefaults()
+
+	awsc := MockAwsClients(release)
+	awsc.ALB.AddTargetGroup("web-blue", *release.ProjectName, *release.ConfigName, "web")
+	awsc.ALB.AddTargetGroup("web-green", *release.ProjectName, *release.ConfigName, "web")
+	awsc.ALB.AddForwardRule("rule", "10", "web-blue")
+	awsc.CW.AlarmStates = map[string]string{"errors": "OK"}
+
+	resources, err := release.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS)
+	assert.NoError(t, err)
+	release.UpdateWithResources(resources)
+
+	service.Healthy = true
+	assert.NoError(t, release.ShiftTraffic(awsc.ALB, awsc.CW, time.Now()))
+
+	awsc.CW.AlarmStates["errors"] = "ALARM"
+	err = release.ShiftTraffic(awsc.ALB, awsc.CW, time.Now())
+	assert.IsType(t, &HaltError{}, err)
+
+	assert.NoError(t, release.RollbackTraffic(awsc.ALB))
+	assert.Equal(t, "web-blue", *awsc.ALB.Rules[0].Actions[0].TargetGroupArn)
+}
@@ -0,0 +1,258 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// lockTTL is how many seconds a lock is held without a heartbeat, the deployer renews it on every state
+// so it must outlast the longest Lambda, plus the longest wait or retry interval between states
+const lockTTL = 20 * 60
+
+// maxLockWait is the most seconds a release can wait for the lock, the Lock state retries within it
+const maxLockWait = 60 * 60
+
+// Lock is who holds a project configs lock, written next to the fields step uses to grab and release it
+// so a stuck lock can be checked against its execution before it is deleted
+type Lock struct {
+	HolderUUID   *string    `json:"holder_uuid,omitempty"`
+	ReleaseID    *string    `json:"release_id,omitempty"`
+	ExecutionArn *string    `json:"execution_arn,omitempty"`
+	AcquiredAt   *time.Time `json:"acquired_at,omitempty"`
+	HeartbeatAt  *time.Time `json:"heartbeat_at,omitempty"`
+	TTL          *int       `json:"ttl,omitempty"`   // Seconds after the last heartbeat the lock expires
+	Fence        *int64     `json:"fence,omitempty"` // Increases every time the lock is grabbed, only in lock tables
+}
+
+// Expired returns whether the lock has not been renewed within its TTL
+// Locks from older deployers have no heartbeat so expire TTL seconds after they were acquired
+func (lock *Lock) Expired(now time.Time) bool {
+	renewed := lock.HeartbeatAt
+	if renewed == nil {
+		renewed = lock.AcquiredAt
+	}
+
+	if renewed == nil || lock.TTL == nil {
+		return false
+	}
+	return now.After(renewed.Add(time.Duration(*lock.TTL) * time.Second))
+}
+
+func (release *Release) lockPath() *string {
+	s := fmt.Sprintf("%v/lock", *release.RootDir())
+	return &s
+}
+
+// lockRenewAttempts is how many times a renewal is retried when the lock changed since it was read,
+// as the branches of a release renew its lock at the same time
+const lockRenewAttempts = 5
+
+// lockStore is where the lock is kept, it is read and written conditionally on its ETag
+// Locks stay in S3 if the releases store cannot write conditionally
+func (release *Release) lockStore(s3c aws.S3API) ConditionalStore {
+	if store, ok := release.Store(s3c).(ConditionalStore); ok {
+		return store
+	}
+	return &S3Store{S3: s3c, Bucket: release.Bucket}
+}
+
+// getLock returns the raw fields of the project configs lock and its ETag, nil if it is not locked
+func (release *Release) getLock(s3c aws.S3API) (map[string]interface{}, *string, error) {
+	raw, etag, err := release.lockStore(s3c).GetETag(release.lockPath())
+	if IsNotFound(err) {
+		return nil, nil, nil
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if etag == nil {
+		return nil, nil, fmt.Errorf("Lock has no ETag")
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, nil, fmt.Errorf("Lock invalid %v", err.Error())
+	}
+
+	return fields, etag, nil
+}
+
+// parseLock returns who holds the lock from its raw fields
+func parseLock(fields map[string]interface{}) (*Lock, error) {
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var lock Lock
+	if err := json.Unmarshal(raw, &lock); err != nil {
+		return nil, fmt.Errorf("Lock invalid %v", err.Error())
+	}
+
+	return &lock, nil
+}
+
+// WriteLockMetadata adds who holds the lock to the lock the release grabbed
+func (release *Release) WriteLockMetadata(s3c aws.S3API, now time.Time) error {
+	fields, etag, err := release.getLock(s3c)
+	if err != nil {
+		return err
+	}
+
+	if fields == nil {
+		return fmt.Errorf("Lock not found")
+	}
+
+	if !release.holds(fields) {
+		return fmt.Errorf("Lock held by another release")
+	}
+
+	lock := &Lock{
+		HolderUUID:   release.UUID,
+		ReleaseID:    release.ReleaseID,
+		ExecutionArn: release.ExecutionArn,
+		AcquiredAt:   &now,
+		HeartbeatAt:  &now,
+		TTL:          to.Intp(lockTTL),
+	}
+
+	return release.putLock(s3c, fields, etag, lock)
+}
+
+// putLock writes the lock metadata over its fields, only if the lock has not changed since they were read
+func (release *Release) putLock(s3c aws.S3API, fields map[string]interface{}, etag *string, lock *Lock) error {
+	raw, err := json.Marshal(lock)
+	if err != nil {
+		return err
+	}
+
+	// Keep the fields step wrote so it can still release the lock
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return err
+	}
+
+	raw, err = json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	return release.lockStore(s3c).PutIfMatch(release.lockPath(), raw, etag)
+}
+
+// holds returns whether the lock fields are of the release, locks without metadata are checked by the UUID step wrote
+func (release *Release) holds(fields map[string]interface{}) bool {
+	holder, ok := fields["holder_uuid"].(string)
+	if !ok {
+		holder, _ = fields["uuid"].(string)
+	}
+	return holder != "" && holder == to.Strs(release.UUID)
+}
+
+// RenewLock heartbeats the lock the release holds so it does not expire while the deploy is running
+// It errors if the lock was deleted or stolen, as another deploy may now be changing the project config
+// The heartbeat is only written over the lock that was read, so a lock stolen in between is never overwritten,
+// and a lock renewed in between by another branch of the release is read again and renewed
+func (release *Release) RenewLock(s3c aws.S3API, now time.Time) error {
+	for attempt := 0; attempt < lockRenewAttempts; attempt++ {
+		fields, etag, err := release.getLock(s3c)
+		if err != nil {
+			return err
+		}
+
+		if fields == nil {
+			return fmt.Errorf("%v Lock was deleted while deploying", release.ErrorPrefix())
+		}
+
+		if !release.holds(fields) {
+			return fmt.Errorf("%v Lock was taken by another release while deploying", release.ErrorPrefix())
+		}
+
+		lock, err := parseLock(fields)
+		if err != nil {
+			return err
+		}
+
+		lock.HeartbeatAt = &now
+		if lock.TTL == nil {
+			lock.TTL = to.Intp(lockTTL)
+		}
+
+		if err := release.putLock(s3c, fields, etag, lock); !IsConflict(err) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("%v Lock kept changing while it was renewed", release.ErrorPrefix())
+}
+
+// StealExpiredLock deletes the lock of another release if it has not been renewed within its TTL,
+// e.g. its deployer timed out, returning whether it was stolen so the lock can be grabbed again
+// The lock is only deleted if it has not changed since it was read, so a lock renewed in between is kept
+func (release *Release) StealExpiredLock(s3c aws.S3API, now time.Time) (bool, error) {
+	fields, etag, err := release.getLock(s3c)
+	if err != nil || fields == nil {
+		return false, err
+	}
+
+	lock, err := parseLock(fields)
+	if err != nil {
+		return false, err
+	}
+
+	if !lock.Expired(now) {
+		return false, nil
+	}
+
+	err = release.lockStore(s3c).DeleteIfMatch(release.lockPath(), etag)
+	if IsConflict(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Lock returns who holds the project configs lock, nil if it is not locked
+// Locks grabbed before the metadata was written are empty
+func (release *Release) Lock(s3c aws.S3API) (*Lock, error) {
+	fields, _, err := release.getLock(s3c)
+	if err != nil || fields == nil {
+		return nil, err
+	}
+
+	return parseLock(fields)
+}
+
+// ForceUnlock deletes the project configs lock whoever holds it
+func (release *Release) ForceUnlock(s3c aws.S3API) error {
+	return release.Store(s3c).Delete(release.lockPath())
+}
+
+// WaitsForLock returns whether the release should try the lock again, it waits lock_wait seconds from when it was created
+func (release *Release) WaitsForLock(now time.Time) bool {
+	if release.LockWait == nil || *release.LockWait <= 0 || release.CreatedAt == nil {
+		return false
+	}
+	return now.Before(release.CreatedAt.Add(time.Duration(*release.LockWait) * time.Second))
+}
+
+// String describes who holds the lock
+func (lock *Lock) String() string {
+	return fmt.Sprintf("release %v (uuid %v) acquired at %v last heartbeat %v", to.Strs(lock.ReleaseID), to.Strs(lock.HolderUUID), lockTime(lock.AcquiredAt), lockTime(lock.HeartbeatAt))
+}
+
+func lockTime(t *time.Time) string {
+	if t == nil {
+		return "unknown"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
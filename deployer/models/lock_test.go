@@ -0,0 +1,134 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_WriteLockMetadata(t *testing.T) {
+	release := MockRelease(t)
+	awsc := MockAwsClients(release)
+	release.ExecutionArn = to.Strp("arn:aws:states:us-east-1:000000000000:execution:coinbase-odin:deploy")
+	now := time.Now()
+
+	lock, err := release.Lock(awsc.S3)
+	assert.NoError(t, err)
+	assert.Nil(t, lock)
+
+	assert.NoError(t, release.GrabLock(awsc.S3))
+	assert.NoError(t, release.WriteLockMetadata(awsc.S3, now))
+
+	lock, err = release.Lock(awsc.S3)
+	assert.NoError(t, err)
+	assert.Equal(t, *release.UUID, *lock.HolderUUID)
+	assert.Equal(t, *release.ReleaseID, *lock.ReleaseID)
+	assert.Equal(t, *release.ExecutionArn, *lock.ExecutionArn)
+	assert.Equal(t, lockTTL, *lock.TTL)
+
+	assert.False(t, lock.Expired(now))
+	assert.True(t, lock.Expired(now.Add((lockTTL+1)*time.Second)))
+
+	// The lock can still be grabbed again and released by its holder
+	assert.NoError(t, release.GrabLock(awsc.S3))
+	assert.NoError(t, release.ReleaseLock(awsc.S3))
+}
+
+func Test_Release_RenewLock(t *testing.T) {
+	release := MockRelease(t)
+	release.UUID = to.Strp("holder")
+	awsc := MockAwsClients(release)
+	now := time.Now()
+
+	// Cannot renew a lock that is not held
+	assert.Error(t, release.RenewLock(awsc.S3, now))
+
+	assert.NoError(t, release.GrabLock(awsc.S3))
+	assert.NoError(t, release.WriteLockMetadata(awsc.S3, now))
+
+	later := now.Add(lockTTL * time.Second)
+	assert.NoError(t, release.RenewLock(awsc.S3, later))
+
+	lock, err := release.Lock(awsc.S3)
+	assert.NoError(t, err)
+	assert.Equal(t, later.Unix(), lock.HeartbeatAt.Unix())
+	assert.False(t, lock.Expired(later.Add(time.Minute)))
+
+	// A renewed lock is not stolen
+	other := MockRelease(t)
+	other.UUID = to.Strp("other")
+	stolen, err := other.StealExpiredLock(awsc.S3, later.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.False(t, stolen)
+
+	// Once it lapses another release can steal it, and the holder can no longer renew it
+	stolen, err = other.StealExpiredLock(awsc.S3, later.Add((lockTTL+1)*time.Second))
+	assert.NoError(t, err)
+	assert.True(t, stolen)
+
+	assert.NoError(t, other.GrabLock(awsc.S3))
+	assert.Error(t, release.RenewLock(awsc.S3, later))
+}
+
+func Test_Release_Lock_Races(t *testing.T) {
+	release := MockRelease(t)
+	release.UUID = to.Strp("holder")
+	awsc := MockAwsClients(release)
+	now := time.Now()
+
+	assert.NoError(t, release.GrabLock(awsc.S3))
+	assert.NoError(t, release.WriteLockMetadata(awsc.S3, now))
+
+	// A renewal racing a renewal from another branch of the release reads the lock again
+	awsc.S3.BeforeConditionalWrite = func() { assert.NoError(t, release.RenewLock(awsc.S3, now.Add(time.Second))) }
+	later := now.Add(time.Minute)
+	assert.NoError(t, release.RenewLock(awsc.S3, later))
+
+	lock, err := release.Lock(awsc.S3)
+	assert.NoError(t, err)
+	assert.Equal(t, later.Unix(), lock.HeartbeatAt.Unix())
+
+	// A lock renewed after it was read as expired is not stolen
+	other := MockRelease(t)
+	other.UUID = to.Strp("other")
+	expired := later.Add((lockTTL + 1) * time.Second)
+
+	awsc.S3.BeforeConditionalWrite = func() { assert.NoError(t, release.RenewLock(awsc.S3, expired)) }
+	stolen, err := other.StealExpiredLock(awsc.S3, expired)
+	assert.NoError(t, err)
+	assert.False(t, stolen)
+
+	lock, err = release.Lock(awsc.S3)
+	assert.NoError(t, err)
+	assert.Equal(t, "holder", *lock.HolderUUID)
+
+	// A renewal racing a steal never overwrites the new holders lock
+	awsc.S3.BeforeConditionalWrite = func() {
+		assert.NoError(t, release.ForceUnlock(awsc.S3))
+		assert.NoError(t, other.GrabLock(awsc.S3))
+	}
+	assert.Error(t, release.RenewLock(awsc.S3, expired))
+
+	fields, _, err := release.getLock(awsc.S3)
+	assert.NoError(t, err)
+	assert.True(t, other.holds(fields))
+}
+
+func Test_Release_WaitsForLock(t *testing.T) {
+	release := MockRelease(t)
+	now := *release.CreatedAt
+
+	assert.False(t, release.WaitsForLock(now))
+
+	release.LockWait = to.Intp(600)
+	assert.True(t, release.WaitsForLock(now.Add(time.Minute)))
+	assert.False(t, release.WaitsForLock(now.Add(11*time.Minute)))
+
+	MockPrepareRelease(release)
+	assert.NoError(t, release.ValidateConfiguration())
+
+	release.LockWait = to.Intp(maxLockWait + 1)
+	assert.Error(t, release.ValidateConfiguration())
+}
@@ -0,0 +1,305 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/errors"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Locker holds the project configs lock so only one release deploys it at a time
+type Locker interface {
+	Grab(release *Release, now time.Time) error // LockExistsError if another release holds it
+	Renew(release *Release, now time.Time) error
+	Release(release *Release) error
+	Get(release *Release) (*Lock, error) // nil if it is not locked
+	ForceUnlock(release *Release) error
+}
+
+// S3Locker keeps the lock in the releases bucket next to its releases
+type S3Locker struct {
+	S3 aws.S3API
+}
+
+// Grab returns
+func (locker *S3Locker) Grab(release *Release, now time.Time) error {
+	release.LockFence = nil // S3 locks have no fence
+
+	if err := release.GrabLock(locker.S3); err != nil {
+		// A lock that has not been renewed within its TTL was left by a deployer that stopped
+		stolen, serr := release.StealExpiredLock(locker.S3, now)
+		if serr != nil || !stolen {
+			return err
+		}
+
+		if err := release.GrabLock(locker.S3); err != nil {
+			return err
+		}
+	}
+
+	// Best effort, the lock works without it but cannot be checked by odin unlock
+	release.WriteLockMetadata(locker.S3, now)
+
+	return nil
+}
+
+// Renew returns
+func (locker *S3Locker) Renew(release *Release, now time.Time) error {
+	return release.RenewLock(locker.S3, now)
+}
+
+// Release returns
+func (locker *S3Locker) Release(release *Release) error {
+	return release.ReleaseLock(locker.S3)
+}
+
+// Get returns
+func (locker *S3Locker) Get(release *Release) (*Lock, error) {
+	return release.Lock(locker.S3)
+}
+
+// ForceUnlock returns
+func (locker *S3Locker) ForceUnlock(release *Release) error {
+	return release.ForceUnlock(locker.S3)
+}
+
+// DynamoDBLocker keeps the lock in a table with a lock_id string hash key
+// Every change is a conditional write, so unlike S3 two releases can never both think they hold it
+// Released locks keep their item so their fencing token keeps increasing, expires_at can be the tables TTL attribute
+type DynamoDBLocker struct {
+	DynamoDB aws.DynamoDBAPI
+	Table    *string
+}
+
+// holderAttributes are removed from the item when the lock is released
+const holderAttributes = "holder_uuid, release_id, execution_arn, acquired_at, heartbeat_at"
+
+// lockKey is the item of the project configs lock, its bucket and path as it would be in S3
+func (locker *DynamoDBLocker) lockKey(release *Release) map[string]*dynamodb.AttributeValue {
+	id := fmt.Sprintf("%v/%v", to.Strs(release.Bucket), *release.lockPath())
+	return map[string]*dynamodb.AttributeValue{"lock_id": {S: &id}}
+}
+
+// Grab takes the lock if nobody holds it, its holder let it expire, or the release already holds it
+// Each grab increments the locks fence, which is carried in the release so a deploy whose lock was taken
+// from it cannot renew it even if it was taken by a deploy with the same UUID
+func (locker *DynamoDBLocker) Grab(release *Release, now time.Time) error {
+	if release.UUID == nil {
+		return fmt.Errorf("Lock requires the release UUID")
+	}
+
+	set := "holder_uuid = :uuid, acquired_at = :now_time, heartbeat_at = :now_time, lock_ttl = :ttl, expires_at = :expires, fence = if_not_exists(fence, :zero) + :one"
+	values := map[string]*dynamodb.AttributeValue{
+		":uuid":     {S: release.UUID},
+		":now":      {N: to.Strp(strconv.FormatInt(now.Unix(), 10))},
+		":now_time": {S: dynamoDBTime(now)},
+		":ttl":      {N: to.Strp(strconv.Itoa(lockTTL))},
+		":expires":  {N: dynamoDBExpiry(now)},
+		":zero":     {N: to.Strp("0")},
+		":one":      {N: to.Strp("1")},
+	}
+
+	remove := []string{}
+	if release.ReleaseID != nil {
+		set += ", release_id = :release_id"
+		values[":release_id"] = &dynamodb.AttributeValue{S: release.ReleaseID}
+	} else {
+		remove = append(remove, "release_id")
+	}
+
+	if release.ExecutionArn != nil {
+		set += ", execution_arn = :execution_arn"
+		values[":execution_arn"] = &dynamodb.AttributeValue{S: release.ExecutionArn}
+	} else {
+		remove = append(remove, "execution_arn")
+	}
+
+	update := "SET " + set
+	if len(remove) > 0 {
+		update += " REMOVE " + strings.Join(remove, ", ")
+	}
+
+	output, err := locker.DynamoDB.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:                 locker.Table,
+		Key:                       locker.lockKey(release),
+		UpdateExpression:          &update,
+		ConditionExpression:       to.Strp("attribute_not_exists(holder_uuid) OR expires_at < :now OR holder_uuid = :uuid"),
+		ExpressionAttributeValues: values,
+		ReturnValues:              to.Strp(dynamodb.ReturnValueAllNew),
+	})
+
+	if isConditionFailed(err) {
+		return &errors.LockExistsError{fmt.Sprintf("Lock Already Exists in table %v", to.Strs(locker.Table))}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fence := int64Attribute(output.Attributes["fence"])
+	if fence == nil {
+		return fmt.Errorf("Lock fence missing")
+	}
+
+	release.LockFence = fence
+	return nil
+}
+
+// Renew heartbeats the lock only if the release still holds it with the fence it grabbed it with
+func (locker *DynamoDBLocker) Renew(release *Release, now time.Time) error {
+	condition := "holder_uuid = :uuid"
+	values := map[string]*dynamodb.AttributeValue{
+		":heartbeat": {S: dynamoDBTime(now)},
+		":expires":   {N: dynamoDBExpiry(now)},
+		":uuid":      {S: release.UUID},
+	}
+
+	if release.LockFence != nil {
+		condition += " AND fence = :fence"
+		values[":fence"] = &dynamodb.AttributeValue{N: to.Strp(strconv.FormatInt(*release.LockFence, 10))}
+	}
+
+	_, err := locker.DynamoDB.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:                 locker.Table,
+		Key:                       locker.lockKey(release),
+		UpdateExpression:          to.Strp("SET heartbeat_at = :heartbeat, expires_at = :expires"),
+		ConditionExpression:       &condition,
+		ExpressionAttributeValues: values,
+	})
+
+	if !isConditionFailed(err) {
+		return err
+	}
+
+	lock, err := locker.Get(release)
+	if err != nil {
+		return err
+	}
+
+	if lock == nil {
+		return fmt.Errorf("%v Lock was deleted while deploying", release.ErrorPrefix())
+	}
+
+	return fmt.Errorf("%v Lock was taken by another release while deploying", release.ErrorPrefix())
+}
+
+// Release removes the holder from the lock if the release holds it, a lock already released is released
+func (locker *DynamoDBLocker) Release(release *Release) error {
+	_, err := locker.DynamoDB.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:           locker.Table,
+		Key:                 locker.lockKey(release),
+		UpdateExpression:    to.Strp("REMOVE " + holderAttributes),
+		ConditionExpression: to.Strp("attribute_not_exists(holder_uuid) OR holder_uuid = :uuid"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":uuid": {S: release.UUID},
+		},
+	})
+
+	if isConditionFailed(err) {
+		return fmt.Errorf("Lock held by another release")
+	}
+
+	return err
+}
+
+// Get returns
+func (locker *DynamoDBLocker) Get(release *Release) (*Lock, error) {
+	output, err := locker.DynamoDB.GetItem(&dynamodb.GetItemInput{
+		TableName:      locker.Table,
+		Key:            locker.lockKey(release),
+		ConsistentRead: to.Boolp(true),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Released locks keep their item without a holder
+	if output.Item["holder_uuid"] == nil {
+		return nil, nil
+	}
+
+	lock := &Lock{
+		HolderUUID:   stringAttribute(output.Item["holder_uuid"]),
+		AcquiredAt:   parseDynamoDBTime(output.Item["acquired_at"]),
+		HeartbeatAt:  parseDynamoDBTime(output.Item["heartbeat_at"]),
+		ReleaseID:    stringAttribute(output.Item["release_id"]),
+		ExecutionArn: stringAttribute(output.Item["execution_arn"]),
+		Fence:        int64Attribute(output.Item["fence"]),
+	}
+
+	if ttl := int64Attribute(output.Item["lock_ttl"]); ttl != nil {
+		lock.TTL = to.Intp(int(*ttl))
+	}
+
+	return lock, nil
+}
+
+// ForceUnlock removes the holder from the lock whoever it is, keeping its fence
+func (locker *DynamoDBLocker) ForceUnlock(release *Release) error {
+	_, err := locker.DynamoDB.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:        locker.Table,
+		Key:              locker.lockKey(release),
+		UpdateExpression: to.Strp("REMOVE " + holderAttributes),
+	})
+	return err
+}
+
+// dynamoDBExpiry is when a lock heartbeat at now expires, in epoch seconds so it can be the tables TTL attribute
+func dynamoDBExpiry(now time.Time) *string {
+	return to.Strp(strconv.FormatInt(now.Add(lockTTL*time.Second).Unix(), 10))
+}
+
+func dynamoDBTime(t time.Time) *string {
+	return to.Strp(t.UTC().Format(time.RFC3339))
+}
+
+func parseDynamoDBTime(attribute *dynamodb.AttributeValue) *time.Time {
+	if attribute == nil || attribute.S == nil {
+		return nil
+	}
+
+	t, err := time.Parse(time.RFC3339, *attribute.S)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+func stringAttribute(attribute *dynamodb.AttributeValue) *string {
+	if attribute == nil {
+		return nil
+	}
+	return attribute.S
+}
+
+func int64Attribute(attribute *dynamodb.AttributeValue) *int64 {
+	if attribute == nil || attribute.N == nil {
+		return nil
+	}
+
+	n, err := strconv.ParseInt(*attribute.N, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
+
+func isConditionFailed(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException
+}
+
+// NewLocker returns the DynamoDB locker of the table, or the S3 locker if no table is given
+func NewLocker(s3c aws.S3API, dynamodbc aws.DynamoDBAPI, table *string) Locker {
+	if table == nil || *table == "" {
+		return &S3Locker{S3: s3c}
+	}
+	return &DynamoDBLocker{DynamoDB: dynamodbc, Table: table}
+}
@@ -0,0 +1,147 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/errors"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewLocker(t *testing.T) {
+	awsc := mocks.MockAWS()
+
+	assert.IsType(t, &S3Locker{}, NewLocker(awsc.S3, awsc.DynamoDB, nil))
+	assert.IsType(t, &S3Locker{}, NewLocker(awsc.S3, awsc.DynamoDB, to.Strp("")))
+	assert.IsType(t, &DynamoDBLocker{}, NewLocker(awsc.S3, awsc.DynamoDB, to.Strp("odin-locks")))
+}
+
+func Test_DynamoDBLocker(t *testing.T) {
+	awsc := mocks.MockAWS()
+	locker := &DynamoDBLocker{DynamoDB: awsc.DynamoDB, Table: to.Strp("odin-locks")}
+	now := time.Now()
+
+	release := MockRelease(t)
+	release.UUID = to.Strp("holder")
+	release.ExecutionArn = to.Strp("arn:aws:states:us-east-1:000000000000:execution:coinbase-odin:deploy")
+
+	other := MockRelease(t)
+	other.UUID = to.Strp("other")
+
+	lock, err := locker.Get(release)
+	assert.NoError(t, err)
+	assert.Nil(t, lock)
+
+	// Cannot renew a lock that is not held
+	assert.Error(t, locker.Renew(release, now))
+
+	assert.NoError(t, locker.Grab(release, now))
+	lock, err = locker.Get(release)
+	assert.NoError(t, err)
+	assert.Equal(t, "holder", *lock.HolderUUID)
+	assert.Equal(t, *release.ReleaseID, *lock.ReleaseID)
+	assert.Equal(t, *release.ExecutionArn, *lock.ExecutionArn)
+	assert.Equal(t, lockTTL, *lock.TTL)
+	assert.Equal(t, now.Unix(), lock.AcquiredAt.Unix())
+	assert.Equal(t, int64(1), *lock.Fence)
+	assert.Equal(t, int64(1), *release.LockFence)
+
+	// The holder can grab it again with a new fence, nobody else can until it expires
+	assert.NoError(t, locker.Grab(release, now))
+	assert.Equal(t, int64(2), *release.LockFence)
+	err = locker.Grab(other, now)
+	assert.IsType(t, &errors.LockExistsError{}, err)
+
+	later := now.Add(lockTTL * time.Second)
+	assert.NoError(t, locker.Renew(release, later))
+
+	// A copy of the release with an older fence cannot renew it
+	stale := MockRelease(t)
+	stale.UUID = to.Strp("holder")
+	stale.LockFence = to.Int64p(1)
+	assert.Error(t, locker.Renew(stale, later))
+
+	assert.IsType(t, &errors.LockExistsError{}, locker.Grab(other, later.Add(time.Minute)))
+
+	// Others cannot release it
+	assert.Error(t, locker.Release(other))
+
+	// Once it lapses another release takes it, and the holder can no longer renew it
+	assert.NoError(t, locker.Grab(other, later.Add((lockTTL+1)*time.Second)))
+	assert.Error(t, locker.Renew(release, later))
+
+	lock, err = locker.Get(release)
+	assert.NoError(t, err)
+	assert.Equal(t, "other", *lock.HolderUUID)
+	assert.Equal(t, int64(3), *other.LockFence)
+
+	assert.NoError(t, locker.Release(other))
+	lock, err = locker.Get(release)
+	assert.NoError(t, err)
+	assert.Nil(t, lock)
+
+	// Releasing a lock that is gone is fine
+	assert.NoError(t, locker.Release(other))
+
+	// Released and unlocked locks keep counting
+	assert.NoError(t, locker.Grab(release, now))
+	assert.Equal(t, int64(4), *release.LockFence)
+	assert.NoError(t, locker.ForceUnlock(other))
+	lock, err = locker.Get(release)
+	assert.NoError(t, err)
+	assert.Nil(t, lock)
+
+	assert.NoError(t, locker.Grab(other, now))
+	assert.Equal(t, int64(5), *other.LockFence)
+}
+
+func Test_DynamoDBLocker_ProjectConfigs(t *testing.T) {
+	awsc := mocks.MockAWS()
+	locker := &DynamoDBLocker{DynamoDB: awsc.DynamoDB, Table: to.Strp("odin-locks")}
+	now := time.Now()
+
+	release := MockRelease(t)
+	release.UUID = to.Strp("holder")
+
+	other := MockRelease(t)
+	other.UUID = to.Strp("other")
+	other.ConfigName = to.Strp("other-config")
+
+	// Each project config has its own lock and fence
+	assert.NoError(t, locker.Grab(release, now))
+	assert.NoError(t, locker.Grab(other, now))
+	assert.Equal(t, 2, len(awsc.DynamoDB.Tables["odin-locks"]))
+	assert.Equal(t, int64(1), *other.LockFence)
+}
+
+func Test_S3Locker(t *testing.T) {
+	release := MockRelease(t)
+	release.UUID = to.Strp("holder")
+	awsc := MockAwsClients(release)
+	locker := &S3Locker{S3: awsc.S3}
+	now := time.Now()
+
+	// Only lock tables have fences
+	release.LockFence = to.Int64p(10)
+
+	assert.NoError(t, locker.Grab(release, now))
+	assert.NoError(t, locker.Renew(release, now))
+
+	lock, err := locker.Get(release)
+	assert.NoError(t, err)
+	assert.Equal(t, "holder", *lock.HolderUUID)
+	assert.Nil(t, release.LockFence)
+
+	// An expired lock is stolen
+	other := MockRelease(t)
+	other.UUID = to.Strp("other")
+	assert.Error(t, locker.Grab(other, now))
+	assert.NoError(t, locker.Grab(other, now.Add((lockTTL+1)*time.Second)))
+
+	assert.NoError(t, locker.Release(other))
+	lock, err = locker.Get(release)
+	assert.NoError(t, err)
+	assert.Nil(t, lock)
+}
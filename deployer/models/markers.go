@@ -0,0 +1,139 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/secret"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Endpoints of the APM deployment marker APIs, variables to be replaced in tests
+var (
+	datadogEventsURL       = "https://api.datadoghq.com/api/v1/events"
+	newRelicDeploymentsURL = "https://api.newrelic.com/v2/applications/%v/deployments.json"
+)
+
+// MarkerKeys are secret references to the API keys of the APM tools to mark deploys in
+type MarkerKeys struct {
+	Datadog  *string
+	NewRelic *string
+}
+
+// CreateDeployMarkers creates deployment events in Datadog and New Relic for a successful release
+// New Relic requires the releases newrelic_app_id
+func (release *Release) CreateDeployMarkers(ssmc aws.SSMAPI, smc aws.SMAPI, keys *MarkerKeys) error {
+	if keys.Datadog != nil {
+		apiKey, err := secret.Get(ssmc, smc, keys.Datadog)
+		if err != nil {
+			return err
+		}
+
+		if err := release.createDatadogEvent(apiKey); err != nil {
+			return err
+		}
+	}
+
+	if keys.NewRelic != nil && release.NewRelicAppID != nil {
+		apiKey, err := secret.Get(ssmc, smc, keys.NewRelic)
+		if err != nil {
+			return err
+		}
+
+		if err := release.createNewRelicDeployment(apiKey); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (release *Release) createDatadogEvent(apiKey *string) error {
+	tags := []string{
+		fmt.Sprintf("project:%v", to.Strs(release.ProjectName)),
+		fmt.Sprintf("config:%v", to.Strs(release.ConfigName)),
+		fmt.Sprintf("release_id:%v", to.Strs(release.ReleaseID)),
+	}
+
+	return postMarker(datadogEventsURL, map[string]string{"DD-API-KEY": *apiKey}, map[string]interface{}{
+		"title":            fmt.Sprintf("odin deployed %v/%v", to.Strs(release.ProjectName), to.Strs(release.ConfigName)),
+		"text":             release.markerDescription(),
+		"tags":             tags,
+		"alert_type":       "success",
+		"source_type_name": "odin",
+		"aggregation_key":  fmt.Sprintf("odin-%v-%v", to.Strs(release.ProjectName), to.Strs(release.ConfigName)),
+	})
+}
+
+func (release *Release) createNewRelicDeployment(apiKey *string) error {
+	return postMarker(fmt.Sprintf(newRelicDeploymentsURL, *release.NewRelicAppID), map[string]string{"X-Api-Key": *apiKey}, map[string]interface{}{
+		"deployment": map[string]string{
+			"revision":    to.Strs(release.ReleaseID),
+			"description": release.markerDescription(),
+			"user":        "odin",
+		},
+	})
+}
+
+// markerDescription lists what was deployed
+func (release *Release) markerDescription() string {
+	return fmt.Sprintf("release_id: %v, ami: %v (%v), user_data_sha256: %v",
+		to.Strs(release.ReleaseID),
+		to.Strs(release.Image),
+		strings.Join(release.imageIDs(), ", "),
+		to.Strs(release.UserDataSHA256),
+	)
+}
+
+// imageIDs returns the AMI IDs the release deployed
+func (release *Release) imageIDs() []string {
+	seen := map[string]bool{}
+	ids := []string{}
+	for _, service := range release.Services {
+		if service == nil || service.Resources == nil || service.Resources.Image == nil {
+			continue
+		}
+
+		if id := *service.Resources.Image; !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+	return ids
+}
+
+func postMarker(url string, headers map[string]string, body interface{}) error {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := notificationClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Deploy marker %v returned %v", url, resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_CreateDeployMarkers(t *testing.T) {
+	received := map[string]map[string]interface{}{}
+	keys := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := ioutil.ReadAll(r.Body)
+		body := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal(raw, &body))
+		received[r.URL.Path] = body
+		keys[r.URL.Path] = r.Header.Get("DD-API-KEY") + r.Header.Get("X-Api-Key")
+	}))
+	defer server.Close()
+
+	defaultDatadog, defaultNewRelic := datadogEventsURL, newRelicDeploymentsURL
+	datadogEventsURL, newRelicDeploymentsURL = server.URL+"/datadog", server.URL+"/newrelic/%v"
+	defer func() { datadogEventsURL, newRelicDeploymentsURL = defaultDatadog, defaultNewRelic }()
+
+	awsc := mocks.MockAWS()
+	awsc.SSM.AddParameter("/odin/datadog", "dd-key")
+	awsc.SSM.AddParameter("/odin/newrelic", "nr-key")
+
+	release := MockRelease(t)
+	release.NewRelicAppID = to.Strp("1234")
+	release.UserDataSHA256 = to.Strp("sha")
+
+	err := release.CreateDeployMarkers(awsc.SSM, awsc.SM, &MarkerKeys{
+		Datadog:  to.Strp("ssm:/odin/datadog"),
+		NewRelic: to.Strp("ssm:/odin/newrelic"),
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "dd-key", keys["/datadog"])
+	assert.Equal(t, "success", received["/datadog"]["alert_type"])
+	assert.Contains(t, received["/datadog"]["text"], "user_data_sha256: sha")
+
+	assert.Equal(t, "nr-key", keys["/newrelic/1234"])
+	deployment := received["/newrelic/1234"]["deployment"].(map[string]interface{})
+	assert.Equal(t, *release.ReleaseID, deployment["revision"])
+}
+
+func Test_Release_CreateDeployMarkers_NoKeys(t *testing.T) {
+	release := MockRelease(t)
+	awsc := mocks.MockAWS()
+	assert.NoError(t, release.CreateDeployMarkers(awsc.SSM, awsc.SM, &MarkerKeys{}))
+}
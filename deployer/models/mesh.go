@@ -0,0 +1,235 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/mesh"
+	"github.com/coinbase/step/utils/is"
+)
+
+// MeshParameterPrefix is the path SSM weights parameters must be under, which the assumed role can write
+const MeshParameterPrefix = "/mesh/"
+
+// MeshConfig cuts over a service mesh between a blue and green virtual node instead of load balancers
+// The new ASG is tagged with the virtual node that gets no traffic, its proxies register as that node,
+// and once it is healthy the traffic is dialed to it step by step, either on an App Mesh route
+// or in an SSM parameter of {"<virtual_node>": weight} that the proxies read
+type MeshConfig struct {
+	MeshName      *string `json:"mesh_name,omitempty"`
+	VirtualRouter *string `json:"virtual_router,omitempty"`
+	Route         *string `json:"route,omitempty"`
+
+	Parameter *string `json:"parameter,omitempty"`
+
+	VirtualNodes []*string `json:"virtual_nodes,omitempty"` // Names of the blue and green virtual nodes
+
+	// Shift is the weights dialed to the next node, each held for the bake so the proxies converge
+	Shift *ShiftConfig `json:"shift,omitempty"`
+
+	// Generated
+	ActiveNode *string `json:"active_node,omitempty"`
+	NextNode   *string `json:"next_node,omitempty"`
+}
+
+// SetDefaults assigns default values
+func (m *MeshConfig) SetDefaults() {
+	if m.Shift == nil {
+		m.Shift = &ShiftConfig{}
+	}
+
+	m.Shift.SetDefaults()
+}
+
+// ValidateAttributes validates attributes
+func (m *MeshConfig) ValidateAttributes() error {
+	appMesh := !is.EmptyStr(m.MeshName) || !is.EmptyStr(m.VirtualRouter) || !is.EmptyStr(m.Route)
+
+	if appMesh && (is.EmptyStr(m.MeshName) || is.EmptyStr(m.VirtualRouter) || is.EmptyStr(m.Route)) {
+		return fmt.Errorf("Mesh requires mesh_name, virtual_router and route")
+	}
+
+	if appMesh == !is.EmptyStr(m.Parameter) {
+		return fmt.Errorf("Mesh requires either an App Mesh route or a parameter")
+	}
+
+	if m.Parameter != nil && !strings.HasPrefix(*m.Parameter, MeshParameterPrefix) {
+		return fmt.Errorf("Mesh parameter must be under %v", MeshParameterPrefix)
+	}
+
+	if len(m.VirtualNodes) != 2 || !is.UniqueStrp(m.VirtualNodes) {
+		return fmt.Errorf("Mesh requires two unique virtual_nodes")
+	}
+
+	if m.Shift != nil {
+		if err := m.Shift.ValidateAttributes(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *MeshConfig) route() *mesh.Route {
+	return &mesh.Route{MeshName: m.MeshName, VirtualRouterName: m.VirtualRouter, RouteName: m.Route}
+}
+
+func (m *MeshConfig) weights(meshc aws.MeshAPI, ssmc aws.SSMAPI) (mesh.Weights, error) {
+	if m.Parameter != nil {
+		return mesh.ParameterWeights(ssmc, m.Parameter)
+	}
+	return mesh.RouteWeights(meshc, m.route())
+}
+
+// FetchResources finds which of the virtual nodes gets the traffic
+// If neither does, e.g. the first deploy writing the parameter, the new ASG is the first node
+func (m *MeshConfig) FetchResources(meshc aws.MeshAPI, ssmc aws.SSMAPI) error {
+	weights, err := m.weights(meshc, ssmc)
+	if err != nil {
+		return err
+	}
+
+	blue, green := m.VirtualNodes[0], m.VirtualNodes[1]
+
+	switch active := weights.Heaviest(); {
+	case active == nil:
+		m.ActiveNode, m.NextNode = nil, blue
+	case *active == *blue:
+		m.ActiveNode, m.NextNode = blue, green
+	case *active == *green:
+		m.ActiveNode, m.NextNode = green, blue
+	default:
+		return fmt.Errorf("Mesh routes to %v which is not one of the virtual_nodes", *active)
+	}
+
+	return nil
+}
+
+// dial sends percent of the traffic to the next virtual node and the rest to the active one
+func (m *MeshConfig) dial(meshc aws.MeshAPI, ssmc aws.SSMAPI, percent int64) error {
+	weights := mesh.Weights{*m.NextNode: percent}
+	if m.ActiveNode != nil {
+		weights[*m.ActiveNode] = 100 - percent
+	}
+
+	if m.Parameter != nil {
+		return mesh.SetParameterWeights(ssmc, m.Parameter, weights)
+	}
+	return mesh.SetRouteWeights(meshc, m.route(), weights)
+}
+
+// converged returns whether the proxies are being sent the last weights
+// App Mesh routes are only pushed to the proxies once active, SSM parameters are read by the proxies during the bake
+func (m *MeshConfig) converged(meshc aws.MeshAPI) (bool, error) {
+	if m.Parameter != nil {
+		return true, nil
+	}
+	return mesh.RouteActive(meshc, m.route())
+}
+
+// dialMesh checks the alarms then dials the next weight once the current one has converged and baked
+// The service is only healthy once all traffic has been dialed and the proxies have converged
+func (service *Service) dialMesh(meshc aws.MeshAPI, ssmc aws.SSMAPI, cwc aws.CWAPI, now time.Time) error {
+	m := service.Mesh
+	shift := m.Shift
+
+	if err := shift.checkAlarms(cwc, service.errorPrefix()); err != nil {
+		return err
+	}
+
+	// The bake only starts once the proxies have the weight, so old ASGs are never deleted while still routed to
+	if shift.Step != nil {
+		converged, err := m.converged(meshc)
+		if err != nil {
+			return err // This might retry
+		}
+
+		if !converged {
+			shift.ShiftedAt = &now
+			service.Healthy = false
+			return nil
+		}
+	}
+
+	if !shift.shifted(now) && (shift.Step == nil || shift.baked(now)) {
+		step := 0
+		if shift.Step != nil {
+			step = *shift.Step + 1
+		}
+
+		if step < len(shift.Weights) {
+			if err := m.dial(meshc, ssmc, shift.Weights[step]); err != nil {
+				return err // This might retry
+			}
+
+			shift.Step = &step
+			shift.ShiftedAt = &now
+		}
+	}
+
+	service.Healthy = shift.shifted(now)
+	return nil
+}
+
+// FetchMeshes finds the active virtual node of every service in a mesh
+func (release *Release) FetchMeshes(meshc aws.MeshAPI, ssmc aws.SSMAPI) error {
+	for _, service := range release.Services {
+		if service.Mesh == nil {
+			continue
+		}
+
+		if err := service.Mesh.FetchResources(meshc, ssmc); err != nil {
+			return fmt.Errorf("%v %v", service.errorPrefix(), err.Error())
+		}
+	}
+
+	return nil
+}
+
+// DialMeshes dials the mesh traffic of every healthy service to the new ASGs virtual node
+func (release *Release) DialMeshes(meshc aws.MeshAPI, ssmc aws.SSMAPI, cwc aws.CWAPI, now time.Time) error {
+	healthy := true
+
+	for _, service := range release.Services {
+		if service.Healthy && service.dialing() && !service.IsHalted() {
+			if err := service.dialMesh(meshc, ssmc, cwc, now); err != nil {
+				return err
+			}
+		}
+
+		healthy = healthy && service.Healthy
+	}
+
+	release.Healthy = &healthy
+	return nil
+}
+
+// RollbackMeshes sends all of the traffic of dialed meshes back to the old virtual node
+func (release *Release) RollbackMeshes(meshc aws.MeshAPI, ssmc aws.SSMAPI) error {
+	for _, service := range release.Services {
+		if !service.dialing() || service.Mesh.Shift.Step == nil {
+			continue
+		}
+
+		if err := service.Mesh.dial(meshc, ssmc, 0); err != nil {
+			return fmt.Errorf("%v %v", service.errorPrefix(), err.Error())
+		}
+	}
+
+	return nil
+}
+
+// dialing returns whether the service dials traffic between mesh virtual nodes
+func (service *Service) dialing() bool {
+	return service.Mesh != nil && service.Mesh.NextNode != nil && service.Mesh.Shift != nil
+}
+
+// virtualNode returns the virtual node the new ASGs proxies register as
+func (service *Service) virtualNode() *string {
+	if service.Mesh == nil {
+		return nil
+	}
+	return service.Mesh.NextNode
+}
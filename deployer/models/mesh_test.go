@@ -0,0 +1,152 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockMeshRelease(t *testing.T, mesh *MeshConfig) *Release {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	release.Services["web"].Mesh = mesh
+	mesh.SetDefaults()
+	return release
+}
+
+func Test_MeshConfig_ValidateAttributes(t *testing.T) {
+	nodes := []*string{to.Strp("web-blue"), to.Strp("web-green")}
+
+	m := &MeshConfig{MeshName: to.Strp("mesh"), VirtualRouter: to.Strp("router"), Route: to.Strp("route"), VirtualNodes: nodes}
+	assert.NoError(t, m.ValidateAttributes())
+
+	m = &MeshConfig{Parameter: to.Strp("/mesh/web"), VirtualNodes: nodes}
+	assert.NoError(t, m.ValidateAttributes())
+
+	m = &MeshConfig{MeshName: to.Strp("mesh"), VirtualNodes: nodes}
+	assert.Error(t, m.ValidateAttributes())
+
+	m = &MeshConfig{MeshName: to.Strp("mesh"), VirtualRouter: to.Strp("router"), Route: to.Strp("route"), Parameter: to.Strp("/mesh/web"), VirtualNodes: nodes}
+	assert.Error(t, m.ValidateAttributes())
+
+	m = &MeshConfig{VirtualNodes: nodes}
+	assert.Error(t, m.ValidateAttributes())
+
+	m = &MeshConfig{Parameter: to.Strp("/mesh/web"), VirtualNodes: []*string{to.Strp("web-blue"), to.Strp("web-blue")}}
+	assert.Error(t, m.ValidateAttributes())
+
+	// Only parameters the assumed role can write
+	m = &MeshConfig{Parameter: to.Strp("/odin/web"), VirtualNodes: nodes}
+	assert.Error(t, m.ValidateAttributes())
+
+	m = &MeshConfig{Parameter: to.Strp("/mesh/web"), VirtualNodes: nodes, Shift: &ShiftConfig{Weights: []int64{50}, Bake: to.Intp(0)}}
+	assert.Error(t, m.ValidateAttributes())
+}
+
+func routeWeights(meshc *mocks.MeshClient) map[string]int64 {
+	weights := map[string]int64{}
+	for _, target := range meshc.Routes["mesh/router/route"].Spec.HttpRoute.Action.WeightedTargets {
+		weights[*target.VirtualNode] = *target.Weight
+	}
+	return weights
+}
+
+func Test_Release_DialMeshes_Route(t *testing.T) {
+	release := mockMeshRelease(t, &MeshConfig{
+		MeshName:      to.Strp("mesh"),
+		VirtualRouter: to.Strp("router"),
+		Route:         to.Strp("route"),
+		VirtualNodes:  []*string{to.Strp("web-blue"), to.Strp("web-green")},
+		Shift:         &ShiftConfig{Weights: []int64{50, 100}, Bake: to.Intp(60)},
+	})
+
+	meshc := &mocks.MeshClient{}
+	ssmc := &mocks.SSMClient{}
+	cwc := &mocks.CWClient{}
+	meshc.AddRoute("mesh", "router", "route", map[string]int64{"web-blue": 100, "web-green": 0})
+
+	assert.NoError(t, release.FetchMeshes(meshc, ssmc))
+
+	service := release.Services["web"]
+	assert.Equal(t, "web-blue", *service.Mesh.ActiveNode)
+	assert.Equal(t, "web-green", *service.Mesh.NextNode)
+
+	// The new ASG registers as the node without traffic
+	tags := map[string]string{}
+	for _, tag := range service.createInput().Tags {
+		tags[*tag.Key] = *tag.Value
+	}
+	assert.Equal(t, "web-green", tags["VirtualNode"])
+
+	now := time.Now()
+	dial := func(after time.Duration) {
+		service.Healthy = true // Each health check finds the instances healthy
+		assert.NoError(t, release.DialMeshes(meshc, ssmc, cwc, now.Add(after)))
+	}
+
+	// The traffic is dialed one weight at a time
+	dial(0)
+	assert.Equal(t, map[string]int64{"web-blue": 50, "web-green": 50}, routeWeights(meshc))
+	assert.False(t, *release.Healthy)
+
+	dial(30 * time.Second)
+	assert.Equal(t, map[string]int64{"web-blue": 50, "web-green": 50}, routeWeights(meshc))
+
+	// The bake starts over until the proxies converge
+	meshc.Routes["mesh/router/route"].Status.Status = to.Strp("UPDATING")
+	dial(90 * time.Second)
+	assert.Equal(t, int64(50), routeWeights(meshc)["web-green"])
+
+	meshc.Routes["mesh/router/route"].Status.Status = to.Strp("ACTIVE")
+	dial(120 * time.Second)
+	assert.Equal(t, int64(50), routeWeights(meshc)["web-green"])
+
+	dial(150 * time.Second)
+	assert.Equal(t, map[string]int64{"web-blue": 0, "web-green": 100}, routeWeights(meshc))
+	assert.False(t, *release.Healthy)
+	assert.Equal(t, "/", *meshc.Routes["mesh/router/route"].Spec.HttpRoute.Match.Prefix)
+
+	// Only healthy once the last weight has baked
+	dial(210 * time.Second)
+	assert.True(t, *release.Healthy)
+
+	// A failed deploy sends the traffic back
+	assert.NoError(t, release.RollbackMeshes(meshc, ssmc))
+	assert.Equal(t, map[string]int64{"web-blue": 100, "web-green": 0}, routeWeights(meshc))
+}
+
+func Test_Release_DialMeshes_Parameter(t *testing.T) {
+	release := mockMeshRelease(t, &MeshConfig{
+		Parameter:    to.Strp("/mesh/web"),
+		VirtualNodes: []*string{to.Strp("web-blue"), to.Strp("web-green")},
+		Shift:        &ShiftConfig{Weights: []int64{100}, Bake: to.Intp(0)},
+	})
+
+	meshc := &mocks.MeshClient{}
+	ssmc := &mocks.SSMClient{}
+	cwc := &mocks.CWClient{}
+	service := release.Services["web"]
+
+	// The first deploy has no parameter so goes to the first node
+	assert.NoError(t, release.FetchMeshes(meshc, ssmc))
+	assert.Nil(t, service.Mesh.ActiveNode)
+	assert.Equal(t, "web-blue", *service.Mesh.NextNode)
+
+	service.Healthy = true
+	assert.NoError(t, release.DialMeshes(meshc, ssmc, cwc, time.Now()))
+	assert.Equal(t, `{"web-blue":100}`, ssmc.Parameters["/mesh/web"])
+	assert.True(t, *release.Healthy)
+
+	// The next deploy goes to the other node
+	service.Mesh.Shift = &ShiftConfig{Weights: []int64{100}, Bake: to.Intp(0)}
+	assert.NoError(t, release.FetchMeshes(meshc, ssmc))
+	assert.NoError(t, release.DialMeshes(meshc, ssmc, cwc, time.Now()))
+	assert.Equal(t, `{"web-blue":0,"web-green":100}`, ssmc.Parameters["/mesh/web"])
+
+	// Weights to a node that is not configured are refused
+	ssmc.AddParameter("/mesh/web", `{"web-red":100}`)
+	assert.Error(t, release.FetchMeshes(meshc, ssmc))
+}
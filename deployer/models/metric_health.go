@@ -0,0 +1,324 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/alarms"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Placeholders in metric dimensions replaced with the services resources
+const (
+	newASGPlaceholder         = "{{NEW_ASG}}"
+	oldASGPlaceholder         = "{{OLD_ASG}}"
+	newTargetGroupPlaceholder = "{{NEW_TARGET_GROUP}}"
+	oldTargetGroupPlaceholder = "{{OLD_TARGET_GROUP}}"
+)
+
+var metricIDRegex = regexp.MustCompile(`^[a-z][a-zA-Z0-9_]*$`)
+
+// MetricHealthConfig checks CloudWatch metric math expressions once the service is healthy,
+// e.g. the 5xx rate of the new target group against the old, and halts the deploy if any breaches its threshold
+// The service is only healthy once the checks have passed for the bake period
+type MetricHealthConfig struct {
+	Bake   *int                 `json:"bake,omitempty"` // Seconds the checks must pass before the service is healthy
+	Checks []*MetricCheckConfig `json:"checks,omitempty"`
+
+	// Generated
+	HealthyAt *time.Time `json:"healthy_at,omitempty"`
+}
+
+// MetricCheckConfig is a metric math expression over metrics, breached if its latest complete value crosses the threshold
+type MetricCheckConfig struct {
+	Name        *string                 `json:"name,omitempty"`
+	Expression  *string                 `json:"expression,omitempty"`
+	Metrics     map[string]*MetricQuery `json:"metrics,omitempty"` // Metrics used in the expression by ID
+	Threshold   *float64                `json:"threshold,omitempty"`
+	Comparison  *string                 `json:"comparison,omitempty"`   // greater_than or less_than, the threshold breaches
+	Period      *int64                  `json:"period,omitempty"`       // Seconds of each datapoint
+	MissingData *string                 `json:"missing_data,omitempty"` // breaching or not_breaching, whether a check without data breaches
+
+	// Generated
+	Value *float64 `json:"value,omitempty"` // The latest value of the expression
+}
+
+// MetricQuery is a CloudWatch metric and statistic, dimensions can use {{NEW_ASG}}, {{OLD_ASG}},
+// {{NEW_TARGET_GROUP}} and {{OLD_TARGET_GROUP}}
+type MetricQuery struct {
+	Namespace  *string            `json:"namespace,omitempty"`
+	MetricName *string            `json:"metric_name,omitempty"`
+	Dimensions map[string]*string `json:"dimensions,omitempty"`
+	Stat       *string            `json:"stat,omitempty"` // e.g. Sum, Average or p99
+}
+
+// SetDefaults assigns default values
+func (m *MetricHealthConfig) SetDefaults() {
+	if m.Bake == nil {
+		m.Bake = to.Intp(300)
+	}
+
+	for _, check := range m.Checks {
+		if check == nil {
+			continue
+		}
+
+		if check.Comparison == nil {
+			check.Comparison = to.Strp("greater_than")
+		}
+
+		if check.Period == nil {
+			check.Period = to.Int64p(60)
+		}
+
+		if check.MissingData == nil {
+			check.MissingData = to.Strp("breaching")
+		}
+	}
+}
+
+// ValidateAttributes validates attributes
+func (m *MetricHealthConfig) ValidateAttributes(service *Service) error {
+	if m.Bake == nil || *m.Bake < 0 {
+		return fmt.Errorf("MetricHealth bake must be 0 or greater")
+	}
+
+	if len(m.Checks) == 0 {
+		return fmt.Errorf("MetricHealth requires checks")
+	}
+
+	for _, check := range m.Checks {
+		if err := check.ValidateAttributes(service); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ValidateAttributes validates attributes
+func (c *MetricCheckConfig) ValidateAttributes(service *Service) error {
+	if c == nil || c.Name == nil || *c.Name == "" {
+		return fmt.Errorf("MetricHealth checks require a name")
+	}
+
+	if c.Expression == nil || *c.Expression == "" || c.Threshold == nil {
+		return fmt.Errorf("MetricHealth check %v requires an expression and threshold", *c.Name)
+	}
+
+	if c.Comparison == nil || (*c.Comparison != "greater_than" && *c.Comparison != "less_than") {
+		return fmt.Errorf("MetricHealth check %v comparison must be greater_than or less_than", *c.Name)
+	}
+
+	if c.Period == nil || *c.Period < 60 || *c.Period%60 != 0 {
+		return fmt.Errorf("MetricHealth check %v period must be a multiple of 60", *c.Name)
+	}
+
+	if c.MissingData == nil || (*c.MissingData != "breaching" && *c.MissingData != "not_breaching") {
+		return fmt.Errorf("MetricHealth check %v missing_data must be breaching or not_breaching", *c.Name)
+	}
+
+	if len(c.Metrics) == 0 {
+		return fmt.Errorf("MetricHealth check %v requires metrics", *c.Name)
+	}
+
+	for id, metric := range c.Metrics {
+		if !metricIDRegex.MatchString(id) || id == alarms.MetricMathID {
+			return fmt.Errorf("MetricHealth check %v metric ID %q must start lower case and not be %q", *c.Name, id, alarms.MetricMathID)
+		}
+
+		if metric == nil || metric.Namespace == nil || metric.MetricName == nil || metric.Stat == nil {
+			return fmt.Errorf("MetricHealth check %v metric %v requires namespace, metric_name and stat", *c.Name, id)
+		}
+
+		for _, value := range metric.Dimensions {
+			if value == nil {
+				return fmt.Errorf("MetricHealth check %v metric %v dimensions must have values", *c.Name, id)
+			}
+
+			targetGroups := strings.Contains(*value, newTargetGroupPlaceholder) || strings.Contains(*value, oldTargetGroupPlaceholder)
+			if targetGroups && service.ListenerRule == nil {
+				return fmt.Errorf("MetricHealth check %v target group placeholders require a listener_rule", *c.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *MetricHealthConfig) baked(now time.Time) bool {
+	return m.HealthyAt != nil && now.Sub(*m.HealthyAt) >= time.Duration(*m.Bake)*time.Second
+}
+
+// breached returns whether the value crosses the threshold
+func (c *MetricCheckConfig) breached(value float64) bool {
+	if *c.Comparison == "less_than" {
+		return value < *c.Threshold
+	}
+	return value > *c.Threshold
+}
+
+// periodEnd returns the end of the last complete period before now, CloudWatch aligns periods to the epoch
+func (c *MetricCheckConfig) periodEnd(now time.Time) time.Time {
+	return time.Unix(now.Unix()-now.Unix()%*c.Period, 0)
+}
+
+// targetGroupDimension returns the CloudWatch TargetGroup dimension of a target group ARN
+func targetGroupDimension(arn *string) *string {
+	if arn == nil {
+		return nil
+	}
+
+	parts := strings.SplitN(*arn, ":", 6)
+	return to.Strp(parts[len(parts)-1])
+}
+
+// metricPlaceholders returns the values of the placeholders the service can replace
+func (service *Service) metricPlaceholders() map[string]*string {
+	placeholders := map[string]*string{
+		newASGPlaceholder: service.CreatedASG,
+		oldASGPlaceholder: service.release.PreviousASGs[to.Strs(service.ServiceName)],
+	}
+
+	if l := service.ListenerRule; l != nil {
+		placeholders[newTargetGroupPlaceholder] = targetGroupDimension(l.NextTargetGroupArn)
+		placeholders[oldTargetGroupPlaceholder] = targetGroupDimension(l.ActiveTargetGroupArn)
+	}
+
+	return placeholders
+}
+
+// comparesOld returns whether the check uses an old resource the service does not have,
+// e.g. {{OLD_ASG}} on the first deploy of the project config, so there is nothing to compare with
+func (service *Service) comparesOld(check *MetricCheckConfig) bool {
+	placeholders := service.metricPlaceholders()
+
+	for _, metric := range check.Metrics {
+		for _, value := range metric.Dimensions {
+			for _, placeholder := range []string{oldASGPlaceholder, oldTargetGroupPlaceholder} {
+				if strings.Contains(*value, placeholder) && placeholders[placeholder] == nil {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// metricQueries returns the checks metrics with their dimensions placeholders replaced
+func (service *Service) metricQueries(check *MetricCheckConfig) ([]*cloudwatch.MetricDataQuery, error) {
+	placeholders := service.metricPlaceholders()
+
+	queries := []*cloudwatch.MetricDataQuery{}
+	for id, metric := range check.Metrics {
+		dimensions := []*cloudwatch.Dimension{}
+		for name, value := range metric.Dimensions {
+			replaced := *value
+			for placeholder, resource := range placeholders {
+				if !strings.Contains(replaced, placeholder) {
+					continue
+				}
+
+				if resource == nil {
+					return nil, fmt.Errorf("%v MetricHealth check %v cannot replace %v", service.errorPrefix(), *check.Name, placeholder)
+				}
+
+				replaced = strings.Replace(replaced, placeholder, *resource, -1)
+			}
+
+			dimensions = append(dimensions, &cloudwatch.Dimension{Name: to.Strp(name), Value: to.Strp(replaced)})
+		}
+
+		queries = append(queries, &cloudwatch.MetricDataQuery{
+			Id: to.Strp(id),
+			MetricStat: &cloudwatch.MetricStat{
+				Metric: &cloudwatch.Metric{
+					Namespace:  metric.Namespace,
+					MetricName: metric.MetricName,
+					Dimensions: dimensions,
+				},
+				Period: check.Period,
+				Stat:   metric.Stat,
+			},
+			ReturnData: to.Boolp(false),
+		})
+	}
+
+	return queries, nil
+}
+
+// checkMetrics evaluates the checks of a healthy service, halting if any is breached
+// The service is only healthy once the checks have passed for the bake period
+// Checks without data breach unless their missing_data is not_breaching, checks against old resources the service does not have are skipped
+func (service *Service) checkMetrics(cwc aws.CWAPI, now time.Time) error {
+	health := service.MetricHealth
+
+	if !service.Healthy {
+		// The bake restarts once the service is healthy again
+		health.HealthyAt = nil
+		return nil
+	}
+
+	if health.HealthyAt == nil {
+		health.HealthyAt = &now
+	}
+
+	for _, check := range health.Checks {
+		if service.comparesOld(check) {
+			check.Value = nil
+			continue
+		}
+
+		queries, err := service.metricQueries(check)
+		if err != nil {
+			return err // This might retry
+		}
+
+		// The period in progress is partial, so the latest of the last few complete periods is read, allowing for late datapoints
+		end := check.periodEnd(now)
+		start := end.Add(-5 * time.Duration(*check.Period) * time.Second)
+
+		value, err := alarms.MetricMath(cwc, check.Expression, queries, start, end)
+		if err != nil {
+			return err // This might retry
+		}
+
+		check.Value = value
+
+		if value == nil && *check.MissingData == "breaching" {
+			err := fmt.Errorf("%v MetricHealth check %v has no data", service.errorPrefix(), *check.Name)
+			return &HaltError{err} // This will immediately stop deploying
+		}
+
+		if value != nil && check.breached(*value) {
+			err := fmt.Errorf("%v MetricHealth check %v breached with %v %v %v", service.errorPrefix(), *check.Name, *value, strings.Replace(*check.Comparison, "_", " ", -1), *check.Threshold)
+			return &HaltError{err} // This will immediately stop deploying
+		}
+	}
+
+	service.Healthy = health.baked(now)
+	return nil
+}
+
+// CheckMetrics evaluates the metric health checks of every healthy service
+func (release *Release) CheckMetrics(cwc aws.CWAPI, now time.Time) error {
+	healthy := true
+
+	for _, service := range release.Services {
+		if service.MetricHealth != nil && !service.IsHalted() {
+			if err := service.checkMetrics(cwc, now); err != nil {
+				return err
+			}
+		}
+
+		healthy = healthy && service.Healthy
+	}
+
+	release.Healthy = &healthy
+	return nil
+}
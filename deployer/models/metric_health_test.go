@@ -0,0 +1,130 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockMetricHealth() *MetricHealthConfig {
+	m := &MetricHealthConfig{
+		Bake: to.Intp(60),
+		Checks: []*MetricCheckConfig{
+			&MetricCheckConfig{
+				Name:       to.Strp("cpu"),
+				Expression: to.Strp("cpu / 100"),
+				Threshold:  to.Float64p(0.8),
+				Metrics: map[string]*MetricQuery{
+					"cpu": &MetricQuery{
+						Namespace:  to.Strp("AWS/EC2"),
+						MetricName: to.Strp("CPUUtilization"),
+						Dimensions: map[string]*string{"AutoScalingGroupName": to.Strp("{{NEW_ASG}}")},
+						Stat:       to.Strp("Average"),
+					},
+				},
+			},
+		},
+	}
+	m.SetDefaults()
+	return m
+}
+
+func Test_MetricHealthConfig_ValidateAttributes(t *testing.T) {
+	service := &Service{}
+
+	m := mockMetricHealth()
+	assert.NoError(t, m.ValidateAttributes(service))
+
+	m.Checks[0].Comparison = to.Strp("equal")
+	assert.Error(t, m.ValidateAttributes(service))
+
+	m = mockMetricHealth()
+	m.Checks[0].Metrics["result"] = m.Checks[0].Metrics["cpu"]
+	assert.Error(t, m.ValidateAttributes(service))
+
+	m = mockMetricHealth()
+	m.Checks[0].Period = to.Int64p(30)
+	assert.Error(t, m.ValidateAttributes(service))
+
+	// Target groups are only known for listener rules
+	m = mockMetricHealth()
+	m.Checks[0].Metrics["cpu"].Dimensions["TargetGroup"] = to.Strp("{{NEW_TARGET_GROUP}}")
+	assert.Error(t, m.ValidateAttributes(service))
+}
+
+func Test_Release_CheckMetrics(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	service := release.Services["web"]
+	service.MetricHealth = mockMetricHealth()
+	service.CreatedASG = to.Strp("new-web")
+	service.Healthy = true
+
+	cwc := &mocks.CWClient{MetricMathValues: map[string]float64{"cpu / 100": 0.5}}
+
+	// Healthy once the checks pass for the bake period
+	now := time.Now()
+	assert.NoError(t, release.CheckMetrics(cwc, now))
+	assert.False(t, *release.Healthy)
+	assert.Equal(t, 0.5, *service.MetricHealth.Checks[0].Value)
+
+	service.Healthy = true
+	assert.NoError(t, release.CheckMetrics(cwc, now.Add(time.Minute)))
+	assert.True(t, *release.Healthy)
+
+	// A breach halts the deploy
+	cwc.MetricMathValues["cpu / 100"] = 0.9
+	err := release.CheckMetrics(cwc, now.Add(2*time.Minute))
+	assert.IsType(t, &HaltError{}, err)
+
+	// The old ASG is only known for linked releases, so the first deploy skips comparing with it
+	service.MetricHealth.Checks[0].Metrics["cpu"].Dimensions["AutoScalingGroupName"] = to.Strp("{{OLD_ASG}}")
+	service.Healthy = true
+	assert.NoError(t, release.CheckMetrics(cwc, now.Add(2*time.Minute)))
+	assert.True(t, *release.Healthy)
+	assert.Nil(t, service.MetricHealth.Checks[0].Value)
+
+	// The new ASG is always known
+	service.CreatedASG = nil
+	service.MetricHealth.Checks[0].Metrics["cpu"].Dimensions["AutoScalingGroupName"] = to.Strp("{{NEW_ASG}}")
+	err = release.CheckMetrics(cwc, now.Add(2*time.Minute))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "{{NEW_ASG}}")
+}
+
+func Test_Release_CheckMetrics_MissingData(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	service := release.Services["web"]
+	service.MetricHealth = mockMetricHealth()
+	service.CreatedASG = to.Strp("new-web")
+	service.Healthy = true
+
+	cwc := &mocks.CWClient{MetricMathValues: map[string]float64{}}
+
+	// No data breaches by default
+	err := release.CheckMetrics(cwc, time.Now())
+	assert.IsType(t, &HaltError{}, err)
+	assert.Contains(t, err.Error(), "no data")
+
+	service.MetricHealth.Checks[0].MissingData = to.Strp("not_breaching")
+	assert.NoError(t, release.CheckMetrics(cwc, time.Now()))
+
+	service.MetricHealth.Checks[0].MissingData = to.Strp("ignore")
+	assert.Error(t, service.MetricHealth.ValidateAttributes(service))
+}
+
+func Test_MetricCheckConfig_PeriodEnd(t *testing.T) {
+	check := mockMetricHealth().Checks[0]
+	check.Period = to.Int64p(300)
+
+	// The period in progress is never read
+	now := time.Unix(1000*300+299, 0)
+	assert.Equal(t, time.Unix(1000*300, 0), check.periodEnd(now))
+	assert.Equal(t, time.Unix(1000*300, 0), check.periodEnd(time.Unix(1000*300, 0)))
+}
@@ -0,0 +1,168 @@
+package models
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// MetricsNamespace is the CloudWatch namespace of deploy metrics
+const MetricsNamespace = "Odin"
+
+// Metric is a deploy metric for the release, or one of its services if Service is set
+type Metric struct {
+	Name    string
+	Unit    string // CloudWatch unit, Seconds or Count
+	Value   float64
+	Service *string
+}
+
+// Metrics returns the metrics for the deploy transition
+func (release *Release) Metrics(event string, now time.Time) []*Metric {
+	metrics := []*Metric{}
+
+	switch event {
+	case NotifyHealthy:
+		if release.CreatedAt != nil {
+			metrics = append(metrics, &Metric{Name: "DeployDuration", Unit: "Seconds", Value: now.Sub(*release.CreatedAt).Seconds()})
+		}
+
+		if release.DeployStartedAt != nil {
+			metrics = append(metrics, &Metric{Name: "TimeToHealthy", Unit: "Seconds", Value: now.Sub(*release.DeployStartedAt).Seconds()})
+		}
+
+		for name, service := range release.Services {
+			if service == nil || service.HealthReport == nil || service.HealthReport.Launching == nil {
+				continue
+			}
+
+			metrics = append(metrics, &Metric{Name: "InstancesLaunched", Unit: "Count", Value: float64(*service.HealthReport.Launching), Service: to.Strp(name)})
+		}
+	case NotifyRolledBack:
+		metrics = append(metrics, &Metric{Name: "Rollbacks", Unit: "Count", Value: 1})
+	case NotifyHalted:
+		metrics = append(metrics, &Metric{Name: "Halts", Unit: "Count", Value: 1})
+	}
+
+	return metrics
+}
+
+// PutMetrics sends the metrics for the transition to CloudWatch, and to statsd if its address is set
+func (release *Release) PutMetrics(cwc aws.CWAPI, statsdAddr string, event string) error {
+	metrics := release.Metrics(event, time.Now())
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	data := []*cloudwatch.MetricDatum{}
+	for _, m := range metrics {
+		data = append(data, &cloudwatch.MetricDatum{
+			MetricName: to.Strp(m.Name),
+			Unit:       to.Strp(m.Unit),
+			Value:      &m.Value,
+			Dimensions: release.metricDimensions(m.Service),
+		})
+	}
+
+	_, err := cwc.PutMetricData(&cloudwatch.PutMetricDataInput{
+		Namespace:  to.Strp(MetricsNamespace),
+		MetricData: data,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if statsdAddr == "" {
+		return nil
+	}
+
+	return sendStatsd(statsdAddr, release.statsdLines(metrics))
+}
+
+// PutCanaryMetric records whether a canary deploy of the release failed, to alarm on when the deployer is broken
+func (release *Release) PutCanaryMetric(cwc aws.CWAPI, failed bool) error {
+	value := 0.0
+	if failed {
+		value = 1.0
+	}
+
+	_, err := cwc.PutMetricData(&cloudwatch.PutMetricDataInput{
+		Namespace: to.Strp(MetricsNamespace),
+		MetricData: []*cloudwatch.MetricDatum{
+			&cloudwatch.MetricDatum{
+				MetricName: to.Strp("CanaryFailed"),
+				Unit:       to.Strp("Count"),
+				Value:      &value,
+				Dimensions: release.metricDimensions(nil),
+			},
+		},
+	})
+
+	return err
+}
+
+func (release *Release) metricDimensions(service *string) []*cloudwatch.Dimension {
+	dims := []*cloudwatch.Dimension{
+		&cloudwatch.Dimension{Name: to.Strp("ProjectName"), Value: release.ProjectName},
+		&cloudwatch.Dimension{Name: to.Strp("ConfigName"), Value: release.ConfigName},
+	}
+
+	if service != nil {
+		dims = append(dims, &cloudwatch.Dimension{Name: to.Strp("ServiceName"), Value: service})
+	}
+
+	return dims
+}
+
+// statsdLines formats the metrics as DogStatsD lines, durations are timers in ms and counts are counters
+func (release *Release) statsdLines(metrics []*Metric) []string {
+	lines := []string{}
+	for _, m := range metrics {
+		tags := []string{
+			fmt.Sprintf("project:%v", to.Strs(release.ProjectName)),
+			fmt.Sprintf("config:%v", to.Strs(release.ConfigName)),
+		}
+
+		if m.Service != nil {
+			tags = append(tags, fmt.Sprintf("service:%v", *m.Service))
+		}
+
+		value, kind := m.Value, "c"
+		if m.Unit == "Seconds" {
+			value, kind = m.Value*1000, "ms"
+		}
+
+		lines = append(lines, fmt.Sprintf("odin.%v:%v|%v|#%v", statsdName(m.Name), value, kind, strings.Join(tags, ",")))
+	}
+
+	return lines
+}
+
+// statsdName converts a CloudWatch metric name like TimeToHealthy to time_to_healthy
+func statsdName(name string) string {
+	out := ""
+	for i, r := range name {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			out += "_"
+		}
+		out += strings.ToLower(string(r))
+	}
+	return out
+}
+
+func sendStatsd(addr string, lines []string) error {
+	conn, err := net.DialTimeout("udp", addr, 2*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(strings.Join(lines, "\n")))
+	return err
+}
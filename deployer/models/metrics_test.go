@@ -0,0 +1,58 @@
+package models
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_Metrics(t *testing.T) {
+	release := MockRelease(t)
+	now := time.Now()
+	release.CreatedAt = to.Timep(now.Add(-10 * time.Minute))
+	release.DeployStartedAt = to.Timep(now.Add(-5 * time.Minute))
+	release.Services["web"].HealthReport = &HealthReport{Launching: to.Intp(3)}
+
+	metrics := map[string]*Metric{}
+	for _, m := range release.Metrics(NotifyHealthy, now) {
+		metrics[m.Name] = m
+	}
+
+	assert.Equal(t, 600.0, metrics["DeployDuration"].Value)
+	assert.Equal(t, 300.0, metrics["TimeToHealthy"].Value)
+	assert.Equal(t, 3.0, metrics["InstancesLaunched"].Value)
+	assert.Equal(t, "web", *metrics["InstancesLaunched"].Service)
+
+	assert.Equal(t, "Rollbacks", release.Metrics(NotifyRolledBack, now)[0].Name)
+	assert.Equal(t, "Halts", release.Metrics(NotifyHalted, now)[0].Name)
+	assert.Equal(t, 0, len(release.Metrics(NotifyStarted, now)))
+}
+
+func Test_Release_PutMetrics(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	release := MockRelease(t)
+	awsc := mocks.MockAWS()
+
+	assert.NoError(t, release.PutMetrics(awsc.CW, listener.LocalAddr().String(), NotifyRolledBack))
+	assert.Equal(t, 1, len(awsc.CW.MetricData))
+	assert.Equal(t, "Rollbacks", *awsc.CW.MetricData[0].MetricName)
+
+	buf := make([]byte, 1024)
+	listener.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := listener.ReadFrom(buf)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(buf[:n]), "odin.rollbacks:1|c|#project:"))
+}
+
+func Test_StatsdName(t *testing.T) {
+	assert.Equal(t, "time_to_healthy", statsdName("TimeToHealthy"))
+	assert.Equal(t, "halts", statsdName("Halts"))
+}
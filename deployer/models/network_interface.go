@@ -0,0 +1,104 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/lt"
+)
+
+// NetworkInterfaceConfig sets options on the instances primary network interface
+// Services with a network interface are launched from a launch template instead of a launch configuration
+type NetworkInterfaceConfig struct {
+	// AssociatePublicIpAddress overrides the services associate_public_ip_address
+	AssociatePublicIpAddress *bool `json:"associate_public_ip_address,omitempty"`
+
+	// Ipv6AddressCount IPv6 addresses are assigned from the subnets IPv6 CIDR for dual-stack networking
+	Ipv6AddressCount *int64 `json:"ipv6_address_count,omitempty"`
+
+	// SecondaryPrivateIpAddressCount extra private IPs are assigned, e.g. for containers that need their own IP
+	SecondaryPrivateIpAddressCount *int64 `json:"secondary_private_ip_address_count,omitempty"`
+}
+
+// Validate validates the network interface
+func (ni *NetworkInterfaceConfig) Validate() error {
+	if ni.Ipv6AddressCount != nil && *ni.Ipv6AddressCount < 0 {
+		return fmt.Errorf("NetworkInterface ipv6_address_count must not be negative")
+	}
+
+	if ni.SecondaryPrivateIpAddressCount != nil && *ni.SecondaryPrivateIpAddressCount < 0 {
+		return fmt.Errorf("NetworkInterface secondary_private_ip_address_count must not be negative")
+	}
+
+	return nil
+}
+
+func (service *Service) validateNetworkInterface() error {
+	if service.NetworkInterface == nil {
+		return nil
+	}
+
+	return service.NetworkInterface.Validate()
+}
+
+func (service *Service) validateLaunchTemplate() error {
+	// Falling back creates a new launch configuration
+	if service.usesLaunchTemplate() && len(service.InstanceTypeFallbacks) > 0 {
+		return fmt.Errorf("Launch templates cannot be used with instance_type_fallbacks")
+	}
+
+	if service.Placement != nil && service.Placement.HostTenancy() && !service.usesLaunchTemplate() {
+		return fmt.Errorf("Placement host tenancy requires a launch template, set a network_interface, imdsv2 or enable the launch_templates feature")
+	}
+
+	return nil
+}
+
+// usesLaunchTemplate returns whether the service needs a launch template for options launch configurations lack,
+// or the release enabled launching from launch templates
+func (service *Service) usesLaunchTemplate() bool {
+	if service.release != nil && service.release.FeatureEnabled(FeatureLaunchTemplates) {
+		return true
+	}
+	return service.NetworkInterface != nil || service.requiresIMDSv2()
+}
+
+// requiresIMDSv2 returns whether instances must use IMDSv2, which only launch templates can require
+func (service *Service) requiresIMDSv2() bool {
+	return service.IMDSv2 != nil && *service.IMDSv2
+}
+
+// associatePublicIP returns whether instances get a public IP, the network interface overrides the service
+func (service *Service) associatePublicIP() *bool {
+	if service.NetworkInterface != nil && service.NetworkInterface.AssociatePublicIpAddress != nil {
+		return service.NetworkInterface.AssociatePublicIpAddress
+	}
+	return service.AssociatePublicIpAddress
+}
+
+func (service *Service) createLaunchTemplateInput() *lt.Input {
+	input := service.createLaunchConfigurationInput()
+
+	ni := &lt.NetworkInterface{AssociatePublicIpAddress: service.associatePublicIP()}
+	if service.NetworkInterface != nil {
+		ni.Ipv6AddressCount = service.NetworkInterface.Ipv6AddressCount
+		ni.SecondaryPrivateIpAddressCount = service.NetworkInterface.SecondaryPrivateIpAddressCount
+	}
+
+	template := lt.FromLaunchConfig(input.CreateLaunchConfigurationInput, ni)
+	template.AddTags(service.resourceTags())
+
+	if service.requiresIMDSv2() {
+		template.RequireIMDSv2()
+	}
+
+	if service.Placement != nil && service.Placement.HostResourceGroupArn != nil {
+		template.UseHostResourceGroup(service.Placement.HostResourceGroupArn)
+	}
+
+	return template
+}
+
+func (service *Service) createLaunchTemplate(ec2c aws.EC2API) error {
+	return service.createLaunchTemplateInput().Create(ec2c)
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Service_NetworkInterface_Validate(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	service := release.Services["web"]
+
+	service.NetworkInterface = &NetworkInterfaceConfig{Ipv6AddressCount: to.Int64p(1)}
+	assert.NoError(t, service.validateNetworkInterface())
+
+	service.NetworkInterface.SecondaryPrivateIpAddressCount = to.Int64p(-1)
+	assert.Error(t, service.validateNetworkInterface())
+
+	service.NetworkInterface.SecondaryPrivateIpAddressCount = to.Int64p(2)
+	service.InstanceTypeFallbacks = []*string{to.Strp("m5.large")}
+	assert.Error(t, service.validateLaunchTemplate())
+}
+
+func Test_Service_NetworkInterface_LaunchTemplate(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	service := release.Services["web"]
+
+	service.NetworkInterface = &NetworkInterfaceConfig{
+		AssociatePublicIpAddress:       to.Boolp(true),
+		Ipv6AddressCount:               to.Int64p(1),
+		SecondaryPrivateIpAddressCount: to.Int64p(2),
+	}
+
+	input := service.createInput()
+	assert.Nil(t, input.LaunchConfigurationName)
+	assert.Equal(t, *service.ServiceID(), *input.LaunchTemplate.LaunchTemplateName)
+
+	ni := service.createLaunchTemplateInput().LaunchTemplateData.NetworkInterfaces[0]
+	assert.True(t, *ni.AssociatePublicIpAddress) // Overrides the service
+	assert.Equal(t, int64(1), *ni.Ipv6AddressCount)
+	assert.Equal(t, int64(2), *ni.SecondaryPrivateIpAddressCount)
+
+	awsc := MockAwsClients(release)
+	assert.NoError(t, service.CreateResources(awsc.ASG, awsc.CW, awsc.EC2))
+	assert.NotNil(t, awsc.EC2.LaunchTemplates[*service.ServiceID()])
+}
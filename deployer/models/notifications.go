@@ -0,0 +1,122 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/secret"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Deploy transitions that send notifications
+const (
+	NotifyStarted           = "started"
+	NotifyHealthy           = "healthy"
+	NotifyFailed            = "failed"
+	NotifyRolledBack        = "rolled_back"
+	NotifyHalted            = "halted"
+	NotifyApprovalRequested = "approval_requested"
+)
+
+var notificationClient = &http.Client{Timeout: 5 * time.Second}
+
+// Notification is the JSON posted to generic HTTP endpoints
+type Notification struct {
+	Event        string  `json:"event"`
+	ProjectName  *string `json:"project_name,omitempty"`
+	ConfigName   *string `json:"config_name,omitempty"`
+	ReleaseID    *string `json:"release_id,omitempty"`
+	AwsAccountID *string `json:"aws_account_id,omitempty"`
+	AwsRegion    *string `json:"aws_region,omitempty"`
+	Error        *string `json:"error,omitempty"`
+	Cause        *string `json:"cause,omitempty"`
+}
+
+// ValidateNotifications returns an error if a notification does not reference an odin secret
+func (release *Release) ValidateNotifications() error {
+	for _, ref := range release.Notifications {
+		if err := secret.ValidateReference(ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Notify posts the event to the webhooks referenced by the release and the deployer
+// Every webhook is attempted, and the first error is returned
+func (release *Release) Notify(ssmc aws.SSMAPI, smc aws.SMAPI, event string, deployerRefs []*string) error {
+	var firstErr error
+	for _, ref := range append(deployerRefs, release.Notifications...) {
+		if err := release.notify(ssmc, smc, event, ref); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (release *Release) notify(ssmc aws.SSMAPI, smc aws.SMAPI, event string, ref *string) error {
+	webhook, err := secret.Get(ssmc, smc, ref)
+	if err != nil {
+		return err
+	}
+
+	u, err := url.Parse(*webhook)
+	if err != nil || u.Scheme != "https" {
+		// Do not include the URL in the error as it is a secret
+		return fmt.Errorf("Notification %v must be an https URL", *ref)
+	}
+
+	var body interface{} = release.notification(event)
+	if u.Host == "hooks.slack.com" {
+		body = map[string]string{"text": release.notificationText(event)}
+	}
+
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := notificationClient.Post(u.String(), "application/json", bytes.NewReader(raw))
+	if err != nil {
+		return fmt.Errorf("Notification %v failed", *ref)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Notification %v returned %v", *ref, resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (release *Release) notification(event string) *Notification {
+	n := &Notification{
+		Event:        event,
+		ProjectName:  release.ProjectName,
+		ConfigName:   release.ConfigName,
+		ReleaseID:    release.ReleaseID,
+		AwsAccountID: release.AwsAccountID,
+		AwsRegion:    release.AwsRegion,
+	}
+
+	if release.Error != nil {
+		n.Error = release.Error.Error
+		n.Cause = release.Error.Cause
+	}
+
+	return n
+}
+
+func (release *Release) notificationText(event string) string {
+	text := fmt.Sprintf("odin %v/%v %v %v", to.Strs(release.ProjectName), to.Strs(release.ConfigName), to.Strs(release.ReleaseID), strings.Replace(event, "_", " ", -1))
+	if release.Error != nil {
+		text = fmt.Sprintf("%v: %v %v", text, to.Strs(release.Error.Error), to.Strs(release.Error.Cause))
+	}
+	return text
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_ValidateNotifications(t *testing.T) {
+	release := MockRelease(t)
+	release.Notifications = []*string{to.Strp("ssm:/odin/slack")}
+	assert.NoError(t, release.ValidateNotifications())
+
+	release.Notifications = []*string{to.Strp("ssm:/prod/db-password")}
+	assert.Error(t, release.ValidateNotifications())
+}
+
+func Test_Release_Notify(t *testing.T) {
+	var received []*Notification
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := ioutil.ReadAll(r.Body)
+		var n Notification
+		assert.NoError(t, json.Unmarshal(raw, &n))
+		received = append(received, &n)
+	}))
+	defer server.Close()
+
+	defaultClient := notificationClient
+	notificationClient = server.Client()
+	defer func() { notificationClient = defaultClient }()
+
+	awsc := mocks.MockAWS()
+	awsc.SSM.AddParameter("/odin/hook", server.URL)
+	awsc.SM.AddSecret("odin/hook", server.URL)
+
+	release := MockRelease(t)
+	release.Notifications = []*string{to.Strp("secretsmanager:odin/hook")}
+
+	err := release.Notify(awsc.SSM, awsc.SM, NotifyStarted, []*string{to.Strp("ssm:/odin/hook")})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(received))
+	assert.Equal(t, NotifyStarted, received[0].Event)
+	assert.Equal(t, *release.ProjectName, *received[0].ProjectName)
+
+	// A missing secret is an error but the other webhooks are still called
+	err = release.Notify(awsc.SSM, awsc.SM, NotifyHealthy, []*string{to.Strp("ssm:/odin/missing")})
+	assert.Error(t, err)
+	assert.Equal(t, 3, len(received))
+}
+
+func Test_Release_Notify_RequiresHTTPS(t *testing.T) {
+	awsc := mocks.MockAWS()
+	awsc.SSM.AddParameter("/odin/hook", "http://example.com/hook")
+
+	release := MockRelease(t)
+	err := release.Notify(awsc.SSM, awsc.SM, NotifyStarted, []*string{to.Strp("ssm:/odin/hook")})
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "example.com")
+}
+
+func Test_Release_NotificationText(t *testing.T) {
+	release := MockRelease(t)
+	assert.Contains(t, release.notificationText(NotifyRolledBack), "rolled back")
+}
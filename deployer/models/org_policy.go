@@ -0,0 +1,216 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/ami"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Names of the org policy rules, reported with each violation
+const (
+	RuleAllowedInstanceTypes  = "allowed_instance_types"
+	RuleRequireIMDSv2         = "require_imdsv2"
+	RuleRequiredTags          = "required_tags"
+	RuleMaxDesiredCapacity    = "max_desired_capacity"
+	RuleBanOpenSecurityGroups = "ban_open_security_groups"
+	RuleMaxAMIAgeDays         = "max_ami_age_days"
+	RuleAMIOwners             = "ami_owners"
+	RuleRequiredAMITags       = "required_ami_tags"
+)
+
+// orgPolicyPath is the policy document at the root of the bucket, so it applies to every account
+var orgPolicyPath = "policy.json"
+
+// OrgPolicy are rules security and platform teams define for every release, outside of the releases themselves
+type OrgPolicy struct {
+	AllowedInstanceTypes  []string `json:"allowed_instance_types,omitempty"` // Patterns e.g. m5.*, empty allows all
+	RequireIMDSv2         bool     `json:"require_imdsv2,omitempty"`
+	RequiredTags          []string `json:"required_tags,omitempty"`        // Keys set on the release or each service
+	MaxDesiredCapacity    *int     `json:"max_desired_capacity,omitempty"` // The most a services max_size can be
+	BanOpenSecurityGroups bool     `json:"ban_open_security_groups,omitempty"`
+
+	// The releases AMI must be recent, owned by an allowed account, and have tags e.g. baked-by: imagepipeline
+	MaxAMIAgeDays   *int              `json:"max_ami_age_days,omitempty"`
+	AMIOwners       []string          `json:"ami_owners,omitempty"`
+	RequiredAMITags map[string]string `json:"required_ami_tags,omitempty"` // Tag values, an empty value only requires the key
+}
+
+// PolicyViolation is a service, or the releases AMI, breaking an org policy rule
+type PolicyViolation struct {
+	Rule    string
+	Service string // Empty for the releases AMI
+	Message string
+}
+
+// LoadOrgPolicy returns the org policy in the store, or nil if there is none
+func LoadOrgPolicy(store ArtifactStore) (*OrgPolicy, error) {
+	raw, err := store.Get(&orgPolicyPath)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var policy OrgPolicy
+	if err := json.Unmarshal(raw, &policy); err != nil {
+		return nil, fmt.Errorf("Org policy invalid %v", err.Error())
+	}
+
+	for _, pattern := range policy.AllowedInstanceTypes {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("Org policy invalid pattern %q %v", pattern, err.Error())
+		}
+	}
+
+	return &policy, nil
+}
+
+// ValidateOrgPolicy rejects the release if it violates the org policy in its bucket
+// The security group and AMI rules are only evaluated if the services resources are given
+func (release *Release) ValidateOrgPolicy(s3c aws.S3API, resources map[string]*ServiceResources) error {
+	policy, err := LoadOrgPolicy(release.Store(s3c))
+	if err != nil || policy == nil {
+		return err
+	}
+
+	violations := policy.Evaluate(release, resources)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	messages := []string{}
+	for _, v := range violations {
+		if v.Service == "" {
+			messages = append(messages, fmt.Sprintf("%v: %v", v.Rule, v.Message))
+		} else {
+			messages = append(messages, fmt.Sprintf("%v: %v %v", v.Rule, v.Service, v.Message))
+		}
+	}
+
+	return fmt.Errorf("Org policy violations: %v", strings.Join(messages, "; "))
+}
+
+// Evaluate returns every violation of the policy by the releases AMI and services, ordered by service
+func (policy *OrgPolicy) Evaluate(release *Release, resources map[string]*ServiceResources) []*PolicyViolation {
+	names := []string{}
+	for name := range release.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	violations := []*PolicyViolation{}
+
+	// Every service launches the same AMI
+	for _, name := range names {
+		if sr := resources[name]; sr != nil && sr.Image != nil {
+			violations = append(violations, policy.evaluateImage(sr.Image, time.Now())...)
+			break
+		}
+	}
+
+	for _, name := range names {
+		service := release.Services[name]
+		violation := func(rule string, format string, args ...interface{}) {
+			violations = append(violations, &PolicyViolation{Rule: rule, Service: name, Message: fmt.Sprintf(format, args...)})
+		}
+
+		// Fallbacks launch in place of the instance type, so must be allowed too
+		for _, instanceType := range append([]*string{service.InstanceType}, service.InstanceTypeFallbacks...) {
+			if instanceType != nil && !policy.allowsInstanceType(*instanceType) {
+				violation(RuleAllowedInstanceTypes, "instance type %v is not allowed", *instanceType)
+			}
+		}
+
+		if policy.RequireIMDSv2 && (service.IMDSv2 == nil || !*service.IMDSv2) {
+			violation(RuleRequireIMDSv2, "must set imdsv2")
+		}
+
+		tags := service.customTags()
+		for _, tag := range policy.RequiredTags {
+			if to.Strs(tags[tag]) == "" {
+				violation(RuleRequiredTags, "is missing tag %v", tag)
+			}
+		}
+
+		if max := policy.MaxDesiredCapacity; max != nil && service.Autoscaling != nil && service.Autoscaling.MaxSizeInt() > *max {
+			violation(RuleMaxDesiredCapacity, "max_size %v is over %v", service.Autoscaling.MaxSizeInt(), *max)
+		}
+
+		if sr := resources[name]; policy.BanOpenSecurityGroups && sr != nil {
+			for _, sg := range sr.SecurityGroups {
+				if sg.OpenIngress {
+					violation(RuleBanOpenSecurityGroups, "security group %v allows ingress from anywhere", to.Strs(sg.Name()))
+				}
+			}
+		}
+	}
+
+	return violations
+}
+
+// evaluateImage returns the violations of the AMI rules
+func (policy *OrgPolicy) evaluateImage(image *ami.Image, now time.Time) []*PolicyViolation {
+	violations := []*PolicyViolation{}
+	violation := func(rule string, format string, args ...interface{}) {
+		violations = append(violations, &PolicyViolation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	id := to.Strs(image.ImageID)
+
+	if max := policy.MaxAMIAgeDays; max != nil {
+		if image.CreatedAt == nil {
+			violation(RuleMaxAMIAgeDays, "image %v creation date unknown", id)
+		} else if age := int(now.Sub(*image.CreatedAt).Hours() / 24); age > *max {
+			violation(RuleMaxAMIAgeDays, "image %v is %v days old, over %v", id, age, *max)
+		}
+	}
+
+	if len(policy.AMIOwners) > 0 {
+		owned := false
+		for _, owner := range policy.AMIOwners {
+			owned = owned || owner == to.Strs(image.OwnerID)
+		}
+
+		if !owned {
+			violation(RuleAMIOwners, "image %v is owned by %v, which is not allowed", id, to.Strs(image.OwnerID))
+		}
+	}
+
+	keys := []string{}
+	for key := range policy.RequiredAMITags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value, ok := image.Tags[key]
+		if !ok || value == nil || (policy.RequiredAMITags[key] != "" && *value != policy.RequiredAMITags[key]) {
+			violation(RuleRequiredAMITags, "image %v requires tag %v: %v", id, key, policy.RequiredAMITags[key])
+		}
+	}
+
+	return violations
+}
+
+func (policy *OrgPolicy) allowsInstanceType(instanceType string) bool {
+	if len(policy.AllowedInstanceTypes) == 0 {
+		return true
+	}
+
+	for _, pattern := range policy.AllowedInstanceTypes {
+		if ok, _ := path.Match(pattern, instanceType); ok {
+			return true
+		}
+	}
+
+	return false
+}
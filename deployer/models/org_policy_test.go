@@ -0,0 +1,106 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coinbase/odin/aws/ami"
+	"github.com/coinbase/odin/aws/sg"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_ValidateOrgPolicy(t *testing.T) {
+	release := MockRelease(t)
+	awsc := MockAwsClients(release)
+	release.ReleaseSHA256 = to.SHA256Struct(release)
+	MockPrepareRelease(release)
+
+	// No policy document allows everything
+	assert.NoError(t, release.ValidateOrgPolicy(awsc.S3, nil))
+	assert.NoError(t, release.Validate(awsc.S3))
+
+	awsc.S3.AddGetObject("policy.json", `{
+		"allowed_instance_types": ["t2.*", "m5.*"],
+		"require_imdsv2": true,
+		"required_tags": ["custom", "team"],
+		"max_desired_capacity": 10,
+		"ban_open_security_groups": true
+	}`, nil)
+
+	err := release.ValidateOrgPolicy(awsc.S3, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "require_imdsv2: web must set imdsv2")
+	assert.Contains(t, err.Error(), "required_tags: web is missing tag team")
+	assert.NotContains(t, err.Error(), "allowed_instance_types")
+	assert.Error(t, release.Validate(awsc.S3))
+
+	web := release.Services["web"]
+	web.IMDSv2 = to.Boolp(true)
+	web.Tags["team"] = to.Strp("platform")
+	assert.NoError(t, release.ValidateOrgPolicy(awsc.S3, nil))
+
+	web.InstanceTypeFallbacks = []*string{to.Strp("c5.large")}
+	web.Autoscaling.MaxSize = to.Int64p(20)
+	err = release.ValidateOrgPolicy(awsc.S3, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "allowed_instance_types: web instance type c5.large is not allowed")
+	assert.Contains(t, err.Error(), "max_desired_capacity: web max_size 20 is over 10")
+}
+
+func Test_OrgPolicy_Evaluate_SecurityGroups(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	policy := &OrgPolicy{BanOpenSecurityGroups: true}
+	resources := map[string]*ServiceResources{
+		"web": &ServiceResources{SecurityGroups: []*sg.SecurityGroup{
+			&sg.SecurityGroup{NameTag: to.Strp("web-sg")},
+			&sg.SecurityGroup{NameTag: to.Strp("open-sg"), OpenIngress: true},
+		}},
+	}
+
+	assert.Equal(t, 0, len(policy.Evaluate(release, nil)))
+
+	violations := policy.Evaluate(release, resources)
+	assert.Equal(t, 1, len(violations))
+	assert.Equal(t, RuleBanOpenSecurityGroups, violations[0].Rule)
+	assert.Equal(t, "web", violations[0].Service)
+	assert.Contains(t, violations[0].Message, "open-sg")
+}
+
+func Test_OrgPolicy_Evaluate_Image(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	policy := &OrgPolicy{
+		MaxAMIAgeDays:   to.Intp(30),
+		AMIOwners:       []string{"111111111111"},
+		RequiredAMITags: map[string]string{"baked-by": "imagepipeline", "version": ""},
+	}
+
+	image := &ami.Image{
+		ImageID:   to.Strp("ami-123456"),
+		OwnerID:   to.Strp("111111111111"),
+		CreatedAt: to.Timep(time.Now().Add(-10 * 24 * time.Hour)),
+		Tags:      map[string]*string{"baked-by": to.Strp("imagepipeline"), "version": to.Strp("1.2")},
+	}
+	resources := map[string]*ServiceResources{"web": &ServiceResources{Image: image}}
+
+	assert.Equal(t, 0, len(policy.Evaluate(release, resources)))
+
+	image.CreatedAt = to.Timep(time.Now().Add(-40 * 24 * time.Hour))
+	image.OwnerID = to.Strp("099720109477")
+	image.Tags["baked-by"] = to.Strp("laptop")
+	delete(image.Tags, "version")
+
+	violations := policy.Evaluate(release, resources)
+	rules := []string{}
+	for _, v := range violations {
+		rules = append(rules, v.Rule)
+		assert.Equal(t, "", v.Service)
+	}
+
+	assert.Equal(t, []string{RuleMaxAMIAgeDays, RuleAMIOwners, RuleRequiredAMITags, RuleRequiredAMITags}, rules)
+	assert.Contains(t, violations[0].Message, "40 days old")
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// PauseRecord is written to pause the project configs running deploy at its next safe checkpoint
+// It is deleted when the deploy finishes, so it never holds a later deploy
+type PauseRecord struct {
+	Reason   *string    `json:"reason,omitempty"`
+	PausedAt *time.Time `json:"paused_at,omitempty"`
+}
+
+func (release *Release) pausePath() *string {
+	s := fmt.Sprintf("%v/pause", *release.RootDir())
+	return &s
+}
+
+// Pause holds the running deploy once its new ASGs are healthy, before rolling out more zones,
+// shifting traffic or deleting the old ASGs, until it is resumed
+func (release *Release) Pause(s3c aws.S3API, reason *string) error {
+	return PutArtifact(release.Store(s3c), release.pausePath(), &PauseRecord{
+		Reason:   reason,
+		PausedAt: to.Timep(time.Now()),
+	})
+}
+
+// Unpause lets a paused deploy continue, its timeouts start again
+func (release *Release) Unpause(s3c aws.S3API) error {
+	return release.Store(s3c).Delete(release.pausePath())
+}
+
+// CheckPaused sets whether the deploy is paused
+func (release *Release) CheckPaused(s3c aws.S3API) error {
+	_, err := release.Store(s3c).Get(release.pausePath())
+	if IsNotFound(err) {
+		release.Paused = to.Boolp(false)
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	release.Paused = to.Boolp(true)
+	return nil
+}
+
+// UpdatePaused sets whether the deploy is paused, and stops the release and phase timeouts while it is,
+// so a held deploy is not rolled back for the time it was paused
+func (release *Release) UpdatePaused(s3c aws.S3API, now time.Time) error {
+	if err := release.CheckPaused(s3c); err != nil {
+		return err
+	}
+
+	release.holdTimeouts(now)
+	return nil
+}
+
+// holdTimeouts moves the release timeout and the start of the current phase on by the whole seconds since the deploy was last seen paused
+func (release *Release) holdTimeouts(now time.Time) {
+	if release.PausedAt != nil {
+		held := now.Sub(*release.PausedAt).Truncate(time.Second)
+		if held > 0 {
+			if release.Timeout != nil {
+				release.Timeout = to.Intp(*release.Timeout + int(held/time.Second))
+			}
+
+			if release.PhaseStartedAt != nil {
+				release.PhaseStartedAt = to.Timep(release.PhaseStartedAt.Add(held))
+			}
+
+			release.PausedAt = to.Timep(release.PausedAt.Add(held))
+		}
+	}
+
+	switch {
+	case !release.IsPaused():
+		release.PausedAt = nil
+	case release.PausedAt == nil:
+		release.PausedAt = &now
+	}
+}
+
+// IsPaused returns whether the deploy is held at its checkpoint
+func (release *Release) IsPaused() bool {
+	return release.Paused != nil && *release.Paused
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_Pause(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	awsc := MockAwsClients(release)
+
+	assert.NoError(t, release.CheckPaused(awsc.S3))
+	assert.False(t, release.IsPaused())
+
+	assert.NoError(t, release.Pause(awsc.S3, to.Strp("reason")))
+	assert.NoError(t, release.CheckPaused(awsc.S3))
+	assert.True(t, release.IsPaused())
+
+	assert.NoError(t, release.Unpause(awsc.S3))
+	assert.NoError(t, release.CheckPaused(awsc.S3))
+	assert.False(t, release.IsPaused())
+}
+
+func Test_Release_UpdatePaused_Holds_Timeouts(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	awsc := MockAwsClients(release)
+
+	release.Timeout = to.Intp(600)
+	release.Timeouts = &TimeoutsConfig{Healthy: to.Intp(60)}
+	release.StartPhase(PhaseHealthy)
+	started := *release.PhaseStartedAt
+
+	assert.NoError(t, release.Pause(awsc.S3, to.Strp("reason")))
+	assert.NoError(t, release.UpdatePaused(awsc.S3, started))
+	assert.True(t, release.IsPaused())
+
+	// An hour paused neither times out the phase nor uses up the release timeout
+	assert.NoError(t, release.UpdatePaused(awsc.S3, started.Add(time.Hour)))
+	assert.NoError(t, release.checkPhaseTimeout(started.Add(time.Hour+30*time.Second)))
+	assert.Equal(t, 600+3600, *release.Timeout)
+
+	assert.NoError(t, release.Unpause(awsc.S3))
+	assert.NoError(t, release.UpdatePaused(awsc.S3, started.Add(time.Hour+10*time.Second)))
+	assert.False(t, release.IsPaused())
+	assert.Nil(t, release.PausedAt)
+	assert.Equal(t, 600+3610, *release.Timeout)
+
+	// Once unpaused the clocks run again
+	assert.Error(t, release.checkPhaseTimeout(started.Add(2*time.Hour)))
+}
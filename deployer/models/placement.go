@@ -0,0 +1,92 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/pg"
+)
+
+var placementStrategies = map[string]bool{
+	"cluster":   true,
+	"spread":    true,
+	"partition": true,
+}
+
+// Launch configurations do not support "host" tenancy, only launch templates do
+var placementTenancies = map[string]bool{
+	"default":   true,
+	"dedicated": true,
+	"host":      true,
+}
+
+// PlacementConfig struct
+type PlacementConfig struct {
+	GroupName      *string `json:"group_name,omitempty"`
+	Create         *bool   `json:"create,omitempty"`
+	Strategy       *string `json:"strategy,omitempty"`
+	PartitionCount *int64  `json:"partition_count,omitempty"`
+	Tenancy        *string `json:"tenancy,omitempty"`
+
+	// HostResourceGroupArn is the host resource group that "host" tenancy instances are launched onto
+	HostResourceGroupArn *string `json:"host_resource_group_arn,omitempty"`
+}
+
+// CreateGroup returns whether a transient placement group is created for the release
+func (p *PlacementConfig) CreateGroup() bool {
+	return p.Create != nil && *p.Create
+}
+
+// HostTenancy returns whether instances are launched onto dedicated hosts
+func (p *PlacementConfig) HostTenancy() bool {
+	return p.Tenancy != nil && *p.Tenancy == "host"
+}
+
+// ValidateAttributes validates attributes
+func (p *PlacementConfig) ValidateAttributes() error {
+	if p.GroupName != nil && p.CreateGroup() {
+		return fmt.Errorf("Placement cannot have both group_name and create")
+	}
+
+	if p.CreateGroup() {
+		if p.Strategy == nil {
+			return fmt.Errorf("Placement create requires a strategy")
+		}
+
+		if !placementStrategies[*p.Strategy] {
+			return fmt.Errorf("Placement strategy must be one of cluster, spread or partition")
+		}
+	} else if p.Strategy != nil || p.PartitionCount != nil {
+		return fmt.Errorf("Placement strategy and partition_count are only used with create")
+	}
+
+	if p.PartitionCount != nil {
+		if p.Strategy == nil || *p.Strategy != "partition" {
+			return fmt.Errorf("Placement partition_count requires the partition strategy")
+		}
+
+		if *p.PartitionCount < 1 || *p.PartitionCount > 7 {
+			return fmt.Errorf("Placement partition_count must be between 1 and 7")
+		}
+	}
+
+	if p.Tenancy != nil && !placementTenancies[*p.Tenancy] {
+		return fmt.Errorf("Placement tenancy must be one of default, dedicated or host")
+	}
+
+	if p.HostTenancy() != (p.HostResourceGroupArn != nil) {
+		return fmt.Errorf("Placement host tenancy requires a host_resource_group_arn, and is the only tenancy that uses one")
+	}
+
+	return nil
+}
+
+// FetchResources validates an existing placement group exists
+func (p *PlacementConfig) FetchResources(ec2c aws.EC2API) error {
+	if p.GroupName == nil {
+		return nil
+	}
+
+	_, err := pg.Find(ec2c, p.GroupName)
+	return err
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+const hostResourceGroup = "arn:aws:resource-groups:us-east-1:000000000000:group/hosts"
+
+func Test_PlacementConfig_ValidateAttributes(t *testing.T) {
+	assert.NoError(t, (&PlacementConfig{GroupName: to.Strp("group")}).ValidateAttributes())
+	assert.NoError(t, (&PlacementConfig{Create: to.Boolp(true), Strategy: to.Strp("spread")}).ValidateAttributes())
+	assert.NoError(t, (&PlacementConfig{Create: to.Boolp(true), Strategy: to.Strp("partition"), PartitionCount: to.Int64p(3)}).ValidateAttributes())
+	assert.NoError(t, (&PlacementConfig{Tenancy: to.Strp("dedicated")}).ValidateAttributes())
+	assert.NoError(t, (&PlacementConfig{Tenancy: to.Strp("host"), HostResourceGroupArn: to.Strp(hostResourceGroup)}).ValidateAttributes())
+
+	assert.Error(t, (&PlacementConfig{GroupName: to.Strp("group"), Create: to.Boolp(true), Strategy: to.Strp("spread")}).ValidateAttributes())
+	assert.Error(t, (&PlacementConfig{Create: to.Boolp(true)}).ValidateAttributes())
+	assert.Error(t, (&PlacementConfig{Create: to.Boolp(true), Strategy: to.Strp("random")}).ValidateAttributes())
+	assert.Error(t, (&PlacementConfig{Strategy: to.Strp("spread")}).ValidateAttributes())
+	assert.Error(t, (&PlacementConfig{Create: to.Boolp(true), Strategy: to.Strp("spread"), PartitionCount: to.Int64p(3)}).ValidateAttributes())
+	assert.Error(t, (&PlacementConfig{Create: to.Boolp(true), Strategy: to.Strp("partition"), PartitionCount: to.Int64p(8)}).ValidateAttributes())
+	assert.Error(t, (&PlacementConfig{Tenancy: to.Strp("host")}).ValidateAttributes())
+	assert.Error(t, (&PlacementConfig{Tenancy: to.Strp("dedicated"), HostResourceGroupArn: to.Strp(hostResourceGroup)}).ValidateAttributes())
+	assert.Error(t, (&PlacementConfig{Tenancy: to.Strp("shared")}).ValidateAttributes())
+}
+
+func Test_Service_Placement_HostTenancy(t *testing.T) {
+	r := MockRelease(t)
+	MockPrepareRelease(r)
+	service := r.Services["web"]
+	service.Placement = &PlacementConfig{Tenancy: to.Strp("host"), HostResourceGroupArn: to.Strp(hostResourceGroup)}
+
+	// Launch configurations cannot use host tenancy
+	service.IMDSv2 = to.Boolp(false)
+	assert.Error(t, service.validateLaunchTemplate())
+
+	service.IMDSv2 = to.Boolp(true)
+	assert.NoError(t, service.validateLaunchTemplate())
+
+	placement := service.createLaunchTemplateInput().LaunchTemplateData.Placement
+	assert.Equal(t, "host", *placement.Tenancy)
+	assert.Equal(t, hostResourceGroup, *placement.HostResourceGroupArn)
+}
+
+func Test_Release_Placement_CreateAndTeardown(t *testing.T) {
+	r := MockRelease(t)
+	r.Services["web"].Placement = &PlacementConfig{Create: to.Boolp(true), Strategy: to.Strp("cluster")}
+	MockPrepareRelease(r)
+
+	awsc := MockAwsClients(r)
+	assert.NoError(t, r.CreateResources(awsc.ASG, awsc.CW, awsc.EC2))
+
+	name := *r.Services["web"].PlacementGroupName()
+	assert.NotNil(t, awsc.EC2.PlacementGroups[name])
+	assert.Equal(t, name, *r.Services["web"].createInput().PlacementGroup)
+
+	// Successful Teardown keeps the releases group
+	assert.NoError(t, r.SuccessfulTearDown(awsc.ASG, awsc.ELB, awsc.ALB, awsc.CW, awsc.EC2))
+	assert.NotNil(t, awsc.EC2.PlacementGroups[name])
+
+	// Unsuccessful Teardown removes the releases group
+	assert.NoError(t, r.UnsuccessfulTearDown(awsc.ASG, awsc.CW, awsc.EC2))
+	assert.Nil(t, awsc.EC2.PlacementGroups[name])
+}
+
+func Test_Release_Placement_ExistingGroup(t *testing.T) {
+	r := MockRelease(t)
+	r.Services["web"].Placement = &PlacementConfig{GroupName: to.Strp("group"), Tenancy: to.Strp("dedicated")}
+	MockPrepareRelease(r)
+
+	awsc := MockAwsClients(r)
+	_, err := r.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS)
+	assert.Error(t, err)
+
+	awsc.EC2.AddPlacementGroup("group", "spread")
+	_, err = r.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "group", *r.Services["web"].createInput().PlacementGroup)
+	assert.Equal(t, "dedicated", *r.Services["web"].createLaunchConfigurationInput().PlacementTenancy)
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/secret"
+	"github.com/coinbase/step/utils/is"
+	"github.com/coinbase/step/utils/to"
+)
+
+// PluginRegistryRef is the SSM parameter in the deployers account that registers the plugins
+const PluginRegistryRef = "ssm:/odin/plugins"
+
+// Points in a deploy where plugins are invoked
+const (
+	PluginPostValidate = "post-validate" // Resources are valid, failing rejects the release
+	PluginPreCutover   = "pre-cutover"   // The new fleet is healthy, failing rolls it back
+	PluginPostCleanUp  = "post-cleanup"  // The deploy finished, failures are ignored
+)
+
+var pluginPoints = map[string]bool{
+	PluginPostValidate: true,
+	PluginPreCutover:   true,
+	PluginPostCleanUp:  true,
+}
+
+// hookPluginPoints are the plugin points invoked with the releases Lambda hooks of a transition
+var hookPluginPoints = map[string]string{
+	LambdaHookPreCreate:   PluginPostValidate,
+	LambdaHookPostHealthy: PluginPreCutover,
+}
+
+// Plugins are invoked by the deployers Lambda, which can only invoke odin-plugin- functions
+var pluginArn = regexp.MustCompile(`^arn:aws:lambda:[a-z0-9-]+:[0-9]+:function:odin-plugin-[a-zA-Z0-9_-]+$`)
+
+// Plugin is a Lambda function a platform team registers to extend deploys
+type Plugin struct {
+	FunctionArn *string  `json:"function_arn"`
+	Points      []string `json:"points"`
+}
+
+// PluginRegistry maps plugin names to their Lambda functions
+type PluginRegistry map[string]*Plugin
+
+// PluginRequest is the JSON payload plugins are invoked with
+type PluginRequest struct {
+	Point   string       `json:"point"`
+	Plugin  string       `json:"plugin"`
+	Release *HookRelease `json:"release"`
+}
+
+// LoadPluginRegistry reads and validates the registry
+func LoadPluginRegistry(ssmc aws.SSMAPI, smc aws.SMAPI) (PluginRegistry, error) {
+	raw, err := secret.Get(ssmc, smc, to.Strp(PluginRegistryRef))
+	if err != nil {
+		return nil, fmt.Errorf("Plugin registry %v", err.Error())
+	}
+
+	var registry PluginRegistry
+	if err := json.Unmarshal([]byte(*raw), &registry); err != nil {
+		return nil, fmt.Errorf("Plugin registry invalid %v", err.Error())
+	}
+
+	for name, plugin := range registry {
+		if plugin == nil || plugin.FunctionArn == nil {
+			return nil, fmt.Errorf("Plugin %v requires a function_arn", name)
+		}
+
+		if !pluginArn.MatchString(*plugin.FunctionArn) {
+			return nil, fmt.Errorf("Plugin %v function_arn %q must be the ARN of an odin-plugin- function", name, *plugin.FunctionArn)
+		}
+
+		for _, point := range plugin.Points {
+			if !pluginPoints[point] {
+				return nil, fmt.Errorf("Plugin %v point %q unknown", name, point)
+			}
+		}
+	}
+
+	return registry, nil
+}
+
+// HasPlugins returns whether the release opted in to any plugins
+func (release *Release) HasPlugins() bool {
+	return len(release.Plugins) > 0
+}
+
+// ValidatePlugins errors if the release opts in to plugins that are not registered
+func (release *Release) ValidatePlugins(registry PluginRegistry) error {
+	if !is.UniqueStrp(release.Plugins) {
+		return fmt.Errorf("Non Unique Plugins")
+	}
+
+	for _, name := range release.Plugins {
+		if registry[to.Strs(name)] == nil {
+			return fmt.Errorf("Plugin %q is not registered", to.Strs(name))
+		}
+	}
+
+	return nil
+}
+
+// HookPluginPoint returns the plugin point invoked with the Lambda hooks of the transition, if there is one
+func HookPluginPoint(hook string) *string {
+	point, ok := hookPluginPoints[hook]
+	if !ok {
+		return nil
+	}
+	return &point
+}
+
+// RunPlugins invokes the releases plugins registered for the point, in the order the release lists them
+func (release *Release) RunPlugins(lambdac aws.LambdaAPI, registry PluginRegistry, point string) error {
+	for _, name := range release.Plugins {
+		plugin := registry[to.Strs(name)]
+		if plugin == nil || !plugin.runsAt(point) {
+			continue
+		}
+
+		if err := plugin.invoke(lambdac, &PluginRequest{Point: point, Plugin: *name, Release: release.hookRelease()}); err != nil {
+			return fmt.Errorf("Plugin %v at %v failed %v", *name, point, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (plugin *Plugin) runsAt(point string) bool {
+	for _, p := range plugin.Points {
+		if p == point {
+			return true
+		}
+	}
+	return false
+}
+
+func (plugin *Plugin) invoke(lambdac aws.LambdaAPI, request *PluginRequest) error {
+	_, err := invokeLambda(lambdac, plugin.FunctionArn, request)
+	return err
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoadPluginRegistry(t *testing.T) {
+	ssmc := &mocks.SSMClient{}
+	_, err := LoadPluginRegistry(ssmc, &mocks.SMClient{})
+	assert.Error(t, err)
+
+	ssmc.AddParameter("/odin/plugins", `{"audit": {"function_arn": "arn:aws:lambda:us-east-1:000000000000:function:odin-plugin-audit", "points": ["post-validate", "post-cleanup"]}}`)
+	registry, err := LoadPluginRegistry(ssmc, &mocks.SMClient{})
+	assert.NoError(t, err)
+	assert.True(t, registry["audit"].runsAt(PluginPostValidate))
+	assert.False(t, registry["audit"].runsAt(PluginPreCutover))
+
+	// Only odin-plugin- functions can be registered
+	ssmc.AddParameter("/odin/plugins", `{"audit": {"function_arn": "arn:aws:lambda:us-east-1:000000000000:function:audit", "points": ["post-cleanup"]}}`)
+	_, err = LoadPluginRegistry(ssmc, &mocks.SMClient{})
+	assert.Error(t, err)
+
+	ssmc.AddParameter("/odin/plugins", `{"audit": {"function_arn": "arn:aws:lambda:us-east-1:000000000000:function:odin-plugin-audit", "points": ["pre-deploy"]}}`)
+	_, err = LoadPluginRegistry(ssmc, &mocks.SMClient{})
+	assert.Error(t, err)
+
+	ssmc.AddParameter("/odin/plugins", `{"audit": {"points": ["post-cleanup"]}}`)
+	_, err = LoadPluginRegistry(ssmc, &mocks.SMClient{})
+	assert.Error(t, err)
+}
+
+func Test_Release_ValidatePlugins(t *testing.T) {
+	release := MockRelease(t)
+	registry := PluginRegistry{"audit": &Plugin{FunctionArn: to.Strp("audit")}}
+
+	assert.NoError(t, release.ValidatePlugins(registry))
+
+	release.Plugins = []*string{to.Strp("audit")}
+	assert.NoError(t, release.ValidatePlugins(registry))
+
+	release.Plugins = []*string{to.Strp("audit"), to.Strp("audit")}
+	assert.Error(t, release.ValidatePlugins(registry))
+
+	release.Plugins = []*string{to.Strp("scanner")}
+	assert.Error(t, release.ValidatePlugins(registry))
+}
+
+func Test_Release_RunPlugins(t *testing.T) {
+	release := MockRelease(t)
+	release.Plugins = []*string{to.Strp("scanner"), to.Strp("audit")}
+
+	registry := PluginRegistry{
+		"audit":   &Plugin{FunctionArn: to.Strp("audit"), Points: []string{PluginPostCleanUp}},
+		"scanner": &Plugin{FunctionArn: to.Strp("scanner"), Points: []string{PluginPostValidate, PluginPostCleanUp}},
+	}
+
+	lambdac := &mocks.LambdaClient{}
+	assert.NoError(t, release.RunPlugins(lambdac, registry, PluginPostValidate))
+	assert.Equal(t, 1, len(lambdac.Invoked))
+
+	var request PluginRequest
+	assert.NoError(t, json.Unmarshal(lambdac.Invoked[0].Payload, &request))
+	assert.Equal(t, PluginPostValidate, request.Point)
+	assert.Equal(t, "scanner", request.Plugin)
+	assert.Equal(t, "rr", *request.Release.ReleaseID)
+	assert.Equal(t, []bool{true}, lambdac.Deadlines)
+
+	// Plugins are never sent the releases identity or user data
+	release.Identity = to.Strp("https://sts.amazonaws.com/?Action=GetCallerIdentity")
+	identityc := &mocks.LambdaClient{}
+	assert.NoError(t, release.RunPlugins(identityc, registry, PluginPostValidate))
+	assert.NotContains(t, string(identityc.Invoked[0].Payload), "GetCallerIdentity")
+	assert.NotContains(t, string(identityc.Invoked[0].Payload), "user_data")
+
+	// Plugins are invoked in the order of the release
+	assert.NoError(t, release.RunPlugins(lambdac, registry, PluginPostCleanUp))
+	assert.Equal(t, "scanner", *lambdac.Invoked[1].FunctionName)
+	assert.Equal(t, "audit", *lambdac.Invoked[2].FunctionName)
+
+	lambdac.FailFunction("scanner", "image has critical vulnerabilities")
+	err := release.RunPlugins(lambdac, registry, PluginPostValidate)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "image has critical vulnerabilities")
+}
@@ -0,0 +1,158 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/iam"
+	"github.com/coinbase/odin/aws/secret"
+	"github.com/coinbase/step/utils/to"
+)
+
+// ProfileTemplatePrefix is where the reviewed policy templates are stored in the deployers SSM
+const ProfileTemplatePrefix = "ssm:/odin/policies/"
+
+// PermissionsBoundaryName is the policy every created role is bounded by, it must exist in the account
+const PermissionsBoundaryName = "odin-permissions-boundary"
+
+var profileTemplateName = regexp.MustCompile(`^[a-zA-Z0-9_\-]+$`)
+
+var profileRoleArn = regexp.MustCompile(`^arn:aws[a-z\-]*:iam::(\d{12}):role/([\w+=,.@\-/]+)$`)
+
+func (service *Service) validateProfileTemplate() error {
+	if service.ProfileTemplate == nil {
+		return nil
+	}
+
+	// The profile is set to the created profile once it exists
+	if service.Profile != nil && *service.Profile != *service.templateProfileName() {
+		return fmt.Errorf("profile_template cannot be used with profile")
+	}
+
+	if !profileTemplateName.MatchString(*service.ProfileTemplate) {
+		return fmt.Errorf("profile_template %q must only contain letters, numbers, _ and -", *service.ProfileTemplate)
+	}
+
+	if len(*service.templateProfileName()) > 64 {
+		return fmt.Errorf("profile_template role name %v is longer than 64 characters", *service.templateProfileName())
+	}
+
+	return nil
+}
+
+func (service *Service) validateProfileRoleArn() error {
+	if service.ProfileRoleArn == nil {
+		return nil
+	}
+
+	if service.ProfileTemplate != nil {
+		return fmt.Errorf("profile_role_arn cannot be used with profile_template")
+	}
+
+	// The profile is set to the created profile once it exists
+	if service.Profile != nil && *service.Profile != *service.templateProfileName() {
+		return fmt.Errorf("profile_role_arn cannot be used with profile")
+	}
+
+	match := profileRoleArn.FindStringSubmatch(*service.ProfileRoleArn)
+	if match == nil {
+		return fmt.Errorf("profile_role_arn %q is not a role ARN", *service.ProfileRoleArn)
+	}
+
+	if service.release != nil && match[1] != to.Strs(service.release.AwsAccountID) {
+		return fmt.Errorf("profile_role_arn must be a role in account %v", to.Strs(service.release.AwsAccountID))
+	}
+
+	// Only roles in the services own path can be used, so a release cannot hand its instances any other role
+	if "/"+match[2] != *service.templateProfilePath()+*service.profileRoleName() {
+		return fmt.Errorf("profile_role_arn must be a role with the path %v", *service.templateProfilePath())
+	}
+
+	if len(*service.templateProfileName()) > 128 {
+		return fmt.Errorf("profile_role_arn instance profile name %v is longer than 128 characters", *service.templateProfileName())
+	}
+
+	return nil
+}
+
+// profileRoleName is the name of the role in profile_role_arn, without its path
+func (service *Service) profileRoleName() *string {
+	parts := strings.Split(to.Strs(service.ProfileRoleArn), "/")
+	return to.Strp(parts[len(parts)-1])
+}
+
+// templateProfileName is the name of the role and instance profile created for the service
+func (service *Service) templateProfileName() *string {
+	return to.Strp(fmt.Sprintf("odin-%v-%v-%v", to.Strs(service.ProjectName()), to.Strs(service.ConfigName()), to.Strs(service.ServiceName)))
+}
+
+// templateProfilePath is the path validated for service instance profiles
+func (service *Service) templateProfilePath() *string {
+	return to.Strp(fmt.Sprintf("/odin/%v/%v/%v/", to.Strs(service.ProjectName()), to.Strs(service.ConfigName()), to.Strs(service.ServiceName)))
+}
+
+// profilePolicy renders the services policy template
+func (service *Service) profilePolicy(ssmc aws.SSMAPI, smc aws.SMAPI) (*string, error) {
+	template, err := secret.Get(ssmc, smc, to.Strp(ProfileTemplatePrefix+*service.ProfileTemplate))
+	if err != nil {
+		return nil, err
+	}
+
+	replacer := strings.NewReplacer(
+		"{{AWS_ACCOUNT}}", to.Strs(service.release.AwsAccountID),
+		"{{AWS_REGION}}", to.Strs(service.release.AwsRegion),
+		"{{PROJECT_NAME}}", to.Strs(service.ProjectName()),
+		"{{CONFIG_NAME}}", to.Strs(service.ConfigName()),
+		"{{SERVICE_NAME}}", to.Strs(service.ServiceName),
+	)
+
+	policy := replacer.Replace(*template)
+
+	if !json.Valid([]byte(policy)) {
+		return nil, fmt.Errorf("profile_template %v is not valid JSON", *service.ProfileTemplate)
+	}
+
+	return &policy, nil
+}
+
+// CreateTemplateProfiles creates the role and instance profile of every service with a profile_template
+// The templates are read from the deployers SSM, so only reviewed policies can be used,
+// and the roles are bounded by the accounts odin-permissions-boundary policy
+// Services with a profile_role_arn have an instance profile created or updated to contain that role,
+// which must have the services path and be bounded by the same policy
+func (release *Release) CreateTemplateProfiles(iamc aws.IAMAPI, ssmc aws.SSMAPI, smc aws.SMAPI) error {
+	boundaryArn := to.Strp(fmt.Sprintf("arn:aws:iam::%v:policy/%v", to.Strs(release.AwsAccountID), PermissionsBoundaryName))
+
+	for _, service := range release.Services {
+		if service.ProfileRoleArn != nil {
+			name := service.templateProfileName()
+			if err := iam.EnsureRoleProfile(iamc, name, service.templateProfilePath(), service.profileRoleName(), boundaryArn); err != nil {
+				return fmt.Errorf("%v %v", service.errorPrefix(), err.Error())
+			}
+
+			service.Profile = name
+			continue
+		}
+
+		if service.ProfileTemplate == nil {
+			continue
+		}
+
+		policy, err := service.profilePolicy(ssmc, smc)
+		if err != nil {
+			return fmt.Errorf("%v %v", service.errorPrefix(), err.Error())
+		}
+
+		name := service.templateProfileName()
+		if err := iam.EnsureProfile(iamc, name, service.templateProfilePath(), boundaryArn, policy); err != nil {
+			return fmt.Errorf("%v %v", service.errorPrefix(), err.Error())
+		}
+
+		service.Profile = name
+	}
+
+	return nil
+}
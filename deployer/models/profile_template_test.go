@@ -0,0 +1,132 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Service_ProfileTemplate_Validate(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	service := release.Services["web"]
+	service.ProfileTemplate = to.Strp("web-app")
+	assert.Error(t, service.validateProfileTemplate()) // The mock release has a profile
+
+	service.Profile = nil
+	assert.NoError(t, service.validateProfileTemplate())
+
+	service.ProfileTemplate = to.Strp("../admin")
+	assert.Error(t, service.validateProfileTemplate())
+}
+
+func Test_Release_CreateTemplateProfiles(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	service := release.Services["web"]
+	service.Profile = nil
+	service.ProfileTemplate = to.Strp("web-app")
+
+	awsc := MockAwsClients(release)
+
+	// The template must exist
+	assert.Error(t, release.CreateTemplateProfiles(awsc.IAM, awsc.SSM, awsc.SM))
+
+	awsc.SSM.AddParameter("/odin/policies/web-app", `{"Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"arn:aws:s3:::{{PROJECT_NAME}}/{{SERVICE_NAME}}/*"}]}`)
+	assert.NoError(t, release.CreateTemplateProfiles(awsc.IAM, awsc.SSM, awsc.SM))
+
+	name := *service.templateProfileName()
+	assert.Equal(t, name, *service.Profile)
+	assert.Equal(t, 1, len(awsc.IAM.CreatedRoles))
+	assert.Equal(t, "arn:aws:iam::"+*release.AwsAccountID+":policy/odin-permissions-boundary", *awsc.IAM.CreatedRoles[0].PermissionsBoundary)
+	assert.Contains(t, awsc.IAM.RolePolicies[name], "arn:aws:s3:::"+*release.ProjectName+"/web/*")
+
+	// The created profile passes the path validation
+	resources, err := release.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS)
+	assert.NoError(t, err)
+	assert.NoError(t, release.ValidateResources(resources))
+
+	// Deploying again only updates the policy
+	assert.NoError(t, release.CreateTemplateProfiles(awsc.IAM, awsc.SSM, awsc.SM))
+	assert.Equal(t, 1, len(awsc.IAM.CreatedRoles))
+}
+
+func Test_Service_ProfileRoleArn_Validate(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	service := release.Services["web"]
+	roleArn := "arn:aws:iam::" + *release.AwsAccountID + ":role" + *service.templateProfilePath() + "web-role"
+	service.ProfileRoleArn = to.Strp(roleArn)
+	assert.Error(t, service.validateProfileRoleArn()) // The mock release has a profile
+
+	service.Profile = nil
+	assert.NoError(t, service.validateProfileRoleArn())
+	assert.Equal(t, "web-role", *service.profileRoleName())
+
+	// Roles outside the services path are rejected
+	service.ProfileRoleArn = to.Strp("arn:aws:iam::" + *release.AwsAccountID + ":role/app/web-role")
+	assert.Error(t, service.validateProfileRoleArn())
+
+	service.ProfileRoleArn = to.Strp("arn:aws:iam::" + *release.AwsAccountID + ":role/admin")
+	assert.Error(t, service.validateProfileRoleArn())
+
+	service.ProfileRoleArn = to.Strp("arn:aws:iam::" + *release.AwsAccountID + ":role" + *service.templateProfilePath() + "nested/web-role")
+	assert.Error(t, service.validateProfileRoleArn())
+
+	service.ProfileRoleArn = to.Strp(roleArn)
+
+	service.ProfileTemplate = to.Strp("web-app")
+	assert.Error(t, service.validateProfileRoleArn())
+	service.ProfileTemplate = nil
+
+	service.ProfileRoleArn = to.Strp("arn:aws:iam::999999999999:role/web-role")
+	assert.Error(t, service.validateProfileRoleArn())
+
+	service.ProfileRoleArn = to.Strp("web-role")
+	assert.Error(t, service.validateProfileRoleArn())
+}
+
+func Test_Release_CreateTemplateProfiles_ProfileRoleArn(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	service := release.Services["web"]
+	service.Profile = nil
+	service.ProfileRoleArn = to.Strp("arn:aws:iam::" + *release.AwsAccountID + ":role" + *service.templateProfilePath() + "web-role")
+	boundary := "arn:aws:iam::" + *release.AwsAccountID + ":policy/odin-permissions-boundary"
+
+	awsc := MockAwsClients(release)
+
+	// The role must exist
+	assert.Error(t, release.CreateTemplateProfiles(awsc.IAM, awsc.SSM, awsc.SM))
+
+	// The role must have the services path and permissions boundary
+	awsc.IAM.AddBoundedRole("web-role", "/", boundary)
+	assert.Error(t, release.CreateTemplateProfiles(awsc.IAM, awsc.SSM, awsc.SM))
+
+	awsc.IAM.AddGetRole("web-role")
+	assert.Error(t, release.CreateTemplateProfiles(awsc.IAM, awsc.SSM, awsc.SM))
+	assert.Equal(t, 0, len(awsc.IAM.AddedProfileRoles))
+
+	awsc.IAM.AddBoundedRole("web-role", *service.templateProfilePath(), boundary)
+	assert.NoError(t, release.CreateTemplateProfiles(awsc.IAM, awsc.SSM, awsc.SM))
+
+	name := *service.templateProfileName()
+	assert.Equal(t, name, *service.Profile)
+	assert.Equal(t, 0, len(awsc.IAM.CreatedRoles))
+	assert.Equal(t, 1, len(awsc.IAM.AddedProfileRoles))
+	assert.Equal(t, "web-role", *awsc.IAM.AddedProfileRoles[0].RoleName)
+
+	resources, err := release.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS)
+	assert.NoError(t, err)
+	assert.NoError(t, release.ValidateResources(resources))
+
+	// The existing profile has its role replaced
+	assert.NoError(t, release.CreateTemplateProfiles(awsc.IAM, awsc.SSM, awsc.SM))
+	assert.Equal(t, 1, len(awsc.IAM.RemovedProfileRoles))
+	assert.Equal(t, 2, len(awsc.IAM.AddedProfileRoles))
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/identity"
+	"github.com/coinbase/step/utils/to"
+)
+
+// RBAC maps IAM principal ARNs, or role tags as "tag:<key>=<value>", to the project configs they can deploy
+// Each pattern is matched against <project_name>/<config_name>, e.g. "coinbase/deploy-test/*"
+type RBAC map[string][]string
+
+// rbacTagPrefix marks a grant to every role with the tag, e.g. "tag:team=payments"
+const rbacTagPrefix = "tag:"
+
+// ParseRBAC parses the RBAC map, an empty string disables RBAC
+func ParseRBAC(raw string) (RBAC, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var rbac RBAC
+	if err := json.Unmarshal([]byte(raw), &rbac); err != nil {
+		return nil, fmt.Errorf("RBAC invalid %v", err.Error())
+	}
+
+	for principal, patterns := range rbac {
+		if strings.HasPrefix(principal, rbacTagPrefix) {
+			tag := strings.SplitN(strings.TrimPrefix(principal, rbacTagPrefix), "=", 2)
+			if len(tag) != 2 || tag[0] == "" {
+				return nil, fmt.Errorf("RBAC role tag %q must be tag:<key>=<value>", principal)
+			}
+		}
+
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("RBAC pattern %q for %v invalid %v", pattern, principal, err.Error())
+			}
+		}
+	}
+
+	return rbac, nil
+}
+
+// Allowed returns whether any of the principals can deploy the project config
+func (rbac RBAC) Allowed(principals []string, projectName *string, configName *string) bool {
+	projectConfig := fmt.Sprintf("%v/%v", to.Strs(projectName), to.Strs(configName))
+
+	for _, principal := range principals {
+		for _, pattern := range rbac[principal] {
+			if ok, _ := path.Match(pattern, projectConfig); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// tagGrants returns whether any grant is to a role tag, so the callers role tags must be read
+func (rbac RBAC) tagGrants() bool {
+	for principal := range rbac {
+		if strings.HasPrefix(principal, rbacTagPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// TagPrincipals returns the grants a role with the tags is authorized as
+func TagPrincipals(tags map[string]string) []string {
+	principals := []string{}
+	for key, value := range tags {
+		principals = append(principals, fmt.Sprintf("%v%v=%v", rbacTagPrefix, key, value))
+	}
+	sort.Strings(principals)
+	return principals
+}
+
+// ValidateAuthorization verifies the identity that created the release is allowed to deploy it
+// Role tags are read with iamc, which is in accountID, so only the tags of roles in that account grant anything
+// as a role of the same name in another account is a different role
+func (release *Release) ValidateAuthorization(rbac RBAC, iamc aws.IAMAPI, accountID *string) error {
+	if rbac == nil {
+		return nil
+	}
+
+	// The caller is only set by VerifyAudit from a verified identity
+	if release.Audit == nil || release.Audit.CallerARN == nil {
+		return fmt.Errorf("Identity must be defined")
+	}
+
+	arn := release.Audit.CallerARN
+	principals := identity.Principals(arn)
+
+	if rbac.tagGrants() && accountID != nil && to.Strs(identity.Account(arn)) == *accountID {
+		tags, err := identity.RoleTags(iamc, arn)
+		if err != nil {
+			return fmt.Errorf("Reading the role tags of %v failed %v", *arn, err.Error())
+		}
+		principals = append(principals, TagPrincipals(tags)...)
+	}
+
+	if !rbac.Allowed(principals, release.ProjectName, release.ConfigName) {
+		return fmt.Errorf("%v is not authorized to deploy %v/%v", *arn, to.Strs(release.ProjectName), to.Strs(release.ConfigName))
+	}
+
+	return nil
+}
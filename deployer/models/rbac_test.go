@@ -0,0 +1,111 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseRBAC(t *testing.T) {
+	rbac, err := ParseRBAC("")
+	assert.NoError(t, err)
+	assert.Nil(t, rbac)
+
+	rbac, err = ParseRBAC(`{"arn:aws:iam::000000000000:role/team-a": ["project/*"]}`)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(rbac))
+
+	_, err = ParseRBAC(`{"arn:aws:iam::000000000000:role/team-a": "project/*"}`)
+	assert.Error(t, err)
+
+	_, err = ParseRBAC(`{"arn:aws:iam::000000000000:role/team-a": ["project/["]}`)
+	assert.Error(t, err)
+
+	rbac, err = ParseRBAC(`{"tag:team=payments": ["payments/*"], "tag:odin-admin=": ["*/*"]}`)
+	assert.NoError(t, err)
+	assert.True(t, rbac.tagGrants())
+
+	_, err = ParseRBAC(`{"tag:team": ["payments/*"]}`)
+	assert.Error(t, err)
+
+	_, err = ParseRBAC(`{"tag:=payments": ["payments/*"]}`)
+	assert.Error(t, err)
+}
+
+func Test_RBAC_Allowed(t *testing.T) {
+	rbac, err := ParseRBAC(`{
+    "arn:aws:iam::000000000000:role/team-a": ["project/*"],
+    "arn:aws:iam::000000000000:user/alice": ["coinbase/deploy-test/development"]
+  }`)
+	assert.NoError(t, err)
+
+	teamA := []string{"arn:aws:sts::000000000000:assumed-role/team-a/session", "arn:aws:iam::000000000000:role/team-a"}
+	alice := []string{"arn:aws:iam::000000000000:user/alice"}
+
+	assert.True(t, rbac.Allowed(teamA, to.Strp("project"), to.Strp("config")))
+	assert.False(t, rbac.Allowed(teamA, to.Strp("coinbase/deploy-test"), to.Strp("development")))
+
+	assert.True(t, rbac.Allowed(alice, to.Strp("coinbase/deploy-test"), to.Strp("development")))
+	assert.False(t, rbac.Allowed(alice, to.Strp("coinbase/deploy-test"), to.Strp("production")))
+
+	assert.False(t, rbac.Allowed([]string{}, to.Strp("project"), to.Strp("config")))
+}
+
+func Test_Release_ValidateAuthorization(t *testing.T) {
+	r := MockRelease(t)
+	MockPrepareRelease(r)
+
+	// RBAC disabled
+	assert.NoError(t, r.ValidateAuthorization(nil, nil, nil))
+
+	rbac := RBAC{"arn:aws:iam::000000000000:role/team-a": []string{"project/*"}}
+	assert.Error(t, r.ValidateAuthorization(rbac, nil, nil))
+
+	// Only the verified caller is authorized, not what the client reports
+	r.Identity = to.Strp("https://evil.com/?Action=GetCallerIdentity")
+	assert.Error(t, r.ValidateAuthorization(rbac, nil, nil))
+
+	r.Audit = &Audit{CallerARN: to.Strp("arn:aws:sts::000000000000:assumed-role/team-a/alice")}
+	assert.NoError(t, r.ValidateAuthorization(rbac, nil, nil))
+
+	r.Audit = &Audit{CallerARN: to.Strp("arn:aws:sts::000000000000:assumed-role/team-b/bob")}
+	assert.Error(t, r.ValidateAuthorization(rbac, nil, nil))
+}
+
+func Test_Release_ValidateAuthorization_RoleTags(t *testing.T) {
+	r := MockRelease(t)
+	MockPrepareRelease(r)
+
+	iamc := &mocks.IAMClient{}
+	iamc.TagRole("team-a", "team", "project-team")
+	iamc.TagRole("team-b", "team", "other-team")
+
+	rbac := RBAC{"tag:team=project-team": []string{"project/*"}}
+	account := to.Strp("000000000000")
+
+	// Roles with the tag are allowed
+	r.Audit = &Audit{CallerARN: to.Strp("arn:aws:sts::000000000000:assumed-role/team-a/alice")}
+	assert.NoError(t, r.ValidateAuthorization(rbac, iamc, account))
+
+	// Roles with another value, or without the tag, are denied
+	r.Audit = &Audit{CallerARN: to.Strp("arn:aws:sts::000000000000:assumed-role/team-b/bob")}
+	assert.Error(t, r.ValidateAuthorization(rbac, iamc, account))
+
+	r.Audit = &Audit{CallerARN: to.Strp("arn:aws:sts::000000000000:assumed-role/untagged/carol")}
+	assert.Error(t, r.ValidateAuthorization(rbac, iamc, account))
+
+	// A role of the same name in another account is not the tagged role
+	r.Audit = &Audit{CallerARN: to.Strp("arn:aws:sts::111111111111:assumed-role/team-a/mallory")}
+	assert.Error(t, r.ValidateAuthorization(rbac, iamc, account))
+
+	// Users have no role tags
+	r.Audit = &Audit{CallerARN: to.Strp("arn:aws:iam::000000000000:user/dave")}
+	assert.Error(t, r.ValidateAuthorization(rbac, iamc, account))
+}
+
+func Test_TagPrincipals(t *testing.T) {
+	assert.Equal(t, []string{}, TagPrincipals(nil))
+	assert.Equal(t, []string{"tag:env=prod", "tag:team=payments"}, TagPrincipals(map[string]string{"team": "payments", "env": "prod"}))
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/coinbase/step/utils/to"
+)
+
+// Registration is a release pushed to the bucket to be executed later, e.g. CI pushes it and an operator executes it
+// Its ID is the releases ID
+type Registration struct {
+	RegistrationID *string    `json:"registration_id"`
+	ReleaseSHA256  *string    `json:"release_sha256"` // SHA256 of the release as it was pushed
+	PushedAt       *time.Time `json:"pushed_at"`
+	Release        *Release   `json:"release"`
+}
+
+// RegistrationPath returns the path of the registration, which is found by its ID alone
+func RegistrationPath(accountID *string, registrationID *string) *string {
+	s := fmt.Sprintf("%v/registrations/%v", to.Strs(accountID), to.Strs(registrationID))
+	return &s
+}
+
+// Register saves the release to the store as a registration, its userdata must already be uploaded
+func (release *Release) Register(store ArtifactStore, now time.Time) (*Registration, error) {
+	registration := &Registration{
+		RegistrationID: release.ReleaseID,
+		ReleaseSHA256:  to.SHA256Struct(release),
+		PushedAt:       to.Timep(now),
+		Release:        release,
+	}
+
+	path := RegistrationPath(release.AwsAccountID, release.ReleaseID)
+	if err := PutArtifact(store, path, registration); err != nil {
+		return nil, err
+	}
+
+	return registration, nil
+}
+
+// LoadRegistration returns the registration, erroring if its release no longer matches the SHA256 it was pushed with
+func LoadRegistration(store ArtifactStore, accountID *string, registrationID *string) (*Registration, error) {
+	raw, err := store.Get(RegistrationPath(accountID, registrationID))
+	if IsNotFound(err) {
+		return nil, fmt.Errorf("Registration %v not found, push the release first", to.Strs(registrationID))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var registration Registration
+	if err := json.Unmarshal(raw, &registration); err != nil {
+		return nil, fmt.Errorf("Registration invalid %v", err.Error())
+	}
+
+	if registration.Release == nil {
+		return nil, fmt.Errorf("Registration %v has no release", to.Strs(registrationID))
+	}
+
+	if to.Strs(to.SHA256Struct(registration.Release)) != to.Strs(registration.ReleaseSHA256) {
+		return nil, &CodedError{Code: ErrorCodeValidationSHA, Cause: fmt.Sprintf("Registration %v release does not match its SHA256 %v", to.Strs(registrationID), to.Strs(registration.ReleaseSHA256))}
+	}
+
+	return &registration, nil
+}
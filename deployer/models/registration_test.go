@@ -0,0 +1,22 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_Register(t *testing.T) {
+	release := MockRelease(t)
+	awsc := MockAwsClients(release)
+
+	registration, err := release.Register(release.Store(awsc.S3), time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, "rr", *registration.RegistrationID)
+
+	loaded, err := LoadRegistration(release.Store(awsc.S3), release.AwsAccountID, release.ReleaseID)
+	assert.NoError(t, err)
+	assert.Equal(t, *registration.ReleaseSHA256, *loaded.ReleaseSHA256)
+	assert.Equal(t, *release.ProjectName, *loaded.Release.ProjectName)
+}
@@ -2,9 +2,9 @@ package models
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/coinbase/odin/aws"
-	"github.com/coinbase/step/aws/s3"
 	"github.com/coinbase/step/bifrost"
 	"github.com/coinbase/step/utils/is"
 	"github.com/coinbase/step/utils/to"
@@ -16,11 +16,28 @@ type Release struct {
 
 	Subnets []*string `json:"subnets,omitempty"`
 
+	// SubnetTag resolves the Subnets by tag when the release is deployed
+	SubnetTag *SubnetTagConfig `json:"subnet_tag,omitempty"`
+
+	vpcID *string // VPC of the subnets, not serialized
+
 	Image *string `json:"ami,omitempty"`
 
+	// AMIFilter resolves the Image to the latest AMI matching it when the release is deployed
+	AMIFilter *string `json:"ami_filter,omitempty"`
+
+	// Interpolations are the ${ssm:/path} and ${env:VAR} references the client resolved in the release file
+	Interpolations map[string]*Interpolation `json:"interpolations,omitempty"`
+
 	userdata       *string // Not serialized
 	UserDataSHA256 *string `json:"user_data_sha256,omitempty"`
 
+	// UserDataKMSKey is the KMS key the client encrypts the userdata with, defaults to the AWS managed alias/aws/s3
+	UserDataKMSKey *string `json:"user_data_kms_key,omitempty"`
+
+	// ReleaseKMSKey envelope encrypts the release wherever it is stored in S3, for releases with sensitive values
+	ReleaseKMSKey *string `json:"release_kms_key,omitempty"`
+
 	// LifeCycleHooks
 	LifeCycleHooks map[string]*LifeCycleHook `json:"lifecycle,omitempty"`
 
@@ -29,8 +46,119 @@ type Release struct {
 
 	WaitForHealthy *int `json:"wait_for_healthy,omitempty"`
 
+	// HealthPoll configures the wait between health checks, WaitForHealthy is the next wait
+	HealthPoll *HealthPollConfig `json:"health_poll,omitempty"`
+
+	// HealthSummary is the latest health check of every service
+	HealthSummary *HealthSummary `json:"health_summary,omitempty"`
+
+	// ValidationTimings are how long each check of the validation states took
+	ValidationTimings []*CheckTiming `json:"validation_timings,omitempty"`
+
+	// DeployStartedAt is when resources started being created, used to measure time to healthy
+	DeployStartedAt *time.Time `json:"deploy_started_at,omitempty"`
+
+	// Timeouts of each phase, the current phase and when it started
+	Timeouts       *TimeoutsConfig `json:"timeouts,omitempty"`
+	Phase          *string         `json:"phase,omitempty"`
+	PhaseStartedAt *time.Time      `json:"phase_started_at,omitempty"`
+
+	// TearDownStartedAt is when the first attempt to tear down the failed release started
+	TearDownStartedAt *time.Time `json:"teardown_started_at,omitempty"`
+
+	// Identity is a presigned sts:GetCallerIdentity URL proving who created the release
+	Identity *string `json:"identity,omitempty"`
+
+	// Audit records who deployed the release and from where
+	Audit *Audit `json:"audit,omitempty"`
+
+	// Signature is the clients signature of the release, verified by the deployer
+	Signature *Signature `json:"signature,omitempty"`
+
+	// ForceAdopt lets the release delete old ASGs missing the project, config or release tags Odin creates them with
+	ForceAdopt *bool `json:"force_adopt,omitempty"`
+
+	// LockWait is the most seconds to wait for another release to release the lock instead of failing
+	LockWait *int `json:"lock_wait,omitempty"`
+
+	// ExecutionArn is the deploys execution, named by the client so it can be recorded in the lock
+	ExecutionArn *string `json:"execution_arn,omitempty"`
+
+	// LockFence is the fencing token of the lock the deployer grabbed, only set when locks are in a table
+	LockFence *int64 `json:"lock_fence,omitempty"`
+
+	// StateRef is set instead of the rest of the release when it is passed between states
+	StateRef *StateRef `json:"state_ref,omitempty"`
+
+	// Tags are applied to every services ASG and instances, and their launch templates and volumes
+	Tags map[string]*string `json:"tags,omitempty"`
+
+	// Hardening is the name of a preset of security defaults enforced on all services
+	Hardening *string `json:"hardening,omitempty"`
+
+	// Notifications are SSM or Secrets Manager references to webhook URLs called on deploy transitions
+	Notifications []*string `json:"notifications,omitempty"`
+
+	// NewRelicAppID is the New Relic application to record deployments in
+	NewRelicAppID *string `json:"newrelic_app_id,omitempty"`
+
+	// Hooks are commands the odin client runs locally around the deploy, the deployer never runs them
+	Hooks *HooksConfig `json:"hooks,omitempty"`
+
+	// LambdaHooks are Lambda functions the deployer invokes at transitions of the deploy, which can veto it
+	LambdaHooks *LambdaHooksConfig `json:"lambda_hooks,omitempty"`
+
+	// ErrorCode is the stable code of the releases error, set when it fails
+	ErrorCode *string `json:"error_code,omitempty"`
+
+	// Diagnostics of each service that was not healthy when the release failed its health checks
+	Diagnostics map[string]*Diagnostics `json:"diagnostics,omitempty"`
+
+	// Verifications are the results of the deployers verifiers checking the releases artifacts
+	Verifications []*Verification `json:"verifications,omitempty"`
+
+	// Paused is whether the deploy is held once healthy, until it is unpaused
+	// PausedAt is when the deploy was last seen paused, the timeouts do not count the time since
+	Paused   *bool      `json:"paused,omitempty"`
+	PausedAt *time.Time `json:"paused_at,omitempty"`
+
+	// OverrideFreeze deploys the release outside the deployers deploy windows, the reason is recorded with the release
+	OverrideFreeze *bool   `json:"override_freeze,omitempty"`
+	OverrideReason *string `json:"override_reason,omitempty"`
+
+	// Approval holds the healthy release before cutover until it is approved with the client
+	Approval            *ApprovalConfig `json:"approval,omitempty"`
+	ApprovalRequestedAt *time.Time      `json:"approval_requested_at,omitempty"`
+	Approved            *bool           `json:"approved,omitempty"`
+
+	// DeployLevel is the level of services ordered by depends_on being deployed
+	DeployLevel *int `json:"deploy_level,omitempty"`
+
+	// PartialSuccess is whether the release succeeded with some of its services halted
+	PartialSuccess *bool `json:"partial_success,omitempty"`
+
+	// Checkpoint is the last state the release completed, Resumed releases reuse the ASGs it created
+	Checkpoint *string `json:"checkpoint,omitempty"`
+	Resumed    *bool   `json:"resumed,omitempty"`
+
+	// Features enable experimental behaviors, each must be allowed by the deployers ODIN_FEATURES
+	Features map[string]bool `json:"features,omitempty"`
+
+	// Annotations are messages about the release, e.g. what changed and its ticket, included in its release notes
+	Annotations []*string `json:"annotations,omitempty"`
+
+	// PreviousReleaseID is the deployed release this release replaces, with the ASGs it created by service
+	PreviousReleaseID *string            `json:"previous_release_id,omitempty"`
+	PreviousASGs      map[string]*string `json:"previous_asgs,omitempty"`
+
+	// Plugins are the names of registered plugin Lambdas the release opts in to
+	Plugins []*string `json:"plugins,omitempty"`
+
 	// AWS Service is Downloaded
 	Services map[string]*Service `json:"services,omitempty"` // Downloaded From S3
+
+	// storeFunc is where the releases artifacts are kept, set by UseStore
+	storeFunc StoreFunc
 }
 
 //////////
@@ -66,23 +194,19 @@ func (release *Release) SetDefaultsWithUserData(s3c aws.S3API) error {
 
 // SetDefaults assigns default values
 func (release *Release) SetDefaults() {
-	// Overwrite WaitForHealthy to be Min 15 seconds, Max 5 minutes
-	waitForHealthy := 120
-	switch {
-	case *release.Timeout < 1800:
-		// Under 30 mins check every 15 seconds
-		waitForHealthy = 15
-	case *release.Timeout < 7200:
-		// Under 2 hour check every 60 seconds
-		waitForHealthy = 60
+	// WaitForHealthy starts at the health poll interval and backs off up to its cap
+	if release.WaitForHealthy == nil || *release.WaitForHealthy < release.shortestWaitForHealthy() || *release.WaitForHealthy > release.maxWaitForHealthy() {
+		release.WaitForHealthy = to.Intp(release.shortestWaitForHealthy())
 	}
 
-	release.WaitForHealthy = to.Intp(waitForHealthy)
-
 	if release.Healthy == nil {
 		release.Healthy = to.Boolp(false)
 	}
 
+	if release.Approval != nil {
+		release.Approval.SetDefaults()
+	}
+
 	for name, lc := range release.LifeCycleHooks {
 		if lc != nil {
 			lc.SetDefaults(release.AwsRegion, release.AwsAccountID, name)
@@ -106,21 +230,90 @@ func (release *Release) Validate(s3c aws.S3API) error {
 		return err
 	}
 
+	if err := release.ValidateNotDestroyed(s3c); err != nil {
+		return err
+	}
+
+	// The previous release decides which ASGs are deleted, so it is only linked by the deployer
+	release.PreviousReleaseID, release.PreviousASGs = nil, nil
+
+	if err := release.ValidateUserDataSHA(s3c); err != nil {
+		return prefixError(release.ErrorPrefix(), err)
+	}
+
+	if err := release.ValidateOrgPolicy(s3c, nil); err != nil {
+		return fmt.Errorf("%v %v", release.ErrorPrefix(), err.Error())
+	}
+
+	return release.ValidateConfiguration()
+}
+
+// ValidateConfiguration validates the release without calling AWS
+func (release *Release) ValidateConfiguration() error {
 	// Max timeout is 48 hours (for now)
 	if *release.Timeout > 172800 {
 		// 48 hours of timeout means the WaitForHealthy of 120 will work
 		return fmt.Errorf("%v Max timeout is 172800 (48 hours)", release.ErrorPrefix())
 	}
 
-	if (5.0/float64(*release.WaitForHealthy))*(float64(*release.Timeout)) > 10000.0 {
+	if err := release.ValidateHealthPoll(); err != nil {
+		return fmt.Errorf("%v %v", release.ErrorPrefix(), err.Error())
+	}
+
+	if release.budgetWaitForHealthy() > release.shortestWaitForHealthy() {
 		// There are 5 state transitions per health check
+		// Activity resets WaitForHealthy to its floor, so the floor not the cap bounds the health checks
 		// (5/WaitForHealthy) * Timeout is about equal to the max state transistions
 		// Due to limitations on StepFucntions History Events the max state transistions is about 10k
 		// So (5/WaitForHealthy) * Timeout < 10k as a rule of thumb
-		return fmt.Errorf("%v Rule of Thumb (5/WaitForHealthy) * Timeout < 10k", release.ErrorPrefix())
+		return fmt.Errorf("%v Rule of Thumb (5/WaitForHealthy) * Timeout < 10k, health_poll interval must be at least %v", release.ErrorPrefix(), release.budgetWaitForHealthy())
 	}
 
-	if err := release.ValidateUserDataSHA(s3c); err != nil {
+	if release.LockWait != nil && (*release.LockWait < 0 || *release.LockWait > maxLockWait) {
+		return fmt.Errorf("%v LockWait must be between 0 and %v", release.ErrorPrefix(), maxLockWait)
+	}
+
+	if release.SubnetTag != nil {
+		if err := release.SubnetTag.ValidateAttributes(); err != nil {
+			return fmt.Errorf("%v %v", release.ErrorPrefix(), err.Error())
+		}
+	}
+
+	if err := release.ValidateInterpolations(); err != nil {
+		return prefixError(release.ErrorPrefix(), err)
+	}
+
+	if err := release.ValidateAMIFilter(); err != nil {
+		return fmt.Errorf("%v %v", release.ErrorPrefix(), err.Error())
+	}
+
+	if release.Timeouts != nil {
+		if err := release.Timeouts.ValidateAttributes(); err != nil {
+			return fmt.Errorf("%v %v", release.ErrorPrefix(), err.Error())
+		}
+	}
+
+	if err := release.ValidateHardening(); err != nil {
+		return fmt.Errorf("%v %v", release.ErrorPrefix(), err.Error())
+	}
+
+	if err := release.ValidateNotifications(); err != nil {
+		return fmt.Errorf("%v %v", release.ErrorPrefix(), err.Error())
+	}
+
+	if err := release.ValidateLambdaHooks(); err != nil {
+		return fmt.Errorf("%v %v", release.ErrorPrefix(), err.Error())
+	}
+
+	if err := release.ValidateApproval(); err != nil {
+		return fmt.Errorf("%v %v", release.ErrorPrefix(), err.Error())
+	}
+
+	if err := release.ValidateTags(); err != nil {
+		return fmt.Errorf("%v %v", release.ErrorPrefix(), err.Error())
+	}
+
+	if err := release.ValidateFreezeOverride(); err != nil {
 		return fmt.Errorf("%v %v", release.ErrorPrefix(), err.Error())
 	}
 
@@ -145,7 +338,7 @@ func (release *Release) ValidateUserDataSHA(s3c aws.S3API) error {
 
 	userdataSha := to.SHA256Str(release.UserData())
 	if userdataSha != *release.UserDataSHA256 {
-		return fmt.Errorf("UserData SHA incorrect expected %v, got %v", userdataSha, *release.UserDataSHA256)
+		return &CodedError{Code: ErrorCodeValidationSHA, Cause: fmt.Sprintf("UserData SHA incorrect expected %v, got %v", userdataSha, *release.UserDataSHA256)}
 	}
 
 	return nil
@@ -158,13 +351,13 @@ func (release *Release) UserData() *string {
 
 // DownloadUserData fetches and populates the User data from S3
 func (release *Release) DownloadUserData(s3c aws.S3API) error {
-	userdataBytes, err := s3.Get(s3c, release.Bucket, release.UserDataPath())
+	userdataBytes, err := release.Store(s3c).Get(release.UserDataPath())
 
 	if err != nil {
 		return err
 	}
 
-	release.SetUserData(to.Strp(string(*userdataBytes)))
+	release.SetUserData(to.Strp(string(userdataBytes)))
 	return nil
 }
 
@@ -194,5 +387,5 @@ func (release *Release) ValidateServices() error {
 		}
 	}
 
-	return nil
+	return release.ValidateDependsOn()
 }
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/envelope"
+	"github.com/coinbase/step/utils/to"
+)
+
+// envelopeS3 envelope encrypts the documents it writes to the sealed paths, and decrypts every envelope it reads
+// Documents at the sealed paths must be envelopes, so a plaintext copy of the release is not trusted
+type envelopeS3 struct {
+	aws.S3API
+	kmsc   aws.KMSAPI
+	keyID  *string
+	sealed map[string]bool
+}
+
+// DecryptingS3 returns an S3 client that decrypts the envelope encrypted documents it reads
+func DecryptingS3(s3c aws.S3API, kmsc aws.KMSAPI) aws.S3API {
+	return &envelopeS3{S3API: s3c, kmsc: kmsc, sealed: map[string]bool{}}
+}
+
+// EnvelopeS3 returns an S3 client that also encrypts the releases record, checkpoint, state and registration with its release_kms_key
+func (release *Release) EnvelopeS3(s3c aws.S3API, kmsc aws.KMSAPI) aws.S3API {
+	// A release without its paths fails validation before it is read
+	if release.ReleaseKMSKey == nil || release.AwsAccountID == nil || release.ProjectName == nil || release.ConfigName == nil || release.ReleaseID == nil {
+		return DecryptingS3(s3c, kmsc)
+	}
+
+	return &envelopeS3{
+		S3API: s3c,
+		kmsc:  kmsc,
+		keyID: release.ReleaseKMSKey,
+		sealed: map[string]bool{
+			*release.ReleasePath():    true,
+			*release.CheckpointPath(): true,
+			*release.RecordPath():     true,
+			*release.StatePath():      true,
+			*RegistrationPath(release.AwsAccountID, release.ReleaseID): true,
+		},
+	}
+}
+
+// envelopeContext binds an envelope to where it is stored
+func envelopeContext(bucket *string, key *string) map[string]*string {
+	return map[string]*string{
+		"odin:bucket": to.Strp(to.Strs(bucket)),
+		"odin:key":    to.Strp(to.Strs(key)),
+	}
+}
+
+// PutObject seals documents written to the sealed paths
+func (m *envelopeS3) PutObject(in *awss3.PutObjectInput) (*awss3.PutObjectOutput, error) {
+	if !m.sealed[to.Strs(in.Key)] {
+		return m.S3API.PutObject(in)
+	}
+
+	plaintext, err := ioutil.ReadAll(in.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := envelope.Seal(m.kmsc, *m.keyID, envelopeContext(in.Bucket, in.Key), plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("Error encrypting %v with %v", to.Strs(in.Key), err.Error())
+	}
+
+	sealedIn := *in
+	sealedIn.Body = bytes.NewReader(sealed)
+	sealedIn.ContentMD5 = nil
+	if in.ContentLength != nil {
+		sealedIn.ContentLength = to.Int64p(int64(len(sealed)))
+	}
+
+	return m.S3API.PutObject(&sealedIn)
+}
+
+// GetObject opens envelopes, and errors if a document at a sealed path is not one
+func (m *envelopeS3) GetObject(in *awss3.GetObjectInput) (*awss3.GetObjectOutput, error) {
+	out, err := m.S3API.GetObject(in)
+	if err != nil {
+		return out, err
+	}
+
+	raw, err := ioutil.ReadAll(out.Body)
+	out.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if envelope.IsSealed(raw) {
+		// Documents at the sealed paths must be sealed with the releases key, others e.g. older releases with any
+		var keyID *string
+		if m.sealed[to.Strs(in.Key)] {
+			keyID = m.keyID
+		}
+
+		if raw, err = envelope.Open(m.kmsc, keyID, envelopeContext(in.Bucket, in.Key), raw); err != nil {
+			return nil, fmt.Errorf("Error decrypting %v with %v", to.Strs(in.Key), err.Error())
+		}
+	} else if m.sealed[to.Strs(in.Key)] {
+		return nil, fmt.Errorf("%v must be encrypted with release_kms_key %v", to.Strs(in.Key), *m.keyID)
+	}
+
+	out.Body = ioutil.NopCloser(bytes.NewReader(raw))
+	if out.ContentLength != nil {
+		out.ContentLength = to.Int64p(int64(len(raw)))
+	}
+
+	return out, nil
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/coinbase/odin/aws/envelope"
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_EnvelopeS3(t *testing.T) {
+	release := MockRelease(t)
+	awsc := MockAwsClients(release)
+	MockPrepareRelease(release)
+	release.ReleaseKMSKey = to.Strp("alias/odin-releases")
+
+	s3c := release.EnvelopeS3(awsc.S3, awsc.KMS)
+
+	assert.NoError(t, s3.PutStruct(s3c, release.Bucket, release.ReleasePath(), release))
+	assert.NoError(t, release.SaveCheckpoint(s3c, CheckpointDeploy))
+
+	// Stored encrypted
+	for _, path := range []*string{release.ReleasePath(), release.CheckpointPath()} {
+		raw, err := s3.Get(awsc.S3, release.Bucket, path)
+		assert.NoError(t, err)
+		assert.True(t, envelope.IsSealed(*raw))
+		assert.NotContains(t, string(*raw), "web-sg")
+	}
+
+	// Read decrypted, and the SHA matches the plaintext
+	raw, err := s3.Get(s3c, release.Bucket, release.ReleasePath())
+	assert.NoError(t, err)
+
+	var stored Release
+	assert.NoError(t, json.Unmarshal(*raw, &stored))
+	assert.Equal(t, to.Strs(to.SHA256Struct(release)), to.Strs(to.SHA256Struct(&stored)))
+
+	checkpoint, err := release.LoadCheckpoint(DecryptingS3(awsc.S3, awsc.KMS))
+	assert.NoError(t, err)
+	assert.Equal(t, CheckpointDeploy, *checkpoint.Checkpoint)
+
+	// Other documents are not encrypted
+	assert.NoError(t, release.WriteAudit(s3c))
+	raw, err = s3.Get(awsc.S3, release.Bucket, release.AuditPath())
+	assert.NoError(t, err)
+	assert.False(t, envelope.IsSealed(*raw))
+}
+
+func Test_Release_EnvelopeS3_Rejects(t *testing.T) {
+	release := MockRelease(t)
+	awsc := MockAwsClients(release)
+	MockPrepareRelease(release)
+	release.ReleaseKMSKey = to.Strp("alias/odin-releases")
+
+	// MockAwsClients uploads the release in plaintext
+	_, err := s3.Get(release.EnvelopeS3(awsc.S3, awsc.KMS), release.Bucket, release.ReleasePath())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "must be encrypted with release_kms_key")
+
+	// Encrypted with another key
+	other := *release
+	other.ReleaseKMSKey = to.Strp("alias/other")
+	assert.NoError(t, s3.PutStruct(other.EnvelopeS3(awsc.S3, awsc.KMS), release.Bucket, release.ReleasePath(), release))
+	_, err = s3.Get(release.EnvelopeS3(awsc.S3, awsc.KMS), release.Bucket, release.ReleasePath())
+	assert.Error(t, err)
+
+	// Moved from where it was encrypted
+	raw, err := s3.Get(awsc.S3, release.Bucket, release.ReleasePath())
+	assert.NoError(t, err)
+	awsc.S3.AddGetObject(*release.CheckpointPath(), string(*raw), nil)
+	_, err = release.LoadCheckpoint(DecryptingS3(awsc.S3, &mocks.KMSClient{}))
+	assert.Error(t, err)
+}
@@ -18,7 +18,7 @@ type XReleaseExceptions struct {
 // UnmarshalJSON should error if there is something unexpected
 func (release *Release) UnmarshalJSON(data []byte) error {
 	var releaseE XReleaseExceptions
-	dec := json.NewDecoder(bytes.NewReader(data))
+	dec := json.NewDecoder(bytes.NewReader(moveSubnetTag(data)))
 	dec.DisallowUnknownFields() // Force
 
 	if err := dec.Decode(&releaseE); err != nil {
@@ -15,3 +15,18 @@ func Test_Parsing_Errors(t *testing.T) {
 
 	assert.Error(t, json.Unmarshal([]byte(`{"release_ids" : "1"}`), &r))
 }
+
+func Test_Parsing_SubnetTag(t *testing.T) {
+	var r Release
+	assert.NoError(t, json.Unmarshal([]byte(`{"subnets": {"tag": "odin:tier=private", "per_az": true}}`), &r))
+	assert.Nil(t, r.Subnets)
+	assert.Equal(t, "odin:tier=private", *r.SubnetTag.Tag)
+	assert.True(t, *r.SubnetTag.PerAZ)
+
+	r = Release{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"subnets": ["subnet-1"]}`), &r))
+	assert.Nil(t, r.SubnetTag)
+	assert.Equal(t, 1, len(r.Subnets))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"subnets": {"tags": "odin:tier=private"}}`), &r))
+}
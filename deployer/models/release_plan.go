@@ -0,0 +1,73 @@
+package models
+
+import (
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/asg"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Plan lists the ASGs a release will create, and terminate if it succeeds
+type Plan struct {
+	Create    []*PlannedASG `json:"create"`
+	Terminate []*PlannedASG `json:"terminate"`
+	Cost      *PlanCost     `json:"cost,omitempty"`
+}
+
+// PlannedASG struct
+type PlannedASG struct {
+	steadyCapacity *int64 // Desired capacity once launching finishes, without the spread
+
+	Name            *string `json:"name,omitempty"`
+	ServiceName     *string `json:"service_name,omitempty"`
+	ReleaseID       *string `json:"release_id,omitempty"`
+	DesiredCapacity *int64  `json:"desired_capacity,omitempty"`
+	InstanceType    *string `json:"instance_type,omitempty"`
+	Image           *string `json:"image,omitempty"`
+
+	EBSVolumeSize *int64        `json:"ebs_volume_size,omitempty"`
+	EBSVolumeType *string       `json:"ebs_volume_type,omitempty"`
+	Cost          *CostEstimate `json:"cost,omitempty"`
+}
+
+// Plan returns the releases plan, UpdateWithResources must be called first
+func (release *Release) Plan(asgc aws.ASGAPI) (*Plan, error) {
+	plan := &Plan{Create: []*PlannedASG{}, Terminate: []*PlannedASG{}}
+
+	for name, service := range release.Services {
+		var image *string
+		if service.Resources != nil {
+			image = service.Resources.Image
+		}
+
+		capacity := int64(service.targetCapacity())
+		steady := int64(service.Autoscaling.DesiredCapacity(service.PreviousDesiredCapacity))
+		plan.Create = append(plan.Create, &PlannedASG{
+			Name:            service.ServiceID(),
+			ServiceName:     to.Strp(name),
+			ReleaseID:       release.ReleaseID,
+			DesiredCapacity: &capacity,
+			InstanceType:    service.InstanceType,
+			Image:           image,
+			EBSVolumeSize:   service.EBSVolumeSize,
+			EBSVolumeType:   service.EBSVolumeType,
+			steadyCapacity:  &steady,
+		})
+	}
+
+	// The same ASGs SuccessfulTearDown deletes
+	asgs, err := asg.ForProjectConfigNOTReleaseID(asgc, release.ProjectName, release.ConfigName, release.ReleaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range asgs {
+		plan.Terminate = append(plan.Terminate, &PlannedASG{
+			Name:            group.AutoScalingGroupName,
+			ServiceName:     group.ServiceName(),
+			ReleaseID:       group.ReleaseID(),
+			DesiredCapacity: group.DesiredCapacity,
+		})
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_Plan(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	awsc := MockAwsClients(release)
+
+	resources, err := release.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS)
+	assert.NoError(t, err)
+	assert.NoError(t, release.ValidateResources(resources))
+	release.UpdateWithResources(resources)
+
+	plan, err := release.Plan(awsc.ASG)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, len(plan.Create))
+	assert.Equal(t, "web", *plan.Create[0].ServiceName)
+	assert.Equal(t, *release.Services["web"].ServiceID(), *plan.Create[0].Name)
+	assert.Equal(t, "ami-123456", *plan.Create[0].Image)
+
+	assert.Equal(t, 1, len(plan.Terminate))
+	assert.Equal(t, "project-config-web-old-release", *plan.Terminate[0].Name)
+	assert.Equal(t, "old-release", *plan.Terminate[0].ReleaseID)
+}
+
+func Test_Release_Plan_ServiceNames(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	awsc := MockAwsClients(release)
+
+	resources, err := release.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS)
+	assert.NoError(t, err)
+	release.UpdateWithResources(resources)
+
+	api := *release.Services["web"]
+	api.ServiceName = to.Strp("api")
+	release.Services["api"] = &api
+
+	plan, err := release.Plan(awsc.ASG)
+	assert.NoError(t, err)
+
+	// Each planned ASG has its own service name
+	names := []string{}
+	for _, planned := range plan.Create {
+		names = append(names, *planned.ServiceName)
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"api", "web"}, names)
+}
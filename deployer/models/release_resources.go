@@ -2,11 +2,45 @@ package models
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/coinbase/odin/aws"
 	"github.com/coinbase/odin/aws/ami"
 	"github.com/coinbase/odin/aws/asg"
+	"github.com/coinbase/odin/aws/lt"
+	"github.com/coinbase/odin/aws/pg"
+	"github.com/coinbase/odin/aws/quota"
 	"github.com/coinbase/odin/aws/subnet"
+	"github.com/coinbase/step/utils/to"
+)
+
+// maxTearDownWait is the most seconds the teardown waits for the old instances in all,
+// leaving over a third of the 300 second Lambda timeout to delete the old ASGs
+const maxTearDownWait = 180
+
+// The drain timeout must leave time in the Lambda to delete the old ASGs
+const maxDrainTimeout = maxTearDownWait
+
+// maxHealthConcurrency is the most services whose health is checked at the same time, to stay under AWS rate limits
+const maxHealthConcurrency = 4
+
+// drainPoll is how often the old ASGs are first checked while their instances drain and terminate,
+// it doubles after each check up to maxDrainPoll
+var drainPoll = 5 * time.Second
+
+const maxDrainPoll = 30 * time.Second
+
+// Health checks start minWaitForHealthy seconds apart by default, and never more often
+const minWaitForHealthy = 5
+
+// Each health check is about healthCheckTransitions state transitions, and due to limitations
+// on Step Functions history events an execution has at most about maxHealthTransitions of them
+const (
+	healthCheckTransitions = 5
+	maxHealthTransitions   = 10000
 )
 
 //////////
@@ -18,28 +52,51 @@ import (
 func (release *Release) FetchResources(asgc aws.ASGAPI, ec2 aws.EC2API, elbc aws.ELBAPI, albc aws.ALBAPI, iamc aws.IAMAPI, snsc aws.SNSAPI) (map[string]*ServiceResources, error) {
 	resources := map[string]*ServiceResources{}
 
-	// If there are any ASGs with this release ID error
+	// If there are any ASGs with this release ID error, unless they are being resumed
 	badASGs, err := asg.ForProjectConfigReleaseID(asgc, release.ProjectName, release.ConfigName, release.ReleaseID)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(badASGs) != 0 {
+	if len(badASGs) != 0 && !release.resumesASGs(badASGs) {
 		return nil, fmt.Errorf("%v ASGs exist for same project config release", release.ErrorPrefix())
 	}
 
-	prevASGs, err := asg.ForProjectConfigNotReleaseIDServiceMap(asgc, release.ProjectName, release.ConfigName, release.ReleaseID)
+	previous, err := release.previousASGs(asgc)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fail before deploying rather than when the old ASGs are deleted
+	if err := release.checkOwnership(previous); err != nil {
+		return nil, err
+	}
+
+	prevASGs, err := release.previousASGServiceMap(previous)
 	if err != nil {
 		return nil, err
 	}
 
-	// Fetch Subnets
+	// Fetch Subnets, resolving them first if they are selected by tag
+	if err := release.ResolveSubnets(ec2); err != nil {
+		return nil, err
+	}
+
 	subnets, err := subnet.Find(ec2, release.Subnets)
 	if err != nil {
 		return nil, err
 	}
 
-	// Fetch Image
+	// Security groups are found in the VPC of the subnets
+	if release.vpcID, err = subnet.VPC(subnets); err != nil {
+		return nil, err
+	}
+
+	// Fetch Image, resolving it first if it is selected by filter
+	if err := release.ResolveImage(ec2); err != nil {
+		return nil, err
+	}
+
 	im, err := ami.Find(ec2, release.Image)
 	if err != nil {
 		return nil, err
@@ -53,6 +110,12 @@ func (release *Release) FetchResources(asgc aws.ASGAPI, ec2 aws.EC2API, elbc aws
 	}
 
 	for name, service := range release.Services {
+		if service.TerminationHook != nil {
+			if err := service.TerminationHook.FetchResources(iamc, snsc); err != nil {
+				return nil, err
+			}
+		}
+
 		sr, err := service.FetchResources(ec2, elbc, albc, iamc)
 		if err != nil {
 			return nil, err
@@ -101,14 +164,44 @@ func (release *Release) UpdateWithResources(resources map[string]*ServiceResourc
 	}
 }
 
+// ValidateQuotas errors if the resources the release will create exceed the regions limits
+// PreviousDesiredCapacity must be set so the number of new instances is known
+func (release *Release) ValidateQuotas(asgc aws.ASGAPI, ec2c aws.EC2API, sqc aws.QuotasAPI) error {
+	usage := &quota.Usage{}
+
+	for _, service := range release.Services {
+		usage.ASGs++
+		if !service.usesLaunchTemplate() {
+			usage.LaunchConfigurations++
+		}
+
+		// Spot instances do not count towards the On-Demand vCPU quotas
+		if service.SpotPrice == nil {
+			usage.AddInstances(service.InstanceType, service.targetCapacity())
+		}
+	}
+
+	if err := quota.Validate(asgc, ec2c, sqc, usage); err != nil {
+		return prefixError(release.ErrorPrefix(), err)
+	}
+
+	return nil
+}
+
 //////////
 // Create Resources
 //////////
 
 // CreateResources returns
-func (release *Release) CreateResources(asgc aws.ASGAPI, cwc aws.CWAPI) error {
-	for _, service := range release.Services {
-		err := service.CreateResources(asgc, cwc)
+// Only the first level of services is created, services that depend on them are created once they are healthy
+func (release *Release) CreateResources(asgc aws.ASGAPI, cwc aws.CWAPI, ec2c aws.EC2API) error {
+	services, err := release.levelServices(release.deployLevel())
+	if err != nil {
+		return err
+	}
+
+	for _, service := range services {
+		err := service.CreateResources(asgc, cwc, ec2c)
 		if err != nil {
 			return err
 		}
@@ -120,18 +213,149 @@ func (release *Release) CreateResources(asgc aws.ASGAPI, cwc aws.CWAPI) error {
 // Healthy Resources
 //////////
 
+// maxWaitForHealthy returns the cap of the wait between health checks, the health polls max_interval
+// or by default longer releases check less often
+func (release *Release) maxWaitForHealthy() int {
+	poll := release.HealthPoll
+	switch {
+	case poll.backoff() == HealthPollFixed:
+		return poll.interval()
+	case poll != nil && poll.MaxInterval != nil:
+		return *poll.MaxInterval
+	}
+
+	max := release.defaultMaxWaitForHealthy()
+	if shortest := release.shortestWaitForHealthy(); max < shortest {
+		return shortest
+	}
+	return max
+}
+
+// shortestWaitForHealthy returns the floor of the wait between health checks, the health polls interval
+// By default it is raised so checking that often for the whole timeout stays within the state transitions
+func (release *Release) shortestWaitForHealthy() int {
+	poll := release.HealthPoll
+	if poll != nil && poll.Interval != nil {
+		return *poll.Interval
+	}
+
+	if budget := release.budgetWaitForHealthy(); budget > minWaitForHealthy {
+		return budget
+	}
+	return minWaitForHealthy
+}
+
+// budgetWaitForHealthy returns the shortest wait that keeps the health checks of the whole timeout within maxHealthTransitions
+func (release *Release) budgetWaitForHealthy() int {
+	return (healthCheckTransitions*(*release.Timeout) + maxHealthTransitions - 1) / maxHealthTransitions
+}
+
+func (release *Release) defaultMaxWaitForHealthy() int {
+	switch {
+	case *release.Timeout < 1800:
+		// Under 30 mins check at least every 15 seconds
+		return 15
+	case *release.Timeout < 7200:
+		// Under 2 hour check at least every 60 seconds
+		return 60
+	}
+	return 120
+}
+
+// HealthActivity summarizes the instances of each service,
+// it changes when the ASGs launch, terminate or mark healthy instances
+func (release *Release) HealthActivity() string {
+	names := []string{}
+	for name := range release.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	activity := []string{}
+	for _, name := range names {
+		report := release.Services[name].HealthReport
+		if report == nil {
+			continue
+		}
+
+		activity = append(activity, fmt.Sprintf("%v:%v/%v/%v", name, intValue(report.Healthy), intValue(report.Launching), intValue(report.Terminating)))
+	}
+
+	return strings.Join(activity, ",")
+}
+
+func intValue(i *int) int {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+// UpdateWaitForHealthy backs off the wait before the next health check up to its cap,
+// by default doubling it unless there was activity since the previous check so it checks again soon
+func (release *Release) UpdateWaitForHealthy(previousActivity string) {
+	wait := release.HealthPoll.next(*release.WaitForHealthy, release.HealthActivity() != previousActivity)
+
+	if shortest := release.shortestWaitForHealthy(); wait < shortest {
+		wait = shortest
+	}
+
+	if max := release.maxWaitForHealthy(); wait > max {
+		wait = max
+	}
+
+	release.WaitForHealthy = &wait
+}
+
 // UpdateHealthy will try set the Healthy attribute
-// First Error is a Halting Error, Second Error is a Retry Error
-func (release *Release) UpdateHealthy(asgc aws.ASGAPI, elbc aws.ELBAPI, albc aws.ALBAPI) error {
+// Services are checked in parallel, up to maxHealthConcurrency at a time, and summarized in the HealthSummary
+// A Halting Error from any service is returned before a Retry Error
+func (release *Release) UpdateHealthy(asgc aws.ASGAPI, elbc aws.ELBAPI, albc aws.ALBAPI, ec2c aws.EC2API, ssmc aws.SSMAPI) error {
+	start := time.Now()
 	healthy := true
 
-	for _, service := range release.Services {
+	names := []string{}
+	for name, service := range release.Services {
+		// Halted services no longer have a new ASG to check
+		if service.IsHalted() {
+			continue
+		}
 
-		if err := service.UpdateHealthy(asgc, elbc, albc); err != nil {
-			return err
+		// Services waiting on their dependencies have no ASG yet
+		if !release.deploying(service) {
+			service.Healthy = false
+			healthy = false
+			continue
 		}
 
-		healthy = healthy && service.Healthy // Healthy if all services are healthy
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Each service only updates itself so they can be checked at the same time
+	errs := make([]error, len(names))
+	sem := make(chan struct{}, maxHealthConcurrency)
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, service *Service) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			errs[i] = service.UpdateHealthy(asgc, elbc, albc, ec2c, ssmc)
+		}(i, release.Services[name])
+	}
+	wg.Wait()
+
+	release.updateHealthSummary(names, errs, start)
+
+	if err := firstHealthError(errs); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		healthy = healthy && release.Services[name].Healthy // Healthy if all services are healthy
 	}
 
 	release.Healthy = &healthy
@@ -139,44 +363,186 @@ func (release *Release) UpdateHealthy(asgc aws.ASGAPI, elbc aws.ELBAPI, albc aws
 	return nil
 }
 
+// firstHealthError returns the first Halting Error, otherwise the first Retry Error
+func firstHealthError(errs []error) error {
+	var retry error
+	for _, err := range errs {
+		if _, ok := err.(*HaltError); ok {
+			return err
+		}
+
+		if retry == nil {
+			retry = err
+		}
+	}
+
+	return retry
+}
+
 //////////
 // Teardown
 //////////
 
+// placementGroupPrefix returns the prefix of all transient placement groups for the project config
+func (release *Release) placementGroupPrefix() *string {
+	return pg.SafeName(to.Strp(fmt.Sprintf("%v-%v-", to.Strs(release.ProjectName), to.Strs(release.ConfigName))))
+}
+
+// placementGroupNames returns the transient placement groups created by this release
+func (release *Release) placementGroupNames() []*string {
+	names := []*string{}
+	for _, service := range release.Services {
+		if service.Placement != nil && service.Placement.CreateGroup() {
+			names = append(names, service.PlacementGroupName())
+		}
+	}
+	return names
+}
+
+// checkOwnership errors if any of the old ASGs are missing Odins tags, unless the release force adopts them
+func (release *Release) checkOwnership(asgs []*asg.ASG) error {
+	if release.ForceAdopt != nil && *release.ForceAdopt {
+		return nil
+	}
+
+	for _, a := range asgs {
+		if !a.Owned() {
+			return fmt.Errorf("%v ASG %v is missing the ProjectName, ConfigName or ReleaseID tags so was not created by Odin, set force_adopt to delete it", release.ErrorPrefix(), to.Strs(a.AutoScalingGroupName))
+		}
+	}
+
+	return nil
+}
+
 // SuccessfulTearDown returns
-func (release *Release) SuccessfulTearDown(asgc aws.ASGAPI, cwc aws.CWAPI) error {
+func (release *Release) SuccessfulTearDown(asgc aws.ASGAPI, elbc aws.ELBAPI, albc aws.ALBAPI, cwc aws.CWAPI, ec2c aws.EC2API) error {
 	// Tear down all resources in NOT in this release
-	asgs, err := asg.ForProjectConfigNOTReleaseID(asgc, release.ProjectName, release.ConfigName, release.ReleaseID)
+	asgs, err := release.previousASGs(asgc)
 
 	if err != nil {
 		return err
 	}
 
-	// Delete all Previous Resources
+	// Halted services keep their old ASG
+	asgs = release.withoutKeptASGs(asgs)
+
+	// Never delete an ASG Odin did not create
+	if err := release.checkOwnership(asgs); err != nil {
+		return err
+	}
+
+	// Detach all Previous Resources so their instances start draining
 	for _, asg := range asgs {
-		if *release.ProjectName != *asg.ProjectName() {
+		// Even linked ASGs must have the project and config tags, so a release can never delete another project configs ASGs
+		if *release.ProjectName != to.Strs(asg.ProjectName()) {
 			return fmt.Errorf("Bad Project")
 		}
 
-		if *release.ConfigName != *asg.ConfigName() {
+		if *release.ConfigName != to.Strs(asg.ConfigName()) {
 			return fmt.Errorf("Bad Config")
 		}
 
-		if *release.ReleaseID == *asg.ReleaseID() {
+		if *release.ReleaseID == to.Strs(asg.ReleaseID()) {
 			return fmt.Errorf("Bad ReleaseID")
 		}
 
-		if err := asg.Teardown(asgc, cwc); err != nil {
+		if err := asg.Detach(asgc); err != nil {
 			return err
 		}
+	}
+
+	// Every wait shares one deadline so together they never use up the Lambda
+	deadline := time.Now().Add(maxTearDownWait * time.Second)
 
+	if err := release.drain(elbc, albc, asgs, deadline); err != nil {
+		return err
+	}
+
+	if err := release.terminate(asgc, asgs, deadline); err != nil {
+		return err
+	}
+
+	// Delete all Previous Resources
+	for _, asg := range asgs {
+		if err := asg.Delete(asgc, cwc); err != nil {
+			return err
+		}
+
+		if asg.LaunchTemplateName != nil {
+			if err := lt.Teardown(ec2c, asg.LaunchTemplateName); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Delete previous transient placement groups once they are no longer in use
+	return pg.TeardownTransient(ec2c, release.placementGroupPrefix(), release.placementGroupNames())
+}
+
+// drain waits for the detached ASGs instances to leave their load balancers,
+// for at most the drain timeout of their service or until the deadline before they are terminated anyway
+func (release *Release) drain(elbc aws.ELBAPI, albc aws.ALBAPI, asgs []*asg.ASG, deadline time.Time) error {
+	start := time.Now()
+
+	for _, group := range asgs {
+		until := earliest(start.Add(release.drainTimeout(group.ServiceName())), deadline)
+		poll := drainPoll
+
+		for time.Now().Before(until) {
+			draining, err := group.Draining(elbc, albc)
+			if err != nil {
+				return err
+			}
+
+			if !draining {
+				break
+			}
+
+			poll = sleepBackoff(poll, until)
+		}
 	}
 
 	return nil
 }
 
+// sleepBackoff sleeps for the poll without passing the deadline, and returns the doubled poll up to maxDrainPoll
+func sleepBackoff(poll time.Duration, deadline time.Time) time.Duration {
+	if remaining := time.Until(deadline); remaining < poll {
+		poll = remaining
+	}
+
+	time.Sleep(poll)
+
+	if poll*2 > maxDrainPoll {
+		return maxDrainPoll
+	}
+	return poll * 2
+}
+
+// earliest returns the earlier of the times
+func earliest(a time.Time, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+// drainTimeout returns the drain timeout of the service, old ASGs of removed services do not wait
+func (release *Release) drainTimeout(serviceName *string) time.Duration {
+	if serviceName == nil {
+		return 0
+	}
+
+	service, ok := release.Services[*serviceName]
+	if !ok || service.DrainTimeout == nil {
+		return 0
+	}
+
+	return time.Duration(*service.DrainTimeout) * time.Second
+}
+
 // UnsuccessfulTearDown deletes the services we were trying to create because :(
-func (release *Release) UnsuccessfulTearDown(asgc aws.ASGAPI, cwc aws.CWAPI) error {
+func (release *Release) UnsuccessfulTearDown(asgc aws.ASGAPI, cwc aws.CWAPI, ec2c aws.EC2API) error {
 	// Tear down all resources in this release
 	asgs, err := asg.ForProjectConfigReleaseID(asgc, release.ProjectName, release.ConfigName, release.ReleaseID)
 	if err != nil {
@@ -204,7 +570,14 @@ func (release *Release) UnsuccessfulTearDown(asgc aws.ASGAPI, cwc aws.CWAPI) err
 		if err := asg.Teardown(asgc, cwc); err != nil {
 			return err
 		}
+
+		if asg.LaunchTemplateName != nil {
+			if err := lt.Teardown(ec2c, asg.LaunchTemplateName); err != nil {
+				return err
+			}
+		}
 	}
 
-	return nil
+	// Delete transient placement groups, any still in use are removed by the next teardown
+	return pg.TeardownTransient(ec2c, release.placementGroupPrefix(), nil)
 }
@@ -1,8 +1,12 @@
 package models
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -46,39 +50,93 @@ func Test_Release_UpdateWithResources_Works(t *testing.T) {
 }
 
 func Test_Release_CreateResources_Works(t *testing.T) {
-	// func (release *Release) CreateResources(asgc aws.ASGAPI, cwc aws.CWAPI) error {
+	// func (release *Release) CreateResources(asgc aws.ASGAPI, cwc aws.CWAPI, ec2c aws.EC2API) error {
 	r := MockRelease(t)
 	MockPrepareRelease(r)
 
 	awsc := MockAwsClients(r)
-	assert.NoError(t, r.CreateResources(awsc.ASG, awsc.CW))
+	assert.NoError(t, r.CreateResources(awsc.ASG, awsc.CW, awsc.EC2))
 }
 
 func Test_Release_UpdateHealthy_Works(t *testing.T) {
-	// func (release *Release) UpdateHealthy(asgc aws.ASGAPI, elbc aws.ELBAPI, albc aws.ALBAPI) error {
+	// func (release *Release) UpdateHealthy(asgc aws.ASGAPI, elbc aws.ELBAPI, albc aws.ALBAPI, ec2c aws.EC2API, ssmc aws.SSMAPI) error {
 	r := MockRelease(t)
 	MockPrepareRelease(r)
 
 	awsc := MockAwsClients(r)
 
-	assert.NoError(t, r.CreateResources(awsc.ASG, awsc.CW))
-	assert.NoError(t, r.UpdateHealthy(awsc.ASG, awsc.ELB, awsc.ALB))
+	assert.NoError(t, r.CreateResources(awsc.ASG, awsc.CW, awsc.EC2))
+	assert.NoError(t, r.UpdateHealthy(awsc.ASG, awsc.ELB, awsc.ALB, awsc.EC2, awsc.SSM))
+
+	// Every checked service is summarized
+	assert.NotNil(t, r.HealthSummary.CheckedAt)
+	assert.Nil(t, r.HealthSummary.Services["web"].Error)
+	assert.Equal(t, r.Services["web"].Healthy, r.HealthSummary.Services["web"].Healthy)
+}
+
+func Test_firstHealthError(t *testing.T) {
+	assert.NoError(t, firstHealthError([]error{nil, nil}))
+
+	retry := fmt.Errorf("retry")
+	halt := &HaltError{fmt.Errorf("halt")}
+
+	assert.Equal(t, retry, firstHealthError([]error{nil, retry}))
+	assert.Equal(t, halt, firstHealthError([]error{retry, halt}))
 }
 
 func Test_Release_SuccessfulTearDown_Works(t *testing.T) {
-	// func (release *Release) SuccessfulTearDown(asgc aws.ASGAPI, cwc aws.CWAPI) error {
+	// func (release *Release) SuccessfulTearDown(asgc aws.ASGAPI, elbc aws.ELBAPI, albc aws.ALBAPI, cwc aws.CWAPI, ec2c aws.EC2API) error {
 	r := MockRelease(t)
 	MockPrepareRelease(r)
 
 	awsc := MockAwsClients(r)
-	assert.NoError(t, r.SuccessfulTearDown(awsc.ASG, awsc.CW))
+	assert.NoError(t, r.SuccessfulTearDown(awsc.ASG, awsc.ELB, awsc.ALB, awsc.CW, awsc.EC2))
+}
+
+func Test_Release_SuccessfulTearDown_DrainTimeout(t *testing.T) {
+	r := MockRelease(t)
+	MockPrepareRelease(r)
+
+	r.Services["web"].DrainTimeout = to.Intp(1)
+	assert.Equal(t, time.Second, r.drainTimeout(to.Strp("web")))
+	assert.Equal(t, time.Duration(0), r.drainTimeout(to.Strp("removed")))
+
+	// The deadline cuts the drain timeout short
+	now := time.Now()
+	assert.Equal(t, now, earliest(now.Add(time.Minute), now))
+	assert.Equal(t, now, earliest(now, now.Add(time.Minute)))
+
+	awsc := MockAwsClients(r)
+	assert.NoError(t, r.SuccessfulTearDown(awsc.ASG, awsc.ELB, awsc.ALB, awsc.CW, awsc.EC2))
+
+	r.Services["web"].DrainTimeout = to.Intp(maxTearDownWait + 1)
+	assert.Error(t, r.Services["web"].ValidateAttributes())
 }
 
 func Test_Release_UnsuccessfulTearDown_Works(t *testing.T) {
-	// func (release *Release) UnsuccessfulTearDown(asgc aws.ASGAPI, cwc aws.CWAPI) error {
+	// func (release *Release) UnsuccessfulTearDown(asgc aws.ASGAPI, cwc aws.CWAPI, ec2c aws.EC2API) error {
 	r := MockRelease(t)
 	MockPrepareRelease(r)
 
 	awsc := MockAwsClients(r)
-	assert.NoError(t, r.UnsuccessfulTearDown(awsc.ASG, awsc.CW))
+	assert.NoError(t, r.UnsuccessfulTearDown(awsc.ASG, awsc.CW, awsc.EC2))
+}
+
+func Test_Release_SuccessfulTearDown_NotOwned(t *testing.T) {
+	r := MockRelease(t)
+	MockPrepareRelease(r)
+
+	awsc := MockAwsClients(r)
+	awsc.ASG.AddASG(mocks.MakeMockASG("hand-made", *r.ProjectName, *r.ConfigName, "worker", ""))
+
+	// Refused before anything is deployed, and when cleaning up
+	_, err := r.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS)
+	assert.Error(t, err)
+
+	assert.Error(t, r.SuccessfulTearDown(awsc.ASG, awsc.ELB, awsc.ALB, awsc.CW, awsc.EC2))
+	assert.Equal(t, 0, len(awsc.ASG.DeletedASGs))
+
+	r.ForceAdopt = to.Boolp(true)
+	assert.NoError(t, r.SuccessfulTearDown(awsc.ASG, awsc.ELB, awsc.ALB, awsc.CW, awsc.EC2))
+	assert.Contains(t, awsc.ASG.DeletedASGs, "hand-made")
 }
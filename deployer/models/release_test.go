@@ -28,15 +28,48 @@ func Test_SetDefaults_Sets_WaitForHealthy(t *testing.T) {
 	r := MockRelease(t)
 	MockPrepareRelease(r)
 
-	assert.Equal(t, 15, *r.WaitForHealthy)
+	assert.Equal(t, 5, *r.WaitForHealthy)
+	assert.Equal(t, 15, r.maxWaitForHealthy())
 
 	r = MockRelease(t)
 	r.Timeout = to.Intp(3600)
 	MockPrepareRelease(r)
-	assert.Equal(t, 60, *r.WaitForHealthy)
+	assert.Equal(t, 5, *r.WaitForHealthy)
+	assert.Equal(t, 60, r.maxWaitForHealthy())
 
 	r = MockRelease(t)
 	r.Timeout = to.Intp(8000)
 	MockPrepareRelease(r)
-	assert.Equal(t, 120, *r.WaitForHealthy)
+	assert.Equal(t, 120, r.maxWaitForHealthy())
+
+	// The backed off wait is kept between states
+	r.WaitForHealthy = to.Intp(80)
+	r.SetDefaults()
+	assert.Equal(t, 80, *r.WaitForHealthy)
+
+	r.WaitForHealthy = to.Intp(600)
+	r.SetDefaults()
+	assert.Equal(t, 5, *r.WaitForHealthy)
+}
+
+func Test_Release_UpdateWaitForHealthy(t *testing.T) {
+	r := MockRelease(t)
+	r.Timeout = to.Intp(3600)
+	MockPrepareRelease(r)
+
+	activity := r.HealthActivity()
+
+	// Back off while nothing changes
+	r.UpdateWaitForHealthy(activity)
+	assert.Equal(t, 10, *r.WaitForHealthy)
+	r.UpdateWaitForHealthy(activity)
+	assert.Equal(t, 20, *r.WaitForHealthy)
+	r.UpdateWaitForHealthy(activity)
+	r.UpdateWaitForHealthy(activity)
+	assert.Equal(t, 60, *r.WaitForHealthy)
+
+	// Activity checks again soon
+	r.Services["web"].HealthReport = &HealthReport{Healthy: to.Intp(1), Launching: to.Intp(2)}
+	r.UpdateWaitForHealthy(activity)
+	assert.Equal(t, 5, *r.WaitForHealthy)
 }
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Retention limits the release records kept in the bucket for each project config
+// A release is pruned if it is older than Days or not one of the last Releases, the deployed release is always kept
+type Retention struct {
+	Days     int `json:"days,omitempty"`
+	Releases int `json:"releases,omitempty"`
+}
+
+// ParseRetention parses the retention, an empty string keeps every release
+func ParseRetention(raw string) (*Retention, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var retention Retention
+	if err := json.Unmarshal([]byte(raw), &retention); err != nil {
+		return nil, fmt.Errorf("Retention invalid %v", err.Error())
+	}
+
+	if retention.Days < 0 || retention.Releases < 0 {
+		return nil, fmt.Errorf("Retention days and releases must be 0 or greater")
+	}
+
+	if retention.Days == 0 && retention.Releases == 0 {
+		return nil, fmt.Errorf("Retention requires days or releases")
+	}
+
+	return &retention, nil
+}
+
+// releaseRecords are the keys in a release directory and when it was last written
+type releaseRecords struct {
+	ReleaseID    string
+	Keys         []*string
+	LastModified time.Time
+}
+
+// PruneReleases deletes the release directories of the project config the retention does not keep,
+// returning the pruned release IDs
+// Files in the project configs root, like its lock and breaker, are never pruned
+func (release *Release) PruneReleases(s3c aws.S3API, retention *Retention, now time.Time) ([]string, error) {
+	records, err := release.listReleaseRecords(s3c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Newest first
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].LastModified.After(records[j].LastModified)
+	})
+
+	pruned := []string{}
+	keys := []*string{}
+	for i, r := range records {
+		if r.ReleaseID == to.Strs(release.ReleaseID) || !retention.prunes(i, r.LastModified, now) {
+			continue
+		}
+
+		pruned = append(pruned, r.ReleaseID)
+		keys = append(keys, r.Keys...)
+	}
+
+	if err := release.Store(s3c).DeleteAll(keys); err != nil {
+		return nil, err
+	}
+
+	return pruned, nil
+}
+
+// prunes returns whether the retention prunes the release at index of the newest releases
+func (retention *Retention) prunes(index int, lastModified time.Time, now time.Time) bool {
+	if retention.Releases > 0 && index >= retention.Releases {
+		return true
+	}
+
+	return retention.Days > 0 && now.Sub(lastModified) > time.Duration(retention.Days)*24*time.Hour
+}
+
+// listReleaseRecords groups the paths under the project configs root by release directory
+func (release *Release) listReleaseRecords(s3c aws.S3API) ([]*releaseRecords, error) {
+	root := *release.RootDir() + "/"
+	byRelease := map[string]*releaseRecords{}
+
+	artifacts, err := release.Store(s3c).List(to.Strp(root))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, artifact := range artifacts {
+		parts := strings.SplitN(strings.TrimPrefix(*artifact.Path, root), "/", 2)
+		if len(parts) != 2 {
+			continue // Not in a release directory
+		}
+
+		r := byRelease[parts[0]]
+		if r == nil {
+			r = &releaseRecords{ReleaseID: parts[0]}
+			byRelease[parts[0]] = r
+		}
+
+		r.Keys = append(r.Keys, artifact.Path)
+		if artifact.LastModified.After(r.LastModified) {
+			r.LastModified = artifact.LastModified
+		}
+	}
+
+	records := []*releaseRecords{}
+	for _, r := range byRelease {
+		records = append(records, r)
+	}
+
+	return records, nil
+}
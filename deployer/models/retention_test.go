@@ -0,0 +1,88 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+// retentionS3 lists the added keys and records deletes
+type retentionS3 struct {
+	*mocks.MockS3Client
+	objects []*awss3.Object
+	deleted []string
+}
+
+func (m *retentionS3) add(key string, lastModified time.Time) {
+	m.objects = append(m.objects, &awss3.Object{Key: to.Strp(key), LastModified: to.Timep(lastModified)})
+}
+
+func (m *retentionS3) ListObjectsV2Pages(in *awss3.ListObjectsV2Input, fn func(*awss3.ListObjectsV2Output, bool) bool) error {
+	fn(&awss3.ListObjectsV2Output{Contents: m.objects}, true)
+	return nil
+}
+
+func (m *retentionS3) DeleteObjects(in *awss3.DeleteObjectsInput) (*awss3.DeleteObjectsOutput, error) {
+	for _, obj := range in.Delete.Objects {
+		m.deleted = append(m.deleted, *obj.Key)
+	}
+	return &awss3.DeleteObjectsOutput{}, nil
+}
+
+func Test_ParseRetention(t *testing.T) {
+	retention, err := ParseRetention("")
+	assert.NoError(t, err)
+	assert.Nil(t, retention)
+
+	retention, err = ParseRetention(`{"days": 90, "releases": 20}`)
+	assert.NoError(t, err)
+	assert.Equal(t, 90, retention.Days)
+	assert.Equal(t, 20, retention.Releases)
+
+	_, err = ParseRetention(`{}`)
+	assert.Error(t, err)
+
+	_, err = ParseRetention(`{"days": -1}`)
+	assert.Error(t, err)
+
+	_, err = ParseRetention(`90`)
+	assert.Error(t, err)
+}
+
+func Test_Release_PruneReleases(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	now := time.Now()
+	root := *release.RootDir()
+
+	s3c := &retentionS3{MockS3Client: &mocks.MockS3Client{}}
+	s3c.add(root+"/lock", now.Add(-100*24*time.Hour))
+	s3c.add(root+"/breaker", now.Add(-100*24*time.Hour))
+
+	// The deployed release is always kept
+	s3c.add(root+"/"+*release.ReleaseID+"/release", now.Add(-100*24*time.Hour))
+
+	s3c.add(root+"/new/release", now.Add(-time.Hour))
+	s3c.add(root+"/new/userdata", now.Add(-time.Hour))
+	s3c.add(root+"/recent/release", now.Add(-2*24*time.Hour))
+	s3c.add(root+"/older/release", now.Add(-3*24*time.Hour))
+	s3c.add(root+"/ancient/release", now.Add(-40*24*time.Hour))
+	s3c.add(root+"/ancient/userdata", now.Add(-40*24*time.Hour))
+
+	// Old releases are pruned
+	pruned, err := release.PruneReleases(s3c, &Retention{Days: 30}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ancient"}, pruned)
+	assert.ElementsMatch(t, []string{root + "/ancient/release", root + "/ancient/userdata"}, s3c.deleted)
+
+	// Releases beyond the last are pruned
+	s3c.deleted = nil
+	pruned, err = release.PruneReleases(s3c, &Retention{Releases: 2}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"older", "ancient"}, pruned)
+}
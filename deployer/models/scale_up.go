@@ -0,0 +1,95 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/asg"
+	"github.com/coinbase/step/utils/to"
+)
+
+// ScaleUpConfig launches the new ASG at a fraction of its capacity and steps it up while it is healthy
+type ScaleUpConfig struct {
+	Percentage *int64 `json:"percentage,omitempty"` // Of the target capacity added each step
+	Interval   *int   `json:"interval,omitempty"`   // Minimum seconds between steps
+}
+
+// SetDefaults assigns default values
+func (s *ScaleUpConfig) SetDefaults() {
+	if s.Interval == nil {
+		s.Interval = to.Intp(120)
+	}
+}
+
+// ValidateAttributes validates attributes
+func (s *ScaleUpConfig) ValidateAttributes() error {
+	if s.Percentage == nil || *s.Percentage < 1 || *s.Percentage > 100 {
+		return fmt.Errorf("ScaleUp percentage must be between 1 and 100")
+	}
+
+	if s.Interval == nil || *s.Interval < 0 {
+		return fmt.Errorf("ScaleUp interval must be 0 or greater")
+	}
+
+	return nil
+}
+
+// step is the number of instances added each step, at least one
+func (s *ScaleUpConfig) step(targetCapacity int) int {
+	return max(1, (targetCapacity*int(*s.Percentage)+99)/100)
+}
+
+// launchCapacity is the desired capacity of the ASG, which is less than the target while scaling up
+// or rolling out zones
+func (service *Service) launchCapacity() int {
+	target := service.zoneCapacity(service.targetCapacity())
+
+	scaleUp := service.Autoscaling.ScaleUp
+	if scaleUp == nil || scaleUp.Percentage == nil {
+		return target
+	}
+
+	if service.ScaledCapacity != nil {
+		return min(int(*service.ScaledCapacity), target)
+	}
+
+	return min(scaleUp.step(target), target)
+}
+
+// launchMinSize keeps the ASGs min size at or below its desired capacity while scaling up
+func (service *Service) launchMinSize() *int64 {
+	return to.Int64p(int64(min(service.Autoscaling.MinSizeInt(), service.launchCapacity())))
+}
+
+// scaledUp returns whether the ASG has reached its target capacity in the rolled out zones
+func (service *Service) scaledUp() bool {
+	return service.launchCapacity() >= service.zoneCapacity(service.targetCapacity())
+}
+
+// scaleUp steps up the desired capacity once the current step is healthy and the interval has passed
+func (service *Service) scaleUp(asgc aws.ASGAPI, healthy int, now time.Time) error {
+	if service.scaledUp() || healthy < service.launchCapacity() {
+		return nil
+	}
+
+	last := service.ScaledUpAt
+	if last == nil {
+		last = service.release.DeployStartedAt
+	}
+
+	if last != nil && now.Sub(*last) < time.Duration(*service.Autoscaling.ScaleUp.Interval)*time.Second {
+		return nil
+	}
+
+	target := service.targetCapacity()
+	capacity := min(service.launchCapacity()+service.Autoscaling.ScaleUp.step(target), target)
+
+	if err := asg.UpdateCapacity(asgc, service.CreatedASG, int64(min(service.Autoscaling.MinSizeInt(), capacity)), int64(capacity)); err != nil {
+		return err
+	}
+
+	service.ScaledCapacity = to.Int64p(int64(capacity))
+	service.ScaledUpAt = &now
+	return nil
+}
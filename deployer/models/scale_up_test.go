@@ -0,0 +1,74 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ScaleUpConfig_ValidateAttributes(t *testing.T) {
+	s := &ScaleUpConfig{}
+	s.SetDefaults()
+	assert.Error(t, s.ValidateAttributes())
+
+	s.Percentage = to.Int64p(25)
+	assert.NoError(t, s.ValidateAttributes())
+	assert.Equal(t, 120, *s.Interval)
+
+	s.Percentage = to.Int64p(101)
+	assert.Error(t, s.ValidateAttributes())
+}
+
+func Test_Service_ScaleUp(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	service := release.Services["web"]
+	service.Autoscaling.MinSize = to.Int64p(8)
+	service.Autoscaling.MaxSize = to.Int64p(8)
+	service.Autoscaling.ScaleUp = &ScaleUpConfig{Percentage: to.Int64p(25), Interval: to.Intp(120)}
+	service.Autoscaling.ScaleUp.SetDefaults()
+	service.CreatedASG = service.ServiceID()
+
+	start := time.Now()
+	release.DeployStartedAt = &start
+
+	// The ASG starts with the first step
+	input := service.createInput()
+	assert.Equal(t, int64(2), *input.DesiredCapacity)
+	assert.Equal(t, int64(2), *input.MinSize)
+
+	asgc := &mocks.ASGClient{}
+
+	// Not until the step is healthy
+	assert.NoError(t, service.scaleUp(asgc, 1, start.Add(time.Hour)))
+	assert.Equal(t, 0, len(asgc.UpdateAutoScalingGroupInputs))
+
+	// Not until the interval has passed
+	assert.NoError(t, service.scaleUp(asgc, 2, start.Add(time.Minute)))
+	assert.Equal(t, 0, len(asgc.UpdateAutoScalingGroupInputs))
+
+	assert.NoError(t, service.scaleUp(asgc, 2, start.Add(3*time.Minute)))
+	assert.Equal(t, int64(4), *asgc.UpdateAutoScalingGroupInputs[0].DesiredCapacity)
+	assert.False(t, service.scaledUp())
+
+	service.setHealthy(nil)
+	assert.False(t, service.Healthy)
+
+	// Launching is measured against the current step, not the final capacity
+	assert.Equal(t, 4, *service.HealthReport.TargetLaunched)
+
+	now := start.Add(6 * time.Minute)
+	assert.NoError(t, service.scaleUp(asgc, 4, now))
+	assert.NoError(t, service.scaleUp(asgc, 6, now.Add(3*time.Minute)))
+	assert.Equal(t, int64(8), *service.ScaledCapacity)
+	assert.Equal(t, int64(8), *asgc.UpdateAutoScalingGroupInputs[2].MinSize)
+	assert.True(t, service.scaledUp())
+
+	// Done scaling
+	assert.NoError(t, service.scaleUp(asgc, 8, now.Add(time.Hour)))
+	assert.Equal(t, 3, len(asgc.UpdateAutoScalingGroupInputs))
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ReleaseSchema returns a JSON Schema of the release file, generated from the Release so it cannot drift from the parser
+// Like the parser it rejects unknown keys, and "subnets" is either a list of subnets or a subnet tag
+func ReleaseSchema() map[string]interface{} {
+	schema := typeSchema(reflect.TypeOf(XRelease{}), map[reflect.Type]bool{})
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "Odin Release"
+
+	properties := schema["properties"].(map[string]interface{})
+	properties["Task"] = map[string]interface{}{"type": "string"} // Allowed by the parser, see XReleaseExceptions
+	properties["subnets"] = map[string]interface{}{
+		"oneOf": []interface{}{
+			properties["subnets"],
+			properties["subnet_tag"],
+		},
+	}
+
+	return schema
+}
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// typeSchema returns the schema of the values encoding/json decodes into t
+func typeSchema(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case reflect.PtrTo(t).Implements(unmarshalerType) || visiting[t]:
+		return map[string]interface{}{} // Custom or recursive types can be anything
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), visiting)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem(), visiting)}
+	case reflect.Struct:
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		properties := map[string]interface{}{}
+		structProperties(t, properties, visiting)
+		return map[string]interface{}{"type": "object", "properties": properties, "additionalProperties": false}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structProperties adds the fields of t, and the fields of its embedded structs, by their JSON names
+func structProperties(t reflect.Type, properties map[string]interface{}, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				structProperties(embedded, properties, visiting)
+				continue
+			}
+		}
+
+		if field.PkgPath != "" {
+			continue // Unexported
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = typeSchema(field.Type, visiting)
+	}
+}
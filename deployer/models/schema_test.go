@@ -0,0 +1,27 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ReleaseSchema(t *testing.T) {
+	schema := ReleaseSchema()
+	assert.Equal(t, false, schema["additionalProperties"])
+
+	properties := schema["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "string"}, properties["project_name"])
+	assert.Equal(t, map[string]interface{}{"type": "string"}, properties["ami"])
+	assert.NotNil(t, properties["subnets"].(map[string]interface{})["oneOf"])
+	assert.Nil(t, properties["userdata"])
+
+	services := properties["services"].(map[string]interface{})
+	service := services["additionalProperties"].(map[string]interface{})
+	assert.Equal(t, false, service["additionalProperties"])
+	assert.Equal(t, map[string]interface{}{"type": "string"}, service["properties"].(map[string]interface{})["instance_type"])
+
+	_, err := json.Marshal(schema)
+	assert.NoError(t, err)
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -10,9 +11,13 @@ import (
 	"github.com/coinbase/odin/aws"
 	"github.com/coinbase/odin/aws/alb"
 	"github.com/coinbase/odin/aws/asg"
+	"github.com/coinbase/odin/aws/ecr"
 	"github.com/coinbase/odin/aws/elb"
+	"github.com/coinbase/odin/aws/fleet"
 	"github.com/coinbase/odin/aws/iam"
 	"github.com/coinbase/odin/aws/lc"
+	"github.com/coinbase/odin/aws/lt"
+	"github.com/coinbase/odin/aws/pg"
 	"github.com/coinbase/odin/aws/sg"
 	"github.com/coinbase/step/utils/is"
 	"github.com/coinbase/step/utils/to"
@@ -28,6 +33,7 @@ type HealthReport struct {
 	Launching      *int     `json:"launching,omitempty"`       // Number of instances that have been created
 	Terminating    *int     `json:"terminating,omitempty"`     // Number of instances that are Terminating
 	TerminatingIDs []string `json:"terminating_ids,omitempty"` // Instance IDs that are Terminating
+	InstanceIDs    []string `json:"instance_ids,omitempty"`    // Instance IDs that have been created
 }
 
 // TYPES
@@ -47,29 +53,98 @@ type Service struct {
 	SecurityGroups []*string          `json:"security_groups,omitempty"`
 	Tags           map[string]*string `json:"tags,omitempty"`
 
+	// ProfileTemplate creates the services role and instance profile from a reviewed policy template
+	ProfileTemplate *string `json:"profile_template,omitempty"`
+
+	// ProfileRoleArn creates or updates the services instance profile to contain an existing role
+	ProfileRoleArn *string `json:"profile_role_arn,omitempty"`
+
+	// ListenerRule is cutover between blue and green target groups on a shared ALB
+	ListenerRule *ListenerRuleConfig `json:"listener_rule,omitempty"`
+
+	// LoadBalancerAttributes are asserted on the ELBs, target groups and their ALBs
+	LoadBalancerAttributes *LoadBalancerAttributesConfig `json:"load_balancer_attributes,omitempty"`
+
+	// SlowStart is the seconds the target groups ramp newly attached instances up to their share of requests
+	SlowStart *int64 `json:"slow_start,omitempty"`
+
+	// TerminationHook lets old instances finish their work before a deploy deletes their ASG
+	TerminationHook *TerminationHookConfig `json:"termination_hook,omitempty"`
+
+	// AZRollout brings the new ASG up one availability zone at a time
+	AZRollout *AZRolloutConfig `json:"az_rollout,omitempty"`
+
+	// MetricHealth checks metric math expressions on the healthy service before it is cut over
+	MetricHealth *MetricHealthConfig `json:"metric_health,omitempty"`
+
+	// Mesh is cutover between blue and green virtual nodes of an App Mesh route or SSM weights parameter
+	Mesh *MeshConfig `json:"mesh,omitempty"`
+
+	// DependsOn are the services that must be healthy before this service is deployed
+	DependsOn []*string `json:"depends_on,omitempty"`
+
+	// DrainTimeout is the most seconds to wait for the old ASGs instances to drain from its load balancers
+	DrainTimeout *int `json:"drain_timeout,omitempty"`
+
 	// Create Resources
 	InstanceType *string            `json:"instance_type,omitempty"`
 	Autoscaling  *AutoScalingConfig `json:"autoscaling,omitempty"`
 	SpotPrice    *string            `json:"spot_price,omitempty"`
 
+	// InstanceTypeFallbacks are used in order if EC2 has insufficient capacity for the instance type
+	InstanceTypeFallbacks []*string `json:"instance_type_fallbacks,omitempty"`
+
 	// EBS
 	EBSVolumeSize *int64  `json:"ebs_volume_size,omitempty"`
 	EBSVolumeType *string `json:"ebs_volume_type,omitempty"`
 	EBSDeviceName *string `json:"ebs_device_name,omitempty"`
+	EBSEncrypted  *bool   `json:"ebs_encrypted,omitempty"`
 
 	// Network
+	// AssociatePublicIpAddress defaults to false instead of following the subnets default
 	AssociatePublicIpAddress *bool `json:"associate_public_ip_address,omitempty"`
 
+	// IMDSv2 requires instances to use session tokens to access the instance metadata service
+	IMDSv2 *bool `json:"imdsv2,omitempty"`
+
+	// NetworkInterface adds IPv6 and secondary private IPs to the instances
+	NetworkInterface *NetworkInterfaceConfig `json:"network_interface,omitempty"`
+
+	// Placement
+	Placement *PlacementConfig `json:"placement,omitempty"`
+
+	// HealthCheck is checked in addition to the ASG, ELB and target group health
+	HealthCheck *HealthCheckConfig `json:"healthcheck,omitempty"`
+
+	// CapacityReservation reserves On-Demand capacity for the new fleet until the deploy completes
+	CapacityReservation *bool `json:"capacity_reservation,omitempty"`
+
+	// Containers are ECR images the instance profile must be able to pull
+	Containers        []*string `json:"containers,omitempty"`
+	PrePullContainers *bool     `json:"pre_pull_containers,omitempty"`
+
 	// Found Resources
 	Resources *ServiceResourceNames `json:"resources,omitempty"`
 
 	// Created Resources
-	CreatedASG              *string `json:"created_asg,omitempty"`
-	PreviousDesiredCapacity *int64  `json:"previous_desired_capacity,omitempty"`
+	CapacityReservationIDs  []*string  `json:"capacity_reservation_ids,omitempty"`
+	InstanceTypeFallbackAt  *time.Time `json:"instance_type_fallback_at,omitempty"`
+	CreatedASG              *string    `json:"created_asg,omitempty"`
+	PreviousDesiredCapacity *int64     `json:"previous_desired_capacity,omitempty"`
+	ScaledCapacity          *int64     `json:"scaled_capacity,omitempty"`
+	ScaledUpAt              *time.Time `json:"scaled_up_at,omitempty"`
 
 	// What is Healthy
 	HealthReport *HealthReport `json:"healthy_report,omitempty"`
 	Healthy      bool
+
+	// Halted services had their new ASG deleted and keep their old ASG, the other services continue
+	Halted  *bool   `json:"halted,omitempty"`
+	KeptASG *string `json:"kept_asg,omitempty"`
+	Status  *string `json:"status,omitempty"` // deployed or halted, recorded after success
+
+	// What was launched, recorded after success
+	Composition *FleetComposition `json:"composition,omitempty"`
 }
 
 //////////
@@ -116,6 +191,18 @@ func (service *Service) ServiceID() *string {
 	return to.Strp(fmt.Sprintf("%v-%v-%v-%v", *service.ProjectName(), *service.ConfigName(), tf, *service.ServiceName))
 }
 
+// ServiceIDs returns the IDs of the releases services, which name the launch configurations and templates it created
+func (release *Release) ServiceIDs() []string {
+	ids := []string{}
+	for name := range release.Services {
+		if id := (&Service{release: release, ServiceName: to.Strp(name)}).ServiceID(); id != nil {
+			ids = append(ids, *id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
 // Subnets returns subnets
 func (service *Service) Subnets() []*string {
 	return service.release.Subnets
@@ -128,12 +215,31 @@ func (service *Service) UserData() *string {
 	templateARGs = append(templateARGs, "{{PROJECT_NAME}}", to.Strs(service.ProjectName()))
 	templateARGs = append(templateARGs, "{{CONFIG_NAME}}", to.Strs(service.ConfigName()))
 	templateARGs = append(templateARGs, "{{SERVICE_NAME}}", to.Strs(service.ServiceName))
+	templateARGs = append(templateARGs, "{{PRE_PULL_CONTAINERS}}", service.prePullScript())
 
 	replacer := strings.NewReplacer(templateARGs...)
 
 	return to.Strp(replacer.Replace(to.Strs(service.userdata)))
 }
 
+// prePullScript returns the commands to pull all containers if pre_pull_containers is set
+func (service *Service) prePullScript() string {
+	if service.PrePullContainers == nil || !*service.PrePullContainers {
+		return ""
+	}
+
+	script := ""
+	for _, container := range service.Containers {
+		im, err := ecr.Parse(container)
+		if err != nil {
+			continue // Validated elsewhere
+		}
+		script += im.PrePullScript()
+	}
+
+	return script
+}
+
 // SetUserData sets the userdata
 func (service *Service) SetUserData(userdata *string) {
 	service.userdata = userdata
@@ -155,9 +261,26 @@ func (service *Service) LifeCycleHookSpecs() []*autoscaling.LifecycleHookSpecifi
 	for _, lc := range service.LifeCycleHooks() {
 		lcs = append(lcs, lc.ToLifecycleHookSpecification())
 	}
+
+	if service.TerminationHook != nil {
+		lcs = append(lcs, service.TerminationHook.ToLifecycleHookSpecification())
+	}
 	return lcs
 }
 
+// PlacementGroupName returns the name of the services placement group
+func (service *Service) PlacementGroupName() *string {
+	if service.Placement == nil {
+		return nil
+	}
+
+	if service.Placement.CreateGroup() {
+		return pg.SafeName(service.ServiceID())
+	}
+
+	return service.Placement.GroupName
+}
+
 func (service *Service) targetCapacity() int {
 	return service.Autoscaling.TargetCapacity(service.PreviousDesiredCapacity)
 }
@@ -199,25 +322,66 @@ func (service *Service) SetDefaults(release *Release, serviceName string) {
 	}
 
 	service.Autoscaling.SetDefaults(service.ServiceID(), service.release.Timeout)
+
+	if service.AssociatePublicIpAddress == nil {
+		service.AssociatePublicIpAddress = to.Boolp(false)
+	}
+
+	if service.HealthCheck != nil {
+		service.HealthCheck.SetDefaults()
+	}
+
+	if service.LoadBalancerAttributes != nil {
+		service.LoadBalancerAttributes.SetDefaults()
+	}
+
+	if service.ListenerRule != nil && service.ListenerRule.Shift != nil {
+		service.ListenerRule.Shift.SetDefaults()
+	}
+
+	if service.Mesh != nil {
+		service.Mesh.SetDefaults()
+	}
+
+	if service.AZRollout != nil {
+		service.AZRollout.SetDefaults()
+	}
+
+	if service.MetricHealth != nil {
+		service.MetricHealth.SetDefaults()
+	}
+
+	if service.TerminationHook != nil {
+		service.TerminationHook.SetDefaults(release.AwsRegion, release.AwsAccountID)
+	}
+
+	if preset := service.release.HardeningPreset(); preset != nil {
+		preset.SetDefaults(service)
+	}
 }
 
 // setHealthy sets the health state from the instances
 func (service *Service) setHealthy(instances aws.Instances) {
 	healthy := instances.HealthyIDs()
 	terming := instances.TerminatingIDs()
+	ids := instances.InstanceIDs()
+	sort.Strings(ids)
+	target := service.zoneCapacity(service.target())
 
 	service.HealthReport = &HealthReport{
-		TargetHealthy:  to.Intp(service.target()),
-		TargetLaunched: to.Intp(service.targetCapacity()),
+		TargetHealthy:  to.Intp(target),
+		TargetLaunched: to.Intp(service.launchCapacity()), // The current step while scaling up
 		Healthy:        to.Intp(len(healthy)),
 		Terminating:    to.Intp(len(terming)),
 		TerminatingIDs: terming,
+		InstanceIDs:    ids,
 		Launching:      to.Intp(len(instances)),
 	}
 
 	// The Service is Healthy if
-	// the number of instances that are healthy is greater than or equal to the target
-	service.Healthy = len(healthy) >= service.target()
+	// the number of instances that are healthy is greater than or equal to the target in the rolled out zones
+	// and it has finished scaling up
+	service.Healthy = len(healthy) >= target && service.scaledUp()
 }
 
 //////////
@@ -241,6 +405,16 @@ func (service *Service) Validate() error {
 		}
 	}
 
+	if service.TerminationHook != nil {
+		if _, ok := service.LifeCycleHooks()[terminationHookName]; ok {
+			return fmt.Errorf("%v LifeCycle %v is reserved for the termination_hook", service.errorPrefix(), terminationHookName)
+		}
+
+		if err := service.TerminationHook.ValidateAttributes(); err != nil {
+			return fmt.Errorf("%v %v", service.errorPrefix(), err.Error())
+		}
+	}
+
 	// VALIDATE Autoscaling Group Input (this in implemented by AWS)
 	if err := service.createInput().Validate(); err != nil {
 		return fmt.Errorf("%v %v", service.errorPrefix(), err.Error())
@@ -271,6 +445,10 @@ func (service *Service) ValidateAttributes() error {
 		return err
 	}
 
+	if service.Autoscaling.capacityRebalance() && service.SpotPrice == nil {
+		return fmt.Errorf("CapacityRebalance requires spot_price")
+	}
+
 	// Must have security groups
 	if len(service.SecurityGroups) < 1 {
 		return fmt.Errorf("Security Groups must be included")
@@ -290,6 +468,120 @@ func (service *Service) ValidateAttributes() error {
 		return fmt.Errorf("Non Unique TargetGroups")
 	}
 
+	if !is.UniqueStrp(service.InstanceTypeFallbacks) {
+		return fmt.Errorf("Non Unique InstanceTypeFallbacks")
+	}
+
+	if err := validateTags(service.Tags); err != nil {
+		return err
+	}
+
+	if err := service.validateContainers(); err != nil {
+		return err
+	}
+
+	if err := service.validateProfileTemplate(); err != nil {
+		return err
+	}
+
+	if err := service.validateProfileRoleArn(); err != nil {
+		return err
+	}
+
+	if err := service.validateNetworkInterface(); err != nil {
+		return err
+	}
+
+	if err := service.validateLaunchTemplate(); err != nil {
+		return err
+	}
+
+	if err := service.validateAZRollout(); err != nil {
+		return err
+	}
+
+	if service.MetricHealth != nil {
+		if err := service.MetricHealth.ValidateAttributes(service); err != nil {
+			return err
+		}
+	}
+
+	if service.Mesh != nil {
+		if err := service.Mesh.ValidateAttributes(); err != nil {
+			return err
+		}
+	}
+
+	if service.DrainTimeout != nil && (*service.DrainTimeout < 0 || *service.DrainTimeout > maxDrainTimeout) {
+		return fmt.Errorf("DrainTimeout must be between 0 and %v", maxDrainTimeout)
+	}
+
+	if service.Placement != nil {
+		if err := service.Placement.ValidateAttributes(); err != nil {
+			return err
+		}
+	}
+
+	if err := service.validateCapacityReservation(); err != nil {
+		return err
+	}
+
+	if service.HealthCheck != nil {
+		if err := service.HealthCheck.ValidateAttributes(); err != nil {
+			return err
+		}
+	}
+
+	if service.LoadBalancerAttributes != nil {
+		if err := service.LoadBalancerAttributes.ValidateAttributes(); err != nil {
+			return err
+		}
+	}
+
+	if service.ListenerRule != nil {
+		if err := service.ListenerRule.ValidateAttributes(service); err != nil {
+			return err
+		}
+	}
+
+	if err := service.validateSlowStart(); err != nil {
+		return err
+	}
+
+	if preset := service.release.HardeningPreset(); preset != nil {
+		if err := preset.Validate(service, service.release.UserData()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (service *Service) validateContainers() error {
+	if !is.UniqueStrp(service.Containers) {
+		return fmt.Errorf("Non Unique Containers")
+	}
+
+	for _, container := range service.Containers {
+		if _, err := ecr.Parse(container); err != nil {
+			return err
+		}
+	}
+
+	if len(service.Containers) > 0 && service.Profile == nil {
+		return fmt.Errorf("Containers require a Profile to pull with")
+	}
+
+	if service.PrePullContainers != nil && *service.PrePullContainers {
+		if len(service.Containers) == 0 {
+			return fmt.Errorf("PrePullContainers requires Containers")
+		}
+
+		if !strings.Contains(to.Strs(service.release.UserData()), "{{PRE_PULL_CONTAINERS}}") {
+			return fmt.Errorf("PrePullContainers requires {{PRE_PULL_CONTAINERS}} in the UserData")
+		}
+	}
+
 	return nil
 }
 
@@ -301,7 +593,7 @@ func (service *Service) ValidateAttributes() error {
 func (service *Service) FetchResources(ec2 aws.EC2API, elbc aws.ELBAPI, albc aws.ALBAPI, iamc aws.IAMAPI) (*ServiceResources, error) {
 	// RESOURCES THAT ARE PROJECT-CONFIG-SERVICE specific
 	// Fetch Security Group
-	sgs, err := sg.Find(ec2, service.SecurityGroups)
+	sgs, err := sg.Find(ec2, service.SecurityGroups, service.release.vpcID)
 	if err != nil {
 		return nil, err
 	}
@@ -327,23 +619,98 @@ func (service *Service) FetchResources(ec2 aws.EC2API, elbc aws.ELBAPI, albc aws
 		}
 	}
 
+	if err := service.validateContainerPull(iamc, iamProfile); err != nil {
+		return nil, err
+	}
+
+	// Fetch Placement Group
+	if service.Placement != nil {
+		if err := service.Placement.FetchResources(ec2); err != nil {
+			return nil, err
+		}
+	}
+
+	// Fetch Listener Rule
+	if service.ListenerRule != nil {
+		if err := service.ListenerRule.FetchResources(albc, service); err != nil {
+			return nil, err
+		}
+	}
+
+	// Fetch Instance Type Architectures
+	architectures := map[string][]string{}
+	for _, instanceType := range append([]*string{service.InstanceType}, service.InstanceTypeFallbacks...) {
+		if instanceType == nil {
+			continue
+		}
+
+		supported, err := lc.Architectures(ec2, instanceType)
+		if err != nil {
+			return nil, err
+		}
+		architectures[*instanceType] = supported
+	}
+
 	return &ServiceResources{
 		SecurityGroups: sgs,
 		ELBs:           elbs,
 		TargetGroups:   targetGroups,
 		Profile:        iamProfile,
+		Architectures:  architectures,
 	}, nil
 }
 
+// validateContainerPull simulates the profiles roles pulling all containers from ECR
+func (service *Service) validateContainerPull(iamc aws.IAMAPI, profile *iam.Profile) error {
+	if len(service.Containers) == 0 {
+		return nil
+	}
+
+	if profile == nil || len(profile.RoleArns) == 0 {
+		return fmt.Errorf("%v Containers require a Profile with a Role", service.errorPrefix())
+	}
+
+	repositoryArns := []*string{}
+	for _, container := range service.Containers {
+		im, err := ecr.Parse(container)
+		if err != nil {
+			return err
+		}
+		repositoryArns = append(repositoryArns, im.RepositoryArn())
+	}
+
+	for _, roleArn := range profile.RoleArns {
+		if err := iam.SimulateAllowed(iamc, roleArn, ecr.AuthActions, []*string{to.Strp("*")}); err != nil {
+			return fmt.Errorf("%v %v", service.errorPrefix(), err.Error())
+		}
+
+		if err := iam.SimulateAllowed(iamc, roleArn, ecr.PullActions, repositoryArns); err != nil {
+			return fmt.Errorf("%v %v", service.errorPrefix(), err.Error())
+		}
+	}
+
+	return nil
+}
+
 //////////
 // Create Resources
 //////////
 
 // CreateResources creates the ASG and Launch configuration for the service
-func (service *Service) CreateResources(asgc aws.ASGAPI, cwc aws.CWAPI) error {
+func (service *Service) CreateResources(asgc aws.ASGAPI, cwc aws.CWAPI, ec2c aws.EC2API) error {
+	if err := service.createPlacementGroup(ec2c); err != nil {
+		return err
+	}
 
-	err := service.createLaunchConfiguration(asgc)
-	if err != nil {
+	if err := service.reserveCapacity(ec2c); err != nil {
+		return err
+	}
+
+	if service.usesLaunchTemplate() {
+		if err := service.createLaunchTemplate(ec2c); err != nil {
+			return err
+		}
+	} else if err := service.createLaunchConfiguration(asgc); err != nil {
 		return err
 	}
 
@@ -355,6 +722,10 @@ func (service *Service) CreateResources(asgc aws.ASGAPI, cwc aws.CWAPI) error {
 
 	service.CreatedASG = createdASG.AutoScalingGroupName
 
+	if err := service.enableCapacityRebalance(asgc); err != nil {
+		return err
+	}
+
 	if err := service.createAutoScalingPolicies(asgc, cwc); err != nil {
 		return err
 	}
@@ -367,23 +738,32 @@ func (service *Service) createInput() *asg.Input {
 	input := &asg.Input{&autoscaling.CreateAutoScalingGroupInput{}}
 
 	input.AutoScalingGroupName = service.ServiceID()
-	input.LaunchConfigurationName = service.ServiceID()
+	if service.usesLaunchTemplate() {
+		input.LaunchTemplate = lt.Specification(service.ServiceID())
+	} else {
+		input.LaunchConfigurationName = service.launchConfigurationName()
+	}
 
-	input.MinSize = service.Autoscaling.MinSize
+	input.MinSize = service.launchMinSize()
 	input.MaxSize = service.Autoscaling.MaxSize
 
 	input.DefaultCooldown = service.Autoscaling.DefaultCooldown
 	input.HealthCheckGracePeriod = service.Autoscaling.HealthCheckGracePeriod
 
-	input.DesiredCapacity = to.Int64p(int64(service.targetCapacity()))
+	input.DesiredCapacity = to.Int64p(int64(service.launchCapacity()))
+
+	if service.Autoscaling.protectedFromScaleIn() {
+		input.NewInstancesProtectedFromScaleIn = to.Boolp(true)
+	}
 
 	input.LoadBalancerNames = service.Resources.ELBs
-	input.TargetGroupARNs = service.Resources.TargetGroups
+	input.TargetGroupARNs = service.targetGroupArns()
 
-	input.VPCZoneIdentifier = service.SubnetIds()
+	input.VPCZoneIdentifier = service.rolloutSubnetIds()
+	input.PlacementGroup = service.PlacementGroupName()
 	input.LifecycleHookSpecificationList = service.LifeCycleHookSpecs()
 
-	for key, value := range service.Tags {
+	for key, value := range service.customTags() {
 		input.AddTag(key, value)
 	}
 
@@ -394,6 +774,14 @@ func (service *Service) createInput() *asg.Input {
 	input.AddTag("ReleaseUUID", service.ReleaseUUID())
 	input.AddTag("Name", service.ServiceID())
 
+	if service.release.PreviousReleaseID != nil {
+		input.AddTag("PreviousReleaseID", service.release.PreviousReleaseID)
+	}
+
+	if node := service.virtualNode(); node != nil {
+		input.AddTag("VirtualNode", node)
+	}
+
 	input.SetDefaults()
 
 	return input
@@ -423,7 +811,7 @@ func (service *Service) createLaunchConfigurationInput() *lc.LaunchConfigInput {
 	input := &lc.LaunchConfigInput{&autoscaling.CreateLaunchConfigurationInput{}}
 	input.SetDefaults()
 
-	input.LaunchConfigurationName = service.ServiceID()
+	input.LaunchConfigurationName = service.launchConfigurationName()
 
 	if service.Resources != nil {
 		input.ImageId = service.Resources.Image
@@ -434,15 +822,27 @@ func (service *Service) createLaunchConfigurationInput() *lc.LaunchConfigInput {
 
 	input.AssociatePublicIpAddress = service.AssociatePublicIpAddress
 
+	if service.Placement != nil {
+		input.PlacementTenancy = service.Placement.Tenancy
+	}
+
 	input.UserData = to.Base64p(service.UserData())
 
-	input.AddBlockDevice(service.EBSVolumeSize, service.EBSVolumeType, service.EBSDeviceName)
+	input.AddBlockDevice(service.EBSVolumeSize, service.EBSVolumeType, service.EBSDeviceName, service.EBSEncrypted)
 
 	input.SpotPrice = service.SpotPrice
 
 	return input
 }
 
+func (service *Service) createPlacementGroup(ec2c aws.EC2API) error {
+	if service.Placement == nil || !service.Placement.CreateGroup() {
+		return nil
+	}
+
+	return pg.Create(ec2c, service.PlacementGroupName(), service.Placement.Strategy, service.Placement.PartitionCount)
+}
+
 func (service *Service) createLaunchConfiguration(asgc autoscalingiface.AutoScalingAPI) error {
 	input := service.createLaunchConfigurationInput()
 
@@ -467,23 +867,42 @@ func (he *HaltError) Error() string {
 	return he.err.Error()
 }
 
+// ErrorCode returns the error code of the cause of the halt, or E_HALT
+func (he *HaltError) ErrorCode() string {
+	if code := errorCodeOf(he.err); code != "" {
+		return code
+	}
+	return ErrorCodeHalt
+}
+
 // UpdateHealthy updates the health status of the service
 // This might cause a Halt Error which will force the release to stop
-func (service *Service) UpdateHealthy(asgc aws.ASGAPI, elbc aws.ELBAPI, albc aws.ALBAPI) error {
+func (service *Service) UpdateHealthy(asgc aws.ASGAPI, elbc aws.ELBAPI, albc aws.ALBAPI, ec2c aws.EC2API, ssmc aws.SSMAPI) error {
+	if err := service.fallbackInstanceType(asgc); err != nil {
+		return err // This might retry
+	}
+
 	all, err := asg.GetInstances(asgc, service.CreatedASG)
 	if err != nil {
 		return err // This might retry
 	}
 
+	// Spot interruptions are not failures of the release
+	all, err = service.removeInterrupted(asgc, all)
+	if err != nil {
+		return err // This might retry
+	}
+
 	// Early exit and Halt if there are instances Terminating
 	if terming := all.TerminatingIDs(); len(terming) > service.maxTerminations() {
 		err := fmt.Errorf("Found terming instances %v, %v", *service.ServiceName, strings.Join(terming, ","))
 		return &HaltError{err} // This will immediately stop deploying
 	}
 
-	// Fetch All the instances
+	// Fetch All the instances, a batch at a time as large fleets are truncated otherwise
+	fl := fleet.New(all)
 	for _, checkELB := range service.Resources.ELBs {
-		elbInstances, err := elb.GetInstances(elbc, checkELB, all.InstanceIDs())
+		elbInstances, err := fl.ELBInstances(elbc, checkELB)
 		if err != nil {
 			return err // This might retry
 		}
@@ -491,8 +910,8 @@ func (service *Service) UpdateHealthy(asgc aws.ASGAPI, elbc aws.ELBAPI, albc aws
 		all = all.MergeInstances(elbInstances)
 	}
 
-	for _, checkTG := range service.Resources.TargetGroups {
-		tgInstances, err := alb.GetInstances(albc, checkTG, all.InstanceIDs())
+	for _, checkTG := range service.targetGroupArns() {
+		tgInstances, err := fl.TargetGroupInstances(albc, checkTG)
 
 		if err != nil {
 			return err // This might retry
@@ -501,6 +920,27 @@ func (service *Service) UpdateHealthy(asgc aws.ASGAPI, elbc aws.ELBAPI, albc aws
 		all = all.MergeInstances(tgInstances)
 	}
 
+	if service.HealthCheck != nil {
+		provider, err := service.HealthCheck.Provider(ec2c, ssmc)
+		if err != nil {
+			return err
+		}
+
+		// Only check instances that are otherwise healthy
+		checked, err := provider.Instances(all.HealthyIDs())
+		if err != nil {
+			return err // This might retry
+		}
+
+		for id, state := range checked {
+			all[id] = state
+		}
+	}
+
+	if err := service.scaleUp(asgc, len(all.HealthyIDs()), time.Now()); err != nil {
+		return err // This might retry
+	}
+
 	service.setHealthy(all)
 	return nil
 }
@@ -0,0 +1,178 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/alb"
+	"github.com/coinbase/odin/aws/asg"
+	"github.com/coinbase/odin/aws/lt"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Statuses of each service recorded when the release succeeds
+const (
+	ServiceDeployed = "deployed"
+	ServiceHalted   = "halted"
+)
+
+// ServiceHaltRecord is written to halt one service of the running release
+type ServiceHaltRecord struct {
+	Reason   *string    `json:"reason,omitempty"`
+	HaltedAt *time.Time `json:"halted_at,omitempty"`
+}
+
+func (release *Release) serviceHaltPath(serviceName string) *string {
+	s := fmt.Sprintf("%v/halt_service_%v", *release.ReleaseDir(), serviceName)
+	return &s
+}
+
+// HaltService halts one service of the running release, the other services continue to deploy
+func (release *Release) HaltService(s3c aws.S3API, serviceName string, reason *string) error {
+	if _, ok := release.Services[serviceName]; !ok {
+		return fmt.Errorf("%v service %q is not in the release", release.ErrorPrefix(), serviceName)
+	}
+
+	return PutArtifact(release.Store(s3c), release.serviceHaltPath(serviceName), &ServiceHaltRecord{
+		Reason:   reason,
+		HaltedAt: to.Timep(time.Now()),
+	})
+}
+
+func (release *Release) serviceHaltWritten(s3c aws.S3API, serviceName string) (bool, error) {
+	_, err := release.Store(s3c).Get(release.serviceHaltPath(serviceName))
+	if IsNotFound(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// IsHalted returns whether the service was halted, it keeps its old ASG
+func (service *Service) IsHalted() bool {
+	return service.Halted != nil && *service.Halted
+}
+
+// HaltServices tears down the new ASGs of services halted since the last check, leaving their old ASGs in place
+// Halting every service returns a HaltError, as the release would deploy nothing
+func (release *Release) HaltServices(s3c aws.S3API, asgc aws.ASGAPI, albc aws.ALBAPI, cwc aws.CWAPI, ec2c aws.EC2API) error {
+	halted := 0
+	for name, service := range release.Services {
+		if !service.IsHalted() {
+			written, err := release.serviceHaltWritten(s3c, name)
+			if err != nil {
+				return err
+			}
+
+			if written {
+				if err := release.haltService(name, service, asgc, albc, cwc, ec2c); err != nil {
+					return err
+				}
+			}
+		}
+
+		if service.IsHalted() {
+			halted++
+		}
+	}
+
+	if halted > 0 && halted == len(release.Services) {
+		return &HaltError{fmt.Errorf("%v every service was halted", release.ErrorPrefix())}
+	}
+
+	return nil
+}
+
+func (release *Release) haltService(name string, service *Service, asgc aws.ASGAPI, albc aws.ALBAPI, cwc aws.CWAPI, ec2c aws.EC2API) error {
+	// Send any shifted traffic back to the old target group before the new ASG is removed
+	if service.shifting() && service.ListenerRule.Shift.Step != nil {
+		l := service.ListenerRule
+		if err := alb.SetForwardTargetGroup(albc, l.FoundRuleArn, l.ActiveTargetGroupArn); err != nil {
+			return fmt.Errorf("%v %v", service.errorPrefix(), err.Error())
+		}
+	}
+
+	if service.CreatedASG != nil {
+		created, err := asg.ForNames(asgc, []*string{service.CreatedASG})
+		if err != nil {
+			return err
+		}
+
+		for _, a := range created {
+			if to.Strs(a.ReleaseID()) != to.Strs(release.ReleaseID) {
+				return fmt.Errorf("Bad ReleaseID")
+			}
+
+			if err := a.Teardown(asgc, cwc); err != nil {
+				return err
+			}
+
+			if a.LaunchTemplateName != nil {
+				if err := lt.Teardown(ec2c, a.LaunchTemplateName); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// The old ASG of the service is kept, so the next release replaces it
+	asgs, err := release.previousASGs(asgc)
+	if err != nil {
+		return err
+	}
+
+	prevASGs, err := release.previousASGServiceMap(asgs)
+	if err != nil {
+		return err
+	}
+
+	if prev, ok := prevASGs[name]; ok {
+		service.KeptASG = prev.AutoScalingGroupName
+	}
+
+	service.CreatedASG = nil
+	service.Healthy = true
+	service.Halted = to.Boolp(true)
+
+	return nil
+}
+
+// withoutKeptASGs removes the old ASGs kept by halted services
+func (release *Release) withoutKeptASGs(asgs []*asg.ASG) []*asg.ASG {
+	kept := map[string]bool{}
+	for _, service := range release.Services {
+		if service.IsHalted() && service.KeptASG != nil {
+			kept[*service.KeptASG] = true
+		}
+	}
+
+	remaining := []*asg.ASG{}
+	for _, a := range asgs {
+		if !kept[to.Strs(a.AutoScalingGroupName)] {
+			remaining = append(remaining, a)
+		}
+	}
+
+	return remaining
+}
+
+// UpdateServiceStatus records whether each service was deployed or halted,
+// the release partially succeeds if any service was halted
+func (release *Release) UpdateServiceStatus() {
+	partial := false
+	for _, service := range release.Services {
+		if service.IsHalted() {
+			service.Status = to.Strp(ServiceHalted)
+			partial = true
+		} else {
+			service.Status = to.Strp(ServiceDeployed)
+		}
+	}
+
+	release.PartialSuccess = to.Boolp(partial)
+}
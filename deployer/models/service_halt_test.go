@@ -0,0 +1,50 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_HaltServices(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	release.Services["worker"] = &Service{release: release, ServiceName: to.Strp("worker")}
+
+	awsc := MockAwsClients(release)
+	awsc.ASG.AddASG(mocks.MakeMockASG("new-web", *release.ProjectName, *release.ConfigName, "web", *release.ReleaseID))
+	release.Services["web"].CreatedASG = to.Strp("new-web")
+
+	// Nothing is halted without a halt record
+	assert.NoError(t, release.HaltServices(awsc.S3, awsc.ASG, awsc.ALB, awsc.CW, awsc.EC2))
+	assert.False(t, release.Services["web"].IsHalted())
+
+	assert.Error(t, release.HaltService(awsc.S3, "missing", to.Strp("reason")))
+	assert.NoError(t, release.HaltService(awsc.S3, "web", to.Strp("reason")))
+
+	// The web services new ASG is deleted and its old ASG kept, the worker continues
+	assert.NoError(t, release.HaltServices(awsc.S3, awsc.ASG, awsc.ALB, awsc.CW, awsc.EC2))
+
+	web := release.Services["web"]
+	assert.True(t, web.IsHalted())
+	assert.True(t, web.Healthy)
+	assert.Nil(t, web.CreatedASG)
+	assert.NotNil(t, web.KeptASG)
+	assert.Contains(t, awsc.ASG.DeletedASGs, "new-web")
+	assert.False(t, release.Services["worker"].IsHalted())
+
+	assert.NoError(t, release.SuccessfulTearDown(awsc.ASG, awsc.ELB, awsc.ALB, awsc.CW, awsc.EC2))
+	assert.NotContains(t, awsc.ASG.DeletedASGs, *web.KeptASG)
+
+	release.UpdateServiceStatus()
+	assert.True(t, *release.PartialSuccess)
+	assert.Equal(t, ServiceHalted, *web.Status)
+	assert.Equal(t, ServiceDeployed, *release.Services["worker"].Status)
+
+	// Halting every service halts the release
+	assert.NoError(t, release.HaltService(awsc.S3, "worker", to.Strp("reason")))
+	err := release.HaltServices(awsc.S3, awsc.ASG, awsc.ALB, awsc.CW, awsc.EC2)
+	assert.IsType(t, &HaltError{}, err)
+}
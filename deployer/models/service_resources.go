@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/coinbase/odin/aws"
 	"github.com/coinbase/odin/aws/alb"
@@ -39,6 +40,9 @@ type ServiceResources struct {
 	ELBs           []*elb.LoadBalancer
 	TargetGroups   []*alb.TargetGroup
 	Subnets        []*subnet.Subnet
+
+	// Architectures of the instance type and its fallbacks, by instance type
+	Architectures map[string][]string
 }
 
 // ServiceResourceNames struct
@@ -50,6 +54,9 @@ type ServiceResourceNames struct {
 	ELBs           []*string `json:"elbs,omitempty"`
 	TargetGroups   []*string `json:"target_group_arns,omitempty"`
 	Subnets        []*string `json:"subnets,omitempty"`
+
+	AvailabilityZones       []*string          `json:"availability_zones,omitempty"`
+	SubnetAvailabilityZones map[string]*string `json:"subnet_availability_zones,omitempty"`
 }
 
 // ToServiceResourceNames returns
@@ -97,12 +104,21 @@ func (sr *ServiceResources) ToServiceResourceNames() *ServiceResourceNames {
 	}
 
 	subnets := []*string{}
+	azs := []*string{}
+	subnetAZs := map[string]*string{}
+	seenAZs := map[string]bool{}
 	for _, subnet := range sr.Subnets {
 		if subnet == nil || is.EmptyStr(subnet.SubnetID) {
 			continue
 		}
 
 		subnets = append(subnets, subnet.SubnetID)
+		subnetAZs[*subnet.SubnetID] = subnet.AvailabilityZone
+
+		if !is.EmptyStr(subnet.AvailabilityZone) && !seenAZs[*subnet.AvailabilityZone] {
+			seenAZs[*subnet.AvailabilityZone] = true
+			azs = append(azs, subnet.AvailabilityZone)
+		}
 	}
 
 	return &ServiceResourceNames{
@@ -113,6 +129,9 @@ func (sr *ServiceResources) ToServiceResourceNames() *ServiceResourceNames {
 		ELBs:           elbs,
 		TargetGroups:   tgs,
 		Subnets:        subnets,
+
+		AvailabilityZones:       azs,
+		SubnetAvailabilityZones: subnetAZs,
 	}
 }
 
@@ -123,10 +142,20 @@ func (sr *ServiceResources) Validate(service *Service) error {
 		return err
 	}
 
+	if err := sr.validateVPC(); err != nil {
+		return err
+	}
+
 	if err := ValidateImage(service, sr.Image); err != nil {
 		return err
 	}
 
+	for _, instanceType := range append([]*string{service.InstanceType}, service.InstanceTypeFallbacks...) {
+		if err := ValidateArchitecture(sr.Image, instanceType, sr.Architectures[to.Strs(instanceType)]); err != nil {
+			return err
+		}
+	}
+
 	// Now the Easy Validations are over time to validate Tags and Paths
 	if err := ValidateIAMProfile(service, sr.Profile); err != nil {
 		return err
@@ -140,6 +169,10 @@ func (sr *ServiceResources) Validate(service *Service) error {
 		if err := ValidateSubnet(service, r); err != nil {
 			return err
 		}
+
+		if err := ValidatePublicIP(service.associatePublicIP(), r); err != nil {
+			return err
+		}
 	}
 
 	for _, r := range sr.SecurityGroups {
@@ -191,6 +224,41 @@ func (sr *ServiceResources) validateAttributes(service *Service) error {
 	return nil
 }
 
+// validateVPC returns an error listing the resources not in the VPC of the subnets,
+// instead of the ASG failing to launch instances later in the deploy
+func (sr *ServiceResources) validateVPC() error {
+	vpcID, err := subnet.VPC(sr.Subnets)
+	if err != nil || vpcID == nil {
+		return err
+	}
+
+	mismatched := []string{}
+
+	for _, r := range sr.SecurityGroups {
+		if r != nil && r.VpcID != nil && *r.VpcID != *vpcID {
+			mismatched = append(mismatched, fmt.Sprintf("SecurityGroup(%v) in %v", to.Strs(r.Name()), *r.VpcID))
+		}
+	}
+
+	for _, r := range sr.ELBs {
+		if r != nil && r.VpcID != nil && *r.VpcID != *vpcID {
+			mismatched = append(mismatched, fmt.Sprintf("ELB(%v) in %v", to.Strs(r.LoadBalancerName), *r.VpcID))
+		}
+	}
+
+	for _, r := range sr.TargetGroups {
+		if r != nil && r.VpcID != nil && *r.VpcID != *vpcID {
+			mismatched = append(mismatched, fmt.Sprintf("TargetGroup(%v) in %v", to.Strs(r.TargetGroupName), *r.VpcID))
+		}
+	}
+
+	if len(mismatched) > 0 {
+		return fmt.Errorf("Resources not in the subnets VPC %v: %v", *vpcID, strings.Join(mismatched, ", "))
+	}
+
+	return nil
+}
+
 // ValidateImage returns
 func ValidateImage(service serviceIface, im *ami.Image) error {
 	if im == nil {
@@ -208,6 +276,21 @@ func ValidateImage(service serviceIface, im *ami.Image) error {
 	return nil
 }
 
+// ValidateArchitecture returns an error if the instance type, with its supported architectures, cannot run the image
+func ValidateArchitecture(im *ami.Image, instanceType *string, architectures []string) error {
+	if im == nil || im.Architecture == nil {
+		return nil
+	}
+
+	for _, architecture := range architectures {
+		if architecture == *im.Architecture {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Image %v architecture %v cannot run on instance type %v (%v)", *im.ImageID, *im.Architecture, to.Strs(instanceType), strings.Join(architectures, ", "))
+}
+
 // ValidateSubnet returns
 func ValidateSubnet(service serviceIface, subnet *subnet.Subnet) error {
 	if subnet == nil {
@@ -225,6 +308,19 @@ func ValidateSubnet(service serviceIface, subnet *subnet.Subnet) error {
 	return nil
 }
 
+// ValidatePublicIP returns an error if a public IP is requested in a private subnet
+func ValidatePublicIP(associatePublicIP *bool, subnet *subnet.Subnet) error {
+	if associatePublicIP == nil || !*associatePublicIP {
+		return nil
+	}
+
+	if subnet.MapPublicIPOnLaunch == nil || !*subnet.MapPublicIPOnLaunch {
+		return fmt.Errorf("Subnet %v is private and cannot be used with associate_public_ip_address", *subnet.SubnetID)
+	}
+
+	return nil
+}
+
 // ValidatePrevASG returns
 func ValidatePrevASG(service serviceIface, as *asg.ASG) error {
 	if as == nil {
@@ -277,14 +373,30 @@ func ValidateIAMProfile(service serviceIface, profile *iam.Profile) error {
 		fmt.Sprintf(pathFormat, "_all", "_all", "_all"),
 	}
 
-	for _, validPath := range validPaths {
-		if *profile.Path == validPath {
-			return nil
+	validPath := false
+	for _, path := range validPaths {
+		if *profile.Path == path {
+			validPath = true
 		}
 	}
 
-	// Again should never happen
-	return fmt.Errorf("Iam Profile Path incorrect, it is %q and requires %q", *profile.Path, specificPath)
+	if !validPath {
+		// Again should never happen
+		return fmt.Errorf("Iam Profile Path incorrect, it is %q and requires %q", *profile.Path, specificPath)
+	}
+
+	// Instances cannot get credentials without a role EC2 can assume
+	if len(profile.Roles) == 0 {
+		return fmt.Errorf("Iam Profile has no Role")
+	}
+
+	for _, role := range profile.Roles {
+		if !role.TrustsEC2 {
+			return fmt.Errorf("Iam Profile Role %v trust policy does not allow ec2.amazonaws.com", to.Strs(role.Arn))
+		}
+	}
+
+	return nil
 }
 
 // ValidateSecurityGroup returns
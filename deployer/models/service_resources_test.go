@@ -41,6 +41,19 @@ func Test_Service_ValidateImage(t *testing.T) {
 	}))
 }
 
+func Test_Service_ValidateArchitecture(t *testing.T) {
+	// func ValidateArchitecture(im *ami.Image, instanceType *string, architectures []string) error {
+	x86 := &ami.Image{ImageID: to.Strp("image"), Architecture: to.Strp("x86_64")}
+	arm := &ami.Image{ImageID: to.Strp("image"), Architecture: to.Strp("arm64")}
+
+	assert.NoError(t, ValidateArchitecture(x86, to.Strp("t2.small"), []string{"i386", "x86_64"}))
+	assert.NoError(t, ValidateArchitecture(arm, to.Strp("m8g.large"), []string{"arm64"}))
+	assert.NoError(t, ValidateArchitecture(&ami.Image{ImageID: to.Strp("image")}, to.Strp("m8g.large"), []string{"arm64"}))
+
+	assert.Error(t, ValidateArchitecture(arm, to.Strp("t2.small"), []string{"i386", "x86_64"}))
+	assert.Error(t, ValidateArchitecture(x86, to.Strp("m8g.large"), []string{"arm64"}))
+}
+
 func Test_Service_ValidateSubnet(t *testing.T) {
 	// func ValidateSubnet(service *Service, subnet *subnet.Subnet) error {
 	assert.Error(t, ValidateSubnet(&MockService{}, &subnet.Subnet{SubnetID: to.Strp("subnet")}))
@@ -51,6 +64,19 @@ func Test_Service_ValidateSubnet(t *testing.T) {
 	}))
 }
 
+func Test_Service_ValidatePublicIP(t *testing.T) {
+	// func ValidatePublicIP(associatePublicIP *bool, subnet *subnet.Subnet) error {
+	private := &subnet.Subnet{SubnetID: to.Strp("subnet"), MapPublicIPOnLaunch: to.Boolp(false)}
+	public := &subnet.Subnet{SubnetID: to.Strp("subnet"), MapPublicIPOnLaunch: to.Boolp(true)}
+
+	assert.NoError(t, ValidatePublicIP(nil, private))
+	assert.NoError(t, ValidatePublicIP(to.Boolp(false), private))
+	assert.NoError(t, ValidatePublicIP(to.Boolp(true), public))
+
+	assert.Error(t, ValidatePublicIP(to.Boolp(true), private))
+	assert.Error(t, ValidatePublicIP(to.Boolp(true), &subnet.Subnet{SubnetID: to.Strp("subnet")}))
+}
+
 func Test_Service_ValidatePrevASG(t *testing.T) {
 	// func ValidatePrevASG(service *Service, as *asg.ASG) error {
 	assert.Error(t, ValidatePrevASG(&MockService{}, &asg.ASG{}))
@@ -97,24 +123,40 @@ func Test_Service_ValidateIAMProfile(t *testing.T) {
 	// func ValidateIAMProfile(service *Service, profile *iam.Profile) error {
 	assert.Error(t, ValidateIAMProfile(&MockService{}, &iam.Profile{}))
 
+	roles := []*iam.Role{&iam.Role{Arn: to.Strp("role"), TrustsEC2: true}}
+
 	assert.NoError(t, ValidateIAMProfile(&MockService{}, &iam.Profile{
-		Path: to.Strp("/odin/project/config/servicename/"),
+		Path:  to.Strp("/odin/project/config/servicename/"),
+		Roles: roles,
 	}))
 
 	assert.NoError(t, ValidateIAMProfile(&MockService{}, &iam.Profile{
-		Path: to.Strp("/odin/project/config/_all/"),
+		Path:  to.Strp("/odin/project/config/_all/"),
+		Roles: roles,
 	}))
 
 	assert.NoError(t, ValidateIAMProfile(&MockService{}, &iam.Profile{
-		Path: to.Strp("/odin/project/_all/_all/"),
+		Path:  to.Strp("/odin/project/_all/_all/"),
+		Roles: roles,
 	}))
 
 	assert.NoError(t, ValidateIAMProfile(&MockService{}, &iam.Profile{
-		Path: to.Strp("/odin/_all/_all/_all/"),
+		Path:  to.Strp("/odin/_all/_all/_all/"),
+		Roles: roles,
 	}))
 
 	assert.Error(t, ValidateIAMProfile(&MockService{}, &iam.Profile{
-		Path: to.Strp("/notodin/_all/_all/_all/"),
+		Path:  to.Strp("/notodin/_all/_all/_all/"),
+		Roles: roles,
+	}))
+
+	assert.Error(t, ValidateIAMProfile(&MockService{}, &iam.Profile{
+		Path: to.Strp("/odin/project/config/servicename/"),
+	}))
+
+	assert.Error(t, ValidateIAMProfile(&MockService{}, &iam.Profile{
+		Path:  to.Strp("/odin/project/config/servicename/"),
+		Roles: []*iam.Role{&iam.Role{Arn: to.Strp("role")}},
 	}))
 }
 
@@ -222,3 +264,20 @@ func Test_Service_ValidateTargetGroup(t *testing.T) {
 		ServiceNameTag: to.Strp("servicename"),
 	}))
 }
+
+func Test_ServiceResources_ValidateVPC(t *testing.T) {
+	sr := &ServiceResources{
+		Subnets:        []*subnet.Subnet{&subnet.Subnet{SubnetID: to.Strp("subnet-1"), VpcID: to.Strp("vpc-1")}},
+		SecurityGroups: []*sg.SecurityGroup{&sg.SecurityGroup{NameTag: to.Strp("web-sg"), VpcID: to.Strp("vpc-1")}},
+		ELBs:           []*elb.LoadBalancer{&elb.LoadBalancer{LoadBalancerName: to.Strp("web-elb"), VpcID: to.Strp("vpc-1")}},
+		TargetGroups:   []*alb.TargetGroup{&alb.TargetGroup{TargetGroupName: to.Strp("web-tg"), VpcID: to.Strp("vpc-1")}},
+	}
+	assert.NoError(t, sr.validateVPC())
+
+	sr.ELBs[0].VpcID = to.Strp("vpc-2")
+	sr.TargetGroups[0].VpcID = to.Strp("vpc-2")
+	assert.EqualError(t, sr.validateVPC(), "Resources not in the subnets VPC vpc-1: ELB(web-elb) in vpc-2, TargetGroup(web-tg) in vpc-2")
+
+	sr.Subnets = append(sr.Subnets, &subnet.Subnet{SubnetID: to.Strp("subnet-2"), VpcID: to.Strp("vpc-2")})
+	assert.Error(t, sr.validateVPC())
+}
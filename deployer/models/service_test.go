@@ -29,3 +29,49 @@ func Test_Service_CreateInput_HealthCheckGracePeriod(t *testing.T) {
 	input := service.createInput()
 	assert.Equal(t, *input.HealthCheckGracePeriod, int64(10))
 }
+
+func Test_Service_AssociatePublicIpAddress_Default(t *testing.T) {
+	release := MockMinimalRelease(t)
+
+	service := Service{}
+	service.SetDefaults(release, "web")
+	assert.False(t, *service.createLaunchConfigurationInput().AssociatePublicIpAddress)
+
+	service = Service{AssociatePublicIpAddress: to.Boolp(true)}
+	service.SetDefaults(release, "web")
+	assert.True(t, *service.createLaunchConfigurationInput().AssociatePublicIpAddress)
+}
+
+func Test_Service_Containers_Validate(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	service := release.Services["web"]
+
+	service.Containers = []*string{to.Strp("000000000000.dkr.ecr.us-east-1.amazonaws.com/repo:v1")}
+	assert.NoError(t, service.ValidateAttributes())
+
+	service.Containers = []*string{to.Strp("nginx:latest")}
+	assert.Error(t, service.ValidateAttributes())
+
+	service.Containers = []*string{to.Strp("000000000000.dkr.ecr.us-east-1.amazonaws.com/repo:v1")}
+	service.Profile = nil
+	assert.Error(t, service.ValidateAttributes())
+}
+
+func Test_Service_Containers_PrePull(t *testing.T) {
+	release := MockRelease(t)
+	release.SetUserData(to.Strp("#!/bin/bash\n{{PRE_PULL_CONTAINERS}}"))
+	MockPrepareRelease(release)
+	service := release.Services["web"]
+	service.SetUserData(release.UserData())
+
+	service.Containers = []*string{to.Strp("000000000000.dkr.ecr.us-east-1.amazonaws.com/repo:v1")}
+	assert.Equal(t, "#!/bin/bash\n", *service.UserData())
+
+	service.PrePullContainers = to.Boolp(true)
+	assert.NoError(t, service.ValidateAttributes())
+	assert.Regexp(t, "docker pull 000000000000.dkr.ecr.us-east-1.amazonaws.com/repo:v1", *service.UserData())
+
+	release.SetUserData(to.Strp("#!/bin/bash"))
+	assert.Error(t, service.ValidateAttributes())
+}
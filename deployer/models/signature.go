@@ -0,0 +1,109 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/signing"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Signature is the clients signature of the release with a KMS asymmetric key
+// The release records its userdatas SHA256, so the signature covers the userdata too
+type Signature struct {
+	KeyID     *string `json:"key_id,omitempty"`
+	Algorithm *string `json:"algorithm,omitempty"`
+	Value     *string `json:"value,omitempty"`  // Base64 signature of the releases SigningDigest
+	Signer    *string `json:"signer,omitempty"` // ARN of the key the deployer verified the signature with
+}
+
+// SigningDigest is the SHA256 of the release as the client sent it, without its signature
+// The deployer must take it before anything alters the release
+func (release *Release) SigningDigest() *string {
+	unsigned := *release
+	unsigned.Signature = nil
+	unsigned.ReleaseSHA256 = nil
+	unsigned.Identity = nil // Taken from the release before validating
+	return to.SHA256Struct(&unsigned)
+}
+
+// Sign signs the release with its signatures key
+func (release *Release) Sign(kmsc aws.KMSAPI) error {
+	if release.Signature == nil || release.Signature.KeyID == nil {
+		return fmt.Errorf("Signature key_id must be defined")
+	}
+
+	if release.Signature.Algorithm == nil {
+		release.Signature.Algorithm = to.Strp(signing.DefaultAlgorithm)
+	}
+
+	// A release signed before, e.g. a checkpoint, is signed again as it has changed
+	release.Signature.Value = nil
+	release.Signature.Signer = nil
+
+	value, _, err := signing.Sign(kmsc, *release.Signature.KeyID, *release.Signature.Algorithm, *release.SigningDigest())
+	if err != nil {
+		return fmt.Errorf("Signing release with %v failed %v", *release.Signature.KeyID, err.Error())
+	}
+
+	release.Signature.Value = value
+	return nil
+}
+
+// ParseSigningKeys parses the comma separated KMS key ARNs trusted to sign releases, empty does not require signatures
+func ParseSigningKeys(raw string) []string {
+	return parseKeys(raw)
+}
+
+// parseKeys parses a comma separated list of KMS keys
+func parseKeys(raw string) []string {
+	keys := []string{}
+	for _, key := range strings.Split(raw, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// VerifySignature verifies the releases signature of its digest and records its signer
+// If the deployer trusts any keys the release must be signed by one of them
+func (release *Release) VerifySignature(kmsc aws.KMSAPI, digest *string, keys []string) error {
+	sig := release.Signature
+	if sig != nil {
+		sig.Signer = nil // Only the deployer records the signer
+	}
+
+	if sig == nil || sig.Value == nil {
+		if len(keys) > 0 {
+			return fmt.Errorf("Signature must be defined, the deployer requires releases signed by %v", strings.Join(keys, ", "))
+		}
+		return nil
+	}
+
+	if sig.KeyID == nil || sig.Algorithm == nil {
+		return fmt.Errorf("Signature key_id and algorithm must be defined")
+	}
+
+	signer, err := signing.Verify(kmsc, *sig.KeyID, *sig.Algorithm, to.Strs(digest), *sig.Value)
+	if err != nil {
+		return err
+	}
+
+	if len(keys) > 0 && !containsStr(keys, *sig.KeyID) && !containsStr(keys, to.Strs(signer)) {
+		return fmt.Errorf("Signature key %v is not trusted by the deployer", to.Strs(signer))
+	}
+
+	sig.Signer = signer
+	return nil
+}
+
+func containsStr(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
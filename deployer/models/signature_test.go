@@ -0,0 +1,42 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_Signature(t *testing.T) {
+	kmsc := &mocks.KMSClient{}
+	release := MockRelease(t)
+	trusted := []string{"arn:aws:kms:us-east-1:000000000000:key/odin-signing"}
+
+	// Unsigned releases are only allowed if the deployer trusts no keys
+	assert.NoError(t, release.VerifySignature(kmsc, release.SigningDigest(), nil))
+	assert.Error(t, release.VerifySignature(kmsc, release.SigningDigest(), trusted))
+
+	release.Signature = &Signature{KeyID: to.Strp("alias/odin-signing")}
+	assert.NoError(t, release.Sign(kmsc))
+
+	// The digest does not change with the signature or the deployers SHA
+	digest := release.SigningDigest()
+	release.ReleaseSHA256 = to.SHA256Struct(release)
+	assert.Equal(t, *digest, *release.SigningDigest())
+
+	assert.NoError(t, release.VerifySignature(kmsc, digest, trusted))
+	assert.Equal(t, trusted[0], *release.Signature.Signer)
+
+	// Untrusted keys, and releases changed after they were signed, fail
+	assert.Error(t, release.VerifySignature(kmsc, digest, []string{"arn:aws:kms:us-east-1:000000000000:key/other"}))
+
+	release.Image = to.Strp("ami-654321")
+	assert.Error(t, release.VerifySignature(kmsc, release.SigningDigest(), trusted))
+	assert.Nil(t, release.Signature.Signer)
+}
+
+func Test_ParseSigningKeys(t *testing.T) {
+	assert.Equal(t, []string{}, ParseSigningKeys(""))
+	assert.Equal(t, []string{"arn:1", "arn:2"}, ParseSigningKeys("arn:1, arn:2,"))
+}
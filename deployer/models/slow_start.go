@@ -0,0 +1,76 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/alb"
+	"github.com/coinbase/step/utils/to"
+)
+
+const (
+	slowStartAttribute = "slow_start.duration_seconds"
+	algorithmAttribute = "load_balancing.algorithm.type"
+)
+
+// validateSlowStart validates the services slow start
+func (service *Service) validateSlowStart() error {
+	if service.SlowStart == nil {
+		return nil
+	}
+
+	// 0 turns slow start off again
+	if *service.SlowStart != 0 && (*service.SlowStart < 30 || *service.SlowStart > 900) {
+		return fmt.Errorf("slow_start must be 0 or between 30 and 900")
+	}
+
+	if len(service.TargetGroups) == 0 {
+		return fmt.Errorf("slow_start requires target_groups")
+	}
+
+	return nil
+}
+
+// SetSlowStart sets the slow start of the services target groups before the new ASGs attach to them,
+// so the new instances are ramped up to their share of requests instead of taking it all at once
+func (release *Release) SetSlowStart(resources map[string]*ServiceResources, albc aws.ALBAPI) error {
+	for name, service := range release.Services {
+		if sr := resources[name]; sr != nil {
+			if err := service.setSlowStart(sr, albc); err != nil {
+				return fmt.Errorf("%v %v", service.errorPrefix(), err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+func (service *Service) setSlowStart(sr *ServiceResources, albc aws.ALBAPI) error {
+	if service.SlowStart == nil {
+		return nil
+	}
+
+	duration := fmt.Sprintf("%v", *service.SlowStart)
+
+	for _, tg := range sr.TargetGroups {
+		attrs, err := alb.TargetGroupAttributes(albc, tg.TargetGroupArn)
+		if err != nil {
+			return err
+		}
+
+		// Target groups routing to the least outstanding requests cannot slow start
+		if *service.SlowStart != 0 && attrs[algorithmAttribute] == "least_outstanding_requests" {
+			return fmt.Errorf("TargetGroup(%v) slow_start cannot be used with the least_outstanding_requests algorithm", to.Strs(tg.TargetGroupName))
+		}
+
+		if attrs[slowStartAttribute] == duration {
+			continue
+		}
+
+		if err := alb.SetTargetGroupAttributes(albc, tg.TargetGroupArn, map[string]string{slowStartAttribute: duration}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
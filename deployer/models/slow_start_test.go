@@ -0,0 +1,42 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Service_ValidateSlowStart(t *testing.T) {
+	service := &Service{TargetGroups: []*string{to.Strp("tg")}}
+	assert.NoError(t, service.validateSlowStart())
+
+	service.SlowStart = to.Int64p(0)
+	assert.NoError(t, service.validateSlowStart())
+
+	service.SlowStart = to.Int64p(10)
+	assert.Error(t, service.validateSlowStart())
+
+	service.SlowStart = to.Int64p(120)
+	assert.NoError(t, service.validateSlowStart())
+
+	service.TargetGroups = nil
+	assert.Error(t, service.validateSlowStart())
+}
+
+func Test_Release_SetSlowStart(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	release.Services["web"].SlowStart = to.Int64p(120)
+
+	awsc := MockAwsClients(release)
+	resources, err := release.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS)
+	assert.NoError(t, err)
+
+	assert.NoError(t, release.SetSlowStart(resources, awsc.ALB))
+	assert.Equal(t, "120", awsc.ALB.Attributes["web-elb-target"][slowStartAttribute])
+
+	awsc.ALB.Attributes["web-elb-target"][algorithmAttribute] = "least_outstanding_requests"
+	assert.Error(t, release.SetSlowStart(resources, awsc.ALB))
+}
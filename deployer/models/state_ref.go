@@ -0,0 +1,92 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// StateRef points at the release a state saved for the next one
+// The state machine passes only the reference, so its payload stays the same size however large the release grows
+type StateRef struct {
+	Bucket *string `json:"bucket,omitempty"`
+	Key    *string `json:"key,omitempty"`
+	SHA256 *string `json:"sha256,omitempty"` // Of the saved release JSON
+}
+
+// StatePath returns the path of the release passed between states
+func (release *Release) StatePath() *string {
+	s := fmt.Sprintf("%v/state", *release.ReleaseDir())
+	return &s
+}
+
+// Offload saves the release to its state path and returns its reference, which keeps the fields the
+// state machine chooses on and clients show without loading it: who and what the release is, its error and health
+func (release *Release) Offload(s3c aws.S3API) (*Release, error) {
+	release.StateRef = nil
+
+	raw, err := json.Marshal(release)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := release.Store(s3c).Put(release.StatePath(), raw); err != nil {
+		return nil, err
+	}
+
+	ref := &Release{
+		Release:        release.Release,
+		Healthy:        release.Healthy,
+		WaitForHealthy: release.WaitForHealthy,
+		StateRef: &StateRef{
+			Bucket: release.Bucket,
+			Key:    release.StatePath(),
+			SHA256: to.Strp(to.SHA256Str(to.Strp(string(raw)))),
+		},
+	}
+
+	ref.UseStoreOf(release)
+	return ref, nil
+}
+
+// Hydrate loads the release a reference points to, returning releases that are not references as they are
+// The state machine writes the error a state caught to the reference, so it replaces the saved one
+func (release *Release) Hydrate(s3c aws.S3API) (*Release, error) {
+	ref := release.StateRef
+	if ref == nil {
+		return release, nil
+	}
+
+	if ref.Bucket == nil || ref.Key == nil || ref.SHA256 == nil {
+		return nil, fmt.Errorf("State reference requires bucket, key and sha256")
+	}
+
+	// A reference can only point at the state of its own release
+	if release.ReleaseID == nil || release.ProjectName == nil || release.ConfigName == nil || release.AwsAccountID == nil ||
+		*ref.Key != *release.StatePath() || *ref.Bucket != to.Strs(release.Bucket) {
+		return nil, fmt.Errorf("State reference %v/%v is not of the release", *ref.Bucket, *ref.Key)
+	}
+
+	raw, err := release.Store(s3c).Get(ref.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	if sha := to.SHA256Str(to.Strp(string(raw))); sha != *ref.SHA256 {
+		return nil, &CodedError{Code: ErrorCodeValidationSHA, Cause: fmt.Sprintf("State SHA incorrect expected %v, got %v", *ref.SHA256, sha)}
+	}
+
+	var hydrated Release
+	if err := json.Unmarshal(raw, &hydrated); err != nil {
+		return nil, fmt.Errorf("State invalid %v", err.Error())
+	}
+
+	if release.Error != nil {
+		hydrated.Error = release.Error
+	}
+
+	hydrated.storeFunc = release.storeFunc
+	return &hydrated, nil
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/bifrost"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_Offload_Hydrate(t *testing.T) {
+	release := MockRelease(t)
+	awsc := MockAwsClients(release)
+	release.Healthy = to.Boolp(true)
+
+	ref, err := release.Offload(awsc.S3)
+	assert.NoError(t, err)
+
+	// The reference keeps what the state machine and clients read without the services
+	assert.Equal(t, *release.ReleaseID, *ref.ReleaseID)
+	assert.Equal(t, true, *ref.Healthy)
+	assert.Nil(t, ref.Services)
+	assert.Equal(t, *release.StatePath(), *ref.StateRef.Key)
+
+	hydrated, err := ref.Hydrate(awsc.S3)
+	assert.NoError(t, err)
+	assert.Nil(t, hydrated.StateRef)
+	assert.Equal(t, len(release.Services), len(hydrated.Services))
+	assert.Equal(t, to.SHA256Struct(release), to.SHA256Struct(hydrated))
+
+	// Releases that are not references are returned as they are
+	same, err := release.Hydrate(awsc.S3)
+	assert.NoError(t, err)
+	assert.Equal(t, release, same)
+}
+
+func Test_Release_Hydrate_Error(t *testing.T) {
+	release := MockRelease(t)
+	awsc := MockAwsClients(release)
+
+	ref, err := release.Offload(awsc.S3)
+	assert.NoError(t, err)
+
+	// The error a state caught is written to the reference
+	ref.Error = &bifrost.ReleaseError{Error: to.Strp("HaltError"), Cause: to.Strp("halted")}
+
+	hydrated, err := ref.Hydrate(awsc.S3)
+	assert.NoError(t, err)
+	assert.Equal(t, "HaltError", *hydrated.Error.Error)
+}
+
+func Test_Release_Hydrate_Invalid(t *testing.T) {
+	release := MockRelease(t)
+	awsc := MockAwsClients(release)
+
+	ref, err := release.Offload(awsc.S3)
+	assert.NoError(t, err)
+
+	// The saved release was changed
+	awsc.S3.AddGetObject(*release.StatePath(), `{"release_id": "1"}`, nil)
+	_, err = ref.Hydrate(awsc.S3)
+	assert.Error(t, err)
+
+	// The reference points at another releases state
+	ref, err = release.Offload(awsc.S3)
+	assert.NoError(t, err)
+	ref.StateRef.Key = to.Strp("other/state")
+	_, err = ref.Hydrate(awsc.S3)
+	assert.Error(t, err)
+}
@@ -0,0 +1,326 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// S3 deletes at most 1000 keys per request
+const maxDeleteKeys = 1000
+
+// ArtifactStore keeps the documents of project configs and their releases by path
+// S3 is the default, paths are the same in every store so a release can be moved between them
+type ArtifactStore interface {
+	Get(path *string) ([]byte, error) // NotFoundError if nothing is at the path
+	Put(path *string, body []byte) error
+	PutSecure(path *string, body []byte, kmsKeyID *string) error // Encrypted with the KMS key, or the stores default key if nil
+	EncryptionKey(path *string) (*string, error)                 // KMS key the path is encrypted with, nil if none
+	List(prefix *string) ([]*Artifact, error)
+	Delete(path *string) error
+	DeleteAll(paths []*string) error
+}
+
+// Artifact is a listed path and when it was last written
+type Artifact struct {
+	Path         *string
+	LastModified time.Time
+}
+
+// StoreFunc returns the store of a bucket, the S3 client is the callers, e.g. one that decrypts envelopes
+type StoreFunc func(s3c aws.S3API, bucket *string) ArtifactStore
+
+// StoreClients are AWS clients that keep artifacts somewhere other than S3
+type StoreClients interface {
+	ArtifactStore(s3c aws.S3API, bucket *string) ArtifactStore
+}
+
+// NewS3Store is the default StoreFunc
+func NewS3Store(s3c aws.S3API, bucket *string) ArtifactStore {
+	return &S3Store{S3: s3c, Bucket: bucket}
+}
+
+// StoreOf returns the StoreFunc of the clients, NewS3Store unless they are StoreClients
+func StoreOf(awsc aws.Clients) StoreFunc {
+	if sc, ok := awsc.(StoreClients); ok {
+		return sc.ArtifactStore
+	}
+	return NewS3Store
+}
+
+// ConditionalStore is a store that writes a path only if it is unchanged since it was read, e.g. for locks
+type ConditionalStore interface {
+	ArtifactStore
+	GetETag(path *string) ([]byte, *string, error)
+	PutIfMatch(path *string, body []byte, etag *string) error
+	DeleteIfMatch(path *string, etag *string) error
+}
+
+// NotFoundError is returned by stores when nothing is at the path
+type NotFoundError struct {
+	Path string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("Artifact %v not found", e.Path)
+}
+
+// IsNotFound returns whether the error is from a store having nothing at the path
+func IsNotFound(err error) bool {
+	_, ok := err.(*NotFoundError)
+	return ok
+}
+
+// ConflictError is returned by conditional writes when the path changed since it was read
+type ConflictError struct {
+	Path string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("Artifact %v changed since it was read", e.Path)
+}
+
+// IsConflict returns whether the error is from a conditional write of a path that changed
+func IsConflict(err error) bool {
+	_, ok := err.(*ConflictError)
+	return ok
+}
+
+// S3Store keeps documents in a bucket
+type S3Store struct {
+	S3     aws.S3API
+	Bucket *string
+}
+
+// Get returns
+func (store *S3Store) Get(path *string) ([]byte, error) {
+	output, err := store.S3.GetObject(&awss3.GetObjectInput{
+		Bucket: store.Bucket,
+		Key:    path,
+	})
+
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == awss3.ErrCodeNoSuchKey {
+		return nil, &NotFoundError{*path}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer output.Body.Close()
+	return ioutil.ReadAll(output.Body)
+}
+
+// Put returns
+func (store *S3Store) Put(path *string, body []byte) error {
+	_, err := store.S3.PutObject(&awss3.PutObjectInput{
+		Bucket: store.Bucket,
+		Key:    path,
+		Body:   bytes.NewReader(body),
+	})
+	return err
+}
+
+// PutSecure writes the path encrypted with SSE-KMS
+func (store *S3Store) PutSecure(path *string, body []byte, kmsKeyID *string) error {
+	_, err := store.S3.PutObject(&awss3.PutObjectInput{
+		Bucket:               store.Bucket,
+		Key:                  path,
+		Body:                 bytes.NewReader(body),
+		ACL:                  to.Strp("private"),
+		ServerSideEncryption: to.Strp(awss3.ServerSideEncryptionAwsKms),
+		SSEKMSKeyId:          kmsKeyID,
+	})
+	return err
+}
+
+// EncryptionKey returns the SSE-KMS key of the path
+func (store *S3Store) EncryptionKey(path *string) (*string, error) {
+	output, err := store.S3.GetObject(&awss3.GetObjectInput{
+		Bucket: store.Bucket,
+		Key:    path,
+	})
+
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == awss3.ErrCodeNoSuchKey {
+		return nil, &NotFoundError{*path}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	output.Body.Close()
+	return output.SSEKMSKeyId, nil
+}
+
+// List returns the paths under the prefix
+func (store *S3Store) List(prefix *string) ([]*Artifact, error) {
+	artifacts := []*Artifact{}
+	err := store.S3.ListObjectsV2Pages(&awss3.ListObjectsV2Input{
+		Bucket: store.Bucket,
+		Prefix: prefix,
+	}, func(page *awss3.ListObjectsV2Output, _ bool) bool {
+		for _, obj := range page.Contents {
+			if obj.Key == nil {
+				continue
+			}
+
+			artifact := &Artifact{Path: obj.Key}
+			if obj.LastModified != nil {
+				artifact.LastModified = *obj.LastModified
+			}
+			artifacts = append(artifacts, artifact)
+		}
+		return true
+	})
+
+	return artifacts, err
+}
+
+// Delete returns
+func (store *S3Store) Delete(path *string) error {
+	_, err := store.S3.DeleteObject(&awss3.DeleteObjectInput{
+		Bucket: store.Bucket,
+		Key:    path,
+	})
+	return err
+}
+
+// DeleteAll deletes the paths in batches of maxDeleteKeys
+func (store *S3Store) DeleteAll(paths []*string) error {
+	for len(paths) > 0 {
+		batch := paths[:min(len(paths), maxDeleteKeys)]
+		paths = paths[len(batch):]
+
+		objects := []*awss3.ObjectIdentifier{}
+		for _, path := range batch {
+			objects = append(objects, &awss3.ObjectIdentifier{Key: path})
+		}
+
+		output, err := store.S3.DeleteObjects(&awss3.DeleteObjectsInput{
+			Bucket: store.Bucket,
+			Delete: &awss3.Delete{Objects: objects, Quiet: to.Boolp(true)},
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if len(output.Errors) > 0 {
+			e := output.Errors[0]
+			return fmt.Errorf("Deleting %v failed %v", to.Strs(e.Key), to.Strs(e.Message))
+		}
+	}
+
+	return nil
+}
+
+// GetETag returns the document at the path and its ETag, for a conditional write of it
+func (store *S3Store) GetETag(path *string) ([]byte, *string, error) {
+	output, err := store.S3.GetObject(&awss3.GetObjectInput{
+		Bucket: store.Bucket,
+		Key:    path,
+	})
+
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == awss3.ErrCodeNoSuchKey {
+		return nil, nil, &NotFoundError{*path}
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer output.Body.Close()
+	raw, err := ioutil.ReadAll(output.Body)
+	return raw, output.ETag, err
+}
+
+// PutIfMatch writes the path only if its ETag is still etag, otherwise returns a ConflictError
+func (store *S3Store) PutIfMatch(path *string, body []byte, etag *string) error {
+	_, err := store.S3.PutObjectWithContext(context.Background(), &awss3.PutObjectInput{
+		Bucket: store.Bucket,
+		Key:    path,
+		Body:   bytes.NewReader(body),
+	}, ifMatch(etag))
+	return conflictError(path, err)
+}
+
+// DeleteIfMatch deletes the path only if its ETag is still etag, otherwise returns a ConflictError
+func (store *S3Store) DeleteIfMatch(path *string, etag *string) error {
+	_, err := store.S3.DeleteObjectWithContext(context.Background(), &awss3.DeleteObjectInput{
+		Bucket: store.Bucket,
+		Key:    path,
+	}, ifMatch(etag))
+	return conflictError(path, err)
+}
+
+// ifMatch sets the If-Match header, which this SDK has no field for, so S3 only writes an unchanged object
+func ifMatch(etag *string) request.Option {
+	return func(r *request.Request) {
+		if etag != nil {
+			r.HTTPRequest.Header.Set("If-Match", *etag)
+		}
+	}
+}
+
+// conflictError converts S3s failed conditions, or a conditional write racing another, to a ConflictError
+func conflictError(path *string, err error) error {
+	if aerr, ok := err.(awserr.Error); ok {
+		switch aerr.Code() {
+		case "PreconditionFailed", "ConditionalRequestConflict", awss3.ErrCodeNoSuchKey:
+			return &ConflictError{*path}
+		}
+	}
+	return err
+}
+
+// UseStore sets where the releases artifacts are kept, S3 if it is never set
+func (release *Release) UseStore(storeFunc StoreFunc) {
+	release.storeFunc = storeFunc
+}
+
+// UseStoreOf keeps the releases artifacts where the other releases are kept
+func (release *Release) UseStoreOf(other *Release) {
+	release.storeFunc = other.storeFunc
+}
+
+// Store returns the store of the releases bucket
+func (release *Release) Store(s3c aws.S3API) ArtifactStore {
+	if release.storeFunc != nil {
+		return release.storeFunc(s3c, release.Bucket)
+	}
+	return NewS3Store(s3c, release.Bucket)
+}
+
+// GetArtifact reads the JSON document at the path into v
+func GetArtifact(store ArtifactStore, path *string, v interface{}) error {
+	raw, err := store.Get(path)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("Artifact %v invalid %v", *path, err.Error())
+	}
+
+	return nil
+}
+
+// PutArtifact writes v as a JSON document to the path
+func PutArtifact(store ArtifactStore, path *string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return store.Put(path, raw)
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_S3Store(t *testing.T) {
+	release := MockRelease(t)
+	awsc := MockAwsClients(release)
+	store := release.Store(awsc.S3)
+
+	_, err := store.Get(to.Strp("missing"))
+	assert.True(t, IsNotFound(err))
+
+	assert.NoError(t, PutArtifact(store, to.Strp("doc"), &Lock{ReleaseID: to.Strp("1")}))
+
+	var lock Lock
+	assert.NoError(t, GetArtifact(store, to.Strp("doc"), &lock))
+	assert.Equal(t, "1", *lock.ReleaseID)
+
+	// The release was put by MockAwsClients at the same path
+	var stored Release
+	assert.NoError(t, GetArtifact(store, release.ReleasePath(), &stored))
+	assert.Equal(t, *release.ReleaseID, *stored.ReleaseID)
+
+	assert.NoError(t, store.Delete(to.Strp("doc")))
+	_, err = store.Get(to.Strp("doc"))
+	assert.True(t, IsNotFound(err))
+}
+
+// memoryStore keeps artifacts in a map, to check releases use the store they are given instead of S3
+type memoryStore struct {
+	artifacts map[string][]byte
+	keys      map[string]*string
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{artifacts: map[string][]byte{}, keys: map[string]*string{}}
+}
+
+func (m *memoryStore) Get(path *string) ([]byte, error) {
+	raw, ok := m.artifacts[*path]
+	if !ok {
+		return nil, &NotFoundError{*path}
+	}
+	return raw, nil
+}
+
+func (m *memoryStore) Put(path *string, body []byte) error {
+	m.artifacts[*path] = body
+	return nil
+}
+
+func (m *memoryStore) PutSecure(path *string, body []byte, kmsKeyID *string) error {
+	m.keys[*path] = kmsKeyID
+	return m.Put(path, body)
+}
+
+func (m *memoryStore) EncryptionKey(path *string) (*string, error) {
+	if _, ok := m.artifacts[*path]; !ok {
+		return nil, &NotFoundError{*path}
+	}
+	return m.keys[*path], nil
+}
+
+func (m *memoryStore) List(prefix *string) ([]*Artifact, error) {
+	artifacts := []*Artifact{}
+	for path := range m.artifacts {
+		if strings.HasPrefix(path, *prefix) {
+			artifacts = append(artifacts, &Artifact{Path: to.Strp(path)})
+		}
+	}
+	return artifacts, nil
+}
+
+func (m *memoryStore) Delete(path *string) error {
+	delete(m.artifacts, *path)
+	return nil
+}
+
+func (m *memoryStore) DeleteAll(paths []*string) error {
+	for _, path := range paths {
+		delete(m.artifacts, *path)
+	}
+	return nil
+}
+
+// storeClients are mock clients that keep artifacts in a memoryStore
+type storeClients struct {
+	*mocks.MockClients
+	store *memoryStore
+}
+
+func (c *storeClients) ArtifactStore(aws.S3API, *string) ArtifactStore {
+	return c.store
+}
+
+func Test_Release_UseStore(t *testing.T) {
+	release := MockRelease(t)
+	awsc := &storeClients{MockClients: MockAwsClients(release), store: newMemoryStore()}
+	release.UseStore(StoreOf(awsc))
+
+	assert.NoError(t, release.Pause(awsc.S3, to.Strp("reason")))
+	assert.Contains(t, awsc.store.artifacts, *release.pausePath())
+
+	// Nothing was written to S3
+	_, err := NewS3Store(awsc.S3, release.Bucket).Get(release.pausePath())
+	assert.True(t, IsNotFound(err))
+
+	assert.NoError(t, release.CheckPaused(awsc.S3))
+	assert.True(t, *release.Paused)
+
+	assert.NoError(t, release.Unpause(awsc.S3))
+	assert.NoError(t, release.CheckPaused(awsc.S3))
+	assert.False(t, *release.Paused)
+
+	// A hydrated release keeps the store of its reference
+	offloaded, err := release.Offload(awsc.S3)
+	assert.NoError(t, err)
+	assert.Contains(t, awsc.store.artifacts, *release.StatePath())
+
+	hydrated, err := offloaded.Hydrate(awsc.S3)
+	assert.NoError(t, err)
+	assert.NoError(t, hydrated.Pause(awsc.S3, to.Strp("reason")))
+	assert.Contains(t, awsc.store.artifacts, *release.pausePath())
+}
+
+func Test_StoreOf(t *testing.T) {
+	release := MockRelease(t)
+	awsc := MockAwsClients(release)
+
+	_, ok := StoreOf(awsc)(awsc.S3, release.Bucket).(*S3Store)
+	assert.True(t, ok)
+
+	store := newMemoryStore()
+	assert.Equal(t, store, StoreOf(&storeClients{MockClients: awsc, store: store})(awsc.S3, release.Bucket))
+}
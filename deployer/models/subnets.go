@@ -0,0 +1,74 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/subnet"
+	"github.com/coinbase/step/utils/is"
+)
+
+// SubnetTagConfig selects the releases subnets by tag when it is deployed,
+// so release files do not break when a VPC is rebuilt
+type SubnetTagConfig struct {
+	Tag   *string `json:"tag,omitempty"`    // e.g. odin:tier=private
+	PerAZ *bool   `json:"per_az,omitempty"` // Only use one subnet in each availability zone
+}
+
+// ValidateAttributes validates attributes
+func (s *SubnetTagConfig) ValidateAttributes() error {
+	if is.EmptyStr(s.Tag) {
+		return fmt.Errorf("Subnet tag must be defined")
+	}
+
+	return nil
+}
+
+// ResolveSubnets sets the releases subnets to the IDs of the subnets with its subnet tag
+func (release *Release) ResolveSubnets(ec2c aws.EC2API) error {
+	if release.SubnetTag == nil {
+		return nil
+	}
+
+	subnets, err := subnet.FindByTag(ec2c, *release.SubnetTag.Tag)
+	if err != nil {
+		return err
+	}
+
+	if release.SubnetTag.PerAZ != nil && *release.SubnetTag.PerAZ {
+		subnets = subnet.BalanceAZs(subnets)
+	}
+
+	release.Subnets = []*string{}
+	for _, s := range subnets {
+		release.Subnets = append(release.Subnets, s.SubnetID)
+	}
+
+	return nil
+}
+
+// moveSubnetTag moves a "subnets" object selecting subnets by tag to "subnet_tag"
+// so "subnets" can be either a list of subnets or a tag
+func moveSubnetTag(data []byte) []byte {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return data // The decoder returns the error
+	}
+
+	subnets := bytes.TrimSpace(raw["subnets"])
+	if len(subnets) == 0 || subnets[0] != '{' {
+		return data
+	}
+
+	raw["subnet_tag"] = subnets
+	delete(raw, "subnets")
+
+	moved, err := json.Marshal(raw)
+	if err != nil {
+		return data
+	}
+
+	return moved
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_ResolveSubnets(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	release.SubnetTag = &SubnetTagConfig{}
+	assert.Error(t, release.ValidateConfiguration())
+
+	release.SubnetTag.Tag = to.Strp("odin:tier=private")
+	release.SubnetTag.PerAZ = to.Boolp(true)
+	assert.NoError(t, release.ValidateConfiguration())
+
+	ec2c := &mocks.EC2Client{}
+	ec2c.AddSubnet("private-subnet1", "subnet-2")
+	ec2c.AddSubnet("private-subnet2", "subnet-1")
+
+	assert.NoError(t, release.ResolveSubnets(ec2c))
+	assert.Equal(t, []string{"subnet-1"}, to.StrSlice(release.Subnets))
+
+	release.SubnetTag.PerAZ = nil
+	assert.NoError(t, release.ResolveSubnets(ec2c))
+	assert.Equal(t, []string{"subnet-1", "subnet-2"}, to.StrSlice(release.Subnets))
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validateTags validates tags can be applied to ASGs, launch templates, instances and volumes
+func validateTags(tags map[string]*string) error {
+	for key, value := range tags {
+		if key == "" || len(key) > 128 {
+			return fmt.Errorf("Tag key %q must be between 1 and 128 characters", key)
+		}
+
+		if strings.HasPrefix(strings.ToLower(key), "aws:") {
+			return fmt.Errorf("Tag key %q cannot start with aws:", key)
+		}
+
+		if value == nil {
+			return fmt.Errorf("Tag %q value is null", key)
+		}
+
+		if len(*value) > 256 {
+			return fmt.Errorf("Tag %q value must be at most 256 characters", key)
+		}
+	}
+
+	return nil
+}
+
+// ValidateTags validates the releases tags, which apply to every service
+func (release *Release) ValidateTags() error {
+	return validateTags(release.Tags)
+}
+
+// customTags returns the releases tags overridden by the services tags
+func (service *Service) customTags() map[string]*string {
+	tags := map[string]*string{}
+
+	if service.release != nil {
+		for key, value := range service.release.Tags {
+			tags[key] = value
+		}
+	}
+
+	for key, value := range service.Tags {
+		tags[key] = value
+	}
+
+	return tags
+}
+
+// resourceTags are the custom tags with the tags identifying the service, for resources the ASG does not tag
+func (service *Service) resourceTags() map[string]*string {
+	tags := service.customTags()
+
+	tags["ProjectName"] = service.ProjectName()
+	tags["ConfigName"] = service.ConfigName()
+	tags["ServiceName"] = service.ServiceName
+	tags["ReleaseID"] = service.ReleaseID()
+
+	return tags
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Release_Tags(t *testing.T) {
+	release := MockRelease(t)
+	release.Tags = map[string]*string{"team": to.Strp("platform"), "custom": to.Strp("release")}
+	MockPrepareRelease(release)
+	service := release.Services["web"]
+
+	tags := map[string]string{}
+	for _, tag := range service.createInput().Tags {
+		tags[*tag.Key] = *tag.Value
+		assert.True(t, *tag.PropagateAtLaunch)
+	}
+
+	assert.Equal(t, "platform", tags["team"])
+	assert.Equal(t, "tag", tags["custom"]) // The service overrides the release
+	assert.Equal(t, "project", tags["ProjectName"])
+
+	service.NetworkInterface = &NetworkInterfaceConfig{Ipv6AddressCount: to.Int64p(1)}
+	data := service.createLaunchTemplateInput().LaunchTemplateData
+	assert.Equal(t, "volume", *data.TagSpecifications[1].ResourceType)
+
+	volumeTags := map[string]string{}
+	for _, tag := range data.TagSpecifications[1].Tags {
+		volumeTags[*tag.Key] = *tag.Value
+	}
+
+	assert.Equal(t, "platform", volumeTags["team"])
+	assert.Equal(t, "web", volumeTags["ServiceName"])
+}
+
+func Test_Release_ValidateTags(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	release.Tags = map[string]*string{"team": to.Strp("platform")}
+	assert.NoError(t, release.ValidateTags())
+
+	release.Tags = map[string]*string{"aws:cloudformation:stack-name": to.Strp("stack")}
+	assert.Error(t, release.ValidateTags())
+
+	release.Tags = map[string]*string{"team": nil}
+	assert.Error(t, release.ValidateTags())
+
+	release.Tags = nil
+	release.Services["web"].Tags["AWS:reserved"] = to.Strp("value")
+	assert.Error(t, release.Services["web"].ValidateAttributes())
+}
+
+func Test_OrgPolicy_RequiredTags_Release(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	policy := &OrgPolicy{RequiredTags: []string{"team"}}
+	assert.Equal(t, 1, len(policy.Evaluate(release, nil)))
+
+	release.Tags = map[string]*string{"team": to.Strp("platform")}
+	assert.Equal(t, 0, len(policy.Evaluate(release, nil)))
+}
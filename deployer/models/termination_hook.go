@@ -0,0 +1,128 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/asg"
+	"github.com/coinbase/step/utils/to"
+)
+
+// terminationHookName is the name of the services termination hook on its ASGs
+const terminationHookName = "odin-termination"
+
+// The max wait must leave time in the Lambda to delete the old ASGs
+const maxTerminationWait = maxTearDownWait
+
+// TerminationHookConfig notifies an SQS queue or SNS topic when an instance is terminating,
+// and when a deploy deletes the old ASG it waits for the instances to complete the lifecycle action
+type TerminationHookConfig struct {
+	LifeCycleHook
+	SQS     *string `json:"sqs,omitempty"`
+	MaxWait *int    `json:"max_wait,omitempty"` // Most seconds to wait for the old instances to terminate
+}
+
+// SetDefaults assigns default values
+func (t *TerminationHookConfig) SetDefaults(region *string, accountID *string) {
+	t.Transistion = to.Strp("autoscaling:EC2_INSTANCE_TERMINATING")
+
+	if t.SQS != nil && t.NotificationTargetARN == nil {
+		t.NotificationTargetARN = to.Strp(fmt.Sprintf("arn:aws:sqs:%v:%v:%v", *region, *accountID, *t.SQS))
+	}
+
+	t.LifeCycleHook.SetDefaults(region, accountID, terminationHookName)
+
+	if t.MaxWait == nil {
+		wait := maxTerminationWait
+		if t.HeartbeatTimeout != nil && *t.HeartbeatTimeout < int64(wait) {
+			wait = int(*t.HeartbeatTimeout)
+		}
+		t.MaxWait = &wait
+	}
+}
+
+// ValidateAttributes validates attributes
+func (t *TerminationHookConfig) ValidateAttributes() error {
+	if (t.SQS == nil) == (t.SNS == nil) {
+		return fmt.Errorf("TerminationHook requires one of sqs or sns")
+	}
+
+	if err := t.LifeCycleHook.ValidateAttributes(); err != nil {
+		return err
+	}
+
+	if t.MaxWait == nil || *t.MaxWait < 0 || *t.MaxWait > maxTerminationWait {
+		return fmt.Errorf("TerminationHook max_wait must be between 0 and %v", maxTerminationWait)
+	}
+
+	return nil
+}
+
+// terminationWait returns the max wait of the services termination hook, old ASGs of removed services do not wait
+func (release *Release) terminationWait(serviceName *string) time.Duration {
+	if serviceName == nil {
+		return 0
+	}
+
+	service, ok := release.Services[*serviceName]
+	if !ok || service.TerminationHook == nil || service.TerminationHook.MaxWait == nil {
+		return 0
+	}
+
+	return time.Duration(*service.TerminationHook.MaxWait) * time.Second
+}
+
+// terminate scales the old ASGs with termination hooks to zero so their instances run the hooks,
+// then waits for at most the max wait of their service or until the deadline before they are deleted anyway
+func (release *Release) terminate(asgc aws.ASGAPI, asgs []*asg.ASG, deadline time.Time) error {
+	terminating := []*asg.ASG{}
+
+	for _, group := range asgs {
+		if release.terminationWait(group.ServiceName()) == 0 {
+			continue
+		}
+
+		hooked, err := group.HasTerminationHooks(asgc)
+		if err != nil {
+			return err
+		}
+
+		if !hooked {
+			continue
+		}
+
+		// Protected instances would not be terminated by scaling in
+		if err := asg.RemoveScaleInProtection(asgc, group.ServiceID()); err != nil {
+			return err
+		}
+
+		if err := asg.UpdateCapacity(asgc, group.ServiceID(), 0, 0); err != nil {
+			return err
+		}
+
+		terminating = append(terminating, group)
+	}
+
+	start := time.Now()
+
+	for _, group := range terminating {
+		until := earliest(start.Add(release.terminationWait(group.ServiceName())), deadline)
+		poll := drainPoll
+
+		for time.Now().Before(until) {
+			instances, err := asg.GetInstances(asgc, group.ServiceID())
+			if err != nil {
+				return err
+			}
+
+			if len(instances) == 0 {
+				break
+			}
+
+			poll = sleepBackoff(poll, until)
+		}
+	}
+
+	return nil
+}
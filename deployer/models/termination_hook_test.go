@@ -0,0 +1,60 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TerminationHookConfig_Defaults(t *testing.T) {
+	hook := &TerminationHookConfig{SQS: to.Strp("drain"), LifeCycleHook: LifeCycleHook{Role: to.Strp("hooks"), HeartbeatTimeout: to.Int64p(120)}}
+	hook.SetDefaults(to.Strp("us-east-1"), to.Strp("000000000000"))
+
+	assert.Equal(t, "arn:aws:sqs:us-east-1:000000000000:drain", *hook.NotificationTargetARN)
+	assert.Equal(t, "autoscaling:EC2_INSTANCE_TERMINATING", *hook.Transistion)
+	assert.Equal(t, 120, *hook.MaxWait)
+	assert.NoError(t, hook.ValidateAttributes())
+
+	hook.SNS = to.Strp("drain")
+	assert.Error(t, hook.ValidateAttributes())
+
+	hook.SNS = nil
+	hook.MaxWait = to.Intp(maxTearDownWait + 1)
+	assert.Error(t, hook.ValidateAttributes())
+}
+
+func Test_Release_SuccessfulTearDown_TerminationHook(t *testing.T) {
+	r := MockRelease(t)
+	MockPrepareRelease(r)
+
+	r.Services["web"].TerminationHook = &TerminationHookConfig{
+		SQS:           to.Strp("drain"),
+		LifeCycleHook: LifeCycleHook{Role: to.Strp("hooks")},
+		MaxWait:       to.Intp(1),
+	}
+	r.SetDefaults()
+	assert.Equal(t, 2, len(r.Services["web"].LifeCycleHookSpecs()))
+
+	drainPoll = 10 * time.Millisecond
+	defer func() { drainPoll = 5 * time.Second }()
+
+	awsc := MockAwsClients(r)
+	awsc.ASG.AddTerminationHook(fmt.Sprintf("%v-%v-web-old-release", *r.ProjectName, *r.ConfigName), terminationHookName)
+
+	asgs, err := r.previousASGs(awsc.ASG)
+	assert.NoError(t, err)
+
+	assert.NoError(t, r.SuccessfulTearDown(awsc.ASG, awsc.ELB, awsc.ALB, awsc.CW, awsc.EC2))
+	assert.Equal(t, 2, len(awsc.ASG.UpdateAutoScalingGroupInputs))
+	assert.Equal(t, int64(0), *awsc.ASG.UpdateAutoScalingGroupInputs[1].DesiredCapacity)
+
+	// The teardown deadline cuts the max wait short
+	r.Services["web"].TerminationHook.MaxWait = to.Intp(maxTearDownWait)
+
+	start := time.Now()
+	assert.NoError(t, r.terminate(awsc.ASG, asgs, start))
+	assert.True(t, time.Since(start) < time.Second)
+}
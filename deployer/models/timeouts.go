@@ -0,0 +1,154 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Deploy phases that can have their own timeout
+const (
+	PhaseCreateResources = "create_resources" // Creating the ASGs and their resources
+	PhaseLaunch          = "launch"           // Until every service has launched its target instances
+	PhaseHealthy         = "healthy"          // Until the launched instances are healthy
+	PhaseDrain           = "drain"            // Tearing down the old ASGs after success
+	PhaseTearDown        = "teardown"         // Tearing down the new ASGs after failure
+)
+
+// TimeoutsConfig are the timeouts in seconds of each deploy phase
+// The release timeout still bounds the whole deploy
+type TimeoutsConfig struct {
+	CreateResources *int `json:"create_resources,omitempty"`
+	Launch          *int `json:"launch,omitempty"`
+	Healthy         *int `json:"healthy,omitempty"`
+	Drain           *int `json:"drain,omitempty"`
+	TearDown        *int `json:"teardown,omitempty"`
+}
+
+// PhaseTimeoutError is returned when a phase takes longer than its timeout
+type PhaseTimeoutError struct {
+	Phase   string
+	Timeout int
+}
+
+// Error returns error
+func (e *PhaseTimeoutError) Error() string {
+	return fmt.Sprintf("Timeout in %v phase after %v seconds", e.Phase, e.Timeout)
+}
+
+// ErrorCode returns the error code of timeouts
+func (e *PhaseTimeoutError) ErrorCode() string {
+	return ErrorCodeHealthTimeout
+}
+
+// ValidateAttributes validates attributes
+func (t *TimeoutsConfig) ValidateAttributes() error {
+	for phase, timeout := range t.timeouts() {
+		if timeout != nil && *timeout < 1 {
+			return fmt.Errorf("Timeouts %v must be greater than 0", phase)
+		}
+	}
+
+	return nil
+}
+
+func (t *TimeoutsConfig) timeouts() map[string]*int {
+	return map[string]*int{
+		PhaseCreateResources: t.CreateResources,
+		PhaseLaunch:          t.Launch,
+		PhaseHealthy:         t.Healthy,
+		PhaseDrain:           t.Drain,
+		PhaseTearDown:        t.TearDown,
+	}
+}
+
+// StartPhase records when the phase started, if it is not already the current phase
+func (release *Release) StartPhase(phase string) {
+	if release.Phase != nil && *release.Phase == phase {
+		return
+	}
+
+	release.Phase = &phase
+	release.PhaseStartedAt = to.Timep(time.Now())
+}
+
+// TearDownPath is where the start of the first teardown attempt is saved
+func (release *Release) TearDownPath() *string {
+	s := fmt.Sprintf("%v/teardown", *release.ReleaseDir())
+	return &s
+}
+
+// StartTearDown starts the teardown phase from the first attempt to tear down
+// Retried attempts start from the release as it failed, so the first attempt is read back from S3
+func (release *Release) StartTearDown(s3c aws.S3API) error {
+	return release.startTearDown(s3c, time.Now())
+}
+
+func (release *Release) startTearDown(s3c aws.S3API, now time.Time) error {
+	if release.TearDownStartedAt == nil {
+		first := &Release{}
+		err := GetArtifact(release.Store(s3c), release.TearDownPath(), first)
+
+		switch {
+		case err == nil && first.TearDownStartedAt != nil:
+			release.TearDownStartedAt = first.TearDownStartedAt
+		case err == nil || IsNotFound(err):
+			release.TearDownStartedAt = &now
+			if err := PutArtifact(release.Store(s3c), release.TearDownPath(), &Release{TearDownStartedAt: &now}); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+	}
+
+	release.Phase = to.Strp(PhaseTearDown)
+	release.PhaseStartedAt = release.TearDownStartedAt
+	return nil
+}
+
+// CheckPhaseTimeout returns a PhaseTimeoutError if the current phase has run longer than its timeout
+func (release *Release) CheckPhaseTimeout() error {
+	return release.checkPhaseTimeout(time.Now())
+}
+
+func (release *Release) checkPhaseTimeout(now time.Time) error {
+	if release.Timeouts == nil || release.Phase == nil || release.PhaseStartedAt == nil {
+		return nil
+	}
+
+	timeout := release.Timeouts.timeouts()[*release.Phase]
+	if timeout == nil {
+		return nil
+	}
+
+	if now.Sub(*release.PhaseStartedAt) > time.Duration(*timeout)*time.Second {
+		return &PhaseTimeoutError{Phase: *release.Phase, Timeout: *timeout}
+	}
+
+	return nil
+}
+
+// UpdatePhase moves from launching to waiting for healthy once every service has launched its target instances,
+// and to draining once the release is healthy
+func (release *Release) UpdatePhase() {
+	if release.Healthy != nil && *release.Healthy {
+		release.StartPhase(PhaseDrain)
+		return
+	}
+
+	if release.Phase == nil || *release.Phase != PhaseLaunch {
+		return
+	}
+
+	for _, service := range release.Services {
+		report := service.HealthReport
+		if report == nil || report.Launching == nil || report.TargetLaunched == nil || *report.Launching < *report.TargetLaunched {
+			return
+		}
+	}
+
+	release.StartPhase(PhaseHealthy)
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TimeoutsConfig_ValidateAttributes(t *testing.T) {
+	assert.NoError(t, (&TimeoutsConfig{Launch: to.Intp(300)}).ValidateAttributes())
+	assert.Error(t, (&TimeoutsConfig{Healthy: to.Intp(0)}).ValidateAttributes())
+}
+
+func Test_Release_CheckPhaseTimeout(t *testing.T) {
+	release := MockRelease(t)
+	assert.NoError(t, release.CheckPhaseTimeout())
+
+	release.Timeouts = &TimeoutsConfig{Launch: to.Intp(60)}
+	release.StartPhase(PhaseLaunch)
+	started := *release.PhaseStartedAt
+
+	assert.NoError(t, release.checkPhaseTimeout(started.Add(30*time.Second)))
+
+	err := release.checkPhaseTimeout(started.Add(90 * time.Second))
+	assert.Error(t, err)
+	assert.Equal(t, PhaseLaunch, err.(*PhaseTimeoutError).Phase)
+	assert.Contains(t, err.Error(), "launch phase")
+
+	// Restarting the same phase keeps its start
+	release.StartPhase(PhaseLaunch)
+	assert.Equal(t, started, *release.PhaseStartedAt)
+
+	// Phases without a timeout never time out
+	release.StartPhase(PhaseHealthy)
+	assert.NoError(t, release.checkPhaseTimeout(started.Add(time.Hour)))
+}
+
+func Test_Release_StartTearDown(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	awsc := MockAwsClients(release)
+	release.Timeouts = &TimeoutsConfig{TearDown: to.Intp(60)}
+
+	first := time.Now().Add(-90 * time.Second)
+	assert.NoError(t, release.startTearDown(awsc.S3, first))
+	assert.Equal(t, PhaseTearDown, *release.Phase)
+
+	// A retried attempt starts from the release as it failed, but is timed from the first attempt
+	retry := MockRelease(t)
+	MockPrepareRelease(retry)
+	retry.Timeouts = &TimeoutsConfig{TearDown: to.Intp(60)}
+
+	assert.NoError(t, retry.StartTearDown(awsc.S3))
+	assert.True(t, first.Equal(*retry.TearDownStartedAt))
+	assert.Error(t, retry.CheckPhaseTimeout())
+}
+
+func Test_Release_UpdatePhase(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	release.StartPhase(PhaseLaunch)
+
+	service := release.Services["web"]
+	service.HealthReport = &HealthReport{TargetLaunched: to.Intp(3), Launching: to.Intp(2)}
+	release.UpdatePhase()
+	assert.Equal(t, PhaseLaunch, *release.Phase)
+
+	service.HealthReport.Launching = to.Intp(3)
+	release.UpdatePhase()
+	assert.Equal(t, PhaseHealthy, *release.Phase)
+
+	release.Healthy = to.Boolp(true)
+	release.UpdatePhase()
+	assert.Equal(t, PhaseDrain, *release.Phase)
+}
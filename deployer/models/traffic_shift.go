@@ -0,0 +1,156 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/alarms"
+	"github.com/coinbase/odin/aws/alb"
+	"github.com/coinbase/odin/aws/weighted"
+	"github.com/coinbase/step/utils/to"
+)
+
+// ShiftConfig gradually moves a listener rules traffic to the new ASGs target group
+// Each weight is held for the bake period, and the deploy halts if any alarm breaches
+type ShiftConfig struct {
+	Weights []int64   `json:"weights,omitempty"` // Percent of traffic sent to the new target group each step
+	Bake    *int      `json:"bake,omitempty"`    // Seconds each weight is held before the next step
+	Alarms  []*string `json:"alarms,omitempty"`  // CloudWatch alarm names that roll the deploy back
+
+	// Generated
+	Step      *int       `json:"step,omitempty"`
+	ShiftedAt *time.Time `json:"shifted_at,omitempty"`
+}
+
+// SetDefaults assigns default values
+func (s *ShiftConfig) SetDefaults() {
+	if len(s.Weights) == 0 {
+		s.Weights = []int64{10, 50, 100}
+	}
+
+	if s.Bake == nil {
+		s.Bake = to.Intp(300)
+	}
+}
+
+// ValidateAttributes validates attributes
+func (s *ShiftConfig) ValidateAttributes() error {
+	last := int64(0)
+	for _, weight := range s.Weights {
+		if weight <= last || weight > 100 {
+			return fmt.Errorf("Shift weights must be increasing and between 1 and 100")
+		}
+		last = weight
+	}
+
+	if last != 100 {
+		return fmt.Errorf("Shift weights must end at 100")
+	}
+
+	if s.Bake == nil || *s.Bake < 0 {
+		return fmt.Errorf("Shift bake must be 0 or greater")
+	}
+
+	return nil
+}
+
+// shifted returns whether the final weight has been held for the bake period
+func (s *ShiftConfig) shifted(now time.Time) bool {
+	return s.Step != nil && *s.Step == len(s.Weights)-1 && s.baked(now)
+}
+
+func (s *ShiftConfig) baked(now time.Time) bool {
+	return s.ShiftedAt != nil && now.Sub(*s.ShiftedAt) >= time.Duration(*s.Bake)*time.Second
+}
+
+// checkAlarms halts the deploy if any of the alarms is breached
+func (s *ShiftConfig) checkAlarms(cwc aws.CWAPI, prefix string) error {
+	breached, err := alarms.InAlarm(cwc, s.Alarms)
+	if err != nil {
+		return err // This might retry
+	}
+
+	if len(breached) > 0 {
+		err := fmt.Errorf("%v Alarms breached while shifting traffic %v", prefix, strings.Join(breached, ","))
+		return &HaltError{err} // This will immediately stop deploying
+	}
+
+	return nil
+}
+
+// shiftTraffic checks the alarms then moves to the next weight once the current one has baked
+// The service is only healthy once all traffic has shifted
+func (service *Service) shiftTraffic(api weighted.API, cwc aws.CWAPI, now time.Time) error {
+	l := service.ListenerRule
+	shift := l.Shift
+
+	if err := shift.checkAlarms(cwc, service.errorPrefix()); err != nil {
+		return err
+	}
+
+	if !shift.shifted(now) && (shift.Step == nil || shift.baked(now)) {
+		step := 0
+		if shift.Step != nil {
+			step = *shift.Step + 1
+		}
+
+		if step < len(shift.Weights) {
+			if err := weighted.Forward(api, l.FoundRuleArn, l.ActiveTargetGroupArn, l.NextTargetGroupArn, shift.Weights[step]); err != nil {
+				return err // This might retry
+			}
+
+			shift.Step = &step
+			shift.ShiftedAt = &now
+		}
+	}
+
+	service.Healthy = shift.shifted(now)
+	return nil
+}
+
+// ShiftTraffic shifts the traffic of every healthy service with a weighted listener rule
+func (release *Release) ShiftTraffic(albc aws.ALBAPI, cwc aws.CWAPI, now time.Time) error {
+	healthy := true
+
+	for _, service := range release.Services {
+		if service.Healthy && service.shifting() && !service.IsHalted() {
+			api, err := weighted.Client(albc)
+			if err != nil {
+				return err
+			}
+
+			if err := service.shiftTraffic(api, cwc, now); err != nil {
+				return err
+			}
+		}
+
+		healthy = healthy && service.Healthy
+	}
+
+	release.Healthy = &healthy
+	return nil
+}
+
+// shifting returns whether the service shifts traffic between weighted target groups
+func (service *Service) shifting() bool {
+	l := service.ListenerRule
+	return l != nil && l.Shift != nil && l.FoundRuleArn != nil && l.NextTargetGroupArn != nil
+}
+
+// RollbackTraffic sends all of the traffic of shifted listener rules back to the old target group
+func (release *Release) RollbackTraffic(albc aws.ALBAPI) error {
+	for _, service := range release.Services {
+		if !service.shifting() || service.ListenerRule.Shift.Step == nil {
+			continue
+		}
+
+		l := service.ListenerRule
+		if err := alb.SetForwardTargetGroup(albc, l.FoundRuleArn, l.ActiveTargetGroupArn); err != nil {
+			return fmt.Errorf("%v %v", service.errorPrefix(), err.Error())
+		}
+	}
+
+	return nil
+}
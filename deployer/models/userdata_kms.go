@@ -0,0 +1,151 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// DefaultUserDataKMSKey is the AWS managed key userdata is encrypted with unless the release sets user_data_kms_key
+const DefaultUserDataKMSKey = "alias/aws/s3"
+
+// ParseUserDataKMSKeys parses the comma separated customer managed key ARNs userdata must be encrypted with,
+// empty allows any key including the AWS managed key
+func ParseUserDataKMSKeys(raw string) []string {
+	return parseKeys(raw)
+}
+
+// UserDataKMSKeyID returns the key the client encrypts the releases userdata with
+func (release *Release) UserDataKMSKeyID() *string {
+	if release.UserDataKMSKey != nil {
+		return release.UserDataKMSKey
+	}
+	return to.Strp(DefaultUserDataKMSKey)
+}
+
+// ValidateUserDataKMSKey checks the releases userdata is encrypted with one of the allowed keys,
+// and that each keys policy lets the deployers role decrypt it
+func (release *Release) ValidateUserDataKMSKey(s3c aws.S3API, kmsc aws.KMSAPI, deployerRole *string, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	if key := *release.UserDataKMSKeyID(); !containsStr(allowed, key) {
+		return fmt.Errorf("user_data_kms_key %v is not allowed, userdata must be encrypted with one of %v", key, strings.Join(allowed, ", "))
+	}
+
+	// The key the userdata was actually encrypted with, which is always an ARN
+	keyID, err := release.Store(s3c).EncryptionKey(release.UserDataPath())
+	if err != nil {
+		return fmt.Errorf("Error Getting UserData with %v", err.Error())
+	}
+
+	encryptedWith := to.Strs(keyID)
+	if !containsStr(allowed, encryptedWith) {
+		return fmt.Errorf("UserData is encrypted with %q, which is not allowed", encryptedWith)
+	}
+
+	policy, err := kmsc.GetKeyPolicy(&kms.GetKeyPolicyInput{KeyId: &encryptedWith, PolicyName: to.Strp("default")})
+	if err != nil {
+		return fmt.Errorf("Error Getting key policy of %v with %v", encryptedWith, err.Error())
+	}
+
+	permits, err := keyPolicyPermitsDecrypt(to.Strs(policy.Policy), to.Strs(deployerRole))
+	if err != nil {
+		return fmt.Errorf("Key policy of %v invalid %v", encryptedWith, err.Error())
+	}
+
+	if !permits {
+		return fmt.Errorf("Key policy of %v does not allow the deployer role %v to kms:Decrypt", encryptedWith, to.Strs(deployerRole))
+	}
+
+	return nil
+}
+
+// keyPolicyStatement is the part of a key policy statement that decides who can use the key
+// Conditions are not evaluated, so a conditional Allow is treated as allowing
+type keyPolicyStatement struct {
+	Effect    string
+	Principal interface{}
+	Action    interface{}
+}
+
+// keyPolicyPermitsDecrypt returns whether the key policy allows the role to decrypt, directly, through its
+// account (which delegates to IAM), or through any principal, and does not deny it
+func keyPolicyPermitsDecrypt(raw string, role string) (bool, error) {
+	var policy struct {
+		Statement []*keyPolicyStatement
+	}
+
+	if err := json.Unmarshal([]byte(raw), &policy); err != nil {
+		return false, err
+	}
+
+	principals := map[string]bool{"*": true, role: true}
+	if parts := strings.Split(role, ":"); len(parts) == 6 {
+		principals[parts[4]] = true
+		principals[fmt.Sprintf("arn:%v:iam::%v:root", parts[1], parts[4])] = true
+	}
+
+	allowed := false
+	for _, statement := range policy.Statement {
+		if statement == nil || !statement.decrypts() || !statement.appliesTo(principals) {
+			continue
+		}
+
+		if statement.Effect == "Deny" {
+			return false, nil
+		}
+
+		allowed = allowed || statement.Effect == "Allow"
+	}
+
+	return allowed, nil
+}
+
+func (statement *keyPolicyStatement) decrypts() bool {
+	for _, action := range stringOrList(statement.Action) {
+		if action == "*" || action == "kms:*" || action == "kms:Decrypt" {
+			return true
+		}
+	}
+	return false
+}
+
+func (statement *keyPolicyStatement) appliesTo(principals map[string]bool) bool {
+	if p, ok := statement.Principal.(string); ok {
+		return p == "*"
+	}
+
+	if p, ok := statement.Principal.(map[string]interface{}); ok {
+		for _, principal := range stringOrList(p["AWS"]) {
+			if principals[principal] {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// stringOrList returns a policy value that is either a string or a list of strings as a list
+func stringOrList(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := []string{}
+		for _, s := range v {
+			if str, ok := s.(string); ok {
+				values = append(values, str)
+			}
+		}
+		return values
+	default:
+		return []string{}
+	}
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"testing"
+
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/coinbase/odin/aws/mocks"
+	stepmocks "github.com/coinbase/step/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+// encryptedS3 returns objects as encrypted with a KMS key
+type encryptedS3 struct {
+	*stepmocks.MockS3Client
+	keyID *string
+}
+
+func (m *encryptedS3) GetObject(in *awss3.GetObjectInput) (*awss3.GetObjectOutput, error) {
+	out, err := m.MockS3Client.GetObject(in)
+	if out != nil {
+		out.SSEKMSKeyId = m.keyID
+	}
+	return out, err
+}
+
+func Test_Release_ValidateUserDataKMSKey(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+
+	cmk := "arn:aws:kms:us-east-1:000000000000:key/userdata"
+	role := to.Strp("arn:aws:iam::000000000000:role/coinbase-odin")
+
+	s3c := &encryptedS3{MockS3Client: &stepmocks.MockS3Client{}, keyID: to.Strp("arn:aws:kms:us-east-1:000000000000:key/aws-s3")}
+	s3c.AddGetObject(*release.UserDataPath(), "#cloud_config", nil)
+
+	kmsc := &mocks.KMSClient{Policies: map[string]string{cmk: `{"Statement": [
+		{"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::000000000000:role/coinbase-odin"}, "Action": ["kms:Decrypt", "kms:DescribeKey"], "Resource": "*"}
+	]}`}}
+
+	// Nothing is required without allowed keys
+	assert.NoError(t, release.ValidateUserDataKMSKey(s3c, kmsc, role, nil))
+
+	// The AWS managed key is not allowed
+	err := release.ValidateUserDataKMSKey(s3c, kmsc, role, []string{cmk})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "alias/aws/s3 is not allowed")
+
+	// The release must not claim a key the userdata was not encrypted with
+	release.UserDataKMSKey = to.Strp(cmk)
+	assert.Error(t, release.ValidateUserDataKMSKey(s3c, kmsc, role, []string{cmk}))
+
+	s3c.keyID = to.Strp(cmk)
+	assert.NoError(t, release.ValidateUserDataKMSKey(s3c, kmsc, role, []string{cmk}))
+
+	// The key policy must let the deployer decrypt
+	err = release.ValidateUserDataKMSKey(s3c, kmsc, to.Strp("arn:aws:iam::000000000000:role/other"), []string{cmk})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "does not allow the deployer role")
+}
+
+func Test_keyPolicyPermitsDecrypt(t *testing.T) {
+	role := "arn:aws:iam::000000000000:role/coinbase-odin"
+	permits := func(policy string) bool {
+		ok, err := keyPolicyPermitsDecrypt(policy, role)
+		assert.NoError(t, err)
+		return ok
+	}
+
+	// The account root delegates to IAM
+	assert.True(t, permits(`{"Statement": [{"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::000000000000:root"}, "Action": "kms:*"}]}`))
+	assert.True(t, permits(`{"Statement": [{"Effect": "Allow", "Principal": "*", "Action": "kms:Decrypt"}]}`))
+
+	assert.False(t, permits(`{"Statement": [{"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::111111111111:root"}, "Action": "kms:*"}]}`))
+	assert.False(t, permits(`{"Statement": [{"Effect": "Allow", "Principal": {"AWS": ["`+role+`"]}, "Action": "kms:Encrypt"}]}`))
+	assert.False(t, permits(`{"Statement": [
+		{"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::000000000000:root"}, "Action": "kms:*"},
+		{"Effect": "Deny", "Principal": {"AWS": "`+role+`"}, "Action": "kms:Decrypt"}
+	]}`))
+
+	_, err := keyPolicyPermitsDecrypt("not json", role)
+	assert.Error(t, err)
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultValidationBudget is how long a validation state can take, leaving most of the 300 second Lambda timeout spare
+const DefaultValidationBudget = 120 * time.Second
+
+// slowValidationCheck is how long a check can take before it is reported
+const slowValidationCheck = 10 * time.Second
+
+// CheckTiming is how long a validation check took
+type CheckTiming struct {
+	State    string  `json:"state"`
+	Name     string  `json:"name"`
+	Duration float64 `json:"duration"` // Seconds
+	Slow     bool    `json:"slow,omitempty"`
+}
+
+// ParseValidationBudget parses the seconds a validation state can take, an empty string is the default budget
+func ParseValidationBudget(raw string) (time.Duration, error) {
+	if raw == "" {
+		return DefaultValidationBudget, nil
+	}
+
+	seconds, err := strconv.Atoi(raw)
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("Validation budget %q must be a positive number of seconds", raw)
+	}
+
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// ValidationTimer times the checks of a validation state against its budget
+type ValidationTimer struct {
+	release *Release
+	state   string
+	budget  time.Duration
+	slow    time.Duration
+	start   time.Time
+}
+
+// NewValidationTimer starts timing the validation state
+func (release *Release) NewValidationTimer(state string, budget time.Duration) *ValidationTimer {
+	return &ValidationTimer{
+		release: release,
+		state:   state,
+		budget:  budget,
+		slow:    slowValidationCheck,
+		start:   time.Now(),
+	}
+}
+
+// Time runs the check and records how long it took, slow checks are reported as EMF records
+// It returns the checks error, or an error if the state has taken longer than its budget
+func (timer *ValidationTimer) Time(name string, check func() error) error {
+	start := time.Now()
+	err := check()
+	duration := time.Since(start)
+
+	timing := &CheckTiming{
+		State:    timer.state,
+		Name:     name,
+		Duration: duration.Seconds(),
+		Slow:     duration >= timer.slow,
+	}
+
+	timer.release.ValidationTimings = append(timer.release.ValidationTimings, timing)
+
+	if timing.Slow {
+		timer.release.EmitSlowCheck(timing) // Best effort
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if elapsed := time.Since(timer.start); elapsed > timer.budget {
+		return fmt.Errorf(
+			"%v %v took %.1fs, over its %v budget, slowest checks: %v",
+			timer.release.ErrorPrefix(), timer.state, elapsed.Seconds(), timer.budget, timer.slowest(3),
+		)
+	}
+
+	return nil
+}
+
+// slowest returns the n slowest checks of the state
+func (timer *ValidationTimer) slowest(n int) string {
+	timings := []*CheckTiming{}
+	for _, t := range timer.release.ValidationTimings {
+		if t.State == timer.state {
+			timings = append(timings, t)
+		}
+	}
+
+	sort.SliceStable(timings, func(i, j int) bool {
+		return timings[i].Duration > timings[j].Duration
+	})
+
+	names := []string{}
+	for i, t := range timings {
+		if i >= n {
+			break
+		}
+		names = append(names, fmt.Sprintf("%v (%.1fs)", t.Name, t.Duration))
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// EmitSlowCheck writes an EMF record of the slow check, dimensioned by the state and check
+func (release *Release) EmitSlowCheck(timing *CheckTiming) error {
+	if release.ProjectName == nil || release.ConfigName == nil {
+		return nil // Dimensions must have values
+	}
+
+	record := release.emfRecord(time.Now(), map[string]string{"State": timing.State, "Check": timing.Name}, []*Metric{
+		&Metric{Name: "SlowValidationCheck", Unit: "Seconds", Value: timing.Duration},
+	})
+
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	_, err = emfWriter.Write(append(raw, '\n'))
+	return err
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseValidationBudget(t *testing.T) {
+	budget, err := ParseValidationBudget("")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultValidationBudget, budget)
+
+	budget, err = ParseValidationBudget("60")
+	assert.NoError(t, err)
+	assert.Equal(t, 60*time.Second, budget)
+
+	_, err = ParseValidationBudget("0")
+	assert.Error(t, err)
+
+	_, err = ParseValidationBudget("1m")
+	assert.Error(t, err)
+}
+
+func Test_ValidationTimer_Time(t *testing.T) {
+	release := MockRelease(t)
+
+	timer := release.NewValidationTimer("ValidateResources", time.Minute)
+	assert.NoError(t, timer.Time("fetch_resources", func() error { return nil }))
+	assert.EqualError(t, timer.Time("quotas", func() error { return fmt.Errorf("quota") }), "quota")
+
+	assert.Equal(t, 2, len(release.ValidationTimings))
+	assert.Equal(t, "fetch_resources", release.ValidationTimings[0].Name)
+	assert.Equal(t, "ValidateResources", release.ValidationTimings[0].State)
+	assert.False(t, release.ValidationTimings[0].Slow)
+}
+
+func Test_ValidationTimer_OverBudget(t *testing.T) {
+	var buf bytes.Buffer
+	writer := emfWriter
+	emfWriter = &buf
+	defer func() { emfWriter = writer }()
+
+	release := MockRelease(t)
+
+	timer := release.NewValidationTimer("Validate", 0)
+	timer.slow = 0
+
+	err := timer.Time("release", func() error { return nil })
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "over its 0s budget, slowest checks: release")
+
+	// Slow checks are reported
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, 1, len(lines))
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "release", record["Check"])
+	assert.Equal(t, "Validate", record["State"])
+	assert.NotNil(t, record["SlowValidationCheck"])
+}
@@ -0,0 +1,284 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coinbase/odin/aws"
+	"github.com/coinbase/odin/aws/ecr"
+	"github.com/coinbase/odin/aws/secret"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Types of artifacts a release deploys
+const (
+	ArtifactAMI       = "ami"
+	ArtifactUserData  = "userdata"
+	ArtifactContainer = "container"
+)
+
+var verifierClient = &http.Client{Timeout: 30 * time.Second}
+
+// Artifact is something deployed by the release that is checked by the verifiers
+type Artifact struct {
+	Type    string  `json:"type"`
+	Service *string `json:"service,omitempty"` // Containers are per service
+	URI     *string `json:"uri,omitempty"`     // AMI ID or container image URI
+	SHA256  *string `json:"sha256,omitempty"`
+}
+
+func (a *Artifact) String() string {
+	if a.URI != nil {
+		return fmt.Sprintf("%v %v", a.Type, *a.URI)
+	}
+	return a.Type
+}
+
+// Verification is the result of a verifier checking an artifact
+type Verification struct {
+	Verifier string  `json:"verifier"`
+	Artifact string  `json:"artifact"`
+	Passed   bool    `json:"passed"`
+	Message  *string `json:"message,omitempty"`
+}
+
+// Verifier checks an artifact of the release, returning an error if it must not be deployed
+type Verifier interface {
+	Name() string
+	Verify(release *Release, artifact *Artifact) error
+}
+
+// ParseVerifiers parses the comma separated chain of verifiers, an empty string has no verifiers
+// The verifiers are "sha256" and "webhook:<secret reference>"
+func ParseVerifiers(raw string, ssmc aws.SSMAPI, smc aws.SMAPI, s3c aws.S3API, ecrc aws.ECRAPI) ([]Verifier, error) {
+	verifiers := []Verifier{}
+
+	for _, v := range strings.Split(raw, ",") {
+		v = strings.TrimSpace(v)
+		name, arg := v, ""
+		if i := strings.Index(v, ":"); i >= 0 {
+			name, arg = v[:i], v[i+1:]
+		}
+
+		switch name {
+		case "":
+			continue
+		case "sha256":
+			verifiers = append(verifiers, &sha256Verifier{s3c: s3c, ecrc: ecrc})
+		case "webhook":
+			if err := secret.ValidateReference(&arg); err != nil {
+				return nil, fmt.Errorf("Verifier %q invalid %v", v, err.Error())
+			}
+			verifiers = append(verifiers, &webhookVerifier{ref: arg, ssmc: ssmc, smc: smc})
+		default:
+			return nil, fmt.Errorf("Verifier %q unknown", v)
+		}
+	}
+
+	return verifiers, nil
+}
+
+// Artifacts returns the AMI, userdata and containers deployed by the release
+func (release *Release) Artifacts() []*Artifact {
+	artifacts := []*Artifact{
+		&Artifact{Type: ArtifactUserData, SHA256: release.UserDataSHA256},
+	}
+
+	names := []string{}
+	for name := range release.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	amis := map[string]bool{}
+	for _, name := range names {
+		service := release.Services[name]
+		if service.Resources != nil && service.Resources.Image != nil && !amis[*service.Resources.Image] {
+			amis[*service.Resources.Image] = true
+			artifacts = append(artifacts, &Artifact{Type: ArtifactAMI, URI: service.Resources.Image})
+		}
+	}
+
+	for _, name := range names {
+		for _, container := range release.Services[name].Containers {
+			artifacts = append(artifacts, &Artifact{
+				Type:    ArtifactContainer,
+				Service: to.Strp(name),
+				URI:     container,
+				SHA256:  containerDigest(container),
+			})
+		}
+	}
+
+	return artifacts
+}
+
+// containerDigest returns the digest of a container image URI pinned with @sha256:
+func containerDigest(uri *string) *string {
+	i := strings.Index(to.Strs(uri), "@sha256:")
+	if i < 0 {
+		return nil
+	}
+	return to.Strp((*uri)[i+len("@sha256:"):])
+}
+
+// Verify runs the chain of verifiers over every artifact recording the results in the release,
+// an artifact that fails a verifier is not checked by the rest of the chain
+func (release *Release) Verify(verifiers []Verifier) error {
+	if len(verifiers) == 0 {
+		return nil
+	}
+
+	release.Verifications = []*Verification{}
+	failures := []string{}
+
+	for _, artifact := range release.Artifacts() {
+		for _, verifier := range verifiers {
+			verification := &Verification{Verifier: verifier.Name(), Artifact: artifact.String(), Passed: true}
+			release.Verifications = append(release.Verifications, verification)
+
+			if err := verifier.Verify(release, artifact); err != nil {
+				verification.Passed = false
+				verification.Message = to.Strp(err.Error())
+				failures = append(failures, fmt.Sprintf("%v %v", artifact.String(), err.Error()))
+				break
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("Artifact verification failed: %v", strings.Join(failures, ", "))
+	}
+
+	return nil
+}
+
+// sha256Verifier requires every artifact to be pinned by its SHA256 and match the SHA256 it was published with:
+// the userdata uploaded with the release, and the digest of the container image pushed to ECR in the releases region
+// Images in other registries cannot be checked so fail it, AMI IDs are immutable
+type sha256Verifier struct {
+	s3c  aws.S3API
+	ecrc aws.ECRAPI
+}
+
+func (v *sha256Verifier) Name() string {
+	return "sha256"
+}
+
+func (v *sha256Verifier) Verify(release *Release, artifact *Artifact) error {
+	if artifact.Type == ArtifactAMI {
+		return nil
+	}
+
+	if artifact.SHA256 == nil {
+		return fmt.Errorf("is not pinned by SHA256")
+	}
+
+	switch artifact.Type {
+	case ArtifactUserData:
+		return v.verifyUserData(release, artifact)
+	case ArtifactContainer:
+		return v.verifyContainer(release, artifact)
+	}
+
+	return fmt.Errorf("cannot be verified by SHA256")
+}
+
+// verifyUserData compares the SHA256 of the uploaded userdata with the one the release was published with
+func (v *sha256Verifier) verifyUserData(release *Release, artifact *Artifact) error {
+	raw, err := release.Store(v.s3c).Get(release.UserDataPath())
+	if err != nil {
+		return err
+	}
+
+	if sha := to.SHA256Str(to.Strp(string(raw))); sha != *artifact.SHA256 {
+		return fmt.Errorf("SHA256 %v does not match the published %v", sha, *artifact.SHA256)
+	}
+
+	return nil
+}
+
+// verifyContainer compares the pinned digest with the digests ECR published for the image
+func (v *sha256Verifier) verifyContainer(release *Release, artifact *Artifact) error {
+	image, err := ecr.Parse(artifact.URI)
+	if err != nil || *image.Region != to.Strs(release.AwsRegion) {
+		return fmt.Errorf("is not in ECR in %v so its SHA256 cannot be verified", to.Strs(release.AwsRegion))
+	}
+
+	published, err := image.Published(v.ecrc, to.Strp("sha256:"+*artifact.SHA256))
+	if err != nil {
+		return err
+	}
+
+	if !published {
+		return fmt.Errorf("SHA256 %v does not match any image published in ECR", *artifact.SHA256)
+	}
+
+	return nil
+}
+
+// webhookVerifier posts the artifact to an https endpoint, e.g. a signature or malware scanner,
+// which must respond with a 2xx status for it to pass
+type webhookVerifier struct {
+	ref  string
+	ssmc aws.SSMAPI
+	smc  aws.SMAPI
+}
+
+// VerifyRequest is the JSON posted to webhook verifiers
+type VerifyRequest struct {
+	ProjectName  *string   `json:"project_name,omitempty"`
+	ConfigName   *string   `json:"config_name,omitempty"`
+	ReleaseID    *string   `json:"release_id,omitempty"`
+	AwsAccountID *string   `json:"aws_account_id,omitempty"`
+	AwsRegion    *string   `json:"aws_region,omitempty"`
+	Artifact     *Artifact `json:"artifact"`
+}
+
+func (v *webhookVerifier) Name() string {
+	return "webhook:" + v.ref
+}
+
+func (v *webhookVerifier) Verify(release *Release, artifact *Artifact) error {
+	webhook, err := secret.Get(v.ssmc, v.smc, &v.ref)
+	if err != nil {
+		return err
+	}
+
+	u, err := url.Parse(*webhook)
+	if err != nil || u.Scheme != "https" {
+		// Do not include the URL in the error as it is a secret
+		return fmt.Errorf("Verifier %v must be an https URL", v.ref)
+	}
+
+	raw, err := json.Marshal(&VerifyRequest{
+		ProjectName:  release.ProjectName,
+		ConfigName:   release.ConfigName,
+		ReleaseID:    release.ReleaseID,
+		AwsAccountID: release.AwsAccountID,
+		AwsRegion:    release.AwsRegion,
+		Artifact:     artifact,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	resp, err := verifierClient.Post(u.String(), "application/json", bytes.NewReader(raw))
+	if err != nil {
+		return fmt.Errorf("Verifier %v failed", v.ref)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("rejected by verifier %v with %v", v.ref, resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseVerifiers(t *testing.T) {
+	awsc := mocks.MockAWS()
+
+	verifiers, err := ParseVerifiers("", awsc.SSM, awsc.SM, awsc.S3, awsc.ECR)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(verifiers))
+
+	verifiers, err = ParseVerifiers("sha256, webhook:ssm:/odin/scanner", awsc.SSM, awsc.SM, awsc.S3, awsc.ECR)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(verifiers))
+	assert.Equal(t, "webhook:ssm:/odin/scanner", verifiers[1].Name())
+
+	_, err = ParseVerifiers("webhook:https://example.com", awsc.SSM, awsc.SM, awsc.S3, awsc.ECR)
+	assert.Error(t, err)
+
+	_, err = ParseVerifiers("unknown", awsc.SSM, awsc.SM, awsc.S3, awsc.ECR)
+	assert.Error(t, err)
+}
+
+func Test_Release_Artifacts(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	awsc := MockAwsClients(release)
+	digest := to.SHA256Str(to.Strp("web image"))
+
+	service := release.Services["web"]
+	service.Resources.Image = to.Strp("ami-123456")
+	service.Containers = []*string{
+		to.Strp("000000000000.dkr.ecr.region.amazonaws.com/web@sha256:" + digest),
+		to.Strp("000000000000.dkr.ecr.region.amazonaws.com/web:latest"),
+	}
+
+	artifacts := release.Artifacts()
+	assert.Equal(t, ArtifactUserData, artifacts[0].Type)
+	assert.Equal(t, ArtifactAMI, artifacts[1].Type)
+	assert.Equal(t, digest, *artifacts[2].SHA256)
+	assert.Nil(t, artifacts[3].SHA256)
+
+	verifier := &sha256Verifier{s3c: awsc.S3, ecrc: awsc.ECR}
+
+	// Unpinned containers fail the sha256 verifier
+	err := release.Verify([]Verifier{verifier})
+	assert.Error(t, err)
+	assert.Equal(t, 4, len(release.Verifications))
+	assert.False(t, release.Verifications[3].Passed)
+
+	// Pinned containers must be published with the digest
+	service.Containers = service.Containers[:1]
+	assert.Error(t, release.Verify([]Verifier{verifier}))
+	assert.False(t, release.Verifications[2].Passed)
+
+	awsc.ECR.AddImage("web", "sha256:"+digest)
+	assert.NoError(t, release.Verify([]Verifier{verifier}))
+}
+
+func Test_Release_Verify_SHA256_Mismatch(t *testing.T) {
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	awsc := MockAwsClients(release)
+	verifier := &sha256Verifier{s3c: awsc.S3, ecrc: awsc.ECR}
+
+	assert.NoError(t, release.Verify([]Verifier{verifier}))
+
+	// The userdata uploaded is not the userdata the release was published with
+	awsc.S3.AddGetObject(*release.UserDataPath(), "#tampered", nil)
+	assert.Error(t, release.Verify([]Verifier{verifier}))
+	assert.False(t, release.Verifications[0].Passed)
+
+	// A digest pushed to another repository does not match
+	awsc.S3.AddGetObject(*release.UserDataPath(), *release.UserData(), nil)
+	digest := to.SHA256Str(to.Strp("web image"))
+	awsc.ECR.AddImage("other", "sha256:"+digest)
+	release.Services["web"].Containers = []*string{to.Strp("000000000000.dkr.ecr.region.amazonaws.com/web@sha256:" + digest)}
+	assert.Error(t, release.Verify([]Verifier{verifier}))
+
+	// Images outside ECR in the releases region cannot be verified
+	release.Services["web"].Containers = []*string{to.Strp("docker.io/library/web@sha256:" + digest)}
+	assert.Error(t, release.Verify([]Verifier{verifier}))
+}
+
+func Test_Release_Verify_Webhook(t *testing.T) {
+	var received []*VerifyRequest
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := ioutil.ReadAll(r.Body)
+		var req VerifyRequest
+		assert.NoError(t, json.Unmarshal(raw, &req))
+		received = append(received, &req)
+
+		if req.Artifact.Type == ArtifactAMI {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	defaultClient := verifierClient
+	verifierClient = server.Client()
+	defer func() { verifierClient = defaultClient }()
+
+	awsc := mocks.MockAWS()
+	awsc.SSM.AddParameter("/odin/scanner", server.URL)
+
+	release := MockRelease(t)
+	MockPrepareRelease(release)
+	awsc.S3.AddGetObject(*release.UserDataPath(), *release.UserData(), nil)
+
+	verifiers, err := ParseVerifiers("sha256,webhook:ssm:/odin/scanner", awsc.SSM, awsc.SM, awsc.S3, awsc.ECR)
+	assert.NoError(t, err)
+
+	assert.NoError(t, release.Verify(verifiers))
+	assert.Equal(t, 1, len(received))
+	assert.Equal(t, *release.ReleaseID, *received[0].ReleaseID)
+
+	release.Services["web"].Resources.Image = to.Strp("ami-123456")
+	assert.Error(t, release.Verify(verifiers))
+	assert.Equal(t, 3, len(received))
+	assert.False(t, release.Verifications[3].Passed)
+}
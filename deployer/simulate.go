@@ -0,0 +1,170 @@
+package deployer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/coinbase/odin/aws/mocks"
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Failures that can be injected into a simulated deploy
+const (
+	FailASGCreate    = "asg_create"    // Creating the AutoScaling Group errors
+	FailNeverHealthy = "never_healthy" // Instances never become healthy and the deploy times out
+	FailHalt         = "halt"          // The deploy is halted while checking health
+	FailUserDataSHA  = "userdata_sha"  // The userdata in S3 does not match the releases SHA
+)
+
+var simulatedFailures = map[string]bool{
+	FailASGCreate:    true,
+	FailNeverHealthy: true,
+	FailHalt:         true,
+	FailUserDataSHA:  true,
+}
+
+// Simulation is the result of a simulated deploy
+type Simulation struct {
+	Path   []string
+	Output string
+	Error  error
+}
+
+// Simulate runs the release through the state machine against mocked AWS resources that match it
+// failure is one of the Fail constants, or empty for a successful deploy
+func Simulate(release *models.Release, failure string) (*Simulation, error) {
+	if failure != "" && !simulatedFailures[failure] {
+		return nil, fmt.Errorf("Unknown failure %q", failure)
+	}
+
+	if failure == FailNeverHealthy {
+		// Time out on the first unhealthy check instead of waiting for the releases timeout
+		release.Timeout = to.Intp(1)
+	}
+
+	awsc, err := simulatedAwsClients(release)
+	if err != nil {
+		return nil, err
+	}
+
+	tm := CreateTaskFunctinons(awsc)
+
+	switch failure {
+	case FailASGCreate:
+		awsc.ASG.CreateAutoScalingGroupError = fmt.Errorf("Simulated CreateAutoScalingGroup failure")
+	case FailNeverHealthy:
+		neverHealthy(awsc, release)
+	case FailHalt:
+		tm["CheckHealthy"] = withOffload(awsc, "CheckHealthy", haltFirst(awsc, CheckHealthy(awsc)))
+	case FailUserDataSHA:
+		awsc.S3.AddGetObject(*release.UserDataPath(), "simulated tampered userdata", nil)
+	}
+
+	stateMachine, err := StateMachine()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := stateMachine.SetTaskFnHandlers(tm); err != nil {
+		return nil, err
+	}
+
+	exec, err := stateMachine.Execute(release)
+	return &Simulation{Path: exec.Path(), Output: exec.LastOutputJSON, Error: err}, nil
+}
+
+// simulatedAwsClients mocks every resource the release references as existing and correctly tagged
+func simulatedAwsClients(release *models.Release) (*mocks.MockClients, error) {
+	if release.ProjectName == nil || release.ConfigName == nil || release.Image == nil {
+		return nil, fmt.Errorf("Release must have project_name, config_name and ami")
+	}
+
+	awsc := mocks.MockAWS()
+	projectName, configName := *release.ProjectName, *release.ConfigName
+
+	awsc.EC2.AddImage(*release.Image, "ami-simulated")
+	for i, subnet := range release.Subnets {
+		awsc.EC2.AddSubnet(to.Strs(subnet), fmt.Sprintf("subnet-simulated%v", i))
+	}
+
+	for _, lc := range release.LifeCycleHooks {
+		if lc != nil && lc.Role != nil {
+			awsc.IAM.AddGetRole(*lc.Role)
+		}
+	}
+
+	for name, service := range release.Services {
+		if service == nil {
+			continue
+		}
+
+		awsc.ASG.AddPreviousRuntimeResources(projectName, configName, name, "simulated-previous-release")
+
+		for _, sg := range service.SecurityGroups {
+			awsc.EC2.AddSecurityGroup(to.Strs(sg), projectName, configName, name, nil)
+		}
+
+		for _, elb := range service.ELBs {
+			awsc.ELB.AddELB(to.Strs(elb), projectName, configName, name)
+		}
+
+		for _, tg := range service.TargetGroups {
+			awsc.ALB.AddTargetGroup(to.Strs(tg), projectName, configName, name)
+		}
+
+		if service.Profile != nil {
+			awsc.IAM.AddGetInstanceProfile(*service.Profile, fmt.Sprintf("/odin/%v/%v/%v/", projectName, configName, name))
+		}
+	}
+
+	// The release and userdata are uploaded by the client
+	release.UserDataSHA256 = to.Strp(to.SHA256Str(release.UserData()))
+	awsc.S3.AddGetObject(*release.UserDataPath(), to.Strs(release.UserData()), nil)
+
+	raw, err := json.Marshal(release)
+	if err != nil {
+		return nil, err
+	}
+	awsc.S3.AddGetObject(*release.ReleasePath(), string(raw), nil)
+
+	return awsc, nil
+}
+
+// neverHealthy makes every services instances, ELBs and target groups unhealthy
+func neverHealthy(awsc *mocks.MockClients, release *models.Release) {
+	awsc.ASG.DescribeAutoScalingGroupsPageResp = nil
+
+	for name, service := range release.Services {
+		if service == nil {
+			continue
+		}
+
+		group := mocks.MakeMockASG("simulated", *release.ProjectName, *release.ConfigName, name, "simulated-previous-release")
+		group.Instances = mocks.MakeMockASGInstances(0, 1, 0)
+		awsc.ASG.AddASG(group)
+
+		for _, elb := range service.ELBs {
+			awsc.ELB.DescribeInstanceHealthResp[to.Strs(elb)] = &mocks.DescribeInstanceHealthResponse{}
+		}
+
+		for _, tg := range service.TargetGroups {
+			awsc.ALB.DescribeTargetHealthResp[to.Strs(tg)] = &mocks.DescribeTargetHealthResponse{}
+		}
+	}
+}
+
+// haltFirst halts the release before the first health check
+func haltFirst(awsc *mocks.MockClients, next DeployHandler) DeployHandler {
+	halted := false
+	return func(ctx context.Context, release *models.Release) (*models.Release, error) {
+		if !halted {
+			halted = true
+			if err := release.Halt(awsc.S3, to.Strp("Simulated halt")); err != nil {
+				return nil, err
+			}
+		}
+		return next(ctx, release)
+	}
+}
@@ -0,0 +1,46 @@
+package deployer
+
+import (
+	"testing"
+
+	"github.com/coinbase/odin/deployer/models"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func simulatedRelease(t *testing.T) *models.Release {
+	release := models.MockRelease(t)
+	release.Release.SetDefaults(to.Strp("region"), to.Strp("account"), "")
+	release.SetUserData(to.Strp("#cloud_config"))
+	release.Image = to.Strp("my-ami")
+	release.Subnets = []*string{to.Strp("subnet-a"), to.Strp("subnet-b")}
+	return release
+}
+
+func Test_Simulate_Success(t *testing.T) {
+	sim, err := Simulate(simulatedRelease(t), "")
+	assert.NoError(t, err)
+	assert.NoError(t, sim.Error)
+	assert.Equal(t, "Success", sim.Path[len(sim.Path)-1])
+}
+
+func Test_Simulate_UnknownFailure(t *testing.T) {
+	_, err := Simulate(simulatedRelease(t), "meteor")
+	assert.Error(t, err)
+}
+
+func Test_Simulate_Failures(t *testing.T) {
+	expected := map[string][]string{
+		FailUserDataSHA:  []string{"Validate", "FailureClean"},
+		FailASGCreate:    []string{"Deploy", "CleanUpFailure", "ReleaseLockFailure", "FailureClean"},
+		FailHalt:         []string{"CheckHealthy", "CleanUpFailure", "ReleaseLockFailure", "FailureClean"},
+		FailNeverHealthy: []string{"CheckHealthy", "CleanUpFailure", "ReleaseLockFailure", "FailureClean"},
+	}
+
+	for failure, tail := range expected {
+		sim, err := Simulate(simulatedRelease(t), failure)
+		assert.NoError(t, err)
+		assert.Error(t, sim.Error, failure)
+		assert.Equal(t, tail, sim.Path[len(sim.Path)-len(tail):], failure)
+	}
+}
@@ -0,0 +1,162 @@
+package stack
+
+import (
+	"bytes"
+	"encoding/json"
+	"text/template"
+)
+
+// CDK placeholders replaced in the construct with tokens of the stack it is added to
+const (
+	cdkRegion  = "__ODIN_REGION__"
+	cdkAccount = "__ODIN_ACCOUNT__"
+	cdkLambda  = "__ODIN_LAMBDA__"
+)
+
+var cdkTemplate = template.Must(template.New("cdk").Parse(`// Generated by odin stack, do not edit
+import * as cdk from 'aws-cdk-lib';
+import * as dynamodb from 'aws-cdk-lib/aws-dynamodb';
+import * as iam from 'aws-cdk-lib/aws-iam';
+import * as lambda from 'aws-cdk-lib/aws-lambda';
+import * as s3 from 'aws-cdk-lib/aws-s3';
+import * as sfn from 'aws-cdk-lib/aws-stepfunctions';
+import { Construct } from 'constructs';
+
+export interface OdinDeployerProps {
+  // Path of the lambda.zip built by scripts/build_lambda_zip
+  readonly lambdaZip: string;
+}
+
+// withTokens replaces the placeholders of the generated JSON with the stacks tokens
+function withTokens(raw: string, lambdaName: string): string {
+  return raw
+    .split('{{.Region}}').join(cdk.Aws.REGION)
+    .split('{{.Account}}').join(cdk.Aws.ACCOUNT_ID)
+    .split('{{.Lambda}}').join(lambdaName);
+}
+
+// OdinDeployer is the Odin Lambda, state machine, their roles, the releases bucket and lock table
+export class OdinDeployer extends Construct {
+  public readonly stateMachine: sfn.StateMachine;
+  public readonly bucket: s3.Bucket;
+  public readonly lockTable: dynamodb.Table;
+
+  constructor(scope: Construct, id: string, props: OdinDeployerProps) {
+    super(scope, id);
+
+    const tags: { [key: string]: string } = {{.Tags}};
+    Object.entries(tags).forEach(([k, v]) => cdk.Tags.of(this).add(k, v));
+
+    this.bucket = new s3.Bucket(this, 'Releases', {
+      bucketName: withTokens({{.Bucket}}, ''),
+      encryption: s3.BucketEncryption.S3_MANAGED,
+      blockPublicAccess: s3.BlockPublicAccess.BLOCK_ALL,
+      versioned: true,
+      lifecycleRules: [{ noncurrentVersionExpiration: cdk.Duration.days(30) }],
+    });
+
+    this.lockTable = new dynamodb.Table(this, 'Locks', {
+      tableName: {{.LockTable}},
+      partitionKey: { name: 'lock_id', type: dynamodb.AttributeType.STRING },
+      billingMode: dynamodb.BillingMode.PAY_PER_REQUEST,
+      timeToLiveAttribute: 'expires_at',
+    });
+
+    const lambdaRole = new iam.Role(this, 'LambdaRole', {
+      roleName: {{.LambdaRoleName}},
+      assumedBy: new iam.ServicePrincipal('lambda.amazonaws.com'),
+      managedPolicies: [iam.ManagedPolicy.fromAwsManagedPolicyName('service-role/AWSLambdaBasicExecutionRole')],
+      inlinePolicies: {
+        odin: iam.PolicyDocument.fromJson(JSON.parse(withTokens({{.LambdaPolicy}}, ''))),
+      },
+    });
+
+    const fn = new lambda.Function(this, 'Lambda', {
+      functionName: {{.Name}},
+      role: lambdaRole,
+      handler: 'lambda',
+      runtime: lambda.Runtime.GO_1_X,
+      timeout: cdk.Duration.seconds(300),
+      code: lambda.Code.fromAsset(props.lambdaZip),
+      environment: { ODIN_LOCK_TABLE: this.lockTable.tableName },
+    });
+
+    this.stateMachine = new sfn.StateMachine(this, 'StateMachine', {
+      stateMachineName: {{.Name}},
+      definitionBody: sfn.DefinitionBody.fromString(withTokens({{.Definition}}, fn.functionName)),
+    });
+    fn.grantInvoke(this.stateMachine);
+  }
+}
+
+export interface OdinAssumedRoleProps {
+  // Account the deployer Lambda runs in
+  readonly deployerAccountId: string;
+}
+
+// OdinAssumedRole is the role the deployer assumes into each account it deploys to
+export class OdinAssumedRole extends Construct {
+  constructor(scope: Construct, id: string, props: OdinAssumedRoleProps) {
+    super(scope, id);
+
+    new iam.Role(this, 'Role', {
+      roleName: {{.AssumedRoleName}},
+      assumedBy: new iam.AccountPrincipal(props.deployerAccountId),
+      inlinePolicies: {
+        odin: iam.PolicyDocument.fromJson({{.AssumedPolicy}}),
+      },
+    });
+  }
+}
+`))
+
+// CDK returns a TypeScript construct of the deployer and its assumed role
+// The JSON it contains is generated from the same definitions the deployer uses
+func (s *Stack) CDK() (map[string][]byte, error) {
+	def, err := definition(cdkRegion, cdkAccount, cdkLambda)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket := s.BucketPrefix + cdkAccount
+
+	// The lambda policy has placeholders so is parsed from a string after they are replaced
+	lambdaPolicy, err := json.Marshal(s.LambdaPolicy(bucket))
+	if err != nil {
+		return nil, err
+	}
+
+	// Each value is a TypeScript literal
+	values := map[string]interface{}{
+		"Name":            s.Name,
+		"LambdaRoleName":  s.Name + "-lambda",
+		"AssumedRoleName": s.AssumedRoleName,
+		"Bucket":          bucket,
+		"LockTable":       s.LockTable(),
+		"Tags":            s.Tags(),
+		"Definition":      def,
+		"LambdaPolicy":    string(lambdaPolicy),
+		"AssumedPolicy":   s.AssumedPolicy(),
+	}
+
+	literals := map[string]string{
+		"Region":  cdkRegion,
+		"Account": cdkAccount,
+		"Lambda":  cdkLambda,
+	}
+
+	for k, v := range values {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		literals[k] = string(raw)
+	}
+
+	var out bytes.Buffer
+	if err := cdkTemplate.Execute(&out, literals); err != nil {
+		return nil, err
+	}
+
+	return map[string][]byte{"odin-deployer.ts": out.Bytes()}, nil
+}
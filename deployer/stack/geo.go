@@ -0,0 +1,49 @@
+package stack
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// GeoEngineer template variables the bootstrap in resources/ fills in
+const (
+	geoAssumedRoleName = "<%= assumed_role_name %>"
+	geoBucket          = "<%= s3_bucket_name %>"
+)
+
+// Geo returns the policy templates of the GeoEngineer bootstrap in resources/, by their file name
+// They are generated from the same policies as the other formats, so the bootstrapped deployer has the same permissions
+func (s *Stack) Geo() (map[string][]byte, error) {
+	geo := *s
+	geo.AssumedRoleName = geoAssumedRoleName
+
+	lambdaPolicy, err := geoPolicy(geo.LambdaPolicy(geoBucket))
+	if err != nil {
+		return nil, err
+	}
+
+	assumedPolicy, err := geoPolicy(geo.AssumedPolicy())
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string][]byte{
+		"odin_lambda_policy.json.erb":  lambdaPolicy,
+		"odin_assumed_policy.json.erb": assumedPolicy,
+	}, nil
+}
+
+// geoPolicy returns the indented policy without escaping the template tags
+func geoPolicy(policy *PolicyDocument) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(policy); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,178 @@
+package stack
+
+// PolicyDocument is an IAM policy
+type PolicyDocument struct {
+	Version   string       `json:"Version"`
+	Statement []*Statement `json:"Statement"`
+}
+
+// Statement is a statement of an IAM policy
+type Statement struct {
+	Effect      string                            `json:"Effect"`
+	Principal   map[string][]string               `json:"Principal,omitempty"`
+	Action      []string                          `json:"Action,omitempty"`
+	Resource    []string                          `json:"Resource,omitempty"`
+	NotResource []string                          `json:"NotResource,omitempty"`
+	Condition   map[string]map[string]interface{} `json:"Condition,omitempty"`
+}
+
+func newPolicy(statements ...*Statement) *PolicyDocument {
+	return &PolicyDocument{Version: "2012-10-17", Statement: statements}
+}
+
+// trustPolicy lets the principals assume the role
+func trustPolicy(principalType string, principals ...string) *PolicyDocument {
+	return newPolicy(&Statement{
+		Effect:    "Allow",
+		Principal: map[string][]string{principalType: principals},
+		Action:    []string{"sts:AssumeRole"},
+	})
+}
+
+// LambdaPolicy is the policy of the Lambda, it can only use the releases bucket and lock table, and assume the assumed role
+func (s *Stack) LambdaPolicy(bucket string) *PolicyDocument {
+	buckets := []string{
+		"arn:aws:s3:::" + bucket + "/*",
+		"arn:aws:s3:::" + bucket,
+	}
+
+	return newPolicy(
+		&Statement{
+			Effect:   "Allow",
+			Action:   []string{"sts:AssumeRole"},
+			Resource: []string{"arn:aws:iam::*:role/" + s.AssumedRoleName},
+		},
+		&Statement{
+			Effect:   "Allow",
+			Action:   []string{"s3:GetObject*", "s3:PutObject*", "s3:DeleteObject*", "s3:ListBucket"},
+			Resource: buckets,
+		},
+		&Statement{
+			Effect:   "Allow",
+			Action:   []string{"dynamodb:GetItem", "dynamodb:PutItem", "dynamodb:UpdateItem", "dynamodb:DeleteItem"},
+			Resource: []string{"arn:aws:dynamodb:*:*:table/" + s.LockTable()},
+		},
+		&Statement{
+			Effect:   "Allow",
+			Action:   []string{"ssm:GetParameter", "secretsmanager:GetSecretValue"},
+			Resource: []string{"arn:aws:ssm:*:*:parameter/odin/*", "arn:aws:secretsmanager:*:*:secret:odin/*"},
+		},
+		&Statement{
+			Effect:   "Allow",
+			Action:   []string{"kms:Sign", "kms:Verify"},
+			Resource: []string{"arn:aws:kms:*:*:key/*"},
+		},
+		&Statement{
+			Effect:   "Allow",
+			Action:   []string{"iam:ListRoleTags"},
+			Resource: []string{"arn:aws:iam::*:role/*"},
+		},
+		&Statement{
+			Effect:   "Allow",
+			Action:   []string{"sns:Publish"},
+			Resource: []string{"arn:aws:sns:*:*:odin-*"},
+		},
+		&Statement{
+			Effect:   "Allow",
+			Action:   []string{"lambda:InvokeFunction"},
+			Resource: []string{"arn:aws:lambda:*:*:function:odin-plugin-*"},
+		},
+		&Statement{
+			Effect:    "Allow",
+			Action:    []string{"cloudwatch:PutMetricData"},
+			Resource:  []string{"*"},
+			Condition: map[string]map[string]interface{}{"StringEquals": {"cloudwatch:namespace": "Odin"}},
+		},
+		&Statement{
+			Effect:      "Deny",
+			Action:      []string{"s3:*"},
+			NotResource: buckets,
+		},
+	)
+}
+
+// AssumedPolicy is the policy of the role assumed into each account
+func (s *Stack) AssumedPolicy() *PolicyDocument {
+	return newPolicy(
+		&Statement{
+			Effect: "Allow",
+			Action: []string{
+				"iam:GetRole",
+				"iam:PassRole",
+				"iam:GetInstanceProfile",
+				"iam:SimulatePrincipalPolicy",
+				"ec2:DescribeImages",
+				"ec2:RunInstances",
+				"ec2:DescribeSubnets",
+				"ec2:DescribeSecurityGroups",
+				"ec2:DescribeInstances",
+				"ec2:DescribeInstanceStatus",
+				"ec2:DescribeInstanceTypes",
+				"ec2:GetConsoleOutput",
+				"ec2:CreateCapacityReservation",
+				"ec2:CancelCapacityReservation",
+				"ec2:DescribeCapacityReservations",
+				"ec2:CreatePlacementGroup",
+				"ec2:DeletePlacementGroup",
+				"ec2:DescribePlacementGroups",
+				"ec2:CreateLaunchTemplate",
+				"ec2:DeleteLaunchTemplate",
+				"ec2:DeleteLaunchTemplateVersions",
+				"ec2:DescribeLaunchTemplates",
+				"ec2:DescribeLaunchTemplateVersions",
+				"ec2:CreateTags",
+				"elasticloadbalancing:DescribeLoadBalancerAttributes",
+				"elasticloadbalancing:DescribeLoadBalancers",
+				"elasticloadbalancing:DescribeTargetGroupAttributes",
+				"elasticloadbalancing:DescribeTags",
+				"elasticloadbalancing:DescribeTargetHealth",
+				"elasticloadbalancing:DescribeTargetGroups",
+				"elasticloadbalancing:DescribeLoadBalancerPolicies",
+				"elasticloadbalancing:DescribeLoadBalancerPolicyTypes",
+				"elasticloadbalancing:DescribeInstanceHealth",
+				"elasticloadbalancing:ModifyLoadBalancerAttributes",
+				"elasticloadbalancing:ModifyTargetGroupAttributes",
+				"elasticloadbalancing:DescribeRules",
+				"elasticloadbalancing:ModifyRule",
+				"cloudwatch:PutMetricAlarm",
+				"cloudwatch:DeleteAlarms",
+				"cloudwatch:DescribeAlarms",
+				"cloudwatch:GetMetricData",
+				"sns:GetTopicAttributes",
+				"ssm:SendCommand",
+				"ssm:GetCommandInvocation",
+				"servicequotas:GetServiceQuota",
+				"ecr:DescribeImages",
+				"autoscaling:*",
+			},
+			Resource:  []string{"*"},
+			Condition: map[string]map[string]interface{}{"Bool": {"aws:SecureTransport": "true"}},
+		},
+		&Statement{
+			Effect:    "Allow",
+			Action:    []string{"iam:CreateRole"},
+			Resource:  []string{"arn:aws:iam::*:role/odin/*"},
+			Condition: map[string]map[string]interface{}{"StringLike": {"iam:PermissionsBoundary": "arn:aws:iam::*:policy/odin-permissions-boundary"}},
+		},
+		&Statement{
+			Effect:   "Allow",
+			Action:   []string{"iam:PutRolePolicy", "iam:CreateInstanceProfile", "iam:AddRoleToInstanceProfile", "iam:RemoveRoleFromInstanceProfile"},
+			Resource: []string{"arn:aws:iam::*:role/odin/*", "arn:aws:iam::*:instance-profile/odin/*"},
+		},
+		&Statement{
+			Effect:   "Allow",
+			Action:   []string{"lambda:InvokeFunction"},
+			Resource: []string{"arn:aws:lambda:*:*:function:odin-*"},
+		},
+		&Statement{
+			Effect:   "Allow",
+			Action:   []string{"appmesh:DescribeRoute", "appmesh:UpdateRoute"},
+			Resource: []string{"arn:aws:appmesh:*:*:mesh/*/virtualRouter/*/route/*"},
+		},
+		&Statement{
+			Effect:   "Allow",
+			Action:   []string{"ssm:GetParameter", "ssm:PutParameter"},
+			Resource: []string{"arn:aws:ssm:*:*:parameter/mesh/*"},
+		},
+	)
+}
@@ -0,0 +1,86 @@
+package stack
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/coinbase/odin/deployer"
+)
+
+// Formats the stack can be generated in
+const (
+	FormatTerraform = "terraform"
+	FormatCDK       = "cdk"
+	FormatGeo       = "geo"
+)
+
+// Stack is the deployer installed into an account: its Lambda, state machine, their roles and the releases bucket,
+// with the role it assumes into each account it deploys to
+type Stack struct {
+	Name            string // Name of the Lambda and state machine
+	AssumedRoleName string // Role assumed into each account to deploy
+	BucketPrefix    string // The releases bucket is the prefix and the account ID
+	ProjectName     string
+	ConfigName      string
+}
+
+// Default returns the stack as the bootstrap script creates it
+func Default() *Stack {
+	return &Stack{
+		Name:            "coinbase-odin",
+		AssumedRoleName: "coinbase-odin-assumed",
+		BucketPrefix:    "coinbase-odin-",
+		ProjectName:     "coinbase/odin",
+		ConfigName:      "development",
+	}
+}
+
+// Tags are the tags of each resource, the deployer deploys itself with step
+func (s *Stack) Tags() map[string]string {
+	return map[string]string{
+		"ProjectName": s.ProjectName,
+		"ConfigName":  s.ConfigName,
+		"DeployWith":  "step-deployer",
+	}
+}
+
+// LockTable is the DynamoDB table the deployer keeps its locks in
+func (s *Stack) LockTable() string {
+	return s.Name + "-locks"
+}
+
+// Generate returns the files of the stack in the format by their path
+func (s *Stack) Generate(format string) (map[string][]byte, error) {
+	switch format {
+	case FormatTerraform:
+		return s.Terraform()
+	case FormatCDK:
+		return s.CDK()
+	case FormatGeo:
+		return s.Geo()
+	}
+
+	return nil, fmt.Errorf("Unknown format %q, must be %v, %v or %v", format, FormatTerraform, FormatCDK, FormatGeo)
+}
+
+// definition returns the state machine definition with its placeholders replaced
+func definition(region string, account string, lambdaName string) (string, error) {
+	sm, err := deployer.StateMachine()
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := json.Marshal(sm)
+	if err != nil {
+		return "", err
+	}
+
+	replacer := strings.NewReplacer(
+		"{{aws_region}}", region,
+		"{{aws_account}}", account,
+		"{{lambda_name}}", lambdaName,
+	)
+
+	return replacer.Replace(string(raw)), nil
+}
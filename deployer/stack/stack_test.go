@@ -0,0 +1,72 @@
+package stack
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Stack_Terraform(t *testing.T) {
+	files, err := Default().Generate(FormatTerraform)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(files))
+
+	var module struct {
+		Resource map[string]map[string]map[string]interface{} `json:"resource"`
+	}
+	assert.NoError(t, json.Unmarshal(files["odin/main.tf.json"], &module))
+
+	def := module.Resource["aws_sfn_state_machine"]["odin"]["definition"].(string)
+	assert.NotContains(t, def, "{{")
+	assert.Contains(t, def, "${aws_lambda_function.odin.function_name}")
+
+	// The Lambda can only use its own bucket
+	policy := module.Resource["aws_iam_role_policy"]["lambda"]["policy"].(string)
+	assert.Contains(t, policy, "coinbase-odin-${data.aws_caller_identity.current.account_id}")
+
+	// The bucket passes the clients preflight
+	assert.Equal(t, true, module.Resource["aws_s3_bucket_public_access_block"]["releases"]["restrict_public_buckets"])
+	assert.NotNil(t, module.Resource["aws_s3_bucket_server_side_encryption_configuration"]["releases"])
+
+	// The deployer keeps its locks in the table
+	assert.Equal(t, "coinbase-odin-locks", module.Resource["aws_dynamodb_table"]["locks"]["name"])
+	assert.Contains(t, policy, "table/coinbase-odin-locks")
+	assert.Contains(t, string(files["odin/main.tf.json"]), `"ODIN_LOCK_TABLE": "${aws_dynamodb_table.locks.name}"`)
+
+	assert.NoError(t, json.Unmarshal(files["assumed/main.tf.json"], &module))
+	assert.Equal(t, "coinbase-odin-assumed", module.Resource["aws_iam_role"]["assumed"]["name"])
+}
+
+func Test_Stack_CDK(t *testing.T) {
+	files, err := Default().Generate(FormatCDK)
+	assert.NoError(t, err)
+
+	construct := string(files["odin-deployer.ts"])
+	assert.Contains(t, construct, "export class OdinDeployer")
+	assert.Contains(t, construct, "export class OdinAssumedRole")
+	assert.Contains(t, construct, "s3.BlockPublicAccess.BLOCK_ALL")
+	assert.Contains(t, construct, `tableName: "coinbase-odin-locks"`)
+	assert.False(t, strings.Contains(construct, "{{"))
+}
+
+func Test_Stack_Geo_Matches_Resources(t *testing.T) {
+	files, err := Default().Generate(FormatGeo)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(files))
+
+	// The bootstrap policies are generated, so they cannot drift from the deployers
+	for name, generated := range files {
+		committed, err := ioutil.ReadFile(filepath.Join("..", "..", "resources", name))
+		assert.NoError(t, err)
+		assert.Equal(t, string(generated), string(committed), "regenerate resources/%v with odin stack --format geo resources", name)
+	}
+}
+
+func Test_Stack_Generate_UnknownFormat(t *testing.T) {
+	_, err := Default().Generate("pulumi")
+	assert.Error(t, err)
+}
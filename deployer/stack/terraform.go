@@ -0,0 +1,234 @@
+package stack
+
+import (
+	"encoding/json"
+)
+
+// Terraform interpolations of the account the module is applied in
+const (
+	tfRegion  = "${data.aws_region.current.name}"
+	tfAccount = "${data.aws_caller_identity.current.account_id}"
+)
+
+type tfBlocks map[string]map[string]interface{}
+
+// Terraform returns a module of the deployer, and a module of the assumed role to apply in each account it deploys to
+// They are in Terraform's JSON syntax so are generated from the same definitions the deployer uses
+func (s *Stack) Terraform() (map[string][]byte, error) {
+	deployerModule, err := s.terraformDeployer()
+	if err != nil {
+		return nil, err
+	}
+
+	assumedModule, err := s.terraformAssumed()
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string][]byte{
+		"odin/main.tf.json":    deployerModule,
+		"assumed/main.tf.json": assumedModule,
+	}, nil
+}
+
+func (s *Stack) terraformDeployer() ([]byte, error) {
+	bucket := s.BucketPrefix + tfAccount
+
+	def, err := definition(tfRegion, tfAccount, "${aws_lambda_function.odin.function_name}")
+	if err != nil {
+		return nil, err
+	}
+
+	lambdaPolicy, err := json.Marshal(s.LambdaPolicy(bucket))
+	if err != nil {
+		return nil, err
+	}
+
+	lambdaTrust, err := json.Marshal(trustPolicy("Service", "lambda.amazonaws.com"))
+	if err != nil {
+		return nil, err
+	}
+
+	statesTrust, err := json.Marshal(trustPolicy("Service", "states.amazonaws.com"))
+	if err != nil {
+		return nil, err
+	}
+
+	statesPolicy, err := json.Marshal(newPolicy(&Statement{
+		Effect:   "Allow",
+		Action:   []string{"lambda:InvokeFunction"},
+		Resource: []string{"${aws_lambda_function.odin.arn}"},
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	module := map[string]interface{}{
+		"variable": tfBlocks{
+			"lambda_zip": {
+				"type":        "string",
+				"description": "Path of the lambda.zip built by scripts/build_lambda_zip",
+			},
+		},
+		"data": tfBlocks{
+			"aws_region":          {"current": map[string]interface{}{}},
+			"aws_caller_identity": {"current": map[string]interface{}{}},
+		},
+		"resource": tfBlocks{
+			"aws_s3_bucket": {
+				"releases": map[string]interface{}{
+					"bucket": bucket,
+					"tags":   s.Tags(),
+				},
+			},
+			// The client preflights the bucket, which must be encrypted and block public access
+			"aws_s3_bucket_server_side_encryption_configuration": {
+				"releases": map[string]interface{}{
+					"bucket": "${aws_s3_bucket.releases.id}",
+					"rule": []map[string]interface{}{
+						{"apply_server_side_encryption_by_default": []map[string]interface{}{{"sse_algorithm": "AES256"}}},
+					},
+				},
+			},
+			"aws_s3_bucket_public_access_block": {
+				"releases": map[string]interface{}{
+					"bucket":                  "${aws_s3_bucket.releases.id}",
+					"block_public_acls":       true,
+					"ignore_public_acls":      true,
+					"block_public_policy":     true,
+					"restrict_public_buckets": true,
+				},
+			},
+			// Overwritten records can be recovered for 30 days
+			"aws_s3_bucket_versioning": {
+				"releases": map[string]interface{}{
+					"bucket":                   "${aws_s3_bucket.releases.id}",
+					"versioning_configuration": []map[string]interface{}{{"status": "Enabled"}},
+				},
+			},
+			"aws_s3_bucket_lifecycle_configuration": {
+				"releases": map[string]interface{}{
+					"bucket": "${aws_s3_bucket.releases.id}",
+					"rule": []map[string]interface{}{
+						{
+							"id":                            "noncurrent",
+							"status":                        "Enabled",
+							"filter":                        []map[string]interface{}{{"prefix": ""}},
+							"noncurrent_version_expiration": []map[string]interface{}{{"noncurrent_days": 30}},
+						},
+					},
+				},
+			},
+			// Locks are conditional writes, released locks are deleted by TTL once they expire
+			"aws_dynamodb_table": {
+				"locks": map[string]interface{}{
+					"name":         s.LockTable(),
+					"billing_mode": "PAY_PER_REQUEST",
+					"hash_key":     "lock_id",
+					"attribute":    []map[string]interface{}{{"name": "lock_id", "type": "S"}},
+					"ttl":          []map[string]interface{}{{"attribute_name": "expires_at", "enabled": true}},
+					"tags":         s.Tags(),
+				},
+			},
+			"aws_iam_role": {
+				"lambda": map[string]interface{}{
+					"name":               s.Name + "-lambda",
+					"assume_role_policy": string(lambdaTrust),
+					"tags":               s.Tags(),
+				},
+				"states": map[string]interface{}{
+					"name":               s.Name + "-states",
+					"assume_role_policy": string(statesTrust),
+					"tags":               s.Tags(),
+				},
+			},
+			"aws_iam_role_policy": {
+				"lambda": map[string]interface{}{
+					"name":   s.Name,
+					"role":   "${aws_iam_role.lambda.id}",
+					"policy": string(lambdaPolicy),
+				},
+				"states": map[string]interface{}{
+					"name":   s.Name,
+					"role":   "${aws_iam_role.states.id}",
+					"policy": string(statesPolicy),
+				},
+			},
+			"aws_iam_role_policy_attachment": {
+				"lambda_logs": map[string]interface{}{
+					"role":       "${aws_iam_role.lambda.name}",
+					"policy_arn": "arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole",
+				},
+			},
+			"aws_lambda_function": {
+				"odin": map[string]interface{}{
+					"function_name":    s.Name,
+					"role":             "${aws_iam_role.lambda.arn}",
+					"handler":          "lambda",
+					"runtime":          "go1.x",
+					"timeout":          300,
+					"filename":         "${var.lambda_zip}",
+					"source_code_hash": "${filebase64sha256(var.lambda_zip)}",
+					"environment": []map[string]interface{}{
+						{"variables": map[string]string{"ODIN_LOCK_TABLE": "${aws_dynamodb_table.locks.name}"}},
+					},
+					"tags": s.Tags(),
+				},
+			},
+			"aws_sfn_state_machine": {
+				"odin": map[string]interface{}{
+					"name":       s.Name,
+					"role_arn":   "${aws_iam_role.states.arn}",
+					"definition": def,
+					"tags":       s.Tags(),
+				},
+			},
+		},
+		"output": tfBlocks{
+			"state_machine_arn": {"value": "${aws_sfn_state_machine.odin.arn}"},
+			"bucket":            {"value": "${aws_s3_bucket.releases.bucket}"},
+			"lock_table":        {"value": "${aws_dynamodb_table.locks.name}"},
+		},
+	}
+
+	return json.MarshalIndent(module, "", "  ")
+}
+
+func (s *Stack) terraformAssumed() ([]byte, error) {
+	assumedPolicy, err := json.Marshal(s.AssumedPolicy())
+	if err != nil {
+		return nil, err
+	}
+
+	assumedTrust, err := json.Marshal(trustPolicy("AWS", "arn:aws:iam::${var.deployer_account_id}:root"))
+	if err != nil {
+		return nil, err
+	}
+
+	module := map[string]interface{}{
+		"variable": tfBlocks{
+			"deployer_account_id": {
+				"type":        "string",
+				"description": "Account the deployer Lambda runs in",
+			},
+		},
+		"resource": tfBlocks{
+			"aws_iam_role": {
+				"assumed": map[string]interface{}{
+					"name":               s.AssumedRoleName,
+					"assume_role_policy": string(assumedTrust),
+					"tags":               s.Tags(),
+				},
+			},
+			"aws_iam_role_policy": {
+				"assumed": map[string]interface{}{
+					"name":   s.AssumedRoleName,
+					"role":   "${aws_iam_role.assumed.id}",
+					"policy": string(assumedPolicy),
+				},
+			},
+		},
+	}
+
+	return json.MarshalIndent(module, "", "  ")
+}
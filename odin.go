@@ -1,68 +1,315 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/coinbase/odin/client"
 	"github.com/coinbase/odin/deployer"
-	"github.com/coinbase/step/utils/is"
 	"github.com/coinbase/step/utils/run"
-	"github.com/coinbase/step/utils/to"
 )
 
 func main() {
-	var arg, command string
-	switch len(os.Args) {
-	case 1:
+	if len(os.Args) == 1 {
 		fmt.Println("Starting Lambda")
 		run.LambdaTasks(deployer.TaskHandlers())
-	case 2:
-		command = os.Args[1]
-		arg = ""
-	case 3:
-		command = os.Args[1]
-		arg = os.Args[2]
-	default:
-		printUsage() // Print how to use and exit
+		return
+	}
+
+	output := flag.String("output", "text", "output format, text or json")
+	flag.Usage = printUsage
+	flag.Parse()
+
+	if err := client.SetOutput(*output); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	if flag.NArg() == 0 {
+		printUsage()
 	}
 
-	stepFn := to.Strp(os.Getenv("ODIN_STEP"))
+	command, args := flag.Arg(0), flag.Args()[1:]
 
-	if is.EmptyStr(stepFn) {
-		stepFn = to.Strp("coinbase-odin")
+	// ODIN_STEP overrides the deployer of the current context
+	var stepFn *string
+	if s := os.Getenv("ODIN_STEP"); s != "" {
+		stepFn = &s
 	}
 
+	start := time.Now()
+
+	var err error
 	switch command {
 	case "json":
 		run.JSON(deployer.StateMachine())
+	case "stack":
+		// Generate the deployer stack for infrastructure as code
+		flags := flag.NewFlagSet("stack", flag.ExitOnError)
+		format := flags.String("format", "terraform", "terraform, cdk or geo")
+		flags.Parse(args)
+
+		err = client.Stack(format, arg(flags.Args(), 0))
 	case "deploy":
 		// Send Configuration to the deployer
 		// arg is a filename
-		err := client.Deploy(stepFn, &arg)
-		if err != nil {
-			fmt.Println(err.Error())
-			os.Exit(1)
+		flags := flag.NewFlagSet("deploy", flag.ExitOnError)
+		var files releaseFiles
+		flags.Var(&files, "f", "release file, merged over the previous files, can be repeated")
+		dryRun := flags.Bool("dry-run", false, "validate the release and print a plan without deploying")
+		waitForLock := flags.Duration("wait-for-lock", 0, "wait up to this long for another release to release the lock, e.g. 30m")
+		confirmCost := flags.Bool("confirm-cost", false, "deploy even if the release increases the estimated cost over the contexts cost_threshold")
+		yes := flags.Bool("yes", false, "do not ask for confirmation in a terminal")
+		watch := flags.Bool("watch", false, "show the deploys progress as a live view refreshed in place")
+		flags.Parse(args)
+
+		client.SetWatch(*watch)
+
+		if *dryRun {
+			err = client.Plan(stepFn, files.with(flags.Args()))
+		} else {
+			err = client.Deploy(stepFn, files.with(flags.Args()), *waitForLock, *confirmCost, *yes)
+		}
+	case "validate":
+		// Check a JSON or YAML release file before anything touches AWS
+		flags := flag.NewFlagSet("validate", flag.ExitOnError)
+		var files releaseFiles
+		flags.Var(&files, "f", "release file, merged over the previous files, can be repeated")
+		schema := flags.Bool("schema", false, "print the JSON Schema of release files")
+		flags.Parse(args)
+
+		if *schema {
+			err = client.PrintSchema()
+		} else {
+			err = client.ValidateFile(files.with(flags.Args()))
 		}
+	case "push":
+		// Upload the release without deploying it, to be executed later
+		flags := flag.NewFlagSet("push", flag.ExitOnError)
+		var files releaseFiles
+		flags.Var(&files, "f", "release file, merged over the previous files, can be repeated")
+		flags.Parse(args)
+
+		err = client.Push(stepFn, files.with(flags.Args()))
+	case "execute":
+		// Deploy a pushed release by its registration ID
+		flags := flag.NewFlagSet("execute", flag.ExitOnError)
+		waitForLock := flags.Duration("wait-for-lock", 0, "wait up to this long for another release to release the lock, e.g. 30m")
+		flags.Parse(args)
+
+		err = client.Execute(stepFn, arg(flags.Args(), 0), *waitForLock)
+	case "simulate":
+		// Run the release through the state machine against mocked AWS
+		flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+		fail := flags.String("fail", "", "inject a failure: asg_create, never_healthy, halt or userdata_sha")
+		flags.Parse(args)
+
+		err = client.Simulate(arg(flags.Args(), 0), *fail)
+	case "canary":
+		// Deploy a small release to check the deployer works, every interval if set
+		flags := flag.NewFlagSet("canary", flag.ExitOnError)
+		interval := flags.Duration("interval", 0, "keep deploying the release with this interval, e.g. 1h")
+		flags.Parse(args)
+
+		err = client.Canary(stepFn, arg(flags.Args(), 0), *interval)
 	case "fails":
 		// List the recent failures and their causes
-		err := client.Failures(stepFn)
-		if err != nil {
-			fmt.Println(err.Error())
-			os.Exit(1)
-		}
+		err = client.Failures(stepFn)
 	case "halt":
-		err := client.Halt(stepFn, &arg)
-		if err != nil {
-			fmt.Println(err.Error())
-			os.Exit(1)
+		flags := flag.NewFlagSet("halt", flag.ExitOnError)
+		service := flags.String("service", "", "halt only this service, the others continue to deploy")
+		flags.Parse(args)
+
+		err = client.Halt(stepFn, arg(flags.Args(), 0), service)
+	case "reset-breaker":
+		err = client.ResetBreaker(stepFn, arg(args, 0))
+	case "pause":
+		// Hold the running deploy once its new ASGs are healthy
+		err = client.Pause(stepFn, arg(args, 0))
+	case "unpause":
+		// Continue a paused deploy
+		err = client.Unpause(stepFn, arg(args, 0))
+	case "resume":
+		// Restart a failed release from its last checkpoint
+		err = client.Resume(stepFn, arg(args, 0), arg(args, 1))
+	case "approve":
+		// Let a healthy release waiting at its approval gate cut over, or roll it back
+		flags := flag.NewFlagSet("approve", flag.ExitOnError)
+		reject := flags.Bool("reject", false, "reject the release so it is rolled back")
+		reason := flags.String("reason", "", "reason recorded with the approval")
+		flags.Parse(args)
+
+		err = client.Approve(stepFn, arg(flags.Args(), 0), arg(flags.Args(), 1), !*reject, *reason)
+	case "destroy":
+		// Decommission a project config, deleting all its ASGs
+		flags := flag.NewFlagSet("destroy", flag.ExitOnError)
+		yes := flags.Bool("yes", false, "do not ask for confirmation")
+		flags.Parse(args)
+
+		err = client.Destroy(stepFn, arg(flags.Args(), 0), arg(flags.Args(), 1), *yes)
+	case "unlock":
+		// Delete a lock left by a deployer that crashed, once its execution is checked to be dead
+		flags := flag.NewFlagSet("unlock", flag.ExitOnError)
+		force := flags.Bool("force", false, "delete the lock")
+		flags.Parse(args)
+
+		// --force can also follow the project and config names
+		names := flags.Args()
+		if len(names) > 2 {
+			flags.Parse(names[2:])
 		}
+
+		err = client.Unlock(stepFn, arg(names, 0), arg(names, 1), *force)
+	case "cleanup":
+		// Finish deleting the old ASGs of an interrupted cleanup, --plan only lists them
+		flags := flag.NewFlagSet("cleanup", flag.ExitOnError)
+		planOnly := flags.Bool("plan", false, "list the resources cleanup would delete without deleting them")
+		flags.Parse(args)
+
+		err = client.Cleanup(stepFn, arg(flags.Args(), 0), arg(flags.Args(), 1), arg(flags.Args(), 2), *planOnly)
+	case "gc":
+		// Delete resources left behind by failed releases, --dry-run only lists them
+		flags := flag.NewFlagSet("gc", flag.ExitOnError)
+		dryRun := flags.Bool("dry-run", false, "list the orphaned resources without deleting them")
+		minAge := flags.Duration("min-age", 7*24*time.Hour, "only collect resources older than this, e.g. 72h")
+		flags.Parse(args)
+
+		err = client.GC(stepFn, *minAge, *dryRun)
+	case "status":
+		err = client.Status(stepFn, arg(args, 0), arg(args, 1))
+	case "attach":
+		err = client.Attach(stepFn, arg(args, 0))
+	case "release-notes":
+		// Summarize what a release changed for change tickets
+		err = client.ReleaseNotes(stepFn, arg(args, 0), arg(args, 1), arg(args, 2))
+	case "ami":
+		// Find the latest AMI matching a filter, e.g. one built by an Image Builder pipeline
+		if *arg(args, 0) != "latest" {
+			printUsage()
+		}
+
+		err = client.AMILatest(stepFn, arg(args, 1))
+	case "export":
+		err = client.Export(arg(args, 0), arg(args, 1), arg(args, 2))
+	case "import":
+		err = client.Import(arg(args, 0))
+	case "failover":
+		err = client.Failover(arg(args, 0), arg(args, 1), arg(args, 2))
+	case "context":
+		err = contextCommand(args)
+	case "server":
+		// Serve deploy, halt and status over HTTP for deploy portals and bots
+		flags := flag.NewFlagSet("server", flag.ExitOnError)
+		addr := flags.String("addr", "127.0.0.1:8080", "address to listen on, only loopback without TLS")
+		tlsCert := flags.String("tls-cert", "", "TLS certificate file")
+		tlsKey := flags.String("tls-key", "", "TLS private key file")
+		flags.Parse(args)
+
+		err = client.Serve(stepFn, *addr, os.Getenv("ODIN_SERVER_TOKENS"), *tlsCert, *tlsKey)
+	case "completion":
+		// Print the completion script for a shell
+		err = client.Completion(arg(args, 0))
+	case "__complete":
+		// Called by the completion scripts with the words typed so far
+		err = client.Complete(args)
+	case "self-update":
+		flags := flag.NewFlagSet("self-update", flag.ExitOnError)
+		version := flags.String("version", os.Getenv("ODIN_VERSION"), "install this version instead of the latest")
+		flags.Parse(args)
+
+		err = client.SelfUpdate(version)
+	case "version":
+		fmt.Println(client.Version)
 	default:
 		printUsage() // Print how to use and exit
 	}
+
+	// Completion runs on every tab, so is not reported
+	if command != "__complete" {
+		client.ReportCommand(command, start, err)
+	}
+
+	if err != nil {
+		client.PrintError(err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// contextCommand lists contexts or with "use <name>" switches the current context
+func contextCommand(args []string) error {
+	switch {
+	case len(args) == 0:
+		return client.ListContexts()
+	case len(args) == 2 && args[0] == "use":
+		return client.UseContext(arg(args, 1))
+	default:
+		printUsage()
+		return nil
+	}
+}
+
+// releaseFiles are the -f flags, a base release file followed by its overlays
+type releaseFiles []string
+
+func (f *releaseFiles) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *releaseFiles) Set(file string) error {
+	*f = append(*f, file)
+	return nil
+}
+
+// with returns the files followed by any given as arguments
+func (f releaseFiles) with(args []string) []string {
+	return append(append([]string{}, f...), args...)
+}
+
+func arg(args []string, i int) *string {
+	if i >= len(args) {
+		return new(string)
+	}
+	return &args[i]
 }
 
 func printUsage() {
-	fmt.Println("Usage: odin <json|deploy|halt|fails> <release_file> (No args starts Lambda)")
+	fmt.Println("Usage: odin [--output text|json] <json|deploy|halt|fails> <release_file> (No args starts Lambda)")
+	fmt.Println("       odin status <project_name> <config_name>")
+	fmt.Println("       odin validate [--schema] [-f <release_file>]... <release_file>")
+	fmt.Println("       odin push [-f <release_file>]... <release_file>")
+	fmt.Println("       odin execute [--wait-for-lock <duration>] <registration_id>")
+	fmt.Println("       odin stack [--format terraform|cdk|geo] <dir>")
+	fmt.Println("       odin reset-breaker <release_file>")
+	fmt.Println("       odin halt --service <service_name> <release_file>")
+	fmt.Println("       odin pause <release_file>")
+	fmt.Println("       odin unpause <release_file>")
+	fmt.Println("       odin resume <release_file> <release_id>")
+	fmt.Println("       odin approve [--reject] [--reason <reason>] <release_file> <release_id>")
+	fmt.Println("       odin destroy [--yes] <project_name> <config_name>")
+	fmt.Println("       odin unlock <project_name> <config_name> [--force]")
+	fmt.Println("       odin cleanup [--plan] <project_name> <config_name> [<release_id>]")
+	fmt.Println("       odin gc [--dry-run] [--min-age <duration>]")
+	fmt.Println("       odin release-notes <project_name> <config_name> <release_id>")
+	fmt.Println("       odin attach <execution_arn>")
+	fmt.Println("       odin deploy --dry-run <release_file>")
+	fmt.Println("       odin deploy --wait-for-lock <duration> <release_file>")
+	fmt.Println("       odin deploy --confirm-cost <release_file>")
+	fmt.Println("       odin deploy --yes <release_file>")
+	fmt.Println("       odin deploy --watch <release_file>")
+	fmt.Println("       odin deploy -f <base_file> -f <overlay_file>")
+	fmt.Println("       odin simulate [--fail <failure>] <release_file>")
+	fmt.Println("       odin canary [--interval <duration>] <release_file>")
+	fmt.Println("       odin ami latest <filter>")
+	fmt.Println("       odin export <project_name> <config_name> <archive_file>")
+	fmt.Println("       odin import <archive_file>")
+	fmt.Println("       odin failover <project_name> <config_name> <failover_file>")
+	fmt.Println("       odin context [use <name>]")
+	fmt.Println("       odin server [--addr <host:port>] [--tls-cert <file> --tls-key <file>]")
+	fmt.Println("       odin completion bash|zsh|fish")
+	fmt.Println("       odin self-update [--version <version>]")
+	fmt.Println("       odin version")
 	os.Exit(0)
 }